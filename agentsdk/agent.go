@@ -0,0 +1,302 @@
+// Package agentsdk runs an agent on ZionLayer on top of the Go client: it
+// registers the agent's DID, watches its inbox, hands each TASK message to
+// a handler and sends the handler's answer back as a RESULT message,
+// together with an inference receipt proving it. Transactions are signed
+// with the controller key of the agent; transaction and message nonces are
+// tracked locally and failed submissions are retried.
+package agentsdk
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zionlayer/zionlayer/client"
+	"github.com/zionlayer/zionlayer/core/crypto"
+	"github.com/zionlayer/zionlayer/core/did"
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/rpc"
+)
+
+var (
+	ErrNoHandler     = errors.New("agentsdk: no task handler")
+	ErrNotController = errors.New("agentsdk: agent controlled by another address")
+	ErrProverKey     = errors.New("agentsdk: receipt has no prover signature and no prover key is configured")
+)
+
+// Handler answers the TASK message task. A nil Result sends no answer; an
+// error is logged and the task is not answered either. Tasks are handled
+// one at a time, in inbox order.
+type Handler func(ctx context.Context, task rpc.AgentMessageEntry) (*Result, error)
+
+// Result is the answer to a task.
+type Result struct {
+	// Payload, or PayloadRef for a payload kept on a data availability
+	// network, is sent back to the sender of the task in a RESULT message.
+	Payload    []byte
+	PayloadRef *transaction.DataRef
+	// Receipt, if set, is submitted with SubmitReceipt after the RESULT
+	// message.
+	Receipt *transaction.InferenceReceipt
+}
+
+// Config configures an Agent.
+type Config struct {
+	// DID is the identity registered by Register. Its ID defaults to the
+	// did:agc DID of the controller address, its Controller to that
+	// address and its PublicKey to the controller key.
+	DID transaction.AgentDID
+
+	// Handler answers the TASK messages received by Run.
+	Handler Handler
+	// OnMessage, if set, is called with the other messages received by Run,
+	// such as the RESULT messages answering tasks the agent sent.
+	OnMessage func(ctx context.Context, msg rpc.AgentMessageEntry)
+
+	// Prover is the address of the compute provider vouching for the
+	// receipts of the agent and ProverKey its receipt signing key. Receipts
+	// without a ProverSig are signed with it.
+	Prover    string
+	ProverKey crypto.PrivateKey
+
+	// Cursor is the position in the message log from which Run handles
+	// inbox messages, typically the Cursor of a previous run. If it is
+	// zero, only messages stored after Run starts are handled.
+	Cursor uint64
+	// PollInterval is how often Run polls the inbox besides polling on
+	// every message notification, which covers notifications missed while
+	// the subscription is down.
+	PollInterval time.Duration
+
+	// MaxRetries is how many times a failed submission is retried, waiting
+	// RetryInterval before the first retry and twice as long before each
+	// following one.
+	MaxRetries    int
+	RetryInterval time.Duration
+}
+
+// DefaultConfig returns the default polling and retry settings.
+func DefaultConfig() Config {
+	return Config{
+		PollInterval:  10 * time.Second,
+		MaxRetries:    5,
+		RetryInterval: time.Second,
+	}
+}
+
+// Agent is an agent run through a node. Its methods are safe for
+// concurrent use.
+type Agent struct {
+	client *client.Client
+	wallet *client.Wallet
+	config Config
+	logger *zap.Logger
+
+	cursor atomic.Uint64
+
+	mu        sync.Mutex // serializes message submissions
+	msgNonce  uint64
+	msgSynced bool // msgNonce is the next message nonce of the agent
+}
+
+// New returns the agent described by config, controlled by key and
+// talking to the node through c. Zero polling and retry settings take
+// their DefaultConfig values.
+func New(c *client.Client, key crypto.PrivateKey, config Config, logger *zap.Logger) (*Agent, error) {
+	w, err := client.NewWallet(c, key)
+	if err != nil {
+		return nil, err
+	}
+	def := DefaultConfig()
+	if config.PollInterval <= 0 {
+		config.PollInterval = def.PollInterval
+	}
+	if config.MaxRetries <= 0 {
+		config.MaxRetries = def.MaxRetries
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = def.RetryInterval
+	}
+	if config.DID.Controller == "" {
+		config.DID.Controller = w.Address()
+	}
+	if config.DID.ID == "" {
+		config.DID.ID = did.Prefix + config.DID.Controller
+	}
+	if len(config.DID.PublicKey) == 0 {
+		config.DID.PublicKey = key.Public().(crypto.PublicKey)
+	}
+	a := &Agent{client: c, wallet: w, config: config, logger: logger}
+	a.cursor.Store(config.Cursor)
+	return a, nil
+}
+
+// ID returns the DID of the agent.
+func (a *Agent) ID() string { return a.config.DID.ID }
+
+// Wallet returns the wallet signing the transactions of the agent, for
+// instance to set their gas price.
+func (a *Agent) Wallet() *client.Wallet { return a.wallet }
+
+// Cursor returns the position in the message log from which Run resumes
+// handling the inbox. Saving it lets a later run pick up where this one
+// stopped.
+func (a *Agent) Cursor() uint64 { return a.cursor.Load() }
+
+// Register registers the DID of the agent if it is not registered yet and
+// returns the hash of the registration transaction, or "" if the agent
+// already exists. It fails with ErrNotController if the DID is registered
+// to another controller.
+func (a *Agent) Register(ctx context.Context) (string, error) {
+	rec, err := a.client.GetAgent(ctx, a.ID())
+	switch {
+	case err == nil:
+		if !strings.EqualFold(rec.DID.Controller, a.wallet.Address()) {
+			return "", ErrNotController
+		}
+		return "", nil
+	case !client.IsNotFound(err):
+		return "", err
+	}
+	id := a.config.DID
+	return a.submit(ctx, "register", func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+		return transaction.NewAgentRegisterTx(from, id, nonce, gasPrice), nil
+	})
+}
+
+// Run registers the agent and handles its inbox until ctx is done. It
+// subscribes to the messages sent to the agent and polls the inbox on
+// every notification and every PollInterval, so messages stored while the
+// subscription is down are handled once it is restored.
+func (a *Agent) Run(ctx context.Context) error {
+	if a.config.Handler == nil {
+		return ErrNoHandler
+	}
+	if _, err := a.Register(ctx); err != nil {
+		return err
+	}
+	if a.cursor.Load() == 0 {
+		end, err := a.inboxEnd(ctx)
+		if err != nil {
+			return err
+		}
+		a.cursor.Store(end)
+	}
+
+	notify := make(chan rpc.AgentMessageResult, 16)
+	var sub *client.Subscription
+	var subErr <-chan error
+	defer func() {
+		if sub != nil {
+			sub.Unsubscribe()
+		}
+	}()
+	ticker := time.NewTicker(a.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		if sub == nil {
+			s, err := a.client.SubscribeAgentMessages(ctx, rpc.SubscriptionFilter{To: a.ID()}, notify)
+			if err != nil {
+				a.logger.Warn("agent message subscription failed", zap.String("agent", a.ID()), zap.Error(err))
+			} else {
+				sub, subErr = s, s.Err()
+			}
+		}
+		if err := a.poll(ctx); err != nil && ctx.Err() == nil {
+			a.logger.Warn("inbox poll failed", zap.String("agent", a.ID()), zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-notify:
+		case <-ticker.C:
+		case err := <-subErr:
+			a.logger.Warn("agent message subscription ended", zap.String("agent", a.ID()), zap.Error(err))
+			sub, subErr = nil, nil
+		}
+	}
+}
+
+// inboxEnd returns the position following the last message of the inbox
+// of the agent.
+func (a *Agent) inboxEnd(ctx context.Context) (uint64, error) {
+	var end uint64
+	page := rpc.PageArgs{Limit: rpc.MaxPageSize}
+	for {
+		res, err := a.client.GetAgentMessages(ctx, a.ID(), client.MessagesIn, page)
+		if err != nil {
+			return 0, err
+		}
+		if n := len(res.Items); n > 0 {
+			end = res.Items[n-1].Seq + 1
+		}
+		if res.Next == "" {
+			return end, nil
+		}
+		page.Cursor = res.Next
+	}
+}
+
+// poll handles the inbox messages from the cursor on, advancing it past
+// each message handled.
+func (a *Agent) poll(ctx context.Context) error {
+	for {
+		page := rpc.PageArgs{Cursor: strconv.FormatUint(a.cursor.Load(), 10), Limit: rpc.MaxPageSize}
+		res, err := a.client.GetAgentMessages(ctx, a.ID(), client.MessagesIn, page)
+		if err != nil {
+			return err
+		}
+		for _, msg := range res.Items {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			a.dispatch(ctx, msg)
+			a.cursor.Store(msg.Seq + 1)
+		}
+		if res.Next == "" {
+			return nil
+		}
+	}
+}
+
+// dispatch hands msg to the handler of its type and sends the answer to a
+// task.
+func (a *Agent) dispatch(ctx context.Context, msg rpc.AgentMessageEntry) {
+	if msg.Type != transaction.MsgTask {
+		if a.config.OnMessage != nil {
+			a.config.OnMessage(ctx, msg)
+		}
+		return
+	}
+	log := a.logger.With(zap.String("agent", a.ID()), zap.String("from", msg.From), zap.Uint64("seq", msg.Seq))
+	res, err := a.config.Handler(ctx, msg)
+	if err != nil {
+		log.Warn("task failed", zap.Error(err))
+		return
+	}
+	if res == nil {
+		return
+	}
+	reply := transaction.AgentMessage{To: msg.From, Type: transaction.MsgResult, Payload: res.Payload, PayloadRef: res.PayloadRef}
+	hash, err := a.SendMessage(ctx, reply)
+	if err != nil {
+		log.Error("sending task result failed", zap.Error(err))
+		return
+	}
+	log.Info("task answered", zap.String("tx", hash))
+	if res.Receipt != nil {
+		hash, err := a.SubmitReceipt(ctx, *res.Receipt)
+		if err != nil {
+			log.Error("submitting inference receipt failed", zap.Error(err))
+			return
+		}
+		log.Info("inference receipt submitted", zap.String("tx", hash))
+	}
+}
@@ -0,0 +1,121 @@
+package agentsdk
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/url"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zionlayer/zionlayer/client"
+	"github.com/zionlayer/zionlayer/core/crypto"
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/rpc"
+)
+
+// SendMessage sends msg from the agent with the next message nonce of the
+// agent and returns the hash of its transaction. From and Nonce are set by
+// the agent.
+//
+// The message nonce is read from the agent record once and then counted
+// locally, so that several messages can be sent before the first is
+// included. A message failing on-chain leaves the count ahead of the
+// record; ResetNonce reads it again.
+func (a *Agent) SendMessage(ctx context.Context, msg transaction.AgentMessage) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.msgSynced {
+		rec, err := a.client.GetAgent(ctx, a.ID())
+		switch {
+		case err == nil:
+			a.msgNonce = rec.MessageNonce
+		case client.IsNotFound(err):
+			a.msgNonce = 0
+		default:
+			return "", err
+		}
+		a.msgSynced = true
+	}
+	msg.From, msg.Nonce = a.ID(), a.msgNonce
+	hash, err := a.submit(ctx, "message", func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+		return transaction.NewAgentMessageTx(from, msg, nonce, gasPrice), nil
+	})
+	if err != nil {
+		return "", err
+	}
+	a.msgNonce++
+	return hash, nil
+}
+
+// SubmitReceipt submits r as an inference receipt of the agent and returns
+// the hash of its transaction. AgentID is set to the agent and a zero
+// Timestamp to the current time. A receipt without ProverSig is signed as
+// the configured Prover with ProverKey.
+func (a *Agent) SubmitReceipt(ctx context.Context, r transaction.InferenceReceipt) (string, error) {
+	r.AgentID = a.ID()
+	if r.Timestamp == 0 {
+		r.Timestamp = time.Now().Unix()
+	}
+	if len(r.ProverSig) == 0 {
+		if len(a.config.ProverKey) != crypto.PrivateKeySize {
+			return "", ErrProverKey
+		}
+		r.Prover = a.config.Prover
+		if err := r.Sign(a.config.ProverKey); err != nil {
+			return "", err
+		}
+	}
+	return a.submit(ctx, "receipt", func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+		return transaction.NewInferenceReceiptTx(from, r, nonce, gasPrice), nil
+	})
+}
+
+// ResetNonce makes the next submissions read the transaction nonce of the
+// controller and the message nonce of the agent from the node again, for
+// instance after a message failed on-chain.
+func (a *Agent) ResetNonce() {
+	a.mu.Lock()
+	a.msgSynced = false
+	a.mu.Unlock()
+	a.wallet.ResetNonce()
+}
+
+// submit sends the transaction built by build through the wallet, retrying
+// failures that may be temporary with exponential backoff. The wallet
+// reads the nonce of the controller again after each failure.
+func (a *Agent) submit(ctx context.Context, what string, build client.BuildFunc) (string, error) {
+	delay := a.config.RetryInterval
+	for attempt := 0; ; attempt++ {
+		hash, err := a.wallet.Send(ctx, build)
+		if err == nil || attempt == a.config.MaxRetries || !temporary(err) || ctx.Err() != nil {
+			return hash, err
+		}
+		a.logger.Warn("submission failed, retrying", zap.String("agent", a.ID()), zap.String("tx", what),
+			zap.Int("attempt", attempt+1), zap.Duration("delay", delay), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// temporary reports whether a submission failing with err may succeed
+// when retried: the node was unreachable, or it turned the transaction
+// away for a nonce that is out of date or for lack of room.
+func temporary(err error) bool {
+	var rpcErr *client.Error
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.Code {
+		case rpc.CodeNonceTooLow, rpc.CodeNonceTooHigh, rpc.CodePoolFull, rpc.CodeSenderLimit,
+			rpc.CodeLimitExceeded, rpc.CodeServerError, rpc.CodeInternalError:
+			return true
+		}
+		return false
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
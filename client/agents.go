@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+
+	"github.com/zionlayer/zionlayer/core/did"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/rpc"
+)
+
+// GetAgent returns the agent registered under the DID id.
+func (c *Client) GetAgent(ctx context.Context, id string) (*state.AgentRecord, error) {
+	var rec state.AgentRecord
+	if err := c.Call(ctx, &rec, "zion_getAgent", id); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// GetAgentHistory returns the registration, deactivations and
+// reactivations of the agent id, oldest first.
+func (c *Client) GetAgentHistory(ctx context.Context, id string) ([]state.LifecycleEvent, error) {
+	var events []state.LifecycleEvent
+	if err := c.Call(ctx, &events, "zion_getAgentHistory", id); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// ResolveDID resolves id into its DID document. Resolution failures are
+// reported in the metadata of the result.
+func (c *Client) ResolveDID(ctx context.Context, id string) (*did.Result, error) {
+	var res did.Result
+	if err := c.Call(ctx, &res, "zion_resolveDID", id); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ListAgents lists the registered agents by DID.
+func (c *Client) ListAgents(ctx context.Context, page rpc.PageArgs) (*rpc.Page[state.AgentRecord], error) {
+	var res rpc.Page[state.AgentRecord]
+	if err := c.Call(ctx, &res, "zion_listAgents", page); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetAgentsByController lists the agents controlled by addr.
+func (c *Client) GetAgentsByController(ctx context.Context, addr string, page rpc.PageArgs) (*rpc.Page[state.AgentRecord], error) {
+	var res rpc.Page[state.AgentRecord]
+	if err := c.Call(ctx, &res, "zion_getAgentsByController", addr, page); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// SearchAgents lists the agents advertising capability.
+func (c *Client) SearchAgents(ctx context.Context, capability string, page rpc.PageArgs) (*rpc.Page[state.AgentRecord], error) {
+	var res rpc.Page[state.AgentRecord]
+	if err := c.Call(ctx, &res, "zion_searchAgents", rpc.SearchAgentsArgs{Capability: capability, PageArgs: page}); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetReputation returns the reputation of the agent id.
+func (c *Client) GetReputation(ctx context.Context, id string) (*state.Reputation, error) {
+	var rep state.Reputation
+	if err := c.Call(ctx, &rep, "zion_getReputation", id); err != nil {
+		return nil, err
+	}
+	return &rep, nil
+}
+
+// GetDelegations returns the capability delegations the agent id
+// received, expired ones included.
+func (c *Client) GetDelegations(ctx context.Context, id string) ([]state.DelegationRecord, error) {
+	var recs []state.DelegationRecord
+	if err := c.Call(ctx, &recs, "zion_getDelegations", id); err != nil {
+		return nil, err
+	}
+	return recs, nil
+}
+
+// VerifyDelegation checks whether the agent id holds capability at the
+// chain head.
+func (c *Client) VerifyDelegation(ctx context.Context, id string, capability transaction.Capability) (*rpc.DelegationCheck, error) {
+	var res rpc.DelegationCheck
+	if err := c.Call(ctx, &res, "zion_verifyDelegation", id, capability); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetAgentProof returns the entry of the agent state under key, or under
+// the agent key of a DID, with its proof against the AgentRoot of the next
+// block.
+func (c *Client) GetAgentProof(ctx context.Context, key string) (*rpc.AgentProofResult, error) {
+	var res rpc.AgentProofResult
+	if err := c.Call(ctx, &res, "zion_getAgentProof", key); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Directions of GetAgentMessages.
+const (
+	MessagesAll = "all"
+	MessagesIn  = "in"
+	MessagesOut = "out"
+)
+
+// GetAgentMessages lists the messages sent to or by the agent id, oldest
+// first, filtered by direction.
+func (c *Client) GetAgentMessages(ctx context.Context, id, direction string, page rpc.PageArgs) (*rpc.Page[rpc.AgentMessageEntry], error) {
+	var res rpc.Page[rpc.AgentMessageEntry]
+	if err := c.Call(ctx, &res, "zion_getAgentMessages", id, direction, page); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetOffer returns the offer of the agent id for capability, active or
+// withdrawn.
+func (c *Client) GetOffer(ctx context.Context, id, capability string) (*state.Offer, error) {
+	var o state.Offer
+	if err := c.Call(ctx, &o, "zion_getOffer", id, capability); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// GetAgentOffers returns the offers of the agent id.
+func (c *Client) GetAgentOffers(ctx context.Context, id string) ([]*state.Offer, error) {
+	var offers []*state.Offer
+	if err := c.Call(ctx, &offers, "zion_getAgentOffers", id); err != nil {
+		return nil, err
+	}
+	return offers, nil
+}
+
+// SearchOffers lists the active offers matching q, cheapest first.
+func (c *Client) SearchOffers(ctx context.Context, q state.OfferQuery, page rpc.PageArgs) (*rpc.Page[state.Offer], error) {
+	var res rpc.Page[state.Offer]
+	if err := c.Call(ctx, &res, "zion_searchOffers", rpc.SearchOffersArgs{OfferQuery: q, PageArgs: page}); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,223 @@
+package client
+
+import (
+	"context"
+
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/rpc"
+)
+
+// GetIBCClient returns the IBC light client id.
+func (c *Client) GetIBCClient(ctx context.Context, id string) (*state.IBCClient, error) {
+	var res state.IBCClient
+	if err := c.Call(ctx, &res, "zion_getIBCClient", id); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetIBCConnection returns the IBC connection id.
+func (c *Client) GetIBCConnection(ctx context.Context, id string) (*state.IBCConnection, error) {
+	var res state.IBCConnection
+	if err := c.Call(ctx, &res, "zion_getIBCConnection", id); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetIBCChannel returns the channel end id of port.
+func (c *Client) GetIBCChannel(ctx context.Context, port, id string) (*state.IBCChannel, error) {
+	var res state.IBCChannel
+	if err := c.Call(ctx, &res, "zion_getIBCChannels", port, id); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetIBCChannels returns every channel end.
+func (c *Client) GetIBCChannels(ctx context.Context) ([]*state.IBCChannel, error) {
+	var res []*state.IBCChannel
+	if err := c.Call(ctx, &res, "zion_getIBCChannels"); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetIBCPackets returns the packets sent over the channel end awaiting
+// acknowledgement and those received from sequence from on.
+func (c *Client) GetIBCPackets(ctx context.Context, port, channel string, from uint64) (*rpc.IBCPacketsResult, error) {
+	var res rpc.IBCPacketsResult
+	if err := c.Call(ctx, &res, "zion_getIBCPackets", port, channel, from); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetIBCProof proves the IBC record under key for the counterparty.
+func (c *Client) GetIBCProof(ctx context.Context, key string) (*rpc.IBCProofResult, error) {
+	var res rpc.IBCProofResult
+	if err := c.Call(ctx, &res, "zion_getIBCProof", key); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetIBCPacketProof proves the commitment to a packet sent over the
+// channel end, for kind "commitment", or the acknowledgement of a packet
+// received over it, for kind "ack".
+func (c *Client) GetIBCPacketProof(ctx context.Context, port, channel string, sequence uint64, kind string) (*rpc.IBCProofResult, error) {
+	var res rpc.IBCProofResult
+	if err := c.Call(ctx, &res, "zion_getIBCPacketProof", port, channel, sequence, kind); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetIBCVouchers returns the balances of tokens received over IBC held by
+// addr, by denomination, in base units.
+func (c *Client) GetIBCVouchers(ctx context.Context, addr string) (map[string]string, error) {
+	var res map[string]string
+	if err := c.Call(ctx, &res, "zion_getIBCVouchers", addr); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetBridge returns the bridge parameters and the tokens it holds.
+func (c *Client) GetBridge(ctx context.Context) (*rpc.BridgeResult, error) {
+	var res rpc.BridgeResult
+	if err := c.Call(ctx, &res, "zion_getBridge"); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetBridgeDeposit returns the record of the deposit event
+// "<txHash>:<logIndex>" of the bridged chain.
+func (c *Client) GetBridgeDeposit(ctx context.Context, id string) (*state.BridgeDepositRecord, error) {
+	var res state.BridgeDepositRecord
+	if err := c.Call(ctx, &res, "zion_getBridgeDeposit", id); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetBridgeWithdrawals lists the withdrawals to the bridged chain in nonce
+// order.
+func (c *Client) GetBridgeWithdrawals(ctx context.Context, page rpc.PageArgs) (*rpc.Page[state.BridgeWithdrawal], error) {
+	var res rpc.Page[state.BridgeWithdrawal]
+	if err := c.Call(ctx, &res, "zion_getBridgeWithdrawals", page); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetBridgeWithdrawalProof returns the withdrawal nonce with its proof for
+// the bridge contract of the bridged chain.
+func (c *Client) GetBridgeWithdrawalProof(ctx context.Context, nonce uint64) (*rpc.BridgeWithdrawalProof, error) {
+	var res rpc.BridgeWithdrawalProof
+	if err := c.Call(ctx, &res, "zion_getBridgeWithdrawalProof", nonce); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetBridgeBalances returns the wrapped token balances of addr by token
+// contract, in base units.
+func (c *Client) GetBridgeBalances(ctx context.Context, addr string) (map[string]string, error) {
+	var res map[string]string
+	if err := c.Call(ctx, &res, "zion_getBridgeBalances", addr); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetToken returns the ZRC-20 token symbol.
+func (c *Client) GetToken(ctx context.Context, symbol string) (*state.Token, error) {
+	var res state.Token
+	if err := c.Call(ctx, &res, "zion_getToken", symbol); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ListTokens lists the ZRC-20 tokens by symbol.
+func (c *Client) ListTokens(ctx context.Context, page rpc.PageArgs) (*rpc.Page[state.Token], error) {
+	var res rpc.Page[state.Token]
+	if err := c.Call(ctx, &res, "zion_listTokens", page); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetTokenBalance returns the balance of the ZRC-20 token symbol of addr.
+func (c *Client) GetTokenBalance(ctx context.Context, symbol, addr string) (*rpc.TokenBalanceResult, error) {
+	var res rpc.TokenBalanceResult
+	if err := c.Call(ctx, &res, "zion_getTokenBalance", symbol, addr); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetTokenBalances returns the ZRC-20 balances of addr by symbol, in base
+// units.
+func (c *Client) GetTokenBalances(ctx context.Context, addr string) (map[string]string, error) {
+	var res map[string]string
+	if err := c.Call(ctx, &res, "zion_getTokenBalances", addr); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetTokenAllowance returns the amount of the token symbol spender may
+// still transfer from owner, in base units.
+func (c *Client) GetTokenAllowance(ctx context.Context, symbol, owner, spender string) (string, error) {
+	var res string
+	err := c.Call(ctx, &res, "zion_getTokenAllowance", symbol, owner, spender)
+	return res, err
+}
+
+// GetNFTCollection returns the ZRC-721 collection symbol.
+func (c *Client) GetNFTCollection(ctx context.Context, symbol string) (*state.NFTCollection, error) {
+	var res state.NFTCollection
+	if err := c.Call(ctx, &res, "zion_getNFTCollection", symbol); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ListNFTCollections lists the ZRC-721 collections by symbol.
+func (c *Client) ListNFTCollections(ctx context.Context, page rpc.PageArgs) (*rpc.Page[state.NFTCollection], error) {
+	var res rpc.Page[state.NFTCollection]
+	if err := c.Call(ctx, &res, "zion_listNFTCollections", page); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetNFT returns the token id of the ZRC-721 collection.
+func (c *Client) GetNFT(ctx context.Context, collection, id string) (*state.NFT, error) {
+	var res state.NFT
+	if err := c.Call(ctx, &res, "zion_getNFT", collection, id); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetNFTsByOwner lists the ZRC-721 tokens of addr.
+func (c *Client) GetNFTsByOwner(ctx context.Context, addr string, page rpc.PageArgs) (*rpc.Page[state.NFT], error) {
+	var res rpc.Page[state.NFT]
+	if err := c.Call(ctx, &res, "zion_getNFTsByOwner", addr, page); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetNFTsByCollection lists the tokens of the ZRC-721 collection symbol.
+func (c *Client) GetNFTsByCollection(ctx context.Context, symbol string, page rpc.PageArgs) (*rpc.Page[state.NFT], error) {
+	var res rpc.Page[state.NFT]
+	if err := c.Call(ctx, &res, "zion_getNFTsByCollection", symbol, page); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,391 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/indexer"
+	"github.com/zionlayer/zionlayer/rpc"
+	"github.com/zionlayer/zionlayer/version"
+)
+
+// Hashes are passed and returned as 0x-prefixed hex, as in the JSON-RPC
+// API, and listings take an rpc.PageArgs, whose zero value asks for the
+// first page.
+
+// ChainID returns the chain ID transactions must be signed for.
+func (c *Client) ChainID(ctx context.Context) (uint64, error) {
+	var hex string
+	if err := c.Call(ctx, &hex, "zion_chainId"); err != nil {
+		return 0, err
+	}
+	return parseHexUint("zion_chainId", hex)
+}
+
+// ClientVersion returns the version of the node software.
+func (c *Client) ClientVersion(ctx context.Context) (*version.Info, error) {
+	var info version.Info
+	if err := c.Call(ctx, &info, "zion_clientVersion"); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// GetBalance returns the ZIO balance of addr in base units.
+func (c *Client) GetBalance(ctx context.Context, addr string) (*big.Int, error) {
+	var res struct {
+		Balance string `json:"balance"`
+	}
+	if err := c.Call(ctx, &res, "zion_getBalance", addr); err != nil {
+		return nil, err
+	}
+	bal, ok := new(big.Int).SetString(res.Balance, 10)
+	if !ok {
+		return nil, fmt.Errorf("zion_getBalance: invalid balance %q", res.Balance)
+	}
+	return bal, nil
+}
+
+// GetTransactionCount returns the next nonce of addr, counting its
+// transactions waiting in the mempool if pending is set.
+func (c *Client) GetTransactionCount(ctx context.Context, addr string, pending bool) (uint64, error) {
+	tag := "latest"
+	if pending {
+		tag = "pending"
+	}
+	var hex string
+	if err := c.Call(ctx, &hex, "zion_getTransactionCount", addr, tag); err != nil {
+		return 0, err
+	}
+	return parseHexUint("zion_getTransactionCount", hex)
+}
+
+// SendRawTransaction submits a signed transaction to the mempool and
+// returns its hash.
+func (c *Client) SendRawTransaction(ctx context.Context, tx *transaction.Tx) (string, error) {
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	var hash string
+	if err := c.Call(ctx, &hash, "zion_sendRawTransaction", fmt.Sprintf("0x%x", raw)); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// GetTransaction returns an included transaction with its receipt, which
+// is nil until the transaction is executed.
+func (c *Client) GetTransaction(ctx context.Context, hash string) (*rpc.TransactionResult, error) {
+	var res rpc.TransactionResult
+	if err := c.Call(ctx, &res, "zion_getTransaction", hash); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetTransactionProof returns the Merkle proof that the transaction hash
+// is included in its block, checkable against the TxRoot of the header.
+func (c *Client) GetTransactionProof(ctx context.Context, hash string) (*rpc.TxProofResult, error) {
+	var res rpc.TxProofResult
+	if err := c.Call(ctx, &res, "zion_getTransactionProof", hash); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetBlockByHeight returns the block at height, with full transactions if
+// fullTxs is set and their hashes otherwise.
+func (c *Client) GetBlockByHeight(ctx context.Context, height uint64, fullTxs bool) (*rpc.BlockResult, error) {
+	var res rpc.BlockResult
+	if err := c.Call(ctx, &res, "zion_getBlockByHeight", height, fullTxs); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetBlockByHash returns the block hash, like GetBlockByHeight.
+func (c *Client) GetBlockByHash(ctx context.Context, hash string, fullTxs bool) (*rpc.BlockResult, error) {
+	var res rpc.BlockResult
+	if err := c.Call(ctx, &res, "zion_getBlockByHash", hash, fullTxs); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetHeader returns the encoded header of the block at height.
+func (c *Client) GetHeader(ctx context.Context, height uint64) (*rpc.HeaderResult, error) {
+	var res rpc.HeaderResult
+	if err := c.Call(ctx, &res, "zion_getHeader", height); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// CallContract executes a read-only contract call against the latest
+// state and returns its output as 0x-prefixed hex.
+func (c *Client) CallContract(ctx context.Context, args rpc.CallArgs) (string, error) {
+	var out string
+	if err := c.Call(ctx, &out, "zion_call", args); err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// EstimateGas returns the gas a contract call would use.
+func (c *Client) EstimateGas(ctx context.Context, args rpc.CallArgs) (uint64, error) {
+	var hex string
+	if err := c.Call(ctx, &hex, "zion_estimateGas", args); err != nil {
+		return 0, err
+	}
+	return parseHexUint("zion_estimateGas", hex)
+}
+
+// SimulateTransaction executes tx, which need not be signed, against the
+// latest state without committing it.
+func (c *Client) SimulateTransaction(ctx context.Context, tx *transaction.Tx) (*rpc.SimulationResult, error) {
+	var res rpc.SimulationResult
+	if err := c.Call(ctx, &res, "zion_simulateTransaction", tx); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// EstimateTxGas returns the gas tx would use, from a simulation with the
+// gas of tx or, if it has none, the block gas limit. The simulation runs
+// with the latest nonce of the sender, as tx may follow transactions still
+// in the mempool. It fails if the transaction would fail.
+func (c *Client) EstimateTxGas(ctx context.Context, tx *transaction.Tx) (uint64, error) {
+	sim := *tx
+	nonce, err := c.GetTransactionCount(ctx, tx.From, false)
+	if err != nil {
+		return 0, err
+	}
+	sim.Nonce = nonce
+	if sim.Gas == 0 {
+		params, err := c.GetParams(ctx)
+		if err != nil {
+			return 0, err
+		}
+		sim.Gas = params.Params.Consensus.BlockGasLimit
+	}
+	res, err := c.SimulateTransaction(ctx, &sim)
+	if err != nil {
+		return 0, err
+	}
+	if res.Status != "success" {
+		return 0, fmt.Errorf("zion_simulateTransaction: transaction would fail: %s", res.Error)
+	}
+	return parseHexUint("zion_simulateTransaction", res.GasUsed)
+}
+
+// GetLogs returns the logs matching args.
+func (c *Client) GetLogs(ctx context.Context, args rpc.FilterArgs) ([]rpc.LogResult, error) {
+	var logs []rpc.LogResult
+	if err := c.Call(ctx, &logs, "zion_getLogs", args); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// NewFilter installs a log filter and returns its ID for GetFilterChanges.
+func (c *Client) NewFilter(ctx context.Context, args rpc.FilterArgs) (string, error) {
+	var id string
+	if err := c.Call(ctx, &id, "zion_newFilter", args); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// GetFilterChanges returns the logs matching the filter id in the blocks
+// added since it was last polled.
+func (c *Client) GetFilterChanges(ctx context.Context, id string) ([]rpc.LogResult, error) {
+	var logs []rpc.LogResult
+	if err := c.Call(ctx, &logs, "zion_getFilterChanges", id); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// UninstallFilter removes the filter id and reports whether it existed.
+func (c *Client) UninstallFilter(ctx context.Context, id string) (bool, error) {
+	var ok bool
+	err := c.Call(ctx, &ok, "zion_uninstallFilter", id)
+	return ok, err
+}
+
+// MempoolContent is the result of zion_mempoolContent: the pooled
+// transactions by sender and then by nonce.
+type MempoolContent struct {
+	Pending map[string]map[string]*transaction.Tx `json:"pending"`
+	Queued  map[string]map[string]*transaction.Tx `json:"queued"`
+}
+
+// MempoolInspect is MempoolContent with each transaction reduced to a one
+// line summary.
+type MempoolInspect struct {
+	Pending map[string]map[string]string `json:"pending"`
+	Queued  map[string]map[string]string `json:"queued"`
+}
+
+// GetMempoolSize returns the number of pooled transactions.
+func (c *Client) GetMempoolSize(ctx context.Context) (int, error) {
+	var res struct {
+		Size int `json:"size"`
+	}
+	err := c.Call(ctx, &res, "zion_getMempoolSize")
+	return res.Size, err
+}
+
+// GetMempoolContent returns the pooled transactions.
+func (c *Client) GetMempoolContent(ctx context.Context) (*MempoolContent, error) {
+	var res MempoolContent
+	if err := c.Call(ctx, &res, "zion_mempoolContent"); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// InspectMempool returns summaries of the pooled transactions.
+func (c *Client) InspectMempool(ctx context.Context) (*MempoolInspect, error) {
+	var res MempoolInspect
+	if err := c.Call(ctx, &res, "zion_mempoolInspect"); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetValidators returns the validator set.
+func (c *Client) GetValidators(ctx context.Context) ([]rpc.ValidatorResult, error) {
+	var vals []rpc.ValidatorResult
+	if err := c.Call(ctx, &vals, "zion_getValidators"); err != nil {
+		return nil, err
+	}
+	return vals, nil
+}
+
+// GetValidatorStatus returns the signing record of addr and its entry in
+// the validator set, if any.
+func (c *Client) GetValidatorStatus(ctx context.Context, addr string) (*rpc.ValidatorStatus, error) {
+	var res rpc.ValidatorStatus
+	if err := c.Call(ctx, &res, "zion_getValidatorStatus", addr); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetStakingValidator returns the description, commission and bonded
+// stake of the validator addr.
+func (c *Client) GetStakingValidator(ctx context.Context, addr string) (*state.StakingValidator, error) {
+	var v state.StakingValidator
+	if err := c.Call(ctx, &v, "zion_getStakingValidator", addr); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// GetStakeDelegations returns the stake addr has delegated to validators
+// and its stake awaiting release.
+func (c *Client) GetStakeDelegations(ctx context.Context, addr string) (*rpc.StakeDelegationsResult, error) {
+	var res rpc.StakeDelegationsResult
+	if err := c.Call(ctx, &res, "zion_getDelegations", addr); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetSupply returns the supply of ZIO and the inflation schedule.
+func (c *Client) GetSupply(ctx context.Context) (*rpc.SupplyResult, error) {
+	var res rpc.SupplyResult
+	if err := c.Call(ctx, &res, "zion_getSupply"); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetChainStats returns the agent and stake totals and, if the node runs
+// the SQL indexer, the indexed totals with the verified inference receipts
+// of the last days days (a default window if zero).
+func (c *Client) GetChainStats(ctx context.Context, days int) (*rpc.ChainStatsResult, error) {
+	var res rpc.ChainStatsResult
+	var params []interface{}
+	if days > 0 {
+		params = append(params, days)
+	}
+	if err := c.Call(ctx, &res, "zion_getChainStats", params...); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetStateRoot returns the root of the current state, which the next block
+// will carry, and the shape of its snapshot.
+func (c *Client) GetStateRoot(ctx context.Context) (*rpc.StateRootResult, error) {
+	var res rpc.StateRootResult
+	if err := c.Call(ctx, &res, "zion_getStateRoot"); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetAddressActivity returns the summary of the transactions of addr
+// indexed by the SQL indexer of the node.
+func (c *Client) GetAddressActivity(ctx context.Context, addr string) (*indexer.Activity, error) {
+	var res indexer.Activity
+	if err := c.Call(ctx, &res, "zion_getAddressActivity", addr); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetParams returns the protocol parameters in effect and the parameter
+// forks.
+func (c *Client) GetParams(ctx context.Context) (*rpc.ParamsResult, error) {
+	var res rpc.ParamsResult
+	if err := c.Call(ctx, &res, "zion_getParams"); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetProposal returns the governance proposal id.
+func (c *Client) GetProposal(ctx context.Context, id uint64) (*state.Proposal, error) {
+	var p state.Proposal
+	if err := c.Call(ctx, &p, "zion_getProposal", id); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetProposals lists the governance proposals with status, or all of them
+// if it is empty, newest first.
+func (c *Client) GetProposals(ctx context.Context, status string, page rpc.PageArgs) (*rpc.Page[state.Proposal], error) {
+	var res rpc.Page[state.Proposal]
+	if err := c.Call(ctx, &res, "zion_getProposals", status, page); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetUpgradePlan returns the software upgrade passed by governance, or nil
+// if there is none.
+func (c *Client) GetUpgradePlan(ctx context.Context) (*transaction.UpgradePlan, error) {
+	var plan *transaction.UpgradePlan
+	if err := c.Call(ctx, &plan, "zion_getUpgradePlan"); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func parseHexUint(method, s string) (uint64, error) {
+	n, err := strconv.ParseUint(strings.TrimPrefix(s, "0x"), 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid quantity %q", method, s)
+	}
+	return n, nil
+}
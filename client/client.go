@@ -0,0 +1,140 @@
+// Package client is a Go client of the ZionLayer node API. Client wraps
+// the zion_ JSON-RPC methods with typed calls, Wallet signs and submits
+// transactions with a local key, tracking its nonce and waiting for
+// receipts, and the Subscribe methods stream events over WebSocket.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/zionlayer/zionlayer/rpc"
+)
+
+// DefaultTimeout bounds a single request to the node.
+const DefaultTimeout = 30 * time.Second
+
+// Error is an error returned by the node for a call. Data is set for the
+// errors of the node that carry a machine-readable reason.
+type Error struct {
+	Method  string
+	Code    int
+	Message string
+	Data    *rpc.ErrorData
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s (code %d)", e.Method, e.Message, e.Code)
+}
+
+// IsNotFound reports whether err is the node reporting that the block,
+// transaction or record asked for does not exist.
+func IsNotFound(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && (e.Code == rpc.CodeNotFound || e.Code == rpc.CodeAgentNotFound)
+}
+
+// Client is a connection to the JSON-RPC API of a node. It is safe for
+// concurrent use.
+type Client struct {
+	url    string
+	apiKey string
+	http   *http.Client
+	nextID atomic.Int64
+}
+
+// New returns a client of the JSON-RPC endpoint url, such as
+// http://localhost:8545, authenticating with apiKey if it is not empty.
+func New(url, apiKey string) *Client {
+	return &Client{url: url, apiKey: apiKey, http: &http.Client{Timeout: DefaultTimeout}}
+}
+
+// SetHTTPClient replaces the HTTP client used for calls, for instance to
+// change the timeout or the transport.
+func (c *Client) SetHTTPClient(hc *http.Client) {
+	c.http = hc
+}
+
+type request struct {
+	JSONRPC string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+	ID      int64         `json:"id"`
+}
+
+type response struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int            `json:"code"`
+		Message string         `json:"message"`
+		Data    *rpc.ErrorData `json:"data"`
+	} `json:"error"`
+}
+
+// err returns the error of resp for method, or nil.
+func (resp *response) err(method string) error {
+	if resp.Error == nil {
+		return nil
+	}
+	return &Error{Method: method, Code: resp.Error.Code, Message: resp.Error.Message, Data: resp.Error.Data}
+}
+
+// Call invokes method with params and decodes its result into result,
+// which may be nil. It is the escape hatch for methods without a typed
+// wrapper.
+func (c *Client) Call(ctx context.Context, result interface{}, method string, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, err := json.Marshal(request{JSONRPC: "2.0", Method: method, Params: params, ID: c.nextID.Add(1)})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var out response
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return fmt.Errorf("%s: %s", method, resp.Status)
+	}
+	if err := out.err(method); err != nil {
+		return err
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(out.Result, result); err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	return nil
+}
+
+// wsURL returns the WebSocket endpoint of the node, served under /ws.
+func (c *Client) wsURL() string {
+	u := strings.TrimSuffix(c.url, "/")
+	switch {
+	case strings.HasPrefix(u, "https://"):
+		u = "wss://" + strings.TrimPrefix(u, "https://")
+	case strings.HasPrefix(u, "http://"):
+		u = "ws://" + strings.TrimPrefix(u, "http://")
+	}
+	return u + "/ws"
+}
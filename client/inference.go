@@ -0,0 +1,168 @@
+package client
+
+import (
+	"context"
+
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/rpc"
+)
+
+// GetInferenceReceipt returns the inference receipt included by the
+// transaction hash.
+func (c *Client) GetInferenceReceipt(ctx context.Context, hash string) (*rpc.InferenceReceiptResult, error) {
+	var res rpc.InferenceReceiptResult
+	if err := c.Call(ctx, &res, "zion_getInferenceReceipt", hash); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetInferenceReceipts lists the inference receipts of the agent id
+// included between the heights from and to.
+func (c *Client) GetInferenceReceipts(ctx context.Context, id string, from, to uint64, page rpc.PageArgs) (*rpc.Page[rpc.InferenceReceiptResult], error) {
+	var res rpc.Page[rpc.InferenceReceiptResult]
+	if err := c.Call(ctx, &res, "zion_getInferenceReceipts", id, from, to, page); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetModelReceipts lists the inference receipts of the model hash, oldest
+// first.
+func (c *Client) GetModelReceipts(ctx context.Context, model string, page rpc.PageArgs) (*rpc.Page[state.ReceiptRecord], error) {
+	var res rpc.Page[state.ReceiptRecord]
+	if err := c.Call(ctx, &res, "zion_getModelReceipts", model, page); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetDispute returns the dispute of the inference receipt included by the
+// transaction hash.
+func (c *Client) GetDispute(ctx context.Context, hash string) (*state.Dispute, error) {
+	var d state.Dispute
+	if err := c.Call(ctx, &d, "zion_getDispute", hash); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// GetReveal returns the data revealed for the inference receipt included
+// by the transaction hash.
+func (c *Client) GetReveal(ctx context.Context, hash string) (*transaction.InferenceReveal, error) {
+	var v transaction.InferenceReveal
+	if err := c.Call(ctx, &v, "zion_getReveal", hash); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// GetReceiptBatch returns the receipt batch included by the transaction
+// hash.
+func (c *Client) GetReceiptBatch(ctx context.Context, hash string) (*state.BatchRecord, error) {
+	var b state.BatchRecord
+	if err := c.Call(ctx, &b, "zion_getReceiptBatch", hash); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// VerifyBatchedReceipt reports whether a receipt is part of the batch
+// included by a transaction.
+func (c *Client) VerifyBatchedReceipt(ctx context.Context, args rpc.BatchedReceiptArgs) (bool, error) {
+	var ok bool
+	err := c.Call(ctx, &ok, "zion_verifyBatchedReceipt", args)
+	return ok, err
+}
+
+// GetDataAttestations lists the attestations in force of pinning
+// providers retaining the payload cid on network.
+func (c *Client) GetDataAttestations(ctx context.Context, network, cid string) ([]*state.DataAttestationRecord, error) {
+	var recs []*state.DataAttestationRecord
+	if err := c.Call(ctx, &recs, "zion_getDataAttestations", network, cid); err != nil {
+		return nil, err
+	}
+	return recs, nil
+}
+
+// GetEvaluator returns the quality oracle member addr.
+func (c *Client) GetEvaluator(ctx context.Context, addr string) (*state.Evaluator, error) {
+	var ev state.Evaluator
+	if err := c.Call(ctx, &ev, "zion_getEvaluator", addr); err != nil {
+		return nil, err
+	}
+	return &ev, nil
+}
+
+// GetEvaluators lists the quality oracle committee by address.
+func (c *Client) GetEvaluators(ctx context.Context, page rpc.PageArgs) (*rpc.Page[state.Evaluator], error) {
+	var res rpc.Page[state.Evaluator]
+	if err := c.Call(ctx, &res, "zion_getEvaluators", page); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetEvaluation returns the quality evaluation of the inference receipt
+// included by the transaction hash.
+func (c *Client) GetEvaluation(ctx context.Context, hash string) (*state.Evaluation, error) {
+	var e state.Evaluation
+	if err := c.Call(ctx, &e, "zion_getEvaluation", hash); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// GetProvider returns the compute provider addr.
+func (c *Client) GetProvider(ctx context.Context, addr string) (*state.Provider, error) {
+	var p state.Provider
+	if err := c.Call(ctx, &p, "zion_getProvider", addr); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetProviders lists the compute providers by address.
+func (c *Client) GetProviders(ctx context.Context, page rpc.PageArgs) (*rpc.Page[state.Provider], error) {
+	var res rpc.Page[state.Provider]
+	if err := c.Call(ctx, &res, "zion_getProviders", page); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetEnclaves returns the approved TEE enclave images.
+func (c *Client) GetEnclaves(ctx context.Context) ([]*state.EnclaveImage, error) {
+	var images []*state.EnclaveImage
+	if err := c.Call(ctx, &images, "zion_getEnclaves"); err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// GetVerifyingKey returns the Groth16 verifying key registered for the
+// circuit of the model hash, as 0x-prefixed hex.
+func (c *Client) GetVerifyingKey(ctx context.Context, model string) (string, error) {
+	var vk string
+	err := c.Call(ctx, &vk, "zion_getVerifyingKey", model)
+	return vk, err
+}
+
+// GetModel returns the registered model hash.
+func (c *Client) GetModel(ctx context.Context, hash string) (*state.Model, error) {
+	var m state.Model
+	if err := c.Call(ctx, &m, "zion_getModel", hash); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ListModels lists the registered models matching q.
+func (c *Client) ListModels(ctx context.Context, q state.ModelQuery, page rpc.PageArgs) (*rpc.Page[state.Model], error) {
+	var res rpc.Page[state.Model]
+	if err := c.Call(ctx, &res, "zion_listModels", q, page); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/zionlayer/zionlayer/internal/ws"
+	"github.com/zionlayer/zionlayer/rpc"
+)
+
+// wsMaxMessage bounds the size of a reassembled notification.
+const wsMaxMessage = 16 << 20
+
+// Subscription is a stream of zion_subscribe notifications delivered on
+// the channel given to the Subscribe method that created it. Each
+// subscription has its own WebSocket connection to the node.
+type Subscription struct {
+	conn *ws.Conn
+	err  chan error
+	quit chan struct{}
+	once sync.Once
+}
+
+// Err returns a channel receiving the error that ended the subscription,
+// such as the node closing the connection. It is closed by Unsubscribe.
+func (sub *Subscription) Err() <-chan error { return sub.err }
+
+// Unsubscribe ends the subscription and closes its connection.
+func (sub *Subscription) Unsubscribe() {
+	sub.once.Do(func() {
+		close(sub.quit)
+		sub.conn.Close()
+	})
+}
+
+// SubscribeNewHeads delivers the header of each finalized block on ch.
+func (c *Client) SubscribeNewHeads(ctx context.Context, ch chan<- rpc.HeadResult) (*Subscription, error) {
+	return subscribe(ctx, c, ch, rpc.SubNewHeads, nil)
+}
+
+// SubscribeLogs delivers on ch the logs of finalized blocks emitted by
+// address, or by any contract if it is empty.
+func (c *Client) SubscribeLogs(ctx context.Context, address string, ch chan<- rpc.LogResult) (*Subscription, error) {
+	return subscribe(ctx, c, ch, rpc.SubLogs, &rpc.SubscriptionFilter{Address: address})
+}
+
+// SubscribePendingTransactions delivers on ch the hash of each
+// transaction admitted to the mempool of the node.
+func (c *Client) SubscribePendingTransactions(ctx context.Context, ch chan<- string) (*Subscription, error) {
+	return subscribe(ctx, c, ch, rpc.SubPendingTransactions, nil)
+}
+
+// SubscribeAgentMessages delivers on ch the agent messages of finalized
+// blocks sent from filter.From to filter.To, either of which may be empty
+// to match any agent.
+func (c *Client) SubscribeAgentMessages(ctx context.Context, filter rpc.SubscriptionFilter, ch chan<- rpc.AgentMessageResult) (*Subscription, error) {
+	return subscribe(ctx, c, ch, rpc.SubAgentMessages, &filter)
+}
+
+// subscribe opens a connection to the node, subscribes to kind with filter
+// and relays the notifications, decoded as T, to ch until the
+// subscription ends. Delivery blocks while ch is full.
+func subscribe[T any](ctx context.Context, c *Client, ch chan<- T, kind string, filter *rpc.SubscriptionFilter) (*Subscription, error) {
+	header := make(http.Header)
+	if c.apiKey != "" {
+		header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	conn, err := ws.Dial(ctx, c.wsURL(), header)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetReadLimit(wsMaxMessage)
+	params := []interface{}{kind}
+	if filter != nil {
+		params = append(params, filter)
+	}
+	req, err := json.Marshal(request{JSONRPC: "2.0", Method: "zion_subscribe", Params: params, ID: 1})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := conn.WriteText(req); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	var id string
+	for id == "" {
+		msg, err := conn.ReadMessage()
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		var resp response
+		if err := json.Unmarshal(msg, &resp); err != nil || string(resp.ID) != "1" {
+			continue
+		}
+		if err := resp.err("zion_subscribe"); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if err := json.Unmarshal(resp.Result, &id); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	sub := &Subscription{conn: conn, err: make(chan error, 1), quit: make(chan struct{})}
+	go func() {
+		defer close(sub.err)
+		for {
+			msg, err := conn.ReadMessage()
+			if err != nil {
+				select {
+				case <-sub.quit:
+				default:
+					sub.err <- err
+					sub.Unsubscribe()
+				}
+				return
+			}
+			var n struct {
+				Method string `json:"method"`
+				Params struct {
+					Subscription string          `json:"subscription"`
+					Result       json.RawMessage `json:"result"`
+				} `json:"params"`
+			}
+			if err := json.Unmarshal(msg, &n); err != nil || n.Method != "zion_subscription" || n.Params.Subscription != id {
+				continue
+			}
+			var v T
+			if err := json.Unmarshal(n.Params.Result, &v); err != nil {
+				continue
+			}
+			select {
+			case ch <- v:
+			case <-sub.quit:
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
@@ -0,0 +1,188 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/zionlayer/zionlayer/core/crypto"
+	"github.com/zionlayer/zionlayer/core/keystore"
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/rpc"
+)
+
+// WaitPollInterval is how often WaitMined polls for a receipt, half the
+// default block time.
+const WaitPollInterval = time.Second
+
+// BuildFunc creates the transaction to send from from with nonce and
+// gasPrice, typically with one of the constructors of the transaction
+// package. A transaction built without gas gets the gas estimated by
+// simulating it.
+type BuildFunc func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error)
+
+// Wallet signs transactions with a key and submits them to a node. It
+// assigns nonces itself, so that several transactions can be sent before
+// the first is included, and fetches the pending nonce of its address
+// again after a failed submission. It is safe for concurrent use;
+// submissions are serialized.
+type Wallet struct {
+	client  *Client
+	key     crypto.PrivateKey
+	address string
+
+	mu       sync.Mutex
+	gasPrice *big.Int
+	chainID  uint64
+	nonce    uint64
+	synced   bool // nonce is the next nonce of address
+}
+
+// NewWallet returns a wallet sending transactions signed with key through
+// c.
+func NewWallet(c *Client, key crypto.PrivateKey) (*Wallet, error) {
+	pub, ok := key.Public().(crypto.PublicKey)
+	if !ok || len(key) != crypto.PrivateKeySize {
+		return nil, crypto.ErrInvalidPrivateKey
+	}
+	return &Wallet{
+		client:   c,
+		key:      key,
+		address:  crypto.PubkeyToAddress(pub).String(),
+		gasPrice: big.NewInt(1),
+	}, nil
+}
+
+// OpenWallet returns a wallet of the key name of ks, unlocked with
+// passphrase.
+func OpenWallet(c *Client, ks *keystore.Keystore, name, passphrase string) (*Wallet, error) {
+	key, err := ks.Unlock(name, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return NewWallet(c, key)
+}
+
+// Address returns the address of the wallet.
+func (w *Wallet) Address() string { return w.address }
+
+// SetGasPrice sets the gas price of the transactions sent from then on.
+// It defaults to 1, the default minimum of the mempool.
+func (w *Wallet) SetGasPrice(price *big.Int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.gasPrice = new(big.Int).Set(price)
+}
+
+// SetChainID sets the chain ID transactions are signed for instead of
+// asking the node for it.
+func (w *Wallet) SetChainID(id uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.chainID = id
+}
+
+// ResetNonce makes the next submission fetch the pending nonce of the
+// address again, for instance after transactions were sent with the same
+// key from elsewhere.
+func (w *Wallet) ResetNonce() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.synced = false
+}
+
+// Send builds a transaction with the next nonce of the wallet, estimates
+// its gas if it has none, signs it and submits it, returning its hash.
+func (w *Wallet) Send(ctx context.Context, build BuildFunc) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.chainID == 0 {
+		id, err := w.client.ChainID(ctx)
+		if err != nil {
+			return "", err
+		}
+		w.chainID = id
+	}
+	if !w.synced {
+		nonce, err := w.client.GetTransactionCount(ctx, w.address, true)
+		if err != nil {
+			return "", err
+		}
+		w.nonce, w.synced = nonce, true
+	}
+	tx, err := build(w.address, w.nonce, new(big.Int).Set(w.gasPrice))
+	if err != nil {
+		return "", err
+	}
+	if tx.Gas == 0 {
+		tx.ChainID = w.chainID
+		if tx.Gas, err = w.client.EstimateTxGas(ctx, tx); err != nil {
+			return "", err
+		}
+	}
+	if err := tx.Sign(w.key, w.chainID); err != nil {
+		return "", err
+	}
+	if err := tx.ValidateBasic(); err != nil {
+		return "", err
+	}
+	hash, err := w.client.SendRawTransaction(ctx, tx)
+	if err != nil {
+		w.synced = false
+		return "", err
+	}
+	w.nonce++
+	return hash, nil
+}
+
+// Transfer sends amount of ZIO, in base units, to to.
+func (w *Wallet) Transfer(ctx context.Context, to string, amount *big.Int) (string, error) {
+	return w.Send(ctx, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+		return transaction.NewTransferTx(from, to, amount, nonce, gasPrice), nil
+	})
+}
+
+// SendPayload sends a transaction of type typ carrying payload, created by
+// a constructor taking the type such as transaction.NewTokenTx,
+// transaction.NewNFTTx or transaction.NewIBCTx.
+func (w *Wallet) SendPayload(ctx context.Context, typ transaction.TxType,
+	newTx func(transaction.TxType, string, interface{}, uint64, *big.Int) *transaction.Tx, payload interface{}) (string, error) {
+	return w.Send(ctx, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+		return newTx(typ, from, payload, nonce, gasPrice), nil
+	})
+}
+
+// WaitMined waits until the transaction hash is executed and returns it
+// with its receipt. The transaction may have failed; see the status of
+// the receipt. It gives up when ctx is done.
+func (c *Client) WaitMined(ctx context.Context, hash string) (*rpc.TransactionResult, error) {
+	ticker := time.NewTicker(WaitPollInterval)
+	defer ticker.Stop()
+	for {
+		res, err := c.GetTransaction(ctx, hash)
+		switch {
+		case err == nil && res.Receipt != nil:
+			return res, nil
+		case ctx.Err() != nil:
+			return nil, ctx.Err()
+		case err != nil && !IsNotFound(err):
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendAndWait sends a transaction like Send and waits until it is
+// executed.
+func (w *Wallet) SendAndWait(ctx context.Context, build BuildFunc) (*rpc.TransactionResult, error) {
+	hash, err := w.Send(ctx, build)
+	if err != nil {
+		return nil, err
+	}
+	return w.client.WaitMined(ctx, hash)
+}
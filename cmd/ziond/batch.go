@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+var txBatchCmd = &cobra.Command{
+	Use:   "batch <batch.json>",
+	Short: "Submit a receipt batch signed by its compute provider",
+	Long: "Submit a receipt batch: the Merkle root of many inference receipts of one agent\n" +
+		"and model, signed by the compute provider that proved them, read as JSON.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		raw, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		var b transaction.InferenceBatch
+		if err := json.Unmarshal(raw, &b); err != nil {
+			return fmt.Errorf("%s: %w", args[0], err)
+		}
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewInferenceBatchTx(from, b, nonce, gasPrice), nil
+		})
+	},
+}
+
+var queryBatchCmd = &cobra.Command{
+	Use:   "batch <batch-tx-hash>",
+	Short: "Show a receipt batch",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_getReceiptBatch", []interface{}{args[0]}, func(w io.Writer, raw json.RawMessage) error {
+			var rec state.BatchRecord
+			if err := json.Unmarshal(raw, &rec); err != nil {
+				return err
+			}
+			b := rec.Batch
+			return printFields(w,
+				"agent", b.AgentID,
+				"model", fmt.Sprintf("0x%x", b.ModelHash),
+				"root", fmt.Sprintf("0x%x", b.Root),
+				"receipts", strconv.FormatUint(b.Count, 10),
+				"prover", b.Prover,
+				"submitter", rec.Submitter,
+				"height", strconv.FormatUint(rec.Height, 10),
+			)
+		})
+	},
+}
+
+func init() {
+	txCmd.AddCommand(txBatchCmd)
+	queryCmd.AddCommand(queryBatchCmd)
+}
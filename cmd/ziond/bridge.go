@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/rpc"
+)
+
+var txBridgeCmd = &cobra.Command{
+	Use:   "bridge",
+	Short: "Withdraw to the bridged chain and attest its deposits",
+}
+
+var txBridgeWithdrawCmd = &cobra.Command{
+	Use:   "withdraw <token> <amount> <recipient>",
+	Short: "Withdraw tokens to a recipient on the bridged chain",
+	Long: "Withdraw tokens to a recipient on the bridged chain. Token is zio, locked\n" +
+		"in the bridge escrow and minted as wrapped ZIO there, or the contract of a\n" +
+		"wrapped token of the bridged chain, burned here and released there.",
+	Args: cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		amount, err := parseAmount(args[1])
+		if err != nil {
+			return err
+		}
+		w := transaction.BridgeWithdraw{Token: args[0], Amount: amount, Recipient: args[2]}
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewBridgeWithdrawTx(from, w, nonce, gasPrice), nil
+		})
+	},
+}
+
+var txBridgeAttestCmd = &cobra.Command{
+	Use:   "attest <tx-hash> <log-index> <token> <amount> <recipient>",
+	Short: "Attest a deposit event of the bridged chain as a committee member",
+	Args:  cobra.ExactArgs(5),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logIndex, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid log index %q", args[1])
+		}
+		amount, ok := new(big.Int).SetString(args[3], 10)
+		if !ok || amount.Sign() <= 0 {
+			return fmt.Errorf("invalid amount %q: base units of the token", args[3])
+		}
+		recipient, err := parseAddressArg(args[4])
+		if err != nil {
+			return err
+		}
+		d := transaction.BridgeDeposit{TxHash: args[0], LogIndex: logIndex, Token: args[2], Amount: amount, Recipient: recipient}
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewBridgeAttestTx(from, d, nonce, gasPrice), nil
+		})
+	},
+}
+
+var queryBridgeCmd = &cobra.Command{
+	Use:   "bridge",
+	Short: "Show the bridge committee and the tokens it holds",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_getBridge", nil, func(w io.Writer, raw json.RawMessage) error {
+			var res rpc.BridgeResult
+			if err := json.Unmarshal(raw, &res); err != nil {
+				return err
+			}
+			if err := printFields(w,
+				"Chain", res.Params.Chain,
+				"Committee", strings.Join(res.Params.Committee, ", "),
+				"Threshold", strconv.Itoa(res.Params.Threshold),
+				"Wrapped ZIO", res.Params.WrappedZIO,
+				"Locked", res.Locked,
+				"Withdrawals", strconv.Itoa(res.Withdrawals),
+			); err != nil {
+				return err
+			}
+			return printBalances(w, "WRAPPED TOKEN", "SUPPLY", res.WrappedSupply)
+		})
+	},
+}
+
+var queryBridgeWithdrawalsCmd = &cobra.Command{
+	Use:   "withdrawals",
+	Short: "List the withdrawals to the bridged chain",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_getBridgeWithdrawals", []interface{}{rpc.PageArgs{Limit: rpc.MaxPageSize}}, func(w io.Writer, raw json.RawMessage) error {
+			var page rpc.Page[state.BridgeWithdrawal]
+			if err := json.Unmarshal(raw, &page); err != nil {
+				return err
+			}
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "NONCE\tHEIGHT\tSENDER\tTOKEN\tAMOUNT\tRECIPIENT")
+			for _, wd := range page.Items {
+				fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%s\t%s\n", wd.Nonce, wd.Height, wd.Sender, wd.Token, wd.Amount, wd.Recipient)
+			}
+			return tw.Flush()
+		})
+	},
+}
+
+var queryBridgeBalancesCmd = &cobra.Command{
+	Use:   "balances <address>",
+	Short: "Show the wrapped token balances of an address",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_getBridgeBalances", []interface{}{args[0]}, func(w io.Writer, raw json.RawMessage) error {
+			var bals map[string]string
+			if err := json.Unmarshal(raw, &bals); err != nil {
+				return err
+			}
+			return printBalances(w, "TOKEN", "BALANCE", bals)
+		})
+	},
+}
+
+// printBalances writes amounts by token as a table sorted by token.
+func printBalances(w io.Writer, tokenHeader, amountHeader string, amounts map[string]string) error {
+	tokens := make([]string, 0, len(amounts))
+	for t := range amounts {
+		tokens = append(tokens, t)
+	}
+	sort.Strings(tokens)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\t%s\n", tokenHeader, amountHeader)
+	for _, t := range tokens {
+		fmt.Fprintf(tw, "%s\t%s\n", t, amounts[t])
+	}
+	return tw.Flush()
+}
+
+func init() {
+	txBridgeCmd.AddCommand(txBridgeWithdrawCmd, txBridgeAttestCmd)
+	txCmd.AddCommand(txBridgeCmd)
+	queryBridgeCmd.AddCommand(queryBridgeWithdrawalsCmd, queryBridgeBalancesCmd)
+	queryCmd.AddCommand(queryBridgeCmd)
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// ConfigFile is the name of the node configuration file looked up in the
+// data directory when --config is not given.
+const ConfigFile = "ziond.toml"
+
+// envPrefix prefixes the environment variables overriding config keys:
+// rpc.admin.port is read from ZIOND_RPC_ADMIN_PORT.
+const envPrefix = "ZIOND"
+
+// configKeys maps config file keys to the start flags they set. A flag
+// given on the command line takes precedence over the environment, which
+// takes precedence over the file.
+var configKeys = map[string]string{
+	"data.dir":              "data-dir",
+	"genesis.file":          "genesis",
+	"consensus.validator":   "validator",
+	"consensus.block_time":  "block-time",
+	"mempool.min_gas_price": "min-gas-price",
+
+	"rpc.addr":                "rpc-addr",
+	"rpc.port":                "rpc-port",
+	"rpc.cors_origins":        "rpc-cors",
+	"rpc.rate":                "rpc-rate",
+	"rpc.burst":               "rpc-burst",
+	"rpc.tx_rate":             "rpc-tx-rate",
+	"rpc.tx_burst":            "rpc-tx-burst",
+	"rpc.api_keys":            "rpc-api-keys",
+	"rpc.jwt_secret":          "rpc-jwt-secret",
+	"rpc.tls_cert":            "rpc-tls-cert",
+	"rpc.tls_key":             "rpc-tls-key",
+	"rpc.trusted_proxies":     "rpc-trusted-proxies",
+	"rpc.ready.max_block_age": "ready-max-block-age",
+	"rpc.ready.min_peers":     "ready-min-peers",
+	"rpc.rest.port":           "rest-port",
+	"rpc.admin.addr":          "admin-rpc-addr",
+	"rpc.admin.port":          "admin-rpc-port",
+	"grpc.port":               "grpc-port",
+
+	"p2p.port":      "p2p-port",
+	"p2p.max_peers": "p2p-max-peers",
+
+	"log.level":       "log-level",
+	"log.format":      "log-format",
+	"log.file":        "log-file",
+	"log.max_size":    "log-max-size",
+	"log.max_age":     "log-max-age",
+	"log.max_backups": "log-max-backups",
+	"log.compress":    "log-compress",
+
+	"daemon.pid_file": "pid-file",
+
+	"telemetry.otlp_endpoint": "otlp-endpoint",
+	"telemetry.otlp_insecure": "otlp-insecure",
+	"telemetry.sample_ratio":  "trace-sample-ratio",
+
+	"pruning.keep_blocks": "pruning-keep-blocks",
+
+	"da.ipfs_endpoint": "ipfs-endpoint",
+
+	"indexer.driver": "indexer-driver",
+	"indexer.dsn":    "indexer-dsn",
+}
+
+// defaultConfig is the file written by "ziond config init". It lists every
+// key of configKeys with its flag default.
+const defaultConfig = `# ziond configuration. Every key may be overridden by an environment
+# variable (rpc.admin.port by ZIOND_RPC_ADMIN_PORT) or by the matching
+# command line flag.
+
+[data]
+dir = "./data"
+
+[genesis]
+file = ""            # genesis JSON; empty uses the built-in devnet genesis
+
+[consensus]
+validator = ""       # proposer address; empty uses the devnet validator
+block_time = "2s"
+
+[mempool]
+min_gas_price = "1"
+
+[rpc]
+addr = ""            # empty listens on all interfaces
+port = 8545
+cors_origins = []    # browser origins allowed to call the JSON-RPC, or ["*"]
+rate = 50            # requests/s per client IP
+burst = 100
+tx_rate = 10         # transactions/s per client IP
+tx_burst = 50
+api_keys = []        # when set, clients send "Authorization: Bearer <key>"
+jwt_secret = ""      # file with an HS256 secret for JWT bearer tokens
+tls_cert = ""        # set both to serve HTTPS
+tls_key = ""
+trusted_proxies = [] # e.g. ["10.0.0.0/8"]; their X-Forwarded-For is honoured
+
+[rpc.ready]
+max_block_age = "30s" # /readyz fails when the latest block is older; "0s" disables
+min_peers = 1         # peers /readyz requires when p2p networking is enabled
+
+[rpc.rest]
+port = 0             # REST gateway; 0 disables
+
+[rpc.admin]
+addr = "127.0.0.1"
+port = 0             # 0 disables the admin listener
+
+[grpc]
+port = 9090          # 0 disables
+
+[p2p]
+port = 9000
+max_peers = 50
+
+[log]
+level = "info"       # debug, info, warn or error
+format = "json"      # json or console
+file = ""            # log to this file instead of stderr
+max_size = 100       # megabytes before the file is rotated
+max_age = 0          # days to keep rotated files; 0 keeps them
+max_backups = 0      # rotated files to keep; 0 keeps all
+compress = false     # gzip rotated files
+
+[daemon]
+pid_file = ""        # e.g. "/run/ziond/ziond.pid"
+
+[telemetry]
+otlp_endpoint = ""   # OTLP/gRPC collector for traces, e.g. "localhost:4317"
+otlp_insecure = false
+sample_ratio = 1.0   # fraction of transaction traces recorded
+
+[pruning]
+keep_blocks = 0      # blocks kept in the index; 0 keeps all
+
+[da]
+ipfs_endpoint = ""   # IPFS HTTP API pinning referenced payloads, e.g. "http://localhost:5001"
+
+[indexer]
+driver = ""          # sqlite or postgres, if linked into the binary; empty disables
+dsn = ""             # e.g. "data/index.db" or "postgres://zion@localhost/zion"
+`
+
+var flagConfig string
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the node configuration file",
+}
+
+var flagConfigForce bool
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Write a default " + ConfigFile,
+	Long:  "Write a default configuration to --config, or to " + ConfigFile + " in the data directory.",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigInit,
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&flagConfig, "config", "", "Config file (default <data-dir>/"+ConfigFile+" if present)")
+	rootCmd.PersistentFlags().StringVar(&flagDataDir, "data-dir", "./data", "Data directory")
+	configInitCmd.Flags().BoolVar(&flagConfigForce, "force", false, "Overwrite an existing file")
+	configCmd.AddCommand(configInitCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// configPath returns --config, or the config file in the data directory.
+func configPath(cmd *cobra.Command) string {
+	if flagConfig != "" {
+		return flagConfig
+	}
+	dir := flagDataDir
+	if env := os.Getenv(envPrefix + "_DATA_DIR"); env != "" && !cmd.Flags().Changed("data-dir") {
+		dir = env
+	}
+	return filepath.Join(dir, ConfigFile)
+}
+
+// loadConfig applies the config file and environment to the flags of cmd
+// that were not set on the command line, and returns the file read, if any.
+// An explicit --config must exist; the data directory one is optional.
+func loadConfig(cmd *cobra.Command) (string, error) {
+	v := viper.New()
+	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	path := configPath(cmd)
+	if _, err := os.Stat(path); err == nil || flagConfig != "" {
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			return "", fmt.Errorf("config %s: %w", path, err)
+		}
+	} else {
+		path = ""
+	}
+
+	flags := cmd.Flags()
+	for key, name := range configKeys {
+		f := flags.Lookup(name)
+		if f == nil || f.Changed || !v.IsSet(key) {
+			continue
+		}
+		value := v.GetString(key)
+		if strings.HasSuffix(f.Value.Type(), "Slice") {
+			value = strings.Join(v.GetStringSlice(key), ",")
+		}
+		if err := flags.Set(name, value); err != nil {
+			return "", fmt.Errorf("config %s: %w", key, err)
+		}
+	}
+	return path, nil
+}
+
+func runConfigInit(cmd *cobra.Command, args []string) error {
+	path := configPath(cmd)
+	if _, err := os.Stat(path); err == nil && !flagConfigForce {
+		return fmt.Errorf("%s already exists; use --force to overwrite", path)
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(defaultConfig), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), "wrote", path)
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+var (
+	flagDASize  uint64
+	flagDAHash  string
+	flagDAUntil uint64
+)
+
+var txDACmd = &cobra.Command{
+	Use:   "da",
+	Short: "Vouch for the retention of payloads kept off chain",
+}
+
+var txDAAttestCmd = &cobra.Command{
+	Use:   "attest <network> <cid>",
+	Short: "Attest that the sender retains a payload (network ipfs or arweave) until a block",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ref, err := parseDataRef(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		a := transaction.DataAttestation{Ref: *ref, Until: flagDAUntil}
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewDataAttestTx(from, a, nonce, gasPrice), nil
+		})
+	},
+}
+
+var queryDACmd = &cobra.Command{
+	Use:   "da <network> <cid>",
+	Short: "List the attestations in force of the retention of a payload",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_getDataAttestations", []interface{}{args[0], args[1]}, func(w io.Writer, raw json.RawMessage) error {
+			var recs []state.DataAttestationRecord
+			if err := json.Unmarshal(raw, &recs); err != nil {
+				return err
+			}
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "ATTESTER\tSINCE\tUNTIL")
+			for _, r := range recs {
+				fmt.Fprintf(tw, "%s\t%d\t%d\n", r.Attester, r.Height, r.Until)
+			}
+			return tw.Flush()
+		})
+	},
+}
+
+// parseDataRef builds the reference to the payload cid on network from the
+// --size and --content-hash flags.
+func parseDataRef(network, cid string) (*transaction.DataRef, error) {
+	ref := &transaction.DataRef{Network: network, CID: cid, Size: flagDASize}
+	if flagDAHash != "" {
+		h, err := hex.DecodeString(strings.TrimPrefix(flagDAHash, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("--content-hash: %w", err)
+		}
+		ref.Hash = h
+	}
+	return ref, ref.Check()
+}
+
+func init() {
+	f := txDAAttestCmd.Flags()
+	f.Uint64Var(&flagDASize, "size", 0, "Payload size in bytes")
+	f.StringVar(&flagDAHash, "content-hash", "", "Hex SHA-256 hash of the payload")
+	f.Uint64Var(&flagDAUntil, "until", 0, "Block height the payload is retained until")
+	txDAAttestCmd.MarkFlagRequired("until")
+	txDACmd.AddCommand(txDAAttestCmd)
+	txCmd.AddCommand(txDACmd)
+	queryCmd.AddCommand(queryDACmd)
+}
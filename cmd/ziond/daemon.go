@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+var (
+	flagPIDFile       string
+	flagLogFile       string
+	flagLogMaxSize    int
+	flagLogMaxAge     int
+	flagLogMaxBackups int
+	flagLogCompress   bool
+)
+
+func init() {
+	f := startCmd.Flags()
+	f.StringVar(&flagPIDFile, "pid-file", "", "Write the process ID to this file while running")
+	f.StringVar(&flagLogFile, "log-file", "", "Write logs to this file instead of stderr, rotating it by size")
+	f.IntVar(&flagLogMaxSize, "log-max-size", 100, "Size in megabytes at which the log file is rotated")
+	f.IntVar(&flagLogMaxAge, "log-max-age", 0, "Days to keep rotated log files (0 keeps them regardless of age)")
+	f.IntVar(&flagLogMaxBackups, "log-max-backups", 0, "Number of rotated log files to keep (0 keeps all)")
+	f.BoolVar(&flagLogCompress, "log-compress", false, "Gzip rotated log files")
+}
+
+// buildLogger returns the logger described by config, writing to --log-file
+// with rotation when it is set.
+func buildLogger(config zap.Config) (*zap.Logger, error) {
+	if flagLogFile == "" {
+		return config.Build()
+	}
+	if err := os.MkdirAll(filepath.Dir(flagLogFile), 0o755); err != nil {
+		return nil, fmt.Errorf("--log-file: %w", err)
+	}
+	out := &lumberjack.Logger{
+		Filename:   flagLogFile,
+		MaxSize:    flagLogMaxSize,
+		MaxAge:     flagLogMaxAge,
+		MaxBackups: flagLogMaxBackups,
+		Compress:   flagLogCompress,
+	}
+	var enc zapcore.Encoder
+	if config.Encoding == "console" {
+		enc = zapcore.NewConsoleEncoder(config.EncoderConfig)
+	} else {
+		enc = zapcore.NewJSONEncoder(config.EncoderConfig)
+	}
+	core := zapcore.NewCore(enc, zapcore.AddSync(out), config.Level)
+	return zap.New(core, zap.AddCaller(), zap.AddStacktrace(zap.ErrorLevel)), nil
+}
+
+// writePIDFile writes the process ID to path, refusing to replace the file
+// of a process that is still running. The returned function removes it.
+func writePIDFile(path string) (func(), error) {
+	if data, err := os.ReadFile(path); err == nil {
+		if pid, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil && pid != os.Getpid() && processAlive(pid) {
+			return nil, fmt.Errorf("--pid-file: %s belongs to running process %d", path, pid)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("--pid-file: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("--pid-file: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644); err != nil {
+		return nil, fmt.Errorf("--pid-file: %w", err)
+	}
+	return func() { os.Remove(path) }, nil
+}
+
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return p.Signal(syscall.Signal(0)) == nil
+}
+
+// sdNotify sends state to the systemd notification socket. It does nothing
+// when the node is not run by systemd with Type=notify.
+func sdNotify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	// A leading '@' names a socket in the abstract namespace.
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// sdWatchdogInterval returns how often to send WATCHDOG=1, half the timeout
+// systemd asked for, or 0 when the watchdog is not enabled for this process.
+func sdWatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond / 2
+}
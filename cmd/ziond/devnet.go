@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zionlayer/zionlayer/core/common"
+	"github.com/zionlayer/zionlayer/core/crypto"
+	"github.com/zionlayer/zionlayer/core/genesis"
+	"github.com/zionlayer/zionlayer/core/keystore"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// Files written into the devnet directory.
+const (
+	devnetGenesisFile  = "genesis.json"
+	devnetMnemonicFile = "mnemonic.txt"
+	devnetComposeFile  = "docker-compose.yml"
+)
+
+// Devnet balances in base units: 1,000,000 ZIO per developer account and
+// the minimum validator stake for each validator.
+var (
+	devnetAccountBalance   = new(big.Int).Exp(big.NewInt(10), big.NewInt(24), nil)
+	devnetValidatorBalance = new(big.Int).Exp(big.NewInt(10), big.NewInt(22), nil)
+)
+
+var (
+	flagDevnetDir        string
+	flagDevnetValidators int
+	flagDevnetAccounts   int
+	flagDevnetChainID    uint64
+	flagDevnetRPCPort    int
+	flagDevnetP2PPort    int
+	flagDevnetBlockTime  time.Duration
+	flagDevnetCompose    bool
+	flagDevnetReset      bool
+)
+
+var devnetCmd = &cobra.Command{
+	Use:   "devnet",
+	Short: "Run a local multi-validator development network",
+	Long: `Generate a local network in --dir and run it.
+
+The first run writes a shared genesis, a mnemonic whose first accounts are
+prefunded for development (stored in <dir>/keystore as dev0, dev1, ... with
+an empty passphrase) and a ziond.toml per validator with its own data
+directory and ports. The command then runs one "ziond start" per validator
+until interrupted. Later runs reuse the directory; --reset regenerates it.
+
+With --compose a docker-compose.yml is written instead of starting nodes.`,
+	Args: cobra.NoArgs,
+	RunE: runDevnet,
+}
+
+func init() {
+	f := devnetCmd.Flags()
+	f.StringVar(&flagDevnetDir, "dir", "./devnet", "Devnet directory")
+	f.IntVar(&flagDevnetValidators, "validators", 3, "Number of validators")
+	f.IntVar(&flagDevnetAccounts, "accounts", 5, "Number of prefunded developer accounts")
+	f.Uint64Var(&flagDevnetChainID, "chain-id", transaction.DevnetChainID, "Chain ID")
+	f.IntVar(&flagDevnetRPCPort, "rpc-port", 8545, "JSON-RPC port of the first validator; the others count up")
+	f.IntVar(&flagDevnetP2PPort, "p2p-port", 9000, "P2P port of the first validator; the others count up")
+	f.DurationVar(&flagDevnetBlockTime, "block-time", time.Second, "Interval between blocks")
+	f.BoolVar(&flagDevnetCompose, "compose", false, "Write "+devnetComposeFile+" for the zionlayer:latest image instead of running")
+	f.BoolVar(&flagDevnetReset, "reset", false, "Delete and regenerate an existing devnet directory")
+	rootCmd.AddCommand(devnetCmd)
+}
+
+func runDevnet(cmd *cobra.Command, args []string) error {
+	if flagDevnetValidators < 1 || flagDevnetAccounts < 0 {
+		return errors.New("--validators must be at least 1 and --accounts not negative")
+	}
+	dir, err := filepath.Abs(flagDevnetDir)
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	if flagDevnetReset {
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, devnetGenesisFile)); errors.Is(err, os.ErrNotExist) {
+		if err := initDevnet(out, dir); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else {
+		fmt.Fprintf(out, "reusing devnet in %s (--reset to regenerate)\n", dir)
+	}
+	if flagDevnetCompose {
+		return nil
+	}
+	return superviseDevnet(out, dir)
+}
+
+// initDevnet writes the genesis, keys and per-validator configuration.
+func initDevnet(out io.Writer, dir string) error {
+	mnemonic, err := crypto.NewMnemonic()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	gen := &genesis.Genesis{ChainID: flagDevnetChainID, ChainName: "ZionLayer Local Devnet"}
+
+	// Developer accounts come first on the mnemonic, validators after them.
+	ks := keystore.New(filepath.Join(dir, keystore.Dir))
+	var devAddrs []string
+	for i := 0; i < flagDevnetAccounts; i++ {
+		priv, err := crypto.KeyFromMnemonic(mnemonic, "", uint32(i))
+		if err != nil {
+			return err
+		}
+		info, err := ks.Add(fmt.Sprintf("dev%d", i), priv, "")
+		if err != nil {
+			return err
+		}
+		devAddrs = append(devAddrs, info.Address)
+		gen.Accounts = append(gen.Accounts, genesis.Account{Address: addressOf(priv), Balance: devnetAccountBalance.String()})
+	}
+	validators := make([]string, flagDevnetValidators)
+	for i := range validators {
+		priv, err := crypto.KeyFromMnemonic(mnemonic, "", uint32(flagDevnetAccounts+i))
+		if err != nil {
+			return err
+		}
+		addr := addressOf(priv)
+		validators[i] = addr.String()
+		gen.Accounts = append(gen.Accounts, genesis.Account{Address: addr, Balance: devnetValidatorBalance.String()})
+	}
+	if err := gen.Validate(); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(gen, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, devnetGenesisFile), append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, devnetMnemonicFile), []byte(mnemonic+"\n"), 0o600); err != nil {
+		return err
+	}
+
+	// Compose services see their data directory as /data and the genesis
+	// at /genesis.json, and all listen on the default ports.
+	for i, v := range validators {
+		name := fmt.Sprintf("validator%d", i)
+		home := filepath.Join(dir, name)
+		if err := os.MkdirAll(home, 0o755); err != nil {
+			return err
+		}
+		cfg := devnetNodeConfig{
+			DataDir:   home,
+			Genesis:   filepath.Join(dir, devnetGenesisFile),
+			Validator: v,
+			RPCPort:   flagDevnetRPCPort + i,
+			P2PPort:   flagDevnetP2PPort + i,
+		}
+		if flagDevnetCompose {
+			cfg = devnetNodeConfig{DataDir: "/data", Genesis: "/genesis.json", Validator: v, RPCPort: 8545, P2PPort: 9000}
+		}
+		if err := os.WriteFile(filepath.Join(home, ConfigFile), []byte(cfg.toml()), 0o644); err != nil {
+			return err
+		}
+	}
+	if flagDevnetCompose {
+		if err := os.WriteFile(filepath.Join(dir, devnetComposeFile), []byte(devnetCompose(len(validators))), 0o644); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(out, "devnet written to %s (chain id %d)\n", dir, gen.ChainID)
+	fmt.Fprintf(out, "\nmnemonic (also in %s):\n%s\n\n", devnetMnemonicFile, mnemonic)
+	fmt.Fprintln(out, "developer accounts (keystore passphrase is empty):")
+	for i, a := range devAddrs {
+		fmt.Fprintf(out, "  dev%d  %s\n", i, a)
+	}
+	fmt.Fprintln(out)
+	for i, v := range validators {
+		port := flagDevnetRPCPort + i
+		fmt.Fprintf(out, "validator%d  %s  http://localhost:%d\n", i, v, port)
+	}
+	if flagDevnetCompose {
+		fmt.Fprintf(out, "\nstart with: docker compose -f %s up\n", filepath.Join(dir, devnetComposeFile))
+	}
+	return nil
+}
+
+func addressOf(priv crypto.PrivateKey) common.Address {
+	return crypto.PubkeyToAddress(priv.Public().(crypto.PublicKey))
+}
+
+// devnetNodeConfig is the part of ziond.toml that differs between devnet
+// validators.
+type devnetNodeConfig struct {
+	DataDir   string
+	Genesis   string
+	Validator string
+	RPCPort   int
+	P2PPort   int
+}
+
+func (c devnetNodeConfig) toml() string {
+	return fmt.Sprintf(`[data]
+dir = %q
+
+[genesis]
+file = %q
+
+[consensus]
+validator = %q
+block_time = %q
+
+[mempool]
+min_gas_price = "1"
+
+[rpc]
+port = %d
+cors_origins = ["*"]
+
+[grpc]
+port = 0
+
+[p2p]
+port = %d
+
+[log]
+level = "info"
+format = "console"
+`, c.DataDir, c.Genesis, c.Validator, flagDevnetBlockTime.String(), c.RPCPort, c.P2PPort)
+}
+
+func devnetCompose(n int) string {
+	var b strings.Builder
+	b.WriteString("services:\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, `  validator%[1]d:
+    image: zionlayer:latest
+    command: ["--config", "/data/%[2]s"]
+    ports:
+      - "%[3]d:8545"
+    volumes:
+      - ./validator%[1]d:/data
+      - ./%[4]s:/genesis.json:ro
+`, i, ConfigFile, flagDevnetRPCPort+i, devnetGenesisFile)
+	}
+	return b.String()
+}
+
+// superviseDevnet runs "ziond start" for every validator directory in dir,
+// prefixing their output with the validator name, until interrupted or a
+// node exits.
+func superviseDevnet(out io.Writer, dir string) error {
+	homes, err := filepath.Glob(filepath.Join(dir, "validator*", ConfigFile))
+	if err != nil {
+		return err
+	}
+	if len(homes) == 0 {
+		return fmt.Errorf("no validators in %s", dir)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	var (
+		outMu sync.Mutex
+		wg    sync.WaitGroup
+		procs []*exec.Cmd
+	)
+	exited := make(chan string, len(homes))
+	for _, cfg := range homes {
+		name := filepath.Base(filepath.Dir(cfg))
+		c := exec.Command(exe, "start", "--config", cfg)
+		pipe, err := c.StdoutPipe()
+		if err != nil {
+			return err
+		}
+		c.Stderr = c.Stdout
+		if err := c.Start(); err != nil {
+			stopDevnet(procs)
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		procs = append(procs, c)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sc := bufio.NewScanner(pipe)
+			for sc.Scan() {
+				outMu.Lock()
+				fmt.Fprintf(out, "%-11s %s\n", name, sc.Text())
+				outMu.Unlock()
+			}
+			c.Wait()
+			exited <- name
+		}()
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+	var failed string
+	select {
+	case <-quit:
+	case failed = <-exited:
+	}
+	stopDevnet(procs)
+	wg.Wait()
+	if failed != "" {
+		return fmt.Errorf("%s exited", failed)
+	}
+	return nil
+}
+
+func stopDevnet(procs []*exec.Cmd) {
+	for _, c := range procs {
+		c.Process.Signal(syscall.SIGTERM)
+	}
+}
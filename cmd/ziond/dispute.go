@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+var (
+	flagDisputeMethod   string
+	flagDisputeEvidence string
+	flagDisputeValid    bool
+	flagDisputeOutput   string
+)
+
+var txDisputeCmd = &cobra.Command{
+	Use:   "dispute",
+	Short: "Challenge inference receipts and arbitrate their disputes",
+}
+
+var txDisputeOpenCmd = &cobra.Command{
+	Use:   "open <receipt-tx-hash>",
+	Short: "Challenge an inference receipt within its challenge window, escrowing the dispute bond",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hash, err := parseHash32(args[0])
+		if err != nil {
+			return err
+		}
+		d := transaction.DisputeOpen{ReceiptHash: hash, Method: flagDisputeMethod, Evidence: flagDisputeEvidence}
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewDisputeOpenTx(from, d, nonce, gasPrice), nil
+		})
+	},
+}
+
+var txDisputeVoteCmd = &cobra.Command{
+	Use:   "vote <receipt-tx-hash>",
+	Short: "Vote on a dispute as a designated verifier",
+	Long: "Vote on a dispute as a designated verifier. Disputes arbitrated by re-execution\n" +
+		"take the hash of the output of the verifier's re-run (--output-hash) instead of --valid.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hash, err := parseHash32(args[0])
+		if err != nil {
+			return err
+		}
+		v := transaction.DisputeVote{ReceiptHash: hash, Valid: flagDisputeValid}
+		if flagDisputeOutput != "" {
+			if v.OutputHash, err = parseHash32(flagDisputeOutput); err != nil {
+				return fmt.Errorf("--output-hash: %w", err)
+			}
+		}
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewDisputeVoteTx(from, v, nonce, gasPrice), nil
+		})
+	},
+}
+
+var queryDisputeCmd = &cobra.Command{
+	Use:   "dispute <receipt-tx-hash>",
+	Short: "Show the dispute of an inference receipt and its votes",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_getDispute", []interface{}{args[0]}, func(w io.Writer, raw json.RawMessage) error {
+			var d state.Dispute
+			if err := json.Unmarshal(raw, &d); err != nil {
+				return err
+			}
+			var upheld, rejected int
+			for _, v := range d.Votes {
+				if v.Valid {
+					upheld++
+				} else {
+					rejected++
+				}
+			}
+			return printFields(w,
+				"receipt", fmt.Sprintf("0x%x", d.ReceiptHash),
+				"challenger", d.Challenger,
+				"method", d.Method,
+				"bond", d.Bond.String(),
+				"status", d.Status,
+				"opened", strconv.FormatUint(d.OpenedAt, 10),
+				"deadline", strconv.FormatUint(d.Deadline, 10),
+				"votes", fmt.Sprintf("%d valid, %d invalid of %d verifiers", upheld, rejected, len(d.Verifiers)),
+			)
+		})
+	},
+}
+
+// parseHash32 decodes a 0x-prefixed or bare hex 32-byte hash.
+func parseHash32(s string) ([]byte, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil || len(b) != 32 {
+		return nil, fmt.Errorf("invalid hash %q", s)
+	}
+	return b, nil
+}
+
+func init() {
+	of := txDisputeOpenCmd.Flags()
+	of.StringVar(&flagDisputeMethod, "method", transaction.ArbitrateVote, "Arbitration method: vote or reexecution")
+	of.StringVar(&flagDisputeEvidence, "evidence", "", "Evidence for the verifiers, e.g. a URI to a re-run")
+	vf := txDisputeVoteCmd.Flags()
+	vf.BoolVar(&flagDisputeValid, "valid", false, "Vote that the receipt is valid")
+	vf.StringVar(&flagDisputeOutput, "output-hash", "", "Output hash of the verifier's re-run")
+	txDisputeCmd.AddCommand(txDisputeOpenCmd, txDisputeVoteCmd)
+	txCmd.AddCommand(txDisputeCmd)
+	queryCmd.AddCommand(queryDisputeCmd)
+}
@@ -0,0 +1,6 @@
+//go:build postgres
+
+package main
+
+// Links the PostgreSQL driver of the SQL indexer (--indexer-driver postgres).
+import _ "github.com/lib/pq"
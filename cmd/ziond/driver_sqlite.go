@@ -0,0 +1,6 @@
+//go:build sqlite
+
+package main
+
+// Links the SQLite driver of the SQL indexer (--indexer-driver sqlite).
+import _ "modernc.org/sqlite"
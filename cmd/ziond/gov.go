@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+var (
+	flagGovDeposit string
+	flagGovStatus  string
+)
+
+var txGovCmd = &cobra.Command{
+	Use:   "gov",
+	Short: "Submit, fund and vote on governance proposals",
+}
+
+var txGovSubmitCmd = &cobra.Command{
+	Use:   "submit <proposal.json>",
+	Short: "Submit a parameter change, treasury spend or software upgrade proposal",
+	Long: "Submit a governance proposal read from a JSON file, for example\n\n" +
+		`  {"kind": "param_change", "title": "Raise the block gas limit",` + "\n" +
+		`   "changes": [{"subspace": "consensus", "value": {"blockGasLimit": 40000000}}]}` + "\n\n" +
+		"The --deposit is escrowed; voting starts once the deposits reach the minimum.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		raw, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		var p transaction.Proposal
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return fmt.Errorf("%s: %w", args[0], err)
+		}
+		if err := p.Check(); err != nil {
+			return err
+		}
+		deposit, err := parseAmount(flagGovDeposit)
+		if err != nil {
+			return fmt.Errorf("--deposit: %w", err)
+		}
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewGovSubmitProposalTx(from, p, deposit, nonce, gasPrice), nil
+		})
+	},
+}
+
+var txGovDepositCmd = &cobra.Command{
+	Use:   "deposit <proposal-id> <amount>",
+	Short: "Add to the deposit of a proposal in its deposit period",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid proposal id %q", args[0])
+		}
+		amount, err := parseAmount(args[1])
+		if err != nil {
+			return err
+		}
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewGovDepositTx(from, id, amount, nonce, gasPrice), nil
+		})
+	},
+}
+
+var txGovVoteCmd = &cobra.Command{
+	Use:   "vote <proposal-id> <yes|no|abstain|no_with_veto>",
+	Short: "Vote on a proposal in its voting period with your bonded stake",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid proposal id %q", args[0])
+		}
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewGovVoteTx(from, id, args[1], nonce, gasPrice), nil
+		})
+	},
+}
+
+var queryProposalCmd = &cobra.Command{
+	Use:   "proposal <id>",
+	Short: "Show a governance proposal, its deposits, votes and tally",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid proposal id %q", args[0])
+		}
+		return query(cmd, "zion_getProposal", []interface{}{id}, func(w io.Writer, raw json.RawMessage) error {
+			var p state.Proposal
+			if err := json.Unmarshal(raw, &p); err != nil {
+				return err
+			}
+			fields := []string{
+				"id", strconv.FormatUint(p.ID, 10),
+				"kind", p.Content.Kind,
+				"title", p.Content.Title,
+				"proposer", p.Proposer,
+				"status", p.Status,
+				"deposit", fmt.Sprintf("%s by %d depositors, until %d", p.TotalDeposit, len(p.Deposits), p.DepositEnd),
+				"votes", strconv.Itoa(len(p.Votes)),
+			}
+			if p.VotingEnd != 0 {
+				fields = append(fields, "voting", fmt.Sprintf("%d to %d", p.VotingStart, p.VotingEnd))
+			}
+			if t := p.Tally; t != nil {
+				fields = append(fields, "tally", fmt.Sprintf("yes %s, no %s, abstain %s, veto %s of %s bonded",
+					t.Yes, t.No, t.Abstain, t.NoWithVeto, t.TotalBonded))
+			}
+			if p.Error != "" {
+				fields = append(fields, "error", p.Error)
+			}
+			return printFields(w, fields...)
+		})
+	},
+}
+
+var queryProposalsCmd = &cobra.Command{
+	Use:   "proposals",
+	Short: "List governance proposals, newest first",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_getProposals", []interface{}{flagGovStatus}, func(w io.Writer, raw json.RawMessage) error {
+			var page struct {
+				Items []state.Proposal `json:"items"`
+			}
+			if err := json.Unmarshal(raw, &page); err != nil {
+				return err
+			}
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "ID\tKIND\tSTATUS\tDEPOSIT\tTITLE")
+			for _, p := range page.Items {
+				fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", p.ID, p.Content.Kind, p.Status, p.TotalDeposit, p.Content.Title)
+			}
+			return tw.Flush()
+		})
+	},
+}
+
+func init() {
+	txGovSubmitCmd.Flags().StringVar(&flagGovDeposit, "deposit", "0", "Initial deposit in base units")
+	queryProposalsCmd.Flags().StringVar(&flagGovStatus, "status", "", "Only proposals with this status, e.g. voting")
+	txGovCmd.AddCommand(txGovSubmitCmd, txGovDepositCmd, txGovVoteCmd)
+	txCmd.AddCommand(txGovCmd)
+	queryCmd.AddCommand(queryProposalCmd, queryProposalsCmd)
+}
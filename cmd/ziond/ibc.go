@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/rpc"
+)
+
+var (
+	flagIBCDenom   string
+	flagIBCTimeout uint64
+	flagIBCType    string
+	flagIBCNonce   uint64
+)
+
+var txIBCCmd = &cobra.Command{
+	Use:   "ibc",
+	Short: "Send tokens and agent messages to other chains over IBC channels",
+}
+
+var txIBCTransferCmd = &cobra.Command{
+	Use:   "transfer <channel> <receiver> <amount>",
+	Short: "Send tokens over a channel of the transfer port",
+	Long: "Send tokens over a channel of the transfer port. ZIO is escrowed until it\n" +
+		"returns; vouchers received over IBC (--denom port/channel/...) are burned.\n" +
+		"The transfer is refunded if it fails on the counterparty or reaches it at\n" +
+		"or after --timeout-height.",
+	Args: cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		amount, err := parseAmount(args[2])
+		if err != nil {
+			return err
+		}
+		t := transaction.IBCTransfer{ChannelID: args[0], Denom: flagIBCDenom, Amount: amount, Receiver: args[1], TimeoutHeight: flagIBCTimeout}
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewIBCTx(transaction.TxIBCTransfer, from, t, nonce, gasPrice), nil
+		})
+	},
+}
+
+var txIBCMessageCmd = &cobra.Command{
+	Use:   "message <channel> <from-did> <to-did> <payload>",
+	Short: "Send an agent message to an agent of another chain over a channel of the agent port",
+	Args:  cobra.ExactArgs(4),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m := transaction.IBCSendMessage{
+			ChannelID: args[0],
+			Message: transaction.AgentMessage{
+				From:    args[1],
+				To:      args[2],
+				Type:    transaction.MessageType(flagIBCType),
+				Payload: []byte(args[3]),
+				Nonce:   flagIBCNonce,
+			},
+			TimeoutHeight: flagIBCTimeout,
+		}
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewIBCTx(transaction.TxIBCSendMessage, from, m, nonce, gasPrice), nil
+		})
+	},
+}
+
+var queryIBCCmd = &cobra.Command{
+	Use:   "ibc",
+	Short: "Query IBC channels, packets and vouchers",
+}
+
+var queryIBCChannelsCmd = &cobra.Command{
+	Use:   "channels",
+	Short: "List the IBC channel ends",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_getIBCChannels", nil, func(w io.Writer, raw json.RawMessage) error {
+			var chans []state.IBCChannel
+			if err := json.Unmarshal(raw, &chans); err != nil {
+				return err
+			}
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "PORT\tCHANNEL\tSTATE\tCONNECTION\tCOUNTERPARTY\tSENT\tESCROWED")
+			for _, ch := range chans {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s/%s\t%d\t%s\n", ch.Port, ch.ID, ch.State, ch.ConnectionID,
+					ch.Counterparty.Port, ch.Counterparty.ChannelID, ch.NextSequence-1, ch.Escrowed)
+			}
+			return tw.Flush()
+		})
+	},
+}
+
+var queryIBCPacketsCmd = &cobra.Command{
+	Use:   "packets <port> <channel>",
+	Short: "List the packets of a channel awaiting acknowledgement and those received",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_getIBCPackets", []interface{}{args[0], args[1]}, func(w io.Writer, raw json.RawMessage) error {
+			var res rpc.IBCPacketsResult
+			if err := json.Unmarshal(raw, &res); err != nil {
+				return err
+			}
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "DIRECTION\tSEQUENCE\tTIMEOUT\tACKNOWLEDGEMENT")
+			for _, p := range res.Pending {
+				fmt.Fprintf(tw, "sent\t%d\t%d\tpending\n", p.Sequence, p.TimeoutHeight)
+			}
+			for _, a := range res.Acknowledgements {
+				ack := "ok"
+				if a.Acknowledgement.Error != "" {
+					ack = "error: " + a.Acknowledgement.Error
+				}
+				fmt.Fprintf(tw, "received\t%d\t%d\t%s\n", a.Packet.Sequence, a.Packet.TimeoutHeight, ack)
+			}
+			return tw.Flush()
+		})
+	},
+}
+
+var queryIBCVouchersCmd = &cobra.Command{
+	Use:   "vouchers <address>",
+	Short: "Show the balances of tokens an address received over IBC",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_getIBCVouchers", []interface{}{args[0]}, func(w io.Writer, raw json.RawMessage) error {
+			var bals map[string]string
+			if err := json.Unmarshal(raw, &bals); err != nil {
+				return err
+			}
+			return printBalances(w, "DENOM", "BALANCE", bals)
+		})
+	},
+}
+
+func init() {
+	txIBCTransferCmd.Flags().StringVar(&flagIBCDenom, "denom", transaction.IBCNativeDenom, "Denomination to send: zio or a voucher")
+	txIBCMessageCmd.Flags().StringVar(&flagIBCType, "type", string(transaction.MsgTask), "Message type: TASK or RESULT")
+	txIBCMessageCmd.Flags().Uint64Var(&flagIBCNonce, "message-nonce", 0, "Message nonce of the sending agent")
+	for _, c := range []*cobra.Command{txIBCTransferCmd, txIBCMessageCmd} {
+		c.Flags().Uint64Var(&flagIBCTimeout, "timeout-height", 0, "Counterparty height from which the packet times out (0 for none)")
+	}
+	txIBCCmd.AddCommand(txIBCTransferCmd, txIBCMessageCmd)
+	txCmd.AddCommand(txIBCCmd)
+	queryIBCCmd.AddCommand(queryIBCChannelsCmd, queryIBCPacketsCmd, queryIBCVouchersCmd)
+	queryCmd.AddCommand(queryIBCCmd)
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/zionlayer/zionlayer/core/crypto"
+	"github.com/zionlayer/zionlayer/core/keystore"
+	"golang.org/x/term"
+)
+
+// passphraseEnv holds the keystore passphrase for non-interactive use.
+const passphraseEnv = envPrefix + "_KEYRING_PASSPHRASE"
+
+var (
+	flagPassphraseFile string
+	flagNoMnemonic     bool
+	flagImportMnemonic bool
+	flagAccount        uint32
+	flagShowPubKey     bool
+	flagUnsafeExport   bool
+)
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manage the keys in the data directory keystore",
+	Long: "Manage named ed25519 keys stored in <data-dir>/" + keystore.Dir + ", encrypted with a passphrase.\n" +
+		"The passphrase is read from --passphrase-file, $" + passphraseEnv + " or the terminal.",
+}
+
+var keysNewCmd = &cobra.Command{
+	Use:   "new <name>",
+	Short: "Create a key, printing its recovery mnemonic",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runKeysNew,
+}
+
+var keysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List stored keys and their addresses",
+	Args:  cobra.NoArgs,
+	RunE:  runKeysList,
+}
+
+var keysShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Print the address of a key",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runKeysShow,
+}
+
+var keysImportCmd = &cobra.Command{
+	Use:   "import <name>",
+	Short: "Import a hex private key or, with --mnemonic, a BIP-39 mnemonic read from stdin",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runKeysImport,
+}
+
+var keysExportCmd = &cobra.Command{
+	Use:   "export <name>",
+	Short: "Print the hex private key of a key",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runKeysExport,
+}
+
+var keysDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a key",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runKeysDelete,
+}
+
+func init() {
+	keysCmd.PersistentFlags().StringVar(&flagPassphraseFile, "passphrase-file", "", "File holding the keystore passphrase")
+	keysNewCmd.Flags().BoolVar(&flagNoMnemonic, "no-mnemonic", false, "Generate a random key without a recovery mnemonic")
+	keysNewCmd.Flags().Uint32Var(&flagAccount, "account", 0, "HD account index derived from the mnemonic")
+	keysImportCmd.Flags().BoolVar(&flagImportMnemonic, "mnemonic", false, "Import from a BIP-39 mnemonic instead of a private key")
+	keysImportCmd.Flags().Uint32Var(&flagAccount, "account", 0, "HD account index derived from the mnemonic")
+	keysShowCmd.Flags().BoolVar(&flagShowPubKey, "pubkey", false, "Also print the public key")
+	keysExportCmd.Flags().BoolVar(&flagUnsafeExport, "unsafe", false, "Confirm printing the unencrypted private key")
+	keysCmd.AddCommand(keysNewCmd, keysListCmd, keysShowCmd, keysImportCmd, keysExportCmd, keysDeleteCmd)
+	rootCmd.AddCommand(keysCmd)
+}
+
+func openKeystore() *keystore.Keystore {
+	return keystore.New(filepath.Join(flagDataDir, keystore.Dir))
+}
+
+func runKeysNew(cmd *cobra.Command, args []string) error {
+	var (
+		priv     crypto.PrivateKey
+		mnemonic string
+		err      error
+	)
+	if flagNoMnemonic {
+		priv, err = crypto.GenerateKey()
+	} else if mnemonic, err = crypto.NewMnemonic(); err == nil {
+		priv, err = crypto.KeyFromMnemonic(mnemonic, "", flagAccount)
+	}
+	if err != nil {
+		return err
+	}
+	pass, err := readPassphrase(cmd, true)
+	if err != nil {
+		return err
+	}
+	info, err := openKeystore().Add(args[0], priv, pass)
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "%s\t%s\n", info.Name, info.Address)
+	if mnemonic != "" {
+		fmt.Fprintln(out, "\nWrite down this mnemonic; it is the only way to recover the key:")
+		fmt.Fprintln(out, mnemonic)
+	}
+	return nil
+}
+
+func runKeysList(cmd *cobra.Command, args []string) error {
+	keys, err := openKeystore().List()
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tADDRESS")
+	for _, k := range keys {
+		fmt.Fprintf(tw, "%s\t%s\n", k.Name, k.Address)
+	}
+	return tw.Flush()
+}
+
+func runKeysShow(cmd *cobra.Command, args []string) error {
+	info, err := openKeystore().Get(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), info.Address)
+	if flagShowPubKey {
+		fmt.Fprintf(cmd.OutOrStdout(), "0x%x\n", info.PubKey)
+	}
+	return nil
+}
+
+func runKeysImport(cmd *cobra.Command, args []string) error {
+	var priv crypto.PrivateKey
+	if flagImportMnemonic {
+		words, err := readSecret(cmd, "Mnemonic: ")
+		if err != nil {
+			return err
+		}
+		if priv, err = crypto.KeyFromMnemonic(strings.Join(strings.Fields(words), " "), "", flagAccount); err != nil {
+			return err
+		}
+	} else {
+		secret, err := readSecret(cmd, "Private key (hex): ")
+		if err != nil {
+			return err
+		}
+		if priv, err = parsePrivateKey(secret); err != nil {
+			return err
+		}
+	}
+	pass, err := readPassphrase(cmd, true)
+	if err != nil {
+		return err
+	}
+	info, err := openKeystore().Add(args[0], priv, pass)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\n", info.Name, info.Address)
+	return nil
+}
+
+func runKeysExport(cmd *cobra.Command, args []string) error {
+	if !flagUnsafeExport {
+		return errors.New("export prints the unencrypted private key; pass --unsafe to confirm")
+	}
+	pass, err := readPassphrase(cmd, false)
+	if err != nil {
+		return err
+	}
+	priv, err := openKeystore().Unlock(args[0], pass)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "0x%x\n", priv.Seed())
+	return nil
+}
+
+func runKeysDelete(cmd *cobra.Command, args []string) error {
+	pass, err := readPassphrase(cmd, false)
+	if err != nil {
+		return err
+	}
+	if err := openKeystore().Delete(args[0], pass); err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), "deleted", args[0])
+	return nil
+}
+
+// parsePrivateKey accepts a hex ed25519 seed (32 bytes) or full private
+// key (64 bytes).
+func parsePrivateKey(s string) (crypto.PrivateKey, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(s), "0x"))
+	if err != nil {
+		return nil, crypto.ErrInvalidPrivateKey
+	}
+	switch len(b) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(b), nil
+	case crypto.PrivateKeySize:
+		priv := ed25519.NewKeyFromSeed(b[:ed25519.SeedSize])
+		if !priv.Equal(ed25519.PrivateKey(b)) {
+			return nil, crypto.ErrInvalidPrivateKey
+		}
+		return priv, nil
+	}
+	return nil, crypto.ErrInvalidPrivateKey
+}
+
+// stdin is shared so that a secret and a passphrase piped on consecutive
+// lines are both read.
+var stdin = bufio.NewReader(os.Stdin)
+
+// readPassphrase returns the keystore passphrase from --passphrase-file,
+// the environment or the terminal. New passphrases typed at the terminal
+// are asked for twice.
+func readPassphrase(cmd *cobra.Command, confirm bool) (string, error) {
+	if flagPassphraseFile != "" {
+		raw, err := os.ReadFile(flagPassphraseFile)
+		if err != nil {
+			return "", fmt.Errorf("--passphrase-file: %w", err)
+		}
+		return strings.TrimRight(string(raw), "\r\n"), nil
+	}
+	if pass, ok := os.LookupEnv(passphraseEnv); ok {
+		return pass, nil
+	}
+	pass, err := readSecret(cmd, "Passphrase: ")
+	if err != nil || !confirm || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return pass, err
+	}
+	again, err := readSecret(cmd, "Repeat passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if pass != again {
+		return "", errors.New("passphrases do not match")
+	}
+	return pass, nil
+}
+
+// readSecret reads a line from the terminal without echo, or from stdin
+// when it is not a terminal.
+func readSecret(cmd *cobra.Command, prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprint(cmd.ErrOrStderr(), prompt)
+		b, err := term.ReadPassword(fd)
+		fmt.Fprintln(cmd.ErrOrStderr())
+		return string(b), err
+	}
+	line, err := stdin.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
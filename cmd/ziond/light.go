@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zionlayer/zionlayer/consensus"
+	"github.com/zionlayer/zionlayer/light"
+	"github.com/zionlayer/zionlayer/rpc"
+	"go.uber.org/zap"
+)
+
+var (
+	flagLightPrimary       string
+	flagLightAPIKey        string
+	flagLightListen        string
+	flagLightTrustedHeight uint64
+	flagLightTrustedHash   string
+	flagLightValidators    string
+	flagLightInterval      time.Duration
+)
+
+var lightCmd = &cobra.Command{
+	Use:   "light",
+	Short: "Run a light node serving verified headers and agent state proofs",
+	Long: `Run a light node following a full node (--primary) from a trusted header.
+
+The light node verifies every header after --trusted-height against the
+commit of the validator set, read from --validators (a saved result of
+zion_getValidators from a node you trust) or, trusting on first use, from
+the primary. Without --trusted-hash the header at --trusted-height is trusted
+on first use as well.
+
+It serves JSON-RPC on --listen:
+  light_status()              latest verified height, hash and validator set
+  light_getHeader(height)     a verified header
+  light_getAgentProof(key)    an agent state entry proven against a verified header`,
+	Args: cobra.NoArgs,
+	RunE: runLight,
+}
+
+func init() {
+	f := lightCmd.Flags()
+	f.StringVar(&flagLightPrimary, "primary", "http://localhost:8545", "JSON-RPC endpoint of the full node to follow")
+	f.StringVar(&flagLightAPIKey, "primary-api-key", "", "API key for the primary")
+	f.StringVar(&flagLightListen, "listen", "127.0.0.1:8645", "Address to serve JSON-RPC on")
+	f.Uint64Var(&flagLightTrustedHeight, "trusted-height", 1, "Height of the trusted header")
+	f.StringVar(&flagLightTrustedHash, "trusted-hash", "", "Hash of the trusted header")
+	f.StringVar(&flagLightValidators, "validators", "", "JSON file of the trusted validator set")
+	f.DurationVar(&flagLightInterval, "sync-interval", consensus.BlockTime, "Interval between header syncs")
+	rootCmd.AddCommand(lightCmd)
+}
+
+func runLight(cmd *cobra.Command, args []string) error {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		return err
+	}
+	defer logger.Sync()
+	ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	primary := light.NewRPCProvider(flagLightPrimary, flagLightAPIKey)
+	trusted, err := primary.SignedHeader(ctx, flagLightTrustedHeight)
+	if err != nil {
+		return fmt.Errorf("trusted header: %w", err)
+	}
+	hash := fmt.Sprintf("0x%x", trusted.Hash())
+	switch {
+	case flagLightTrustedHash == "":
+		logger.Warn("trusting the header of the primary on first use", zap.Uint64("height", flagLightTrustedHeight), zap.String("hash", hash))
+	case flagLightTrustedHash != hash:
+		return fmt.Errorf("--trusted-hash: primary has %s at height %d", hash, flagLightTrustedHeight)
+	}
+	var vals *light.ValidatorSet
+	if flagLightValidators != "" {
+		raw, err := os.ReadFile(flagLightValidators)
+		if err != nil {
+			return fmt.Errorf("--validators: %w", err)
+		}
+		var list []light.Validator
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return fmt.Errorf("--validators: %w", err)
+		}
+		if vals, err = light.NewValidatorSet(list); err != nil {
+			return fmt.Errorf("--validators: %w", err)
+		}
+	} else {
+		if vals, err = primary.ValidatorSet(ctx); err != nil {
+			return fmt.Errorf("validator set: %w", err)
+		}
+		if vals == nil {
+			return errors.New("the primary reports no validators; set --validators")
+		}
+		logger.Warn("trusting the validator set of the primary on first use", zap.Int("validators", len(vals.Validators)))
+	}
+	client, err := light.NewClient(light.TrustOptions{Header: trusted.Header, Validators: vals})
+	if err != nil {
+		return err
+	}
+
+	ln := &lightNode{client: client, primary: primary, logger: logger}
+	srv := &http.Server{Addr: flagLightListen, Handler: ln, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		ticker := time.NewTicker(flagLightInterval)
+		defer ticker.Stop()
+		for {
+			if height, err := client.Sync(ctx, primary); err != nil && ctx.Err() == nil {
+				logger.Warn("header sync failed", zap.Uint64("height", height), zap.Error(err))
+			}
+			select {
+			case <-ctx.Done():
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				srv.Shutdown(shutdownCtx)
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	logger.Info("light node serving", zap.String("listen", flagLightListen), zap.String("primary", flagLightPrimary))
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// lightNode serves the JSON-RPC API of the light node.
+type lightNode struct {
+	client  *light.Client
+	primary *light.RPCProvider
+	logger  *zap.Logger
+}
+
+// lightHeader is a verified header as served by the light node.
+type lightHeader struct {
+	rpc.HeaderResult
+	AgentRoot string `json:"agentRoot"`
+}
+
+func (ln *lightNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req rpc.Request
+	resp := rpc.Response{JSONRPC: "2.0"}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		resp.Error = &rpc.RPCError{Code: rpc.CodeParseError, Message: "parse error"}
+	} else {
+		resp.ID = req.ID
+		resp.Result, resp.Error = ln.dispatch(r.Context(), req)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (ln *lightNode) dispatch(ctx context.Context, req rpc.Request) (interface{}, *rpc.RPCError) {
+	switch req.Method {
+	case "light_status":
+		h := ln.client.LatestHeader()
+		return map[string]interface{}{
+			"height":     h.Height,
+			"hash":       fmt.Sprintf("0x%x", (&light.SignedHeader{Header: h}).Hash()),
+			"validators": ln.client.Validators().Validators,
+		}, nil
+	case "light_getHeader":
+		var args []uint64
+		if err := json.Unmarshal(req.Params, &args); err != nil || len(args) == 0 {
+			return nil, &rpc.RPCError{Code: rpc.CodeInvalidParams, Message: "invalid params"}
+		}
+		h, err := ln.client.Header(args[0])
+		if err != nil {
+			return nil, &rpc.RPCError{Code: rpc.CodeNotFound, Message: err.Error()}
+		}
+		raw, _ := h.MarshalBinary()
+		return lightHeader{
+			HeaderResult: rpc.HeaderResult{Hash: fmt.Sprintf("0x%x", (&light.SignedHeader{Header: h}).Hash()), Height: h.Height, Header: fmt.Sprintf("0x%x", raw)},
+			AgentRoot:    fmt.Sprintf("0x%x", h.AgentRoot),
+		}, nil
+	case "light_getAgentProof":
+		var args []string
+		if err := json.Unmarshal(req.Params, &args); err != nil || len(args) == 0 {
+			return nil, &rpc.RPCError{Code: rpc.CodeInvalidParams, Message: "invalid params"}
+		}
+		return ln.agentProof(ctx, args[0])
+	}
+	return nil, &rpc.RPCError{Code: rpc.CodeMethodNotFound, Message: "method not found: " + req.Method}
+}
+
+// agentProof fetches the proof of key from the primary and verifies it
+// against the trusted headers. The root of the current state is committed
+// by the next block, so it waits for a few headers before giving up.
+func (ln *lightNode) agentProof(ctx context.Context, key string) (interface{}, *rpc.RPCError) {
+	proof, err := ln.primary.AgentProof(ctx, key)
+	if err != nil {
+		return nil, &rpc.RPCError{Code: rpc.CodeServerError, Message: err.Error()}
+	}
+	for attempt := 0; ; attempt++ {
+		height, err := ln.client.VerifyAgentProof(proof)
+		if err == nil {
+			return map[string]interface{}{"height": height, "key": proof.Key, "value": proof.Value}, nil
+		}
+		if attempt == 3 {
+			return nil, &rpc.RPCError{Code: rpc.CodeServerError, Message: err.Error()}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, &rpc.RPCError{Code: rpc.CodeServerError, Message: ctx.Err().Error()}
+		case <-time.After(flagLightInterval):
+		}
+		if _, err := ln.client.Sync(ctx, ln.primary); err != nil {
+			ln.logger.Warn("header sync failed", zap.Error(err))
+		}
+	}
+}
@@ -1,22 +1,33 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"math/big"
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/zionlayer/zionlayer/consensus"
-	"github.com/zionlayer/zionlayer/core/mempool"
-	"github.com/zionlayer/zionlayer/core/state"
-	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/core/common"
+	"github.com/zionlayer/zionlayer/core/crypto"
+	"github.com/zionlayer/zionlayer/core/genesis"
+	"github.com/zionlayer/zionlayer/indexer"
+	"github.com/zionlayer/zionlayer/node"
 	"github.com/zionlayer/zionlayer/rpc"
+	"github.com/zionlayer/zionlayer/rpc/grpcapi"
+	"github.com/zionlayer/zionlayer/telemetry"
+	"github.com/zionlayer/zionlayer/version"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "ziond",
+	Use:     "ziond",
+	Version: version.Get().String(),
 	Short: "ZionLayer Node",
 	Long:  "ZionLayer — The General-Purpose AI-Native Layer 1 Blockchain",
 }
@@ -27,81 +38,303 @@ var startCmd = &cobra.Command{
 	RunE:  runNode,
 }
 
+// devnetValidatorAddr is the proposer used when --validator is not set.
+const devnetValidatorAddr = "0x72feFB990879f4C28591cDAAEddB4cb485559974"
+
+// shutdownTimeout bounds the graceful shutdown after a signal.
+const shutdownTimeout = 10 * time.Second
+
 var (
 	flagRPCPort       int
+	flagGRPCPort      int
 	flagValidatorAddr string
+	flagValidatorKey  string
 	flagDataDir       string
+	flagGenesis       string
+	flagMinGasPrice   string
+	flagTxRate        float64
+	flagTxBurst       int
+	flagRPCAddr       string
+	flagReqRate       float64
+	flagReqBurst      int
+	flagCORSOrigins   []string
+	flagRPCAPIKeys    []string
+	flagJWTSecret     string
+	flagAdminAddr     string
+	flagAdminPort     int
+	flagRESTPort      int
+	flagTLSCert       string
+	flagTLSKey        string
+	flagProxies       []string
+	flagP2PPort       int
+	flagMaxPeers      int
+	flagLogLevel      string
+	flagLogFormat     string
+	flagKeepBlocks    uint64
+	flagIPFSEndpoint  string
+	flagIndexerDriver string
+	flagIndexerDSN    string
+	flagBlockTime     time.Duration
+	flagOTLPEndpoint  string
+	flagOTLPInsecure  bool
+	flagTraceRatio    float64
+	flagReadyAge      time.Duration
+	flagReadyPeers    int
 )
 
 func init() {
 	startCmd.Flags().IntVar(&flagRPCPort, "rpc-port", 8545, "JSON-RPC port")
+	startCmd.Flags().IntVar(&flagGRPCPort, "grpc-port", 9090, "gRPC port (0 disables)")
 	startCmd.Flags().StringVar(&flagValidatorAddr, "validator", "", "Validator address")
-	startCmd.Flags().StringVar(&flagDataDir, "data-dir", "./data", "Data directory")
+	startCmd.Flags().StringVar(&flagValidatorKey, "validator-key", "", "Keystore key signing the proposed blocks; defaults --validator to its address")
+	startCmd.Flags().StringVar(&flagPassphraseFile, "passphrase-file", "", "File holding the passphrase of --validator-key")
+	startCmd.Flags().StringVar(&flagGenesis, "genesis", "", "Genesis file (JSON); defaults to the built-in devnet genesis")
+	startCmd.Flags().StringVar(&flagMinGasPrice, "min-gas-price", "1", "Minimum gas price accepted into the mempool")
+	startCmd.Flags().Float64Var(&flagTxRate, "rpc-tx-rate", 10, "Transactions per second accepted from one client IP (0 disables)")
+	startCmd.Flags().IntVar(&flagTxBurst, "rpc-tx-burst", 50, "Burst of transactions accepted from one client IP")
+	startCmd.Flags().Float64Var(&flagReqRate, "rpc-rate", 50, "Requests per second accepted from one client IP (0 disables)")
+	startCmd.Flags().IntVar(&flagReqBurst, "rpc-burst", 100, "Burst of requests accepted from one client IP")
+	startCmd.Flags().StringSliceVar(&flagCORSOrigins, "rpc-cors", nil, "Browser origins allowed to call the JSON-RPC (comma separated, * for any)")
+	startCmd.Flags().StringVar(&flagRPCAddr, "rpc-addr", "", "JSON-RPC listen address (empty for all interfaces)")
+	startCmd.Flags().StringSliceVar(&flagRPCAPIKeys, "rpc-api-keys", nil, "API keys required by the public JSON-RPC (comma separated)")
+	startCmd.Flags().StringVar(&flagJWTSecret, "rpc-jwt-secret", "", "File holding the HS256 secret for JWT bearer tokens")
+	startCmd.Flags().StringVar(&flagAdminAddr, "admin-rpc-addr", "127.0.0.1", "Admin JSON-RPC listen address")
+	startCmd.Flags().IntVar(&flagAdminPort, "admin-rpc-port", 0, "Admin JSON-RPC port (0 disables)")
+	startCmd.Flags().StringVar(&flagTLSCert, "rpc-tls-cert", "", "TLS certificate file; serves JSON-RPC and REST over HTTPS")
+	startCmd.Flags().StringVar(&flagTLSKey, "rpc-tls-key", "", "TLS private key file")
+	startCmd.Flags().StringSliceVar(&flagProxies, "rpc-trusted-proxies", nil, "Reverse proxy IPs/CIDRs whose X-Forwarded-For is trusted")
+	startCmd.Flags().DurationVar(&flagReadyAge, "ready-max-block-age", 30*time.Second, "Age of the latest block beyond which /readyz fails (0 disables)")
+	startCmd.Flags().IntVar(&flagReadyPeers, "ready-min-peers", 1, "Peers /readyz requires when P2P networking is enabled")
+	startCmd.Flags().IntVar(&flagRESTPort, "rest-port", 0, "REST gateway port (0 disables)")
+	startCmd.Flags().IntVar(&flagP2PPort, "p2p-port", 9000, "P2P listen port")
+	startCmd.Flags().IntVar(&flagMaxPeers, "p2p-max-peers", 50, "Maximum number of P2P peers")
+	startCmd.Flags().StringVar(&flagLogLevel, "log-level", "info", "Log level (debug, info, warn, error)")
+	startCmd.Flags().StringVar(&flagLogFormat, "log-format", "json", "Log format (json or console)")
+	startCmd.Flags().DurationVar(&flagBlockTime, "block-time", consensus.BlockTime, "Interval between proposed blocks")
+	startCmd.Flags().StringVar(&flagOTLPEndpoint, "otlp-endpoint", "", "OTLP/gRPC collector address for traces, e.g. localhost:4317 (empty disables tracing)")
+	startCmd.Flags().BoolVar(&flagOTLPInsecure, "otlp-insecure", false, "Connect to the OTLP collector without TLS")
+	startCmd.Flags().Float64Var(&flagTraceRatio, "trace-sample-ratio", 1, "Fraction of transaction traces recorded")
+	startCmd.Flags().Uint64Var(&flagKeepBlocks, "pruning-keep-blocks", 0, "Number of recent blocks kept in the index (0 keeps all)")
+	startCmd.Flags().StringVar(&flagIPFSEndpoint, "ipfs-endpoint", "", "IPFS HTTP API pinning payloads referenced on chain, e.g. http://localhost:5001 (empty disables)")
+	startCmd.Flags().StringVar(&flagIndexerDriver, "indexer-driver", "", "SQL database indexing finalized blocks: sqlite or postgres (empty disables)")
+	startCmd.Flags().StringVar(&flagIndexerDSN, "indexer-dsn", "", "Data source of the SQL indexer, e.g. data/index.db or postgres://user@host/zion")
 	rootCmd.AddCommand(startCmd)
 }
 
 func runNode(cmd *cobra.Command, args []string) error {
-	logger, _ := zap.NewProduction()
+	configFile, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+	logConfig := zap.NewProductionConfig()
+	if logConfig.Level, err = zap.ParseAtomicLevel(flagLogLevel); err != nil {
+		return fmt.Errorf("--log-level: %w", err)
+	}
+	switch flagLogFormat {
+	case "json", "console":
+		logConfig.Encoding = flagLogFormat
+	default:
+		return fmt.Errorf("--log-format: invalid value %q", flagLogFormat)
+	}
+	logger, err := buildLogger(logConfig)
+	if err != nil {
+		return err
+	}
 	defer logger.Sync()
+	if flagPIDFile != "" {
+		removePIDFile, err := writePIDFile(flagPIDFile)
+		if err != nil {
+			return err
+		}
+		defer removePIDFile()
+	}
 
 	logger.Info("⛓️  ZionLayer starting",
-		zap.String("version", "0.1.0"),
+		zap.String("version", version.Get().String()),
+		zap.String("config", configFile),
 		zap.Int("rpc-port", flagRPCPort),
+		zap.Int("p2p-port", flagP2PPort),
+		zap.Int("p2p-max-peers", flagMaxPeers),
 	)
 
-	// Initialize components
-	stateDB := state.NewStateDB()
-	pool := mempool.NewPool()
-	engine := consensus.NewZionBFT(stateDB, logger)
-
-	// Start consensus (tx feed channel)
-	txFeed := make(chan []*transaction.Tx, 10)
-	validatorAddr := flagValidatorAddr
-	if validatorAddr == "" {
-		validatorAddr = "0xDevnetValidator0000000000000000000000001"
-	}
-	engine.Start(validatorAddr, txFeed)
-
-	// Feed mempool batches to consensus
-	go func() {
-		for {
-			batch := pool.Pop(100)
-			if len(batch) > 0 {
-				txFeed <- batch
-			}
+	traceConfig := telemetry.DefaultConfig()
+	traceConfig.Endpoint = flagOTLPEndpoint
+	traceConfig.Insecure = flagOTLPInsecure
+	traceConfig.SampleRatio = flagTraceRatio
+	shutdownTracing, err := telemetry.Setup(context.Background(), traceConfig)
+	if err != nil {
+		return fmt.Errorf("tracing: %w", err)
+	}
+	if flagOTLPEndpoint != "" {
+		logger.Info("tracing enabled", zap.String("otlp-endpoint", flagOTLPEndpoint), zap.Float64("sample-ratio", flagTraceRatio))
+	}
+
+	gen := genesis.Devnet()
+	if flagGenesis != "" {
+		if gen, err = genesis.Load(flagGenesis); err != nil {
+			return err
+		}
+	}
+	logger.Info("genesis loaded", zap.Uint64("chain-id", gen.ChainID), zap.String("chain", gen.ChainName))
+
+	nodeConfig := node.DefaultConfig()
+	nodeConfig.DataDir = flagDataDir
+	nodeConfig.Validator = flagValidatorAddr
+	if flagValidatorKey != "" {
+		pass, err := readPassphrase(cmd, false)
+		if err != nil {
+			return err
+		}
+		priv, err := openKeystore().Unlock(flagValidatorKey, pass)
+		if err != nil {
+			return fmt.Errorf("--validator-key: %w", err)
 		}
-	}()
-
-	// Log finalized blocks
-	go func() {
-		for b := range engine.Blocks() {
-			logger.Info("✅ block finalized",
-				zap.Uint64("height", b.Header.Height),
-				zap.Int("txs", len(b.Txs)),
-			)
+		nodeConfig.ValidatorKey = priv
+		if nodeConfig.Validator == "" {
+			nodeConfig.Validator = crypto.PubkeyToAddress(priv.Public().(crypto.PublicKey)).String()
 		}
-	}()
+	}
+	if nodeConfig.Validator == "" {
+		nodeConfig.Validator = devnetValidatorAddr
+	}
+	addr, err := common.ParseAddress(nodeConfig.Validator)
+	if err != nil {
+		return fmt.Errorf("--validator: %w", err)
+	}
+	nodeConfig.Validator = addr.String()
+	if flagBlockTime <= 0 {
+		return fmt.Errorf("--block-time: invalid value %s", flagBlockTime)
+	}
+	nodeConfig.BlockTime = flagBlockTime
+	minGasPrice, ok := new(big.Int).SetString(flagMinGasPrice, 10)
+	if !ok || minGasPrice.Sign() < 0 {
+		return fmt.Errorf("--min-gas-price: invalid value %q", flagMinGasPrice)
+	}
+	nodeConfig.Mempool.MinGasPrice = minGasPrice
+	nodeConfig.KeepBlocks = flagKeepBlocks
+	nodeConfig.IPFSEndpoint = flagIPFSEndpoint
+	nodeConfig.Indexer = indexer.Config{Driver: flagIndexerDriver, DSN: flagIndexerDSN}
+	n, err := node.New(nodeConfig, gen, logger)
+	if err != nil {
+		return err
+	}
+	for name, handler := range upgradeHandlers {
+		n.RegisterUpgrade(name, handler)
+	}
 
-	// Start RPC server in background
-	rpcServer := rpc.NewServer(stateDB, pool, logger, flagRPCPort)
-	go func() {
-		if err := rpcServer.Start(); err != nil {
-			logger.Fatal("RPC server error", zap.Error(err))
+	var jwtSecret []byte
+	if flagJWTSecret != "" {
+		raw, err := os.ReadFile(flagJWTSecret)
+		if err != nil {
+			return fmt.Errorf("--rpc-jwt-secret: %w", err)
 		}
-	}()
+		jwtSecret = bytes.TrimSpace(raw)
+	}
+
+	rpcConfig := rpc.DefaultConfig(flagRPCPort)
+	rpcConfig.Host = flagRPCAddr
+	rpcConfig.APIKeys = flagRPCAPIKeys
+	rpcConfig.JWTSecret = jwtSecret
+	rpcConfig.CORSOrigins = flagCORSOrigins
+	rpcConfig.TLSCertFile = flagTLSCert
+	rpcConfig.TLSKeyFile = flagTLSKey
+	rpcConfig.TrustedProxies = flagProxies
+	rpcConfig.MaxBlockAge = flagReadyAge
+	rpcConfig.MinPeers = flagReadyPeers
+	rpcServer := rpc.NewServer(n.State, n.Pool, n.Chain, n.AVM, logger, n.ChainID, rpcConfig)
+	rpcServer.SetTxRateLimit(flagTxRate, flagTxBurst)
+	rpcServer.SetRequestRateLimit(flagReqRate, flagReqBurst)
+	rpcServer.SetEngine(n.Engine)
+	rpcServer.SetEventBus(n.Events)
+	rpcServer.SetIndexer(n.Index)
+	n.Register("RPC server", rpcServer)
 
+	if flagRESTPort != 0 {
+		restConfig := rpc.DefaultConfig(flagRESTPort)
+		restConfig.Host = flagRPCAddr
+		restConfig.TLSCertFile = flagTLSCert
+		restConfig.TLSKeyFile = flagTLSKey
+		n.Register("REST gateway", rpc.NewGateway(rpcServer, restConfig))
+	}
+
+	// The admin listener serves the admin namespace as well as the public
+	// ones. It binds to loopback unless told otherwise, and may only be
+	// exposed beyond it with credentials configured.
+	if flagAdminPort != 0 {
+		if len(flagRPCAPIKeys) == 0 && len(jwtSecret) == 0 && !isLoopback(flagAdminAddr) {
+			return fmt.Errorf("--admin-rpc-addr %s is not loopback; set --rpc-api-keys or --rpc-jwt-secret", flagAdminAddr)
+		}
+		adminConfig := rpc.DefaultConfig(flagAdminPort)
+		adminConfig.Host = flagAdminAddr
+		adminConfig.Namespaces = append([]string{rpc.NamespaceAdmin}, rpc.PublicNamespaces...)
+		adminConfig.APIKeys = flagRPCAPIKeys
+		adminConfig.JWTSecret = jwtSecret
+		adminConfig.DataDir = flagDataDir
+		adminConfig.MaxBlockAge = flagReadyAge
+		adminConfig.MinPeers = flagReadyPeers
+		adminServer := rpc.NewServer(n.State, n.Pool, n.Chain, n.AVM, logger, n.ChainID, adminConfig)
+		adminServer.SetLogLevel(logConfig.Level)
+		adminServer.SetEngine(n.Engine)
+		adminServer.SetEventBus(n.Events)
+		n.Register("admin RPC server", adminServer)
+	}
+
+	if flagGRPCPort != 0 {
+		n.Register("gRPC server", grpcapi.NewServer(n.State, n.Pool, n.Chain, logger, n.ChainID, flagGRPCPort))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if err := n.Start(ctx); err != nil {
+		return err
+	}
 	logger.Info("🚀 node ready",
 		zap.String("rpc", fmt.Sprintf("http://localhost:%d", flagRPCPort)),
 	)
+	if err := sdNotify("READY=1"); err != nil {
+		logger.Warn("systemd notify failed", zap.Error(err))
+	}
+	if interval := sdWatchdogInterval(); interval > 0 {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					sdNotify("WATCHDOG=1")
+				}
+			}
+		}()
+	}
 
-	// Graceful shutdown
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
+	// Run until interrupted or a service fails, then shut down in order
+	var runErr error
+	select {
+	case <-ctx.Done():
+	case runErr = <-n.Err():
+	}
 	logger.Info("shutting down...")
-	engine.Stop()
-	return nil
+	sdNotify("STOPPING=1")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := n.Stop(shutdownCtx); err != nil {
+		logger.Warn("shutdown incomplete", zap.Error(err))
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Warn("trace export failed", zap.Error(err))
+	}
+	return runErr
+}
+
+// isLoopback reports whether host only accepts local connections.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
 }
 
 func main() {
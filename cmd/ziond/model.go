@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+var (
+	flagModelName    string
+	flagModelVersion string
+	flagModelLicense string
+	flagModelRuntime string
+	flagModelCID     string
+	flagModelMeta    map[string]string
+)
+
+var txModelCmd = &cobra.Command{
+	Use:   "model",
+	Short: "Register, update and deprecate models in the model registry",
+}
+
+var txModelRegisterCmd = &cobra.Command{
+	Use:   "register <model-hash>",
+	Short: "Register a model, owned by the sender",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return sendModel(cmd, args[0], transaction.NewModelRegisterTx)
+	},
+}
+
+var txModelUpdateCmd = &cobra.Command{
+	Use:   "update <model-hash>",
+	Short: "Replace the metadata of a model owned by the sender",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return sendModel(cmd, args[0], transaction.NewModelUpdateTx)
+	},
+}
+
+var txModelDeprecateCmd = &cobra.Command{
+	Use:   "deprecate <model-hash>",
+	Short: "Deprecate a model owned by the sender; new receipts and offers may no longer reference it",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hash, err := parseModelHash(args[0])
+		if err != nil {
+			return err
+		}
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewModelDeprecateTx(from, hash, nonce, gasPrice), nil
+		})
+	},
+}
+
+func sendModel(cmd *cobra.Command, hashArg string, build func(string, transaction.ModelInfo, uint64, *big.Int) *transaction.Tx) error {
+	hash, err := parseModelHash(hashArg)
+	if err != nil {
+		return err
+	}
+	m := transaction.ModelInfo{
+		Hash:     hash,
+		CID:      flagModelCID,
+		Name:     flagModelName,
+		Version:  flagModelVersion,
+		License:  flagModelLicense,
+		Runtime:  flagModelRuntime,
+		Metadata: flagModelMeta,
+	}
+	return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+		return build(from, m, nonce, gasPrice), nil
+	})
+}
+
+var queryModelCmd = &cobra.Command{
+	Use:   "model <model-hash>",
+	Short: "Show a registered model",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_getModel", []interface{}{args[0]}, func(w io.Writer, raw json.RawMessage) error {
+			var m state.Model
+			if err := json.Unmarshal(raw, &m); err != nil {
+				return err
+			}
+			status := "active"
+			if m.Deprecated {
+				status = fmt.Sprintf("deprecated at %d", m.DeprecatedAt)
+			}
+			if err := printFields(w,
+				"hash", fmt.Sprintf("0x%x", m.Hash),
+				"name", m.Name,
+				"version", m.Version,
+				"license", m.License,
+				"runtime", m.Runtime,
+				"cid", m.CID,
+				"owner", m.Owner,
+				"status", status,
+				"registered", strconv.FormatUint(m.RegisteredAt, 10),
+				"updated", strconv.FormatUint(m.UpdatedAt, 10),
+			); err != nil {
+				return err
+			}
+			keys := make([]string, 0, len(m.Metadata))
+			for k := range m.Metadata {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				fmt.Fprintf(w, "  %s=%s\n", k, m.Metadata[k])
+			}
+			return nil
+		})
+	},
+}
+
+// parseModelHash decodes a hex model hash, with or without 0x.
+func parseModelHash(s string) ([]byte, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("invalid model hash %q", s)
+	}
+	return b, nil
+}
+
+func init() {
+	for _, c := range []*cobra.Command{txModelRegisterCmd, txModelUpdateCmd} {
+		f := c.Flags()
+		f.StringVar(&flagModelName, "name", "", "Model name")
+		f.StringVar(&flagModelVersion, "version", "", "Model version")
+		f.StringVar(&flagModelLicense, "license", "", "SPDX license identifier")
+		f.StringVar(&flagModelRuntime, "runtime", "", "Expected runtime, e.g. onnx or gguf")
+		f.StringVar(&flagModelCID, "cid", "", "Content identifier of the weights")
+		f.StringToStringVar(&flagModelMeta, "metadata", nil, "Metadata entries as key=value")
+		c.MarkFlagRequired("name")
+	}
+	txModelCmd.AddCommand(txModelRegisterCmd, txModelUpdateCmd, txModelDeprecateCmd)
+	txCmd.AddCommand(txModelCmd)
+	queryCmd.AddCommand(queryModelCmd)
+}
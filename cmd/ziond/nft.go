@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/rpc"
+)
+
+var (
+	flagNFTContentHash string
+	flagNFTOwner       string
+	flagNFTCollection  string
+)
+
+var txNFTCmd = &cobra.Command{
+	Use:   "nft",
+	Short: "Create collections and mint, transfer and burn ZRC-721 tokens",
+}
+
+var txNFTCreateCollectionCmd = payloadTxCmd("create-collection <symbol> <name>", "Create a ZRC-721 collection minted by the sender",
+	transaction.TxNFTCreateCollection, transaction.NewNFTTx, 2,
+	func(args []string) (interface{}, error) {
+		return transaction.NFTCreateCollection{Symbol: args[0], Name: args[1]}, nil
+	})
+
+var txNFTMintCmd = payloadTxCmd("mint <collection> <id> <to> <uri>", "Mint a token of a collection created by the sender",
+	transaction.TxNFTMint, transaction.NewNFTTx, 4,
+	func(args []string) (interface{}, error) {
+		to, err := parseAddressArg(args[2])
+		if err != nil {
+			return nil, err
+		}
+		m := transaction.NFTMint{Collection: args[0], ID: args[1], To: to, URI: args[3]}
+		if flagNFTContentHash != "" {
+			if m.ContentHash, err = parseHash32(flagNFTContentHash); err != nil {
+				return nil, fmt.Errorf("--content-hash: %w", err)
+			}
+		}
+		return m, nil
+	})
+
+var txNFTTransferCmd = payloadTxCmd("transfer <collection> <id> <to>", "Transfer a token of the sender",
+	transaction.TxNFTTransfer, transaction.NewNFTTx, 3,
+	func(args []string) (interface{}, error) {
+		to, err := parseAddressArg(args[2])
+		return transaction.NFTTransfer{Collection: args[0], ID: args[1], To: to}, err
+	})
+
+var txNFTBurnCmd = payloadTxCmd("burn <collection> <id>", "Burn a token of the sender",
+	transaction.TxNFTBurn, transaction.NewNFTTx, 2,
+	func(args []string) (interface{}, error) {
+		return transaction.NFTBurn{Collection: args[0], ID: args[1]}, nil
+	})
+
+var queryNFTCmd = &cobra.Command{
+	Use:   "nft <collection> <id>",
+	Short: "Show a ZRC-721 token",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_getNFT", []interface{}{args[0], args[1]}, func(w io.Writer, raw json.RawMessage) error {
+			var n state.NFT
+			if err := json.Unmarshal(raw, &n); err != nil {
+				return err
+			}
+			hash := "-"
+			if n.ContentHash != nil {
+				hash = "0x" + hex.EncodeToString(n.ContentHash)
+			}
+			return printFields(w,
+				"Collection", n.Collection,
+				"ID", n.ID,
+				"Owner", n.Owner,
+				"URI", n.URI,
+				"Content hash", hash,
+				"Minted at", strconv.FormatUint(n.MintedAt, 10),
+			)
+		})
+	},
+}
+
+var queryNFTsCmd = &cobra.Command{
+	Use:   "nfts",
+	Short: "List the ZRC-721 tokens of an owner or a collection",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		method, key := "zion_getNFTsByOwner", flagNFTOwner
+		if flagNFTCollection != "" {
+			method, key = "zion_getNFTsByCollection", flagNFTCollection
+		}
+		if (flagNFTOwner == "") == (flagNFTCollection == "") {
+			return errors.New("set one of --owner and --collection")
+		}
+		return query(cmd, method, []interface{}{key, rpc.PageArgs{Limit: rpc.MaxPageSize}}, func(w io.Writer, raw json.RawMessage) error {
+			var page rpc.Page[state.NFT]
+			if err := json.Unmarshal(raw, &page); err != nil {
+				return err
+			}
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "COLLECTION\tID\tOWNER\tURI")
+			for _, n := range page.Items {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", n.Collection, n.ID, n.Owner, n.URI)
+			}
+			return tw.Flush()
+		})
+	},
+}
+
+var queryNFTCollectionsCmd = &cobra.Command{
+	Use:   "nft-collections",
+	Short: "List the ZRC-721 collections",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_listNFTCollections", []interface{}{rpc.PageArgs{Limit: rpc.MaxPageSize}}, func(w io.Writer, raw json.RawMessage) error {
+			var page rpc.Page[state.NFTCollection]
+			if err := json.Unmarshal(raw, &page); err != nil {
+				return err
+			}
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "SYMBOL\tNAME\tCREATOR\tSUPPLY\tMINTED")
+			for _, c := range page.Items {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\n", c.Symbol, c.Name, c.Creator, c.Supply, c.Minted)
+			}
+			return tw.Flush()
+		})
+	},
+}
+
+func init() {
+	txNFTMintCmd.Flags().StringVar(&flagNFTContentHash, "content-hash", "", "SHA-256 digest of the artifact, 0x-prefixed hex")
+	queryNFTsCmd.Flags().StringVar(&flagNFTOwner, "owner", "", "Owner address")
+	queryNFTsCmd.Flags().StringVar(&flagNFTCollection, "collection", "", "Collection symbol")
+	txNFTCmd.AddCommand(txNFTCreateCollectionCmd, txNFTMintCmd, txNFTTransferCmd, txNFTBurnCmd)
+	txCmd.AddCommand(txNFTCmd)
+	queryCmd.AddCommand(queryNFTCmd, queryNFTsCmd, queryNFTCollectionsCmd)
+}
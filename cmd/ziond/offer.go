@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+var (
+	flagOfferPrice        string
+	flagOfferLatency      uint64
+	flagOfferAvailability uint32
+	flagOfferEndpoint     string
+	flagOfferMeta         map[string]string
+	flagOfferModels       []string
+	flagOfferMaxPrice     string
+	flagOfferMinRep       uint64
+)
+
+var txOfferCmd = &cobra.Command{
+	Use:   "offer",
+	Short: "Post, update and withdraw service offers on the marketplace",
+}
+
+var txOfferPostCmd = &cobra.Command{
+	Use:   "post <did> <capability>",
+	Short: "Offer a capability (name or name@version) of an agent at a price per call",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return sendOffer(cmd, args, transaction.NewOfferPostTx)
+	},
+}
+
+var txOfferUpdateCmd = &cobra.Command{
+	Use:   "update <did> <capability>",
+	Short: "Replace the terms of an agent's offer for a capability",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return sendOffer(cmd, args, transaction.NewOfferUpdateTx)
+	},
+}
+
+var txOfferWithdrawCmd = &cobra.Command{
+	Use:   "withdraw <did> <capability>",
+	Short: "Withdraw an agent's offer for a capability",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewOfferWithdrawTx(from, args[0], args[1], nonce, gasPrice), nil
+		})
+	},
+}
+
+func sendOffer(cmd *cobra.Command, args []string, build func(string, transaction.ServiceOffer, uint64, *big.Int) *transaction.Tx) error {
+	caps, err := parseCapabilities(args[1:])
+	if err != nil {
+		return err
+	}
+	price, err := parseAmount(flagOfferPrice)
+	if err != nil {
+		return fmt.Errorf("--price: %w", err)
+	}
+	offer := transaction.ServiceOffer{
+		AgentID:      args[0],
+		Capability:   caps[0],
+		PricePerCall: price,
+		SLA:          transaction.ServiceSLA{LatencyMs: flagOfferLatency, Availability: flagOfferAvailability},
+		Endpoint:     flagOfferEndpoint,
+		Metadata:     flagOfferMeta,
+	}
+	for _, m := range flagOfferModels {
+		hash, err := parseModelHash(m)
+		if err != nil {
+			return fmt.Errorf("--model: %w", err)
+		}
+		offer.Models = append(offer.Models, hash)
+	}
+	return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+		return build(from, offer, nonce, gasPrice), nil
+	})
+}
+
+var queryOffersCmd = &cobra.Command{
+	Use:   "offers <capability>",
+	Short: "List the active offers for a capability, cheapest first",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		caps, err := parseCapabilities(args)
+		if err != nil {
+			return err
+		}
+		q := map[string]interface{}{
+			"capability":    caps[0].Name,
+			"version":       caps[0].Version,
+			"maxLatencyMs":  flagOfferLatency,
+			"minReputation": flagOfferMinRep,
+		}
+		if flagOfferMaxPrice != "" {
+			max, err := parseAmount(flagOfferMaxPrice)
+			if err != nil {
+				return fmt.Errorf("--max-price: %w", err)
+			}
+			q["maxPrice"] = max
+		}
+		return query(cmd, "zion_searchOffers", []interface{}{q}, func(w io.Writer, raw json.RawMessage) error {
+			var page struct {
+				Items []state.Offer `json:"items"`
+			}
+			if err := json.Unmarshal(raw, &page); err != nil {
+				return err
+			}
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "AGENT\tVERSION\tPRICE\tLATENCY\tENDPOINT")
+			for _, o := range page.Items {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%dms\t%s\n", o.AgentID, o.Capability.Version, o.PricePerCall, o.SLA.LatencyMs, o.Endpoint)
+			}
+			return tw.Flush()
+		})
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{txOfferPostCmd, txOfferUpdateCmd} {
+		f := c.Flags()
+		f.StringVar(&flagOfferPrice, "price", "0", "Price per call in base units")
+		f.Uint64Var(&flagOfferLatency, "latency-ms", 0, "Committed p95 latency in milliseconds")
+		f.Uint32Var(&flagOfferAvailability, "availability", 0, "Committed availability in basis points")
+		f.StringVar(&flagOfferEndpoint, "endpoint", "", "Endpoint serving the calls")
+		f.StringToStringVar(&flagOfferMeta, "metadata", nil, "Metadata entries as key=value")
+		f.StringSliceVar(&flagOfferModels, "model", nil, "Hex hash of a registered model serving the calls (repeatable)")
+	}
+	txOfferCmd.AddCommand(txOfferPostCmd, txOfferUpdateCmd, txOfferWithdrawCmd)
+	txCmd.AddCommand(txOfferCmd)
+
+	qf := queryOffersCmd.Flags()
+	qf.StringVar(&flagOfferMaxPrice, "max-price", "", "Highest price per call in base units")
+	qf.Uint64Var(&flagOfferLatency, "max-latency-ms", 0, "Highest committed latency in milliseconds")
+	qf.Uint64Var(&flagOfferMinRep, "min-reputation", 0, "Lowest agent reputation score")
+	queryCmd.AddCommand(queryOffersCmd)
+}
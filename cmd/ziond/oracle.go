@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+var flagEvaluatorStake string
+
+var txEvaluatorCmd = &cobra.Command{
+	Use:   "evaluator",
+	Short: "Join the quality oracle committee and score inference outputs",
+}
+
+var txEvaluatorRegisterCmd = &cobra.Command{
+	Use:   "register",
+	Short: "Register the sender as a quality evaluator, escrowing a stake",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		stake, err := parseAmount(flagEvaluatorStake)
+		if err != nil {
+			return fmt.Errorf("--stake: %w", err)
+		}
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewEvaluatorRegisterTx(from, transaction.EvaluatorRegistration{Stake: stake}, nonce, gasPrice), nil
+		})
+	},
+}
+
+var txEvaluatorDeregisterCmd = &cobra.Command{
+	Use:   "deregister",
+	Short: "Deregister the sender as a quality evaluator; the stake is released after unbonding",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewEvaluatorDeregisterTx(from, nonce, gasPrice), nil
+		})
+	},
+}
+
+var txEvaluatorScoreCmd = &cobra.Command{
+	Use:   "score <receipt-tx-hash> <score>",
+	Short: fmt.Sprintf("Score the output quality of an inference receipt, from 0 to %d", transaction.MaxQualityScore),
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hash, err := parseHash32(args[0])
+		if err != nil {
+			return err
+		}
+		score, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil || score > transaction.MaxQualityScore {
+			return fmt.Errorf("invalid score %q", args[1])
+		}
+		q := transaction.QualityScore{ReceiptHash: hash, Score: score}
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewQualityScoreTx(from, q, nonce, gasPrice), nil
+		})
+	},
+}
+
+var queryEvaluationCmd = &cobra.Command{
+	Use:   "evaluation <receipt-tx-hash>",
+	Short: "Show the quality evaluation of an inference receipt and its scores",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_getEvaluation", []interface{}{args[0]}, func(w io.Writer, raw json.RawMessage) error {
+			var e state.Evaluation
+			if err := json.Unmarshal(raw, &e); err != nil {
+				return err
+			}
+			if err := printFields(w,
+				"receipt", fmt.Sprintf("0x%x", e.ReceiptHash),
+				"status", e.Status,
+				"median", strconv.FormatUint(e.Median, 10),
+				"opened", strconv.FormatUint(e.OpenedAt, 10),
+				"deadline", strconv.FormatUint(e.Deadline, 10),
+			); err != nil {
+				return err
+			}
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "EVALUATOR\tSCORE\tSTAKE\tOUTLIER")
+			for _, sc := range e.Scores {
+				fmt.Fprintf(tw, "%s\t%d\t%s\t%t\n", sc.Evaluator, sc.Score, sc.Stake, sc.Outlier)
+			}
+			return tw.Flush()
+		})
+	},
+}
+
+func init() {
+	txEvaluatorRegisterCmd.Flags().StringVar(&flagEvaluatorStake, "stake", "0", "Stake in base units")
+	txEvaluatorCmd.AddCommand(txEvaluatorRegisterCmd, txEvaluatorDeregisterCmd, txEvaluatorScoreCmd)
+	txCmd.AddCommand(txEvaluatorCmd)
+	queryCmd.AddCommand(queryEvaluationCmd)
+}
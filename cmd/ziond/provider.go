@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+var (
+	flagProviderKey    string
+	flagProviderModels []string
+	flagProviderBond   string
+)
+
+var txProviderCmd = &cobra.Command{
+	Use:   "provider",
+	Short: "Register and deregister compute providers proving inference receipts",
+}
+
+var txProviderRegisterCmd = &cobra.Command{
+	Use:   "register",
+	Short: "Register the sender as a compute provider, escrowing a bond",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pub, err := hex.DecodeString(strings.TrimPrefix(flagProviderKey, "0x"))
+		if err != nil {
+			return fmt.Errorf("--prover-key: %w", err)
+		}
+		reg := transaction.ProviderRegistration{PubKey: pub}
+		for _, m := range flagProviderModels {
+			b, err := hex.DecodeString(strings.TrimPrefix(m, "0x"))
+			if err != nil {
+				return fmt.Errorf("--model %q: %w", m, err)
+			}
+			reg.Models = append(reg.Models, b)
+		}
+		if reg.Bond, err = parseAmount(flagProviderBond); err != nil {
+			return fmt.Errorf("--bond: %w", err)
+		}
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewProviderRegisterTx(from, reg, nonce, gasPrice), nil
+		})
+	},
+}
+
+var txProviderDeregisterCmd = &cobra.Command{
+	Use:   "deregister",
+	Short: "Deregister the sender as a compute provider; the bond is released after unbonding",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewProviderDeregisterTx(from, nonce, gasPrice), nil
+		})
+	},
+}
+
+var queryProviderCmd = &cobra.Command{
+	Use:   "provider <address>",
+	Short: "Show a registered compute provider",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_getProvider", []interface{}{args[0]}, func(w io.Writer, raw json.RawMessage) error {
+			var p state.Provider
+			if err := json.Unmarshal(raw, &p); err != nil {
+				return err
+			}
+			status := "active"
+			if !p.Active {
+				status = fmt.Sprintf("unbonding until %d", p.UnbondingAt)
+			}
+			if err := printFields(w,
+				"address", p.Address,
+				"prover key", fmt.Sprintf("0x%x", p.PubKey),
+				"bond", p.Bond.String(),
+				"status", status,
+				"registered", strconv.FormatUint(p.RegisteredAt, 10),
+				"models", strconv.Itoa(len(p.Models)),
+			); err != nil {
+				return err
+			}
+			for _, m := range p.Models {
+				fmt.Fprintf(w, "  0x%x\n", m)
+			}
+			return nil
+		})
+	},
+}
+
+func init() {
+	f := txProviderRegisterCmd.Flags()
+	f.StringVar(&flagProviderKey, "prover-key", "", "Hex ed25519 public key signing the provider's receipts")
+	f.StringSliceVar(&flagProviderModels, "model", nil, "Hex hash of a model served (repeatable)")
+	f.StringVar(&flagProviderBond, "bond", "0", "Bond in base units")
+	txProviderRegisterCmd.MarkFlagRequired("prover-key")
+	txProviderRegisterCmd.MarkFlagRequired("model")
+	txProviderCmd.AddCommand(txProviderRegisterCmd, txProviderDeregisterCmd)
+	txCmd.AddCommand(txProviderCmd)
+	queryCmd.AddCommand(queryProviderCmd)
+}
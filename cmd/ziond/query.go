@@ -0,0 +1,280 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/rpc"
+)
+
+var flagOutput string
+
+var queryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Query chain state from a running node",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if flagOutput != "text" && flagOutput != "json" {
+			return fmt.Errorf("--output: invalid value %q", flagOutput)
+		}
+		return nil
+	},
+}
+
+var queryBalanceCmd = &cobra.Command{
+	Use:   "balance <address>",
+	Short: "Show the balance and nonce of an account",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_getBalance", []interface{}{args[0]}, func(w io.Writer, raw json.RawMessage) error {
+			var acc map[string]string
+			if err := json.Unmarshal(raw, &acc); err != nil {
+				return err
+			}
+			return printFields(w, "address", acc["address"], "balance", acc["balance"], "nonce", acc["nonce"])
+		})
+	},
+}
+
+var querySupplyCmd = &cobra.Command{
+	Use:   "supply",
+	Short: "Show the supply of ZIO, the community treasury and the inflation rate",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_getSupply", nil, func(w io.Writer, raw json.RawMessage) error {
+			var res rpc.SupplyResult
+			if err := json.Unmarshal(raw, &res); err != nil {
+				return err
+			}
+			return printFields(w,
+				"total", res.Total.String(),
+				"minted", res.Minted.String(),
+				"burned", res.Burned.String(),
+				"treasury", res.Treasury.String(),
+				"inflation", fmt.Sprintf("%.2f%%", float64(res.InflationRate)/100),
+				"block reward", res.BlockReward.String(),
+			)
+		})
+	},
+}
+
+var queryParamsCmd = &cobra.Command{
+	Use:   "params",
+	Short: "Show the protocol parameters and the scheduled parameter forks",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_getParams", nil, func(w io.Writer, raw json.RawMessage) error {
+			var res rpc.ParamsResult
+			if err := json.Unmarshal(raw, &res); err != nil {
+				return err
+			}
+			c, v := res.Params.Consensus, res.Params.VM
+			fields := []string{
+				"block gas limit", strconv.FormatUint(c.BlockGasLimit, 10),
+				"max block txs", strconv.Itoa(c.MaxBlockTxs),
+				"min validator stake", c.MinValidatorStake.String(),
+				"fee burn", fmt.Sprintf("%d%%", v.FeeBurnPercent),
+				"unbonding period", fmt.Sprintf("%d blocks", res.Params.Staking.UnbondingPeriod),
+			}
+			for _, f := range res.Forks {
+				subspaces := make([]string, len(f.Changes))
+				for i, ch := range f.Changes {
+					subspaces[i] = ch.Subspace
+				}
+				fields = append(fields, "fork", fmt.Sprintf("height %d: %s", f.Height, strings.Join(subspaces, ", ")))
+			}
+			return printFields(w, fields...)
+		})
+	},
+}
+
+var queryAgentCmd = &cobra.Command{
+	Use:   "agent <did>",
+	Short: "Show a registered agent",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_getAgent", []interface{}{args[0]}, func(w io.Writer, raw json.RawMessage) error {
+			var rec state.AgentRecord
+			if err := json.Unmarshal(raw, &rec); err != nil {
+				return err
+			}
+			caps := make([]string, len(rec.DID.Capabilities))
+			for i, c := range rec.DID.Capabilities {
+				caps[i] = c.Name
+				if c.Version != "" {
+					caps[i] += "@" + c.Version
+				}
+			}
+			history := make([]string, len(rec.Lifecycle))
+			for i, ev := range rec.Lifecycle {
+				history[i] = fmt.Sprintf("%s@%d", ev.Action, ev.Height)
+				if ev.Reason != "" {
+					history[i] += fmt.Sprintf(" (%s)", ev.Reason)
+				}
+			}
+			return printFields(w,
+				"id", rec.DID.ID,
+				"controller", rec.DID.Controller,
+				"active", strconv.FormatBool(rec.Active),
+				"registered", strconv.FormatUint(rec.RegisteredAt, 10),
+				"messages", strconv.FormatUint(rec.MessageCount, 10),
+				"reputation", fmt.Sprintf("%d (%d inferences, %d tasks, %d/%d disputes won/lost)",
+					rec.Reputation.Score, rec.Reputation.VerifiedInferences, rec.Reputation.CompletedTasks,
+					rec.Reputation.DisputesWon, rec.Reputation.DisputesLost),
+				"capabilities", strings.Join(caps, ", "),
+				"lifecycle", strings.Join(history, ", "),
+			)
+		})
+	},
+}
+
+var queryBlockCmd = &cobra.Command{
+	Use:   "block [height|hash]",
+	Short: "Show a block, by default the latest",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		method, param := "zion_getBlockByHeight", interface{}(nil)
+		switch {
+		case len(args) == 0 || args[0] == "latest":
+			var head string
+			if err := rpcCall("eth_blockNumber", &head); err != nil {
+				return err
+			}
+			height, err := parseHexUint(head)
+			if err != nil {
+				return fmt.Errorf("eth_blockNumber: %w", err)
+			}
+			param = height
+		case strings.HasPrefix(args[0], "0x"):
+			method, param = "zion_getBlockByHash", args[0]
+		default:
+			height, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid block %q", args[0])
+			}
+			param = height
+		}
+		return query(cmd, method, []interface{}{param}, func(w io.Writer, raw json.RawMessage) error {
+			var b rpc.BlockResult
+			if err := json.Unmarshal(raw, &b); err != nil {
+				return err
+			}
+			if err := printFields(w,
+				"hash", b.Hash,
+				"height", strconv.FormatUint(b.Height, 10),
+				"time", time.Unix(0, b.Timestamp).UTC().Format(time.RFC3339),
+				"parent", b.PrevHash,
+				"validator", b.Commit.Validator,
+				"state root", b.StateRoot,
+				"txs", strconv.Itoa(len(b.Txs)),
+			); err != nil {
+				return err
+			}
+			for _, h := range b.Txs {
+				fmt.Fprintf(w, "  %v\n", h)
+			}
+			return nil
+		})
+	},
+}
+
+var queryTxCmd = &cobra.Command{
+	Use:   "tx <hash>",
+	Short: "Show an included transaction and its receipt",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_getTransaction", []interface{}{args[0]}, func(w io.Writer, raw json.RawMessage) error {
+			var tx rpc.TransactionResult
+			if err := json.Unmarshal(raw, &tx); err != nil {
+				return err
+			}
+			value := "0"
+			if tx.Value != nil {
+				value = tx.Value.String()
+			}
+			status := "not executed"
+			if r := tx.Receipt; r != nil {
+				status = "success"
+				if r.Status != transaction.ReceiptSuccess {
+					status = "failed: " + r.Error
+				}
+				status += fmt.Sprintf(" (gas used %d)", r.GasUsed)
+			}
+			return printFields(w,
+				"hash", tx.Hash,
+				"block", fmt.Sprintf("%d (%s)", tx.BlockHeight, tx.BlockHash),
+				"index", strconv.Itoa(tx.Index),
+				"type", strconv.Itoa(int(tx.Type)),
+				"from", tx.From,
+				"to", tx.To,
+				"value", value,
+				"nonce", strconv.FormatUint(tx.Nonce, 10),
+				"status", status,
+			)
+		})
+	},
+}
+
+var queryValidatorsCmd = &cobra.Command{
+	Use:   "validators",
+	Short: "List the validator set",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_getValidators", nil, func(w io.Writer, raw json.RawMessage) error {
+			var vals []rpc.ValidatorResult
+			if err := json.Unmarshal(raw, &vals); err != nil {
+				return err
+			}
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "ADDRESS\tSTAKE\tPOI SCORE\tVOTING POWER")
+			for _, v := range vals {
+				fmt.Fprintf(tw, "%s\t%s\t%.2f\t%d\n", v.Address, v.Stake, v.PoIScore, v.VotingPower)
+			}
+			return tw.Flush()
+		})
+	},
+}
+
+func init() {
+	pf := queryCmd.PersistentFlags()
+	pf.StringVar(&flagNode, "node", "http://localhost:8545", "JSON-RPC endpoint of the node")
+	pf.StringVar(&flagNodeAPIKey, "node-api-key", "", "API key or JWT sent to the node")
+	pf.StringVarP(&flagOutput, "output", "o", "text", "Output format: text or json")
+	queryCmd.AddCommand(queryBalanceCmd, querySupplyCmd, queryParamsCmd, queryAgentCmd, queryBlockCmd, queryTxCmd, queryValidatorsCmd)
+	rootCmd.AddCommand(queryCmd)
+}
+
+// query calls method and prints its result as indented JSON or, for text
+// output, with printText.
+func query(cmd *cobra.Command, method string, params []interface{}, printText func(io.Writer, json.RawMessage) error) error {
+	var raw json.RawMessage
+	if err := rpcCall(method, &raw, params...); err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	if flagOutput == "json" {
+		b, err := json.MarshalIndent(raw, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(b))
+		return err
+	}
+	return printText(out, raw)
+}
+
+// printFields writes label/value pairs as aligned lines.
+func printFields(w io.Writer, kv ...string) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for i := 0; i+1 < len(kv); i += 2 {
+		fmt.Fprintf(tw, "%s:\t%s\n", kv[i], kv[i+1])
+	}
+	return tw.Flush()
+}
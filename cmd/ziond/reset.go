@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/zionlayer/zionlayer/core/mempool"
+	"github.com/zionlayer/zionlayer/indexer"
+	"github.com/zionlayer/zionlayer/node"
+	"go.uber.org/zap"
+)
+
+// chainData lists the entries of the data directory that hold chain data,
+// the only ones unsafe-reset-all removes. Keys, configuration, genesis
+// files and the homes of devnet validators are left alone.
+var chainData = []string{"data", "db", mempool.JournalFile, node.UpgradeInfoFile}
+
+var resetCmd = &cobra.Command{
+	Use:   "unsafe-reset-all",
+	Short: "Delete all chain data, keeping keys and configuration",
+	Long: `Delete the chain data of the data directory, its data/ and db/
+directories, the mempool journal and ` + node.UpgradeInfoFile + `, and empty
+the SQL index if one is configured, so that the next start begins again
+from genesis. Everything else in the data directory is kept. The node must
+not be running.`,
+	Args: cobra.NoArgs,
+	RunE: runReset,
+}
+
+var flagRollbackHeight uint64
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback --height N",
+	Short: "Roll the block index back to a height",
+	Long: `Delete the blocks above --height from the SQL index, so that they
+are indexed again from the chain. Blocks and state are kept in memory and
+rebuilt from genesis on every start, so the index is the only block store on
+disk. The node must not be running.`,
+	Args: cobra.NoArgs,
+	RunE: runRollback,
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{resetCmd, rollbackCmd} {
+		cmd.Flags().StringVar(&flagIndexerDriver, "indexer-driver", "", "SQL database indexing finalized blocks: sqlite or postgres (empty for none)")
+		cmd.Flags().StringVar(&flagIndexerDSN, "indexer-dsn", "", "Data source of the SQL indexer")
+		rootCmd.AddCommand(cmd)
+	}
+	rollbackCmd.Flags().Uint64Var(&flagRollbackHeight, "height", 0, "Height to roll back to; the blocks above it are removed")
+	rollbackCmd.MarkFlagRequired("height")
+}
+
+func runReset(cmd *cobra.Command, args []string) error {
+	if _, err := loadConfig(cmd); err != nil {
+		return err
+	}
+	if _, err := os.Stat(flagDataDir); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("data directory %s does not exist", flagDataDir)
+	}
+	out := cmd.OutOrStdout()
+	for _, name := range chainData {
+		path := filepath.Join(flagDataDir, name)
+		if _, err := os.Lstat(path); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "removed %s\n", path)
+	}
+	return rollbackIndex(cmd, 0)
+}
+
+func runRollback(cmd *cobra.Command, args []string) error {
+	if _, err := loadConfig(cmd); err != nil {
+		return err
+	}
+	if flagIndexerDriver == "" {
+		return errors.New("no SQL index to roll back: set --indexer-driver and --indexer-dsn")
+	}
+	return rollbackIndex(cmd, flagRollbackHeight)
+}
+
+// rollbackIndex rolls the configured SQL index back to height, if an index
+// is configured.
+func rollbackIndex(cmd *cobra.Command, height uint64) error {
+	if flagIndexerDriver == "" {
+		return nil
+	}
+	ix, err := indexer.Open(indexer.Config{Driver: flagIndexerDriver, DSN: flagIndexerDSN}, nil, zap.NewNop())
+	if err != nil {
+		return err
+	}
+	defer ix.Close()
+	from := ix.Height()
+	if err := ix.Rollback(cmd.Context(), height); err != nil {
+		return err
+	}
+	if from > height {
+		fmt.Fprintf(cmd.OutOrStdout(), "rolled the SQL index back from height %d to %d\n", from, height)
+	} else {
+		fmt.Fprintf(cmd.OutOrStdout(), "SQL index at height %d, nothing to roll back\n", from)
+	}
+	return nil
+}
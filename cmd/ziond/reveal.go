@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+var (
+	flagRevealInput     string
+	flagRevealOutput    string
+	flagRevealInputCID  string
+	flagRevealOutputCID string
+	flagRevealKey       string
+	flagRevealSalt      string
+	flagRevealCommit    bool
+)
+
+var txRevealCmd = &cobra.Command{
+	Use:   "reveal [receipt-tx-hash]",
+	Short: "Reveal the data an inference receipt committed to, inline or by CID",
+	Long: "Reveal the data an inference receipt committed to. The input and output are each\n" +
+		"revealed inline (--input, --output: files) or by the CID of an off-chain copy\n" +
+		"(--input-cid, --output-cid), encrypted under --key if set. With --commitment, print\n" +
+		"the commitment to the data for the receipt to carry instead of sending a transaction.",
+	Args: cobra.RangeArgs(0, 1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		v, err := revealFromFlags()
+		if err != nil {
+			return err
+		}
+		if flagRevealCommit {
+			c := v.Commitment()
+			fmt.Fprintf(cmd.OutOrStdout(), "0x%x\n", c)
+			return nil
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("receipt transaction hash required")
+		}
+		if v.ReceiptHash, err = parseHash32(args[0]); err != nil {
+			return err
+		}
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewInferenceRevealTx(from, *v, nonce, gasPrice), nil
+		})
+	},
+}
+
+func revealFromFlags() (*transaction.InferenceReveal, error) {
+	v := &transaction.InferenceReveal{InputCID: flagRevealInputCID, OutputCID: flagRevealOutputCID}
+	var err error
+	if flagRevealInput != "" {
+		if v.Input, err = os.ReadFile(flagRevealInput); err != nil {
+			return nil, fmt.Errorf("--input: %w", err)
+		}
+	}
+	if flagRevealOutput != "" {
+		if v.Output, err = os.ReadFile(flagRevealOutput); err != nil {
+			return nil, fmt.Errorf("--output: %w", err)
+		}
+	}
+	if v.Key, err = hex.DecodeString(strings.TrimPrefix(flagRevealKey, "0x")); err != nil {
+		return nil, fmt.Errorf("--key: %w", err)
+	}
+	if v.Salt, err = hex.DecodeString(strings.TrimPrefix(flagRevealSalt, "0x")); err != nil {
+		return nil, fmt.Errorf("--salt: %w", err)
+	}
+	return v, nil
+}
+
+var queryRevealCmd = &cobra.Command{
+	Use:   "reveal <receipt-tx-hash>",
+	Short: "Show the data revealed for an inference receipt",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_getReveal", []interface{}{args[0]}, func(w io.Writer, raw json.RawMessage) error {
+			var v transaction.InferenceReveal
+			if err := json.Unmarshal(raw, &v); err != nil {
+				return err
+			}
+			input, output := v.InputCID, v.OutputCID
+			if input == "" {
+				input = fmt.Sprintf("%d bytes inline", len(v.Input))
+			}
+			if output == "" {
+				output = fmt.Sprintf("%d bytes inline", len(v.Output))
+			}
+			return printFields(w,
+				"receipt", fmt.Sprintf("0x%x", v.ReceiptHash),
+				"input", input,
+				"output", output,
+				"key", fmt.Sprintf("0x%x", v.Key),
+			)
+		})
+	},
+}
+
+func init() {
+	f := txRevealCmd.Flags()
+	f.StringVar(&flagRevealInput, "input", "", "File holding the inference input")
+	f.StringVar(&flagRevealOutput, "output", "", "File holding the inference output")
+	f.StringVar(&flagRevealInputCID, "input-cid", "", "CID of the off-chain inference input")
+	f.StringVar(&flagRevealOutputCID, "output-cid", "", "CID of the off-chain inference output")
+	f.StringVar(&flagRevealKey, "key", "", "Hex key decrypting the content of the CIDs")
+	f.StringVar(&flagRevealSalt, "salt", "", "Hex salt of the commitment")
+	f.BoolVar(&flagRevealCommit, "commitment", false, "Print the commitment to the data instead of revealing it")
+	txCmd.AddCommand(txRevealCmd)
+	queryCmd.AddCommand(queryRevealCmd)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var (
+	flagNode       string
+	flagNodeAPIKey string
+)
+
+// rpcClientTimeout bounds a single CLI request to the node.
+const rpcClientTimeout = 30 * time.Second
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+	ID      int           `json:"id"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// rpcCall invokes method on the node at --node and decodes its result into
+// result, which may be nil.
+func rpcCall(method string, result interface{}, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, err := json.Marshal(rpcRequest{JSONRPC: "2.0", Method: method, Params: params, ID: 1})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, flagNode, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if flagNodeAPIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+flagNodeAPIKey)
+	}
+	client := &http.Client{Timeout: rpcClientTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var out rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return fmt.Errorf("%s: %s", method, resp.Status)
+	}
+	if out.Error != nil {
+		return fmt.Errorf("%s: %s (code %d)", method, out.Error.Message, out.Error.Code)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(out.Result, result)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/rpc"
+)
+
+var (
+	flagTokenDecimals      uint8
+	flagTokenInitialSupply string
+	flagTokenMaxSupply     string
+)
+
+var txTokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Create, mint and transfer ZRC-20 tokens",
+}
+
+var txTokenCreateCmd = payloadTxCmd("create <symbol> <name>", "Create a ZRC-20 token administered by the sender", transaction.TxTokenCreate, transaction.NewTokenTx, 2,
+	func(args []string) (interface{}, error) {
+		c := transaction.TokenCreate{Symbol: args[0], Name: args[1], Decimals: flagTokenDecimals}
+		var err error
+		if flagTokenInitialSupply != "" {
+			if c.InitialSupply, err = parseAmount(flagTokenInitialSupply); err != nil {
+				return nil, fmt.Errorf("--initial-supply: %w", err)
+			}
+		}
+		if flagTokenMaxSupply != "" {
+			if c.MaxSupply, err = parseAmount(flagTokenMaxSupply); err != nil {
+				return nil, fmt.Errorf("--max-supply: %w", err)
+			}
+		}
+		return c, nil
+	})
+
+var txTokenMintCmd = payloadTxCmd("mint <symbol> <to> <amount>", "Mint tokens as the token admin", transaction.TxTokenMint, transaction.NewTokenTx, 3,
+	func(args []string) (interface{}, error) {
+		to, err := parseAddressArg(args[1])
+		if err != nil {
+			return nil, err
+		}
+		amount, err := parseAmount(args[2])
+		return transaction.TokenMint{Symbol: args[0], To: to, Amount: amount}, err
+	})
+
+var txTokenBurnCmd = payloadTxCmd("burn <symbol> <amount>", "Burn tokens of the sender", transaction.TxTokenBurn, transaction.NewTokenTx, 2,
+	func(args []string) (interface{}, error) {
+		amount, err := parseAmount(args[1])
+		return transaction.TokenBurn{Symbol: args[0], Amount: amount}, err
+	})
+
+var txTokenTransferCmd = payloadTxCmd("transfer <symbol> <to> <amount>", "Transfer tokens of the sender", transaction.TxTokenTransfer, transaction.NewTokenTx, 3,
+	func(args []string) (interface{}, error) {
+		to, err := parseAddressArg(args[1])
+		if err != nil {
+			return nil, err
+		}
+		amount, err := parseAmount(args[2])
+		return transaction.TokenTransfer{Symbol: args[0], To: to, Amount: amount}, err
+	})
+
+var txTokenApproveCmd = payloadTxCmd("approve <symbol> <spender> <amount>", "Allow a spender to transfer up to an amount of the sender's tokens", transaction.TxTokenApprove, transaction.NewTokenTx, 3,
+	func(args []string) (interface{}, error) {
+		spender, err := parseAddressArg(args[1])
+		if err != nil {
+			return nil, err
+		}
+		amount, err := parseAmount(args[2])
+		return transaction.TokenApprove{Symbol: args[0], Spender: spender, Amount: amount}, err
+	})
+
+var txTokenTransferFromCmd = payloadTxCmd("transfer-from <symbol> <from> <to> <amount>", "Transfer tokens under an allowance", transaction.TxTokenTransferFrom, transaction.NewTokenTx, 4,
+	func(args []string) (interface{}, error) {
+		from, err := parseAddressArg(args[1])
+		if err != nil {
+			return nil, err
+		}
+		to, err := parseAddressArg(args[2])
+		if err != nil {
+			return nil, err
+		}
+		amount, err := parseAmount(args[3])
+		return transaction.TokenTransferFrom{Symbol: args[0], From: from, To: to, Amount: amount}, err
+	})
+
+var txTokenSetAdminCmd = payloadTxCmd("set-admin <symbol> <admin|none>", "Hand over the token admin, or renounce it with none", transaction.TxTokenSetAdmin, transaction.NewTokenTx, 2,
+	func(args []string) (interface{}, error) {
+		a := transaction.TokenSetAdmin{Symbol: args[0]}
+		if args[1] != "none" {
+			admin, err := parseAddressArg(args[1])
+			if err != nil {
+				return nil, err
+			}
+			a.Admin = admin
+		}
+		return a, nil
+	})
+
+var queryTokenCmd = &cobra.Command{
+	Use:   "token <symbol>",
+	Short: "Show a ZRC-20 token",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_getToken", []interface{}{args[0]}, func(w io.Writer, raw json.RawMessage) error {
+			var t state.Token
+			if err := json.Unmarshal(raw, &t); err != nil {
+				return err
+			}
+			admin, maxSupply := t.Admin, "unlimited"
+			if admin == "" {
+				admin = "renounced"
+			}
+			if t.MaxSupply != nil {
+				maxSupply = t.MaxSupply.String()
+			}
+			return printFields(w,
+				"Symbol", t.Symbol,
+				"Name", t.Name,
+				"Decimals", strconv.Itoa(int(t.Decimals)),
+				"Creator", t.Creator,
+				"Admin", admin,
+				"Supply", t.Supply.String(),
+				"Max supply", maxSupply,
+				"Created at", strconv.FormatUint(t.CreatedAt, 10),
+			)
+		})
+	},
+}
+
+var queryTokensCmd = &cobra.Command{
+	Use:   "tokens",
+	Short: "List the ZRC-20 tokens",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_listTokens", []interface{}{rpc.PageArgs{Limit: rpc.MaxPageSize}}, func(w io.Writer, raw json.RawMessage) error {
+			var page rpc.Page[state.Token]
+			if err := json.Unmarshal(raw, &page); err != nil {
+				return err
+			}
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "SYMBOL\tNAME\tDECIMALS\tSUPPLY\tADMIN")
+			for _, t := range page.Items {
+				fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", t.Symbol, t.Name, t.Decimals, t.Supply, t.Admin)
+			}
+			return tw.Flush()
+		})
+	},
+}
+
+var queryTokenBalancesCmd = &cobra.Command{
+	Use:   "token-balances <address>",
+	Short: "Show the ZRC-20 balances of an address",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_getTokenBalances", []interface{}{args[0]}, func(w io.Writer, raw json.RawMessage) error {
+			var bals map[string]string
+			if err := json.Unmarshal(raw, &bals); err != nil {
+				return err
+			}
+			return printBalances(w, "SYMBOL", "BALANCE", bals)
+		})
+	},
+}
+
+func init() {
+	txTokenCreateCmd.Flags().Uint8Var(&flagTokenDecimals, "decimals", 18, "Decimals of the token")
+	txTokenCreateCmd.Flags().StringVar(&flagTokenInitialSupply, "initial-supply", "", "Supply minted to the sender, in base units")
+	txTokenCreateCmd.Flags().StringVar(&flagTokenMaxSupply, "max-supply", "", "Cap on the supply, in base units (unlimited if unset)")
+	txTokenCmd.AddCommand(txTokenCreateCmd, txTokenMintCmd, txTokenBurnCmd, txTokenTransferCmd,
+		txTokenApproveCmd, txTokenTransferFromCmd, txTokenSetAdminCmd)
+	txCmd.AddCommand(txTokenCmd)
+	queryCmd.AddCommand(queryTokenCmd, queryTokensCmd, queryTokenBalancesCmd)
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/zionlayer/zionlayer/core/common"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+var (
+	flagTxFrom     string
+	flagTxChainID  uint64
+	flagTxNonce    int64
+	flagTxGas      uint64
+	flagTxGasPrice string
+	flagTxOffline  bool
+
+	flagAgentCaps     []string
+	flagAgentMeta     map[string]string
+	flagAgentCtrl     string
+	flagAgentMsgType  string
+	flagAgentMsgNonce int64
+	flagAgentMsgRef   string
+	flagAgentReason   string
+	flagDelegExpiry   uint64
+	flagDelegDepth    uint64
+)
+
+var txCmd = &cobra.Command{
+	Use:   "tx",
+	Short: "Build, sign and broadcast transactions",
+	Long: "Build a transaction, sign it with a keystore key and submit it to --node.\n" +
+		"With --offline nothing is sent: the signed transaction is printed as raw hex\n" +
+		"for zion_sendRawTransaction, and --chain-id and --nonce must be given.",
+}
+
+var txTransferCmd = &cobra.Command{
+	Use:   "transfer <to> <amount>",
+	Short: "Transfer an amount in base units",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		to, err := common.ParseAddress(args[0])
+		if err != nil {
+			return fmt.Errorf("recipient: %w", err)
+		}
+		amount, err := parseAmount(args[1])
+		if err != nil {
+			return err
+		}
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewTransferTx(from, to.String(), amount, nonce, gasPrice), nil
+		})
+	},
+}
+
+var txAgentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Agent registration, messaging and lifecycle",
+}
+
+var txAgentRegisterCmd = &cobra.Command{
+	Use:   "register <did>",
+	Short: "Register an agent DID controlled by the signer",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		caps, err := parseCapabilities(flagAgentCaps)
+		if err != nil {
+			return err
+		}
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			did := transaction.AgentDID{
+				ID:           args[0],
+				Controller:   from,
+				Capabilities: caps,
+				Metadata:     flagAgentMeta,
+			}
+			if flagAgentCtrl != "" {
+				ctrl, err := common.ParseAddress(flagAgentCtrl)
+				if err != nil {
+					return nil, fmt.Errorf("--controller: %w", err)
+				}
+				did.Controller = ctrl.String()
+			}
+			return transaction.NewAgentRegisterTx(from, did, nonce, gasPrice), nil
+		})
+	},
+}
+
+var txAgentMessageCmd = &cobra.Command{
+	Use:   "message <from-did> <to-did> <payload>",
+	Short: "Send a message from one agent to another",
+	Args:  cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		msg := transaction.AgentMessage{
+			From:    args[0],
+			To:      args[1],
+			Type:    transaction.MessageType(strings.ToUpper(flagAgentMsgType)),
+			Payload: []byte(args[2]),
+		}
+		switch msg.Type {
+		case transaction.MsgTask, transaction.MsgResult, transaction.MsgDelegate, transaction.MsgRevoke:
+		default:
+			return fmt.Errorf("--type: invalid message type %q", flagAgentMsgType)
+		}
+		if flagAgentMsgRef != "" {
+			network, cid, ok := strings.Cut(flagAgentMsgRef, ":")
+			if !ok {
+				return fmt.Errorf("--payload-ref: want network:cid, got %q", flagAgentMsgRef)
+			}
+			ref := &transaction.DataRef{Network: network, CID: cid}
+			if err := ref.Check(); err != nil {
+				return fmt.Errorf("--payload-ref: %w", err)
+			}
+			msg.PayloadRef = ref
+		}
+		return sendAgentMessage(cmd, msg)
+	},
+}
+
+var txAgentDelegateCmd = &cobra.Command{
+	Use:   "delegate <from-did> <to-did> <capability>",
+	Short: "Delegate a capability (name or name@version) to another agent",
+	Long: "Delegate a capability held by <from-did> to <to-did>. The delegation lapses\n" +
+		"at --expires-at and may be passed on at most --max-depth more times.",
+	Args: cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		caps, err := parseCapabilities(args[2:])
+		if err != nil {
+			return err
+		}
+		payload, _ := json.Marshal(transaction.Delegation{
+			From:       args[0],
+			To:         args[1],
+			Capability: caps[0],
+			ExpiresAt:  flagDelegExpiry,
+			MaxDepth:   flagDelegDepth,
+		})
+		return sendAgentMessage(cmd, transaction.AgentMessage{From: args[0], To: args[1], Type: transaction.MsgDelegate, Payload: payload})
+	},
+}
+
+var txAgentRevokeCmd = &cobra.Command{
+	Use:   "revoke <from-did> <to-did> <capability>",
+	Short: "Revoke a capability delegated to another agent",
+	Args:  cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		payload, _ := json.Marshal(transaction.Revocation{Capability: args[2]})
+		return sendAgentMessage(cmd, transaction.AgentMessage{From: args[0], To: args[1], Type: transaction.MsgRevoke, Payload: payload})
+	},
+}
+
+var txAgentDeactivateCmd = &cobra.Command{
+	Use:   "deactivate <did>",
+	Short: "Deactivate an agent controlled by the signer",
+	Long: "Deactivate an agent. Until it is reactivated the agent can neither send nor\n" +
+		"receive messages and resolves as deactivated.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewAgentDeactivateTx(from, args[0], flagAgentReason, nonce, gasPrice), nil
+		})
+	},
+}
+
+var txAgentReactivateCmd = &cobra.Command{
+	Use:   "reactivate <did>",
+	Short: "Reactivate a deactivated agent controlled by the signer",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewAgentReactivateTx(from, args[0], flagAgentReason, nonce, gasPrice), nil
+		})
+	},
+}
+
+var txStakeCmd = &cobra.Command{
+	Use:   "stake <amount>",
+	Short: "Bond an amount in base units as validator stake",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		amount, err := parseAmount(args[0])
+		if err != nil {
+			return err
+		}
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewValidatorStakeTx(from, amount, nonce, gasPrice), nil
+		})
+	},
+}
+
+func init() {
+	addTxFlags(txCmd.PersistentFlags())
+	txCmd.MarkPersistentFlagRequired("from")
+
+	txAgentRegisterCmd.Flags().StringSliceVar(&flagAgentCaps, "capability", nil, "Capability as name or name@version (repeatable)")
+	txAgentRegisterCmd.Flags().StringToStringVar(&flagAgentMeta, "metadata", nil, "Metadata entries as key=value")
+	txAgentRegisterCmd.Flags().StringVar(&flagAgentCtrl, "controller", "", "Controller address (default: the signer)")
+	txAgentMessageCmd.Flags().StringVar(&flagAgentMsgType, "type", string(transaction.MsgTask), "Message type: TASK, RESULT, DELEGATE or REVOKE")
+	txAgentMessageCmd.Flags().StringVar(&flagAgentMsgRef, "payload-ref", "", "Off-chain payload as network:cid, e.g. ipfs:bafy...")
+
+	for _, c := range []*cobra.Command{txAgentMessageCmd, txAgentDelegateCmd, txAgentRevokeCmd} {
+		c.Flags().Int64Var(&flagAgentMsgNonce, "msg-nonce", -1, "Message nonce of the sending agent (default: queried from the node)")
+	}
+	txAgentDelegateCmd.Flags().Uint64Var(&flagDelegExpiry, "expires-at", 0, "Block height at which the delegation lapses (0 never)")
+	txAgentDelegateCmd.Flags().Uint64Var(&flagDelegDepth, "max-depth", 0, "Times the capability may be re-delegated")
+	for _, c := range []*cobra.Command{txAgentDeactivateCmd, txAgentReactivateCmd} {
+		c.Flags().StringVar(&flagAgentReason, "reason", "", "Reason recorded in the agent's lifecycle")
+	}
+
+	txAgentCmd.AddCommand(txAgentRegisterCmd, txAgentMessageCmd, txAgentDelegateCmd, txAgentRevokeCmd,
+		txAgentDeactivateCmd, txAgentReactivateCmd)
+	txCmd.AddCommand(txTransferCmd, txAgentCmd, txStakeCmd)
+	rootCmd.AddCommand(txCmd)
+}
+
+// sendAgentMessage signs and sends msg, filling in the sending agent's
+// next message nonce from the node unless --msg-nonce is given.
+func sendAgentMessage(cmd *cobra.Command, msg transaction.AgentMessage) error {
+	if flagAgentMsgNonce >= 0 {
+		msg.Nonce = uint64(flagAgentMsgNonce)
+	} else if flagTxOffline {
+		return errors.New("--offline requires --msg-nonce")
+	} else {
+		var rec state.AgentRecord
+		if err := rpcCall("zion_getAgent", &rec, msg.From); err != nil {
+			return err
+		}
+		msg.Nonce = rec.MessageNonce
+	}
+	return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+		return transaction.NewAgentMessageTx(from, msg, nonce, gasPrice), nil
+	})
+}
+
+// addTxFlags adds the flags read by signAndSend to fs.
+func addTxFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&flagTxFrom, "from", "", "Name of the keystore key signing the transaction")
+	fs.StringVar(&flagNode, "node", "http://localhost:8545", "JSON-RPC endpoint of the node")
+	fs.StringVar(&flagNodeAPIKey, "node-api-key", "", "API key or JWT sent to the node")
+	fs.StringVar(&flagPassphraseFile, "passphrase-file", "", "File holding the keystore passphrase")
+	fs.Uint64Var(&flagTxChainID, "chain-id", 0, "Chain ID (default: queried from the node)")
+	fs.Int64Var(&flagTxNonce, "nonce", -1, "Account nonce (default: pending nonce queried from the node)")
+	fs.Uint64Var(&flagTxGas, "gas", 0, "Gas limit (default: the intrinsic gas of the transaction)")
+	fs.StringVar(&flagTxGasPrice, "gas-price", "1", "Gas price in base units")
+	fs.BoolVar(&flagTxOffline, "offline", false, "Sign only and print the raw transaction")
+}
+
+// signAndSend unlocks the --from key, builds the transaction with the
+// resolved nonce and gas price, signs it and either submits it or, with
+// --offline, prints it.
+func signAndSend(cmd *cobra.Command, build func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error)) error {
+	if flagTxOffline && (flagTxChainID == 0 || flagTxNonce < 0) {
+		return errors.New("--offline requires --chain-id and --nonce")
+	}
+	gasPrice, err := parseAmount(flagTxGasPrice)
+	if err != nil {
+		return fmt.Errorf("--gas-price: %w", err)
+	}
+	pass, err := readPassphrase(cmd, false)
+	if err != nil {
+		return err
+	}
+	priv, err := openKeystore().Unlock(flagTxFrom, pass)
+	if err != nil {
+		return err
+	}
+	info, err := openKeystore().Get(flagTxFrom)
+	if err != nil {
+		return err
+	}
+
+	chainID := flagTxChainID
+	if chainID == 0 {
+		var hex string
+		if err := rpcCall("zion_chainId", &hex); err != nil {
+			return err
+		}
+		if chainID, err = parseHexUint(hex); err != nil {
+			return fmt.Errorf("zion_chainId: %w", err)
+		}
+	}
+	nonce := uint64(flagTxNonce)
+	if flagTxNonce < 0 {
+		var hex string
+		if err := rpcCall("zion_getTransactionCount", &hex, info.Address, "pending"); err != nil {
+			return err
+		}
+		if nonce, err = parseHexUint(hex); err != nil {
+			return fmt.Errorf("zion_getTransactionCount: %w", err)
+		}
+	}
+
+	tx, err := build(info.Address, nonce, gasPrice)
+	if err != nil {
+		return err
+	}
+	if flagTxGas != 0 {
+		tx.Gas = flagTxGas
+	}
+	if err := tx.Sign(priv, chainID); err != nil {
+		return err
+	}
+	if err := tx.ValidateBasic(); err != nil {
+		return err
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	if flagTxOffline {
+		fmt.Fprintf(out, "0x%x\n", raw)
+		return nil
+	}
+	var hash string
+	if err := rpcCall("zion_sendRawTransaction", &hash, fmt.Sprintf("0x%x", raw)); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, hash)
+	return nil
+}
+
+// payloadTxCmd returns a command sending the transaction of type typ
+// created by newTx with the payload built from its nargs arguments.
+func payloadTxCmd(use, short string, typ transaction.TxType, newTx func(transaction.TxType, string, interface{}, uint64, *big.Int) *transaction.Tx,
+	nargs int, payload func(args []string) (interface{}, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  cobra.ExactArgs(nargs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := payload(args)
+			if err != nil {
+				return err
+			}
+			return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+				return newTx(typ, from, p, nonce, gasPrice), nil
+			})
+		},
+	}
+}
+
+// parseAmount parses a non-negative decimal amount in base units.
+func parseAmount(s string) (*big.Int, error) {
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok || v.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+	return v, nil
+}
+
+// parseAddressArg parses an address argument into its canonical form.
+func parseAddressArg(s string) (string, error) {
+	addr, err := common.ParseAddress(s)
+	if err != nil {
+		return "", err
+	}
+	return addr.String(), nil
+}
+
+func parseHexUint(s string) (uint64, error) {
+	return strconv.ParseUint(strings.TrimPrefix(s, "0x"), 16, 64)
+}
+
+// parseCapabilities parses name or name@version entries.
+func parseCapabilities(list []string) ([]transaction.Capability, error) {
+	caps := make([]transaction.Capability, 0, len(list))
+	for _, c := range list {
+		name, version, _ := strings.Cut(c, "@")
+		if name == "" {
+			return nil, fmt.Errorf("--capability: invalid value %q", c)
+		}
+		caps = append(caps, transaction.Capability{Name: name, Version: version})
+	}
+	return caps, nil
+}
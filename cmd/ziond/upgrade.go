@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/node"
+)
+
+// upgradeHandlers are the software upgrades this binary handles, by name.
+// A release implementing an upgrade passed by governance adds its handler
+// here; nodes running an older binary halt at the upgrade height.
+var upgradeHandlers = map[string]node.UpgradeHandler{}
+
+var upgradeInfoCmd = &cobra.Command{
+	Use:   "upgrade-info",
+	Short: "Show the software upgrade the node in the data directory halted for",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		info, err := node.ReadUpgradeInfo(flagDataDir)
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
+		if info == nil {
+			_, err := fmt.Fprintln(out, "no upgrade pending")
+			return err
+		}
+		_, handled := upgradeHandlers[info.Name]
+		return printFields(out,
+			"upgrade", info.Name,
+			"height", strconv.FormatUint(info.Height, 10),
+			"info", info.Info,
+			"applied", strconv.FormatBool(info.Applied),
+			"handled by this binary", strconv.FormatBool(handled),
+		)
+	},
+}
+
+var queryUpgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Show the software upgrade scheduled by governance",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return query(cmd, "zion_getUpgradePlan", nil, func(w io.Writer, raw json.RawMessage) error {
+			var plan *transaction.UpgradePlan
+			if err := json.Unmarshal(raw, &plan); err != nil {
+				return err
+			}
+			if plan == nil {
+				_, err := fmt.Fprintln(w, "no upgrade scheduled")
+				return err
+			}
+			return printFields(w, "upgrade", plan.Name, "height", strconv.FormatUint(plan.Height, 10), "info", plan.Info)
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(upgradeInfoCmd)
+	queryCmd.AddCommand(queryUpgradeCmd)
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/zionlayer/zionlayer/core/common"
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/rpc"
+)
+
+var (
+	flagValMoniker    string
+	flagValWebsite    string
+	flagValDetails    string
+	flagValCommission uint64
+)
+
+var validatorCmd = &cobra.Command{
+	Use:   "validator",
+	Short: "Create and operate a validator",
+	Long: "Build, sign and submit validator transactions with a keystore key, and\n" +
+		"show the status of a validator as seen by --node.",
+}
+
+var validatorCreateCmd = &cobra.Command{
+	Use:   "create <amount>",
+	Short: "Register the signer as a validator bonding an amount in base units",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		amount, err := parseAmount(args[0])
+		if err != nil {
+			return err
+		}
+		desc := transaction.ValidatorDescription{Moniker: flagValMoniker, Website: flagValWebsite, Details: flagValDetails, Commission: flagValCommission}
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewValidatorCreateTx(from, amount, desc, nonce, gasPrice), nil
+		})
+	},
+}
+
+var validatorStakeCmd = &cobra.Command{
+	Use:   "stake <amount>",
+	Short: "Add an amount in base units to the validator bond",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		amount, err := parseAmount(args[0])
+		if err != nil {
+			return err
+		}
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewValidatorStakeTx(from, amount, nonce, gasPrice), nil
+		})
+	},
+}
+
+var validatorUnstakeCmd = &cobra.Command{
+	Use:   "unstake <amount>",
+	Short: "Withdraw an amount in base units from the validator bond",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		amount, err := parseAmount(args[0])
+		if err != nil {
+			return err
+		}
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewValidatorUnstakeTx(from, amount, nonce, gasPrice), nil
+		})
+	},
+}
+
+var validatorUnjailCmd = &cobra.Command{
+	Use:   "unjail",
+	Short: "Return the signer's jailed validator to the active set",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+			return transaction.NewValidatorUnjailTx(from, nonce, gasPrice), nil
+		})
+	},
+}
+
+var validatorDelegateCmd = &cobra.Command{
+	Use:   "delegate <validator> <amount>",
+	Short: "Delegate an amount in base units to a validator",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return sendStakeTx(cmd, args, transaction.NewStakeDelegateTx)
+	},
+}
+
+var validatorUndelegateCmd = &cobra.Command{
+	Use:   "undelegate <validator> <amount>",
+	Short: "Withdraw an amount in base units delegated to a validator",
+	Long: "Withdraw an amount in base units delegated to a validator. The amount is\n" +
+		"returned once it has unbonded.",
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return sendStakeTx(cmd, args, transaction.NewStakeUndelegateTx)
+	},
+}
+
+// sendStakeTx signs and sends the delegation transaction built by newTx
+// from the validator and amount arguments.
+func sendStakeTx(cmd *cobra.Command, args []string, newTx func(from, validator string, value *big.Int, nonce uint64, gasPrice *big.Int) *transaction.Tx) error {
+	val, err := common.ParseAddress(args[0])
+	if err != nil {
+		return err
+	}
+	amount, err := parseAmount(args[1])
+	if err != nil {
+		return err
+	}
+	return signAndSend(cmd, func(from string, nonce uint64, gasPrice *big.Int) (*transaction.Tx, error) {
+		return newTx(from, val.String(), amount, nonce, gasPrice), nil
+	})
+}
+
+var validatorDelegationsCmd = &cobra.Command{
+	Use:   "delegations [address]",
+	Short: "List the stake an account has delegated and the stake it has unbonding",
+	Long:  "List the delegations of the account at address, or of the --from key.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, err := accountArg(args)
+		if err != nil {
+			return err
+		}
+		return query(cmd, "zion_getDelegations", []interface{}{addr}, func(w io.Writer, raw json.RawMessage) error {
+			var res rpc.StakeDelegationsResult
+			if err := json.Unmarshal(raw, &res); err != nil {
+				return err
+			}
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "VALIDATOR\tAMOUNT\tREWARDS\tRELEASED AT")
+			for _, d := range res.Delegations {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t-\n", d.Validator, d.Amount, d.Rewards)
+			}
+			for _, u := range res.Unbondings {
+				fmt.Fprintf(tw, "%s\t%s\t-\t%d\n", u.Validator, u.Amount, u.CompleteAt)
+			}
+			return tw.Flush()
+		})
+	},
+}
+
+var validatorStatusCmd = &cobra.Command{
+	Use:   "status [address]",
+	Short: "Show the bond, voting power and block signing record of a validator",
+	Long:  "Show the status of the validator at address, or of the --from key.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, err := accountArg(args)
+		if err != nil {
+			return err
+		}
+		if flagOutput != "text" && flagOutput != "json" {
+			return fmt.Errorf("--output: invalid value %q", flagOutput)
+		}
+		return query(cmd, "zion_getValidatorStatus", []interface{}{addr}, func(w io.Writer, raw json.RawMessage) error {
+			var st rpc.ValidatorStatus
+			if err := json.Unmarshal(raw, &st); err != nil {
+				return err
+			}
+			kv := []string{"address", st.Address}
+			if v := st.Validator; v != nil {
+				status := "bonded"
+				if st.Jailed {
+					status = fmt.Sprintf("jailed, may unjail at height %d", st.JailedUntil)
+				}
+				kv = append(kv,
+					"status", status,
+					"stake", v.Stake,
+					"voting power", strconv.FormatInt(v.VotingPower, 10),
+					"poi score", fmt.Sprintf("%.2f", v.PoIScore),
+				)
+			} else {
+				kv = append(kv, "status", "not in the validator set")
+			}
+			sig := st.Signing
+			last := "never"
+			if sig.LastProposed > 0 {
+				last = fmt.Sprintf("%d (%d blocks ago)", sig.LastProposed, st.Height-sig.LastProposed)
+			}
+			kv = append(kv,
+				"proposed blocks", strconv.FormatUint(sig.ProposedBlocks, 10),
+				"missed blocks", strconv.FormatUint(sig.MissedBlocks, 10),
+				"signing rate", signingRate(sig.ProposedBlocks, sig.MissedBlocks),
+				"last proposed", last,
+			)
+			return printFields(w, kv...)
+		})
+	},
+}
+
+// accountArg returns the address given as the optional argument of a
+// command, or else the address of the --from key.
+func accountArg(args []string) (string, error) {
+	switch {
+	case len(args) == 1:
+		a, err := common.ParseAddress(args[0])
+		if err != nil {
+			return "", err
+		}
+		return a.String(), nil
+	case flagTxFrom != "":
+		info, err := openKeystore().Get(flagTxFrom)
+		if err != nil {
+			return "", err
+		}
+		return info.Address, nil
+	}
+	return "", errors.New("an address or --from is required")
+}
+
+// signingRate formats the share of its turns a validator proposed.
+func signingRate(proposed, missed uint64) string {
+	if proposed+missed == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f%%", 100*float64(proposed)/float64(proposed+missed))
+}
+
+func init() {
+	for _, c := range []*cobra.Command{validatorCreateCmd, validatorStakeCmd, validatorUnstakeCmd, validatorUnjailCmd, validatorDelegateCmd, validatorUndelegateCmd} {
+		addTxFlags(c.Flags())
+		c.MarkFlagRequired("from")
+	}
+	validatorCreateCmd.Flags().StringVar(&flagValMoniker, "moniker", "", "Public name of the validator")
+	validatorCreateCmd.Flags().StringVar(&flagValWebsite, "website", "", "Website of the validator")
+	validatorCreateCmd.Flags().StringVar(&flagValDetails, "details", "", "Free-form description")
+	validatorCreateCmd.Flags().Uint64Var(&flagValCommission, "commission", 0, "Share of the block rewards kept before sharing with delegators, in basis points")
+	validatorCreateCmd.MarkFlagRequired("moniker")
+
+	for _, c := range []*cobra.Command{validatorStatusCmd, validatorDelegationsCmd} {
+		sf := c.Flags()
+		sf.StringVar(&flagTxFrom, "from", "", "Name of a keystore key whose address to show")
+		sf.StringVar(&flagNode, "node", "http://localhost:8545", "JSON-RPC endpoint of the node")
+		sf.StringVar(&flagNodeAPIKey, "node-api-key", "", "API key or JWT sent to the node")
+		sf.StringVarP(&flagOutput, "output", "o", "text", "Output format: text or json")
+	}
+
+	validatorCmd.AddCommand(validatorCreateCmd, validatorStakeCmd, validatorUnstakeCmd, validatorUnjailCmd,
+		validatorDelegateCmd, validatorUndelegateCmd, validatorDelegationsCmd, validatorStatusCmd)
+	rootCmd.AddCommand(validatorCmd)
+}
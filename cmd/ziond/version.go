@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/zionlayer/zionlayer/version"
+)
+
+var flagVersionJSON bool
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the version and build information",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		info := version.Get()
+		out := cmd.OutOrStdout()
+		if flagVersionJSON {
+			b, err := json.MarshalIndent(info, "", "  ")
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(out, string(b))
+			return err
+		}
+		commit := info.Commit
+		if info.Modified {
+			commit += " (modified)"
+		}
+		return printFields(out,
+			"version", info.Version,
+			"commit", commit,
+			"build date", info.BuildDate,
+			"go", info.GoVersion,
+			"platform", info.Platform,
+		)
+	},
+}
+
+func init() {
+	versionCmd.Flags().BoolVar(&flagVersionJSON, "json", false, "Print as JSON")
+	rootCmd.AddCommand(versionCmd)
+}
@@ -0,0 +1,88 @@
+package consensus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/zionlayer/zionlayer/core/block"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/vm"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidTx           = errors.New("block contains an invalid transaction")
+	ErrTxRootMismatch      = errors.New("block tx root does not match transactions")
+	ErrReceiptRootMismatch = errors.New("block receipt root does not match execution")
+	ErrGasUsedMismatch     = errors.New("block gas used does not match execution")
+)
+
+// FinalizedBlock is a block produced by the engine with the receipts of its
+// transactions, in block order.
+type FinalizedBlock struct {
+	Block    *block.Block
+	Receipts []*transaction.Receipt
+}
+
+// SetExecutor sets the AVM that applies the transactions of blocks,
+// authenticated for the chain ID set by SetNetwork. Without one, blocks are
+// produced and validated without executing their transactions. It must be
+// called before Start.
+func (e *ZionBFT) SetExecutor(avm *vm.AVM) {
+	e.avm = avm
+}
+
+// executeBlock applies b to st: its transactions in order, then the block
+// reward, the proposer turn and the settlements due at its height. With strict, a transaction
+// the AVM rejects, one that cannot be included even as failed, makes the
+// block invalid; otherwise it is dropped from b.Txs, as the proposer does
+// for transactions that became invalid in the pool, and b.Header.TxRoot is
+// updated. It returns the receipts of the transactions and the gas they
+// used.
+func (e *ZionBFT) executeBlock(ctx context.Context, st *state.StateDB, b *block.Block, strict bool) ([]*transaction.Receipt, uint64, error) {
+	var (
+		receipts []*transaction.Receipt
+		gasUsed  uint64
+	)
+	if e.avm != nil {
+		ectx := &vm.ExecutionContext{
+			Height:       b.Header.Height,
+			Time:         b.Header.Timestamp,
+			ChainID:      e.chainID,
+			Coinbase:     string(b.Header.ValidatorAddr),
+			State:        st,
+			Quiet:        strict,
+			TraceContext: ctx,
+		}
+		included := b.Txs[:0:0]
+		for i, tx := range b.Txs {
+			receipt, err := e.avm.ApplyTransaction(ectx, tx)
+			if receipt == nil {
+				if strict {
+					return nil, 0, fmt.Errorf("%w: tx %d: %v", ErrInvalidTx, i, err)
+				}
+				e.logger.Debug("transaction dropped", zap.String("tx", fmt.Sprintf("0x%x", tx.Hash())), zap.Error(err))
+				continue
+			}
+			included = append(included, tx)
+			receipts = append(receipts, receipt)
+			gasUsed += receipt.GasUsed
+		}
+		if !strict && len(included) < len(b.Txs) {
+			b.Txs = included
+			b.Header.TxRoot = block.TxRoot(included)
+		}
+	}
+	height := b.Header.Height
+	st.MintBlockReward(string(b.Header.ValidatorAddr), height)
+	st.RecordProposer(string(b.Header.ValidatorAddr), height)
+	st.SettleStaking(height)
+	st.SettleDisputes(height)
+	st.SettleProviders(height)
+	st.SettleEvaluations(height)
+	st.SettleDataAttestations(height)
+	st.SettleProposals(height)
+	return receipts, gasUsed, nil
+}
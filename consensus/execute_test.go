@@ -0,0 +1,201 @@
+package consensus
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/zionlayer/zionlayer/core/block"
+	"github.com/zionlayer/zionlayer/core/crypto"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/vm"
+	"go.uber.org/zap"
+)
+
+const (
+	testChainID  = 7
+	testContract = "0x00000000000000000000000000000000000000c0"
+)
+
+var testGenesis = [32]byte{1}
+
+// testEngine returns an engine over st that executes and validates blocks
+// proposed by the holder of proposer.
+func testEngine(t *testing.T, st *state.StateDB, proposer crypto.PrivateKey) *ZionBFT {
+	t.Helper()
+	pub := proposer.Public().(crypto.PublicKey)
+	e := NewZionBFT(st, zap.NewNop())
+	e.SetExecutor(vm.NewAVM(zap.NewNop()))
+	e.SetNetwork(testChainID, testGenesis)
+	e.SetSigner(proposer)
+	if err := e.AddValidator(&Validator{
+		Address:   crypto.PubkeyToAddress(pub).String(),
+		PublicKey: pub,
+		Stake:     st.GetConsensusParams().MinValidatorStake,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+// TestValidatorMatchesProposer checks that a validator executing a block
+// with transactions failing partway through arrives at the receipts and
+// state of its proposer.
+func TestValidatorMatchesProposer(t *testing.T) {
+	proposer, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := crypto.PubkeyToAddress(sender.Public().(crypto.PublicKey)).String()
+
+	genesis := state.NewStateDB()
+	genesis.SetBalance(from, new(big.Int).Mul(big.NewInt(1e6), big.NewInt(1e18)))
+	// Store "v" under "k", then revert.
+	genesis.SetCode(testContract, []byte{
+		byte(vm.OpPush), 1, 'v', byte(vm.OpPush), 1, 'k', byte(vm.OpSStore), byte(vm.OpRevert),
+	})
+
+	gasPrice := big.NewInt(1e9)
+	txs := []*transaction.Tx{
+		transaction.NewTransferTx(from, testContract, big.NewInt(1), 0, gasPrice),
+		{Type: transaction.TxCallContract, To: testContract, Value: new(big.Int), Gas: 100000, GasPrice: gasPrice, Nonce: 1},
+		{Type: transaction.TxCallContract, To: testContract, Value: new(big.Int), Gas: 30000, GasPrice: gasPrice, Nonce: 2},
+	}
+	for _, tx := range txs {
+		if err := tx.Sign(sender, testChainID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := testEngine(t, genesis.Copy(), proposer)
+	v := testEngine(t, genesis.Copy(), proposer)
+	addr := crypto.PubkeyToAddress(proposer.Public().(crypto.PublicKey)).String()
+	p.state.ActivateParams(1)
+	fb := p.proposeBlock(context.Background(), addr, txs)
+
+	if len(fb.Block.Txs) != len(txs) {
+		t.Fatalf("block holds %d transactions, want %d", len(fb.Block.Txs), len(txs))
+	}
+	for i, want := range []transaction.ReceiptStatus{transaction.ReceiptSuccess, transaction.ReceiptFailed, transaction.ReceiptFailed} {
+		if got := fb.Receipts[i].Status; got != want {
+			t.Errorf("receipt %d status = %v, want %v", i, got, want)
+		}
+	}
+	if got := p.state.GetStorage(testContract, []byte("k")); got != nil {
+		t.Errorf("proposer kept storage %q of a failed call", got)
+	}
+	if err := v.ValidateBlock(fb.Block); err != nil {
+		t.Fatalf("ValidateBlock: %v", err)
+	}
+
+	st := v.state.Copy()
+	st.ActivateParams(1)
+	receipts, _, err := v.executeBlock(context.Background(), st, fb.Block, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := block.ReceiptRoot(receipts), block.ReceiptRoot(fb.Receipts); got != want {
+		t.Errorf("validator receipt root = %x, proposer %x", got, want)
+	}
+	if got, want := st.StateRoot(), p.state.StateRoot(); got != want {
+		t.Errorf("validator state root = %x, proposer %x", got, want)
+	}
+
+	tampered := *fb.Block
+	tampered.Header.ReceiptRoot = [32]byte{}
+	if err := tampered.Header.Sign(proposer); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.ValidateBlock(&tampered); !errors.Is(err, ErrReceiptRootMismatch) {
+		t.Errorf("ValidateBlock with a wrong receipt root = %v, want %v", err, ErrReceiptRootMismatch)
+	}
+}
+
+func TestValidateBlockSignature(t *testing.T) {
+	proposer, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis := state.NewStateDB()
+	p := testEngine(t, genesis.Copy(), proposer)
+	v := testEngine(t, genesis.Copy(), proposer)
+	addr := crypto.PubkeyToAddress(proposer.Public().(crypto.PublicKey)).String()
+	p.state.ActivateParams(1)
+	b := p.proposeBlock(context.Background(), addr, nil).Block
+	if err := v.ValidateBlock(b); err != nil {
+		t.Fatalf("ValidateBlock: %v", err)
+	}
+
+	unsigned := *b
+	unsigned.Header.Signature = nil
+	forged := *b
+	if err := forged.Header.Sign(other); err != nil {
+		t.Fatal(err)
+	}
+	for name, b := range map[string]*block.Block{"unsigned": &unsigned, "signed by another key": &forged} {
+		if err := v.ValidateBlock(b); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("ValidateBlock of a block %s = %v, want %v", name, err, ErrInvalidSignature)
+		}
+	}
+}
+
+func TestValidateBlockTxRoot(t *testing.T) {
+	proposer, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := crypto.PubkeyToAddress(sender.Public().(crypto.PublicKey)).String()
+	genesis := state.NewStateDB()
+	genesis.SetBalance(from, new(big.Int).Mul(big.NewInt(1e6), big.NewInt(1e18)))
+	txs := make([]*transaction.Tx, 3)
+	for i := range txs {
+		txs[i] = transaction.NewTransferTx(from, testContract, big.NewInt(int64(i+1)), uint64(i), big.NewInt(1e9))
+		if err := txs[i].Sign(sender, testChainID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := testEngine(t, genesis.Copy(), proposer)
+	v := testEngine(t, genesis.Copy(), proposer)
+	addr := crypto.PubkeyToAddress(proposer.Public().(crypto.PublicKey)).String()
+	p.state.ActivateParams(1)
+	b := p.proposeBlock(context.Background(), addr, txs).Block
+	if err := v.ValidateBlock(b); err != nil {
+		t.Fatalf("ValidateBlock: %v", err)
+	}
+
+	// The header is signed, so tampering with it takes re-signing; the
+	// transactions are not.
+	root := *b
+	root.Header.TxRoot = [32]byte{}
+	if err := root.Header.Sign(proposer); err != nil {
+		t.Fatal(err)
+	}
+	dropped := *b
+	dropped.Txs = b.Txs[:2]
+	reordered := *b
+	reordered.Txs = []*transaction.Tx{b.Txs[1], b.Txs[0], b.Txs[2]}
+	for name, b := range map[string]*block.Block{
+		"with a wrong root":  &root,
+		"missing a tx":       &dropped,
+		"with txs reordered": &reordered,
+	} {
+		if err := v.ValidateBlock(b); !errors.Is(err, ErrTxRootMismatch) {
+			t.Errorf("ValidateBlock of a block %s = %v, want %v", name, err, ErrTxRootMismatch)
+		}
+	}
+}
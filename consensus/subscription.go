@@ -0,0 +1,179 @@
+package consensus
+
+import (
+	"errors"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// BlockLagWarning is the number of finalized blocks queued for a
+	// subscriber past which the engine warns that it is lagging, and again
+	// at every further multiple.
+	BlockLagWarning = 64
+	// MaxBlockLag is the number of finalized blocks queued for a subscriber
+	// past which it is disconnected.
+	MaxBlockLag = 1024
+)
+
+var ErrSubscriberLagged = errors.New("block subscriber fell too far behind")
+
+// BlockSubscription delivers every block finalized by the engine after it
+// was created, in height order. Up to MaxBlockLag blocks are queued for the
+// subscriber, so none is ever skipped: a subscriber that falls behind is
+// reported through Lag and the engine's log, and one that falls further
+// behind is disconnected, which Err reports, instead.
+type BlockSubscription struct {
+	ch   chan *FinalizedBlock
+	quit chan struct{}
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  []*FinalizedBlock
+	closed bool  // no more blocks will be queued
+	err    error // why the subscription ended early
+	unsub  sync.Once
+	engine *ZionBFT
+}
+
+// SubscribeBlocks returns a subscription to the blocks finalized from now
+// on. Its channel is closed once the engine stops and every block finalized
+// before has been received. Subscribe before Start to receive every block.
+func (e *ZionBFT) SubscribeBlocks() *BlockSubscription {
+	s := &BlockSubscription{
+		ch:     make(chan *FinalizedBlock),
+		quit:   make(chan struct{}),
+		engine: e,
+	}
+	s.cond = sync.NewCond(&s.mu)
+	e.subMu.Lock()
+	if e.subsClosed {
+		s.closed = true
+	} else {
+		e.subs[s] = struct{}{}
+	}
+	e.subMu.Unlock()
+	go s.forward()
+	return s
+}
+
+// Blocks returns the channel the blocks are delivered on.
+func (s *BlockSubscription) Blocks() <-chan *FinalizedBlock {
+	return s.ch
+}
+
+// Lag returns the number of finalized blocks waiting to be received.
+func (s *BlockSubscription) Lag() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue)
+}
+
+// Err returns ErrSubscriberLagged once the subscription has been
+// disconnected for falling MaxBlockLag blocks behind, and nil otherwise. Its
+// channel is closed without the queued blocks in that case, so a subscriber
+// should check Err once the channel is closed.
+func (s *BlockSubscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Unsubscribe stops delivery, discarding the queued blocks, and closes the
+// channel. It is safe to call more than once.
+func (s *BlockSubscription) Unsubscribe() {
+	s.unsub.Do(func() {
+		s.engine.subMu.Lock()
+		delete(s.engine.subs, s)
+		s.engine.subMu.Unlock()
+		close(s.quit)
+		s.mu.Lock()
+		s.queue, s.closed = nil, true
+		s.cond.Signal()
+		s.mu.Unlock()
+	})
+}
+
+// push queues b and returns the resulting lag. It fails with
+// ErrSubscriberLagged, ending the subscription, if MaxBlockLag blocks are
+// already queued.
+func (s *BlockSubscription) push(b *FinalizedBlock) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, nil
+	}
+	if len(s.queue) >= MaxBlockLag {
+		s.queue, s.closed, s.err = nil, true, ErrSubscriberLagged
+		s.cond.Signal()
+		return MaxBlockLag, ErrSubscriberLagged
+	}
+	s.queue = append(s.queue, b)
+	s.cond.Signal()
+	return len(s.queue), nil
+}
+
+// close marks the end of the blocks; the channel is closed once the queue
+// is drained.
+func (s *BlockSubscription) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.cond.Signal()
+	s.mu.Unlock()
+}
+
+// forward moves queued blocks to the channel until the subscription is
+// closed and drained or unsubscribed.
+func (s *BlockSubscription) forward() {
+	defer close(s.ch)
+	for {
+		s.mu.Lock()
+		for len(s.queue) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			return
+		}
+		b := s.queue[0]
+		s.queue[0] = nil
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+		select {
+		case s.ch <- b:
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// publish queues b for every subscriber, warning about those lagging and
+// disconnecting those too far behind.
+func (e *ZionBFT) publish(b *FinalizedBlock) {
+	e.subMu.Lock()
+	defer e.subMu.Unlock()
+	for s := range e.subs {
+		lag, err := s.push(b)
+		if err != nil {
+			delete(e.subs, s)
+			e.logger.Error("block subscriber disconnected", zap.Error(err), zap.Uint64("height", b.Block.Header.Height))
+			continue
+		}
+		if lag >= BlockLagWarning && lag%BlockLagWarning == 0 {
+			e.logger.Warn("block subscriber lagging", zap.Int("lag", lag), zap.Uint64("height", b.Block.Header.Height))
+		}
+	}
+}
+
+// closeSubscriptions ends every subscription once its queued blocks are
+// received. Later subscriptions are closed at once.
+func (e *ZionBFT) closeSubscriptions() {
+	e.subMu.Lock()
+	defer e.subMu.Unlock()
+	e.subsClosed = true
+	for s := range e.subs {
+		s.close()
+		delete(e.subs, s)
+	}
+}
@@ -0,0 +1,46 @@
+package consensus
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zionlayer/zionlayer/core/block"
+	"github.com/zionlayer/zionlayer/core/state"
+	"go.uber.org/zap"
+)
+
+func TestSubscriberLagged(t *testing.T) {
+	e := NewZionBFT(state.NewStateDB(), zap.NewNop())
+	slow := e.SubscribeBlocks()
+	fast := e.SubscribeBlocks()
+	defer fast.Unsubscribe()
+
+	received := make(chan uint64, MaxBlockLag+2)
+	go func() {
+		for fb := range fast.Blocks() {
+			received <- fb.Block.Header.Height
+		}
+	}()
+	// The forwarder of slow holds one block on its channel beyond the
+	// queue.
+	for h := uint64(1); h <= MaxBlockLag+2; h++ {
+		e.publish(&FinalizedBlock{Block: &block.Block{Header: block.Header{Height: h}}})
+		if got := <-received; got != h {
+			t.Fatalf("fast subscriber received height %d, want %d", got, h)
+		}
+	}
+
+	n := 0
+	for range slow.Blocks() {
+		n++
+	}
+	if n > 1 {
+		t.Errorf("disconnected subscriber received %d blocks, want at most 1", n)
+	}
+	if err := slow.Err(); !errors.Is(err, ErrSubscriberLagged) {
+		t.Errorf("Err = %v, want %v", err, ErrSubscriberLagged)
+	}
+	if err := fast.Err(); err != nil {
+		t.Errorf("Err of a subscriber keeping up = %v", err)
+	}
+}
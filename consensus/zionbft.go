@@ -1,27 +1,36 @@
 package consensus
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"math/big"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/zionlayer/zionlayer/core/block"
+	"github.com/zionlayer/zionlayer/core/crypto"
+	"github.com/zionlayer/zionlayer/core/mempool"
 	"github.com/zionlayer/zionlayer/core/state"
 	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/telemetry"
+	"github.com/zionlayer/zionlayer/vm"
 	"go.uber.org/zap"
 )
 
 const (
-	BlockTime       = 2 * time.Second
-	MinValidatorStake = 10_000 // in ZIO base units (×10^18)
-	BlockReward     = 5       // ZIO per block
+	BlockTime = 2 * time.Second
 )
 
 var (
 	ErrInvalidBlock     = errors.New("invalid block")
 	ErrInvalidSignature = errors.New("invalid block signature")
 	ErrUnknownValidator = errors.New("unknown validator")
+	ErrBlockGasLimit    = errors.New("block exceeds gas limit")
+	ErrAgentRootMismatch = errors.New("block agent root does not match agent state")
+	ErrStateRootMismatch = errors.New("block state root does not match state")
+	ErrWrongNetwork      = errors.New("block is from another network")
 )
 
 // Validator represents a staked network validator.
@@ -33,28 +42,57 @@ type Validator struct {
 	VotingPower int64
 }
 
+// SigningInfo tracks how a validator has performed as block proposer. A
+// block counts as missed by the validator whose turn it was in the
+// round-robin over the validators not jailed when another validator
+// proposed it.
+type SigningInfo struct {
+	ProposedBlocks uint64 `json:"proposedBlocks"`
+	MissedBlocks   uint64 `json:"missedBlocks"`
+	LastProposed   uint64 `json:"lastProposed"` // height, 0 if none
+}
+
 // ZionBFT is the hybrid PoS + PoI consensus engine.
 type ZionBFT struct {
 	mu         sync.RWMutex
 	validators map[string]*Validator
+	signing    map[string]*SigningInfo
 	state      *state.StateDB
 	logger     *zap.Logger
 	height     uint64
 	tip        *block.Block
+	blockTime  time.Duration
+	proposer   string // set by Start
+	running    bool
+	upgrades   map[string]bool // upgrades handled by this binary
+	signer     crypto.PrivateKey
+	halted     *transaction.UpgradePlan
+	avm        *vm.AVM // applies block transactions; see SetExecutor
+	chainID    uint64
+	genesis    [32]byte // hash of the genesis of the network
+
+	// block subscribers; see subscription.go
+	subMu      sync.Mutex
+	subs       map[*BlockSubscription]struct{}
+	subsClosed bool
 
 	// channels
-	blockCh chan *block.Block
-	quitCh  chan struct{}
+	quitCh chan struct{}
+	doneCh chan struct{}
+	stop   sync.Once
 }
 
 // NewZionBFT creates a new consensus engine.
 func NewZionBFT(stateDB *state.StateDB, logger *zap.Logger) *ZionBFT {
 	return &ZionBFT{
 		validators: make(map[string]*Validator),
+		signing:    make(map[string]*SigningInfo),
 		state:      stateDB,
 		logger:     logger,
-		blockCh:    make(chan *block.Block, 64),
+		blockTime:  BlockTime,
+		subs:       make(map[*BlockSubscription]struct{}),
 		quitCh:     make(chan struct{}),
+		doneCh:     make(chan struct{}),
 	}
 }
 
@@ -62,8 +100,7 @@ func NewZionBFT(stateDB *state.StateDB, logger *zap.Logger) *ZionBFT {
 func (e *ZionBFT) AddValidator(v *Validator) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	minStake := new(big.Int).Mul(big.NewInt(MinValidatorStake), new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
-	if v.Stake.Cmp(minStake) < 0 {
+	if v.Stake.Cmp(e.state.GetConsensusParams().MinValidatorStake) < 0 {
 		return errors.New("stake below minimum")
 	}
 	e.validators[v.Address] = v
@@ -71,90 +108,330 @@ func (e *ZionBFT) AddValidator(v *Validator) error {
 	return nil
 }
 
-// Start begins block production.
-func (e *ZionBFT) Start(proposerAddr string, txPool <-chan []*transaction.Tx) {
-	go e.runProposer(proposerAddr, txPool)
+// Validators returns a copy of the validator set with voting power filled
+// in, ordered by descending voting power and then address.
+func (e *ZionBFT) Validators() []*Validator {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	out := make([]*Validator, 0, len(e.validators))
+	for _, v := range e.validators {
+		cp := *v
+		cp.Stake = new(big.Int).Set(v.Stake)
+		cp.VotingPower = e.VotingPower(v)
+		out = append(out, &cp)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].VotingPower != out[j].VotingPower {
+			return out[i].VotingPower > out[j].VotingPower
+		}
+		return out[i].Address < out[j].Address
+	})
+	return out
 }
 
-// Stop halts the consensus engine.
-func (e *ZionBFT) Stop() {
-	close(e.quitCh)
+// SetBlockTime changes the interval between proposed blocks from BlockTime.
+// It must be called before Start.
+func (e *ZionBFT) SetBlockTime(d time.Duration) {
+	e.blockTime = d
+}
+
+// SetSigner sets the key the engine signs the headers of its blocks with,
+// which must be the key of the proposer. Blocks are left unsigned without
+// one. It must be called before Start.
+func (e *ZionBFT) SetSigner(priv crypto.PrivateKey) {
+	e.signer = priv
+}
+
+// SetNetwork sets the identity of the network, its chain ID and genesis
+// hash, which the engine puts in the headers of its blocks and requires in
+// those it validates. It must be called before Start.
+func (e *ZionBFT) SetNetwork(chainID uint64, genesisHash [32]byte) {
+	e.chainID, e.genesis = chainID, genesisHash
+}
+
+// SetKnownUpgrades names the software upgrades this binary handles; the
+// engine halts at the height of any other upgrade passed by governance.
+// It must be called before Start.
+func (e *ZionBFT) SetKnownUpgrades(names ...string) {
+	e.upgrades = make(map[string]bool, len(names))
+	for _, name := range names {
+		e.upgrades[name] = true
+	}
+}
+
+// Halted returns the upgrade the engine halted for, or nil if it has not.
+func (e *ZionBFT) Halted() *transaction.UpgradePlan {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.halted
+}
+
+// upgradeNeeded returns the upgrade passed by governance that this binary
+// does not handle if the next block is at or past its height.
+func (e *ZionBFT) upgradeNeeded() *transaction.UpgradePlan {
+	plan := e.state.GetUpgradePlan()
+	if plan == nil || e.upgrades[plan.Name] {
+		return nil
+	}
+	if e.nextHeight() < plan.Height {
+		return nil
+	}
+	return plan
+}
+
+// nextHeight returns the height of the next block.
+func (e *ZionBFT) nextHeight() uint64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.height + 1
 }
 
-// Blocks returns the channel of finalized blocks.
-func (e *ZionBFT) Blocks() <-chan *block.Block {
-	return e.blockCh
+// Start begins block production, pulling transactions from pool at each
+// block time.
+func (e *ZionBFT) Start(proposerAddr string, pool *mempool.Pool) {
+	e.mu.Lock()
+	e.proposer, e.running = proposerAddr, true
+	e.mu.Unlock()
+	go e.runProposer(proposerAddr, pool)
+}
+
+// Running reports whether the engine is producing blocks, and as which
+// proposer.
+func (e *ZionBFT) Running() (proposer string, running bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.proposer, e.running
 }
 
-// ValidateBlock checks block validity.
+// Stop halts the consensus engine and waits for the block in progress to
+// be finished, after which the block subscriptions are closed. It must only
+// be called after Start.
+func (e *ZionBFT) Stop() {
+	e.stop.Do(func() { close(e.quitCh) })
+	<-e.doneCh
+}
+
+// ValidateBlock checks block validity. The block must be of the network of
+// the engine, signed by the validator it names, and its TxRoot must commit
+// to the transactions; the block is then executed on a copy of the local
+// state, which its GasUsed, ReceiptRoot, AgentRoot and StateRoot must
+// match.
 func (e *ZionBFT) ValidateBlock(b *block.Block) error {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
+	if b.Header.ChainID != e.chainID || b.Header.GenesisHash != e.genesis {
+		return fmt.Errorf("%w: chain %d genesis %x", ErrWrongNetwork, b.Header.ChainID, b.Header.GenesisHash)
+	}
 	v, ok := e.validators[string(b.Header.ValidatorAddr)]
 	if !ok {
 		return ErrUnknownValidator
 	}
-	_ = v // signature verification would go here
+	if err := b.Header.VerifySignature(v.PublicKey); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
 
 	if b.Header.Height != e.height+1 {
 		return ErrInvalidBlock
 	}
-	prevHash := e.tip.Hash()
+	var prevHash [32]byte
+	if e.tip != nil {
+		prevHash = e.tip.Hash()
+	}
 	if b.Header.PrevHash != prevHash {
 		return ErrInvalidBlock
 	}
+
+	params := e.state.GetConsensusParams()
+	if len(b.Txs) > params.MaxBlockTxs {
+		return ErrInvalidBlock
+	}
+	if root := block.TxRoot(b.Txs); b.Header.TxRoot != root {
+		return fmt.Errorf("%w: block %x, computed %x", ErrTxRootMismatch, b.Header.TxRoot, root)
+	}
+	// Check the signatures in parallel now, so that executing the block
+	// finds them in the signature cache.
+	transaction.Preverify(b.Txs...)
+	var gas uint64
+	for _, tx := range b.Txs {
+		if err := tx.ValidateBasic(); err != nil {
+			return err
+		}
+		if tx.Gas > params.BlockGasLimit-gas {
+			return ErrBlockGasLimit
+		}
+		gas += tx.Gas
+	}
+	st := e.state.Copy()
+	st.ActivateParams(b.Header.Height)
+	receipts, gasUsed, err := e.executeBlock(context.Background(), st, b, true)
+	if err != nil {
+		return err
+	}
+	if b.Header.GasUsed != gasUsed {
+		return fmt.Errorf("%w: block %d, executed %d", ErrGasUsedMismatch, b.Header.GasUsed, gasUsed)
+	}
+	if root := block.ReceiptRoot(receipts); b.Header.ReceiptRoot != root {
+		return fmt.Errorf("%w: block %x, local %x", ErrReceiptRootMismatch, b.Header.ReceiptRoot, root)
+	}
+	if root := st.AgentRoot(); b.Header.AgentRoot != root {
+		return fmt.Errorf("%w: block %x, local %x", ErrAgentRootMismatch, b.Header.AgentRoot, root)
+	}
+	if root := st.StateRoot(); b.Header.StateRoot != root {
+		return fmt.Errorf("%w: block %x, local %x", ErrStateRootMismatch, b.Header.StateRoot, root)
+	}
 	return nil
 }
 
-// runProposer produces blocks at BlockTime intervals.
-func (e *ZionBFT) runProposer(addr string, txPool <-chan []*transaction.Tx) {
-	ticker := time.NewTicker(BlockTime)
+// runProposer produces blocks at the configured block time until the
+// engine is stopped or halts for a software upgrade it does not handle.
+func (e *ZionBFT) runProposer(addr string, pool *mempool.Pool) {
+	ticker := time.NewTicker(e.blockTime)
 	defer ticker.Stop()
+	defer close(e.doneCh)
+	defer e.closeSubscriptions()
+	defer func() {
+		e.mu.Lock()
+		e.running = false
+		e.mu.Unlock()
+	}()
 
 	for {
 		select {
 		case <-e.quitCh:
 			return
 		case <-ticker.C:
-			var txs []*transaction.Tx
-			select {
-			case batch := <-txPool:
-				txs = batch
-			default:
-				txs = []*transaction.Tx{}
+			if plan := e.upgradeNeeded(); plan != nil {
+				e.logger.Error(fmt.Sprintf("UPGRADE %q NEEDED at height %d: %s", plan.Name, plan.Height, plan.Info),
+					zap.String("upgrade", plan.Name), zap.Uint64("height", plan.Height))
+				e.mu.Lock()
+				e.halted = plan
+				e.mu.Unlock()
+				return
+			}
+			ctx, span := telemetry.Start(context.Background(), "consensus.produce_block")
+			for _, v := range e.state.ActivateParams(e.nextHeight()) {
+				if v.Error != "" {
+					e.logger.Error("parameter fork failed", zap.Uint64("height", v.Height), zap.String("error", v.Error))
+				} else {
+					e.logger.Info("parameter fork activated", zap.Uint64("height", v.Height), zap.Int("changes", len(v.Changes)))
+				}
 			}
+			params := e.state.GetConsensusParams()
+			txs := pool.PopContext(ctx, params.MaxBlockTxs, params.BlockGasLimit)
 
+			fb := e.proposeBlock(ctx, addr, txs)
+			b := fb.Block
+			// In production: broadcast for votes
 			e.mu.Lock()
-			var prevHash [32]byte
-			if e.tip != nil {
-				prevHash = e.tip.Hash()
-			}
-			b := block.NewBlock(e.height+1, prevHash, []byte(addr), txs)
-			// In production: compute state root, sign block, broadcast for votes
 			e.height++
 			e.tip = b
+			e.recordProposal(b.Header.Height, addr)
 			e.mu.Unlock()
 
-			e.applyBlockReward(addr)
-			e.logger.Info("block proposed", zap.Uint64("height", b.Header.Height), zap.Int("txs", len(txs)))
+			e.updateStakes()
+			e.updatePoIScores()
+			span.SetAttributes(telemetry.AttrBlockHeight.Int64(int64(b.Header.Height)), telemetry.AttrBlockTxs.Int(len(b.Txs)))
+			span.End()
+			e.logger.Info("block proposed", zap.Uint64("height", b.Header.Height), zap.Int("txs", len(b.Txs)), zap.Uint64("gasUsed", b.Header.GasUsed))
 
-			select {
-			case e.blockCh <- b:
-			default:
-			}
+			e.publish(fb)
 		}
 	}
 }
 
-func (e *ZionBFT) applyBlockReward(validatorAddr string) {
-	reward := new(big.Int).Mul(
-		big.NewInt(BlockReward),
-		new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil),
-	)
-	acc := e.state.GetAccount(validatorAddr)
-	newBal := new(big.Int).Add(acc.Balance, reward)
-	e.state.SetBalance(validatorAddr, newBal)
+// proposeBlock builds the block of txs by addr at the next height, applies
+// it to the state and signs it.
+func (e *ZionBFT) proposeBlock(ctx context.Context, addr string, txs []*transaction.Tx) *FinalizedBlock {
+	e.mu.RLock()
+	var prevHash [32]byte
+	if e.tip != nil {
+		prevHash = e.tip.Hash()
+	}
+	b := block.NewBlock(e.height+1, prevHash, []byte(addr), txs)
+	e.mu.RUnlock()
+	b.Header.ChainID, b.Header.GenesisHash = e.chainID, e.genesis
+	// The block is executed on a copy of the state, swapped in once its
+	// roots are computed, so that readers never see it half-applied. A
+	// transaction the AVM rejects leaves the copy untouched and is dropped
+	// from the block.
+	st := e.state.Copy()
+	receipts, gasUsed, _ := e.executeBlock(ctx, st, b, false)
+	b.Header.GasUsed = gasUsed
+	b.Header.ReceiptRoot = block.ReceiptRoot(receipts)
+	b.Header.AgentRoot = st.AgentRoot()
+	b.Header.StateRoot = st.StateRoot()
+	e.state.Replace(st)
+	if e.signer != nil {
+		if err := b.Header.Sign(e.signer); err != nil {
+			e.logger.Error("block signing failed", zap.Error(err))
+		}
+	}
+	return &FinalizedBlock{Block: b, Receipts: receipts}
+}
+
+// recordProposal updates the signing info for a block proposed by addr at
+// height. The caller holds e.mu.
+func (e *ZionBFT) recordProposal(height uint64, addr string) {
+	info := e.signingInfo(addr)
+	info.ProposedBlocks++
+	info.LastProposed = height
+	order := make([]string, 0, len(e.validators))
+	for a := range e.validators {
+		// Jailed validators have no turns; see state.RecordProposer.
+		if sv, err := e.state.GetStakingValidator(a); err == nil && sv.Jailed {
+			continue
+		}
+		order = append(order, a)
+	}
+	if len(order) == 0 {
+		return
+	}
+	sort.Strings(order)
+	if expected := order[height%uint64(len(order))]; expected != addr {
+		e.signingInfo(expected).MissedBlocks++
+	}
+}
+
+func (e *ZionBFT) signingInfo(addr string) *SigningInfo {
+	info, ok := e.signing[addr]
+	if !ok {
+		info = &SigningInfo{}
+		e.signing[addr] = info
+	}
+	return info
+}
+
+// SigningInfo returns the proposer record of addr.
+func (e *ZionBFT) SigningInfo(addr string) SigningInfo {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if info, ok := e.signing[addr]; ok {
+		return *info
+	}
+	return SigningInfo{}
+}
+
+// updateStakes sets the stake of each validator registered in the state to
+// its bonded stake, delegations included.
+func (e *ZionBFT) updateStakes() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for addr, v := range e.validators {
+		if sv, err := e.state.GetStakingValidator(addr); err == nil {
+			v.Stake = new(big.Int).Set(sv.Tokens)
+		}
+	}
+}
+
+// updatePoIScores sets the Proof-of-Intelligence score of each validator
+// to the reputation of the best agent it controls.
+func (e *ZionBFT) updatePoIScores() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for addr, v := range e.validators {
+		v.PoIScore = e.state.ControllerReputation(addr)
+	}
 }
 
 // VotingPower computes a validator's voting power from stake + PoI score.
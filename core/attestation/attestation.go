@@ -0,0 +1,113 @@
+// Package attestation verifies remote attestation documents of trusted
+// execution environments (TEEs).
+//
+// Each platform reports enclave measurements in its own format: an SGX
+// DCAP quote, an SEV-SNP attestation report or a Nitro COSE_Sign1
+// document. Provers normalize them into a Document, which carries the
+// measurement of the enclave image, the report data the enclave bound to
+// the report, the platform's certificate chain and the signature of the
+// chain's leaf key over Digest. Verify checks the chain against the roots
+// trusted for the platform and the signature; deciding whether the
+// measurement is an approved image is up to the caller.
+package attestation
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/zionlayer/zionlayer/core/rlp"
+)
+
+// Supported platforms.
+const (
+	PlatformSGX   = "sgx"
+	PlatformSEV   = "sev"
+	PlatformNitro = "nitro"
+)
+
+var (
+	ErrUnknownPlatform  = errors.New("attestation: unknown platform")
+	ErrNoCertificates   = errors.New("attestation: no certificate chain")
+	ErrUntrustedChain   = errors.New("attestation: certificate chain does not lead to a trusted root")
+	ErrUnsupportedKey   = errors.New("attestation: unsupported leaf key type")
+	ErrInvalidSignature = errors.New("attestation: invalid signature")
+)
+
+// Document is a normalized attestation document.
+type Document struct {
+	Platform     string   `json:"platform"`
+	Measurement  []byte   `json:"measurement"`  // MRENCLAVE, launch digest or PCR0
+	ReportData   []byte   `json:"reportData"`   // data bound by the enclave
+	Certificates [][]byte `json:"certificates"` // DER, leaf first
+	Signature    []byte   `json:"signature"`    // by the leaf key over Digest
+}
+
+// KnownPlatform reports whether p is a supported platform.
+func KnownPlatform(p string) bool {
+	switch p {
+	case PlatformSGX, PlatformSEV, PlatformNitro:
+		return true
+	}
+	return false
+}
+
+// Digest returns the digest signed by the leaf key of d: the SHA-256 hash
+// of the RLP list of Platform, Measurement and ReportData.
+func (d *Document) Digest() [32]byte {
+	return sha256.Sum256(rlp.EncodeList(
+		rlp.EncodeString(d.Platform),
+		rlp.EncodeBytes(d.Measurement),
+		rlp.EncodeBytes(d.ReportData),
+	))
+}
+
+// Verify checks that the certificate chain of d leads to one of roots and
+// was valid at now, and that its leaf key signed d.
+func Verify(d *Document, roots *x509.CertPool, now time.Time) error {
+	if !KnownPlatform(d.Platform) {
+		return fmt.Errorf("%w %q", ErrUnknownPlatform, d.Platform)
+	}
+	if len(d.Certificates) == 0 {
+		return ErrNoCertificates
+	}
+	certs := make([]*x509.Certificate, len(d.Certificates))
+	for i, der := range d.Certificates {
+		c, err := x509.ParseCertificate(der)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrUntrustedChain, err)
+		}
+		certs[i] = c
+	}
+	inter := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		inter.AddCert(c)
+	}
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: inter,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	if _, err := certs[0].Verify(opts); err != nil {
+		return fmt.Errorf("%w: %v", ErrUntrustedChain, err)
+	}
+	digest := d.Digest()
+	switch pub := certs[0].PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, digest[:], d.Signature) {
+			return ErrInvalidSignature
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, digest[:], d.Signature) {
+			return ErrInvalidSignature
+		}
+	default:
+		return ErrUnsupportedKey
+	}
+	return nil
+}
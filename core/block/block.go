@@ -1,22 +1,25 @@
 package block
 
 import (
-	"crypto/sha256"
-	"encoding/json"
 	"time"
 
+	"github.com/zionlayer/zionlayer/core/crypto"
 	"github.com/zionlayer/zionlayer/core/transaction"
 )
 
 // Header contains the block metadata.
 type Header struct {
 	Version        uint32
+	ChainID        uint64   // network identity, so that headers signed on
+	GenesisHash    [32]byte // one network are never valid on another
 	Height         uint64
 	Timestamp      int64
 	PrevHash       [32]byte
 	StateRoot      [32]byte
 	TxRoot         [32]byte
+	ReceiptRoot    [32]byte // merkle root of the receipts of the transactions
 	AgentRoot      [32]byte // merkle root of agent state trie
+	GasUsed        uint64   // gas used by the transactions, net of refunds
 	ValidatorAddr  []byte
 	Signature      []byte
 }
@@ -27,7 +30,8 @@ type Block struct {
 	Txs    []*transaction.Tx
 }
 
-// NewBlock creates a new block with the given header fields.
+// NewBlock creates a new block with the given header fields and the root
+// of txs.
 func NewBlock(height uint64, prevHash [32]byte, validatorAddr []byte, txs []*transaction.Tx) *Block {
 	return &Block{
 		Header: Header{
@@ -35,16 +39,38 @@ func NewBlock(height uint64, prevHash [32]byte, validatorAddr []byte, txs []*tra
 			Height:        height,
 			Timestamp:     time.Now().UnixNano(),
 			PrevHash:      prevHash,
+			TxRoot:        TxRoot(txs),
 			ValidatorAddr: validatorAddr,
 		},
 		Txs: txs,
 	}
 }
 
-// Hash returns the SHA-256 hash of the block header.
+// Hash returns the SHA-256 hash of the canonical encoding of the block header.
 func (b *Block) Hash() [32]byte {
-	data, _ := json.Marshal(b.Header)
-	return sha256.Sum256(data)
+	return b.Header.hash(true)
+}
+
+// SigningHash returns the digest the proposer signs: the hash of the
+// header with the signature left empty.
+func (h *Header) SigningHash() [32]byte {
+	return h.hash(false)
+}
+
+// Sign signs the header with the proposer key priv.
+func (h *Header) Sign(priv crypto.PrivateKey) error {
+	sig, err := crypto.Sign(priv, h.SigningHash())
+	if err != nil {
+		return err
+	}
+	h.Signature = sig
+	return nil
+}
+
+// VerifySignature checks the signature of the header against the public
+// key of its proposer.
+func (h *Header) VerifySignature(pub crypto.PublicKey) error {
+	return crypto.Verify(pub, h.SigningHash(), h.Signature)
 }
 
 // GenesisBlock creates the genesis block.
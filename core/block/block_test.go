@@ -0,0 +1,94 @@
+package block
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/zionlayer/zionlayer/core/common"
+	"github.com/zionlayer/zionlayer/core/crypto"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// testBlock returns a signed block holding n signed transfers.
+func testBlock(tb testing.TB, n int) *Block {
+	tb.Helper()
+	priv, err := crypto.GenerateKey()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	to := common.BytesToAddress([]byte{0xc0}).String()
+	txs := make([]*transaction.Tx, n)
+	for i := range txs {
+		txs[i] = transaction.NewTransferTx("", to, big.NewInt(1), uint64(i), big.NewInt(1))
+		if err := txs[i].Sign(priv, transaction.DevnetChainID); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	blk := NewBlock(1, [32]byte{}, nil, txs)
+	if err := blk.Header.Sign(priv); err != nil {
+		tb.Fatal(err)
+	}
+	return blk
+}
+
+func TestTxProof(t *testing.T) {
+	// Sizes covering a single leaf and unbalanced trees.
+	for _, n := range []int{1, 2, 5} {
+		blk := testBlock(t, n)
+		for i := range blk.Txs {
+			p, err := blk.TxProof(i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Root != blk.Header.TxRoot {
+				t.Fatalf("%d txs: proof %d root %x, header %x", n, i, p.Root, blk.Header.TxRoot)
+			}
+			if !p.Verify(blk.Header.TxRoot) {
+				t.Fatalf("%d txs: proof %d does not verify", n, i)
+			}
+			if p.Verify([32]byte{}) {
+				t.Errorf("%d txs: proof %d verifies under another root", n, i)
+			}
+
+			tx := *p
+			tx.Tx = append([]byte(nil), p.Tx...)
+			tx.Tx[len(tx.Tx)-1] ^= 1
+			if tx.Verify(blk.Header.TxRoot) {
+				t.Errorf("%d txs: proof %d verifies a tampered transaction", n, i)
+			}
+			index := *p
+			index.Index = (i + 1) % (n + 1)
+			if index.Verify(blk.Header.TxRoot) {
+				t.Errorf("%d txs: proof %d verifies at index %d", n, i, index.Index)
+			}
+		}
+		for _, i := range []int{-1, n} {
+			if _, err := blk.TxProof(i); !errors.Is(err, ErrTxIndex) {
+				t.Errorf("%d txs: TxProof(%d) = %v, want %v", n, i, err, ErrTxIndex)
+			}
+		}
+	}
+}
+
+func BenchmarkBlockHash(b *testing.B) {
+	blk := testBlock(b, 0)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		blk.Hash()
+	}
+}
+
+func BenchmarkTxRoot(b *testing.B) {
+	for _, n := range []int{100, 5000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			blk := testBlock(b, n)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				TxRoot(blk.Txs)
+			}
+		})
+	}
+}
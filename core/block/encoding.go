@@ -0,0 +1,166 @@
+package block
+
+import (
+	"crypto/sha256"
+
+	"github.com/zionlayer/zionlayer/core/rlp"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// encode returns the canonical RLP encoding of the header.
+func (h *Header) encode() []byte {
+	return rlp.AppendList(nil, func(dst []byte) []byte { return h.appendFields(dst, true) })
+}
+
+// hash returns the SHA-256 hash of the encoding of the header, with the
+// signature left empty unless withSig, encoded into a pooled buffer.
+func (h *Header) hash(withSig bool) [32]byte {
+	buf := rlp.GetBuffer()
+	defer rlp.PutBuffer(buf)
+	*buf = rlp.AppendList(*buf, func(dst []byte) []byte { return h.appendFields(dst, withSig) })
+	return sha256.Sum256(*buf)
+}
+
+func (h *Header) appendFields(dst []byte, withSig bool) []byte {
+	dst = rlp.AppendUint(dst, uint64(h.Version))
+	dst = rlp.AppendUint(dst, h.ChainID)
+	dst = rlp.AppendBytes(dst, h.GenesisHash[:])
+	dst = rlp.AppendUint(dst, h.Height)
+	dst = rlp.AppendUint(dst, uint64(h.Timestamp))
+	dst = rlp.AppendBytes(dst, h.PrevHash[:])
+	dst = rlp.AppendBytes(dst, h.StateRoot[:])
+	dst = rlp.AppendBytes(dst, h.TxRoot[:])
+	dst = rlp.AppendBytes(dst, h.ReceiptRoot[:])
+	dst = rlp.AppendBytes(dst, h.AgentRoot[:])
+	dst = rlp.AppendUint(dst, h.GasUsed)
+	dst = rlp.AppendBytes(dst, h.ValidatorAddr)
+	if !withSig {
+		return rlp.AppendBytes(dst, nil)
+	}
+	return rlp.AppendBytes(dst, h.Signature)
+}
+
+// MarshalBinary returns the canonical RLP encoding of the header.
+func (h *Header) MarshalBinary() ([]byte, error) {
+	return h.encode(), nil
+}
+
+// UnmarshalBinary decodes a canonical RLP-encoded header.
+func (h *Header) UnmarshalBinary(data []byte) error {
+	b, rest, err := rlp.SplitList(data)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return rlp.ErrTrailingData
+	}
+	var (
+		dec Header
+		u   uint64
+		buf []byte
+	)
+	if u, b, err = rlp.SplitUint(b); err != nil {
+		return err
+	}
+	if u > 0xffffffff {
+		return rlp.ErrUintOverflow
+	}
+	dec.Version = uint32(u)
+	if dec.ChainID, b, err = rlp.SplitUint(b); err != nil {
+		return err
+	}
+	if dec.GenesisHash, b, err = rlp.SplitHash(b); err != nil {
+		return err
+	}
+	if dec.Height, b, err = rlp.SplitUint(b); err != nil {
+		return err
+	}
+	if u, b, err = rlp.SplitUint(b); err != nil {
+		return err
+	}
+	dec.Timestamp = int64(u)
+	for _, dst := range []*[32]byte{&dec.PrevHash, &dec.StateRoot, &dec.TxRoot, &dec.ReceiptRoot, &dec.AgentRoot} {
+		if *dst, b, err = rlp.SplitHash(b); err != nil {
+			return err
+		}
+	}
+	if dec.GasUsed, b, err = rlp.SplitUint(b); err != nil {
+		return err
+	}
+	if buf, b, err = rlp.SplitBytes(b); err != nil {
+		return err
+	}
+	dec.ValidatorAddr = cloneBytes(buf)
+	if buf, b, err = rlp.SplitBytes(b); err != nil {
+		return err
+	}
+	dec.Signature = cloneBytes(buf)
+	if len(b) > 0 {
+		return rlp.ErrTrailingData
+	}
+	*h = dec
+	return nil
+}
+
+// MarshalBinary returns the canonical RLP encoding of the block: a list of
+// the header and the list of enveloped transactions, each framed as a byte
+// string.
+func (b *Block) MarshalBinary() ([]byte, error) {
+	txs := make([][]byte, len(b.Txs))
+	for i, tx := range b.Txs {
+		enc, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		txs[i] = rlp.EncodeBytes(enc)
+	}
+	return rlp.EncodeList(b.Header.encode(), rlp.EncodeList(txs...)), nil
+}
+
+// UnmarshalBinary decodes a canonical RLP-encoded block.
+func (b *Block) UnmarshalBinary(data []byte) error {
+	content, rest, err := rlp.SplitList(data)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return rlp.ErrTrailingData
+	}
+	_, _, rest, err = rlp.Split(content)
+	if err != nil {
+		return err
+	}
+	var dec Block
+	if err := dec.Header.UnmarshalBinary(content[:len(content)-len(rest)]); err != nil {
+		return err
+	}
+	txList, rest, err := rlp.SplitList(rest)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return rlp.ErrTrailingData
+	}
+	dec.Txs = []*transaction.Tx{}
+	for len(txList) > 0 {
+		enc, next, err := rlp.SplitBytes(txList)
+		if err != nil {
+			return err
+		}
+		tx := new(transaction.Tx)
+		if err := tx.UnmarshalBinary(enc); err != nil {
+			return err
+		}
+		dec.Txs = append(dec.Txs, tx)
+		txList = next
+	}
+	*b = dec
+	return nil
+}
+
+func cloneBytes(b []byte) []byte {
+	if len(b) == 0 {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
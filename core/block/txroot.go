@@ -0,0 +1,79 @@
+package block
+
+import (
+	"errors"
+
+	"github.com/zionlayer/zionlayer/core/merkle"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// The transactions of a block are committed to by Header.TxRoot, the root
+// of a Merkle tree with one leaf per transaction, in block order, holding
+// its canonical enveloped encoding. A client holding a header can check
+// that a transaction is part of the block with the proof returned by
+// TxProof.
+
+var ErrTxIndex = errors.New("transaction index out of range")
+
+// TxProof proves that Tx, a canonical transaction encoding, is transaction
+// Index of the Total transactions committed to by Root.
+type TxProof struct {
+	Root  [32]byte   `json:"root"`
+	Tx    []byte     `json:"tx"`
+	Index int        `json:"index"`
+	Total int        `json:"total"`
+	Proof [][32]byte `json:"proof"`
+}
+
+// Verify reports whether p proves its transaction under root.
+func (p *TxProof) Verify(root [32]byte) bool {
+	return merkle.Verify(root, merkle.LeafHash(p.Tx), p.Index, p.Total, p.Proof)
+}
+
+// TxRoot returns the root of the Merkle tree over txs. A transaction that
+// cannot be encoded, which no valid block holds, is committed to as an
+// empty leaf.
+func TxRoot(txs []*transaction.Tx) [32]byte {
+	return merkle.Root(txLeaves(txs))
+}
+
+// ReceiptRoot returns the root of the Merkle tree over the canonical
+// encodings of receipts, one leaf per transaction in block order, which
+// Header.ReceiptRoot holds.
+func ReceiptRoot(receipts []*transaction.Receipt) [32]byte {
+	leaves := make([][32]byte, len(receipts))
+	for i, r := range receipts {
+		enc, _ := r.MarshalBinary()
+		leaves[i] = merkle.LeafHash(enc)
+	}
+	return merkle.Root(leaves)
+}
+
+// TxProof returns the inclusion proof of transaction i of b under the root
+// of its transactions.
+func (b *Block) TxProof(i int) (*TxProof, error) {
+	if i < 0 || i >= len(b.Txs) {
+		return nil, ErrTxIndex
+	}
+	enc, err := b.Txs[i].MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	leaves := txLeaves(b.Txs)
+	return &TxProof{
+		Root:  merkle.Root(leaves),
+		Tx:    enc,
+		Index: i,
+		Total: len(leaves),
+		Proof: merkle.Prove(leaves, i),
+	}, nil
+}
+
+func txLeaves(txs []*transaction.Tx) [][32]byte {
+	leaves := make([][32]byte, len(txs))
+	for i, tx := range txs {
+		enc, _ := tx.MarshalBinary()
+		leaves[i] = merkle.LeafHash(enc)
+	}
+	return leaves
+}
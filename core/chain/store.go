@@ -0,0 +1,273 @@
+// Package chain indexes finalized blocks, their transactions and receipts
+// for queries.
+package chain
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/zionlayer/zionlayer/core/block"
+	"github.com/zionlayer/zionlayer/core/cache"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// Sizes of the block and receipt caches of a Store.
+const (
+	BlockCacheSize   = 256
+	ReceiptCacheSize = 8192
+)
+
+var (
+	ErrBlockNotFound = errors.New("block not found")
+	ErrTxNotFound    = errors.New("transaction not found")
+)
+
+// TxLocation places a transaction within the chain.
+type TxLocation struct {
+	BlockHash   [32]byte
+	BlockHeight uint64
+	Index       int
+}
+
+// Store is an in-memory index of finalized blocks. The maps stand in for
+// an on-disk database: blocks and receipts are read through LRU caches,
+// shared by RPC and execution, that keep the recent and hot ones in memory
+// once the maps move to disk.
+type Store struct {
+	mu       sync.RWMutex
+	head     uint64
+	base     uint64 // lowest height not yet pruned
+	blocks   map[uint64]*block.Block
+	byHash   map[[32]byte]uint64
+	txs      map[[32]byte]TxLocation
+	receipts map[[32]byte]*transaction.Receipt
+	blooms   map[uint64]transaction.Bloom
+
+	// inferences lists the inference receipt transactions of each agent
+	// in chain order.
+	inferences map[string][][32]byte
+
+	blockCache   *cache.LRU[uint64, *block.Block]
+	receiptCache *cache.LRU[[32]byte, *transaction.Receipt]
+}
+
+// NewStore creates an empty store.
+func NewStore() *Store {
+	return &Store{
+		blocks:   make(map[uint64]*block.Block),
+		byHash:   make(map[[32]byte]uint64),
+		txs:      make(map[[32]byte]TxLocation),
+		receipts: make(map[[32]byte]*transaction.Receipt),
+		blooms:   make(map[uint64]transaction.Bloom),
+
+		inferences: make(map[string][][32]byte),
+
+		blockCache:   cache.NewLRU[uint64, *block.Block](BlockCacheSize),
+		receiptCache: cache.NewLRU[[32]byte, *transaction.Receipt](ReceiptCacheSize),
+	}
+}
+
+// CacheStats returns the counters of the block and receipt caches.
+func (s *Store) CacheStats() map[string]cache.Stats {
+	return map[string]cache.Stats{
+		"blocks":   s.blockCache.Stats(),
+		"receipts": s.receiptCache.Stats(),
+	}
+}
+
+// Add indexes a finalized block. receipts, if given, are the outcomes of
+// b.Txs in order; they may be nil when the transactions were not executed.
+func (s *Store) Add(b *block.Block, receipts []*transaction.Receipt) {
+	hash := b.Hash()
+	height := b.Header.Height
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocks[height] = b
+	s.blockCache.Add(height, b)
+	s.byHash[hash] = height
+	if height > s.head {
+		s.head = height
+	}
+	for i, tx := range b.Txs {
+		h := tx.Hash()
+		s.txs[h] = TxLocation{BlockHash: hash, BlockHeight: height, Index: i}
+		if i < len(receipts) && receipts[i] != nil {
+			s.receipts[h] = receipts[i]
+			s.receiptCache.Add(h, receipts[i])
+		}
+		if tx.Type == transaction.TxInferenceReceipt {
+			var r transaction.InferenceReceipt
+			if json.Unmarshal(tx.Data, &r) == nil && r.AgentID != "" {
+				s.inferences[r.AgentID] = append(s.inferences[r.AgentID], h)
+			}
+		}
+	}
+	s.blooms[height] = transaction.CreateBloom(receipts)
+}
+
+// Prune drops the blocks below height together with their transactions,
+// receipts and blooms, and returns how many blocks were removed.
+func (s *Store) Prune(below uint64) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for h := s.base; h < below; h++ {
+		b, ok := s.blocks[h]
+		if !ok {
+			continue
+		}
+		for _, tx := range b.Txs {
+			hash := tx.Hash()
+			delete(s.txs, hash)
+			delete(s.receipts, hash)
+			s.receiptCache.Remove(hash)
+		}
+		delete(s.byHash, b.Hash())
+		delete(s.blocks, h)
+		s.blockCache.Remove(h)
+		delete(s.blooms, h)
+		n++
+	}
+	if below > s.base {
+		s.base = below
+	}
+	if n == 0 {
+		return 0
+	}
+	for id, hashes := range s.inferences {
+		i := 0
+		for i < len(hashes) {
+			if _, ok := s.txs[hashes[i]]; ok {
+				break
+			}
+			i++
+		}
+		if i == len(hashes) {
+			delete(s.inferences, id)
+		} else {
+			s.inferences[id] = hashes[i:]
+		}
+	}
+	return n
+}
+
+// Head returns the height of the latest block.
+func (s *Store) Head() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.head
+}
+
+// BlockByHeight returns the block at height.
+func (s *Store) BlockByHeight(height uint64) (*block.Block, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.block(height)
+}
+
+// block reads the block at height through the cache. The caller holds
+// s.mu.
+func (s *Store) block(height uint64) (*block.Block, error) {
+	if b, ok := s.blockCache.Get(height); ok {
+		return b, nil
+	}
+	b, ok := s.blocks[height]
+	if !ok {
+		return nil, ErrBlockNotFound
+	}
+	s.blockCache.Add(height, b)
+	return b, nil
+}
+
+// BlockByHash returns the block with the given header hash.
+func (s *Store) BlockByHash(hash [32]byte) (*block.Block, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	height, ok := s.byHash[hash]
+	if !ok {
+		return nil, ErrBlockNotFound
+	}
+	return s.block(height)
+}
+
+// Transaction returns an included transaction and its location.
+func (s *Store) Transaction(hash [32]byte) (*transaction.Tx, TxLocation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	loc, ok := s.txs[hash]
+	if !ok {
+		return nil, TxLocation{}, ErrTxNotFound
+	}
+	b, err := s.block(loc.BlockHeight)
+	if err != nil {
+		return nil, TxLocation{}, ErrTxNotFound
+	}
+	return b.Txs[loc.Index], loc, nil
+}
+
+// Receipt returns the receipt of an executed transaction.
+func (s *Store) Receipt(hash [32]byte) (*transaction.Receipt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.receipt(hash)
+}
+
+// receipt reads the receipt of hash through the cache. The caller holds
+// s.mu.
+func (s *Store) receipt(hash [32]byte) (*transaction.Receipt, error) {
+	if r, ok := s.receiptCache.Get(hash); ok {
+		return r, nil
+	}
+	r, ok := s.receipts[hash]
+	if !ok {
+		return nil, ErrTxNotFound
+	}
+	s.receiptCache.Add(hash, r)
+	return r, nil
+}
+
+// BlockReceipts returns the receipts of the block at height, in transaction
+// order. Transactions without a receipt are skipped.
+func (s *Store) BlockReceipts(height uint64) ([]*transaction.Receipt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	b, err := s.block(height)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*transaction.Receipt, 0, len(b.Txs))
+	for _, tx := range b.Txs {
+		if r, err := s.receipt(tx.Hash()); err == nil {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+// Bloom returns the log bloom filter of the block at height.
+func (s *Store) Bloom(height uint64) (transaction.Bloom, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	b, ok := s.blooms[height]
+	if !ok {
+		return transaction.Bloom{}, ErrBlockNotFound
+	}
+	return b, nil
+}
+
+// InferenceReceipts returns the hashes of the inference receipt
+// transactions submitted for agentID in blocks [from, to], in chain order.
+func (s *Store) InferenceReceipts(agentID string, from, to uint64) [][32]byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out [][32]byte
+	for _, h := range s.inferences[agentID] {
+		height := s.txs[h].BlockHeight
+		if height >= from && height <= to {
+			out = append(out, h)
+		}
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// AddressLength is the size of an account address in bytes.
+const AddressLength = 20
+
+var (
+	ErrInvalidAddress  = errors.New("invalid address")
+	ErrAddressChecksum = errors.New("invalid address checksum")
+)
+
+// Address is a 20-byte account address derived from a public key.
+type Address [AddressLength]byte
+
+// BytesToAddress returns the address made of the last 20 bytes of b.
+func BytesToAddress(b []byte) Address {
+	var a Address
+	if len(b) > AddressLength {
+		b = b[len(b)-AddressLength:]
+	}
+	copy(a[AddressLength-len(b):], b)
+	return a
+}
+
+// ParseAddress parses a 0x-prefixed hex address. All-lowercase and
+// all-uppercase input is accepted as is; mixed-case input must carry a
+// valid checksum.
+func ParseAddress(s string) (Address, error) {
+	var a Address
+	if !strings.HasPrefix(s, "0x") || len(s) != 2+2*AddressLength {
+		return a, ErrInvalidAddress
+	}
+	body := s[2:]
+	if _, err := hex.Decode(a[:], []byte(body)); err != nil {
+		return Address{}, ErrInvalidAddress
+	}
+	if body != strings.ToLower(body) && body != strings.ToUpper(body) && a.String() != s {
+		return Address{}, ErrAddressChecksum
+	}
+	return a, nil
+}
+
+// MustParseAddress is like ParseAddress but panics on error. Intended for
+// constants and tests.
+func MustParseAddress(s string) Address {
+	a, err := ParseAddress(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// IsZero reports whether a is the zero address.
+func (a Address) IsZero() bool {
+	return a == Address{}
+}
+
+// Bytes returns the raw address bytes.
+func (a Address) Bytes() []byte {
+	return a[:]
+}
+
+// String returns the checksummed hex encoding of the address. A hex digit
+// is upper-cased when the corresponding nibble of SHA-256(lowercase hex)
+// is 8 or higher.
+func (a Address) String() string {
+	lower := hex.EncodeToString(a[:])
+	h := sha256.Sum256([]byte(lower))
+	out := []byte(lower)
+	for i, c := range out {
+		if c < 'a' {
+			continue
+		}
+		nibble := h[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+		if nibble&0x0f >= 8 {
+			out[i] = c - 32
+		}
+	}
+	return "0x" + string(out)
+}
+
+// MarshalJSON encodes the address as a checksummed hex string.
+func (a Address) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON strictly parses a hex string address.
+func (a *Address) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := ParseAddress(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
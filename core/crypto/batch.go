@@ -0,0 +1,161 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"errors"
+
+	"filippo.io/edwards25519"
+)
+
+// Signatures are checked against the cofactored ed25519 equation
+// [8][s]B = [8]R + [8][k]A, as ZIP 215 specifies, the one batch
+// verification can check for many signatures at once. Verify uses it as
+// well, so that a signature is accepted or rejected alike whether it is
+// verified alone or in a batch, and nodes agree on it whichever way they
+// checked it. Unlike ZIP 215, the encodings of A and R must be canonical
+// and neither may be of small order: a signature cannot be re-encoded into
+// another valid one, and no key verifies every message.
+
+// SignedDigest is a signature over a 32-byte digest with the public key
+// that made it.
+type SignedDigest struct {
+	PublicKey []byte
+	Hash      [32]byte
+	Signature []byte
+}
+
+// VerifyBatch checks sigs together, which is about twice as fast as
+// checking them one by one when all are valid. It returns nil if they
+// are, and otherwise the error of each signature, nil for the valid ones.
+// Signatures in the signature cache are not checked again, and valid ones
+// are added to it.
+func VerifyBatch(sigs []SignedDigest) []error {
+	if len(sigs) == 0 {
+		return nil
+	}
+	var (
+		errs    []error
+		entries = make([]batchEntry, 0, len(sigs))
+	)
+	for i, sig := range sigs {
+		if sigCache.contains(sig.PublicKey, sig.Hash, sig.Signature) {
+			continue
+		}
+		e, err := decodeSignature(sig.PublicKey, sig.Hash, sig.Signature)
+		if err != nil {
+			if errs == nil {
+				errs = make([]error, len(sigs))
+			}
+			errs[i] = err
+			continue
+		}
+		e.index = i
+		entries = append(entries, e)
+	}
+	if len(entries) == 1 || (len(entries) > 1 && !verifyEntries(entries)) {
+		// Find the invalid signatures.
+		for _, e := range entries {
+			if !verifyEntries([]batchEntry{e}) {
+				if errs == nil {
+					errs = make([]error, len(sigs))
+				}
+				errs[e.index] = ErrInvalidSignature
+			}
+		}
+	}
+	for i, sig := range sigs {
+		if errs == nil || errs[i] == nil {
+			sigCache.add(sig.PublicKey, sig.Hash, sig.Signature)
+		}
+	}
+	return errs
+}
+
+// batchEntry is a decoded signature: [s]B = R + [k]A.
+type batchEntry struct {
+	a, r  *edwards25519.Point
+	s, k  *edwards25519.Scalar
+	index int
+}
+
+func decodeSignature(pub []byte, hash [32]byte, sig []byte) (batchEntry, error) {
+	if len(pub) != PublicKeySize {
+		return batchEntry{}, ErrInvalidPublicKey
+	}
+	if len(sig) != SignatureSize {
+		return batchEntry{}, ErrInvalidSignature
+	}
+	a, err := decodePoint(pub)
+	if err != nil {
+		return batchEntry{}, ErrInvalidPublicKey
+	}
+	r, err := decodePoint(sig[:32])
+	if err != nil {
+		return batchEntry{}, ErrInvalidSignature
+	}
+	s, err := edwards25519.NewScalar().SetCanonicalBytes(sig[32:])
+	if err != nil {
+		return batchEntry{}, ErrInvalidSignature
+	}
+	h := sha512.New()
+	h.Write(sig[:32])
+	h.Write(pub)
+	h.Write(hash[:])
+	k, _ := edwards25519.NewScalar().SetUniformBytes(h.Sum(nil))
+	return batchEntry{a: a, r: r, s: s, k: k}, nil
+}
+
+// decodePoint decodes the canonical encoding of a point not of small
+// order.
+func decodePoint(b []byte) (*edwards25519.Point, error) {
+	p, err := new(edwards25519.Point).SetBytes(b)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(p.Bytes(), b) {
+		return nil, errNonCanonical
+	}
+	if new(edwards25519.Point).MultByCofactor(p).Equal(edwards25519.NewIdentityPoint()) == 1 {
+		return nil, errSmallOrder
+	}
+	return p, nil
+}
+
+var (
+	errNonCanonical = errors.New("non-canonical point encoding")
+	errSmallOrder   = errors.New("point of small order")
+)
+
+// verifyEntries checks [8]([Σzs]B - Σ[z]R - Σ[zk]A) = 0 for random 128-bit
+// z, which holds for invalid signatures with probability 2^-128. A single
+// entry is checked with z = 1.
+func verifyEntries(entries []batchEntry) bool {
+	n := len(entries)
+	scalars := make([]*edwards25519.Scalar, 0, 2*n+1)
+	points := make([]*edwards25519.Point, 0, 2*n+1)
+	sum := edwards25519.NewScalar()
+	scalars = append(scalars, sum)
+	points = append(points, edwards25519.NewGeneratorPoint())
+	for _, e := range entries {
+		z := edwards25519.NewScalar()
+		if n == 1 {
+			z.Set(scalarOne)
+		} else {
+			var buf [32]byte
+			if _, err := rand.Read(buf[:16]); err != nil {
+				panic("crypto: reading randomness: " + err.Error())
+			}
+			z.SetCanonicalBytes(buf[:])
+		}
+		sum.Add(sum, edwards25519.NewScalar().Multiply(z, e.s))
+		negZ := edwards25519.NewScalar().Negate(z)
+		scalars = append(scalars, negZ, edwards25519.NewScalar().Multiply(negZ, e.k))
+		points = append(points, e.r, e.a)
+	}
+	check := new(edwards25519.Point).VarTimeMultiScalarMult(scalars, points)
+	return check.MultByCofactor(check).Equal(edwards25519.NewIdentityPoint()) == 1
+}
+
+var scalarOne, _ = edwards25519.NewScalar().SetCanonicalBytes([]byte{1, 31: 0})
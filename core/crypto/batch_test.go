@@ -0,0 +1,116 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"testing"
+
+	"filippo.io/edwards25519"
+)
+
+// fieldPrime is 2^255 - 19.
+var fieldPrime = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+
+// nonCanonicalPoint returns an encoding y + p of a point with a small y
+// coordinate, which decoders that reduce y accept.
+func nonCanonicalPoint(t *testing.T) []byte {
+	t.Helper()
+	for y := int64(2); y < 19; y++ {
+		v := new(big.Int).Add(fieldPrime, big.NewInt(y)).Bytes()
+		enc := make([]byte, 32)
+		for i := range v {
+			enc[i] = v[len(v)-1-i]
+		}
+		if _, err := new(edwards25519.Point).SetBytes(enc); err == nil {
+			return enc
+		}
+	}
+	t.Fatal("no point with a small y coordinate")
+	return nil
+}
+
+func TestVerifyRejects(t *testing.T) {
+	priv, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := priv.Public().(PublicKey)
+	hash := sha256.Sum256([]byte("message"))
+	sig, err := Sign(priv, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity := edwards25519.NewIdentityPoint().Bytes()
+	// With A and R the identity and s zero, the cofactored equation holds
+	// for every message.
+	forged := append(append([]byte(nil), identity...), make([]byte, 32)...)
+
+	tests := []struct {
+		name string
+		pub  []byte
+		sig  []byte
+		err  error
+	}{
+		{"non-canonical key", nonCanonicalPoint(t), sig, ErrInvalidPublicKey},
+		{"non-canonical R", pub, append(nonCanonicalPoint(t), sig[32:]...), ErrInvalidSignature},
+		{"small-order key", identity, forged, ErrInvalidPublicKey},
+		{"wrong message", pub, func() []byte { s, _ := Sign(priv, sha256.Sum256(nil)); return s }(), ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Verify(tt.pub, hash, tt.sig); !errors.Is(err, tt.err) {
+				t.Errorf("Verify = %v, want %v", err, tt.err)
+			}
+			// Batch verification must agree, whatever else is in the batch.
+			errs := VerifyBatch([]SignedDigest{{pub, hash, sig}, {tt.pub, hash, tt.sig}})
+			if errs == nil || errs[0] != nil || !errors.Is(errs[1], tt.err) {
+				t.Errorf("VerifyBatch = %v, want [nil %v]", errs, tt.err)
+			}
+		})
+	}
+	if err := Verify(pub, hash, sig); err != nil {
+		t.Errorf("Verify of a valid signature = %v", err)
+	}
+}
+
+func BenchmarkVerify(b *testing.B) {
+	sigs := benchSigs(b, 64)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sigCache = newSignatureCache(SigCacheSize)
+		for _, s := range sigs {
+			if err := Verify(s.PublicKey, s.Hash, s.Signature); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkVerifyBatch(b *testing.B) {
+	sigs := benchSigs(b, 64)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sigCache = newSignatureCache(SigCacheSize)
+		if errs := VerifyBatch(sigs); errs != nil {
+			b.Fatal(errs)
+		}
+	}
+}
+
+func benchSigs(b *testing.B, n int) []SignedDigest {
+	sigs := make([]SignedDigest, n)
+	for i := range sigs {
+		priv, err := GenerateKey()
+		if err != nil {
+			b.Fatal(err)
+		}
+		hash := sha256.Sum256([]byte{byte(i)})
+		sig, err := Sign(priv, hash)
+		if err != nil {
+			b.Fatal(err)
+		}
+		sigs[i] = SignedDigest{priv.Public().(PublicKey), hash, sig}
+	}
+	return sigs
+}
@@ -0,0 +1,90 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	"github.com/zionlayer/zionlayer/core/common"
+)
+
+const (
+	PublicKeySize  = ed25519.PublicKeySize
+	PrivateKeySize = ed25519.PrivateKeySize
+	SignatureSize  = ed25519.SignatureSize
+)
+
+var (
+	ErrInvalidPublicKey  = errors.New("invalid public key")
+	ErrInvalidPrivateKey = errors.New("invalid private key")
+	ErrInvalidSignature  = errors.New("invalid signature")
+)
+
+// PrivateKey is an ed25519 signing key.
+type PrivateKey = ed25519.PrivateKey
+
+// PublicKey is an ed25519 verification key.
+type PublicKey = ed25519.PublicKey
+
+// GenerateKey creates a new random key pair.
+func GenerateKey() (PrivateKey, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	return priv, err
+}
+
+// Sign signs a 32-byte digest.
+func Sign(priv PrivateKey, hash [32]byte) ([]byte, error) {
+	if len(priv) != PrivateKeySize {
+		return nil, ErrInvalidPrivateKey
+	}
+	return ed25519.Sign(priv, hash[:]), nil
+}
+
+// Verify checks sig over hash against pub with the cofactored equation
+// (see batch.go). Valid signatures are cached, and those already in the
+// cache are not checked again.
+func Verify(pub []byte, hash [32]byte, sig []byte) error {
+	if sigCache.contains(pub, hash, sig) {
+		return nil
+	}
+	e, err := decodeSignature(pub, hash, sig)
+	if err != nil {
+		return err
+	}
+	if !verifyEntries([]batchEntry{e}) {
+		return ErrInvalidSignature
+	}
+	sigCache.add(pub, hash, sig)
+	return nil
+}
+
+// PubkeyToAddress derives an account address from a public key: the last
+// 20 bytes of its SHA-256 hash.
+func PubkeyToAddress(pub []byte) common.Address {
+	h := sha256.Sum256(pub)
+	return common.BytesToAddress(h[:])
+}
+
+// CreateAddress derives the address of a contract deployed by from with the
+// given account nonce.
+func CreateAddress(from common.Address, nonce uint64) common.Address {
+	var buf [common.AddressLength + 8]byte
+	copy(buf[:], from[:])
+	binary.BigEndian.PutUint64(buf[common.AddressLength:], nonce)
+	h := sha256.Sum256(buf[:])
+	return common.BytesToAddress(h[:])
+}
+
+// MultisigAddress derives the address of a native M-of-N multisig account
+// from its threshold and ordered public keys.
+func MultisigAddress(threshold uint8, pubkeys [][]byte) common.Address {
+	h := sha256.New()
+	h.Write([]byte("multisig"))
+	h.Write([]byte{threshold, byte(len(pubkeys))})
+	for _, pub := range pubkeys {
+		h.Write(pub)
+	}
+	return common.BytesToAddress(h.Sum(nil))
+}
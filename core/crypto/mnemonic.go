@@ -0,0 +1,60 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+// CoinType is the BIP-44 coin type of ZionLayer accounts.
+const CoinType = 7331
+
+// MnemonicEntropyBits gives 24-word mnemonics.
+const MnemonicEntropyBits = 256
+
+var ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+// NewMnemonic returns a random BIP-39 mnemonic.
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(MnemonicEntropyBits)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// KeyFromMnemonic derives the key of the given account from a BIP-39
+// mnemonic and optional passphrase. Keys are derived with SLIP-10 for
+// ed25519 along the hardened path m/44'/CoinType'/account'/0'/0'.
+func KeyFromMnemonic(mnemonic, passphrase string, account uint32) (PrivateKey, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+	if err != nil {
+		return nil, ErrInvalidMnemonic
+	}
+	key := deriveSLIP10(seed, []uint32{44, CoinType, account, 0, 0})
+	return ed25519.NewKeyFromSeed(key), nil
+}
+
+// deriveSLIP10 returns the ed25519 private key seed at path below the
+// master key of seed. Every index is hardened, as ed25519 requires.
+func deriveSLIP10(seed []byte, path []uint32) []byte {
+	mac := hmac.New(sha512.New, []byte("ed25519 seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := sum[:32], sum[32:]
+	for _, index := range path {
+		data := make([]byte, 0, 37)
+		data = append(data, 0)
+		data = append(data, key...)
+		data = binary.BigEndian.AppendUint32(data, index|0x80000000)
+		mac = hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum = mac.Sum(nil)
+		key, chainCode = sum[:32], sum[32:]
+	}
+	return key
+}
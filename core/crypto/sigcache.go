@@ -0,0 +1,57 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"sync"
+)
+
+// SigCacheSize is the number of valid signatures remembered by Verify and
+// VerifyBatch, so that a transaction verified on admission to the mempool
+// is not verified again when its block is validated.
+const SigCacheSize = 1 << 16
+
+var sigCache = newSignatureCache(SigCacheSize)
+
+// signatureCache is a set of valid signatures, keyed by the hash of the
+// key, digest and signature, that forgets the oldest beyond its size.
+type signatureCache struct {
+	mu   sync.Mutex
+	keys map[[32]byte]struct{}
+	ring [][32]byte
+	next int
+}
+
+func newSignatureCache(size int) *signatureCache {
+	return &signatureCache{keys: make(map[[32]byte]struct{}, size), ring: make([][32]byte, size)}
+}
+
+func sigCacheKey(pub []byte, hash [32]byte, sig []byte) [32]byte {
+	h := sha256.New()
+	h.Write(pub)
+	h.Write(hash[:])
+	h.Write(sig)
+	var key [32]byte
+	h.Sum(key[:0])
+	return key
+}
+
+func (c *signatureCache) contains(pub []byte, hash [32]byte, sig []byte) bool {
+	key := sigCacheKey(pub, hash, sig)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.keys[key]
+	return ok
+}
+
+func (c *signatureCache) add(pub []byte, hash [32]byte, sig []byte) {
+	key := sigCacheKey(pub, hash, sig)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.keys[key]; ok {
+		return
+	}
+	delete(c.keys, c.ring[c.next])
+	c.ring[c.next] = key
+	c.keys[key] = struct{}{}
+	c.next = (c.next + 1) % len(c.ring)
+}
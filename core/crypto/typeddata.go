@@ -0,0 +1,268 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/zionlayer/zionlayer/core/common"
+)
+
+// Structured data signing lets wallets display the fields of a payload
+// before signing it, in the manner of EIP-712. The digest is
+//
+//	sha256(0x19 || 0x01 || hashStruct(domain) || hashStruct(message))
+//
+// where hashStruct(s) = sha256(typeHash(s) || encodeData(s)) and
+// typeHash(s) = sha256("Name(type1 field1,type2 field2)" followed by the
+// definitions of referenced struct types in alphabetical order). Atomic
+// field values are encoded as 32-byte words; string, bytes and arrays are
+// replaced by their SHA-256 hash; nested structs by their hashStruct.
+
+// DomainType is the type name of the signing domain.
+const DomainType = "ZionDomain"
+
+var (
+	ErrUnknownType  = errors.New("typed data: unknown type")
+	ErrInvalidValue = errors.New("typed data: invalid value for type")
+)
+
+// TypedField is a named, typed member of a struct type. Supported types are
+// string, bytes, bool, uint64, uint256, address, bytes32, any struct type
+// declared in Types, and arrays of these written as "T[]".
+type TypedField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedDomain separates signatures between applications and networks.
+type TypedDomain struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	ChainID uint64 `json:"chainId"`
+}
+
+// TypedData is a structured payload to be signed.
+type TypedData struct {
+	Types       map[string][]TypedField `json:"types"`
+	PrimaryType string                  `json:"primaryType"`
+	Domain      TypedDomain             `json:"domain"`
+	Message     map[string]interface{}  `json:"message"`
+}
+
+var domainFields = []TypedField{
+	{Name: "name", Type: "string"},
+	{Name: "version", Type: "string"},
+	{Name: "chainId", Type: "uint64"},
+}
+
+// Hash returns the digest to sign for td.
+func (td *TypedData) Hash() ([32]byte, error) {
+	domain, err := td.hashStruct(DomainType, map[string]interface{}{
+		"name":    td.Domain.Name,
+		"version": td.Domain.Version,
+		"chainId": td.Domain.ChainID,
+	})
+	if err != nil {
+		return [32]byte{}, err
+	}
+	msg, err := td.hashStruct(td.PrimaryType, td.Message)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	buf := make([]byte, 0, 2+64)
+	buf = append(buf, 0x19, 0x01)
+	buf = append(buf, domain[:]...)
+	buf = append(buf, msg[:]...)
+	return sha256.Sum256(buf), nil
+}
+
+// SignTypedData signs the digest of td.
+func SignTypedData(priv PrivateKey, td *TypedData) ([]byte, error) {
+	h, err := td.Hash()
+	if err != nil {
+		return nil, err
+	}
+	return Sign(priv, h)
+}
+
+// VerifyTypedData checks sig over td against pub and returns the signer's
+// address.
+func VerifyTypedData(pub []byte, td *TypedData, sig []byte) (common.Address, error) {
+	h, err := td.Hash()
+	if err != nil {
+		return common.Address{}, err
+	}
+	if err := Verify(pub, h, sig); err != nil {
+		return common.Address{}, err
+	}
+	return PubkeyToAddress(pub), nil
+}
+
+func (td *TypedData) fields(typ string) ([]TypedField, bool) {
+	if typ == DomainType {
+		return domainFields, true
+	}
+	f, ok := td.Types[typ]
+	return f, ok
+}
+
+// EncodeType returns the canonical type string of typ.
+func (td *TypedData) EncodeType(typ string) (string, error) {
+	deps := map[string]bool{}
+	if err := td.collectDeps(typ, deps); err != nil {
+		return "", err
+	}
+	delete(deps, typ)
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range append([]string{typ}, names...) {
+		fields, _ := td.fields(name)
+		sb.WriteString(name)
+		sb.WriteByte('(')
+		for i, f := range fields {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(f.Type)
+			sb.WriteByte(' ')
+			sb.WriteString(f.Name)
+		}
+		sb.WriteByte(')')
+	}
+	return sb.String(), nil
+}
+
+func (td *TypedData) collectDeps(typ string, deps map[string]bool) error {
+	typ = strings.TrimSuffix(typ, "[]")
+	if deps[typ] || isAtomic(typ) {
+		return nil
+	}
+	fields, ok := td.fields(typ)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownType, typ)
+	}
+	deps[typ] = true
+	for _, f := range fields {
+		if err := td.collectDeps(f.Type, deps); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (td *TypedData) hashStruct(typ string, data map[string]interface{}) ([32]byte, error) {
+	enc, err := td.EncodeType(typ)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	typeHash := sha256.Sum256([]byte(enc))
+	buf := append([]byte(nil), typeHash[:]...)
+	fields, _ := td.fields(typ)
+	for _, f := range fields {
+		word, err := td.encodeValue(f.Type, data[f.Name])
+		if err != nil {
+			return [32]byte{}, fmt.Errorf("%s.%s: %w", typ, f.Name, err)
+		}
+		buf = append(buf, word[:]...)
+	}
+	return sha256.Sum256(buf), nil
+}
+
+func (td *TypedData) encodeValue(typ string, v interface{}) ([32]byte, error) {
+	var word [32]byte
+	if elem := strings.TrimSuffix(typ, "[]"); elem != typ {
+		items, ok := v.([]interface{})
+		if !ok {
+			return word, ErrInvalidValue
+		}
+		buf := make([]byte, 0, 32*len(items))
+		for _, item := range items {
+			w, err := td.encodeValue(elem, item)
+			if err != nil {
+				return word, err
+			}
+			buf = append(buf, w[:]...)
+		}
+		return sha256.Sum256(buf), nil
+	}
+
+	switch typ {
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return word, ErrInvalidValue
+		}
+		return sha256.Sum256([]byte(s)), nil
+	case "bytes":
+		b, ok := v.([]byte)
+		if !ok {
+			return word, ErrInvalidValue
+		}
+		return sha256.Sum256(b), nil
+	case "bytes32":
+		b, ok := v.([]byte)
+		if !ok || len(b) > 32 {
+			return word, ErrInvalidValue
+		}
+		copy(word[:], b)
+		return word, nil
+	case "bool":
+		b, ok := v.(bool)
+		if !ok {
+			return word, ErrInvalidValue
+		}
+		if b {
+			word[31] = 1
+		}
+		return word, nil
+	case "uint64":
+		u, ok := v.(uint64)
+		if !ok {
+			return word, ErrInvalidValue
+		}
+		binary.BigEndian.PutUint64(word[24:], u)
+		return word, nil
+	case "uint256":
+		i, ok := v.(*big.Int)
+		if !ok || i.Sign() < 0 || i.BitLen() > 256 {
+			return word, ErrInvalidValue
+		}
+		i.FillBytes(word[:])
+		return word, nil
+	case "address":
+		s, ok := v.(string)
+		if !ok {
+			return word, ErrInvalidValue
+		}
+		addr, err := common.ParseAddress(s)
+		if err != nil {
+			return word, err
+		}
+		copy(word[32-common.AddressLength:], addr[:])
+		return word, nil
+	}
+
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return word, ErrInvalidValue
+	}
+	return td.hashStruct(typ, m)
+}
+
+func isAtomic(typ string) bool {
+	switch typ {
+	case "string", "bytes", "bytes32", "bool", "uint64", "uint256", "address":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package crypto
+
+import (
+	"runtime"
+	"sync"
+)
+
+// MaxBatch bounds the signatures a worker of a VerifierPool checks in one
+// batch.
+const MaxBatch = 64
+
+// VerifierPool verifies signatures on a fixed set of workers. Each worker
+// takes the signatures queued by concurrent callers, up to MaxBatch, and
+// checks them in one batch, so that transactions submitted one at a time
+// are batched as well.
+type VerifierPool struct {
+	jobs    chan *verifyJob
+	workers int
+}
+
+type verifyJob struct {
+	sigs []SignedDigest
+	errs []error // of sigs, written by the worker
+	wg   *sync.WaitGroup
+}
+
+// NewVerifierPool starts a pool of workers, GOMAXPROCS if workers is not
+// positive.
+func NewVerifierPool(workers int) *VerifierPool {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	p := &VerifierPool{jobs: make(chan *verifyJob, 4*workers), workers: workers}
+	for i := 0; i < workers; i++ {
+		go p.run()
+	}
+	return p
+}
+
+var (
+	defaultPool     *VerifierPool
+	defaultPoolOnce sync.Once
+)
+
+// DefaultVerifierPool returns the pool shared by the process, started with
+// GOMAXPROCS workers on first use.
+func DefaultVerifierPool() *VerifierPool {
+	defaultPoolOnce.Do(func() { defaultPool = NewVerifierPool(0) })
+	return defaultPool
+}
+
+// Workers returns the number of workers of p.
+func (p *VerifierPool) Workers() int { return p.workers }
+
+// Close stops the workers once the queued signatures are verified. p must
+// not be used afterwards.
+func (p *VerifierPool) Close() { close(p.jobs) }
+
+// Verify checks sigs as VerifyBatch does, spreading them over the workers.
+func (p *VerifierPool) Verify(sigs []SignedDigest) []error {
+	if len(sigs) == 0 {
+		return nil
+	}
+	size := (len(sigs) + p.workers - 1) / p.workers
+	if size > MaxBatch {
+		size = MaxBatch
+	}
+	var (
+		wg   sync.WaitGroup
+		jobs []*verifyJob
+	)
+	for start := 0; start < len(sigs); start += size {
+		end := start + size
+		if end > len(sigs) {
+			end = len(sigs)
+		}
+		job := &verifyJob{sigs: sigs[start:end], wg: &wg}
+		jobs = append(jobs, job)
+		wg.Add(1)
+		p.jobs <- job
+	}
+	wg.Wait()
+
+	var errs []error
+	for i, job := range jobs {
+		for j, err := range job.errs {
+			if err == nil {
+				continue
+			}
+			if errs == nil {
+				errs = make([]error, len(sigs))
+			}
+			errs[i*size+j] = err
+		}
+	}
+	return errs
+}
+
+// run verifies queued jobs, merging those waiting into batches of up to
+// MaxBatch signatures.
+func (p *VerifierPool) run() {
+	for job := range p.jobs {
+		batch := []*verifyJob{job}
+		n := len(job.sigs)
+	drain:
+		for n < MaxBatch {
+			select {
+			case more, ok := <-p.jobs:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, more)
+				n += len(more.sigs)
+			default:
+				break drain
+			}
+		}
+		sigs := job.sigs
+		if len(batch) > 1 {
+			sigs = make([]SignedDigest, 0, n)
+			for _, j := range batch {
+				sigs = append(sigs, j.sigs...)
+			}
+		}
+		errs := VerifyBatch(sigs)
+		for _, j := range batch {
+			if errs != nil {
+				j.errs, errs = errs[:len(j.sigs)], errs[len(j.sigs):]
+			}
+			j.wg.Done()
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Package did implements the did:agc DID method: it resolves the
+// identifiers of agents registered on ZionLayer into W3C DID Documents
+// (https://www.w3.org/TR/did-core/) built from their on-chain records.
+//
+// A did:agc identifier is "did:agc:" followed by the agent's address. The
+// agent's registered public key becomes its verification method, its
+// controller address the document controller, its capabilities a
+// "capabilities" extension property and its "service.<name>" metadata
+// entries service endpoints.
+package did
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zionlayer/zionlayer/core/crypto"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// Method is the DID method name, and Prefix starts every did:agc DID.
+const (
+	Method = "agc"
+	Prefix = "did:" + Method + ":"
+)
+
+// ServicePrefix marks the agent metadata entries published as services:
+// "service.inference" = "https://..." becomes the service "#inference".
+const ServicePrefix = "service."
+
+// JSON-LD contexts of resolved documents.
+const (
+	ContextDIDv1      = "https://www.w3.org/ns/did/v1"
+	ContextJWS2020    = "https://w3id.org/security/suites/jws-2020/v1"
+	ContextResolution = "https://w3id.org/did-resolution/v1"
+)
+
+// ContentType is the media type of a DID Document.
+const ContentType = "application/did+ld+json"
+
+// Resolution errors reported in ResolutionMetadata.Error.
+const (
+	ErrorInvalidDID         = "invalidDid"
+	ErrorNotFound           = "notFound"
+	ErrorMethodNotSupported = "methodNotSupported"
+)
+
+var (
+	ErrInvalidDID         = errors.New("did: invalid DID")
+	ErrMethodNotSupported = errors.New("did: method not supported")
+)
+
+// JWK is the public key of a verification method as a JSON Web Key.
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+}
+
+// VerificationMethod is a key that can authenticate as the DID subject.
+type VerificationMethod struct {
+	ID           string `json:"id"`
+	Type         string `json:"type"`
+	Controller   string `json:"controller"`
+	PublicKeyJwk *JWK   `json:"publicKeyJwk"`
+}
+
+// Service is an endpoint advertised by the DID subject.
+type Service struct {
+	ID              string `json:"id"`
+	Type            string `json:"type"`
+	ServiceEndpoint string `json:"serviceEndpoint"`
+}
+
+// Document is a DID Document.
+type Document struct {
+	Context              []string                 `json:"@context"`
+	ID                   string                   `json:"id"`
+	Controller           string                   `json:"controller,omitempty"`
+	VerificationMethod   []VerificationMethod     `json:"verificationMethod,omitempty"`
+	Authentication       []string                 `json:"authentication,omitempty"`
+	AssertionMethod      []string                 `json:"assertionMethod,omitempty"`
+	CapabilityInvocation []string                 `json:"capabilityInvocation,omitempty"`
+	Service              []Service                `json:"service,omitempty"`
+	Capabilities         []transaction.Capability `json:"capabilities,omitempty"`
+}
+
+// DocumentMetadata describes the document rather than the subject.
+type DocumentMetadata struct {
+	Created     uint64 `json:"created"` // registration block height
+	Deactivated bool   `json:"deactivated"`
+}
+
+// ResolutionMetadata describes the resolution itself.
+type ResolutionMetadata struct {
+	ContentType string `json:"contentType,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Result is the outcome of a DID resolution. Document and
+// DocumentMetadata are nil when ResolutionMetadata.Error is set.
+type Result struct {
+	Context            string             `json:"@context"`
+	Document           *Document          `json:"didDocument"`
+	ResolutionMetadata ResolutionMetadata `json:"didResolutionMetadata"`
+	DocumentMetadata   *DocumentMetadata  `json:"didDocumentMetadata"`
+}
+
+// Parse checks that id is a did:agc DID and returns the agent address it
+// names.
+func Parse(id string) (string, error) {
+	parts := strings.SplitN(id, ":", 3)
+	if len(parts) != 3 || parts[0] != "did" || parts[1] == "" || parts[2] == "" {
+		return "", ErrInvalidDID
+	}
+	if parts[1] != Method {
+		return "", fmt.Errorf("%w: %s", ErrMethodNotSupported, parts[1])
+	}
+	return parts[2], nil
+}
+
+// Resolve resolves id against the agents registered in stateDB. A
+// deactivated agent resolves to its last document with
+// DocumentMetadata.Deactivated set.
+func Resolve(stateDB *state.StateDB, id string) Result {
+	res := Result{Context: ContextResolution}
+	if _, err := Parse(id); err != nil {
+		res.ResolutionMetadata.Error = ErrorInvalidDID
+		if errors.Is(err, ErrMethodNotSupported) {
+			res.ResolutionMetadata.Error = ErrorMethodNotSupported
+		}
+		return res
+	}
+	rec, err := stateDB.GetAgent(id)
+	if err != nil {
+		res.ResolutionMetadata.Error = ErrorNotFound
+		return res
+	}
+	res.Document = NewDocument(rec)
+	res.ResolutionMetadata.ContentType = ContentType
+	res.DocumentMetadata = &DocumentMetadata{Created: rec.RegisteredAt, Deactivated: !rec.Active}
+	return res
+}
+
+// NewDocument builds the DID Document of an agent record.
+func NewDocument(rec *state.AgentRecord) *Document {
+	a := rec.DID
+	doc := &Document{
+		Context:      []string{ContextDIDv1, ContextJWS2020},
+		ID:           a.ID,
+		Capabilities: a.Capabilities,
+	}
+	if a.Controller != "" {
+		doc.Controller = Prefix + a.Controller
+	}
+	if len(a.PublicKey) == crypto.PublicKeySize {
+		key := a.ID + "#key-1"
+		doc.VerificationMethod = []VerificationMethod{{
+			ID:         key,
+			Type:       "JsonWebKey2020",
+			Controller: a.ID,
+			PublicKeyJwk: &JWK{
+				Kty: "OKP",
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(a.PublicKey),
+			},
+		}}
+		doc.Authentication = []string{key}
+		doc.AssertionMethod = []string{key}
+		doc.CapabilityInvocation = []string{key}
+	}
+	names := make([]string, 0, len(a.Metadata))
+	for k := range a.Metadata {
+		if name := strings.TrimPrefix(k, ServicePrefix); name != k && name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		doc.Service = append(doc.Service, Service{
+			ID:              a.ID + "#" + name,
+			Type:            name,
+			ServiceEndpoint: a.Metadata[ServicePrefix+name],
+		})
+	}
+	return doc
+}
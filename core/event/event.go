@@ -0,0 +1,145 @@
+// Package event is the node-internal publish/subscribe bus. Producers such
+// as the mempool, the AVM and the block indexer publish typed events, and
+// the RPC server and other consumers subscribe with channels of their own.
+package event
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/zionlayer/zionlayer/core/block"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// NewBlock is published once a finalized block has been indexed. Receipts
+// is nil if the block's transactions have not been executed.
+type NewBlock struct {
+	Block    *block.Block
+	Receipts []*transaction.Receipt
+}
+
+// NewTx is published when a transaction is admitted to the mempool.
+type NewTx struct {
+	Tx *transaction.Tx
+}
+
+// AgentRegistered is published when a registration transaction is executed.
+type AgentRegistered struct {
+	DID    transaction.AgentDID
+	TxHash [32]byte
+	Height uint64
+}
+
+// InferenceVerified is published when an inference receipt transaction is
+// executed.
+type InferenceVerified struct {
+	Receipt transaction.InferenceReceipt
+	TxHash  [32]byte
+	Height  uint64
+}
+
+// Bus carries one feed per event type.
+type Bus struct {
+	NewBlock          Feed[NewBlock]
+	NewTx             Feed[NewTx]
+	AgentRegistered   Feed[AgentRegistered]
+	InferenceVerified Feed[InferenceVerified]
+}
+
+// NewBus returns a bus without subscribers.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// ErrLagged ends a SubscribeAll subscription whose channel was full when an
+// event was sent.
+var ErrLagged = errors.New("event: subscriber fell behind")
+
+// Subscription is a registration on a feed.
+type Subscription struct {
+	once  sync.Once
+	unsub func()
+	err   chan error
+}
+
+// Unsubscribe stops delivery to the subscribed channel. It is safe to call
+// more than once. The channel is not closed.
+func (s *Subscription) Unsubscribe() {
+	s.once.Do(s.unsub)
+}
+
+// Err returns a channel receiving ErrLagged if a SubscribeAll subscription
+// is ended for falling behind. It never receives for Subscribe.
+func (s *Subscription) Err() <-chan error {
+	return s.err
+}
+
+// Feed fans events out to subscribed channels. Delivery never blocks: an
+// event is skipped for a Subscribe subscriber whose channel is full, so
+// slow consumers cannot stall the publisher, and ends the subscription of a
+// SubscribeAll subscriber instead. The zero value is ready to use.
+type Feed[T any] struct {
+	mu   sync.Mutex
+	next int
+	subs map[int]feedSub[T]
+}
+
+type feedSub[T any] struct {
+	ch  chan<- T
+	sub *Subscription // set for SubscribeAll
+}
+
+// Subscribe delivers the events sent after it returns on ch, skipping those
+// sent while ch is full.
+func (f *Feed[T]) Subscribe(ch chan<- T) *Subscription {
+	return f.subscribe(ch, false)
+}
+
+// SubscribeAll delivers every event sent after it returns on ch, or ends
+// the subscription with ErrLagged on Err at the first it cannot deliver
+// because ch is full. Size ch for the longest backlog the subscriber may
+// build up.
+func (f *Feed[T]) SubscribeAll(ch chan<- T) *Subscription {
+	return f.subscribe(ch, true)
+}
+
+func (f *Feed[T]) subscribe(ch chan<- T, all bool) *Subscription {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.subs == nil {
+		f.subs = make(map[int]feedSub[T])
+	}
+	id := f.next
+	f.next++
+	sub := &Subscription{err: make(chan error, 1), unsub: func() {
+		f.mu.Lock()
+		delete(f.subs, id)
+		f.mu.Unlock()
+	}}
+	fs := feedSub[T]{ch: ch}
+	if all {
+		fs.sub = sub
+	}
+	f.subs[id] = fs
+	return sub
+}
+
+// Send delivers v to every subscriber with room in its channel and returns
+// how many received it.
+func (f *Feed[T]) Send(v T) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for id, s := range f.subs {
+		select {
+		case s.ch <- v:
+			n++
+		default:
+			if s.sub != nil {
+				delete(f.subs, id)
+				s.sub.err <- ErrLagged
+			}
+		}
+	}
+	return n
+}
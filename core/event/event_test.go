@@ -0,0 +1,45 @@
+package event
+
+import "testing"
+
+func TestFeedLagged(t *testing.T) {
+	var f Feed[int]
+	skip := make(chan int, 1)
+	all := make(chan int, 1)
+	subSkip := f.Subscribe(skip)
+	subAll := f.SubscribeAll(all)
+	defer subSkip.Unsubscribe()
+	defer subAll.Unsubscribe()
+
+	if n := f.Send(1); n != 2 {
+		t.Fatalf("Send = %d, want 2", n)
+	}
+	if n := f.Send(2); n != 0 {
+		t.Fatalf("Send to full channels = %d, want 0", n)
+	}
+	select {
+	case err := <-subAll.Err():
+		if err != ErrLagged {
+			t.Errorf("Err = %v, want %v", err, ErrLagged)
+		}
+	default:
+		t.Fatal("SubscribeAll subscriber not told it fell behind")
+	}
+	select {
+	case err := <-subSkip.Err():
+		t.Errorf("Subscribe subscriber ended with %v", err)
+	default:
+	}
+
+	<-skip
+	<-all
+	f.Send(3)
+	if v := <-skip; v != 3 {
+		t.Errorf("Subscribe subscriber received %d, want 3", v)
+	}
+	select {
+	case v := <-all:
+		t.Errorf("ended subscription received %d", v)
+	default:
+	}
+}
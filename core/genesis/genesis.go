@@ -0,0 +1,232 @@
+package genesis
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/zionlayer/zionlayer/core/common"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+var (
+	ErrMissingChainID      = errors.New("genesis: chain id must be non-zero")
+	ErrInvalidBalance      = errors.New("genesis: invalid account balance")
+	ErrInvalidRoots        = errors.New("genesis: invalid attestation roots")
+	ErrInvalidVerifyingKey = errors.New("genesis: invalid verifying key")
+	ErrInvalidTokenomics   = errors.New("genesis: invalid tokenomics")
+	ErrInvalidFork         = errors.New("genesis: invalid parameter fork")
+	ErrInvalidBridge       = errors.New("genesis: invalid bridge")
+)
+
+// Account is a prefunded genesis account.
+type Account struct {
+	Address common.Address `json:"address"`
+	Balance string         `json:"balance"` // decimal, in base units
+}
+
+// Genesis describes the initial state and identity of a network.
+type Genesis struct {
+	ChainID   uint64    `json:"chainId"`
+	ChainName string    `json:"chainName"`
+	Accounts  []Account `json:"accounts"`
+
+	// DisputeVerifiers arbitrate disputes of inference receipts.
+	DisputeVerifiers []common.Address `json:"disputeVerifiers,omitempty"`
+
+	// Enclaves are the approved TEE enclave images, and AttestationRoots
+	// the PEM root certificates trusted for each TEE platform.
+	Enclaves         []state.EnclaveImage `json:"enclaves,omitempty"`
+	AttestationRoots map[string]string    `json:"attestationRoots,omitempty"`
+
+	// VerifyingKeys maps hex model hashes to the hex Groth16 verifying keys
+	// of their zkML circuits.
+	VerifyingKeys map[string]string `json:"verifyingKeys,omitempty"`
+
+	// Tokenomics replaces the default inflation schedule and block reward
+	// split.
+	Tokenomics *state.TokenomicsParams `json:"tokenomics,omitempty"`
+
+	// Bridge sets the bridge committee and the wrapped ZIO contract of the
+	// external chain.
+	Bridge *state.BridgeParams `json:"bridge,omitempty"`
+
+	// Forks schedules parameter changes activated at their heights. Each
+	// must apply to the parameters at genesis.
+	Forks []state.ParamVersion `json:"forks,omitempty"`
+
+	raw []byte // the file read by Load
+}
+
+// Devnet returns the built-in local development network genesis.
+func Devnet() *Genesis {
+	return &Genesis{
+		ChainID:   transaction.DevnetChainID,
+		ChainName: "ZionLayer Devnet",
+		Accounts: []Account{
+			{Address: common.MustParseAddress("0xC26cEF1869b2E506829916aFaEdD2405637df3Ed"), Balance: "100000000000000000000000000"},
+			{Address: common.MustParseAddress("0x72feFB990879f4C28591cDAAEddB4cb485559974"), Balance: "1000000000000000000000000"},
+		},
+		DisputeVerifiers: []common.Address{common.MustParseAddress("0x72feFB990879f4C28591cDAAEddB4cb485559974")},
+	}
+}
+
+// Hash returns the SHA-256 hash of the file g was loaded from, which with
+// the chain ID identifies the network in the headers of its blocks. The
+// nodes of a network share the file byte for byte, so the hash does not
+// depend on how a node decodes it. A genesis built in code, such as Devnet,
+// is hashed over its JSON encoding.
+func (g *Genesis) Hash() ([32]byte, error) {
+	data := g.raw
+	if data == nil {
+		var err error
+		if data, err = json.Marshal(g); err != nil {
+			return [32]byte{}, fmt.Errorf("genesis: %w", err)
+		}
+	}
+	return sha256.Sum256(data), nil
+}
+
+// Load reads a JSON genesis file. Addresses are parsed strictly.
+func Load(path string) (*Genesis, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var g Genesis
+	if err := json.Unmarshal(data, &g); err != nil {
+		return nil, fmt.Errorf("genesis: %w", err)
+	}
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	g.raw = data
+	return &g, nil
+}
+
+// Validate checks the genesis for consistency.
+func (g *Genesis) Validate() error {
+	if g.ChainID == 0 {
+		return ErrMissingChainID
+	}
+	for _, acc := range g.Accounts {
+		if _, err := parseBalance(acc.Balance); err != nil {
+			return fmt.Errorf("%w: %s", err, acc.Address)
+		}
+	}
+	for platform, pemData := range g.AttestationRoots {
+		if _, err := parseRoots(pemData); err != nil {
+			return fmt.Errorf("%w: %s", err, platform)
+		}
+	}
+	if g.Tokenomics != nil {
+		if err := g.Tokenomics.Validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidTokenomics, err)
+		}
+	}
+	if g.Bridge != nil {
+		if err := g.Bridge.Validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidBridge, err)
+		}
+	}
+	for _, f := range g.Forks {
+		if f.Height == 0 || len(f.Changes) == 0 {
+			return fmt.Errorf("%w: a fork needs a height and changes", ErrInvalidFork)
+		}
+	}
+	return nil
+}
+
+// Apply writes the genesis allocations into stateDB, minting the
+// balances of the accounts.
+func (g *Genesis) Apply(stateDB *state.StateDB) error {
+	for _, acc := range g.Accounts {
+		bal, err := parseBalance(acc.Balance)
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, acc.Address)
+		}
+		stateDB.Mint(acc.Address.String(), bal)
+	}
+	if g.Tokenomics != nil {
+		stateDB.SetTokenomicsParams(*g.Tokenomics)
+	}
+	if g.Bridge != nil {
+		stateDB.SetBridgeParams(*g.Bridge)
+	}
+	if len(g.DisputeVerifiers) > 0 {
+		p := stateDB.GetDisputeParams()
+		p.Verifiers = nil
+		for _, v := range g.DisputeVerifiers {
+			p.Verifiers = append(p.Verifiers, v.String())
+		}
+		stateDB.SetDisputeParams(p)
+	}
+	for _, img := range g.Enclaves {
+		stateDB.ApproveEnclave(img)
+	}
+	for platform, pemData := range g.AttestationRoots {
+		roots, err := parseRoots(pemData)
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, platform)
+		}
+		if err := stateDB.SetAttestationRoots(platform, roots); err != nil {
+			return err
+		}
+	}
+	for model, vk := range g.VerifyingKeys {
+		m, err1 := hex.DecodeString(strings.TrimPrefix(model, "0x"))
+		k, err2 := hex.DecodeString(strings.TrimPrefix(vk, "0x"))
+		if err1 != nil || err2 != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidVerifyingKey, model)
+		}
+		if err := stateDB.SetVerifyingKey(m, k); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidVerifyingKey, model, err)
+		}
+	}
+	for _, f := range g.Forks {
+		if err := stateDB.ScheduleParamChanges(f.Height, f.Changes); err != nil {
+			return fmt.Errorf("%w at height %d: %v", ErrInvalidFork, f.Height, err)
+		}
+	}
+	return nil
+}
+
+// parseRoots decodes the DER certificates of the CERTIFICATE blocks of
+// pemData.
+func parseRoots(pemData string) ([][]byte, error) {
+	var roots [][]byte
+	rest := []byte(pemData)
+	for {
+		var b *pem.Block
+		if b, rest = pem.Decode(rest); b == nil {
+			break
+		}
+		if b.Type != "CERTIFICATE" {
+			return nil, ErrInvalidRoots
+		}
+		if _, err := x509.ParseCertificate(b.Bytes); err != nil {
+			return nil, ErrInvalidRoots
+		}
+		roots = append(roots, b.Bytes)
+	}
+	if len(roots) == 0 {
+		return nil, ErrInvalidRoots
+	}
+	return roots, nil
+}
+
+func parseBalance(s string) (*big.Int, error) {
+	bal, ok := new(big.Int).SetString(s, 10)
+	if !ok || bal.Sign() < 0 {
+		return nil, ErrInvalidBalance
+	}
+	return bal, nil
+}
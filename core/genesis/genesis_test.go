@@ -0,0 +1,31 @@
+package genesis
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHashIsFileHash(t *testing.T) {
+	data := []byte(`{"chainId": 7, "chainName": "test", "accounts": []}`)
+	path := filepath.Join(t.TempDir(), "genesis.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := g.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := sha256.Sum256(data); got != want {
+		t.Errorf("Hash = %x, want the file hash %x", got, want)
+	}
+
+	if _, err := Devnet().Hash(); err != nil {
+		t.Errorf("Devnet().Hash: %v", err)
+	}
+}
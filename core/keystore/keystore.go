@@ -0,0 +1,263 @@
+// Package keystore stores named ed25519 keys encrypted under a passphrase.
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/zionlayer/zionlayer/core/crypto"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Dir is the name of the keystore inside the data directory.
+const Dir = "keystore"
+
+// Scrypt parameters for newly written keys. Existing files keep the
+// parameters they were written with.
+const (
+	ScryptN = 1 << 18
+	ScryptR = 8
+	ScryptP = 1
+)
+
+const (
+	keyVersion = 1
+	kdfScrypt  = "scrypt"
+	cipherGCM  = "aes-256-gcm"
+)
+
+var (
+	ErrKeyExists   = errors.New("keystore: key already exists")
+	ErrKeyNotFound = errors.New("keystore: key not found")
+	ErrInvalidName = errors.New("keystore: key names may only contain letters, digits, '-' and '_'")
+	ErrDecrypt     = errors.New("keystore: could not decrypt key with the given passphrase")
+	ErrUnsupported = errors.New("keystore: unsupported key file")
+)
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// KeyInfo is the public part of a stored key.
+type KeyInfo struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	PubKey  []byte `json:"pubKey"`
+}
+
+// keyFile is the on-disk form of a key. The ciphertext is the 32-byte
+// ed25519 seed sealed with AES-256-GCM under a scrypt-derived key.
+type keyFile struct {
+	Version int `json:"version"`
+	KeyInfo
+	Crypto cryptoJSON `json:"crypto"`
+}
+
+type cryptoJSON struct {
+	KDF        string    `json:"kdf"`
+	KDFParams  kdfParams `json:"kdfParams"`
+	Cipher     string    `json:"cipher"`
+	Nonce      []byte    `json:"nonce"`
+	Ciphertext []byte    `json:"ciphertext"`
+}
+
+type kdfParams struct {
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+	Salt []byte `json:"salt"`
+}
+
+// Keystore is a directory of key files, one per name.
+type Keystore struct {
+	dir string
+}
+
+// New returns the keystore in dir. The directory is created on first write.
+func New(dir string) *Keystore {
+	return &Keystore{dir: dir}
+}
+
+func (ks *Keystore) path(name string) string {
+	return filepath.Join(ks.dir, name+".json")
+}
+
+// Add encrypts priv under passphrase and stores it as name.
+func (ks *Keystore) Add(name string, priv crypto.PrivateKey, passphrase string) (*KeyInfo, error) {
+	if !validName.MatchString(name) {
+		return nil, ErrInvalidName
+	}
+	if len(priv) != crypto.PrivateKeySize {
+		return nil, crypto.ErrInvalidPrivateKey
+	}
+	if _, err := os.Stat(ks.path(name)); err == nil {
+		return nil, ErrKeyExists
+	}
+	pub := priv.Public().(crypto.PublicKey)
+	kf := keyFile{
+		Version: keyVersion,
+		KeyInfo: KeyInfo{Name: name, Address: crypto.PubkeyToAddress(pub).String(), PubKey: pub},
+	}
+	var err error
+	if kf.Crypto, err = encrypt(priv.Seed(), passphrase); err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(kf, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(ks.dir, 0o700); err != nil {
+		return nil, err
+	}
+	// O_EXCL keeps a concurrent Add from overwriting the key.
+	f, err := os.OpenFile(ks.path(name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return nil, ErrKeyExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return &kf.KeyInfo, nil
+}
+
+// Get returns the public information of the key stored as name.
+func (ks *Keystore) Get(name string) (*KeyInfo, error) {
+	kf, err := ks.read(name)
+	if err != nil {
+		return nil, err
+	}
+	return &kf.KeyInfo, nil
+}
+
+// List returns the stored keys sorted by name.
+func (ks *Keystore) List() ([]*KeyInfo, error) {
+	entries, err := os.ReadDir(ks.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var keys []*KeyInfo
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() || !validName.MatchString(name) {
+			continue
+		}
+		info, err := ks.Get(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name(), err)
+		}
+		keys = append(keys, info)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Name < keys[j].Name })
+	return keys, nil
+}
+
+// Unlock decrypts the key stored as name.
+func (ks *Keystore) Unlock(name, passphrase string) (crypto.PrivateKey, error) {
+	kf, err := ks.read(name)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := decrypt(&kf.Crypto, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// Delete removes the key stored as name after checking passphrase.
+func (ks *Keystore) Delete(name, passphrase string) error {
+	if _, err := ks.Unlock(name, passphrase); err != nil {
+		return err
+	}
+	return os.Remove(ks.path(name))
+}
+
+func (ks *Keystore) read(name string) (*keyFile, error) {
+	if !validName.MatchString(name) {
+		return nil, ErrInvalidName
+	}
+	data, err := os.ReadFile(ks.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var kf keyFile
+	if err := json.Unmarshal(data, &kf); err != nil {
+		return nil, fmt.Errorf("keystore: %w", err)
+	}
+	if kf.Version != keyVersion || kf.Crypto.KDF != kdfScrypt || kf.Crypto.Cipher != cipherGCM {
+		return nil, ErrUnsupported
+	}
+	return &kf, nil
+}
+
+func encrypt(plaintext []byte, passphrase string) (cryptoJSON, error) {
+	c := cryptoJSON{
+		KDF:       kdfScrypt,
+		KDFParams: kdfParams{N: ScryptN, R: ScryptR, P: ScryptP, Salt: make([]byte, 32)},
+		Cipher:    cipherGCM,
+	}
+	if _, err := rand.Read(c.KDFParams.Salt); err != nil {
+		return c, err
+	}
+	aead, err := newAEAD(&c.KDFParams, passphrase)
+	if err != nil {
+		return c, err
+	}
+	c.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(c.Nonce); err != nil {
+		return c, err
+	}
+	c.Ciphertext = aead.Seal(nil, c.Nonce, plaintext, nil)
+	return c, nil
+}
+
+func decrypt(c *cryptoJSON, passphrase string) ([]byte, error) {
+	aead, err := newAEAD(&c.KDFParams, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.Nonce) != aead.NonceSize() {
+		return nil, ErrUnsupported
+	}
+	plaintext, err := aead.Open(nil, c.Nonce, c.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	if len(plaintext) != ed25519.SeedSize {
+		return nil, ErrUnsupported
+	}
+	return plaintext, nil
+}
+
+func newAEAD(p *kdfParams, passphrase string) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), p.Salt, p.N, p.R, p.P, 32)
+	if err != nil {
+		return nil, fmt.Errorf("keystore: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
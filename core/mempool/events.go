@@ -0,0 +1,62 @@
+package mempool
+
+import (
+	"github.com/zionlayer/zionlayer/core/event"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// DropReason explains why a transaction left the pool without being
+// selected for a block.
+type DropReason uint8
+
+const (
+	DropReplaced DropReason = iota // superseded by a fee bump at the same nonce
+	DropEvicted                    // evicted to make room for a better paying tx
+	DropExpired                    // outlived the pool TTL
+	DropStale                      // nonce already used on chain
+	DropFlushed                    // removed by an operator flush
+)
+
+func (r DropReason) String() string {
+	switch r {
+	case DropReplaced:
+		return "replaced"
+	case DropEvicted:
+		return "evicted"
+	case DropExpired:
+		return "expired"
+	case DropStale:
+		return "stale"
+	case DropFlushed:
+		return "flushed"
+	}
+	return "unknown"
+}
+
+// DropEvent reports a transaction dropped from the pool.
+type DropEvent struct {
+	Tx     *transaction.Tx
+	Reason DropReason
+}
+
+// Subscription is a registration on a pool event feed.
+type Subscription = event.Subscription
+
+// SubscribeNewTx delivers every transaction admitted to the pool on ch.
+func (p *Pool) SubscribeNewTx(ch chan<- *transaction.Tx) *Subscription {
+	return p.newTxFeed.Subscribe(ch)
+}
+
+// SubscribeDropped delivers every transaction dropped from the pool without
+// being selected for a block on ch.
+func (p *Pool) SubscribeDropped(ch chan<- DropEvent) *Subscription {
+	return p.dropFeed.Subscribe(ch)
+}
+
+// SetEventBus publishes every transaction admitted to the pool on bus as
+// an event.NewTx.
+func (p *Pool) SetEventBus(bus *event.Bus) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = bus
+}
@@ -0,0 +1,96 @@
+package mempool
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/zionlayer/zionlayer/core/rlp"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// JournalFile is the name of the mempool journal inside the data directory.
+const JournalFile = "mempool.journal"
+
+// The journal is a flat sequence of RLP byte strings, each holding one
+// binary transaction envelope. Transactions are written sender by sender in
+// nonce order so that replaying the journal through Add reproduces the pool.
+
+// Save writes every pooled transaction to the journal at path. The file is
+// replaced atomically so a crash mid-write leaves the previous journal
+// intact.
+func (p *Pool) Save(path string) error {
+	p.mu.RLock()
+	senders := make([]string, 0, len(p.senders))
+	for from := range p.senders {
+		senders = append(senders, from)
+	}
+	sort.Strings(senders)
+	var buf []byte
+	for _, from := range senders {
+		list := p.senders[from]
+		for _, n := range list.nonces() {
+			enc, err := list.txs[n].MarshalBinary()
+			if err != nil {
+				p.mu.RUnlock()
+				return err
+			}
+			buf = append(buf, rlp.EncodeBytes(enc)...)
+		}
+	}
+	p.mu.RUnlock()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ReadJournal decodes the transactions stored in the journal at path. A
+// missing journal yields no transactions. Decoding stops at the first
+// malformed entry; the transactions read up to that point are returned
+// together with the error.
+func ReadJournal(path string) ([]*transaction.Tx, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var txs []*transaction.Tx
+	for len(data) > 0 {
+		enc, rest, err := rlp.SplitBytes(data)
+		if err != nil {
+			return txs, err
+		}
+		tx := new(transaction.Tx)
+		if err := tx.UnmarshalBinary(enc); err != nil {
+			return txs, err
+		}
+		txs = append(txs, tx)
+		data = rest
+	}
+	return txs, nil
+}
+
+// Load re-admits the transactions journaled at path. Each one goes through
+// Add and is therefore re-validated against the current state; those that
+// no longer qualify are dropped. It reports how many were restored and how
+// many were dropped.
+func (p *Pool) Load(path string) (restored, dropped int, err error) {
+	txs, err := ReadJournal(path)
+	for _, err := range p.AddBatch(txs) {
+		if err != nil {
+			dropped++
+			continue
+		}
+		restored++
+	}
+	return restored, dropped, err
+}
@@ -0,0 +1,139 @@
+package mempool
+
+import (
+	"sort"
+	"time"
+
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// txList holds the transactions of a single sender, indexed by nonce.
+// head is the transaction executable next, the one at the account nonce,
+// and index the position of the list in the pool's sender heap while head
+// is set.
+type txList struct {
+	from  string
+	txs   map[uint64]*transaction.Tx
+	head  *transaction.Tx
+	index int
+}
+
+func newTxList(from string) *txList {
+	return &txList{from: from, txs: make(map[uint64]*transaction.Tx), index: -1}
+}
+
+func (l *txList) Len() int { return len(l.txs) }
+
+// nonces returns the nonces held in ascending order.
+func (l *txList) nonces() []uint64 {
+	out := make([]uint64, 0, len(l.txs))
+	for n := range l.txs {
+		out = append(out, n)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// split partitions the list at the account nonce next into pending
+// transactions, forming a gapless run starting at next, and queued ones
+// that wait behind a gap. Both are returned in nonce order.
+func (l *txList) split(next uint64) (pending, queued []*transaction.Tx) {
+	for _, n := range l.nonces() {
+		tx := l.txs[n]
+		if n == next {
+			pending = append(pending, tx)
+			next++
+			continue
+		}
+		queued = append(queued, tx)
+	}
+	return pending, queued
+}
+
+// forward removes and returns all transactions with a nonce below next.
+func (l *txList) forward(next uint64) []*transaction.Tx {
+	var removed []*transaction.Tx
+	for n, tx := range l.txs {
+		if n < next {
+			removed = append(removed, tx)
+			delete(l.txs, n)
+		}
+	}
+	return removed
+}
+
+// senderHeap orders the senders with an executable transaction by the gas
+// price of their head, highest first, ties broken by address so that
+// selection is deterministic. It implements heap.Interface.
+type senderHeap []*txList
+
+func (h senderHeap) Len() int { return len(h) }
+
+func (h senderHeap) Less(i, j int) bool {
+	c := gasPrice(h[i].head).Cmp(gasPrice(h[j].head))
+	return c > 0 || (c == 0 && h[i].from < h[j].from)
+}
+
+func (h senderHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *senderHeap) Push(x any) {
+	l := x.(*txList)
+	l.index = len(*h)
+	*h = append(*h, l)
+}
+
+func (h *senderHeap) Pop() any {
+	old := *h
+	l := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	l.index = -1
+	return l
+}
+
+// pooledTx is a pooled transaction with its hash, the time it was added
+// and its position in the pool's price heap.
+type pooledTx struct {
+	tx    *transaction.Tx
+	hash  [32]byte
+	added time.Time
+	index int
+}
+
+// priceHeap orders the pooled transactions by gas price, lowest first, and
+// on ties by nonce, highest first, so that evicting its top does not open a
+// gap ahead of other transactions of the same sender. It implements
+// heap.Interface.
+type priceHeap []*pooledTx
+
+func (h priceHeap) Len() int { return len(h) }
+
+func (h priceHeap) Less(i, j int) bool {
+	c := gasPrice(h[i].tx).Cmp(gasPrice(h[j].tx))
+	return c < 0 || (c == 0 && h[i].tx.Nonce > h[j].tx.Nonce)
+}
+
+func (h priceHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *priceHeap) Push(x any) {
+	m := x.(*pooledTx)
+	m.index = len(*h)
+	*h = append(*h, m)
+}
+
+func (h *priceHeap) Pop() any {
+	old := *h
+	m := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	m.index = -1
+	return m
+}
@@ -1,75 +1,647 @@
 package mempool
 
 import (
+	"container/heap"
+	"context"
 	"errors"
+	"fmt"
+	"math/big"
 	"sort"
 	"sync"
+	"time"
 
+	"github.com/zionlayer/zionlayer/core/event"
+	"github.com/zionlayer/zionlayer/core/state"
 	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/telemetry"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	MaxPoolSize = 10_000
+
+	// DefaultPriceBump is the minimum gas price increase, in percent,
+	// required to replace a pooled transaction.
+	DefaultPriceBump = 10
+
+	// DefaultMaxNonceGap is how far ahead of the account nonce a
+	// transaction may be to be queued.
+	DefaultMaxNonceGap = 64
+
+	// DefaultMaxPerSender caps the transactions pooled for one sender.
+	DefaultMaxPerSender = 64
+
+	// DefaultTTL is how long a transaction may stay in the pool.
+	DefaultTTL = 3 * time.Hour
 )
 
 var (
-	ErrPoolFull    = errors.New("mempool is full")
-	ErrDuplicateTx = errors.New("duplicate transaction")
+	ErrPoolFull           = errors.New("mempool is full")
+	ErrDuplicateTx        = errors.New("duplicate transaction")
+	ErrNonceTooLow        = errors.New("nonce too low")
+	ErrReplaceUnderpriced = errors.New("replacement transaction underpriced")
+	ErrNonceTooHigh       = errors.New("nonce too far ahead of account nonce")
+	ErrUnderpriced        = errors.New("gas price below pool minimum")
+	ErrInsufficientFunds  = errors.New("insufficient funds for value + gas * price")
+	ErrSenderLimit        = errors.New("too many pooled transactions from sender")
 )
 
-// Pool is a thread-safe transaction pool.
+// Config holds the tunable mempool parameters.
+type Config struct {
+	// PriceBump is the percentage by which a transaction's gas price must
+	// exceed that of a pooled transaction with the same sender and nonce
+	// to replace it.
+	PriceBump uint64
+	// MinGasPrice is the lowest gas price admitted.
+	MinGasPrice *big.Int
+	// MaxNonceGap bounds how far ahead of the account nonce a queued
+	// transaction may be.
+	MaxNonceGap uint64
+	// MaxSize is the pool capacity. When full, the lowest priced
+	// transaction is evicted in favor of a better paying one.
+	MaxSize int
+	// MaxPerSender caps the transactions pooled for a single sender.
+	MaxPerSender int
+	// TTL is how long a transaction may wait in the pool before it is
+	// dropped. Zero disables expiry.
+	TTL time.Duration
+	// Lanes reserve block space for latency-sensitive transaction types.
+	// They are filled in order before the remaining space is auctioned.
+	Lanes []Lane
+}
+
+// Lane reserves a share of each block's gas for a set of transaction types,
+// so that a flood of other traffic cannot starve them.
+type Lane struct {
+	Name  string
+	Types []transaction.TxType
+	// Share is the percentage of the block gas limit reserved for the lane.
+	Share uint64
+}
+
+// Admits reports whether tx belongs to the lane.
+func (l Lane) Admits(tx *transaction.Tx) bool {
+	for _, t := range l.Types {
+		if tx.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultLanes reserves block space for agent messaging and inference
+// receipts.
+func DefaultLanes() []Lane {
+	return []Lane{
+		{Name: "agent", Types: []transaction.TxType{transaction.TxAgentRegister, transaction.TxAgentMessage}, Share: 20},
+		{Name: "inference", Types: []transaction.TxType{transaction.TxInferenceReceipt}, Share: 10},
+	}
+}
+
+// DefaultConfig returns the default mempool parameters.
+func DefaultConfig() Config {
+	return Config{
+		PriceBump:    DefaultPriceBump,
+		MinGasPrice:  big.NewInt(1),
+		MaxNonceGap:  DefaultMaxNonceGap,
+		MaxSize:      MaxPoolSize,
+		MaxPerSender: DefaultMaxPerSender,
+		TTL:          DefaultTTL,
+		Lanes:        DefaultLanes(),
+	}
+}
+
+// Pool is a thread-safe transaction pool. Transactions are kept per sender,
+// ordered by nonce. A sender's pending transactions form a gapless run
+// starting at its account nonce and are executable now; the rest are
+// queued until the gap before them is filled.
 type Pool struct {
-	mu  sync.RWMutex
-	txs map[[32]byte]*transaction.Tx
+	mu      sync.RWMutex
+	all     map[[32]byte]*pooledTx
+	traces  map[[32]byte]trace.SpanContext // admission span of sampled txs
+	senders map[string]*txList
+	heads   senderHeap // senders with a pending transaction
+	prices  priceHeap  // every pooled transaction, cheapest first
+	// expiry lists the pooled transactions in the order they were added,
+	// and may still hold some since removed; see expire.
+	expiry []*pooledTx
+	// dirty holds the senders of the transactions taken by Pop, whose
+	// account nonces move once the block holding them is applied.
+	dirty map[string]struct{}
+
+	state    *state.StateDB
+	chainID  uint64
+	config   Config
+	verifier Verifier
+
+	newTxFeed event.Feed[*transaction.Tx]
+	dropFeed  event.Feed[DropEvent]
+	events    *event.Bus
 }
 
-// NewPool creates an empty mempool.
-func NewPool() *Pool {
+// Verifier authenticates a transaction before admission and returns the
+// address that pays for its gas. It must accept smart account senders,
+// whose signatures are checked by contract code.
+type Verifier func(tx *transaction.Tx) (payer string, err error)
+
+// NewPool creates an empty mempool accepting transactions signed for chainID.
+// Account nonces are read from stateDB.
+func NewPool(chainID uint64, stateDB *state.StateDB, config Config) *Pool {
 	return &Pool{
-		txs: make(map[[32]byte]*transaction.Tx),
+		all:     make(map[[32]byte]*pooledTx),
+		traces:  make(map[[32]byte]trace.SpanContext),
+		senders: make(map[string]*txList),
+		dirty:   make(map[string]struct{}),
+		state:   stateDB,
+		chainID: chainID,
+		config:  config,
 	}
 }
 
-// Add inserts a transaction into the pool.
-func (p *Pool) Add(tx *transaction.Tx) error {
+// SetVerifier replaces the default signature check used on admission. The
+// default accepts only key-signed senders.
+func (p *Pool) SetVerifier(v Verifier) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	if len(p.txs) >= MaxPoolSize {
-		return ErrPoolFull
-	}
+	p.verifier = v
+}
+
+// Add validates a transaction against current state and inserts it into the
+// pool. It checks the signature, the minimum gas price, that the nonce is
+// neither used nor too far ahead, and that the sender and fee payer can
+// afford the value and full gas allowance. A transaction with the same
+// sender and nonce as a pooled one replaces it if its gas price is at least
+// PriceBump percent higher.
+func (p *Pool) Add(tx *transaction.Tx) error {
+	return p.AddContext(context.Background(), tx)
+}
+
+// AddContext is Add recording the admission as a span under ctx. The span
+// is linked from the block that later includes the transaction.
+func (p *Pool) AddContext(ctx context.Context, tx *transaction.Tx) (err error) {
 	h := tx.Hash()
-	if _, exists := p.txs[h]; exists {
-		return ErrDuplicateTx
+	_, span := telemetry.Start(ctx, "mempool.add", trace.WithAttributes(
+		telemetry.AttrTxHash.String(fmt.Sprintf("0x%x", h)),
+		telemetry.AttrTxType.Int(int(tx.Type)),
+		telemetry.AttrTxFrom.String(tx.From),
+	))
+	defer func() { telemetry.End(span, err) }()
+	if err := p.add(tx, h); err != nil {
+		return err
+	}
+	if sc := span.SpanContext(); sc.IsSampled() {
+		p.mu.Lock()
+		if _, ok := p.all[h]; ok {
+			p.traces[h] = sc
+		}
+		p.mu.Unlock()
 	}
-	p.txs[h] = tx
 	return nil
 }
 
-// Pop removes and returns up to n transactions, sorted by gas price descending.
-func (p *Pool) Pop(n int) []*transaction.Tx {
+func (p *Pool) add(tx *transaction.Tx, h [32]byte) error {
+	if err := tx.ValidateBasic(); err != nil {
+		return err
+	}
+	if p.config.MinGasPrice != nil && gasPrice(tx).Cmp(p.config.MinGasPrice) < 0 {
+		return ErrUnderpriced
+	}
+	// Checked on the verifier pool, the signatures of transactions added
+	// concurrently are batched together.
+	transaction.Preverify(tx)
+	p.mu.RLock()
+	verify := p.verifier
+	p.mu.RUnlock()
+	payer := ""
+	if verify != nil {
+		var err error
+		if payer, err = verify(tx); err != nil {
+			return err
+		}
+	} else {
+		addr, err := tx.FeePayer(p.chainID)
+		if err != nil {
+			return err
+		}
+		payer = addr.String()
+	}
+	if err := p.checkFunds(tx, payer); err != nil {
+		return err
+	}
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	next := p.state.GetNonce(tx.From)
+	if tx.Nonce < next {
+		return ErrNonceTooLow
+	}
+	if p.config.MaxNonceGap > 0 && tx.Nonce-next > p.config.MaxNonceGap {
+		return ErrNonceTooHigh
+	}
+	if _, exists := p.all[h]; exists {
+		return ErrDuplicateTx
+	}
+	list := p.senders[tx.From]
+	if _, ok := p.dirty[tx.From]; ok && list != nil {
+		p.sync(list)
+		list = p.senders[tx.From]
+	}
+	var old *transaction.Tx
+	if list != nil {
+		old = list.txs[tx.Nonce]
+	}
+	if old != nil {
+		if !p.canReplace(old, tx) {
+			return ErrReplaceUnderpriced
+		}
+		p.remove(old)
+		p.dropFeed.Send(DropEvent{Tx: old, Reason: DropReplaced})
+	} else {
+		if list != nil && p.config.MaxPerSender > 0 && list.Len() >= p.config.MaxPerSender {
+			return ErrSenderLimit
+		}
+		if p.config.MaxSize > 0 && len(p.all) >= p.config.MaxSize {
+			p.dropStale()
+		}
+		if p.config.MaxSize > 0 && len(p.all) >= p.config.MaxSize {
+			cheapest := p.cheapest()
+			if cheapest == nil || gasPrice(tx).Cmp(gasPrice(cheapest)) <= 0 {
+				return ErrPoolFull
+			}
+			p.remove(cheapest)
+			p.dropFeed.Send(DropEvent{Tx: cheapest, Reason: DropEvicted})
+		}
+	}
+	if list = p.senders[tx.From]; list == nil {
+		list = newTxList(tx.From)
+		p.senders[tx.From] = list
+	}
+	list.txs[tx.Nonce] = tx
+	p.setHead(list, next)
+	m := &pooledTx{tx: tx, hash: h, added: time.Now()}
+	p.all[h] = m
+	heap.Push(&p.prices, m)
+	p.expiry = append(p.expiry, m)
+	p.compactExpiry()
+	p.newTxFeed.Send(tx)
+	if p.events != nil {
+		p.events.NewTx.Send(event.NewTx{Tx: tx})
+	}
+	return nil
+}
+
+// AddBatch adds txs as Add does, in order, after checking their signatures
+// in parallel, and returns the error of each transaction, nil for those
+// added.
+func (p *Pool) AddBatch(txs []*transaction.Tx) []error {
+	transaction.Preverify(txs...)
+	errs := make([]error, len(txs))
+	for i, tx := range txs {
+		errs[i] = p.Add(tx)
+	}
+	return errs
+}
 
-	all := make([]*transaction.Tx, 0, len(p.txs))
-	for _, tx := range p.txs {
-		all = append(all, tx)
+// Reinject returns the transactions of blocks abandoned by a reorg to the
+// pool. Transactions for which included reports true are already part of
+// the canonical chain and are skipped; the rest are re-validated through Add
+// in sender and nonce order, and those no longer admissible are dropped. It
+// returns the number of transactions re-queued.
+func (p *Pool) Reinject(txs []*transaction.Tx, included func(hash [32]byte) bool) int {
+	sorted := make([]*transaction.Tx, 0, len(txs))
+	for _, tx := range txs {
+		if included != nil && included(tx.Hash()) {
+			continue
+		}
+		sorted = append(sorted, tx)
 	}
-	sort.Slice(all, func(i, j int) bool {
-		return all[i].GasPrice.Cmp(all[j].GasPrice) > 0
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].From != sorted[j].From {
+			return sorted[i].From < sorted[j].From
+		}
+		return sorted[i].Nonce < sorted[j].Nonce
 	})
-	if n > len(all) {
-		n = len(all)
+	n := 0
+	for _, err := range p.AddBatch(sorted) {
+		if err == nil {
+			n++
+		}
 	}
-	selected := all[:n]
+	return n
+}
+
+// Pop removes and returns up to n pending transactions whose combined gas
+// does not exceed gasLimit. Each configured lane is first filled up to its
+// share of gasLimit with transactions of its types; the remaining space goes
+// to all transactions alike. Each sender's transactions are returned in
+// nonce order; across senders the highest gas price is taken first.
+// Transactions whose nonce has already been used are dropped.
+func (p *Pool) Pop(n int, gasLimit uint64) []*transaction.Tx {
+	return p.PopContext(context.Background(), n, gasLimit)
+}
+
+// PopContext is Pop recording the selection as a span under ctx, typically
+// the production of the block the transactions go into, linked to their
+// admission spans.
+func (p *Pool) PopContext(ctx context.Context, n int, gasLimit uint64) []*transaction.Tx {
+	start := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.dropStale()
+	selected := make([]*transaction.Tx, 0, n)
+	for _, lane := range p.config.Lanes {
+		budget := gasLimit / 100 * lane.Share
+		if budget > gasLimit {
+			budget = gasLimit
+		}
+		var used uint64
+		selected, used = p.fill(selected, n, budget, lane.Admits)
+		gasLimit -= used
+	}
+	selected, _ = p.fill(selected, n, gasLimit, nil)
+
+	var links []trace.Link
 	for _, tx := range selected {
-		delete(p.txs, tx.Hash())
+		if sc, ok := p.traces[tx.Hash()]; ok {
+			links = append(links, trace.Link{SpanContext: sc})
+		}
+		p.remove(tx)
+		p.dirty[tx.From] = struct{}{}
 	}
+	// Links must be known when the span starts, so it is started after the
+	// selection with the time the selection began.
+	_, span := telemetry.Start(ctx, "mempool.pop", trace.WithTimestamp(start), trace.WithLinks(links...),
+		trace.WithAttributes(telemetry.AttrBlockTxs.Int(len(selected))))
+	span.End()
 	return selected
 }
 
-// Size returns the number of pending transactions.
+// fill appends the best priced pending heads accepted by admit to
+// selected until n transactions are selected or budget is spent, and
+// returns the gas consumed. A selected head is replaced by the sender's next
+// nonce in p.heads; the selected transactions are left for the caller to
+// remove, which restores the heads. A nil admit accepts every transaction.
+// The caller must hold p.mu.
+func (p *Pool) fill(selected []*transaction.Tx, n int, budget uint64, admit func(*transaction.Tx) bool) ([]*transaction.Tx, uint64) {
+	var (
+		used    uint64
+		skipped []*txList
+	)
+	for len(selected) < n && len(p.heads) > 0 {
+		list := p.heads[0]
+		tx := list.head
+		// Later nonces of a skipped sender cannot be included either.
+		if (admit != nil && !admit(tx)) || tx.Gas > budget-used {
+			skipped = append(skipped, heap.Pop(&p.heads).(*txList))
+			continue
+		}
+		used += tx.Gas
+		selected = append(selected, tx)
+		if list.head = list.txs[tx.Nonce+1]; list.head != nil {
+			heap.Fix(&p.heads, 0)
+		} else {
+			heap.Pop(&p.heads)
+		}
+	}
+	for _, list := range skipped {
+		heap.Push(&p.heads, list)
+	}
+	return selected, used
+}
+
+// Pending returns the executable transactions of every sender, in nonce
+// order.
+func (p *Pool) Pending() map[string][]*transaction.Tx {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	out := make(map[string][]*transaction.Tx)
+	for from, list := range p.senders {
+		if pending, _ := list.split(p.state.GetNonce(from)); len(pending) > 0 {
+			out[from] = pending
+		}
+	}
+	return out
+}
+
+// Queued returns the transactions of every sender that wait behind a nonce
+// gap, in nonce order.
+func (p *Pool) Queued() map[string][]*transaction.Tx {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	out := make(map[string][]*transaction.Tx)
+	for from, list := range p.senders {
+		if _, queued := list.split(p.state.GetNonce(from)); len(queued) > 0 {
+			out[from] = queued
+		}
+	}
+	return out
+}
+
+// PendingNonce returns the next nonce of addr after its pending
+// transactions: the account nonce if none are pooled.
+func (p *Pool) PendingNonce(addr string) uint64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	next := p.state.GetNonce(addr)
+	if list, ok := p.senders[addr]; ok {
+		for list.txs[next] != nil {
+			next++
+		}
+	}
+	return next
+}
+
+// Stats returns the number of pending and queued transactions.
+func (p *Pool) Stats() (pending, queued int) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for from, list := range p.senders {
+		pend, q := list.split(p.state.GetNonce(from))
+		pending += len(pend)
+		queued += len(q)
+	}
+	return pending, queued
+}
+
+// Size returns the number of pooled transactions.
 func (p *Pool) Size() int {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
-	return len(p.txs)
+	return len(p.all)
+}
+
+// checkFunds verifies that the fee payer can cover the full gas allowance
+// and the sender the transferred value. When both are the same account it
+// must cover the sum.
+func (p *Pool) checkFunds(tx *transaction.Tx, payer string) error {
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas), gasPrice(tx))
+	value := new(big.Int)
+	if tx.Value != nil {
+		value.Set(tx.Value)
+	}
+	if payer == tx.From {
+		fee.Add(fee, value)
+		value.SetInt64(0)
+	}
+	if p.state.GetBalance(payer).Cmp(fee) < 0 {
+		return ErrInsufficientFunds
+	}
+	if p.state.GetBalance(tx.From).Cmp(value) < 0 {
+		return ErrInsufficientFunds
+	}
+	return nil
+}
+
+// canReplace reports whether next pays enough to replace old.
+func (p *Pool) canReplace(old, next *transaction.Tx) bool {
+	oldPrice, nextPrice := gasPrice(old), gasPrice(next)
+	if nextPrice.Cmp(oldPrice) <= 0 {
+		return false
+	}
+	// nextPrice * 100 >= oldPrice * (100 + bump)
+	lhs := new(big.Int).Mul(nextPrice, big.NewInt(100))
+	rhs := new(big.Int).Mul(oldPrice, new(big.Int).SetUint64(100+p.config.PriceBump))
+	return lhs.Cmp(rhs) >= 0
+}
+
+func gasPrice(tx *transaction.Tx) *big.Int {
+	if tx.GasPrice == nil {
+		return new(big.Int)
+	}
+	return tx.GasPrice
+}
+
+// cheapest returns the pooled transaction with the lowest gas price,
+// preferring the highest nonce on ties so eviction does not open a gap
+// ahead of other transactions of the same sender.
+func (p *Pool) cheapest() *transaction.Tx {
+	if len(p.prices) == 0 {
+		return nil
+	}
+	return p.prices[0].tx
+}
+
+// dropStale drops the transactions whose nonce has been used by the
+// transactions taken since the last call and those that have outlived the
+// TTL. It only visits the senders marked dirty by Pop and the expired
+// transactions. The caller must hold p.mu.
+func (p *Pool) dropStale() {
+	for from := range p.dirty {
+		if list, ok := p.senders[from]; ok {
+			p.sync(list)
+		} else {
+			delete(p.dirty, from)
+		}
+	}
+	p.expire()
+}
+
+// sync drops the transactions of list whose nonce has been used on chain
+// and sets its head at the account nonce. The caller must hold p.mu.
+func (p *Pool) sync(list *txList) {
+	next := p.state.GetNonce(list.from)
+	for _, tx := range list.forward(next) {
+		p.forget(tx)
+		p.dropFeed.Send(DropEvent{Tx: tx, Reason: DropStale})
+	}
+	delete(p.dirty, list.from)
+	p.setHead(list, next)
+}
+
+// expire drops the transactions that have outlived the TTL, oldest first.
+// The caller must hold p.mu.
+func (p *Pool) expire() {
+	if p.config.TTL <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-p.config.TTL)
+	for len(p.expiry) > 0 && p.expiry[0].added.Before(cutoff) {
+		m := p.expiry[0]
+		p.expiry[0] = nil
+		p.expiry = p.expiry[1:]
+		if p.all[m.hash] == m {
+			p.remove(m.tx)
+			p.dropFeed.Send(DropEvent{Tx: m.tx, Reason: DropExpired})
+		}
+	}
+}
+
+// compactExpiry drops the entries of removed transactions from p.expiry
+// once they make up more than half of it, so that it stays proportional to
+// the pool. The caller must hold p.mu.
+func (p *Pool) compactExpiry() {
+	if len(p.expiry) <= 2*len(p.all)+64 {
+		return
+	}
+	live := make([]*pooledTx, 0, len(p.all))
+	for _, m := range p.expiry {
+		if p.all[m.hash] == m {
+			live = append(live, m)
+		}
+	}
+	p.expiry = live
+}
+
+// Prune drops the used-nonce transactions of every sender, including those
+// whose nonce was used by transactions not taken from the pool, and the
+// expired ones.
+func (p *Pool) Prune() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, list := range p.senders {
+		p.sync(list)
+	}
+	p.expire()
+}
+
+// Flush drops every pooled transaction and returns how many were removed.
+func (p *Pool) Flush() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := len(p.all)
+	for _, m := range p.all {
+		p.remove(m.tx)
+		p.dropFeed.Send(DropEvent{Tx: m.tx, Reason: DropFlushed})
+	}
+	p.expiry = nil
+	return n
+}
+
+// remove deletes tx from the pool. The caller must hold p.mu.
+func (p *Pool) remove(tx *transaction.Tx) {
+	p.forget(tx)
+	if list, ok := p.senders[tx.From]; ok {
+		delete(list.txs, tx.Nonce)
+		p.setHead(list, p.state.GetNonce(tx.From))
+	}
+}
+
+// forget deletes tx from the pool indexes other than its sender's list.
+// The caller must hold p.mu.
+func (p *Pool) forget(tx *transaction.Tx) {
+	h := tx.Hash()
+	if m, ok := p.all[h]; ok {
+		heap.Remove(&p.prices, m.index)
+		delete(p.all, h)
+	}
+	delete(p.traces, h)
+}
+
+// setHead makes the transaction at the account nonce next the head of list,
+// moving the sender in p.heads, and drops the sender once list is empty.
+// The caller must hold p.mu.
+func (p *Pool) setHead(list *txList, next uint64) {
+	list.head = list.txs[next]
+	switch {
+	case list.head == nil && list.index >= 0:
+		heap.Remove(&p.heads, list.index)
+	case list.head != nil && list.index >= 0:
+		heap.Fix(&p.heads, list.index)
+	case list.head != nil:
+		heap.Push(&p.heads, list)
+	}
+	if list.Len() == 0 {
+		delete(p.senders, list.from)
+	}
 }
@@ -0,0 +1,157 @@
+package mempool
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/zionlayer/zionlayer/core/common"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+var testRecipient = common.BytesToAddress([]byte{0xc0}).String()
+
+// testSender returns the address of the i-th test sender.
+func testSender(i int) string {
+	return common.BytesToAddress([]byte{0x10, byte(i >> 16), byte(i >> 8), byte(i)}).String()
+}
+
+// testPool returns a pool over a state funding senders test senders, which
+// takes the From field of transactions as their signer.
+func testPool(senders int, config Config) *Pool {
+	st := state.NewStateDB()
+	for i := 0; i < senders; i++ {
+		st.SetBalance(testSender(i), new(big.Int).Mul(big.NewInt(1e6), big.NewInt(1e18)))
+	}
+	p := NewPool(7, st, config)
+	p.SetVerifier(func(tx *transaction.Tx) (string, error) { return tx.From, nil })
+	return p
+}
+
+func testTx(sender int, nonce uint64, price int64) *transaction.Tx {
+	tx := transaction.NewTransferTx(testSender(sender), testRecipient, big.NewInt(1), nonce, big.NewInt(price))
+	tx.ChainID = 7
+	return tx
+}
+
+func TestEvictExpire(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxSize = 4
+	p := testPool(3, config)
+	ch := make(chan DropEvent, 16)
+	sub := p.SubscribeDropped(ch)
+	defer sub.Unsubscribe()
+
+	for _, tx := range []*transaction.Tx{testTx(0, 0, 5), testTx(0, 1, 2), testTx(1, 0, 2), testTx(1, 1, 9)} {
+		if err := p.Add(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.Add(testTx(2, 0, 2)); err != ErrPoolFull {
+		t.Fatalf("Add at the lowest price to a full pool = %v, want %v", err, ErrPoolFull)
+	}
+	// Of the two cheapest, the one with the highest nonce goes first.
+	if err := p.Add(testTx(2, 0, 3)); err != nil {
+		t.Fatal(err)
+	}
+	if ev := <-ch; ev.Reason != DropEvicted || ev.Tx.From != testSender(0) || ev.Tx.Nonce != 1 {
+		t.Fatalf("dropped %s/%d (%v), want %s/1 evicted", ev.Tx.From, ev.Tx.Nonce, ev.Reason, testSender(0))
+	}
+
+	// Transactions added before the cutoff expire, those added after stay.
+	p.mu.Lock()
+	for _, m := range p.expiry[:4] {
+		m.added = m.added.Add(-config.TTL - time.Second)
+	}
+	p.mu.Unlock()
+	if got := p.Pop(10, 1<<62); len(got) != 1 || got[0].From != testSender(2) {
+		t.Fatalf("Pop = %d transactions, want the one of %s", len(got), testSender(2))
+	}
+	for i := 0; i < 3; i++ {
+		if ev := <-ch; ev.Reason != DropExpired {
+			t.Errorf("drop reason = %v, want expired", ev.Reason)
+		}
+	}
+	if n := p.Size(); n != 0 {
+		t.Errorf("pool holds %d transactions after expiry, want 0", n)
+	}
+}
+
+func TestPopDropsStale(t *testing.T) {
+	p := testPool(1, DefaultConfig())
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		if err := p.Add(testTx(0, nonce, 1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := p.Pop(1, 1<<62); len(got) != 1 {
+		t.Fatalf("Pop = %d transactions, want 1", len(got))
+	}
+	// The block holding the popped transaction and nonce 1, included by
+	// another proposer, is applied.
+	p.state.IncrementNonce(testSender(0))
+	p.state.IncrementNonce(testSender(0))
+	got := p.Pop(10, 1<<62)
+	if len(got) != 1 || got[0].Nonce != 2 {
+		t.Fatalf("Pop = %v, want nonce 2", got)
+	}
+	if n := p.Size(); n != 0 {
+		t.Errorf("pool holds %d transactions, want 0", n)
+	}
+}
+
+// benchPool returns a pool holding n pending transactions spread over
+// senders of DefaultMaxPerSender transactions each, at varied prices.
+func benchPool(b *testing.B, n int) *Pool {
+	b.Helper()
+	config := DefaultConfig()
+	config.MaxSize = n
+	senders := (n + DefaultMaxPerSender - 1) / DefaultMaxPerSender
+	p := testPool(senders+n, config)
+	for i := 0; i < n; i++ {
+		tx := testTx(i/DefaultMaxPerSender, uint64(i%DefaultMaxPerSender), int64(1+i%997))
+		if err := p.Add(tx); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return p
+}
+
+func BenchmarkPop(b *testing.B) {
+	for _, n := range []int{1000, 100000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			p := benchPool(b, n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				txs := p.Pop(500, 1<<62)
+				b.StopTimer()
+				for _, tx := range txs {
+					if err := p.Add(tx); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.StartTimer()
+			}
+		})
+	}
+}
+
+func BenchmarkAddEvict(b *testing.B) {
+	const n = 100000
+	senders := (n + DefaultMaxPerSender - 1) / DefaultMaxPerSender
+	var p *Pool
+	for i := 0; i < b.N; i++ {
+		// Fresh senders outbid the cheapest pooled transaction until every
+		// one has been replaced.
+		if i%n == 0 {
+			b.StopTimer()
+			p = benchPool(b, n)
+			b.StartTimer()
+		}
+		if err := p.Add(testTx(senders+i%n, 0, 1000)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Package merkle builds binary SHA-256 Merkle trees over ordered leaves and
+// the inclusion proofs that tie a leaf to a tree's root.
+//
+// Leaves and interior nodes are hashed with distinct prefixes, so a leaf
+// can never be passed off as an interior node. A node without a sibling
+// at the end of a level is promoted to the next level unchanged rather
+// than paired with itself, so no two leaf lists share a root.
+package merkle
+
+import "crypto/sha256"
+
+const (
+	leafPrefix = 0x00
+	nodePrefix = 0x01
+)
+
+// LeafHash returns the hash of the leaf holding data.
+func LeafHash(data []byte) [32]byte {
+	h := sha256.New()
+	h.Write([]byte{leafPrefix})
+	h.Write(data)
+	var out [32]byte
+	h.Sum(out[:0])
+	return out
+}
+
+func nodeHash(left, right [32]byte) [32]byte {
+	var buf [1 + 64]byte
+	buf[0] = nodePrefix
+	copy(buf[1:], left[:])
+	copy(buf[33:], right[:])
+	return sha256.Sum256(buf[:])
+}
+
+// Root returns the root of the tree over the leaf hashes leaves. The root
+// of an empty tree is the zero hash.
+func Root(leaves [][32]byte) [32]byte {
+	if len(leaves) == 0 {
+		return [32]byte{}
+	}
+	level := append([][32]byte(nil), leaves...)
+	for len(level) > 1 {
+		level = nextLevel(level)
+	}
+	return level[0]
+}
+
+// Prove returns the sibling hashes on the path from leaf i to the root,
+// lowest first. It panics if i is out of range.
+func Prove(leaves [][32]byte, i int) [][32]byte {
+	if i < 0 || i >= len(leaves) {
+		panic("merkle: leaf index out of range")
+	}
+	var proof [][32]byte
+	level := append([][32]byte(nil), leaves...)
+	for len(level) > 1 {
+		if i%2 == 1 {
+			proof = append(proof, level[i-1])
+		} else if i+1 < len(level) {
+			proof = append(proof, level[i+1])
+		}
+		level = nextLevel(level)
+		i /= 2
+	}
+	return proof
+}
+
+// Verify reports whether proof shows that leaf is leaf i of the n-leaf
+// tree with the given root.
+func Verify(root, leaf [32]byte, i, n int, proof [][32]byte) bool {
+	if i < 0 || i >= n {
+		return false
+	}
+	h := leaf
+	for ; n > 1; i, n = i/2, (n+1)/2 {
+		switch {
+		case i%2 == 1:
+			if len(proof) == 0 {
+				return false
+			}
+			h, proof = nodeHash(proof[0], h), proof[1:]
+		case i+1 < n:
+			if len(proof) == 0 {
+				return false
+			}
+			h, proof = nodeHash(h, proof[0]), proof[1:]
+		}
+	}
+	return len(proof) == 0 && h == root
+}
+
+func nextLevel(level [][32]byte) [][32]byte {
+	next := level[:0]
+	for i := 0; i < len(level); i += 2 {
+		if i+1 < len(level) {
+			next = append(next, nodeHash(level[i], level[i+1]))
+		} else {
+			next = append(next, level[i])
+		}
+	}
+	return next
+}
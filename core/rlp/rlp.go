@@ -0,0 +1,284 @@
+// Package rlp implements Recursive Length Prefix encoding, the canonical
+// binary encoding used for hashing, signing and persisting chain objects.
+//
+// Only the canonical form is accepted when decoding: lengths and integers
+// must be minimally encoded, so every value has exactly one encoding.
+package rlp
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sync"
+)
+
+var (
+	ErrUnexpectedEnd = errors.New("rlp: unexpected end of input")
+	ErrNonCanonical  = errors.New("rlp: non-canonical encoding")
+	ErrExpectedList  = errors.New("rlp: expected list")
+	ErrExpectedBytes = errors.New("rlp: expected byte string")
+	ErrUintOverflow  = errors.New("rlp: uint overflow")
+	ErrTrailingData  = errors.New("rlp: trailing data")
+)
+
+// Kind is the type of an encoded item.
+type Kind int
+
+const (
+	Bytes Kind = iota
+	List
+)
+
+// EncodeBytes encodes a byte string.
+func EncodeBytes(b []byte) []byte {
+	return AppendBytes(make([]byte, 0, len(b)+9), b)
+}
+
+// EncodeString encodes a string as a byte string.
+func EncodeString(s string) []byte {
+	return AppendString(make([]byte, 0, len(s)+9), s)
+}
+
+// EncodeUint encodes an unsigned integer as a big-endian byte string with
+// no leading zeros. Zero is the empty string.
+func EncodeUint(u uint64) []byte {
+	return AppendUint(make([]byte, 0, 9), u)
+}
+
+// EncodeBigInt encodes a non-negative big integer. A nil value encodes as
+// zero.
+func EncodeBigInt(i *big.Int) []byte {
+	return AppendBigInt(nil, i)
+}
+
+// EncodeList wraps already-encoded items in a list.
+func EncodeList(items ...[]byte) []byte {
+	size := 0
+	for _, it := range items {
+		size += len(it)
+	}
+	out := appendHeader(make([]byte, 0, size+9), 0xc0, size)
+	for _, it := range items {
+		out = append(out, it...)
+	}
+	return out
+}
+
+// The Append functions append an encoding to dst and return the extended
+// buffer, so that an object can be encoded into a single buffer, taken
+// from GetBuffer when the encoding does not outlive the call.
+
+// AppendBytes appends the encoding of a byte string to dst.
+func AppendBytes(dst, b []byte) []byte {
+	if len(b) == 1 && b[0] < 0x80 {
+		return append(dst, b[0])
+	}
+	return append(appendHeader(dst, 0x80, len(b)), b...)
+}
+
+// AppendString appends the encoding of a string to dst.
+func AppendString(dst []byte, s string) []byte {
+	if len(s) == 1 && s[0] < 0x80 {
+		return append(dst, s[0])
+	}
+	return append(appendHeader(dst, 0x80, len(s)), s...)
+}
+
+// AppendUint appends the encoding of an unsigned integer to dst.
+func AppendUint(dst []byte, u uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], u)
+	i := 0
+	for i < 8 && buf[i] == 0 {
+		i++
+	}
+	return AppendBytes(dst, buf[i:])
+}
+
+// AppendBigInt appends the encoding of a non-negative big integer, zero
+// if nil, to dst.
+func AppendBigInt(dst []byte, i *big.Int) []byte {
+	if i == nil {
+		return AppendBytes(dst, nil)
+	}
+	return AppendBytes(dst, i.Bytes())
+}
+
+// AppendList appends to dst the list of the items that items appends to
+// the buffer it is given.
+func AppendList(dst []byte, items func([]byte) []byte) []byte {
+	start := len(dst)
+	dst = items(dst)
+	size := len(dst) - start
+	var hdr [9]byte
+	n := len(appendHeader(hdr[:0], 0xc0, size))
+	dst = append(dst, hdr[:n]...)
+	copy(dst[start+n:], dst[start:start+size])
+	copy(dst[start:], hdr[:n])
+	return dst
+}
+
+func appendHeader(dst []byte, offset byte, size int) []byte {
+	if size < 56 {
+		return append(dst, offset+byte(size))
+	}
+	sb := uintBytes(uint64(size))
+	dst = append(dst, offset+55+byte(len(sb)))
+	return append(dst, sb...)
+}
+
+// maxPooledBuffer bounds the capacity of the buffers kept by PutBuffer.
+const maxPooledBuffer = 64 << 10
+
+var bufferPool = sync.Pool{New: func() any {
+	b := make([]byte, 0, 1024)
+	return &b
+}}
+
+// GetBuffer returns an empty buffer from a pool. Return it with PutBuffer
+// once the encoding written to it is no longer used.
+func GetBuffer() *[]byte {
+	b := bufferPool.Get().(*[]byte)
+	*b = (*b)[:0]
+	return b
+}
+
+// PutBuffer returns a buffer obtained from GetBuffer to the pool.
+func PutBuffer(b *[]byte) {
+	if cap(*b) <= maxPooledBuffer {
+		bufferPool.Put(b)
+	}
+}
+
+func uintBytes(u uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], u)
+	i := 0
+	for i < 8 && buf[i] == 0 {
+		i++
+	}
+	return buf[i:]
+}
+
+// Split reads the first item of b and returns its kind, its content and the
+// remaining input.
+func Split(b []byte) (kind Kind, content, rest []byte, err error) {
+	if len(b) == 0 {
+		return 0, nil, nil, ErrUnexpectedEnd
+	}
+	prefix := b[0]
+	var offset, size int
+	switch {
+	case prefix < 0x80:
+		return Bytes, b[:1], b[1:], nil
+	case prefix < 0xb8:
+		kind, offset, size = Bytes, 1, int(prefix-0x80)
+		if size == 1 && len(b) > 1 && b[1] < 0x80 {
+			return 0, nil, nil, ErrNonCanonical
+		}
+	case prefix < 0xc0:
+		kind = Bytes
+		offset, size, err = longSize(b, int(prefix-0xb7))
+	case prefix < 0xf8:
+		kind, offset, size = List, 1, int(prefix-0xc0)
+	default:
+		kind = List
+		offset, size, err = longSize(b, int(prefix-0xf7))
+	}
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if len(b) < offset+size {
+		return 0, nil, nil, ErrUnexpectedEnd
+	}
+	return kind, b[offset : offset+size], b[offset+size:], nil
+}
+
+func longSize(b []byte, n int) (offset, size int, err error) {
+	if len(b) < 1+n {
+		return 0, 0, ErrUnexpectedEnd
+	}
+	if b[1] == 0 {
+		return 0, 0, ErrNonCanonical
+	}
+	var u uint64
+	for _, c := range b[1 : 1+n] {
+		u = u<<8 | uint64(c)
+	}
+	if u < 56 {
+		return 0, 0, ErrNonCanonical
+	}
+	if u > uint64(len(b)) {
+		return 0, 0, ErrUnexpectedEnd
+	}
+	return 1 + n, int(u), nil
+}
+
+// SplitList reads a list item from b and returns its content and the
+// remaining input.
+func SplitList(b []byte) (content, rest []byte, err error) {
+	kind, content, rest, err := Split(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	if kind != List {
+		return nil, nil, ErrExpectedList
+	}
+	return content, rest, nil
+}
+
+// SplitBytes reads a byte string from b and returns it and the remaining
+// input.
+func SplitBytes(b []byte) (content, rest []byte, err error) {
+	kind, content, rest, err := Split(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	if kind != Bytes {
+		return nil, nil, ErrExpectedBytes
+	}
+	return content, rest, nil
+}
+
+// SplitUint reads an unsigned integer from b.
+func SplitUint(b []byte) (u uint64, rest []byte, err error) {
+	content, rest, err := SplitBytes(b)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(content) > 8 {
+		return 0, nil, ErrUintOverflow
+	}
+	if len(content) > 0 && content[0] == 0 {
+		return 0, nil, ErrNonCanonical
+	}
+	for _, c := range content {
+		u = u<<8 | uint64(c)
+	}
+	return u, rest, nil
+}
+
+// SplitBigInt reads a non-negative big integer from b.
+func SplitBigInt(b []byte) (i *big.Int, rest []byte, err error) {
+	content, rest, err := SplitBytes(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(content) > 0 && content[0] == 0 {
+		return nil, nil, ErrNonCanonical
+	}
+	return new(big.Int).SetBytes(content), rest, nil
+}
+
+// SplitHash reads a 32-byte string from b.
+func SplitHash(b []byte) (h [32]byte, rest []byte, err error) {
+	content, rest, err := SplitBytes(b)
+	if err != nil {
+		return h, nil, err
+	}
+	if len(content) != 32 {
+		return h, nil, ErrNonCanonical
+	}
+	copy(h[:], content)
+	return h, rest, nil
+}
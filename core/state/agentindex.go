@@ -0,0 +1,150 @@
+package state
+
+import (
+	"sort"
+
+	"github.com/zionlayer/zionlayer/core/common"
+)
+
+// Agent records are indexed by DID, by controller address and by advertised
+// capability name. Each index holds DIDs in sorted order so that listings
+// can resume after a DID instead of an offset, which stays stable while
+// agents register.
+
+// agentIndex maps a key to the sorted DIDs filed under it.
+type agentIndex map[string][]string
+
+func (ix agentIndex) add(key, did string) {
+	ix[key] = insertSorted(ix[key], did)
+}
+
+func (ix agentIndex) remove(key, id string) {
+	ids := ix[key]
+	i := sort.SearchStrings(ids, id)
+	if i == len(ids) || ids[i] != id {
+		return
+	}
+	if len(ids) == 1 {
+		delete(ix, key)
+		return
+	}
+	ix[key] = append(ids[:i:i], ids[i+1:]...)
+}
+
+func (ix agentIndex) copy() agentIndex {
+	cp := make(agentIndex, len(ix))
+	for k, ids := range ix {
+		cp[k] = append([]string(nil), ids...)
+	}
+	return cp
+}
+
+func insertSorted(ids []string, id string) []string {
+	i := sort.SearchStrings(ids, id)
+	if i < len(ids) && ids[i] == id {
+		return ids
+	}
+	ids = append(ids, "")
+	copy(ids[i+1:], ids[i:])
+	ids[i] = id
+	return ids
+}
+
+// indexAgent files rec under its controller and capabilities. Controllers
+// are filed by their checksummed form so that lookups need not match the
+// case the registrant used. The caller holds s.mu.
+func (s *StateDB) indexAgent(rec *AgentRecord) {
+	s.agentIDs = insertSorted(s.agentIDs, rec.DID.ID)
+	controller := rec.DID.Controller
+	if addr, err := common.ParseAddress(controller); err == nil {
+		controller = addr.String()
+	}
+	s.byController.add(controller, rec.DID.ID)
+	for _, c := range rec.DID.Capabilities {
+		s.byCapability.add(c.Name, rec.DID.ID)
+	}
+}
+
+// unindexAgent takes back indexAgent. The caller holds s.mu.
+func (s *StateDB) unindexAgent(rec *AgentRecord) {
+	if i := sort.SearchStrings(s.agentIDs, rec.DID.ID); i < len(s.agentIDs) && s.agentIDs[i] == rec.DID.ID {
+		s.agentIDs = append(s.agentIDs[:i:i], s.agentIDs[i+1:]...)
+	}
+	controller := rec.DID.Controller
+	if addr, err := common.ParseAddress(controller); err == nil {
+		controller = addr.String()
+	}
+	s.byController.remove(controller, rec.DID.ID)
+	for _, c := range rec.DID.Capabilities {
+		s.byCapability.remove(c.Name, rec.DID.ID)
+	}
+}
+
+// page returns up to limit records of ids following the DID after, the DID
+// to resume from, which is empty on the last page, and len(ids). The caller
+// holds s.mu.
+func (s *StateDB) page(ids []string, after string, limit int) ([]*AgentRecord, string, int) {
+	start := 0
+	if after != "" {
+		start = sort.Search(len(ids), func(i int) bool { return ids[i] > after })
+	}
+	end := len(ids)
+	if limit > 0 && start+limit < end {
+		end = start + limit
+	}
+	out := make([]*AgentRecord, 0, end-start)
+	for _, id := range ids[start:end] {
+		out = append(out, s.agents[id].Copy())
+	}
+	next := ""
+	if end < len(ids) {
+		next = ids[end-1]
+	}
+	return out, next, len(ids)
+}
+
+// ListAgents returns registered agents in DID order, up to limit of them
+// after the DID after. next is the DID to pass to fetch the following
+// page, or empty when there are no more; total counts all registered
+// agents. A non-positive limit returns all.
+func (s *StateDB) ListAgents(after string, limit int) (agents []*AgentRecord, next string, total int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.page(s.agentIDs, after, limit)
+}
+
+// AgentsByController pages through the agents controlled by addr.
+func (s *StateDB) AgentsByController(addr, after string, limit int) (agents []*AgentRecord, next string, total int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.page(s.byController[addr], after, limit)
+}
+
+// AgentsByCapability pages through the agents advertising the capability
+// name, in any version.
+func (s *StateDB) AgentsByCapability(name, after string, limit int) (agents []*AgentRecord, next string, total int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.page(s.byCapability[name], after, limit)
+}
+
+// AgentCount returns the number of registered agents.
+func (s *StateDB) AgentCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.agentIDs)
+}
+
+// ActiveAgentCount returns the number of registered agents that are not
+// deactivated.
+func (s *StateDB) ActiveAgentCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n := 0
+	for _, rec := range s.agents {
+		if rec.Active {
+			n++
+		}
+	}
+	return n
+}
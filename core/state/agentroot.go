@@ -0,0 +1,322 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zionlayer/zionlayer/core/merkle"
+	"github.com/zionlayer/zionlayer/core/rlp"
+)
+
+// The agent state is committed to by Header.AgentRoot, the root of a
+// Merkle tree with one leaf per entry of the agent state, ordered by key:
+//
+//	agent/<did>                       the agent record
+//	delegation/<to>/<from>/<cap>      a delegation in effect
+//	mailbox/<did>                     the agent's inbox and outbox indexes
+//	message/<seq>                     a message of the log, seq zero-padded
+//	task/<from>/<to>                  the number of unanswered TASK messages
+//	offer/<did>/<cap>                 a service offer, active or withdrawn
+//
+// A leaf is the RLP list of the key and the JSON encoding of the entry, so
+// a light client holding a block header can check an entry against the
+// header with the proof returned by AgentProof.
+
+var ErrAgentStateNotFound = errors.New("agent state entry not found")
+
+// AgentStateProof proves that an entry of the agent state is leaf Index
+// of the Total leaves under Root.
+type AgentStateProof struct {
+	Root  [32]byte        `json:"root"`
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+	Index int             `json:"index"`
+	Total int             `json:"total"`
+	Proof [][32]byte      `json:"proof"`
+}
+
+// Verify reports whether p proves its entry under root.
+func (p *AgentStateProof) Verify(root [32]byte) bool {
+	return merkle.Verify(root, agentLeaf(p.Key, p.Value), p.Index, p.Total, p.Proof)
+}
+
+// AgentKey returns the agent state key of the record of did.
+func AgentKey(did string) string { return "agent/" + did }
+
+// MailboxKey returns the agent state key of the mailbox of did.
+func MailboxKey(did string) string { return "mailbox/" + did }
+
+// DelegationKey returns the agent state key of the delegation of
+// capability from one agent to another.
+func DelegationKey(from, to, capability string) string {
+	return "delegation/" + to + "/" + from + "/" + capability
+}
+
+// MessageKey returns the agent state key of the message at position seq of
+// the message log.
+func MessageKey(seq uint64) string { return fmt.Sprintf("message/%020d", seq) }
+
+// TaskKey returns the agent state key of the count of unanswered tasks
+// assigned by one agent to another.
+func TaskKey(from, to string) string { return "task/" + from + "/" + to }
+
+// OfferKey returns the agent state key of the offer of an agent for a
+// capability.
+func OfferKey(did, capability string) string { return "offer/" + did + "/" + capability }
+
+// ReceiptKey returns the agent state key of the inference receipt included
+// by the transaction hash.
+func ReceiptKey(hash []byte) string { return "receipt/" + receiptKey(hash) }
+
+// DisputeKey returns the agent state key of the dispute of the inference
+// receipt included by the transaction hash.
+func DisputeKey(hash []byte) string { return "dispute/" + receiptKey(hash) }
+
+// DataAttestationKey returns the agent state key of the attestations of
+// the off-chain payload cid on network.
+func DataAttestationKey(network, cid string) string { return "da/" + dataKey(network, cid) }
+
+// BatchKey returns the agent state key of the receipt batch included by
+// the transaction hash.
+func BatchKey(hash []byte) string { return "batch/" + receiptKey(hash) }
+
+// RevealKey returns the agent state key of the data revealed for the
+// inference receipt included by the transaction hash.
+func RevealKey(hash []byte) string { return "reveal/" + receiptKey(hash) }
+
+// ValidatorKey returns the agent state key of the validator addr.
+func ValidatorKey(addr string) string { return "validator/" + addr }
+
+// StakeKey returns the agent state key of the stake delegator has
+// delegated to validator.
+func StakeKey(delegator, validator string) string { return "stake/" + stakeKey(delegator, validator) }
+
+// UnbondingKey returns the agent state key of the stake at position i of
+// the unbonding queue.
+func UnbondingKey(i int) string { return fmt.Sprintf("unbonding/%020d", i) }
+
+// ParamsKey is the agent state key of the protocol parameters.
+const ParamsKey = "params"
+
+// ParamForkKey returns the agent state key of the i-th scheduled parameter
+// fork.
+func ParamForkKey(i int) string { return fmt.Sprintf("paramfork/%020d", i) }
+
+// ProposalKey returns the agent state key of governance proposal id.
+func ProposalKey(id uint64) string { return fmt.Sprintf("proposal/%020d", id) }
+
+// IBCClientKey returns the agent state key of the IBC client id.
+func IBCClientKey(id string) string { return "ibc/client/" + id }
+
+// IBCConnectionKey returns the agent state key of the IBC connection end
+// id.
+func IBCConnectionKey(id string) string { return "ibc/connection/" + id }
+
+// IBCChannelKey returns the agent state key of the IBC channel end id of
+// port.
+func IBCChannelKey(port, id string) string { return "ibc/channel/" + ibcChannelKey(port, id) }
+
+// IBCCommitmentKey returns the agent state key of the commitment to the
+// packet seq sent over channel of port, held until it is acknowledged.
+func IBCCommitmentKey(port, channel string, seq uint64) string {
+	return "ibc/commitment/" + ibcPacketKey(port, channel, seq)
+}
+
+// IBCAckKey returns the agent state key of the commitment to the
+// acknowledgement of the packet seq received over channel of port.
+func IBCAckKey(port, channel string, seq uint64) string {
+	return "ibc/ack/" + ibcPacketKey(port, channel, seq)
+}
+
+// BridgeWithdrawalKey returns the agent state key of the bridge withdrawal
+// nonce.
+func BridgeWithdrawalKey(nonce uint64) string { return fmt.Sprintf("bridge/withdrawal/%020d", nonce) }
+
+// BridgeDepositKey returns the agent state key of the executed bridge
+// deposit event id.
+func BridgeDepositKey(id string) string { return "bridge/deposit/" + id }
+
+// TokenKey returns the agent state key of the ZRC-20 token symbol.
+func TokenKey(symbol string) string { return "token/" + symbol }
+
+// NFTCollectionKey returns the agent state key of the ZRC-721 collection
+// symbol.
+func NFTCollectionKey(symbol string) string { return "nftcollection/" + symbol }
+
+// NFTStateKey returns the agent state key of the ZRC-721 token id of
+// collection.
+func NFTStateKey(collection, id string) string { return "nft/" + NFTKey(collection, id) }
+
+// ProviderKey returns the agent state key of the compute provider addr.
+func ProviderKey(addr string) string { return "provider/" + addr }
+
+// EvaluatorKey returns the agent state key of the quality evaluator addr.
+func EvaluatorKey(addr string) string { return "evaluator/" + addr }
+
+// EvaluationKey returns the agent state key of the quality evaluation of
+// the inference receipt included by the transaction hash.
+func EvaluationKey(hash []byte) string { return "evaluation/" + receiptKey(hash) }
+
+// ModelKey returns the agent state key of the registered model hash.
+func ModelKey(hash []byte) string { return "model/" + receiptKey(hash) }
+
+// EnclaveKey returns the agent state key of an approved enclave image.
+func EnclaveKey(platform string, measurement []byte) string {
+	return "enclave/" + enclaveKey(platform, measurement)
+}
+
+// AgentRoot returns the root committing to the agent state.
+func (s *StateDB) AgentRoot() [32]byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, leaves := s.agentLeaves()
+	return merkle.Root(leaves)
+}
+
+// AgentProof returns the entry of the agent state under key and the proof
+// of its inclusion under the current AgentRoot.
+func (s *StateDB) AgentProof(key string) (*AgentStateProof, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entries, leaves := s.agentLeaves()
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].key >= key })
+	if i == len(entries) || entries[i].key != key {
+		return nil, ErrAgentStateNotFound
+	}
+	return &AgentStateProof{
+		Root:  merkle.Root(leaves),
+		Key:   key,
+		Value: entries[i].value,
+		Index: i,
+		Total: len(leaves),
+		Proof: merkle.Prove(leaves, i),
+	}, nil
+}
+
+type agentEntry struct {
+	key   string
+	value []byte
+}
+
+// agentLeaves returns the agent state entries sorted by key and their leaf
+// hashes. The caller holds s.mu.
+func (s *StateDB) agentLeaves() ([]agentEntry, [][32]byte) {
+	var entries []agentEntry
+	add := func(key string, v interface{}) {
+		b, err := json.Marshal(v)
+		if err != nil {
+			panic(fmt.Sprintf("state: encoding %s: %v", key, err))
+		}
+		entries = append(entries, agentEntry{key, b})
+	}
+	for id, rec := range s.agents {
+		add(AgentKey(id), rec)
+	}
+	for to, in := range s.delegations {
+		for _, d := range in {
+			add(DelegationKey(d.From, to, d.Capability.Name), d)
+		}
+	}
+	for id, mb := range s.mailboxes {
+		add(MailboxKey(id), mb)
+	}
+	for key, n := range s.openTasks {
+		from, to, _ := strings.Cut(key, "\x00")
+		add(TaskKey(from, to), n)
+	}
+	for _, o := range s.offers {
+		add(OfferKey(o.AgentID, o.Capability.Name), o)
+	}
+	for key, r := range s.receipts {
+		add("receipt/"+key, r)
+	}
+	for key, d := range s.disputes {
+		add("dispute/"+key, d)
+	}
+	for key, recs := range s.dataAttestations {
+		add("da/"+key, recs)
+	}
+	for key, b := range s.batches {
+		add("batch/"+key, b)
+	}
+	for key, v := range s.reveals {
+		add("reveal/"+key, v)
+	}
+	for addr, p := range s.providers {
+		add(ProviderKey(addr), p)
+	}
+	for addr, ev := range s.evaluators {
+		add(EvaluatorKey(addr), ev)
+	}
+	for key, e := range s.evaluations {
+		add("evaluation/"+key, e)
+	}
+	for addr, v := range s.stakingValidators {
+		add(ValidatorKey(addr), v)
+	}
+	for key, d := range s.stakes {
+		add("stake/"+key, d)
+	}
+	for i, u := range s.unbondings {
+		add(UnbondingKey(i), u)
+	}
+	for id, prop := range s.proposals {
+		add(ProposalKey(id), prop)
+	}
+	add(ParamsKey, s.params())
+	for i, v := range s.paramForks {
+		add(ParamForkKey(i), v)
+	}
+	for id, c := range s.ibcClients {
+		add(IBCClientKey(id), c)
+	}
+	for id, c := range s.ibcConnections {
+		add(IBCConnectionKey(id), c)
+	}
+	for key, ch := range s.ibcChannels {
+		add("ibc/channel/"+key, ch)
+	}
+	for key, p := range s.ibcCommitments {
+		add("ibc/commitment/"+key, IBCPacketCommitment(*p))
+	}
+	for key, a := range s.ibcAcks {
+		add("ibc/ack/"+key, IBCAckCommitment(a.Acknowledgement))
+	}
+	for _, w := range s.bridgeWithdrawals {
+		add(BridgeWithdrawalKey(w.Nonce), w)
+	}
+	for id, hash := range s.bridgeProcessed {
+		add(BridgeDepositKey(id), s.bridgeDeposits[hash])
+	}
+	for symbol, t := range s.tokens {
+		add(TokenKey(symbol), t)
+	}
+	for symbol, c := range s.nftCollections {
+		add(NFTCollectionKey(symbol), c)
+	}
+	for key, n := range s.nfts {
+		add("nft/"+key, n)
+	}
+	for key, m := range s.models {
+		add("model/"+key, m)
+	}
+	for key, img := range s.enclaves {
+		add("enclave/"+key, img)
+	}
+	for seq, m := range s.messages {
+		add(MessageKey(uint64(seq)), m)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	leaves := make([][32]byte, len(entries))
+	for i, e := range entries {
+		leaves[i] = agentLeaf(e.key, e.value)
+	}
+	return entries, leaves
+}
+
+func agentLeaf(key string, value []byte) [32]byte {
+	return merkle.LeafHash(rlp.EncodeList(rlp.EncodeString(key), rlp.EncodeBytes(value)))
+}
@@ -0,0 +1,101 @@
+package state
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"github.com/zionlayer/zionlayer/core/merkle"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// A compute provider can submit the receipts of many inferences for one
+// agent and model as a batch: the Merkle root of the receipts, signed once
+// by the provider. Only the batch is kept in state; an individual receipt
+// is shown to be part of it on demand by its inclusion proof against the
+// root. A batch credits its agent one verified inference per receipt.
+
+var (
+	ErrBatchNotFound = errors.New("receipt batch not found")
+	ErrNotInBatch    = errors.New("receipt is not part of the batch")
+)
+
+// BatchRecord is a receipt batch included on chain.
+type BatchRecord struct {
+	Hash      []byte                     `json:"hash"` // of the including transaction
+	Batch     transaction.InferenceBatch `json:"batch"`
+	Submitter string                     `json:"submitter"`
+	Height    uint64                     `json:"height"`
+}
+
+// VerifyBatch checks that b is fit for inclusion in a block of blockTime,
+// as VerifyReceipt checks a receipt. A zero blockTime skips the timestamp
+// check.
+func (s *StateDB) VerifyBatch(b transaction.InferenceBatch, blockTime time.Time) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if err := s.checkInference(b.AgentID, b.ModelHash, b.Timestamp, blockTime); err != nil {
+		return err
+	}
+	return s.checkProver(b.Prover, b.ModelHash, b.SigningHash(), b.ProverSig)
+}
+
+// RecordBatch records the receipt batch b included at height by the
+// transaction hash and credits its agent with its receipts.
+func (s *StateDB) RecordBatch(hash [32]byte, b transaction.InferenceBatch, submitter string, height uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.batches == nil {
+		s.batches = make(map[string]*BatchRecord)
+	}
+	s.batches[receiptKey(hash[:])] = &BatchRecord{
+		Hash:      append([]byte(nil), hash[:]...),
+		Batch:     b,
+		Submitter: submitter,
+		Height:    height,
+	}
+	s.updateReputation(b.AgentID, height, func(r *Reputation) { r.VerifiedInferences += b.Count })
+}
+
+// GetBatch returns the receipt batch included by the transaction hash.
+func (s *StateDB) GetBatch(hash []byte) (*BatchRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rec, ok := s.batches[receiptKey(hash)]
+	if !ok {
+		return nil, ErrBatchNotFound
+	}
+	return rec.Copy(), nil
+}
+
+// VerifyBatchedReceipt checks that r is receipt index of the batch included
+// by the transaction hash, by its inclusion proof.
+func (s *StateDB) VerifyBatchedReceipt(hash []byte, r transaction.InferenceReceipt, index uint64, proof [][32]byte) error {
+	rec, err := s.GetBatch(hash)
+	if err != nil {
+		return err
+	}
+	b := rec.Batch
+	if r.AgentID != b.AgentID || !bytes.Equal(r.ModelHash, b.ModelHash) || index >= b.Count {
+		return ErrNotInBatch
+	}
+	var root [32]byte
+	copy(root[:], b.Root)
+	if !merkle.Verify(root, transaction.BatchLeaf(&r), int(index), int(b.Count), proof) {
+		return ErrNotInBatch
+	}
+	return nil
+}
+
+// copyBatches returns a copy of s.batches. Batches are never modified, so
+// they are shared. The caller holds s.mu.
+func (s *StateDB) copyBatches() map[string]*BatchRecord {
+	if len(s.batches) == 0 {
+		return nil
+	}
+	cp := make(map[string]*BatchRecord, len(s.batches))
+	for k, b := range s.batches {
+		cp[k] = b
+	}
+	return cp
+}
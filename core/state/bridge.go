@@ -0,0 +1,338 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// The bridge connects ZionLayer to an external chain, Ethereum first,
+// whose bridge contract locks tokens deposited to ZionLayer and mints
+// wrapped ZIO. ZionLayer does not follow the external chain itself: a
+// committee of attesters set by governance does, and a deposit event
+// takes effect once Threshold members of the current committee attest to
+// it with the same contents. A deposit of a token of the external chain
+// credits the recipient with as much of its wrapped token, named by the
+// address of its contract; a deposit of wrapped ZIO, burned on the
+// external chain, releases ZIO from the bridge escrow. Each deposit event
+// takes effect once.
+//
+// A withdrawal locks ZIO in the bridge escrow, or burns wrapped tokens,
+// and records the withdrawal under the agent state key
+// bridge/withdrawal/<nonce>. Its proof against the AgentRoot of a header
+// signed by the validators, served by zion_getBridgeWithdrawalProof, lets
+// the external chain mint wrapped ZIO or release the locked tokens.
+
+var (
+	ErrBridgeDisabled           = errors.New("bridge is not configured")
+	ErrNotBridgeAttester        = errors.New("sender is not a member of the bridge committee")
+	ErrBridgeAttested           = errors.New("attester has already attested the deposit")
+	ErrBridgeDepositProcessed   = errors.New("bridge deposit already processed")
+	ErrBridgeDepositNotFound    = errors.New("bridge deposit not found")
+	ErrBridgeWithdrawalNotFound = errors.New("bridge withdrawal not found")
+	ErrBridgeEscrow             = errors.New("bridge escrow holds less ZIO than the deposit releases")
+)
+
+// Bridge deposit statuses.
+const (
+	BridgeDepositPending  = "pending" // short of the threshold
+	BridgeDepositExecuted = "executed"
+)
+
+// BridgeParams configures the bridge. An empty committee disables it.
+type BridgeParams struct {
+	Chain      string   `json:"chain"`      // name of the external chain
+	Committee  []string `json:"committee"`  // addresses of the attesters
+	Threshold  int      `json:"threshold"`  // attestations executing a deposit
+	WrappedZIO string   `json:"wrappedZio"` // contract of wrapped ZIO on the external chain
+}
+
+// DefaultBridgeParams returns the bridge parameters of a new state.
+func DefaultBridgeParams() BridgeParams {
+	return BridgeParams{Chain: "ethereum"}
+}
+
+// Validate checks that p is consistent.
+func (p BridgeParams) Validate() error {
+	seen := make(map[string]bool, len(p.Committee))
+	for _, m := range p.Committee {
+		if seen[strings.ToLower(m)] {
+			return fmt.Errorf("%w: duplicate committee member %s", ErrInvalidParams, m)
+		}
+		seen[strings.ToLower(m)] = true
+	}
+	if len(p.Committee) > 0 && (p.Threshold*2 <= len(p.Committee) || p.Threshold > len(p.Committee)) {
+		return fmt.Errorf("%w: threshold must be a majority of the committee", ErrInvalidParams)
+	}
+	return nil
+}
+
+// BridgeDepositRecord collects the attestations of a deposit event. Hash
+// commits to its contents, so that attestations of differing contents
+// are counted apart.
+type BridgeDepositRecord struct {
+	Hash       string                    `json:"hash"`
+	Deposit    transaction.BridgeDeposit `json:"deposit"`
+	Attesters  []string                  `json:"attesters"`
+	Status     string                    `json:"status"`
+	ExecutedAt uint64                    `json:"executedAt,omitempty"`
+}
+
+// BridgeWithdrawal is a withdrawal to the external chain. Token is the
+// contract the external chain pays out of: wrapped ZIO, minted there, if
+// Native, or a token it released to ZionLayer, unlocked there.
+type BridgeWithdrawal struct {
+	Nonce     uint64   `json:"nonce"`
+	Sender    string   `json:"sender"`
+	Token     string   `json:"token"`
+	Native    bool     `json:"native,omitempty"`
+	Amount    *big.Int `json:"amount"`
+	Recipient string   `json:"recipient"`
+	Height    uint64   `json:"height"`
+}
+
+// SetBridgeParams replaces the bridge parameters.
+func (s *StateDB) SetBridgeParams(p BridgeParams) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bridgeParams = p
+}
+
+// GetBridgeParams returns the bridge parameters.
+func (s *StateDB) GetBridgeParams() BridgeParams {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.bridgeParams
+}
+
+// bridgeDepositHash returns the hex hash of the contents of d.
+func bridgeDepositHash(d transaction.BridgeDeposit) string {
+	b, _ := json.Marshal(d)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// AttestBridgeDeposit records the attestation of d by attester at height
+// and executes the deposit once the threshold of the current committee
+// has attested to it.
+func (s *StateDB) AttestBridgeDeposit(d transaction.BridgeDeposit, attester string, height uint64) (*BridgeDepositRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.bridgeParams
+	if len(p.Committee) == 0 {
+		return nil, ErrBridgeDisabled
+	}
+	if !containsAddress(p.Committee, attester) {
+		return nil, ErrNotBridgeAttester
+	}
+	if _, done := s.bridgeProcessed[d.ID()]; done {
+		return nil, ErrBridgeDepositProcessed
+	}
+	d.TxHash, d.Token = strings.ToLower(d.TxHash), strings.ToLower(d.Token)
+	hash := bridgeDepositHash(d)
+	rec := &BridgeDepositRecord{Hash: hash, Deposit: d, Status: BridgeDepositPending}
+	if cur, ok := s.bridgeDeposits[hash]; ok {
+		if containsAddress(cur.Attesters, attester) {
+			return nil, ErrBridgeAttested
+		}
+		rec = cur
+	}
+	nr := *rec
+	nr.Attesters = append(rec.Attesters[:len(rec.Attesters):len(rec.Attesters)], attester)
+	attested := 0
+	for _, a := range nr.Attesters {
+		if containsAddress(p.Committee, a) {
+			attested++
+		}
+	}
+	if attested >= p.Threshold {
+		if err := s.executeBridgeDeposit(d); err != nil {
+			return nil, err
+		}
+		nr.Status, nr.ExecutedAt = BridgeDepositExecuted, height
+		if s.bridgeProcessed == nil {
+			s.bridgeProcessed = make(map[string]string)
+		}
+		s.bridgeProcessed[d.ID()] = hash
+	}
+	if s.bridgeDeposits == nil {
+		s.bridgeDeposits = make(map[string]*BridgeDepositRecord)
+	}
+	s.bridgeDeposits[hash] = &nr
+	return nr.Copy(), nil
+}
+
+// executeBridgeDeposit credits the recipient of d. The caller holds s.mu.
+func (s *StateDB) executeBridgeDeposit(d transaction.BridgeDeposit) error {
+	if s.bridgeParams.WrappedZIO != "" && strings.EqualFold(d.Token, s.bridgeParams.WrappedZIO) {
+		if s.bridgeLocked.Cmp(d.Amount) < 0 {
+			return ErrBridgeEscrow
+		}
+		s.bridgeLocked = new(big.Int).Sub(s.bridgeLocked, d.Amount)
+		s.release(d.Recipient, d.Amount)
+		return nil
+	}
+	if s.bridgeWrapped == nil {
+		s.bridgeWrapped = make(map[string]*big.Int)
+	}
+	key := bridgeWrappedKey(d.Token, d.Recipient)
+	bal := new(big.Int).Set(d.Amount)
+	if cur, ok := s.bridgeWrapped[key]; ok {
+		bal.Add(bal, cur)
+	}
+	s.bridgeWrapped[key] = bal
+	return nil
+}
+
+// WithdrawBridge locks or burns the tokens of w from sender at height and
+// records the withdrawal for the external chain.
+func (s *StateDB) WithdrawBridge(w transaction.BridgeWithdraw, sender string, height uint64) (*BridgeWithdrawal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.bridgeParams
+	if len(p.Committee) == 0 {
+		return nil, ErrBridgeDisabled
+	}
+	rec := &BridgeWithdrawal{
+		Nonce:     uint64(len(s.bridgeWithdrawals)),
+		Sender:    sender,
+		Amount:    new(big.Int).Set(w.Amount),
+		Recipient: w.Recipient,
+		Height:    height,
+	}
+	if w.Token == transaction.BridgeNativeToken {
+		if p.WrappedZIO == "" {
+			return nil, fmt.Errorf("%w: no wrapped ZIO contract", ErrBridgeDisabled)
+		}
+		if err := s.escrow(sender, w.Amount); err != nil {
+			return nil, err
+		}
+		s.bridgeLocked = new(big.Int).Add(s.bridgeLocked, w.Amount)
+		rec.Token, rec.Native = strings.ToLower(p.WrappedZIO), true
+	} else {
+		rec.Token = strings.ToLower(w.Token)
+		key := bridgeWrappedKey(rec.Token, sender)
+		cur, ok := s.bridgeWrapped[key]
+		if !ok || cur.Cmp(w.Amount) < 0 {
+			return nil, ErrInsufficientBalance
+		}
+		if cur.Cmp(w.Amount) == 0 {
+			delete(s.bridgeWrapped, key)
+		} else {
+			s.bridgeWrapped[key] = new(big.Int).Sub(cur, w.Amount)
+		}
+	}
+	s.bridgeWithdrawals = append(s.bridgeWithdrawals[:len(s.bridgeWithdrawals):len(s.bridgeWithdrawals)], rec)
+	return rec.Copy(), nil
+}
+
+func bridgeWrappedKey(token, addr string) string { return token + "\x00" + addr }
+
+// containsAddress reports whether addrs holds addr.
+func containsAddress(addrs []string, addr string) bool {
+	for _, a := range addrs {
+		if sameAddress(a, addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetBridgeDeposit returns the deposit record of hash, or the executed
+// record of the deposit event id.
+func (s *StateDB) GetBridgeDeposit(key string) (*BridgeDepositRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if hash, ok := s.bridgeProcessed[strings.ToLower(key)]; ok {
+		key = hash
+	}
+	rec, ok := s.bridgeDeposits[strings.TrimPrefix(strings.ToLower(key), "0x")]
+	if !ok {
+		return nil, ErrBridgeDepositNotFound
+	}
+	return rec.Copy(), nil
+}
+
+// GetBridgeWithdrawal returns the withdrawal of nonce.
+func (s *StateDB) GetBridgeWithdrawal(nonce uint64) (*BridgeWithdrawal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if nonce >= uint64(len(s.bridgeWithdrawals)) {
+		return nil, ErrBridgeWithdrawalNotFound
+	}
+	return s.bridgeWithdrawals[nonce].Copy(), nil
+}
+
+// BridgeWithdrawals returns the withdrawals in nonce order.
+func (s *StateDB) BridgeWithdrawals() []*BridgeWithdrawal {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return copyRecords(s.bridgeWithdrawals, (*BridgeWithdrawal).Copy)
+}
+
+// BridgeLocked returns the ZIO held in the bridge escrow.
+func (s *StateDB) BridgeLocked() *big.Int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return new(big.Int).Set(s.bridgeLocked)
+}
+
+// BridgeBalances returns the wrapped token balances of addr by token.
+func (s *StateDB) BridgeBalances(addr string) map[string]*big.Int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]*big.Int)
+	for key, bal := range s.bridgeWrapped {
+		if token, owner, _ := strings.Cut(key, "\x00"); owner == addr {
+			out[token] = new(big.Int).Set(bal)
+		}
+	}
+	return out
+}
+
+// BridgeWrappedSupply returns the supply of each wrapped token.
+func (s *StateDB) BridgeWrappedSupply() map[string]*big.Int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]*big.Int)
+	for key, bal := range s.bridgeWrapped {
+		token, _, _ := strings.Cut(key, "\x00")
+		if cur, ok := out[token]; ok {
+			cur.Add(cur, bal)
+		} else {
+			out[token] = new(big.Int).Set(bal)
+		}
+	}
+	return out
+}
+
+// copyBridge returns copies of the bridge maps. Their records and
+// balances are replaced rather than modified, so they are shared. The
+// caller holds s.mu.
+func (s *StateDB) copyBridge() (deposits map[string]*BridgeDepositRecord, processed map[string]string, wrapped map[string]*big.Int) {
+	if len(s.bridgeDeposits) > 0 {
+		deposits = make(map[string]*BridgeDepositRecord, len(s.bridgeDeposits))
+		for k, v := range s.bridgeDeposits {
+			deposits[k] = v
+		}
+	}
+	if len(s.bridgeProcessed) > 0 {
+		processed = make(map[string]string, len(s.bridgeProcessed))
+		for k, v := range s.bridgeProcessed {
+			processed[k] = v
+		}
+	}
+	if len(s.bridgeWrapped) > 0 {
+		wrapped = make(map[string]*big.Int, len(s.bridgeWrapped))
+		for k, v := range s.bridgeWrapped {
+			wrapped[k] = v
+		}
+	}
+	return
+}
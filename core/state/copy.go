@@ -0,0 +1,349 @@
+package state
+
+import (
+	"math/big"
+
+	"github.com/zionlayer/zionlayer/core/attestation"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// The getters of the state return deep copies of its records, made by the
+// Copy methods below, so that callers can neither change the state nor
+// race with the writers that update records in place under s.mu.
+
+// Copy returns a deep copy of the agent record.
+func (r *AgentRecord) Copy() *AgentRecord {
+	cp := *r
+	cp.DID = copyDID(r.DID)
+	cp.Lifecycle = append([]LifecycleEvent(nil), r.Lifecycle...)
+	return &cp
+}
+
+// Copy returns a deep copy of the batch record.
+func (r *BatchRecord) Copy() *BatchRecord {
+	cp := *r
+	cp.Hash = cloneBytes(r.Hash)
+	cp.Batch.ModelHash = cloneBytes(r.Batch.ModelHash)
+	cp.Batch.Root = cloneBytes(r.Batch.Root)
+	cp.Batch.ProverSig = cloneBytes(r.Batch.ProverSig)
+	return &cp
+}
+
+// Copy returns a deep copy of the deposit record.
+func (r *BridgeDepositRecord) Copy() *BridgeDepositRecord {
+	cp := *r
+	cp.Deposit.Amount = cloneBig(r.Deposit.Amount)
+	cp.Attesters = append([]string(nil), r.Attesters...)
+	return &cp
+}
+
+// Copy returns a deep copy of the withdrawal.
+func (w *BridgeWithdrawal) Copy() *BridgeWithdrawal {
+	cp := *w
+	cp.Amount = cloneBig(w.Amount)
+	return &cp
+}
+
+// Copy returns a deep copy of the attestation.
+func (a *DataAttestationRecord) Copy() *DataAttestationRecord {
+	cp := *a
+	cp.Ref.Hash = cloneBytes(a.Ref.Hash)
+	return &cp
+}
+
+// Copy returns a deep copy of the dispute.
+func (d *Dispute) Copy() *Dispute {
+	cp := *d
+	cp.ReceiptHash = cloneBytes(d.ReceiptHash)
+	cp.Bond = cloneBig(d.Bond)
+	cp.Verifiers = append([]string(nil), d.Verifiers...)
+	cp.Votes = make([]VerifierVote, len(d.Votes))
+	for i, v := range d.Votes {
+		v.OutputHash = cloneBytes(v.OutputHash)
+		cp.Votes[i] = v
+	}
+	return &cp
+}
+
+// Copy returns a deep copy of the enclave image.
+func (img *EnclaveImage) Copy() *EnclaveImage {
+	cp := *img
+	cp.Measurement = cloneBytes(img.Measurement)
+	return &cp
+}
+
+// Copy returns a deep copy of the proposal.
+func (p *Proposal) Copy() *Proposal {
+	cp := *p
+	cp.Content.Changes = copyParamChanges(p.Content.Changes)
+	if p.Content.Spend != nil {
+		spend := *p.Content.Spend
+		spend.Amount = cloneBig(spend.Amount)
+		cp.Content.Spend = &spend
+	}
+	if p.Content.Upgrade != nil {
+		plan := *p.Content.Upgrade
+		cp.Content.Upgrade = &plan
+	}
+	cp.TotalDeposit = cloneBig(p.TotalDeposit)
+	cp.Deposits = make([]ProposalDepositRecord, len(p.Deposits))
+	for i, d := range p.Deposits {
+		d.Amount = cloneBig(d.Amount)
+		cp.Deposits[i] = d
+	}
+	cp.Votes = append([]ProposalVoteRecord(nil), p.Votes...)
+	if p.Tally != nil {
+		cp.Tally = &TallyResult{
+			Yes:         cloneBig(p.Tally.Yes),
+			No:          cloneBig(p.Tally.No),
+			Abstain:     cloneBig(p.Tally.Abstain),
+			NoWithVeto:  cloneBig(p.Tally.NoWithVeto),
+			TotalBonded: cloneBig(p.Tally.TotalBonded),
+		}
+	}
+	return &cp
+}
+
+// Copy returns a deep copy of the client.
+func (c *IBCClient) Copy() *IBCClient {
+	cp := *c
+	cp.Validators = make([]transaction.IBCValidator, len(c.Validators))
+	for i, v := range c.Validators {
+		v.PublicKey = cloneBytes(v.PublicKey)
+		cp.Validators[i] = v
+	}
+	cp.ConsensusStates = append([]IBCConsensusState(nil), c.ConsensusStates...)
+	return &cp
+}
+
+// Copy returns a copy of the connection end.
+func (c *IBCConnection) Copy() *IBCConnection {
+	cp := *c
+	return &cp
+}
+
+// Copy returns a deep copy of the channel end.
+func (ch *IBCChannel) Copy() *IBCChannel {
+	cp := *ch
+	cp.Escrowed = cloneBig(ch.Escrowed)
+	return &cp
+}
+
+// Copy returns a deep copy of the acknowledged packet.
+func (a *IBCPacketAck) Copy() *IBCPacketAck {
+	cp := *a
+	cp.Packet = *copyPacket(&a.Packet)
+	cp.Acknowledgement.Result = cloneBytes(a.Acknowledgement.Result)
+	return &cp
+}
+
+// Copy returns a deep copy of the offer.
+func (o *Offer) Copy() *Offer {
+	cp := *o
+	cp.PricePerCall = cloneBig(o.PricePerCall)
+	cp.Models = cloneByteSlices(o.Models)
+	cp.Metadata = cloneMetadata(o.Metadata)
+	return &cp
+}
+
+// Copy returns a deep copy of the model.
+func (m *Model) Copy() *Model {
+	cp := *m
+	cp.Hash = cloneBytes(m.Hash)
+	cp.Metadata = cloneMetadata(m.Metadata)
+	return &cp
+}
+
+// Copy returns a copy of the collection.
+func (c *NFTCollection) Copy() *NFTCollection {
+	cp := *c
+	return &cp
+}
+
+// Copy returns a deep copy of the token.
+func (n *NFT) Copy() *NFT {
+	cp := *n
+	cp.ContentHash = cloneBytes(n.ContentHash)
+	return &cp
+}
+
+// Copy returns a deep copy of the evaluator.
+func (ev *Evaluator) Copy() *Evaluator {
+	cp := *ev
+	cp.Stake = cloneBig(ev.Stake)
+	cp.Slashed = cloneBig(ev.Slashed)
+	return &cp
+}
+
+// Copy returns a deep copy of the evaluation.
+func (e *Evaluation) Copy() *Evaluation {
+	cp := *e
+	cp.ReceiptHash = cloneBytes(e.ReceiptHash)
+	cp.Scores = make([]EvaluatorScore, len(e.Scores))
+	for i, sc := range e.Scores {
+		sc.Stake = cloneBig(sc.Stake)
+		cp.Scores[i] = sc
+	}
+	return &cp
+}
+
+// Copy returns a deep copy of the fork.
+func (v *ParamVersion) Copy() *ParamVersion {
+	cp := *v
+	cp.Changes = copyParamChanges(v.Changes)
+	return &cp
+}
+
+// Copy returns a deep copy of the provider.
+func (p *Provider) Copy() *Provider {
+	cp := *p
+	cp.PubKey = cloneBytes(p.PubKey)
+	cp.Models = cloneByteSlices(p.Models)
+	cp.Bond = cloneBig(p.Bond)
+	return &cp
+}
+
+// Copy returns a deep copy of the receipt record.
+func (r *ReceiptRecord) Copy() *ReceiptRecord {
+	cp := *r
+	cp.Hash = cloneBytes(r.Hash)
+	cp.Bond = cloneBig(r.Bond)
+	rc := &cp.Receipt
+	rc.ModelHash = cloneBytes(rc.ModelHash)
+	rc.InputHash = cloneBytes(rc.InputHash)
+	rc.OutputHash = cloneBytes(rc.OutputHash)
+	rc.ProverSig = cloneBytes(rc.ProverSig)
+	rc.ZKProof = cloneBytes(rc.ZKProof)
+	rc.Commitment = cloneBytes(rc.Commitment)
+	if rc.Attestation != nil {
+		rc.Attestation = copyAttestation(rc.Attestation)
+	}
+	if rc.DataRefs != nil {
+		refs := make([]transaction.DataRef, len(rc.DataRefs))
+		for i, ref := range rc.DataRefs {
+			ref.Hash = cloneBytes(ref.Hash)
+			refs[i] = ref
+		}
+		rc.DataRefs = refs
+	}
+	return &cp
+}
+
+// Copy returns a deep copy of the validator.
+func (v *StakingValidator) Copy() *StakingValidator {
+	cp := *v
+	cp.Tokens = cloneBig(v.Tokens)
+	return &cp
+}
+
+// Copy returns a deep copy of the delegation.
+func (d *StakeDelegation) Copy() *StakeDelegation {
+	cp := *d
+	cp.Amount = cloneBig(d.Amount)
+	cp.Rewards = cloneBig(d.Rewards)
+	return &cp
+}
+
+// Copy returns a deep copy of the unbonding stake.
+func (u *StakeUnbonding) Copy() *StakeUnbonding {
+	cp := *u
+	cp.Amount = cloneBig(u.Amount)
+	return &cp
+}
+
+// Copy returns a deep copy of the token.
+func (t *Token) Copy() *Token {
+	cp := *t
+	cp.Supply = cloneBig(t.Supply)
+	cp.MaxSupply = cloneBig(t.MaxSupply)
+	return &cp
+}
+
+// copyRecords returns deep copies of recs made by copyFn.
+func copyRecords[T any](recs []*T, copyFn func(*T) *T) []*T {
+	out := make([]*T, len(recs))
+	for i, r := range recs {
+		out[i] = copyFn(r)
+	}
+	return out
+}
+
+func copyDID(d transaction.AgentDID) transaction.AgentDID {
+	d.Capabilities = append([]transaction.Capability(nil), d.Capabilities...)
+	d.PublicKey = cloneBytes(d.PublicKey)
+	d.Metadata = cloneMetadata(d.Metadata)
+	return d
+}
+
+func copyReveal(v *transaction.InferenceReveal) *transaction.InferenceReveal {
+	cp := *v
+	cp.ReceiptHash = cloneBytes(v.ReceiptHash)
+	cp.Salt = cloneBytes(v.Salt)
+	cp.Input = cloneBytes(v.Input)
+	cp.Output = cloneBytes(v.Output)
+	cp.Key = cloneBytes(v.Key)
+	return &cp
+}
+
+func copyPacket(p *transaction.IBCPacket) *transaction.IBCPacket {
+	cp := *p
+	cp.Data = cloneBytes(p.Data)
+	return &cp
+}
+
+func copyAttestation(d *attestation.Document) *attestation.Document {
+	cp := *d
+	cp.Measurement = cloneBytes(d.Measurement)
+	cp.ReportData = cloneBytes(d.ReportData)
+	cp.Certificates = cloneByteSlices(d.Certificates)
+	cp.Signature = cloneBytes(d.Signature)
+	return &cp
+}
+
+func copyParamChanges(changes []transaction.ParamChange) []transaction.ParamChange {
+	if changes == nil {
+		return nil
+	}
+	out := make([]transaction.ParamChange, len(changes))
+	for i, c := range changes {
+		c.Value = cloneBytes(c.Value)
+		out[i] = c
+	}
+	return out
+}
+
+func cloneBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
+
+func cloneByteSlices(bs [][]byte) [][]byte {
+	if bs == nil {
+		return nil
+	}
+	out := make([][]byte, len(bs))
+	for i, b := range bs {
+		out[i] = cloneBytes(b)
+	}
+	return out
+}
+
+func cloneBig(x *big.Int) *big.Int {
+	if x == nil {
+		return nil
+	}
+	return new(big.Int).Set(x)
+}
+
+func cloneMetadata(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
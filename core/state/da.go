@@ -0,0 +1,107 @@
+package state
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// Payloads too large for the chain are kept on a data availability
+// network and referenced by content identifier. Pinning providers vouch
+// for retaining a payload until a given height with a data availability
+// attestation; an account's later attestation of the same payload replaces
+// its earlier one, and attestations are dropped once they lapse.
+
+// MaxAttestationPeriod caps how far ahead of its inclusion an attestation
+// may vouch for retention, in blocks.
+const MaxAttestationPeriod = 5_256_000 // about 4 months of 2s blocks
+
+var ErrAttestationPeriod = errors.New("attested retention must end after the current block and within the maximum period")
+
+// DataAttestationRecord is a data availability attestation included on
+// chain.
+type DataAttestationRecord struct {
+	Attester string              `json:"attester"`
+	Ref      transaction.DataRef `json:"ref"`
+	Height   uint64              `json:"height"`
+	Until    uint64              `json:"until"`
+}
+
+func dataKey(network, cid string) string {
+	return network + "/" + cid
+}
+
+// AttestData records the data availability attestation a by attester at
+// height.
+func (s *StateDB) AttestData(a transaction.DataAttestation, attester string, height uint64) error {
+	if a.Until <= height || a.Until-height > MaxAttestationPeriod {
+		return ErrAttestationPeriod
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dataAttestations == nil {
+		s.dataAttestations = make(map[string][]*DataAttestationRecord)
+	}
+	key := dataKey(a.Ref.Network, a.Ref.CID)
+	rec := &DataAttestationRecord{Attester: attester, Ref: a.Ref, Height: height, Until: a.Until}
+	prev := s.dataAttestations[key]
+	next := make([]*DataAttestationRecord, 0, len(prev)+1)
+	for _, r := range prev {
+		if !sameAddress(r.Attester, attester) {
+			next = append(next, r)
+		}
+	}
+	next = append(next, rec)
+	sort.Slice(next, func(i, j int) bool { return next[i].Attester < next[j].Attester })
+	s.dataAttestations[key] = next
+	return nil
+}
+
+// DataAttestations returns the attestations of the payload cid on network
+// in force, ordered by attester.
+func (s *StateDB) DataAttestations(network, cid string) []*DataAttestationRecord {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return copyRecords(s.dataAttestations[dataKey(network, cid)], (*DataAttestationRecord).Copy)
+}
+
+// SettleDataAttestations drops the attestations that have lapsed by
+// height. The consensus engine calls it once per block.
+func (s *StateDB) SettleDataAttestations(height uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, recs := range s.dataAttestations {
+		n := 0
+		for _, r := range recs {
+			if height < r.Until {
+				n++
+			}
+		}
+		switch {
+		case n == 0:
+			delete(s.dataAttestations, key)
+		case n < len(recs):
+			kept := make([]*DataAttestationRecord, 0, n)
+			for _, r := range recs {
+				if height < r.Until {
+					kept = append(kept, r)
+				}
+			}
+			s.dataAttestations[key] = kept
+		}
+	}
+}
+
+// copyDataAttestations returns a copy of s.dataAttestations. The lists are
+// replaced rather than modified, so they are shared. The caller holds s.mu.
+func (s *StateDB) copyDataAttestations() map[string][]*DataAttestationRecord {
+	if len(s.dataAttestations) == 0 {
+		return nil
+	}
+	cp := make(map[string][]*DataAttestationRecord, len(s.dataAttestations))
+	for k, recs := range s.dataAttestations {
+		cp[k] = recs
+	}
+	return cp
+}
@@ -0,0 +1,230 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/zionlayer/zionlayer/core/common"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// Capabilities are delegated with DELEGATE agent messages and withdrawn
+// with REVOKE messages. An agent holds a capability it declared at
+// registration or one delegated to it by a holder, so every delegation
+// hangs off a chain ending at an agent that declared the capability. A
+// link of the chain applies only while both its agents are active, it has
+// not expired and its MaxDepth leaves room for the links below it, so
+// revoking or expiring a delegation cuts off everything re-delegated from
+// it.
+
+var (
+	ErrCapabilityNotHeld  = errors.New("agent does not hold the capability")
+	ErrDelegationNotFound = errors.New("delegation not found")
+	ErrDelegationExpired  = errors.New("delegation expiry is not in the future")
+	ErrDelegationDepth    = errors.New("delegation exceeds the re-delegation depth allowed")
+)
+
+// DelegationRecord is a delegation in effect and the height it was
+// granted at.
+type DelegationRecord struct {
+	transaction.Delegation
+	GrantedAt uint64 `json:"grantedAt"`
+}
+
+// delegationKey identifies the delegation of capability from one agent
+// among those received by another.
+func delegationKey(from, capability string) string {
+	return from + "\x00" + capability
+}
+
+// Delegate records d, granted at height. The delegating agent must hold
+// the capability with enough depth left to allow d.MaxDepth further
+// re-delegations, and both agents must be registered and active. A
+// delegation of the same capability between the same agents is replaced.
+func (s *StateDB) Delegate(d transaction.Delegation, height uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range []string{d.From, d.To} {
+		if err := s.checkActive(id); err != nil {
+			return err
+		}
+	}
+	if d.ExpiresAt != 0 && d.ExpiresAt <= height {
+		return ErrDelegationExpired
+	}
+	if _, err := s.delegationChain(d.From, d.Capability, height, d.MaxDepth+1, nil); err != nil {
+		if _, held := s.delegationChain(d.From, d.Capability, height, 0, nil); held == nil {
+			return ErrDelegationDepth
+		}
+		return err
+	}
+	if s.delegations == nil {
+		s.delegations = make(map[string]map[string]*DelegationRecord)
+	}
+	in := s.delegations[d.To]
+	if in == nil {
+		in = make(map[string]*DelegationRecord)
+		s.delegations[d.To] = in
+	}
+	key := delegationKey(d.From, d.Capability.Name)
+	s.saveDelegation(d.To, key)
+	in[key] = &DelegationRecord{Delegation: d, GrantedAt: height}
+	return nil
+}
+
+// Revoke withdraws the delegation of capability from one agent to another.
+// Like Delegate, it requires both agents to be active.
+func (s *StateDB) Revoke(from, to, capability string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range []string{from, to} {
+		if err := s.checkActive(id); err != nil {
+			return err
+		}
+	}
+	key := delegationKey(from, capability)
+	if _, ok := s.delegations[to][key]; !ok {
+		return ErrDelegationNotFound
+	}
+	s.saveDelegation(to, key)
+	delete(s.delegations[to], key)
+	if len(s.delegations[to]) == 0 {
+		delete(s.delegations, to)
+	}
+	return nil
+}
+
+// Delegations returns the delegations received by agent, including expired
+// ones, ordered by capability and delegating agent.
+func (s *StateDB) Delegations(agent string) []DelegationRecord {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]DelegationRecord, 0, len(s.delegations[agent]))
+	for _, d := range s.delegations[agent] {
+		out = append(out, *d)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Capability.Name != out[j].Capability.Name {
+			return out[i].Capability.Name < out[j].Capability.Name
+		}
+		return out[i].From < out[j].From
+	})
+	return out
+}
+
+// VerifyDelegation reports whether agent holds capability at height and
+// returns the chain of delegations it holds it through, starting with the
+// one it received and ending with the one granted by an agent that
+// declared the capability. The chain is empty when agent declared it
+// itself. A capability with an empty Version matches any version.
+func (s *StateDB) VerifyDelegation(agent string, capability transaction.Capability, height uint64) ([]transaction.Delegation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.delegationChain(agent, capability, height, 0, nil)
+}
+
+// delegationChain finds a chain through which agent holds capability with
+// at least depth re-delegations left. seen holds the agents already on the
+// chain. The caller holds s.mu.
+func (s *StateDB) delegationChain(agent string, capability transaction.Capability, height, depth uint64, seen map[string]bool) ([]transaction.Delegation, error) {
+	if err := s.checkActive(agent); err != nil {
+		return nil, err
+	}
+	if capability.Name == transaction.CapabilityMessage {
+		return []transaction.Delegation{}, nil
+	}
+	for _, c := range s.agents[agent].DID.Capabilities {
+		if capabilityMatches(c, capability) {
+			return []transaction.Delegation{}, nil
+		}
+	}
+	if seen == nil {
+		seen = make(map[string]bool)
+	}
+	seen[agent] = true
+	defer delete(seen, agent)
+	if len(seen) > transaction.MaxDelegationDepth+1 {
+		return nil, ErrCapabilityNotHeld
+	}
+	in := s.delegations[agent]
+	keys := make([]string, 0, len(in))
+	for k := range in {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys) // the chain found must not depend on map order
+	for _, k := range keys {
+		d := in[k]
+		if d.Capability.Name != capability.Name || seen[d.From] || d.MaxDepth < depth {
+			continue
+		}
+		if d.ExpiresAt != 0 && height >= d.ExpiresAt {
+			continue
+		}
+		if capability.Version != "" && d.Capability.Version != "" && d.Capability.Version != capability.Version {
+			continue
+		}
+		want := capability
+		if want.Version == "" {
+			want.Version = d.Capability.Version
+		}
+		if chain, err := s.delegationChain(d.From, want, height, d.MaxDepth+1, seen); err == nil {
+			return append([]transaction.Delegation{d.Delegation}, chain...), nil
+		}
+	}
+	return nil, ErrCapabilityNotHeld
+}
+
+func capabilityMatches(have, want transaction.Capability) bool {
+	return have.Name == want.Name && (want.Version == "" || have.Version == want.Version)
+}
+
+// checkActive returns an error unless id is a registered, active agent.
+// The caller holds s.mu.
+func (s *StateDB) checkActive(id string) error {
+	rec, ok := s.agents[id]
+	if !ok {
+		return ErrAgentNotFound
+	}
+	if !rec.Active {
+		return ErrAgentInactive
+	}
+	return nil
+}
+
+// AuthorizeMessage checks that sender may send msg at height: the sending
+// agent must be registered and active, sender must be its controller or
+// the controller of an active agent holding an unexpired delegation of
+// CapabilityMessage from it, and msg.Nonce must be the sending agent's
+// next message nonce.
+func (s *StateDB) AuthorizeMessage(msg transaction.AgentMessage, sender string, height uint64) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if err := s.checkActive(msg.From); err != nil {
+		return err
+	}
+	rec := s.agents[msg.From]
+	if !sameAddress(rec.DID.Controller, sender) && !s.messageDelegate(msg.From, sender, height) {
+		return ErrNotAgentController
+	}
+	if msg.Nonce != rec.MessageNonce {
+		return fmt.Errorf("%w: have %d, want %d", ErrMessageNonce, msg.Nonce, rec.MessageNonce)
+	}
+	return nil
+}
+
+// messageDelegate reports whether sender controls an active agent that
+// may send messages from the agent from. The caller holds s.mu.
+func (s *StateDB) messageDelegate(from, sender string, height uint64) bool {
+	if addr, err := common.ParseAddress(sender); err == nil {
+		sender = addr.String()
+	}
+	key := delegationKey(from, transaction.CapabilityMessage)
+	for _, id := range s.byController[sender] {
+		d, ok := s.delegations[id][key]
+		if ok && (d.ExpiresAt == 0 || height < d.ExpiresAt) && s.checkActive(id) == nil {
+			return true
+		}
+	}
+	return false
+}
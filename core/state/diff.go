@@ -0,0 +1,131 @@
+package state
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"sort"
+
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// StateDiff describes the changes between two states.
+type StateDiff struct {
+	Accounts []AccountDiff              // sorted by address
+	Agents   []AgentDiff                // sorted by DID
+	Messages []transaction.AgentMessage // messages appended after the first state
+}
+
+// AccountDiff records how one account changed. Before is nil for an
+// account that did not exist and After is nil for one that was removed.
+// Storage lists only the slots whose value changed.
+type AccountDiff struct {
+	Address string
+	Before  *Account
+	After   *Account
+	Storage []StorageDiff
+}
+
+// StorageDiff is a changed storage slot. A nil value means the slot is
+// empty.
+type StorageDiff struct {
+	Key    []byte
+	Before []byte
+	After  []byte
+}
+
+// AgentDiff is a registered or modified agent record.
+type AgentDiff struct {
+	ID     string
+	Before *AgentRecord // nil if newly registered
+	After  *AgentRecord
+}
+
+// Diff returns the changes that turn before into after. The two states
+// must be distinct, typically a state and a Copy of it that has since been
+// modified.
+func Diff(before, after *StateDB) *StateDiff {
+	before.mu.RLock()
+	defer before.mu.RUnlock()
+	after.mu.RLock()
+	defer after.mu.RUnlock()
+
+	d := &StateDiff{}
+	addrs := make(map[string]struct{}, len(after.accounts))
+	for addr := range before.accounts {
+		addrs[addr] = struct{}{}
+	}
+	for addr := range after.accounts {
+		addrs[addr] = struct{}{}
+	}
+	for addr := range addrs {
+		a, b := before.accounts[addr], after.accounts[addr]
+		if ad, changed := diffAccount(addr, a, b); changed {
+			d.Accounts = append(d.Accounts, ad)
+		}
+	}
+	sort.Slice(d.Accounts, func(i, j int) bool { return d.Accounts[i].Address < d.Accounts[j].Address })
+
+	for id, rec := range after.agents {
+		prev := before.agents[id]
+		if prev != nil && reflect.DeepEqual(prev, rec) {
+			continue
+		}
+		ad := AgentDiff{ID: id, After: rec.Copy()}
+		if prev != nil {
+			ad.Before = prev.Copy()
+		}
+		d.Agents = append(d.Agents, ad)
+	}
+	sort.Slice(d.Agents, func(i, j int) bool { return d.Agents[i].ID < d.Agents[j].ID })
+
+	if n := len(before.messages); len(after.messages) > n {
+		d.Messages = append([]transaction.AgentMessage(nil), after.messages[n:]...)
+	}
+	return d
+}
+
+func diffAccount(addr string, a, b *Account) (AccountDiff, bool) {
+	ad := AccountDiff{Address: addr, Before: copyAccount(a), After: copyAccount(b)}
+	var sa, sb map[string][]byte
+	if a != nil {
+		sa = a.Storage
+	}
+	if b != nil {
+		sb = b.Storage
+	}
+	for k, v := range sa {
+		if w := sb[k]; !bytes.Equal(v, w) {
+			ad.Storage = append(ad.Storage, StorageDiff{Key: []byte(k), Before: v, After: w})
+		}
+	}
+	for k, w := range sb {
+		if _, ok := sa[k]; !ok {
+			ad.Storage = append(ad.Storage, StorageDiff{Key: []byte(k), After: w})
+		}
+	}
+	sort.Slice(ad.Storage, func(i, j int) bool { return bytes.Compare(ad.Storage[i].Key, ad.Storage[j].Key) < 0 })
+
+	if len(ad.Storage) > 0 || (a == nil) != (b == nil) {
+		return ad, true
+	}
+	if a == nil {
+		return ad, false
+	}
+	changed := a.Balance.Cmp(b.Balance) != 0 || a.Nonce != b.Nonce || !bytes.Equal(a.Code, b.Code)
+	return ad, changed
+}
+
+// copyAccount copies the scalar fields and code of acc, leaving out its
+// storage, which AccountDiff reports slot by slot.
+func copyAccount(acc *Account) *Account {
+	if acc == nil {
+		return nil
+	}
+	return &Account{
+		Address: acc.Address,
+		Balance: new(big.Int).Set(acc.Balance),
+		Nonce:   acc.Nonce,
+		Code:    append([]byte(nil), acc.Code...),
+	}
+}
@@ -0,0 +1,321 @@
+package state
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// An inference receipt can be challenged for ChallengeWindow blocks after
+// its inclusion. Its submitter's bond is escrowed for that long and
+// returned once the window closes unchallenged. A challenger escrows a
+// bond of the same size to open a dispute, which the designated verifiers
+// arbitrate, by vote or by committing the output of their own re-run of
+// the inference. The first side to gather a majority of the verifiers
+// wins: the winner gets its bond back and the loser's bond, less the
+// SlashBurnPercent burned, and the receipt's agent gains or loses
+// reputation. A dispute without a majority after ArbitrationPeriod blocks
+// expires and both bonds are returned. A receipt whose commitment lapses
+// unrevealed under an open dispute loses it; see reveal.go.
+
+var (
+	ErrChallengeWindowClosed = errors.New("challenge window of the receipt has closed")
+	ErrDisputeExists         = errors.New("receipt is already disputed")
+	ErrDisputeNotFound       = errors.New("dispute not found")
+	ErrDisputeClosed         = errors.New("dispute is closed")
+	ErrNoVerifiers           = errors.New("no dispute verifiers are designated")
+	ErrNotVerifier           = errors.New("sender is not a designated verifier of the dispute")
+	ErrAlreadyVoted          = errors.New("verifier has already voted on the dispute")
+	ErrSelfChallenge         = errors.New("submitter cannot challenge its own receipt")
+)
+
+// Dispute statuses.
+const (
+	DisputeOpen     = "open"
+	DisputeUpheld   = "upheld"   // the receipt stands; the challenger was slashed
+	DisputeRejected = "rejected" // the receipt fell; the submitter was slashed
+	DisputeExpired  = "expired"  // no majority in time; bonds returned
+)
+
+// DisputeParams configures the challenge of inference receipts.
+type DisputeParams struct {
+	ChallengeWindow   uint64   `json:"challengeWindow"`   // blocks
+	ArbitrationPeriod uint64   `json:"arbitrationPeriod"` // blocks
+	Bond              *big.Int `json:"bond"`              // escrowed by each side
+	SlashBurnPercent  uint64   `json:"slashBurnPercent"`  // of a slashed bond
+	RevealWindow      uint64   `json:"revealWindow"`      // blocks
+	Verifiers         []string `json:"verifiers"`         // designated arbiters
+}
+
+// DefaultDisputeParams returns the dispute parameters of a new state, which
+// has no verifiers designated.
+func DefaultDisputeParams() DisputeParams {
+	return DisputeParams{
+		ChallengeWindow:   100,
+		ArbitrationPeriod: 300,
+		Bond:              new(big.Int).Mul(big.NewInt(10), big.NewInt(1e18)),
+		SlashBurnPercent:  50,
+		RevealWindow:      200,
+	}
+}
+
+// VerifierVote is a verifier's decision on a dispute.
+type VerifierVote struct {
+	Verifier   string `json:"verifier"`
+	Valid      bool   `json:"valid"`
+	OutputHash []byte `json:"outputHash,omitempty"`
+	Height     uint64 `json:"height"`
+}
+
+// Dispute is a challenge of an inference receipt.
+type Dispute struct {
+	ReceiptHash []byte         `json:"receiptHash"`
+	Challenger  string         `json:"challenger"`
+	Method      string         `json:"method"`
+	Evidence    string         `json:"evidence,omitempty"`
+	Bond        *big.Int       `json:"bond"`
+	Verifiers   []string       `json:"verifiers"` // designated when opened
+	Votes       []VerifierVote `json:"votes"`
+	OpenedAt    uint64         `json:"openedAt"`
+	Deadline    uint64         `json:"deadline"`
+	Status      string         `json:"status"`
+	ResolvedAt  uint64         `json:"resolvedAt,omitempty"`
+}
+
+// SetDisputeParams replaces the dispute parameters.
+func (s *StateDB) SetDisputeParams(p DisputeParams) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p.Verifiers = append([]string(nil), p.Verifiers...)
+	s.disputeParams = p
+}
+
+// GetDisputeParams returns the dispute parameters.
+func (s *StateDB) GetDisputeParams() DisputeParams {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.disputeParams
+}
+
+// GetDispute returns the dispute of the receipt included by the transaction
+// hash.
+func (s *StateDB) GetDispute(hash []byte) (*Dispute, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.disputes[receiptKey(hash)]
+	if !ok {
+		return nil, ErrDisputeNotFound
+	}
+	return d.Copy(), nil
+}
+
+// OpenDispute challenges the receipt in open on behalf of challenger at
+// height, escrowing the dispute bond from the challenger.
+func (s *StateDB) OpenDispute(open transaction.DisputeOpen, challenger string, height uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := receiptKey(open.ReceiptHash)
+	rec, ok := s.receipts[key]
+	if !ok {
+		return ErrReceiptNotFound
+	}
+	if _, ok := s.disputes[key]; ok {
+		return ErrDisputeExists
+	}
+	if rec.Status != ReceiptPending || height >= rec.Height+s.disputeParams.ChallengeWindow {
+		return ErrChallengeWindowClosed
+	}
+	if sameAddress(rec.Submitter, challenger) {
+		return ErrSelfChallenge
+	}
+	if len(s.disputeParams.Verifiers) == 0 {
+		return ErrNoVerifiers
+	}
+	bond := s.disputeParams.Bond
+	if err := s.escrow(challenger, bond); err != nil {
+		return err
+	}
+	if s.disputes == nil {
+		s.disputes = make(map[string]*Dispute)
+	}
+	s.disputes[key] = &Dispute{
+		ReceiptHash: append([]byte(nil), open.ReceiptHash...),
+		Challenger:  challenger,
+		Method:      open.Method,
+		Evidence:    open.Evidence,
+		Bond:        new(big.Int).Set(bond),
+		Verifiers:   append([]string(nil), s.disputeParams.Verifiers...),
+		OpenedAt:    height,
+		Deadline:    height + s.disputeParams.ArbitrationPeriod,
+		Status:      DisputeOpen,
+	}
+	s.setReceiptStatus(key, ReceiptDisputed, nil)
+	return nil
+}
+
+// VoteDispute records the vote of verifier on the dispute of the receipt
+// in vote at height, and resolves the dispute once a side has a majority
+// of its verifiers.
+func (s *StateDB) VoteDispute(vote transaction.DisputeVote, verifier string, height uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := receiptKey(vote.ReceiptHash)
+	d, ok := s.disputes[key]
+	if !ok {
+		return ErrDisputeNotFound
+	}
+	if d.Status != DisputeOpen || height >= d.Deadline {
+		return ErrDisputeClosed
+	}
+	rec := s.receipts[key]
+	designated := false
+	for _, v := range d.Verifiers {
+		designated = designated || sameAddress(v, verifier)
+	}
+	if !designated || sameAddress(verifier, d.Challenger) || sameAddress(verifier, rec.Submitter) {
+		return ErrNotVerifier
+	}
+	for _, v := range d.Votes {
+		if sameAddress(v.Verifier, verifier) {
+			return ErrAlreadyVoted
+		}
+	}
+	valid := vote.Valid
+	if d.Method == transaction.ArbitrateReexecution {
+		valid = bytes.Equal(vote.OutputHash, rec.Receipt.OutputHash)
+	}
+	nd := *d
+	nd.Votes = append(d.Votes[:len(d.Votes):len(d.Votes)], VerifierVote{
+		Verifier:   verifier,
+		Valid:      valid,
+		OutputHash: append([]byte(nil), vote.OutputHash...),
+		Height:     height,
+	})
+	s.disputes[key] = &nd
+
+	var upheld, rejected int
+	for _, v := range nd.Votes {
+		if v.Valid {
+			upheld++
+		} else {
+			rejected++
+		}
+	}
+	switch majority := len(nd.Verifiers)/2 + 1; {
+	case upheld >= majority:
+		s.resolveDispute(key, true, height)
+	case rejected >= majority:
+		s.resolveDispute(key, false, height)
+	}
+	return nil
+}
+
+// SettleDisputes releases the bonds of the receipts whose challenge window
+// has closed by height, lapses the commitments whose reveal window has
+// closed and expires the disputes past their deadline. The consensus
+// engine calls it once per block.
+func (s *StateDB) SettleDisputes(height uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settleReveals(height)
+	window := s.disputeParams.ChallengeWindow
+	n := 0
+	for ; n < len(s.pendingReceipts); n++ {
+		key := s.pendingReceipts[n]
+		rec := s.receipts[key]
+		if rec.Status == ReceiptPending && height < rec.Height+window {
+			break
+		}
+		if rec.Status == ReceiptPending {
+			s.release(rec.Submitter, rec.Bond)
+			s.setReceiptStatus(key, ReceiptFinal, new(big.Int))
+		}
+	}
+	s.pendingReceipts = s.pendingReceipts[n:]
+
+	for key, d := range s.disputes {
+		if d.Status != DisputeOpen || height < d.Deadline {
+			continue
+		}
+		rec := s.receipts[key]
+		s.release(d.Challenger, d.Bond)
+		s.release(rec.Submitter, rec.Bond)
+		nd := *d
+		nd.Status, nd.ResolvedAt = DisputeExpired, height
+		s.disputes[key] = &nd
+		s.setReceiptStatus(key, ReceiptFinal, new(big.Int))
+	}
+}
+
+// resolveDispute pays out the dispute under key at height: upheld means the
+// receipt was found valid. The caller holds s.mu.
+func (s *StateDB) resolveDispute(key string, upheld bool, height uint64) {
+	d, rec := s.disputes[key], s.receipts[key]
+	winner, winnerBond, loserBond := rec.Submitter, rec.Bond, d.Bond
+	status, receiptStatus := DisputeUpheld, ReceiptUpheld
+	if !upheld {
+		winner, winnerBond, loserBond = d.Challenger, d.Bond, rec.Bond
+		status, receiptStatus = DisputeRejected, ReceiptRejected
+	}
+	burned := new(big.Int).Mul(loserBond, new(big.Int).SetUint64(s.disputeParams.SlashBurnPercent))
+	burned.Div(burned, big.NewInt(100))
+	s.burn(burned)
+	s.release(winner, winnerBond)
+	s.release(winner, new(big.Int).Sub(loserBond, burned))
+
+	nd := *d
+	nd.Status, nd.ResolvedAt = status, height
+	s.disputes[key] = &nd
+	s.setReceiptStatus(key, receiptStatus, new(big.Int))
+	s.updateReputation(rec.Receipt.AgentID, height, func(r *Reputation) {
+		if upheld {
+			r.DisputesWon++
+		} else {
+			r.DisputesLost++
+		}
+	})
+}
+
+// setReceiptStatus replaces the receipt under key with one in status and,
+// unless nil, holding bond. The caller holds s.mu.
+func (s *StateDB) setReceiptStatus(key, status string, bond *big.Int) {
+	r := *s.receipts[key]
+	r.Status = status
+	if bond != nil {
+		r.Bond = bond
+	}
+	s.receipts[key] = &r
+}
+
+// escrow debits amount from addr into a bond. The caller holds s.mu.
+func (s *StateDB) escrow(addr string, amount *big.Int) error {
+	return s.debit(addr, amount)
+}
+
+// release credits a bond of amount to addr. The caller holds s.mu.
+func (s *StateDB) release(addr string, amount *big.Int) {
+	s.credit(addr, amount)
+}
+
+// copyDisputes returns copies of s.receipts and s.disputes. Their records
+// are replaced rather than modified, so they are shared. The caller holds
+// s.mu.
+func (s *StateDB) copyDisputes() (map[string]*ReceiptRecord, map[string]*Dispute) {
+	var receipts map[string]*ReceiptRecord
+	if len(s.receipts) > 0 {
+		receipts = make(map[string]*ReceiptRecord, len(s.receipts))
+		for k, r := range s.receipts {
+			receipts[k] = r
+		}
+	}
+	var disputes map[string]*Dispute
+	if len(s.disputes) > 0 {
+		disputes = make(map[string]*Dispute, len(s.disputes))
+		for k, d := range s.disputes {
+			disputes[k] = d
+		}
+	}
+	return receipts, disputes
+}
@@ -0,0 +1,145 @@
+package state
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/zionlayer/zionlayer/core/attestation"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// An inference receipt may carry the attestation of the TEE enclave it ran
+// in. The attestation raises the receipt to TrustAttested if its
+// certificate chain leads to a root trusted for the platform, it binds the
+// receipt by carrying its SigningHash as report data, and it measures an
+// approved enclave image. Receipts with an attestation that fails these
+// checks are rejected rather than downgraded.
+
+// Trust tiers of inference receipts.
+const (
+	TrustSigned   = 1 // signed by a registered compute provider
+	TrustAttested = 2 // also attested by an approved TEE enclave
+	TrustProven   = 3 // also proven by a zkML proof; see zkml.go
+)
+
+var (
+	ErrEnclaveNotApproved = errors.New("enclave image is not approved")
+	ErrAttestationBinding = errors.New("attestation does not bind the receipt")
+	ErrInvalidAttestation = errors.New("invalid attestation")
+	ErrNoAttestationRoots = errors.New("no attestation roots for the platform")
+)
+
+// EnclaveImage is an approved TEE enclave image.
+type EnclaveImage struct {
+	Platform    string `json:"platform"`
+	Measurement []byte `json:"measurement"`
+	Name        string `json:"name,omitempty"`
+}
+
+func enclaveKey(platform string, measurement []byte) string {
+	return fmt.Sprintf("%s/%x", platform, measurement)
+}
+
+// ApproveEnclave adds img to the approved enclave images.
+func (s *StateDB) ApproveEnclave(img EnclaveImage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.enclaves == nil {
+		s.enclaves = make(map[string]*EnclaveImage)
+	}
+	img.Measurement = append([]byte(nil), img.Measurement...)
+	s.enclaves[enclaveKey(img.Platform, img.Measurement)] = &img
+}
+
+// RevokeEnclave removes an image from the approved enclave images.
+func (s *StateDB) RevokeEnclave(platform string, measurement []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.enclaves, enclaveKey(platform, measurement))
+}
+
+// EnclaveImages returns the approved enclave images, ordered by platform
+// and measurement.
+func (s *StateDB) EnclaveImages() []*EnclaveImage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*EnclaveImage, 0, len(s.enclaves))
+	for _, img := range s.enclaves {
+		out = append(out, img.Copy())
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Platform != out[j].Platform {
+			return out[i].Platform < out[j].Platform
+		}
+		return bytes.Compare(out[i].Measurement, out[j].Measurement) < 0
+	})
+	return out
+}
+
+// SetAttestationRoots replaces the DER root certificates trusted for the
+// attestations of platform.
+func (s *StateDB) SetAttestationRoots(platform string, roots [][]byte) error {
+	for _, der := range roots {
+		if _, err := x509.ParseCertificate(der); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidAttestation, err)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attestationRoots == nil {
+		s.attestationRoots = make(map[string][][]byte)
+	}
+	s.attestationRoots[platform] = append([][]byte(nil), roots...)
+	return nil
+}
+
+// verifyAttestation checks the attestation of r at blockTime. The caller
+// holds s.mu.
+func (s *StateDB) verifyAttestation(r transaction.InferenceReceipt, blockTime time.Time) error {
+	a := r.Attestation
+	h := r.SigningHash()
+	if !bytes.Equal(a.ReportData, h[:]) {
+		return ErrAttestationBinding
+	}
+	if _, ok := s.enclaves[enclaveKey(a.Platform, a.Measurement)]; !ok {
+		return ErrEnclaveNotApproved
+	}
+	ders := s.attestationRoots[a.Platform]
+	if len(ders) == 0 {
+		return ErrNoAttestationRoots
+	}
+	roots := x509.NewCertPool()
+	for _, der := range ders {
+		c, _ := x509.ParseCertificate(der) // checked by SetAttestationRoots
+		roots.AddCert(c)
+	}
+	if err := attestation.Verify(a, roots, blockTime); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAttestation, err)
+	}
+	return nil
+}
+
+// copyEnclaves returns copies of s.enclaves and s.attestationRoots. Images
+// and roots are replaced rather than modified, so they are shared. The
+// caller holds s.mu.
+func (s *StateDB) copyEnclaves() (map[string]*EnclaveImage, map[string][][]byte) {
+	var enclaves map[string]*EnclaveImage
+	if len(s.enclaves) > 0 {
+		enclaves = make(map[string]*EnclaveImage, len(s.enclaves))
+		for k, img := range s.enclaves {
+			enclaves[k] = img
+		}
+	}
+	var roots map[string][][]byte
+	if len(s.attestationRoots) > 0 {
+		roots = make(map[string][][]byte, len(s.attestationRoots))
+		for k, r := range s.attestationRoots {
+			roots[k] = r
+		}
+	}
+	return enclaves, roots
+}
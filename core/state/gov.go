@@ -0,0 +1,422 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// Governance decides on proposals to change protocol parameters, to spend
+// from the community treasury and to signal software upgrades. A proposal
+// collects deposits for DepositPeriod blocks; once they reach MinDeposit
+// it is voted on for VotingPeriod blocks, weighted by the bonded stake of
+// the voters, self-stake and delegations included, at the end of the
+// period. The proposal fails short of Quorum of the bonded stake voting.
+// It is vetoed if VetoThreshold of the voting stake votes no with veto,
+// and passes if more than Threshold of the stake voting other than
+// abstain votes yes. A passed proposal is executed at once, a parameter
+// change with an activation height still ahead being scheduled as a fork;
+// a parameter change that no longer applies, or a spend exceeding the
+// treasury, fails.
+// Deposits are returned, except those of vetoed proposals and of
+// proposals that never reached MinDeposit, which are burned.
+
+var (
+	ErrProposalNotFound = errors.New("proposal not found")
+	ErrDepositClosed    = errors.New("proposal is not collecting deposits")
+	ErrVotingClosed     = errors.New("proposal is not in its voting period")
+	ErrNoVotingPower    = errors.New("voter has no bonded stake")
+)
+
+// Proposal statuses.
+const (
+	ProposalDeposit  = "deposit" // collecting deposits
+	ProposalVoting   = "voting"
+	ProposalPassed   = "passed"   // and executed
+	ProposalRejected = "rejected" // short of quorum or threshold
+	ProposalVetoed   = "vetoed"
+	ProposalFailed   = "failed"  // passed, but its execution failed
+	ProposalExpired  = "expired" // never reached the minimum deposit
+)
+
+// GovParams configures governance. Quorum and the thresholds are in basis
+// points.
+type GovParams struct {
+	MinDeposit    *big.Int `json:"minDeposit"`
+	DepositPeriod uint64   `json:"depositPeriod"` // blocks
+	VotingPeriod  uint64   `json:"votingPeriod"`  // blocks
+	Quorum        uint64   `json:"quorum"`        // of the bonded stake
+	Threshold     uint64   `json:"threshold"`     // of the stake voting other than abstain
+	VetoThreshold uint64   `json:"vetoThreshold"` // of the voting stake
+}
+
+// DefaultGovParams returns the governance parameters of a new state.
+func DefaultGovParams() GovParams {
+	return GovParams{
+		MinDeposit:    new(big.Int).Mul(big.NewInt(1_000), big.NewInt(1e18)),
+		DepositPeriod: 43_200, // a day of 2s blocks
+		VotingPeriod:  302_400,
+		Quorum:        3_340,
+		Threshold:     5_000,
+		VetoThreshold: 3_340,
+	}
+}
+
+// Validate checks that p is consistent.
+func (p GovParams) Validate() error {
+	switch {
+	case p.MinDeposit == nil || p.MinDeposit.Sign() <= 0:
+		return fmt.Errorf("%w: minDeposit must be positive", ErrInvalidParams)
+	case p.DepositPeriod == 0 || p.VotingPeriod == 0:
+		return fmt.Errorf("%w: periods must be positive", ErrInvalidParams)
+	case p.Quorum > 10_000 || p.Threshold > 10_000 || p.VetoThreshold > 10_000:
+		return fmt.Errorf("%w: quorum and thresholds are at most 10000", ErrInvalidParams)
+	}
+	return nil
+}
+
+// ProposalDepositRecord is the total deposit of a depositor.
+type ProposalDepositRecord struct {
+	Depositor string   `json:"depositor"`
+	Amount    *big.Int `json:"amount"`
+}
+
+// ProposalVoteRecord is the vote of a voter.
+type ProposalVoteRecord struct {
+	Voter  string `json:"voter"`
+	Option string `json:"option"`
+	Height uint64 `json:"height"`
+}
+
+// TallyResult is the bonded stake voting each option.
+type TallyResult struct {
+	Yes         *big.Int `json:"yes"`
+	No          *big.Int `json:"no"`
+	Abstain     *big.Int `json:"abstain"`
+	NoWithVeto  *big.Int `json:"noWithVeto"`
+	TotalBonded *big.Int `json:"totalBonded"`
+}
+
+// Proposal is a governance proposal.
+type Proposal struct {
+	ID           uint64                  `json:"id"`
+	Proposer     string                  `json:"proposer"`
+	Content      transaction.Proposal    `json:"content"`
+	Status       string                  `json:"status"`
+	SubmittedAt  uint64                  `json:"submittedAt"`
+	DepositEnd   uint64                  `json:"depositEnd"`
+	TotalDeposit *big.Int                `json:"totalDeposit"`
+	Deposits     []ProposalDepositRecord `json:"deposits"`
+	VotingStart  uint64                  `json:"votingStart,omitempty"`
+	VotingEnd    uint64                  `json:"votingEnd,omitempty"`
+	Votes        []ProposalVoteRecord    `json:"votes"`
+	Tally        *TallyResult            `json:"tally,omitempty"` // once voting ends
+	Error        string                  `json:"error,omitempty"` // of a failed execution
+}
+
+// SetGovParams replaces the governance parameters.
+func (s *StateDB) SetGovParams(p GovParams) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.govParams = p
+}
+
+// GetGovParams returns the governance parameters.
+func (s *StateDB) GetGovParams() GovParams {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.govParams
+}
+
+// SubmitProposal submits p on behalf of proposer at height with an initial
+// deposit, which may be zero, and returns the proposal ID. A parameter
+// change must apply to the current parameters.
+func (s *StateDB) SubmitProposal(p transaction.Proposal, proposer string, deposit *big.Int, height uint64) (uint64, error) {
+	if err := p.Check(); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p.Kind == transaction.ProposalParamChange {
+		if err := s.checkParamChanges(p.Changes); err != nil {
+			return 0, err
+		}
+	}
+	if deposit == nil {
+		deposit = new(big.Int)
+	}
+	if err := s.escrow(proposer, deposit); err != nil {
+		return 0, err
+	}
+	if s.proposals == nil {
+		s.proposals = make(map[uint64]*Proposal)
+	}
+	s.nextProposalID++
+	prop := &Proposal{
+		ID:           s.nextProposalID,
+		Proposer:     proposer,
+		Content:      p,
+		Status:       ProposalDeposit,
+		SubmittedAt:  height,
+		DepositEnd:   height + s.govParams.DepositPeriod,
+		TotalDeposit: new(big.Int),
+	}
+	s.addDeposit(prop, proposer, deposit, height)
+	s.proposals[prop.ID] = prop
+	s.pendingProposals = append(s.pendingProposals[:len(s.pendingProposals):len(s.pendingProposals)], prop.ID)
+	return prop.ID, nil
+}
+
+// DepositProposal adds amount of the balance of depositor to the deposit
+// of proposal id at height.
+func (s *StateDB) DepositProposal(id uint64, depositor string, amount *big.Int, height uint64) error {
+	if amount == nil || amount.Sign() <= 0 {
+		return fmt.Errorf("%w: deposit must be positive", transaction.ErrInvalidData)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prop, ok := s.proposals[id]
+	if !ok {
+		return ErrProposalNotFound
+	}
+	if prop.Status != ProposalDeposit {
+		return ErrDepositClosed
+	}
+	if err := s.escrow(depositor, amount); err != nil {
+		return err
+	}
+	np := *prop
+	s.addDeposit(&np, depositor, amount, height)
+	s.proposals[id] = &np
+	return nil
+}
+
+// addDeposit records the deposit of amount by depositor to prop, a record
+// not yet in s.proposals, which enters its voting period at height once
+// the minimum deposit is reached. The caller holds s.mu.
+func (s *StateDB) addDeposit(prop *Proposal, depositor string, amount *big.Int, height uint64) {
+	deposits := make([]ProposalDepositRecord, 0, len(prop.Deposits)+1)
+	found := false
+	for _, d := range prop.Deposits {
+		if sameAddress(d.Depositor, depositor) {
+			d.Amount, found = new(big.Int).Add(d.Amount, amount), true
+		}
+		deposits = append(deposits, d)
+	}
+	if !found && amount.Sign() > 0 {
+		deposits = append(deposits, ProposalDepositRecord{Depositor: depositor, Amount: new(big.Int).Set(amount)})
+	}
+	prop.Deposits = deposits
+	prop.TotalDeposit = new(big.Int).Add(prop.TotalDeposit, amount)
+	if prop.TotalDeposit.Cmp(s.govParams.MinDeposit) >= 0 {
+		prop.Status = ProposalVoting
+		prop.VotingStart, prop.VotingEnd = height, height+s.govParams.VotingPeriod
+	}
+}
+
+// VoteProposal records the vote of voter, which must have bonded stake, on
+// proposal id at height.
+func (s *StateDB) VoteProposal(v transaction.ProposalVote, voter string, height uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prop, ok := s.proposals[v.ProposalID]
+	if !ok {
+		return ErrProposalNotFound
+	}
+	if prop.Status != ProposalVoting {
+		return ErrVotingClosed
+	}
+	if s.votingPower(voter).Sign() == 0 {
+		return ErrNoVotingPower
+	}
+	np := *prop
+	np.Votes = make([]ProposalVoteRecord, 0, len(prop.Votes)+1)
+	for _, pv := range prop.Votes {
+		if !sameAddress(pv.Voter, voter) {
+			np.Votes = append(np.Votes, pv)
+		}
+	}
+	np.Votes = append(np.Votes, ProposalVoteRecord{Voter: voter, Option: v.Option, Height: height})
+	s.proposals[v.ProposalID] = &np
+	return nil
+}
+
+// votingPower returns the bonded stake of voter. The caller holds s.mu.
+func (s *StateDB) votingPower(voter string) *big.Int {
+	power := new(big.Int)
+	for _, d := range s.stakes {
+		if sameAddress(d.Delegator, voter) {
+			power.Add(power, d.Amount)
+		}
+	}
+	return power
+}
+
+// SettleProposals closes the deposit and voting periods ending by height,
+// tallying and executing proposals in the order they were submitted. The
+// consensus engine calls it once per block.
+func (s *StateDB) SettleProposals(height uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var pending []uint64
+	for _, id := range s.pendingProposals {
+		prop := s.proposals[id]
+		switch {
+		case prop.Status == ProposalDeposit && height >= prop.DepositEnd:
+			s.closeProposal(prop, ProposalExpired, "", nil)
+		case prop.Status == ProposalVoting && height >= prop.VotingEnd:
+			s.tallyProposal(prop, height)
+		default:
+			pending = append(pending, id)
+		}
+	}
+	s.pendingProposals = pending
+}
+
+// tallyProposal tallies the votes of prop, whose voting period has ended
+// at height, and executes it if it passed. The caller holds s.mu.
+func (s *StateDB) tallyProposal(prop *Proposal, height uint64) {
+	t := &TallyResult{Yes: new(big.Int), No: new(big.Int), Abstain: new(big.Int), NoWithVeto: new(big.Int), TotalBonded: new(big.Int)}
+	for _, v := range s.stakingValidators {
+		t.TotalBonded.Add(t.TotalBonded, v.Tokens)
+	}
+	for _, v := range prop.Votes {
+		power := s.votingPower(v.Voter)
+		switch v.Option {
+		case transaction.VoteYes:
+			t.Yes.Add(t.Yes, power)
+		case transaction.VoteNo:
+			t.No.Add(t.No, power)
+		case transaction.VoteAbstain:
+			t.Abstain.Add(t.Abstain, power)
+		case transaction.VoteNoWithVeto:
+			t.NoWithVeto.Add(t.NoWithVeto, power)
+		}
+	}
+	voted := new(big.Int).Add(t.Yes, t.No)
+	voted.Add(voted, t.NoWithVeto)
+	nonAbstain := new(big.Int).Set(voted)
+	voted.Add(voted, t.Abstain)
+	p := s.govParams
+	switch {
+	case voted.Sign() == 0 || !reaches(voted, t.TotalBonded, p.Quorum):
+		s.closeProposal(prop, ProposalRejected, "", t)
+	case reaches(t.NoWithVeto, voted, p.VetoThreshold):
+		s.closeProposal(prop, ProposalVetoed, "", t)
+	case nonAbstain.Sign() == 0 || reaches(new(big.Int).Sub(nonAbstain, t.Yes), nonAbstain, 10_000-p.Threshold):
+		s.closeProposal(prop, ProposalRejected, "", t)
+	default:
+		if err := s.executeProposal(prop, height); err != nil {
+			s.closeProposal(prop, ProposalFailed, err.Error(), t)
+			return
+		}
+		s.closeProposal(prop, ProposalPassed, "", t)
+	}
+}
+
+// reaches reports whether part is at least bps basis points of whole.
+func reaches(part, whole *big.Int, bps uint64) bool {
+	lhs := new(big.Int).Mul(part, big.NewInt(10_000))
+	rhs := new(big.Int).Mul(whole, new(big.Int).SetUint64(bps))
+	return lhs.Cmp(rhs) >= 0
+}
+
+// executeProposal carries out the passed proposal prop at height. The
+// caller holds s.mu.
+func (s *StateDB) executeProposal(prop *Proposal, height uint64) error {
+	c := prop.Content
+	switch c.Kind {
+	case transaction.ProposalParamChange:
+		if c.ActivationHeight > height {
+			if err := s.checkParamChanges(c.Changes); err != nil {
+				return err
+			}
+			s.scheduleParamChanges(c.ActivationHeight, c.Changes)
+			return nil
+		}
+		return s.applyParamChanges(c.Changes)
+	case transaction.ProposalTreasurySpend:
+		if s.treasury.Cmp(c.Spend.Amount) < 0 {
+			return fmt.Errorf("treasury holds %s, less than %s", s.treasury, c.Spend.Amount)
+		}
+		s.treasury.Sub(s.treasury, c.Spend.Amount)
+		s.release(c.Spend.Recipient, c.Spend.Amount)
+	case transaction.ProposalUpgrade:
+		if c.Upgrade.Height <= height {
+			return fmt.Errorf("upgrade height %d has passed", c.Upgrade.Height)
+		}
+		plan := *c.Upgrade
+		s.upgradePlan = &plan
+	}
+	return nil
+}
+
+// closeProposal gives prop its final status, returning its deposits or,
+// for vetoed and expired proposals, burning them. The caller holds s.mu.
+func (s *StateDB) closeProposal(prop *Proposal, status, errMsg string, tally *TallyResult) {
+	burn := status == ProposalVetoed || status == ProposalExpired
+	for _, d := range prop.Deposits {
+		if burn {
+			s.burn(d.Amount)
+		} else {
+			s.release(d.Depositor, d.Amount)
+		}
+	}
+	np := *prop
+	np.Status, np.Error, np.Tally = status, errMsg, tally
+	s.proposals[prop.ID] = &np
+}
+
+// GetUpgradePlan returns the software upgrade scheduled by governance, or
+// nil if there is none.
+func (s *StateDB) GetUpgradePlan() *transaction.UpgradePlan {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.upgradePlan == nil {
+		return nil
+	}
+	plan := *s.upgradePlan
+	return &plan
+}
+
+// GetProposal returns proposal id.
+func (s *StateDB) GetProposal(id uint64) (*Proposal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	prop, ok := s.proposals[id]
+	if !ok {
+		return nil, ErrProposalNotFound
+	}
+	return prop.Copy(), nil
+}
+
+// Proposals returns the proposals with status, or all of them if status is
+// empty, newest first.
+func (s *StateDB) Proposals(status string) []*Proposal {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*Proposal, 0)
+	for _, prop := range s.proposals {
+		if status == "" || prop.Status == status {
+			out = append(out, prop.Copy())
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID > out[j].ID })
+	return out
+}
+
+// copyProposals returns a copy of s.proposals. Proposals are replaced
+// rather than modified, so they are shared. The caller holds s.mu.
+func (s *StateDB) copyProposals() map[uint64]*Proposal {
+	if len(s.proposals) == 0 {
+		return nil
+	}
+	cp := make(map[uint64]*Proposal, len(s.proposals))
+	for id, prop := range s.proposals {
+		cp[id] = prop
+	}
+	return cp
+}
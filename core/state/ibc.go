@@ -0,0 +1,886 @@
+package state
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zionlayer/zionlayer/core/block"
+	"github.com/zionlayer/zionlayer/core/common"
+	"github.com/zionlayer/zionlayer/core/crypto"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// IBC carries agent messages and token transfers between ZionLayer and
+// counterparty chains, each tracking the other with a light client.
+//
+// A client follows the headers of a counterparty chain from a trusted
+// header, accepting those signed by more than two thirds of the voting
+// power of its validator set; a validator set change must be vouched for
+// by more than a third of the old set. The client keeps the AgentRoot of
+// the recent headers, against which proofs of the counterparty state are
+// checked: IBC records are entries of the agent state under ibc/.
+//
+// Two chains connect their clients of each other with a four-step
+// connection handshake (init, try, ack, confirm), each step proving the
+// connection end of the counterparty in the previous one. Channels are
+// opened over a connection the same way, between ports of the same name:
+// the transfer port moves tokens and the agent port agent messages.
+//
+// A packet sent over a channel is committed to in the state of the sender
+// until acknowledged. Relayers, which anyone can run, submit the packet
+// to the counterparty with the proof of its commitment; the counterparty
+// hands it to the application of the port and records an acknowledgement,
+// which relayers submit back with its proof. The agent state commits to
+// no absence of entries, so instead of proving that a packet was not
+// received in time, the counterparty acknowledges a packet it receives
+// past its timeout height with an error. A transfer acknowledged with an
+// error is refunded.
+//
+// Transfers of ZIO are escrowed in the channel and released when the
+// tokens come back over it. Tokens received from a counterparty are
+// credited as vouchers whose denomination records the path they took,
+// port/channel/ of the receiving end prefixed to theirs, and are burned
+// when sent back.
+
+var (
+	ErrIBCClientNotFound     = errors.New("ibc: client not found")
+	ErrIBCConnectionNotFound = errors.New("ibc: connection not found")
+	ErrIBCChannelNotFound    = errors.New("ibc: channel not found")
+	ErrIBCInvalidHeader      = errors.New("ibc: invalid header")
+	ErrIBCInvalidProof       = errors.New("ibc: invalid proof")
+	ErrIBCHandshake          = errors.New("ibc: handshake mismatch")
+	ErrIBCInvalidPacket      = errors.New("ibc: invalid packet")
+)
+
+// MaxIBCConsensusStates is the number of recent counterparty headers a
+// client keeps proofs can be checked against.
+const MaxIBCConsensusStates = 256
+
+// States of connection and channel ends.
+const (
+	IBCStateInit    = "INIT"
+	IBCStateTryOpen = "TRYOPEN"
+	IBCStateOpen    = "OPEN"
+)
+
+// IBCConsensusState is what a client retains of a counterparty header.
+type IBCConsensusState struct {
+	Height    uint64   `json:"height"`
+	Hash      [32]byte `json:"hash"`
+	AgentRoot [32]byte `json:"agentRoot"`
+	Timestamp int64    `json:"timestamp"`
+}
+
+// IBCClient is a light client of a counterparty chain.
+type IBCClient struct {
+	ID              string                     `json:"id"`
+	ChainID         uint64                     `json:"chainId"`
+	GenesisHash     [32]byte                   `json:"genesisHash"` // of the counterparty network
+	Validators      []transaction.IBCValidator `json:"validators"`  // by address
+	LatestHeight    uint64                     `json:"latestHeight"`
+	ConsensusStates []IBCConsensusState        `json:"consensusStates"` // oldest first
+	CreatedAt       uint64                     `json:"createdAt"`
+}
+
+// IBCConnectionCounterparty identifies the end of a connection on the
+// counterparty.
+type IBCConnectionCounterparty struct {
+	ClientID     string `json:"clientId"`
+	ConnectionID string `json:"connectionId,omitempty"`
+}
+
+// IBCConnection is the end of a connection between a client and its
+// counterparty of the chain it tracks.
+type IBCConnection struct {
+	ID           string                    `json:"id"`
+	ClientID     string                    `json:"clientId"`
+	State        string                    `json:"state"`
+	Counterparty IBCConnectionCounterparty `json:"counterparty"`
+}
+
+// IBCChannelCounterparty identifies the end of a channel on the
+// counterparty.
+type IBCChannelCounterparty struct {
+	Port      string `json:"port"`
+	ChannelID string `json:"channelId,omitempty"`
+}
+
+// IBCChannel is the end of a channel between ports of two chains.
+// NextSequence is the sequence of the next packet sent and Escrowed the
+// ZIO transferred over the channel and not returned.
+type IBCChannel struct {
+	Port         string                 `json:"port"`
+	ID           string                 `json:"id"`
+	ConnectionID string                 `json:"connectionId"`
+	State        string                 `json:"state"`
+	Counterparty IBCChannelCounterparty `json:"counterparty"`
+	NextSequence uint64                 `json:"nextSequence"`
+	Escrowed     *big.Int               `json:"escrowed"`
+}
+
+// IBCPacketAck is a received packet and its acknowledgement.
+type IBCPacketAck struct {
+	Packet          transaction.IBCPacket          `json:"packet"`
+	Acknowledgement transaction.IBCAcknowledgement `json:"acknowledgement"`
+	Height          uint64                         `json:"height"`
+}
+
+// IBCPacketCommitment returns the commitment to p kept in the agent state
+// of the sender until p is acknowledged.
+func IBCPacketCommitment(p transaction.IBCPacket) string {
+	return ibcCommitment(p)
+}
+
+// IBCAckCommitment returns the commitment to the acknowledgement a kept in
+// the agent state of the receiver.
+func IBCAckCommitment(a transaction.IBCAcknowledgement) string {
+	return ibcCommitment(a)
+}
+
+func ibcCommitment(v interface{}) string {
+	b, _ := json.Marshal(v)
+	h := sha256.Sum256(b)
+	return fmt.Sprintf("0x%x", h)
+}
+
+// IBCRemoteAgent returns the sender of an agent message received over
+// channel from the agent did of the counterparty.
+func IBCRemoteAgent(channel, did string) string {
+	return "ibc/" + channel + "/" + did
+}
+
+func ibcChannelKey(port, id string) string { return port + "/" + id }
+
+func ibcPacketKey(port, channel string, seq uint64) string {
+	return fmt.Sprintf("%s/%s/%020d", port, channel, seq)
+}
+
+func ibcVoucherKey(denom, addr string) string { return denom + "\x00" + addr }
+
+// checkIBCValidators returns vals ordered by address, checking that each
+// has a positive power and a public key matching its address.
+func checkIBCValidators(vals []transaction.IBCValidator) ([]transaction.IBCValidator, error) {
+	if len(vals) == 0 {
+		return nil, fmt.Errorf("%w: no validators", ErrIBCInvalidHeader)
+	}
+	out := append([]transaction.IBCValidator(nil), vals...)
+	sort.Slice(out, func(i, j int) bool { return out[i].Address < out[j].Address })
+	for i, v := range out {
+		if v.Power <= 0 || len(v.PublicKey) != crypto.PublicKeySize || crypto.PubkeyToAddress(v.PublicKey).String() != v.Address {
+			return nil, fmt.Errorf("%w: invalid validator %s", ErrIBCInvalidHeader, v.Address)
+		}
+		if i > 0 && out[i-1].Address == v.Address {
+			return nil, fmt.Errorf("%w: validator %s listed twice", ErrIBCInvalidHeader, v.Address)
+		}
+	}
+	return out, nil
+}
+
+// ibcSignedPower returns the fraction of the voting power of vals that
+// signed h, by its proposer or in commit, as signed/total.
+func ibcSignedPower(vals []transaction.IBCValidator, h *block.Header, commit []transaction.IBCCommitSig) (signed, total int64) {
+	digest := h.SigningHash()
+	sigs := append([]transaction.IBCCommitSig{{Validator: string(h.ValidatorAddr), Signature: h.Signature}}, commit...)
+	seen := make(map[string]bool, len(sigs))
+	for _, v := range vals {
+		total += v.Power
+	}
+	for _, sig := range sigs {
+		i := sort.Search(len(vals), func(i int) bool { return vals[i].Address >= sig.Validator })
+		if i == len(vals) || vals[i].Address != sig.Validator || seen[sig.Validator] {
+			continue
+		}
+		if crypto.Verify(vals[i].PublicKey, digest, sig.Signature) == nil {
+			seen[sig.Validator] = true
+			signed += vals[i].Power
+		}
+	}
+	return signed, total
+}
+
+func decodeIBCHeader(raw []byte) (*block.Header, error) {
+	var h block.Header
+	if err := h.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIBCInvalidHeader, err)
+	}
+	return &h, nil
+}
+
+func ibcConsensusState(h *block.Header) IBCConsensusState {
+	b := block.Block{Header: *h}
+	return IBCConsensusState{Height: h.Height, Hash: b.Hash(), AgentRoot: h.AgentRoot, Timestamp: h.Timestamp}
+}
+
+// CreateIBCClient creates a client of the chain c.ChainID at height,
+// trusting its header and validator set, and returns the client ID. The
+// header must be of that chain, and pins the genesis hash of its network.
+func (s *StateDB) CreateIBCClient(c transaction.IBCCreateClient, height uint64) (string, error) {
+	h, err := decodeIBCHeader(c.Header)
+	if err != nil {
+		return "", err
+	}
+	if h.ChainID != c.ChainID {
+		return "", fmt.Errorf("%w: header of chain %d, client of chain %d", ErrIBCInvalidHeader, h.ChainID, c.ChainID)
+	}
+	vals, err := checkIBCValidators(c.Validators)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ibcClients == nil {
+		s.ibcClients = make(map[string]*IBCClient)
+	}
+	client := &IBCClient{
+		ID:              fmt.Sprintf("client-%d", len(s.ibcClients)),
+		ChainID:         c.ChainID,
+		GenesisHash:     h.GenesisHash,
+		Validators:      vals,
+		LatestHeight:    h.Height,
+		ConsensusStates: []IBCConsensusState{ibcConsensusState(h)},
+		CreatedAt:       height,
+	}
+	s.ibcClients[client.ID] = client
+	return client.ID, nil
+}
+
+// UpdateIBCClient adds the header of u to its client. The header must be
+// of the network of the client, newer than its latest header and signed by
+// more than two thirds of the voting power of the validator set, or of
+// u.Validators if it replaces it, more than a third of the current set
+// signing as well.
+func (s *StateDB) UpdateIBCClient(u transaction.IBCUpdateClient) error {
+	h, err := decodeIBCHeader(u.Header)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	client, ok := s.ibcClients[u.ClientID]
+	if !ok {
+		return ErrIBCClientNotFound
+	}
+	if h.ChainID != client.ChainID || h.GenesisHash != client.GenesisHash {
+		return fmt.Errorf("%w: header of chain %d genesis %x", ErrIBCInvalidHeader, h.ChainID, h.GenesisHash)
+	}
+	if h.Height <= client.LatestHeight {
+		return fmt.Errorf("%w: height %d is not above %d", ErrIBCInvalidHeader, h.Height, client.LatestHeight)
+	}
+	vals := client.Validators
+	if len(u.Validators) > 0 {
+		if vals, err = checkIBCValidators(u.Validators); err != nil {
+			return err
+		}
+	}
+	if !sameIBCValidators(vals, client.Validators) {
+		if signed, total := ibcSignedPower(client.Validators, h, u.Commit); signed*3 <= total {
+			return fmt.Errorf("%w: validator set change signed by %d of %d", ErrIBCInvalidHeader, signed, total)
+		}
+	}
+	if signed, total := ibcSignedPower(vals, h, u.Commit); signed*3 <= total*2 {
+		return fmt.Errorf("%w: signed by %d of %d", ErrIBCInvalidHeader, signed, total)
+	}
+	nc := *client
+	nc.Validators = vals
+	nc.LatestHeight = h.Height
+	states := client.ConsensusStates
+	if len(states) >= MaxIBCConsensusStates {
+		states = states[len(states)-MaxIBCConsensusStates+1:]
+	}
+	nc.ConsensusStates = append(states[:len(states):len(states)], ibcConsensusState(h))
+	s.ibcClients[nc.ID] = &nc
+	return nil
+}
+
+// verifyIBCProof checks that p proves the entry under key of the agent
+// state of the chain tracked by clientID, and decodes its value into v.
+// The caller holds s.mu.
+func (s *StateDB) verifyIBCProof(clientID string, p *transaction.IBCProof, key string, v interface{}) error {
+	client, ok := s.ibcClients[clientID]
+	if !ok {
+		return ErrIBCClientNotFound
+	}
+	if p == nil {
+		return fmt.Errorf("%w: missing", ErrIBCInvalidProof)
+	}
+	for _, cs := range client.ConsensusStates {
+		if cs.Height != p.Height {
+			continue
+		}
+		proof := AgentStateProof{Root: cs.AgentRoot, Key: key, Value: p.Value, Index: p.Index, Total: p.Total, Proof: p.Proof}
+		if !proof.Verify(cs.AgentRoot) {
+			return fmt.Errorf("%w: %s at height %d", ErrIBCInvalidProof, key, p.Height)
+		}
+		if err := json.Unmarshal(p.Value, v); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrIBCInvalidProof, key, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: client %s holds no header at height %d", ErrIBCInvalidProof, clientID, p.Height)
+}
+
+// OpenIBCConnection takes a step of a connection handshake and returns the
+// ID of the connection end.
+func (s *StateDB) OpenIBCConnection(c transaction.IBCConnectionOpen) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var conn IBCConnection
+	switch c.Step {
+	case transaction.IBCStepInit, transaction.IBCStepTry:
+		if _, ok := s.ibcClients[c.ClientID]; !ok {
+			return "", ErrIBCClientNotFound
+		}
+		if c.CounterpartyClientID == "" {
+			return "", fmt.Errorf("%w: counterparty client required", ErrIBCHandshake)
+		}
+		conn = IBCConnection{
+			ID:           fmt.Sprintf("connection-%d", len(s.ibcConnections)),
+			ClientID:     c.ClientID,
+			State:        IBCStateInit,
+			Counterparty: IBCConnectionCounterparty{ClientID: c.CounterpartyClientID},
+		}
+		if c.Step == transaction.IBCStepTry {
+			var remote IBCConnection
+			if err := s.verifyIBCProof(c.ClientID, c.Proof, IBCConnectionKey(c.CounterpartyConnectionID), &remote); err != nil {
+				return "", err
+			}
+			if err := expectIBCConnection(remote, IBCStateInit, c.CounterpartyClientID, c.ClientID, ""); err != nil {
+				return "", err
+			}
+			conn.State = IBCStateTryOpen
+			conn.Counterparty.ConnectionID = c.CounterpartyConnectionID
+		}
+	case transaction.IBCStepAck, transaction.IBCStepConfirm:
+		cur, ok := s.ibcConnections[c.ConnectionID]
+		if !ok {
+			return "", ErrIBCConnectionNotFound
+		}
+		conn = *cur
+		want, remoteState := IBCStateInit, IBCStateTryOpen
+		if c.Step == transaction.IBCStepConfirm {
+			want, remoteState = IBCStateTryOpen, IBCStateOpen
+		} else {
+			conn.Counterparty.ConnectionID = c.CounterpartyConnectionID
+		}
+		if conn.State != want {
+			return "", fmt.Errorf("%w: connection %s is %s", ErrIBCHandshake, conn.ID, conn.State)
+		}
+		var remote IBCConnection
+		if err := s.verifyIBCProof(conn.ClientID, c.Proof, IBCConnectionKey(conn.Counterparty.ConnectionID), &remote); err != nil {
+			return "", err
+		}
+		if err := expectIBCConnection(remote, remoteState, conn.Counterparty.ClientID, conn.ClientID, conn.ID); err != nil {
+			return "", err
+		}
+		conn.State = IBCStateOpen
+	default:
+		return "", fmt.Errorf("%w: unknown handshake step %q", transaction.ErrInvalidData, c.Step)
+	}
+	if s.ibcConnections == nil {
+		s.ibcConnections = make(map[string]*IBCConnection)
+	}
+	s.ibcConnections[conn.ID] = &conn
+	return conn.ID, nil
+}
+
+// expectIBCConnection checks the connection end of the counterparty
+// against what the handshake expects of it.
+func expectIBCConnection(remote IBCConnection, state, clientID, cpClientID, cpConnectionID string) error {
+	if remote.State != state || remote.ClientID != clientID || remote.Counterparty.ClientID != cpClientID ||
+		(cpConnectionID != "" && remote.Counterparty.ConnectionID != cpConnectionID) {
+		return fmt.Errorf("%w: counterparty connection %s is %s between %s and %s", ErrIBCHandshake, remote.ID, remote.State, remote.ClientID, remote.Counterparty.ClientID)
+	}
+	return nil
+}
+
+// OpenIBCChannel takes a step of a channel handshake and returns the ID of
+// the channel end.
+func (s *StateDB) OpenIBCChannel(c transaction.IBCChannelOpen) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ch IBCChannel
+	switch c.Step {
+	case transaction.IBCStepInit, transaction.IBCStepTry:
+		conn, ok := s.ibcConnections[c.ConnectionID]
+		if !ok {
+			return "", ErrIBCConnectionNotFound
+		}
+		if conn.State != IBCStateOpen {
+			return "", fmt.Errorf("%w: connection %s is %s", ErrIBCHandshake, conn.ID, conn.State)
+		}
+		ch = IBCChannel{
+			Port:         c.Port,
+			ID:           fmt.Sprintf("channel-%d", len(s.ibcChannels)),
+			ConnectionID: conn.ID,
+			State:        IBCStateInit,
+			Counterparty: IBCChannelCounterparty{Port: c.Port},
+			NextSequence: 1,
+			Escrowed:     new(big.Int),
+		}
+		if c.Step == transaction.IBCStepTry {
+			var remote IBCChannel
+			if err := s.verifyIBCProof(conn.ClientID, c.Proof, IBCChannelKey(c.Port, c.CounterpartyChannelID), &remote); err != nil {
+				return "", err
+			}
+			if err := expectIBCChannel(remote, IBCStateInit, conn.Counterparty.ConnectionID, ""); err != nil {
+				return "", err
+			}
+			ch.State = IBCStateTryOpen
+			ch.Counterparty.ChannelID = c.CounterpartyChannelID
+		}
+	case transaction.IBCStepAck, transaction.IBCStepConfirm:
+		cur, ok := s.ibcChannels[ibcChannelKey(c.Port, c.ChannelID)]
+		if !ok {
+			return "", ErrIBCChannelNotFound
+		}
+		ch = *cur
+		want, remoteState := IBCStateInit, IBCStateTryOpen
+		if c.Step == transaction.IBCStepConfirm {
+			want, remoteState = IBCStateTryOpen, IBCStateOpen
+		} else {
+			ch.Counterparty.ChannelID = c.CounterpartyChannelID
+		}
+		if ch.State != want {
+			return "", fmt.Errorf("%w: channel %s is %s", ErrIBCHandshake, ch.ID, ch.State)
+		}
+		conn := s.ibcConnections[ch.ConnectionID]
+		var remote IBCChannel
+		if err := s.verifyIBCProof(conn.ClientID, c.Proof, IBCChannelKey(ch.Port, ch.Counterparty.ChannelID), &remote); err != nil {
+			return "", err
+		}
+		if err := expectIBCChannel(remote, remoteState, conn.Counterparty.ConnectionID, ch.ID); err != nil {
+			return "", err
+		}
+		ch.State = IBCStateOpen
+	default:
+		return "", fmt.Errorf("%w: unknown handshake step %q", transaction.ErrInvalidData, c.Step)
+	}
+	if s.ibcChannels == nil {
+		s.ibcChannels = make(map[string]*IBCChannel)
+	}
+	s.ibcChannels[ibcChannelKey(ch.Port, ch.ID)] = &ch
+	return ch.ID, nil
+}
+
+// expectIBCChannel checks the channel end of the counterparty against what
+// the handshake expects of it.
+func expectIBCChannel(remote IBCChannel, state, connectionID, cpChannelID string) error {
+	if remote.State != state || remote.ConnectionID != connectionID || remote.Counterparty.Port != remote.Port ||
+		(cpChannelID != "" && remote.Counterparty.ChannelID != cpChannelID) {
+		return fmt.Errorf("%w: counterparty channel %s is %s over %s", ErrIBCHandshake, remote.ID, remote.State, remote.ConnectionID)
+	}
+	return nil
+}
+
+// openChannel returns the open channel end port/id. The caller holds s.mu.
+func (s *StateDB) openChannel(port, id string) (*IBCChannel, error) {
+	ch, ok := s.ibcChannels[ibcChannelKey(port, id)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s/%s", ErrIBCChannelNotFound, port, id)
+	}
+	if ch.State != IBCStateOpen {
+		return nil, fmt.Errorf("%w: channel %s is %s", ErrIBCHandshake, id, ch.State)
+	}
+	return ch, nil
+}
+
+// sendPacket commits to a packet of data sent over ch, a record not yet in
+// s.ibcChannels, and returns it. The caller holds s.mu.
+func (s *StateDB) sendPacket(ch *IBCChannel, data interface{}, timeout uint64) *transaction.IBCPacket {
+	raw, _ := json.Marshal(data)
+	p := &transaction.IBCPacket{
+		Sequence:      ch.NextSequence,
+		SourcePort:    ch.Port,
+		SourceChannel: ch.ID,
+		DestPort:      ch.Counterparty.Port,
+		DestChannel:   ch.Counterparty.ChannelID,
+		Data:          raw,
+		TimeoutHeight: timeout,
+	}
+	ch.NextSequence++
+	s.ibcChannels[ibcChannelKey(ch.Port, ch.ID)] = ch
+	if s.ibcCommitments == nil {
+		s.ibcCommitments = make(map[string]*transaction.IBCPacket)
+	}
+	s.ibcCommitments[ibcPacketKey(p.SourcePort, p.SourceChannel, p.Sequence)] = p
+	return p
+}
+
+// SendIBCTransfer sends t.Amount of t.Denom of sender over a channel of the
+// transfer port, escrowing ZIO and burning vouchers, and returns the
+// packet.
+func (s *StateDB) SendIBCTransfer(t transaction.IBCTransfer, sender string) (*transaction.IBCPacket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, err := s.openChannel(transaction.IBCPortTransfer, t.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	ch := *cur
+	if t.Denom == transaction.IBCNativeDenom {
+		if err := s.escrow(sender, t.Amount); err != nil {
+			return nil, err
+		}
+		ch.Escrowed = new(big.Int).Add(ch.Escrowed, t.Amount)
+	} else if err := s.burnVoucher(t.Denom, sender, t.Amount); err != nil {
+		return nil, err
+	}
+	data := transaction.IBCTransferData{Denom: t.Denom, Amount: t.Amount.String(), Sender: sender, Receiver: t.Receiver}
+	return s.sendPacket(&ch, data, t.TimeoutHeight), nil
+}
+
+// SendIBCMessage sends msg over a channel of the agent port and advances
+// the message nonce of the sending agent. Callers check the sender with
+// AuthorizeMessage first.
+func (s *StateDB) SendIBCMessage(m transaction.IBCSendMessage) (*transaction.IBCPacket, error) {
+	if m.Message.Type != transaction.MsgTask && m.Message.Type != transaction.MsgResult {
+		return nil, fmt.Errorf("%w: only TASK and RESULT messages cross chains", transaction.ErrInvalidData)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, err := s.openChannel(transaction.IBCPortAgent, m.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkActive(m.Message.From); err != nil {
+		return nil, err
+	}
+	ch := *cur
+	rec := s.agents[m.Message.From]
+	rec.MessageCount++
+	rec.MessageNonce++
+	return s.sendPacket(&ch, m.Message, m.TimeoutHeight), nil
+}
+
+// RecvIBCPacket receives the packet relayed in r at height, proven against
+// the client of its channel, and returns its acknowledgement. The
+// application failing, or the packet having timed out, is acknowledged
+// with an error rather than failing the transaction.
+func (s *StateDB) RecvIBCPacket(r transaction.IBCRecvPacket, height uint64) (transaction.IBCAcknowledgement, error) {
+	var ack transaction.IBCAcknowledgement
+	p := r.Packet
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ch, err := s.openChannel(p.DestPort, p.DestChannel)
+	if err != nil {
+		return ack, err
+	}
+	if p.SourcePort != ch.Counterparty.Port || p.SourceChannel != ch.Counterparty.ChannelID {
+		return ack, fmt.Errorf("%w: not from the counterparty of %s", ErrIBCInvalidPacket, ch.ID)
+	}
+	key := ibcPacketKey(p.DestPort, p.DestChannel, p.Sequence)
+	if _, ok := s.ibcAcks[key]; ok {
+		return ack, fmt.Errorf("%w: packet %d already received", ErrIBCInvalidPacket, p.Sequence)
+	}
+	var commitment string
+	if err := s.verifyIBCProof(s.ibcConnections[ch.ConnectionID].ClientID, &r.Proof, IBCCommitmentKey(p.SourcePort, p.SourceChannel, p.Sequence), &commitment); err != nil {
+		return ack, err
+	}
+	if commitment != IBCPacketCommitment(p) {
+		return ack, fmt.Errorf("%w: packet does not match its commitment", ErrIBCInvalidPacket)
+	}
+	switch {
+	case p.TimeoutHeight != 0 && height >= p.TimeoutHeight:
+		ack.Error = fmt.Sprintf("packet timed out at height %d", p.TimeoutHeight)
+	case p.DestPort == transaction.IBCPortTransfer:
+		err = s.recvTransfer(ch, p)
+	case p.DestPort == transaction.IBCPortAgent:
+		ack.Result, err = s.recvMessage(p, height)
+	}
+	if err != nil {
+		ack = transaction.IBCAcknowledgement{Error: err.Error()}
+	}
+	if s.ibcAcks == nil {
+		s.ibcAcks = make(map[string]*IBCPacketAck)
+	}
+	s.ibcAcks[key] = &IBCPacketAck{Packet: p, Acknowledgement: ack, Height: height}
+	return ack, nil
+}
+
+// recvTransfer credits the tokens of the transfer packet p received over
+// ch: ZIO and vouchers returning over the channel they left by are
+// released or minted back; others are minted as vouchers prefixed with
+// the receiving end. Nothing changes if it fails. The caller holds s.mu.
+func (s *StateDB) recvTransfer(ch *IBCChannel, p transaction.IBCPacket) error {
+	var data transaction.IBCTransferData
+	if err := json.Unmarshal(p.Data, &data); err != nil {
+		return fmt.Errorf("%w: %v", ErrIBCInvalidPacket, err)
+	}
+	amount, ok := new(big.Int).SetString(data.Amount, 10)
+	if !ok || amount.Sign() <= 0 || data.Denom == "" {
+		return fmt.Errorf("%w: invalid amount or denom", ErrIBCInvalidPacket)
+	}
+	receiver, err := common.ParseAddress(data.Receiver)
+	if err != nil {
+		return err
+	}
+	prefix := p.SourcePort + "/" + p.SourceChannel + "/"
+	denom, returning := strings.CutPrefix(data.Denom, prefix)
+	switch {
+	case returning && denom == transaction.IBCNativeDenom:
+		if ch.Escrowed.Cmp(amount) < 0 {
+			return fmt.Errorf("%w: %s exceeds the escrow of %s", ErrIBCInvalidPacket, amount, ch.ID)
+		}
+		nc := *ch
+		nc.Escrowed = new(big.Int).Sub(ch.Escrowed, amount)
+		s.ibcChannels[ibcChannelKey(nc.Port, nc.ID)] = &nc
+		s.release(receiver.String(), amount)
+	case returning:
+		s.mintVoucher(denom, receiver.String(), amount)
+	default:
+		s.mintVoucher(p.DestPort+"/"+p.DestChannel+"/"+data.Denom, receiver.String(), amount)
+	}
+	return nil
+}
+
+// recvMessage delivers the agent message packet p to its recipient, which
+// must be an active agent, and returns the position of the message in the
+// log. The caller holds s.mu.
+func (s *StateDB) recvMessage(p transaction.IBCPacket, height uint64) (json.RawMessage, error) {
+	var msg transaction.AgentMessage
+	if err := json.Unmarshal(p.Data, &msg); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIBCInvalidPacket, err)
+	}
+	if err := s.checkActive(msg.To); err != nil {
+		return nil, err
+	}
+	msg.From = IBCRemoteAgent(p.DestChannel, msg.From)
+	seq := uint64(len(s.messages))
+	if err := s.storeMessage(msg, height); err != nil {
+		return nil, err
+	}
+	return json.RawMessage(strconv.FormatUint(seq, 10)), nil
+}
+
+// AcknowledgeIBCPacket processes the acknowledgement relayed in a, proven
+// against the client of the channel the packet was sent over, refunding a
+// transfer acknowledged with an error, and deletes the commitment to the
+// packet.
+func (s *StateDB) AcknowledgeIBCPacket(a transaction.IBCAcknowledgePacket) error {
+	p := a.Packet
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ch, err := s.openChannel(p.SourcePort, p.SourceChannel)
+	if err != nil {
+		return err
+	}
+	key := ibcPacketKey(p.SourcePort, p.SourceChannel, p.Sequence)
+	sent, ok := s.ibcCommitments[key]
+	if !ok || IBCPacketCommitment(*sent) != IBCPacketCommitment(p) {
+		return fmt.Errorf("%w: packet %d is not awaiting acknowledgement", ErrIBCInvalidPacket, p.Sequence)
+	}
+	var commitment string
+	if err := s.verifyIBCProof(s.ibcConnections[ch.ConnectionID].ClientID, &a.Proof, IBCAckKey(p.DestPort, p.DestChannel, p.Sequence), &commitment); err != nil {
+		return err
+	}
+	if commitment != IBCAckCommitment(a.Acknowledgement) {
+		return fmt.Errorf("%w: acknowledgement does not match its commitment", ErrIBCInvalidPacket)
+	}
+	delete(s.ibcCommitments, key)
+	if a.Acknowledgement.Error != "" && p.SourcePort == transaction.IBCPortTransfer {
+		s.refundTransfer(ch, *sent)
+	}
+	return nil
+}
+
+// refundTransfer returns the tokens of the transfer packet p sent over ch
+// to its sender. The caller holds s.mu.
+func (s *StateDB) refundTransfer(ch *IBCChannel, p transaction.IBCPacket) {
+	var data transaction.IBCTransferData
+	json.Unmarshal(p.Data, &data)
+	amount, _ := new(big.Int).SetString(data.Amount, 10)
+	if data.Denom != transaction.IBCNativeDenom {
+		s.mintVoucher(data.Denom, data.Sender, amount)
+		return
+	}
+	nc := *ch
+	nc.Escrowed = new(big.Int).Sub(ch.Escrowed, amount)
+	s.ibcChannels[ibcChannelKey(nc.Port, nc.ID)] = &nc
+	s.release(data.Sender, amount)
+}
+
+// mintVoucher credits amount of the voucher denom to addr. The caller
+// holds s.mu.
+func (s *StateDB) mintVoucher(denom, addr string, amount *big.Int) {
+	if s.ibcVouchers == nil {
+		s.ibcVouchers = make(map[string]*big.Int)
+	}
+	key := ibcVoucherKey(denom, addr)
+	bal := new(big.Int).Set(amount)
+	if cur, ok := s.ibcVouchers[key]; ok {
+		bal.Add(bal, cur)
+	}
+	s.ibcVouchers[key] = bal
+}
+
+// burnVoucher debits amount of the voucher denom from addr. The caller
+// holds s.mu.
+func (s *StateDB) burnVoucher(denom, addr string, amount *big.Int) error {
+	key := ibcVoucherKey(denom, addr)
+	cur, ok := s.ibcVouchers[key]
+	if !ok || cur.Cmp(amount) < 0 {
+		return ErrInsufficientBalance
+	}
+	if cur.Cmp(amount) == 0 {
+		delete(s.ibcVouchers, key)
+		return nil
+	}
+	s.ibcVouchers[key] = new(big.Int).Sub(cur, amount)
+	return nil
+}
+
+// GetIBCClient returns client id.
+func (s *StateDB) GetIBCClient(id string) (*IBCClient, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.ibcClients[id]
+	if !ok {
+		return nil, ErrIBCClientNotFound
+	}
+	return c.Copy(), nil
+}
+
+// GetIBCConnection returns connection end id.
+func (s *StateDB) GetIBCConnection(id string) (*IBCConnection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.ibcConnections[id]
+	if !ok {
+		return nil, ErrIBCConnectionNotFound
+	}
+	return c.Copy(), nil
+}
+
+// GetIBCChannel returns the channel end id of port.
+func (s *StateDB) GetIBCChannel(port, id string) (*IBCChannel, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ch, ok := s.ibcChannels[ibcChannelKey(port, id)]
+	if !ok {
+		return nil, ErrIBCChannelNotFound
+	}
+	return ch.Copy(), nil
+}
+
+// IBCChannels returns the channel ends, ordered by port and ID.
+func (s *StateDB) IBCChannels() []*IBCChannel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*IBCChannel, 0, len(s.ibcChannels))
+	for _, ch := range s.ibcChannels {
+		out = append(out, ch.Copy())
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return ibcChannelKey(out[i].Port, out[i].ID) < ibcChannelKey(out[j].Port, out[j].ID)
+	})
+	return out
+}
+
+// IBCPendingPackets returns the packets sent over channel id of port and
+// not yet acknowledged, by sequence.
+func (s *StateDB) IBCPendingPackets(port, id string) []*transaction.IBCPacket {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	prefix := ibcChannelKey(port, id) + "/"
+	var out []*transaction.IBCPacket
+	for key, p := range s.ibcCommitments {
+		if strings.HasPrefix(key, prefix) {
+			out = append(out, copyPacket(p))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Sequence < out[j].Sequence })
+	return out
+}
+
+// IBCAcknowledgements returns the packets received over channel id of
+// port with their acknowledgements, by sequence, starting at sequence
+// from.
+func (s *StateDB) IBCAcknowledgements(port, id string, from uint64) []*IBCPacketAck {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	prefix := ibcChannelKey(port, id) + "/"
+	var out []*IBCPacketAck
+	for key, a := range s.ibcAcks {
+		if strings.HasPrefix(key, prefix) && a.Packet.Sequence >= from {
+			out = append(out, a.Copy())
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Packet.Sequence < out[j].Packet.Sequence })
+	return out
+}
+
+// IBCVouchers returns the voucher balances of addr by denomination.
+func (s *StateDB) IBCVouchers(addr string) map[string]*big.Int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]*big.Int)
+	for key, bal := range s.ibcVouchers {
+		if denom, owner, _ := strings.Cut(key, "\x00"); owner == addr {
+			out[denom] = new(big.Int).Set(bal)
+		}
+	}
+	return out
+}
+
+// copyIBC returns copies of the IBC maps. Their records are replaced
+// rather than modified, so they are shared. The caller holds s.mu.
+func (s *StateDB) copyIBC() (clients map[string]*IBCClient, conns map[string]*IBCConnection, chans map[string]*IBCChannel, commitments map[string]*transaction.IBCPacket, acks map[string]*IBCPacketAck, vouchers map[string]*big.Int) {
+	if len(s.ibcClients) > 0 {
+		clients = make(map[string]*IBCClient, len(s.ibcClients))
+		for k, v := range s.ibcClients {
+			clients[k] = v
+		}
+	}
+	if len(s.ibcConnections) > 0 {
+		conns = make(map[string]*IBCConnection, len(s.ibcConnections))
+		for k, v := range s.ibcConnections {
+			conns[k] = v
+		}
+	}
+	if len(s.ibcChannels) > 0 {
+		chans = make(map[string]*IBCChannel, len(s.ibcChannels))
+		for k, v := range s.ibcChannels {
+			chans[k] = v
+		}
+	}
+	if len(s.ibcCommitments) > 0 {
+		commitments = make(map[string]*transaction.IBCPacket, len(s.ibcCommitments))
+		for k, v := range s.ibcCommitments {
+			commitments[k] = v
+		}
+	}
+	if len(s.ibcAcks) > 0 {
+		acks = make(map[string]*IBCPacketAck, len(s.ibcAcks))
+		for k, v := range s.ibcAcks {
+			acks[k] = v
+		}
+	}
+	if len(s.ibcVouchers) > 0 {
+		vouchers = make(map[string]*big.Int, len(s.ibcVouchers))
+		for k, v := range s.ibcVouchers {
+			vouchers[k] = v
+		}
+	}
+	return
+}
+
+// sameIBCValidators reports whether a and b, both ordered by address, are
+// the same validator set.
+func sameIBCValidators(a, b []transaction.IBCValidator) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Address != b[i].Address || a[i].Power != b[i].Power || !bytes.Equal(a[i].PublicKey, b[i].PublicKey) {
+			return false
+		}
+	}
+	return true
+}
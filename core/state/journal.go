@@ -0,0 +1,206 @@
+package state
+
+import (
+	"math/big"
+
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// Writes to the state can be undone back to a checkpoint, so that a
+// transaction failing partway leaves nothing of its execution behind.
+// While a checkpoint is open, the writers a transaction can reach before it
+// fails record how to undo their change in a journal: an account is saved
+// the first time it changes and a storage slot each time it is written, and
+// RegisterAgent, StoreMessage, Delegate and Revoke record how to take back
+// what they added. Every other writer checks all it needs before changing
+// anything, and is only ever a transaction's last write, so a failed
+// transaction calling one of them has nothing of it to undo.
+
+// journal holds the undo entries recorded since the outermost open
+// checkpoint, oldest first.
+type journal struct {
+	entries     []func()
+	checkpoints []int          // number of entries when each open checkpoint was taken
+	saved       map[string]int // entry saving each account changed since a checkpoint
+}
+
+func (j *journal) active() bool {
+	return len(j.checkpoints) > 0
+}
+
+// record appends undo if a checkpoint is open.
+func (j *journal) record(undo func()) {
+	if j.active() {
+		j.entries = append(j.entries, undo)
+	}
+}
+
+// Checkpoint opens a checkpoint and returns its ID for RevertToCheckpoint
+// or DiscardCheckpoint. Checkpoints nest; the innermost must be closed
+// first.
+func (s *StateDB) Checkpoint() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.journal.checkpoints = append(s.journal.checkpoints, len(s.journal.entries))
+	return len(s.journal.checkpoints) - 1
+}
+
+// RevertToCheckpoint undoes the writes made since checkpoint id was taken
+// and closes it.
+func (s *StateDB) RevertToCheckpoint(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j := &s.journal
+	start := j.checkpoints[id]
+	for i := len(j.entries) - 1; i >= start; i-- {
+		j.entries[i]()
+		j.entries[i] = nil
+	}
+	j.entries = j.entries[:start]
+	for addr, i := range j.saved {
+		if i >= start {
+			delete(j.saved, addr)
+		}
+	}
+	j.close(id)
+}
+
+// DiscardCheckpoint closes checkpoint id, keeping the writes made since it
+// was taken.
+func (s *StateDB) DiscardCheckpoint(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.journal.close(id)
+}
+
+func (j *journal) close(id int) {
+	j.checkpoints = j.checkpoints[:id]
+	if len(j.checkpoints) == 0 {
+		j.entries, j.saved = nil, nil
+	}
+}
+
+// saveAccount records how to restore the account of addr, or its absence,
+// before its first change since the innermost checkpoint. The caller holds
+// s.mu.
+func (s *StateDB) saveAccount(addr string) {
+	j := &s.journal
+	if !j.active() {
+		return
+	}
+	if i, ok := j.saved[addr]; ok && i >= j.checkpoints[len(j.checkpoints)-1] {
+		return
+	}
+	if j.saved == nil {
+		j.saved = make(map[string]int)
+	}
+	j.saved[addr] = len(j.entries)
+	acc, ok := s.accounts[addr]
+	if !ok {
+		j.record(func() { delete(s.accounts, addr) })
+		return
+	}
+	// Storage is shared with the live account: SetStorage saves each slot
+	// it writes, and Update gives the account a copy instead of changing it.
+	prev := *acc
+	prev.Balance = new(big.Int).Set(acc.Balance)
+	j.record(func() {
+		*acc = prev
+		s.accounts[addr] = acc
+	})
+}
+
+// saveSlot records how to restore the storage slot key of acc. The caller
+// holds s.mu.
+func (s *StateDB) saveSlot(acc *Account, key string) {
+	if !s.journal.active() {
+		return
+	}
+	// Update may swap the map before the undo runs; restore the one saved
+	// from, which the account gets back from saveAccount.
+	storage := acc.Storage
+	prev, ok := storage[key]
+	s.journal.record(func() {
+		if ok {
+			storage[key] = prev
+		} else {
+			delete(storage, key)
+		}
+	})
+}
+
+// saveMessage records how to take back the delivery of msg: its log entry,
+// the mailbox positions, the sender's counters and reputation and the open
+// tasks it changes. The caller holds s.mu.
+func (s *StateDB) saveMessage(msg transaction.AgentMessage) {
+	if !s.journal.active() {
+		return
+	}
+	n := len(s.messages)
+	boxes := make(map[string]*Mailbox, 2)
+	for _, id := range []string{msg.From, msg.To} {
+		if mb, ok := s.mailboxes[id]; ok {
+			prev := *mb
+			boxes[id] = &prev
+		} else {
+			boxes[id] = nil
+		}
+	}
+	var (
+		rec     *AgentRecord
+		prevRec AgentRecord
+	)
+	if r, ok := s.agents[msg.From]; ok {
+		rec, prevRec = r, *r
+	}
+	tasks := make(map[string]*uint64, 2)
+	for _, key := range []string{taskKey(msg.From, msg.To), taskKey(msg.To, msg.From)} {
+		if v, ok := s.openTasks[key]; ok {
+			tasks[key] = &v
+		} else {
+			tasks[key] = nil
+		}
+	}
+	s.journal.record(func() {
+		s.messages = s.messages[:n]
+		for id, prev := range boxes {
+			if prev == nil {
+				delete(s.mailboxes, id)
+			} else {
+				*s.mailboxes[id] = *prev
+			}
+		}
+		if rec != nil {
+			*rec = prevRec
+		}
+		for key, prev := range tasks {
+			if prev == nil {
+				delete(s.openTasks, key)
+			} else {
+				s.openTasks[key] = *prev
+			}
+		}
+	})
+}
+
+// saveDelegation records how to restore the delegation under key received
+// by to, or its absence. The caller holds s.mu.
+func (s *StateDB) saveDelegation(to, key string) {
+	if !s.journal.active() {
+		return
+	}
+	prev, ok := s.delegations[to][key]
+	s.journal.record(func() {
+		if ok {
+			if s.delegations[to] == nil {
+				s.delegations[to] = make(map[string]*DelegationRecord)
+			}
+			s.delegations[to][key] = prev
+			return
+		}
+		delete(s.delegations[to], key)
+		if len(s.delegations[to]) == 0 {
+			delete(s.delegations, to)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+const (
+	journalAlice = "0x1000000000000000000000000000000000000001"
+	journalBob   = "0x2000000000000000000000000000000000000002"
+)
+
+func journalState(t *testing.T) *StateDB {
+	t.Helper()
+	s := NewStateDB()
+	s.SetBalance(journalAlice, big.NewInt(1000))
+	s.SetStorage(journalAlice, []byte("k"), []byte("v"))
+	for _, id := range []string{"did:agc:a", "did:agc:b"} {
+		did := transaction.AgentDID{
+			ID:           id,
+			Controller:   journalAlice,
+			Capabilities: []transaction.Capability{{Name: "summarize", Version: "1"}},
+		}
+		if err := s.RegisterAgent(did, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func TestRevertToCheckpoint(t *testing.T) {
+	s := journalState(t)
+	root := s.StateRoot()
+
+	cp := s.Checkpoint()
+	if err := s.Transfer(journalAlice, journalBob, big.NewInt(400)); err != nil {
+		t.Fatal(err)
+	}
+	s.IncrementNonce(journalBob)
+	s.SetCode(journalBob, []byte{0x01})
+	s.SetStorage(journalAlice, []byte("k"), []byte("w"))
+	s.SetStorage(journalAlice, []byte("k2"), []byte("x"))
+	s.SetStorage(journalBob, []byte("k"), []byte("y"))
+	if err := s.Update(journalAlice, func(acc *Account) error {
+		acc.Nonce = 7
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RegisterAgent(transaction.AgentDID{ID: "did:agc:c", Controller: journalBob}, 2); err != nil {
+		t.Fatal(err)
+	}
+	msg := transaction.AgentMessage{From: "did:agc:a", To: "did:agc:b", Type: transaction.MsgTask}
+	if err := s.StoreMessage(msg, 2); err != nil {
+		t.Fatal(err)
+	}
+	d := transaction.Delegation{From: "did:agc:a", To: "did:agc:b", Capability: transaction.Capability{Name: "summarize", Version: "1"}}
+	if err := s.Delegate(d, 2); err != nil {
+		t.Fatal(err)
+	}
+	s.SelfDestruct(journalAlice, journalBob)
+	if s.StateRoot() == root {
+		t.Fatal("writes did not change the state root")
+	}
+
+	s.RevertToCheckpoint(cp)
+	if got := s.StateRoot(); got != root {
+		t.Fatalf("state root after revert = %x, want %x", got, root)
+	}
+	if got := s.GetBalance(journalAlice); got.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("balance = %v, want 1000", got)
+	}
+	if got := s.GetStorage(journalAlice, []byte("k")); string(got) != "v" {
+		t.Errorf("storage = %q, want %q", got, "v")
+	}
+	if _, err := s.GetAgent("did:agc:c"); err == nil {
+		t.Error("agent registered after the checkpoint is still present")
+	}
+	if _, _, total := s.AgentsByController(journalBob, "", 0); total != 0 {
+		t.Errorf("controller index holds %d agents, want 0", total)
+	}
+	if _, _, total := s.ListAgents("", 0); total != 2 {
+		t.Errorf("agent index holds %d agents, want 2", total)
+	}
+	if rec, _ := s.GetAgent("did:agc:a"); rec.MessageNonce != 0 || rec.MessageCount != 0 {
+		t.Errorf("message counters = %d, %d, want 0, 0", rec.MessageNonce, rec.MessageCount)
+	}
+	if got := s.Delegations("did:agc:b"); len(got) != 0 {
+		t.Errorf("delegations = %v, want none", got)
+	}
+}
+
+func TestNestedCheckpoints(t *testing.T) {
+	s := journalState(t)
+	outer := s.Checkpoint()
+	if err := s.Transfer(journalAlice, journalBob, big.NewInt(100)); err != nil {
+		t.Fatal(err)
+	}
+	root := s.StateRoot()
+
+	inner := s.Checkpoint()
+	if err := s.Transfer(journalAlice, journalBob, big.NewInt(200)); err != nil {
+		t.Fatal(err)
+	}
+	s.RevertToCheckpoint(inner)
+	if got := s.StateRoot(); got != root {
+		t.Fatalf("state root after inner revert = %x, want %x", got, root)
+	}
+
+	inner = s.Checkpoint()
+	if err := s.Transfer(journalAlice, journalBob, big.NewInt(300)); err != nil {
+		t.Fatal(err)
+	}
+	s.DiscardCheckpoint(inner)
+	s.DiscardCheckpoint(outer)
+	if got := s.GetBalance(journalBob); got.Cmp(big.NewInt(400)) != 0 {
+		t.Errorf("balance = %v, want 400", got)
+	}
+	if len(s.journal.entries) != 0 || s.journal.saved != nil {
+		t.Error("journal not cleared after the outermost checkpoint closed")
+	}
+}
@@ -0,0 +1,68 @@
+package state
+
+import (
+	"github.com/zionlayer/zionlayer/core/common"
+)
+
+// Agent lifecycle actions recorded in AgentRecord.Lifecycle.
+const (
+	LifecycleRegistered  = "registered"
+	LifecycleDeactivated = "deactivated"
+	LifecycleReactivated = "reactivated"
+)
+
+// LifecycleEvent is a change of an agent's status.
+type LifecycleEvent struct {
+	Action string `json:"action"`
+	Height uint64 `json:"height"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// SetAgentActive deactivates or reactivates the agent id on behalf of
+// sender, which must be its controller, and records the change with reason
+// in the agent's lifecycle. A deactivated agent can neither send nor
+// receive messages until it is reactivated.
+func (s *StateDB) SetAgentActive(id, sender string, active bool, height uint64, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.agents[id]
+	if !ok {
+		return ErrAgentNotFound
+	}
+	if !sameAddress(rec.DID.Controller, sender) {
+		return ErrNotAgentController
+	}
+	if rec.Active == active {
+		if active {
+			return ErrAgentActive
+		}
+		return ErrAgentInactive
+	}
+	ev := LifecycleEvent{Action: LifecycleDeactivated, Height: height, Reason: reason}
+	if active {
+		ev.Action = LifecycleReactivated
+	}
+	// Copy on append: records copied by Copy share the slice.
+	rec.Lifecycle = append(rec.Lifecycle[:len(rec.Lifecycle):len(rec.Lifecycle)], ev)
+	rec.Active = active
+	return nil
+}
+
+// AgentActive reports whether id is a registered agent that has not been
+// deactivated.
+func (s *StateDB) AgentActive(id string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rec, ok := s.agents[id]
+	return ok && rec.Active
+}
+
+// sameAddress compares two addresses regardless of checksum case.
+func sameAddress(a, b string) bool {
+	x, err := common.ParseAddress(a)
+	if err != nil {
+		return a == b
+	}
+	y, err := common.ParseAddress(b)
+	return err == nil && x == y
+}
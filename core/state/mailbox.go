@@ -0,0 +1,114 @@
+package state
+
+import (
+	"sort"
+
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// Messages are stored once, in the order they were delivered, in the
+// message log. Each agent has a mailbox indexing the positions of the
+// messages addressed to it (its inbox) and sent by it (its outbox), so
+// that listing an agent's messages does not scan the whole log. Positions
+// are appended in log order, so a mailbox can be paged through by binary
+// search from any position.
+
+// Mailbox holds the message log positions of the messages addressed to an
+// agent and sent by it, oldest first.
+type Mailbox struct {
+	Inbox  []uint64 `json:"inbox"`
+	Outbox []uint64 `json:"outbox"`
+}
+
+// MessageDirection selects the messages sent by an agent, addressed to it,
+// or both.
+type MessageDirection int
+
+const (
+	MessagesIn MessageDirection = 1 << iota
+	MessagesOut
+	MessagesAll = MessagesIn | MessagesOut
+)
+
+// indexMessage files the message at position seq of the log in the
+// mailboxes of its sender and recipient. The caller holds s.mu.
+func (s *StateDB) indexMessage(msg transaction.AgentMessage, seq uint64) {
+	if s.mailboxes == nil {
+		s.mailboxes = make(map[string]*Mailbox)
+	}
+	for _, id := range []string{msg.From, msg.To} {
+		if s.mailboxes[id] == nil {
+			s.mailboxes[id] = &Mailbox{}
+		}
+	}
+	s.mailboxes[msg.From].Outbox = append(s.mailboxes[msg.From].Outbox, seq)
+	s.mailboxes[msg.To].Inbox = append(s.mailboxes[msg.To].Inbox, seq)
+}
+
+// AgentMessages returns up to limit messages of did in direction dir,
+// oldest first, starting at position start of the message log. Each
+// message is paired with its position. next is the position to resume
+// from, or 0 when the log is exhausted; total counts every message of did
+// in direction dir. The messages are found through did's mailbox, so the
+// cost does not grow with the size of the log.
+func (s *StateDB) AgentMessages(did string, dir MessageDirection, start uint64, limit int) (msgs []transaction.AgentMessage, seqs []uint64, next uint64, total int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	mb := s.mailboxes[did]
+	if mb == nil {
+		return nil, nil, 0, 0
+	}
+	var index []uint64
+	switch dir {
+	case MessagesIn:
+		index = mb.Inbox
+	case MessagesOut:
+		index = mb.Outbox
+	default:
+		index = mergeSeqs(mb.Inbox, mb.Outbox)
+	}
+	i := sort.Search(len(index), func(i int) bool { return index[i] >= start })
+	end := len(index)
+	if limit > 0 && i+limit < end {
+		end = i + limit
+		next = index[end]
+	}
+	for _, seq := range index[i:end] {
+		msgs = append(msgs, s.messages[seq])
+		seqs = append(seqs, seq)
+	}
+	return msgs, seqs, next, len(index)
+}
+
+// mergeSeqs merges two ascending position lists. A position in both, a
+// message an agent sent to itself, appears once.
+func mergeSeqs(a, b []uint64) []uint64 {
+	out := make([]uint64, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case a[0] < b[0]:
+			out, a = append(out, a[0]), a[1:]
+		case b[0] < a[0]:
+			out, b = append(out, b[0]), b[1:]
+		default:
+			out, a, b = append(out, a[0]), a[1:], b[1:]
+		}
+	}
+	out = append(out, a...)
+	return append(out, b...)
+}
+
+// copyMailboxes returns a deep copy of s.mailboxes. The caller holds s.mu.
+func (s *StateDB) copyMailboxes() map[string]*Mailbox {
+	if len(s.mailboxes) == 0 {
+		return nil
+	}
+	cp := make(map[string]*Mailbox, len(s.mailboxes))
+	for id, mb := range s.mailboxes {
+		cp[id] = &Mailbox{
+			Inbox:  append([]uint64(nil), mb.Inbox...),
+			Outbox: append([]uint64(nil), mb.Outbox...),
+		}
+	}
+	return cp
+}
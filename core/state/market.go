@@ -0,0 +1,206 @@
+package state
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// The marketplace holds the service offers of agents: an agent offers to
+// serve calls of a capability it holds, declared or delegated, at a price
+// per call and with a service level. Offers are indexed by capability name
+// so that consumers can match them without scanning every agent. A
+// withdrawn offer stays on record until the agent posts a new one for the
+// same capability.
+
+var (
+	ErrOfferExists   = errors.New("agent already has an active offer for the capability")
+	ErrOfferNotFound = errors.New("offer not found")
+)
+
+// Offer is a service offer on record.
+type Offer struct {
+	transaction.ServiceOffer
+	Active    bool   `json:"active"`
+	PostedAt  uint64 `json:"postedAt"`
+	UpdatedAt uint64 `json:"updatedAt"`
+}
+
+// OfferQuery selects offers. Zero fields match everything except
+// Capability, which is required.
+type OfferQuery struct {
+	Capability    string   `json:"capability"`
+	Version       string   `json:"version,omitempty"`
+	MaxPrice      *big.Int `json:"maxPrice,omitempty"`
+	MaxLatencyMs  uint64   `json:"maxLatencyMs,omitempty"`
+	MinReputation uint64   `json:"minReputation,omitempty"`
+}
+
+func offerKey(agent, capability string) string {
+	return agent + "\x00" + capability
+}
+
+// PostOffer records offer on behalf of sender at height. sender must
+// control the agent, which must be active and hold the capability offered,
+// and the models offered must be registered and not deprecated.
+// An active offer for the same capability must be updated instead.
+func (s *StateDB) PostOffer(offer transaction.ServiceOffer, sender string, height uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkOfferer(offer, sender, height); err != nil {
+		return err
+	}
+	key := offerKey(offer.AgentID, offer.Capability.Name)
+	if prev := s.offers[key]; prev != nil && prev.Active {
+		return ErrOfferExists
+	}
+	if s.offers == nil {
+		s.offers = make(map[string]*Offer)
+	}
+	s.offers[key] = &Offer{ServiceOffer: offer, Active: true, PostedAt: height, UpdatedAt: height}
+	s.offersByCapability.add(offer.Capability.Name, key)
+	return nil
+}
+
+// UpdateOffer replaces the terms of the active offer of the same agent and
+// capability. The checks of PostOffer apply.
+func (s *StateDB) UpdateOffer(offer transaction.ServiceOffer, sender string, height uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkOfferer(offer, sender, height); err != nil {
+		return err
+	}
+	key := offerKey(offer.AgentID, offer.Capability.Name)
+	prev := s.offers[key]
+	if prev == nil || !prev.Active {
+		return ErrOfferNotFound
+	}
+	s.offers[key] = &Offer{ServiceOffer: offer, Active: true, PostedAt: prev.PostedAt, UpdatedAt: height}
+	return nil
+}
+
+// WithdrawOffer withdraws the active offer of agent for capability on
+// behalf of sender, which must control the agent.
+func (s *StateDB) WithdrawOffer(agent, capability, sender string, height uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.agents[agent]
+	if !ok {
+		return ErrAgentNotFound
+	}
+	if !sameAddress(rec.DID.Controller, sender) {
+		return ErrNotAgentController
+	}
+	key := offerKey(agent, capability)
+	prev := s.offers[key]
+	if prev == nil || !prev.Active {
+		return ErrOfferNotFound
+	}
+	o := *prev
+	o.Active, o.UpdatedAt = false, height
+	s.offers[key] = &o
+	return nil
+}
+
+// checkOfferer checks that sender may offer offer at height. The caller
+// holds s.mu.
+func (s *StateDB) checkOfferer(offer transaction.ServiceOffer, sender string, height uint64) error {
+	if err := s.checkActive(offer.AgentID); err != nil {
+		return err
+	}
+	if !sameAddress(s.agents[offer.AgentID].DID.Controller, sender) {
+		return ErrNotAgentController
+	}
+	if _, err := s.delegationChain(offer.AgentID, offer.Capability, height, 0, nil); err != nil {
+		return err
+	}
+	for _, m := range offer.Models {
+		if err := s.checkModel(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetOffer returns the offer of agent for capability, active or withdrawn.
+func (s *StateDB) GetOffer(agent, capability string) (*Offer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	o, ok := s.offers[offerKey(agent, capability)]
+	if !ok {
+		return nil, ErrOfferNotFound
+	}
+	return o.Copy(), nil
+}
+
+// AgentOffers returns the offers of agent, active and withdrawn, ordered
+// by capability name.
+func (s *StateDB) AgentOffers(agent string) []*Offer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*Offer, 0)
+	for key, o := range s.offers {
+		if strings.HasPrefix(key, agent+"\x00") {
+			out = append(out, o.Copy())
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Capability.Name < out[j].Capability.Name })
+	return out
+}
+
+// MatchOffers returns the active offers matching q at height, cheapest
+// first, then fastest, then by agent. An offer matches only while its
+// agent is active and still holds the capability.
+func (s *StateDB) MatchOffers(q OfferQuery, height uint64) []*Offer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []*Offer
+	for _, key := range s.offersByCapability[q.Capability] {
+		o := s.offers[key]
+		switch {
+		case !o.Active:
+			continue
+		case q.Version != "" && o.Capability.Version != q.Version:
+			continue
+		case q.MaxPrice != nil && o.PricePerCall.Cmp(q.MaxPrice) > 0:
+			continue
+		case q.MaxLatencyMs != 0 && o.SLA.LatencyMs > q.MaxLatencyMs:
+			continue
+		}
+		rec, ok := s.agents[o.AgentID]
+		if !ok || !rec.Active || rec.Reputation.Score < q.MinReputation {
+			continue
+		}
+		if _, err := s.delegationChain(o.AgentID, o.Capability, height, 0, nil); err != nil {
+			continue
+		}
+		out = append(out, o.Copy())
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if c := a.PricePerCall.Cmp(b.PricePerCall); c != 0 {
+			return c < 0
+		}
+		if a.SLA.LatencyMs != b.SLA.LatencyMs {
+			return a.SLA.LatencyMs < b.SLA.LatencyMs
+		}
+		return a.AgentID < b.AgentID
+	})
+	return out
+}
+
+// copyOffers returns a copy of s.offers. Offers are replaced rather than
+// modified, so they are shared. The caller holds s.mu.
+func (s *StateDB) copyOffers() map[string]*Offer {
+	if len(s.offers) == 0 {
+		return nil
+	}
+	cp := make(map[string]*Offer, len(s.offers))
+	for k, o := range s.offers {
+		cp[k] = o
+	}
+	return cp
+}
@@ -0,0 +1,166 @@
+package state
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// The model registry maps model hashes to their metadata. Inference
+// receipts and service offers may only reference registered models, and a
+// deprecated model can no longer be referenced by new receipts or offers.
+// Only the owner, the account that registered a model, may update or
+// deprecate it.
+
+var (
+	ErrModelExists     = errors.New("model already registered")
+	ErrModelNotFound   = errors.New("model not found")
+	ErrNotModelOwner   = errors.New("sender is not the model's owner")
+	ErrModelDeprecated = errors.New("model is deprecated")
+)
+
+// Model is a registered model.
+type Model struct {
+	transaction.ModelInfo
+	Owner        string `json:"owner"`
+	RegisteredAt uint64 `json:"registeredAt"`
+	UpdatedAt    uint64 `json:"updatedAt"`
+	Deprecated   bool   `json:"deprecated"`
+	DeprecatedAt uint64 `json:"deprecatedAt,omitempty"`
+}
+
+// RegisterModel registers m, owned by owner, at height.
+func (s *StateDB) RegisterModel(m transaction.ModelInfo, owner string, height uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := receiptKey(m.Hash)
+	if _, ok := s.models[key]; ok {
+		return ErrModelExists
+	}
+	if s.models == nil {
+		s.models = make(map[string]*Model)
+	}
+	s.models[key] = &Model{ModelInfo: m, Owner: owner, RegisteredAt: height, UpdatedAt: height}
+	return nil
+}
+
+// UpdateModel replaces the metadata of the model with the hash of m on
+// behalf of sender, which must own it, at height.
+func (s *StateDB) UpdateModel(m transaction.ModelInfo, sender string, height uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, err := s.ownedModel(m.Hash, sender)
+	if err != nil {
+		return err
+	}
+	nm := *prev
+	nm.ModelInfo, nm.UpdatedAt = m, height
+	s.models[receiptKey(m.Hash)] = &nm
+	return nil
+}
+
+// DeprecateModel deprecates the model hash on behalf of sender, which must
+// own it, at height.
+func (s *StateDB) DeprecateModel(hash []byte, sender string, height uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, err := s.ownedModel(hash, sender)
+	if err != nil {
+		return err
+	}
+	nm := *prev
+	nm.Deprecated, nm.DeprecatedAt, nm.UpdatedAt = true, height, height
+	s.models[receiptKey(hash)] = &nm
+	return nil
+}
+
+// ownedModel returns the active model hash if sender owns it. The caller
+// holds s.mu.
+func (s *StateDB) ownedModel(hash []byte, sender string) (*Model, error) {
+	m, ok := s.models[receiptKey(hash)]
+	if !ok {
+		return nil, ErrModelNotFound
+	}
+	if !sameAddress(m.Owner, sender) {
+		return nil, ErrNotModelOwner
+	}
+	if m.Deprecated {
+		return nil, ErrModelDeprecated
+	}
+	return m, nil
+}
+
+// checkModel checks that the model hash is registered and not deprecated.
+// The caller holds s.mu.
+func (s *StateDB) checkModel(hash []byte) error {
+	m, ok := s.models[receiptKey(hash)]
+	if !ok {
+		return ErrModelNotFound
+	}
+	if m.Deprecated {
+		return ErrModelDeprecated
+	}
+	return nil
+}
+
+// GetModel returns the registered model hash.
+func (s *StateDB) GetModel(hash []byte) (*Model, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	m, ok := s.models[receiptKey(hash)]
+	if !ok {
+		return nil, ErrModelNotFound
+	}
+	return m.Copy(), nil
+}
+
+// ModelQuery selects models. Zero fields match everything.
+type ModelQuery struct {
+	Name       string `json:"name,omitempty"`
+	Owner      string `json:"owner,omitempty"`
+	Deprecated bool   `json:"deprecated,omitempty"` // include deprecated models
+}
+
+// Models returns the registered models matching q, ordered by name, then
+// version, then hash.
+func (s *StateDB) Models(q ModelQuery) []*Model {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*Model, 0)
+	for _, m := range s.models {
+		switch {
+		case q.Name != "" && m.Name != q.Name:
+			continue
+		case q.Owner != "" && !sameAddress(m.Owner, q.Owner):
+			continue
+		case m.Deprecated && !q.Deprecated:
+			continue
+		}
+		out = append(out, m.Copy())
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Version != b.Version {
+			return a.Version < b.Version
+		}
+		return receiptKey(a.Hash) < receiptKey(b.Hash)
+	})
+	return out
+}
+
+// copyModels returns a copy of s.models. Models are replaced rather than
+// modified, so they are shared. The caller holds s.mu.
+func (s *StateDB) copyModels() map[string]*Model {
+	if len(s.models) == 0 {
+		return nil
+	}
+	cp := make(map[string]*Model, len(s.models))
+	for k, m := range s.models {
+		cp[k] = m
+	}
+	return cp
+}
@@ -0,0 +1,232 @@
+package state
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// ZRC-721 tokens are non-fungible tokens representing agent-owned
+// artifacts such as model checkpoints, datasets and licenses. A creator
+// registers a collection under a unique symbol and alone mints its tokens,
+// each with a metadata URI and optionally the content hash of its
+// artifact. Owners transfer and burn their tokens. Tokens are indexed by
+// owner and by collection under their keys, <collection>/<id>, so that
+// listings resume after a key like those of agents.
+
+var (
+	ErrNFTCollectionExists   = errors.New("nft collection symbol already registered")
+	ErrNFTCollectionNotFound = errors.New("nft collection not found")
+	ErrNFTExists             = errors.New("nft already minted")
+	ErrNFTNotFound           = errors.New("nft not found")
+	ErrNotNFTOwner           = errors.New("sender does not own the nft")
+	ErrNotNFTMinter          = errors.New("sender is not the creator of the nft collection")
+)
+
+// NFTCollection is a ZRC-721 collection. Supply counts its tokens not
+// burned.
+type NFTCollection struct {
+	Symbol    string `json:"symbol"`
+	Name      string `json:"name"`
+	Creator   string `json:"creator"`
+	Supply    uint64 `json:"supply"`
+	Minted    uint64 `json:"minted"`
+	CreatedAt uint64 `json:"createdAt"`
+}
+
+// NFT is a ZRC-721 token.
+type NFT struct {
+	Collection  string `json:"collection"`
+	ID          string `json:"id"`
+	Owner       string `json:"owner"`
+	URI         string `json:"uri"`
+	ContentHash []byte `json:"contentHash,omitempty"`
+	MintedAt    uint64 `json:"mintedAt"`
+}
+
+// NFTKey returns the key of the token id of collection in the NFT indexes.
+func NFTKey(collection, id string) string { return collection + "/" + id }
+
+// CreateNFTCollection registers the collection c created by creator at
+// height.
+func (s *StateDB) CreateNFTCollection(c transaction.NFTCreateCollection, creator string, height uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.nftCollections[c.Symbol]; ok {
+		return ErrNFTCollectionExists
+	}
+	if s.nftCollections == nil {
+		s.nftCollections = make(map[string]*NFTCollection)
+	}
+	s.nftCollections[c.Symbol] = &NFTCollection{Symbol: c.Symbol, Name: c.Name, Creator: creator, CreatedAt: height}
+	return nil
+}
+
+// MintNFT mints m by minter, the creator of its collection, at height.
+func (s *StateDB) MintNFT(m transaction.NFTMint, minter string, height uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, ok := s.nftCollections[m.Collection]
+	if !ok {
+		return ErrNFTCollectionNotFound
+	}
+	if !sameAddress(cur.Creator, minter) {
+		return ErrNotNFTMinter
+	}
+	key := NFTKey(m.Collection, m.ID)
+	if _, ok := s.nfts[key]; ok {
+		return ErrNFTExists
+	}
+	c := *cur
+	c.Supply++
+	c.Minted++
+	s.nftCollections[m.Collection] = &c
+	if s.nfts == nil {
+		s.nfts = make(map[string]*NFT)
+	}
+	s.nfts[key] = &NFT{
+		Collection:  m.Collection,
+		ID:          m.ID,
+		Owner:       m.To,
+		URI:         m.URI,
+		ContentHash: m.ContentHash,
+		MintedAt:    height,
+	}
+	s.nftsByOwner.add(m.To, key)
+	s.nftsByCollection.add(m.Collection, key)
+	return nil
+}
+
+// TransferNFT moves the token id of collection from owner to to.
+func (s *StateDB) TransferNFT(collection, id, owner, to string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := NFTKey(collection, id)
+	cur, ok := s.nfts[key]
+	if !ok {
+		return ErrNFTNotFound
+	}
+	if !sameAddress(cur.Owner, owner) {
+		return ErrNotNFTOwner
+	}
+	n := *cur
+	n.Owner = to
+	s.nfts[key] = &n
+	s.nftsByOwner.remove(cur.Owner, key)
+	s.nftsByOwner.add(to, key)
+	return nil
+}
+
+// BurnNFT burns the token id of collection owned by owner.
+func (s *StateDB) BurnNFT(collection, id, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := NFTKey(collection, id)
+	cur, ok := s.nfts[key]
+	if !ok {
+		return ErrNFTNotFound
+	}
+	if !sameAddress(cur.Owner, owner) {
+		return ErrNotNFTOwner
+	}
+	c := *s.nftCollections[collection]
+	c.Supply--
+	s.nftCollections[collection] = &c
+	delete(s.nfts, key)
+	s.nftsByOwner.remove(cur.Owner, key)
+	s.nftsByCollection.remove(collection, key)
+	return nil
+}
+
+// GetNFTCollection returns the collection symbol.
+func (s *StateDB) GetNFTCollection(symbol string) (*NFTCollection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.nftCollections[symbol]
+	if !ok {
+		return nil, ErrNFTCollectionNotFound
+	}
+	return c.Copy(), nil
+}
+
+// NFTCollections returns the collections ordered by symbol.
+func (s *StateDB) NFTCollections() []*NFTCollection {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*NFTCollection, 0, len(s.nftCollections))
+	for _, c := range s.nftCollections {
+		out = append(out, c.Copy())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Symbol < out[j].Symbol })
+	return out
+}
+
+// GetNFT returns the token id of collection.
+func (s *StateDB) GetNFT(collection, id string) (*NFT, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n, ok := s.nfts[NFTKey(collection, id)]
+	if !ok {
+		return nil, ErrNFTNotFound
+	}
+	return n.Copy(), nil
+}
+
+// NFTsByOwner returns the tokens of owner in key order, up to limit of
+// them after the key after, with the key to resume from and the number of
+// tokens of owner.
+func (s *StateDB) NFTsByOwner(owner, after string, limit int) ([]*NFT, string, int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.nftPage(s.nftsByOwner[owner], after, limit)
+}
+
+// NFTsByCollection returns the tokens of collection in key order, like
+// NFTsByOwner.
+func (s *StateDB) NFTsByCollection(collection, after string, limit int) ([]*NFT, string, int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.nftPage(s.nftsByCollection[collection], after, limit)
+}
+
+// nftPage returns up to limit tokens of keys following the key after, the
+// key to resume from, which is empty on the last page, and len(keys). The
+// caller holds s.mu.
+func (s *StateDB) nftPage(keys []string, after string, limit int) ([]*NFT, string, int) {
+	start := 0
+	if after != "" {
+		start = sort.Search(len(keys), func(i int) bool { return keys[i] > after })
+	}
+	end := len(keys)
+	if limit > 0 && start+limit < end {
+		end = start + limit
+	}
+	out := make([]*NFT, 0, end-start)
+	for _, key := range keys[start:end] {
+		out = append(out, s.nfts[key].Copy())
+	}
+	next := ""
+	if end < len(keys) {
+		next = keys[end-1]
+	}
+	return out, next, len(keys)
+}
+
+// copyNFTs returns copies of the NFT maps. Their records are replaced
+// rather than modified, so they are shared. The caller holds s.mu.
+func (s *StateDB) copyNFTs() (collections map[string]*NFTCollection, nfts map[string]*NFT) {
+	if len(s.nftCollections) > 0 {
+		collections = make(map[string]*NFTCollection, len(s.nftCollections))
+		for k, v := range s.nftCollections {
+			collections[k] = v
+		}
+	}
+	if len(s.nfts) > 0 {
+		nfts = make(map[string]*NFT, len(s.nfts))
+		for k, v := range s.nfts {
+			nfts[k] = v
+		}
+	}
+	return
+}
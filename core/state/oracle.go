@@ -0,0 +1,355 @@
+package state
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// The quality of an inference output cannot be computed on chain, so it
+// is scored by an oracle committee of evaluators, each escrowing a stake.
+// The first score of a receipt opens its evaluation, which accepts scores
+// for EvaluationPeriod blocks. An evaluation closed with at least Quorum
+// scores settles on the stake-weighted median score, which credits the
+// receipt's agent reputation and so feeds its Proof-of-Intelligence score;
+// each evaluator whose score lies more than OutlierDeviation from the
+// median loses SlashPercent of its stake, burned. An evaluator that
+// deregisters gets its stake back after EvaluatorUnbondingPeriod blocks,
+// so that it remains at stake until the evaluations it scored close.
+
+var (
+	ErrEvaluatorExists    = errors.New("evaluator already registered")
+	ErrEvaluatorNotFound  = errors.New("evaluator not found")
+	ErrEvaluatorInactive  = errors.New("evaluator is deregistered")
+	ErrEvaluatorStake     = errors.New("evaluator stake below the minimum")
+	ErrEvaluationClosed   = errors.New("evaluation of the receipt is closed")
+	ErrEvaluationNotFound = errors.New("evaluation not found")
+	ErrAlreadyScored      = errors.New("evaluator has already scored the receipt")
+	ErrSelfEvaluation     = errors.New("evaluator cannot score its own receipt")
+)
+
+// Evaluation statuses.
+const (
+	EvaluationOpen    = "open"
+	EvaluationFinal   = "final"   // settled on the median score
+	EvaluationExpired = "expired" // closed short of the quorum
+)
+
+// EvaluatorUnbondingPeriod is the number of blocks between the
+// deregistration of an evaluator and the release of its stake.
+const EvaluatorUnbondingPeriod = 100
+
+// OracleParams configures the quality oracle committee.
+type OracleParams struct {
+	MinStake         *big.Int `json:"minStake"`
+	EvaluationPeriod uint64   `json:"evaluationPeriod"` // blocks
+	Quorum           int      `json:"quorum"`           // scores to settle an evaluation
+	OutlierDeviation uint64   `json:"outlierDeviation"` // from the median, in score points
+	SlashPercent     uint64   `json:"slashPercent"`     // of an outlier's stake
+}
+
+// DefaultOracleParams returns the oracle parameters of a new state.
+func DefaultOracleParams() OracleParams {
+	return OracleParams{
+		MinStake:         new(big.Int).Mul(big.NewInt(100), big.NewInt(1e18)),
+		EvaluationPeriod: 50,
+		Quorum:           3,
+		OutlierDeviation: 2_000,
+		SlashPercent:     10,
+	}
+}
+
+// Evaluator is a member of the quality oracle committee.
+type Evaluator struct {
+	Address      string   `json:"address"`
+	Stake        *big.Int `json:"stake"`
+	Active       bool     `json:"active"`
+	RegisteredAt uint64   `json:"registeredAt"`
+	UnbondingAt  uint64   `json:"unbondingAt,omitempty"` // height the stake is released at once deregistered
+	Slashed      *big.Int `json:"slashed"`               // total burned
+}
+
+// EvaluatorScore is an evaluator's score of an inference output.
+type EvaluatorScore struct {
+	Evaluator string   `json:"evaluator"`
+	Score     uint64   `json:"score"`
+	Stake     *big.Int `json:"stake"` // weight, the evaluator's stake when it scored
+	Height    uint64   `json:"height"`
+	Outlier   bool     `json:"outlier,omitempty"` // slashed at settlement
+}
+
+// Evaluation is the committee's scoring of the output of an inference
+// receipt.
+type Evaluation struct {
+	ReceiptHash []byte           `json:"receiptHash"`
+	Scores      []EvaluatorScore `json:"scores"`
+	OpenedAt    uint64           `json:"openedAt"`
+	Deadline    uint64           `json:"deadline"`
+	Status      string           `json:"status"`
+	Median      uint64           `json:"median,omitempty"` // once final
+	ResolvedAt  uint64           `json:"resolvedAt,omitempty"`
+}
+
+// SetOracleParams replaces the oracle parameters.
+func (s *StateDB) SetOracleParams(p OracleParams) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.oracleParams = p
+}
+
+// GetOracleParams returns the oracle parameters.
+func (s *StateDB) GetOracleParams() OracleParams {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.oracleParams
+}
+
+// RegisterEvaluator registers addr as an evaluator at height, escrowing the
+// stake of reg from its balance.
+func (s *StateDB) RegisterEvaluator(reg transaction.EvaluatorRegistration, addr string, height uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.evaluators[addr]; ok {
+		return ErrEvaluatorExists
+	}
+	if reg.Stake.Cmp(s.oracleParams.MinStake) < 0 {
+		return ErrEvaluatorStake
+	}
+	if err := s.escrow(addr, reg.Stake); err != nil {
+		return err
+	}
+	if s.evaluators == nil {
+		s.evaluators = make(map[string]*Evaluator)
+	}
+	s.evaluators[addr] = &Evaluator{
+		Address:      addr,
+		Stake:        new(big.Int).Set(reg.Stake),
+		Active:       true,
+		RegisteredAt: height,
+		Slashed:      new(big.Int),
+	}
+	return nil
+}
+
+// DeregisterEvaluator deregisters the evaluator addr at height. Its stake,
+// less any later slashing, is released EvaluatorUnbondingPeriod blocks
+// later.
+func (s *StateDB) DeregisterEvaluator(addr string, height uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ev, ok := s.evaluators[addr]
+	if !ok {
+		return ErrEvaluatorNotFound
+	}
+	if !ev.Active {
+		return ErrEvaluatorInactive
+	}
+	nev := *ev
+	nev.Active, nev.UnbondingAt = false, height+EvaluatorUnbondingPeriod
+	s.evaluators[addr] = &nev
+	return nil
+}
+
+// SubmitQualityScore records the score q of evaluator at height, opening
+// the evaluation of the receipt if it is the first.
+func (s *StateDB) SubmitQualityScore(q transaction.QualityScore, evaluator string, height uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ev, ok := s.evaluators[evaluator]
+	if !ok {
+		return ErrEvaluatorNotFound
+	}
+	if !ev.Active {
+		return ErrEvaluatorInactive
+	}
+	key := receiptKey(q.ReceiptHash)
+	rec, ok := s.receipts[key]
+	if !ok {
+		return ErrReceiptNotFound
+	}
+	if sameAddress(rec.Submitter, evaluator) || sameAddress(rec.Receipt.Prover, evaluator) {
+		return ErrSelfEvaluation
+	}
+	e, ok := s.evaluations[key]
+	if !ok {
+		if s.evaluations == nil {
+			s.evaluations = make(map[string]*Evaluation)
+		}
+		e = &Evaluation{
+			ReceiptHash: append([]byte(nil), q.ReceiptHash...),
+			OpenedAt:    height,
+			Deadline:    height + s.oracleParams.EvaluationPeriod,
+			Status:      EvaluationOpen,
+		}
+		s.pendingEvaluations = append(s.pendingEvaluations[:len(s.pendingEvaluations):len(s.pendingEvaluations)], key)
+	}
+	if e.Status != EvaluationOpen || height >= e.Deadline {
+		return ErrEvaluationClosed
+	}
+	for _, sc := range e.Scores {
+		if sameAddress(sc.Evaluator, evaluator) {
+			return ErrAlreadyScored
+		}
+	}
+	ne := *e
+	ne.Scores = append(e.Scores[:len(e.Scores):len(e.Scores)], EvaluatorScore{
+		Evaluator: evaluator,
+		Score:     q.Score,
+		Stake:     new(big.Int).Set(ev.Stake),
+		Height:    height,
+	})
+	s.evaluations[key] = &ne
+	return nil
+}
+
+// SettleEvaluations settles the evaluations whose scoring period has
+// closed by height and releases the stakes of the deregistered evaluators
+// whose unbonding has completed. The consensus engine calls it once per
+// block.
+func (s *StateDB) SettleEvaluations(height uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for ; n < len(s.pendingEvaluations); n++ {
+		key := s.pendingEvaluations[n]
+		if height < s.evaluations[key].Deadline {
+			break
+		}
+		s.settleEvaluation(key, height)
+	}
+	s.pendingEvaluations = s.pendingEvaluations[n:]
+
+	for addr, ev := range s.evaluators {
+		if !ev.Active && height >= ev.UnbondingAt {
+			s.release(addr, ev.Stake)
+			delete(s.evaluators, addr)
+		}
+	}
+}
+
+// settleEvaluation settles the evaluation under key at height. The caller
+// holds s.mu.
+func (s *StateDB) settleEvaluation(key string, height uint64) {
+	e := s.evaluations[key]
+	ne := *e
+	ne.ResolvedAt = height
+	if len(e.Scores) < s.oracleParams.Quorum {
+		ne.Status = EvaluationExpired
+		s.evaluations[key] = &ne
+		return
+	}
+	median := weightedMedian(e.Scores)
+	ne.Status, ne.Median = EvaluationFinal, median
+	ne.Scores = make([]EvaluatorScore, len(e.Scores))
+	for i, sc := range e.Scores {
+		dev := sc.Score - median
+		if sc.Score < median {
+			dev = median - sc.Score
+		}
+		if dev > s.oracleParams.OutlierDeviation {
+			sc.Outlier = true
+			s.slashEvaluator(sc.Evaluator)
+		}
+		ne.Scores[i] = sc
+	}
+	s.evaluations[key] = &ne
+	rec := s.receipts[key]
+	s.updateReputation(rec.Receipt.AgentID, height, func(r *Reputation) {
+		r.EvaluatedInferences++
+		r.QualityTotal += median
+	})
+}
+
+// slashEvaluator burns SlashPercent of the stake of the evaluator addr, if
+// it still holds one. The caller holds s.mu.
+func (s *StateDB) slashEvaluator(addr string) {
+	ev, ok := s.evaluators[addr]
+	if !ok {
+		return
+	}
+	amount := new(big.Int).Mul(ev.Stake, new(big.Int).SetUint64(s.oracleParams.SlashPercent))
+	amount.Div(amount, big.NewInt(100))
+	s.burn(amount)
+	nev := *ev
+	nev.Stake = new(big.Int).Sub(ev.Stake, amount)
+	nev.Slashed = new(big.Int).Add(ev.Slashed, amount)
+	s.evaluators[addr] = &nev
+}
+
+// weightedMedian returns the stake-weighted median of scores: the lowest
+// score at which the scores up to it hold at least half the total stake.
+func weightedMedian(scores []EvaluatorScore) uint64 {
+	sorted := append([]EvaluatorScore(nil), scores...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Score < sorted[j].Score })
+	total := new(big.Int)
+	for _, sc := range sorted {
+		total.Add(total, sc.Stake)
+	}
+	cum := new(big.Int)
+	for _, sc := range sorted {
+		cum.Add(cum, sc.Stake)
+		if new(big.Int).Lsh(cum, 1).Cmp(total) >= 0 {
+			return sc.Score
+		}
+	}
+	return sorted[len(sorted)-1].Score
+}
+
+// GetEvaluator returns the evaluator addr.
+func (s *StateDB) GetEvaluator(addr string) (*Evaluator, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ev, ok := s.evaluators[canonicalAddress(addr)]
+	if !ok {
+		return nil, ErrEvaluatorNotFound
+	}
+	return ev.Copy(), nil
+}
+
+// Evaluators returns the evaluators, active and unbonding, ordered by
+// address.
+func (s *StateDB) Evaluators() []*Evaluator {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*Evaluator, 0, len(s.evaluators))
+	for _, ev := range s.evaluators {
+		out = append(out, ev.Copy())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Address < out[j].Address })
+	return out
+}
+
+// GetEvaluation returns the evaluation of the receipt included by the
+// transaction hash.
+func (s *StateDB) GetEvaluation(hash []byte) (*Evaluation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.evaluations[receiptKey(hash)]
+	if !ok {
+		return nil, ErrEvaluationNotFound
+	}
+	return e.Copy(), nil
+}
+
+// copyOracle returns copies of s.evaluators and s.evaluations. Their
+// records are replaced rather than modified, so they are shared. The
+// caller holds s.mu.
+func (s *StateDB) copyOracle() (map[string]*Evaluator, map[string]*Evaluation) {
+	var evaluators map[string]*Evaluator
+	if len(s.evaluators) > 0 {
+		evaluators = make(map[string]*Evaluator, len(s.evaluators))
+		for k, ev := range s.evaluators {
+			evaluators[k] = ev
+		}
+	}
+	var evaluations map[string]*Evaluation
+	if len(s.evaluations) > 0 {
+		evaluations = make(map[string]*Evaluation, len(s.evaluations))
+		for k, e := range s.evaluations {
+			evaluations[k] = e
+		}
+	}
+	return evaluators, evaluations
+}
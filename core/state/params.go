@@ -0,0 +1,320 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// Protocol parameters are grouped in subspaces, each a parameter set of
+// the state that governance can change by a param_change proposal:
+//
+//	consensus   ConsensusParams
+//	vm          VMParams
+//	staking     StakingParams
+//	tokenomics  TokenomicsParams; see supply.go
+//	governance  GovParams; see gov.go
+//	dispute     DisputeParams; see dispute.go
+//	oracle      OracleParams; see oracle.go
+//	bridge      BridgeParams; see bridge.go
+//
+// A change sets the fields of its JSON object value and keeps the others.
+// Changes take effect at once or are scheduled as a fork: a ParamVersion
+// activated by the consensus engine before it produces the block at its
+// height, so that protocol upgrades change the rules without new code
+// paths. The genesis schedules the forks known at launch, and passed
+// param_change proposals with an activation height add to them.
+
+var ErrInvalidParams = errors.New("invalid parameter change")
+
+// ConsensusParams configures block production.
+type ConsensusParams struct {
+	BlockGasLimit     uint64   `json:"blockGasLimit"`
+	MaxBlockTxs       int      `json:"maxBlockTxs"`
+	MinValidatorStake *big.Int `json:"minValidatorStake"`
+}
+
+// DefaultConsensusParams returns the consensus parameters of a new state.
+func DefaultConsensusParams() ConsensusParams {
+	return ConsensusParams{
+		BlockGasLimit:     30_000_000,
+		MaxBlockTxs:       100,
+		MinValidatorStake: new(big.Int).Mul(big.NewInt(10_000), big.NewInt(1e18)),
+	}
+}
+
+// VMParams configures transaction execution: the fee split and the gas
+// schedule of contract storage, logs and self-destruct.
+type VMParams struct {
+	FeeBurnPercent    uint64 `json:"feeBurnPercent"` // of every fee; the rest goes to the proposer
+	GasSStoreSet      uint64 `json:"gasSStoreSet"`
+	GasSStoreClear    uint64 `json:"gasSStoreClear"`
+	RefundSStoreClear uint64 `json:"refundSStoreClear"`
+	GasSelfDestruct   uint64 `json:"gasSelfDestruct"`
+	GasLog            uint64 `json:"gasLog"`
+	GasLogTopic       uint64 `json:"gasLogTopic"`
+	GasLogByte        uint64 `json:"gasLogByte"`
+}
+
+// DefaultVMParams returns the VM parameters of a new state.
+func DefaultVMParams() VMParams {
+	return VMParams{
+		FeeBurnPercent:    20,
+		GasSStoreSet:      20000,
+		GasSStoreClear:    5000,
+		RefundSStoreClear: 4800,
+		GasSelfDestruct:   5000,
+		GasLog:            375,
+		GasLogTopic:       375,
+		GasLogByte:        8,
+	}
+}
+
+// StakingParams configures validator staking; see staking.go.
+type StakingParams struct {
+	UnbondingPeriod uint64 `json:"unbondingPeriod"` // blocks
+	MaxMissedBlocks uint64 `json:"maxMissedBlocks"` // proposer turns missed in a row before jailing; 0 never jails
+	JailPeriod      uint64 `json:"jailPeriod"`      // blocks before a jailed validator may unjail
+}
+
+// DefaultStakingParams returns the staking parameters of a new state.
+func DefaultStakingParams() StakingParams {
+	return StakingParams{UnbondingPeriod: 1_000, MaxMissedBlocks: 50, JailPeriod: 600}
+}
+
+// ParamVersion is a set of parameter changes activated at Height.
+type ParamVersion struct {
+	Height  uint64                    `json:"height"`
+	Changes []transaction.ParamChange `json:"changes"`
+	Error   string                    `json:"error,omitempty"` // of a failed activation
+}
+
+// Params holds the parameters of all subspaces.
+type Params struct {
+	Consensus  ConsensusParams  `json:"consensus"`
+	VM         VMParams         `json:"vm"`
+	Staking    StakingParams    `json:"staking"`
+	Tokenomics TokenomicsParams `json:"tokenomics"`
+	Governance GovParams        `json:"governance"`
+	Dispute    DisputeParams    `json:"dispute"`
+	Oracle     OracleParams     `json:"oracle"`
+	Bridge     BridgeParams     `json:"bridge"`
+}
+
+// SetConsensusParams replaces the consensus parameters.
+func (s *StateDB) SetConsensusParams(p ConsensusParams) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.consensusParams = p
+}
+
+// GetConsensusParams returns the consensus parameters.
+func (s *StateDB) GetConsensusParams() ConsensusParams {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.consensusParams
+}
+
+// SetStakingParams replaces the staking parameters.
+func (s *StateDB) SetStakingParams(p StakingParams) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stakingParams = p
+}
+
+// GetStakingParams returns the staking parameters.
+func (s *StateDB) GetStakingParams() StakingParams {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.stakingParams
+}
+
+// GetParams returns the parameters of all subspaces.
+func (s *StateDB) GetParams() Params {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.params()
+}
+
+// SetVMParams replaces the VM parameters.
+func (s *StateDB) SetVMParams(p VMParams) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vmParams = p
+}
+
+// GetVMParams returns the VM parameters.
+func (s *StateDB) GetVMParams() VMParams {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.vmParams
+}
+
+// paramChange returns a function applying the change of subspace to value,
+// which it checks first. The caller holds s.mu.
+func (s *StateDB) paramChange(subspace string, value json.RawMessage) (func(), error) {
+	switch subspace {
+	case "consensus":
+		p, err := mergeParams(s.consensusParams, value)
+		if err == nil && (p.BlockGasLimit == 0 || p.MaxBlockTxs <= 0 || p.MinValidatorStake == nil || p.MinValidatorStake.Sign() < 0) {
+			err = fmt.Errorf("%w: block limits must be positive and minValidatorStake set", ErrInvalidParams)
+		}
+		return func() { s.consensusParams = p }, err
+	case "vm":
+		p, err := mergeParams(s.vmParams, value)
+		if err == nil && p.FeeBurnPercent > 100 {
+			err = fmt.Errorf("%w: feeBurnPercent exceeds 100", ErrInvalidParams)
+		}
+		return func() { s.vmParams = p }, err
+	case "staking":
+		p, err := mergeParams(s.stakingParams, value)
+		if err == nil && p.UnbondingPeriod == 0 {
+			err = fmt.Errorf("%w: unbondingPeriod must be positive", ErrInvalidParams)
+		}
+		return func() { s.stakingParams = p }, err
+	case "tokenomics":
+		p, err := mergeParams(s.tokenomics, value)
+		if err == nil {
+			err = p.Validate()
+		}
+		return func() { s.tokenomics = p }, err
+	case "governance":
+		p, err := mergeParams(s.govParams, value)
+		if err == nil {
+			err = p.Validate()
+		}
+		return func() { s.govParams = p }, err
+	case "dispute":
+		p, err := mergeParams(s.disputeParams, value)
+		if err == nil && (p.Bond == nil || p.SlashBurnPercent > 100) {
+			err = fmt.Errorf("%w: dispute bond required and slashBurnPercent at most 100", ErrInvalidParams)
+		}
+		return func() { s.disputeParams = p }, err
+	case "oracle":
+		p, err := mergeParams(s.oracleParams, value)
+		if err == nil && (p.MinStake == nil || p.SlashPercent > 100) {
+			err = fmt.Errorf("%w: oracle minStake required and slashPercent at most 100", ErrInvalidParams)
+		}
+		return func() { s.oracleParams = p }, err
+	case "bridge":
+		p, err := mergeParams(s.bridgeParams, value)
+		if err == nil {
+			err = p.Validate()
+		}
+		return func() { s.bridgeParams = p }, err
+	}
+	return nil, fmt.Errorf("%w: unknown subspace %q", ErrInvalidParams, subspace)
+}
+
+// mergeParams returns a copy of cur with the fields of the JSON object
+// change set. The copy shares no memory with cur.
+func mergeParams[T any](cur T, change json.RawMessage) (T, error) {
+	var p T
+	b, err := json.Marshal(cur)
+	if err != nil {
+		return p, err
+	}
+	if err := json.Unmarshal(b, &p); err != nil {
+		return p, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(change))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return p, fmt.Errorf("%w: %v", ErrInvalidParams, err)
+	}
+	return p, nil
+}
+
+func (s *StateDB) params() Params {
+	return Params{s.consensusParams, s.vmParams, s.stakingParams, s.tokenomics, s.govParams, s.disputeParams, s.oracleParams, s.bridgeParams}
+}
+
+func (s *StateDB) restoreParams(p Params) {
+	s.consensusParams, s.vmParams, s.stakingParams, s.tokenomics = p.Consensus, p.VM, p.Staking, p.Tokenomics
+	s.govParams, s.disputeParams, s.oracleParams, s.bridgeParams = p.Governance, p.Dispute, p.Oracle, p.Bridge
+}
+
+// applyParamChanges applies changes in order, all or none of them. The
+// caller holds s.mu.
+func (s *StateDB) applyParamChanges(changes []transaction.ParamChange) error {
+	saved := s.params()
+	for _, c := range changes {
+		apply, err := s.paramChange(c.Subspace, c.Value)
+		if err != nil {
+			s.restoreParams(saved)
+			return err
+		}
+		apply()
+	}
+	return nil
+}
+
+// checkParamChanges checks that changes apply, leaving the parameters
+// unchanged. The caller holds s.mu.
+func (s *StateDB) checkParamChanges(changes []transaction.ParamChange) error {
+	saved := s.params()
+	defer s.restoreParams(saved)
+	return s.applyParamChanges(changes)
+}
+
+// ScheduleParamChanges schedules changes as a fork activated at height.
+// The changes must apply to the current parameters.
+func (s *StateDB) ScheduleParamChanges(height uint64, changes []transaction.ParamChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkParamChanges(changes); err != nil {
+		return err
+	}
+	s.scheduleParamChanges(height, changes)
+	return nil
+}
+
+// scheduleParamChanges adds the fork of changes at height after those
+// already scheduled up to it. The caller holds s.mu.
+func (s *StateDB) scheduleParamChanges(height uint64, changes []transaction.ParamChange) {
+	i := sort.Search(len(s.paramForks), func(i int) bool { return s.paramForks[i].Height > height })
+	forks := make([]*ParamVersion, 0, len(s.paramForks)+1)
+	forks = append(forks, s.paramForks[:i]...)
+	forks = append(forks, &ParamVersion{Height: height, Changes: changes})
+	s.paramForks = append(forks, s.paramForks[i:]...)
+}
+
+// ActivateParams activates the forks scheduled up to height in order and
+// returns them. A fork whose changes no longer apply is skipped, with the
+// error recorded. The consensus engine calls it before producing the block
+// at height.
+func (s *StateDB) ActivateParams(height uint64) []*ParamVersion {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var activated []*ParamVersion
+	for len(s.paramForks) > 0 && s.paramForks[0].Height <= height {
+		v := *s.paramForks[0]
+		s.paramForks = s.paramForks[1:]
+		if err := s.applyParamChanges(v.Changes); err != nil {
+			v.Error = err.Error()
+		}
+		s.paramHistory = append(s.paramHistory[:len(s.paramHistory):len(s.paramHistory)], &v)
+		activated = append(activated, &v)
+	}
+	return activated
+}
+
+// ParamForks returns the forks scheduled and not yet activated, soonest
+// first.
+func (s *StateDB) ParamForks() []*ParamVersion {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return copyRecords(s.paramForks, (*ParamVersion).Copy)
+}
+
+// ParamHistory returns the forks activated, in order.
+func (s *StateDB) ParamHistory() []*ParamVersion {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return copyRecords(s.paramHistory, (*ParamVersion).Copy)
+}
@@ -0,0 +1,231 @@
+package state
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/zionlayer/zionlayer/core/common"
+	"github.com/zionlayer/zionlayer/core/crypto"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// Compute providers run inferences on behalf of agents and sign the
+// receipts of the inferences they ran. A provider registers the key it
+// signs with and the models it serves, escrowing a bond; a receipt is only
+// accepted if a registered provider serving its model signed it. A
+// provider that deregisters stops proving receipts at once but gets its
+// bond back only after ProviderUnbondingPeriod blocks, so that it remains
+// at stake while its last receipts can still be disputed.
+
+// ProviderUnbondingPeriod is the number of blocks between the
+// deregistration of a provider and the release of its bond.
+const ProviderUnbondingPeriod = 100
+
+var (
+	ErrProviderExists    = errors.New("compute provider already registered")
+	ErrProviderNotFound  = errors.New("compute provider not found")
+	ErrProviderInactive  = errors.New("compute provider is deregistered")
+	ErrModelNotSupported = errors.New("compute provider does not serve the model")
+	ErrInvalidProverSig  = errors.New("invalid prover signature")
+	ErrReceiptTimestamp  = errors.New("inference receipt timestamp outside the accepted window")
+)
+
+// Provider is a registered compute provider.
+type Provider struct {
+	Address      string   `json:"address"`
+	PubKey       []byte   `json:"pubKey"`
+	Models       [][]byte `json:"models"`
+	Bond         *big.Int `json:"bond"`
+	Active       bool     `json:"active"`
+	RegisteredAt uint64   `json:"registeredAt"`
+	UnbondingAt  uint64   `json:"unbondingAt,omitempty"` // height the bond is released at once deregistered
+}
+
+// RegisterProvider registers addr as a compute provider at height,
+// escrowing the bond of reg from its balance. A deregistered provider can
+// register again once its bond has been released.
+func (s *StateDB) RegisterProvider(reg transaction.ProviderRegistration, addr string, height uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.providers[addr]; ok {
+		return ErrProviderExists
+	}
+	if err := s.escrow(addr, reg.Bond); err != nil {
+		return err
+	}
+	if s.providers == nil {
+		s.providers = make(map[string]*Provider)
+	}
+	models := make([][]byte, len(reg.Models))
+	for i, m := range reg.Models {
+		models[i] = append([]byte(nil), m...)
+	}
+	s.providers[addr] = &Provider{
+		Address:      addr,
+		PubKey:       append([]byte(nil), reg.PubKey...),
+		Models:       models,
+		Bond:         new(big.Int).Set(reg.Bond),
+		Active:       true,
+		RegisteredAt: height,
+	}
+	return nil
+}
+
+// DeregisterProvider deregisters the compute provider addr at height. Its
+// bond is released ProviderUnbondingPeriod blocks later.
+func (s *StateDB) DeregisterProvider(addr string, height uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.providers[addr]
+	if !ok {
+		return ErrProviderNotFound
+	}
+	if !p.Active {
+		return ErrProviderInactive
+	}
+	np := *p
+	np.Active, np.UnbondingAt = false, height+ProviderUnbondingPeriod
+	s.providers[addr] = &np
+	return nil
+}
+
+// SettleProviders releases the bonds of the deregistered providers whose
+// unbonding has completed by height and removes them. The consensus engine
+// calls it once per block.
+func (s *StateDB) SettleProviders(height uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for addr, p := range s.providers {
+		if !p.Active && height >= p.UnbondingAt {
+			s.release(addr, p.Bond)
+			delete(s.providers, addr)
+		}
+	}
+}
+
+// GetProvider returns the compute provider addr.
+func (s *StateDB) GetProvider(addr string) (*Provider, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p, ok := s.providers[canonicalAddress(addr)]
+	if !ok {
+		return nil, ErrProviderNotFound
+	}
+	return p.Copy(), nil
+}
+
+// Providers returns the compute providers, active and unbonding, ordered
+// by address.
+func (s *StateDB) Providers() []*Provider {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*Provider, 0, len(s.providers))
+	for _, p := range s.providers {
+		out = append(out, p.Copy())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Address < out[j].Address })
+	return out
+}
+
+// Receipt timestamps are accepted from ReceiptMaxAge before to
+// ReceiptMaxSkew after the time of the block including them.
+const (
+	ReceiptMaxAge  = time.Hour
+	ReceiptMaxSkew = 5 * time.Minute
+)
+
+// VerifyReceipt checks that r is fit for inclusion in a block of
+// blockTime: its agent is registered and active, its model is registered
+// and not deprecated, its timestamp lies within
+// the accepted window of blockTime, it is signed by its prover, an active
+// compute provider serving its model, and its attestation and zkML proof,
+// if any, are valid; see enclave.go and zkml.go. A zero blockTime skips the
+// timestamp check.
+func (s *StateDB) VerifyReceipt(r transaction.InferenceReceipt, blockTime time.Time) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if err := s.checkInference(r.AgentID, r.ModelHash, r.Timestamp, blockTime); err != nil {
+		return err
+	}
+	if err := s.checkProver(r.Prover, r.ModelHash, r.SigningHash(), r.ProverSig); err != nil {
+		return err
+	}
+	if r.Attestation != nil {
+		if err := s.verifyAttestation(r, blockTime); err != nil {
+			return err
+		}
+	}
+	if len(r.ZKProof) > 0 {
+		return s.verifyZKProof(r)
+	}
+	return nil
+}
+
+// checkInference checks that the agent is registered and active, the
+// model is registered and not deprecated and, unless blockTime is zero,
+// the timestamp lies within the accepted window of blockTime. The caller
+// holds s.mu.
+func (s *StateDB) checkInference(agent string, model []byte, timestamp int64, blockTime time.Time) error {
+	if err := s.checkActive(agent); err != nil {
+		return err
+	}
+	if err := s.checkModel(model); err != nil {
+		return err
+	}
+	if !blockTime.IsZero() {
+		t := time.Unix(timestamp, 0)
+		if t.Before(blockTime.Add(-ReceiptMaxAge)) || t.After(blockTime.Add(ReceiptMaxSkew)) {
+			return ErrReceiptTimestamp
+		}
+	}
+	return nil
+}
+
+// checkProver checks that sig is a signature of hash by prover, an active
+// compute provider serving model. The caller holds s.mu.
+func (s *StateDB) checkProver(prover string, model []byte, hash [32]byte, sig []byte) error {
+	p, ok := s.providers[canonicalAddress(prover)]
+	if !ok {
+		return ErrProviderNotFound
+	}
+	if !p.Active {
+		return ErrProviderInactive
+	}
+	served := false
+	for _, m := range p.Models {
+		served = served || bytes.Equal(m, model)
+	}
+	if !served {
+		return ErrModelNotSupported
+	}
+	if err := crypto.Verify(p.PubKey, hash, sig); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProverSig, err)
+	}
+	return nil
+}
+
+// canonicalAddress returns the checksummed form of addr, or addr unchanged
+// if it does not parse.
+func canonicalAddress(addr string) string {
+	if a, err := common.ParseAddress(addr); err == nil {
+		return a.String()
+	}
+	return addr
+}
+
+// copyProviders returns a copy of s.providers. Providers are replaced
+// rather than modified, so they are shared. The caller holds s.mu.
+func (s *StateDB) copyProviders() map[string]*Provider {
+	if len(s.providers) == 0 {
+		return nil
+	}
+	cp := make(map[string]*Provider, len(s.providers))
+	for k, p := range s.providers {
+		cp[k] = p
+	}
+	return cp
+}
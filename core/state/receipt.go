@@ -0,0 +1,127 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// Inference receipts are kept by the hash of the transaction that included
+// them and indexed by agent and by model hash, in inclusion order. A
+// receipt passes VerifyReceipt before it is recorded; its status then
+// follows its challenge window and disputes, see dispute.go.
+
+var ErrReceiptNotFound = errors.New("inference receipt not found")
+
+// Receipt statuses.
+const (
+	ReceiptPending  = "pending"  // within its challenge window
+	ReceiptFinal    = "final"    // window closed without a decided dispute
+	ReceiptDisputed = "disputed" // under an open dispute
+	ReceiptUpheld   = "upheld"   // a dispute found it valid
+	ReceiptRejected = "rejected" // a dispute found it invalid
+)
+
+// ReceiptRecord is an inference receipt included on chain.
+type ReceiptRecord struct {
+	Hash      []byte                       `json:"hash"` // of the including transaction
+	Receipt   transaction.InferenceReceipt `json:"receipt"`
+	Submitter string                       `json:"submitter"`
+	Height    uint64                       `json:"height"`
+	Bond      *big.Int                     `json:"bond"` // escrowed while pending or disputed
+	Status    string                       `json:"status"`
+	Trust     int                          `json:"trust"` // TrustSigned, TrustAttested or TrustProven
+
+	// Commitment is the commitment status of a receipt carrying one, to be
+	// revealed before RevealDeadline; see reveal.go.
+	Commitment     string `json:"commitment,omitempty"`
+	RevealDeadline uint64 `json:"revealDeadline,omitempty"`
+}
+
+// ReceiptTrust returns the trust tier of r, assuming it verified.
+func ReceiptTrust(r transaction.InferenceReceipt) int {
+	switch {
+	case len(r.ZKProof) > 0:
+		return TrustProven
+	case r.Attestation != nil:
+		return TrustAttested
+	}
+	return TrustSigned
+}
+
+func receiptKey(hash []byte) string {
+	return fmt.Sprintf("%x", hash)
+}
+
+// RecordReceipt records the inference receipt r included at height by the
+// transaction hash, escrowing the dispute bond from submitter until its
+// challenge window closes.
+func (s *StateDB) RecordReceipt(hash [32]byte, r transaction.InferenceReceipt, submitter string, height uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bond := s.disputeParams.Bond
+	if err := s.escrow(submitter, bond); err != nil {
+		return err
+	}
+	if s.receipts == nil {
+		s.receipts = make(map[string]*ReceiptRecord)
+	}
+	key := receiptKey(hash[:])
+	rec := &ReceiptRecord{
+		Hash:      append([]byte(nil), hash[:]...),
+		Receipt:   r,
+		Submitter: submitter,
+		Height:    height,
+		Bond:      new(big.Int).Set(bond),
+		Status:    ReceiptPending,
+		Trust:     ReceiptTrust(r),
+	}
+	if len(r.Commitment) > 0 {
+		rec.Commitment, rec.RevealDeadline = CommitmentPending, height+s.disputeParams.RevealWindow
+		s.pendingReveals = append(s.pendingReveals[:len(s.pendingReveals):len(s.pendingReveals)], key)
+	}
+	s.receipts[key] = rec
+	s.pendingReceipts = append(s.pendingReceipts[:len(s.pendingReceipts):len(s.pendingReceipts)], key)
+	s.receiptsByAgent[r.AgentID] = append(s.receiptsByAgent[r.AgentID], key)
+	model := receiptKey(r.ModelHash)
+	s.receiptsByModel[model] = append(s.receiptsByModel[model], key)
+	return nil
+}
+
+// GetReceipt returns the record of the inference receipt included by the
+// transaction hash.
+func (s *StateDB) GetReceipt(hash []byte) (*ReceiptRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rec, ok := s.receipts[receiptKey(hash)]
+	if !ok {
+		return nil, ErrReceiptNotFound
+	}
+	return rec.Copy(), nil
+}
+
+// AgentReceipts returns the inference receipts of agent, oldest first.
+func (s *StateDB) AgentReceipts(agent string) []*ReceiptRecord {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.receiptRecords(s.receiptsByAgent[agent])
+}
+
+// ModelReceipts returns the inference receipts of the model hash, oldest
+// first.
+func (s *StateDB) ModelReceipts(model []byte) []*ReceiptRecord {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.receiptRecords(s.receiptsByModel[receiptKey(model)])
+}
+
+// receiptRecords returns the receipts under keys. The caller holds s.mu.
+func (s *StateDB) receiptRecords(keys []string) []*ReceiptRecord {
+	out := make([]*ReceiptRecord, len(keys))
+	for i, key := range keys {
+		out[i] = s.receipts[key].Copy()
+	}
+	return out
+}
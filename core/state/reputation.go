@@ -0,0 +1,159 @@
+package state
+
+import (
+	"github.com/zionlayer/zionlayer/core/common"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// An agent earns reputation for each inference receipt verified for it,
+// scaled up by the quality the oracle committee scores its output at, for
+// each task it completes and each dispute it wins, and loses it for each
+// dispute it loses. A task is completed when the agent answers a TASK
+// message with a RESULT message to the agent that sent it; each TASK can
+// be answered once.
+
+// Reputation weights, in score points.
+const (
+	ReputationPerInference   = 10
+	ReputationPerAttestation = 10 // on top of ReputationPerInference
+	ReputationPerProof       = 20 // on top of ReputationPerInference
+	ReputationPerQuality     = 20 // per evaluated inference at MaxQualityScore
+	ReputationPerTask        = 25
+	ReputationPerDisputeWon  = 50
+	ReputationPerDisputeLost = 200
+
+	// MaxReputationScore caps Reputation.Score.
+	MaxReputationScore = 10_000
+)
+
+// Reputation aggregates the track record of an agent.
+type Reputation struct {
+	VerifiedInferences  uint64 `json:"verifiedInferences"`
+	AttestedInferences  uint64 `json:"attestedInferences"`  // of VerifiedInferences, TEE-attested
+	ProvenInferences    uint64 `json:"provenInferences"`    // of VerifiedInferences, zkML-proven
+	EvaluatedInferences uint64 `json:"evaluatedInferences"` // scored by the oracle committee
+	QualityTotal        uint64 `json:"qualityTotal"`        // sum of their median quality scores
+	CompletedTasks      uint64 `json:"completedTasks"`
+	DisputesWon         uint64 `json:"disputesWon"`
+	DisputesLost        uint64 `json:"disputesLost"`
+	Score               uint64 `json:"score"`     // 0 to MaxReputationScore
+	UpdatedAt           uint64 `json:"updatedAt"` // block height, 0 if never
+}
+
+// score computes the score of r from its counters.
+func (r *Reputation) score() uint64 {
+	earned := ReputationPerInference*r.VerifiedInferences +
+		ReputationPerAttestation*r.AttestedInferences +
+		ReputationPerProof*r.ProvenInferences +
+		ReputationPerQuality*r.QualityTotal/transaction.MaxQualityScore +
+		ReputationPerTask*r.CompletedTasks +
+		ReputationPerDisputeWon*r.DisputesWon
+	lost := ReputationPerDisputeLost * r.DisputesLost
+	if lost >= earned {
+		return 0
+	}
+	if earned-lost > MaxReputationScore {
+		return MaxReputationScore
+	}
+	return earned - lost
+}
+
+// GetReputation returns the reputation of the agent did.
+func (s *StateDB) GetReputation(did string) (Reputation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rec, ok := s.agents[did]
+	if !ok {
+		return Reputation{}, ErrAgentNotFound
+	}
+	return rec.Reputation, nil
+}
+
+// RecordInference credits the agent did with a verified inference receipt
+// of trust tier trust at height. Receipts of unregistered agents earn
+// nothing.
+func (s *StateDB) RecordInference(did string, trust int, height uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updateReputation(did, height, func(r *Reputation) {
+		r.VerifiedInferences++
+		switch trust {
+		case TrustAttested:
+			r.AttestedInferences++
+		case TrustProven:
+			r.ProvenInferences++
+		}
+	})
+}
+
+// RecordDisputeOutcome credits or debits the agent did with a dispute
+// resolved at height.
+func (s *StateDB) RecordDisputeOutcome(did string, won bool, height uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updateReputation(did, height, func(r *Reputation) {
+		if won {
+			r.DisputesWon++
+		} else {
+			r.DisputesLost++
+		}
+	})
+}
+
+// ControllerReputation returns the highest score among the active agents
+// controlled by addr, as a fraction of MaxReputationScore. The consensus
+// engine uses it as the Proof-of-Intelligence score of the validator addr.
+func (s *StateDB) ControllerReputation(addr string) float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if a, err := common.ParseAddress(addr); err == nil {
+		addr = a.String()
+	}
+	var best uint64
+	for _, id := range s.byController[addr] {
+		if rec := s.agents[id]; rec.Active && rec.Reputation.Score > best {
+			best = rec.Reputation.Score
+		}
+	}
+	return float64(best) / MaxReputationScore
+}
+
+// trackTask records the TASK or RESULT message msg at height: a TASK opens
+// a task for its recipient, and a RESULT closes one the sender owes its
+// recipient and credits the sender. The caller holds s.mu.
+func (s *StateDB) trackTask(msg transaction.AgentMessage, height uint64) {
+	key := taskKey(msg.From, msg.To)
+	switch msg.Type {
+	case transaction.MsgTask:
+		if s.openTasks == nil {
+			s.openTasks = make(map[string]uint64)
+		}
+		s.openTasks[key]++
+	case transaction.MsgResult:
+		key = taskKey(msg.To, msg.From)
+		if s.openTasks[key] == 0 {
+			return
+		}
+		if s.openTasks[key]--; s.openTasks[key] == 0 {
+			delete(s.openTasks, key)
+		}
+		s.updateReputation(msg.From, height, func(r *Reputation) { r.CompletedTasks++ })
+	}
+}
+
+// taskKey identifies the tasks assigned by one agent to another.
+func taskKey(from, to string) string {
+	return from + "\x00" + to
+}
+
+// updateReputation applies update to the reputation of did, if registered,
+// and rescores it. The caller holds s.mu.
+func (s *StateDB) updateReputation(did string, height uint64, update func(*Reputation)) {
+	rec, ok := s.agents[did]
+	if !ok {
+		return
+	}
+	update(&rec.Reputation)
+	rec.Reputation.Score = rec.Reputation.score()
+	rec.Reputation.UpdatedAt = height
+}
@@ -0,0 +1,128 @@
+package state
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// A receipt carrying a Commitment commits its submitter to reveal the data
+// behind it within RevealWindow blocks of its inclusion. Anyone holding the
+// data may reveal it; the reveal is kept in state for the verifiers of a
+// dispute to re-run the inference on. A commitment that lapses unrevealed
+// while the receipt is disputed decides the dispute against the receipt.
+
+var (
+	ErrNoCommitment       = errors.New("receipt carries no commitment")
+	ErrAlreadyRevealed    = errors.New("receipt data is already revealed")
+	ErrRevealWindowClosed = errors.New("reveal window of the receipt has closed")
+	ErrCommitmentMismatch = errors.New("revealed data does not match the commitment")
+	ErrRevealNotFound     = errors.New("receipt data is not revealed")
+)
+
+// Commitment statuses of a receipt.
+const (
+	CommitmentPending  = "committed" // awaiting its reveal
+	CommitmentRevealed = "revealed"
+	CommitmentLapsed   = "lapsed" // the reveal window closed unrevealed
+)
+
+// RevealInference records the reveal v of the data committed to by a
+// receipt at height.
+func (s *StateDB) RevealInference(v transaction.InferenceReveal, height uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := receiptKey(v.ReceiptHash)
+	rec, ok := s.receipts[key]
+	if !ok {
+		return ErrReceiptNotFound
+	}
+	switch rec.Commitment {
+	case "":
+		return ErrNoCommitment
+	case CommitmentRevealed:
+		return ErrAlreadyRevealed
+	case CommitmentLapsed:
+		return ErrRevealWindowClosed
+	}
+	if height >= rec.RevealDeadline {
+		return ErrRevealWindowClosed
+	}
+	c := v.Commitment()
+	if !bytes.Equal(c[:], rec.Receipt.Commitment) {
+		return ErrCommitmentMismatch
+	}
+	if len(v.Input) > 0 {
+		if h := sha256.Sum256(v.Input); !bytes.Equal(h[:], rec.Receipt.InputHash) {
+			return ErrCommitmentMismatch
+		}
+	}
+	if len(v.Output) > 0 {
+		if h := sha256.Sum256(v.Output); !bytes.Equal(h[:], rec.Receipt.OutputHash) {
+			return ErrCommitmentMismatch
+		}
+	}
+	if s.reveals == nil {
+		s.reveals = make(map[string]*transaction.InferenceReveal)
+	}
+	s.reveals[key] = &v
+	s.setCommitmentStatus(key, CommitmentRevealed)
+	return nil
+}
+
+// GetReveal returns the revealed data of the receipt included by the
+// transaction hash.
+func (s *StateDB) GetReveal(hash []byte) (*transaction.InferenceReveal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.reveals[receiptKey(hash)]
+	if !ok {
+		return nil, ErrRevealNotFound
+	}
+	return copyReveal(v), nil
+}
+
+// settleReveals lapses the commitments whose reveal window has closed by
+// height, deciding the open disputes of their receipts against them. The
+// caller holds s.mu.
+func (s *StateDB) settleReveals(height uint64) {
+	n := 0
+	for ; n < len(s.pendingReveals); n++ {
+		key := s.pendingReveals[n]
+		rec := s.receipts[key]
+		if rec.Commitment == CommitmentPending && height < rec.RevealDeadline {
+			break
+		}
+		if rec.Commitment != CommitmentPending {
+			continue
+		}
+		s.setCommitmentStatus(key, CommitmentLapsed)
+		if d, ok := s.disputes[key]; ok && d.Status == DisputeOpen {
+			s.resolveDispute(key, false, height)
+		}
+	}
+	s.pendingReveals = s.pendingReveals[n:]
+}
+
+// setCommitmentStatus replaces the receipt under key with one whose
+// commitment is in status. The caller holds s.mu.
+func (s *StateDB) setCommitmentStatus(key, status string) {
+	r := *s.receipts[key]
+	r.Commitment = status
+	s.receipts[key] = &r
+}
+
+// copyReveals returns a copy of s.reveals. Reveals are never modified, so
+// they are shared. The caller holds s.mu.
+func (s *StateDB) copyReveals() map[string]*transaction.InferenceReveal {
+	if len(s.reveals) == 0 {
+		return nil
+	}
+	cp := make(map[string]*transaction.InferenceReveal, len(s.reveals))
+	for k, v := range s.reveals {
+		cp[k] = v
+	}
+	return cp
+}
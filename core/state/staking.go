@@ -0,0 +1,345 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// A validator bonds stake of its own and may receive stake delegated by
+// other accounts. Its self-stake is held as its delegation to itself, so
+// that the bonded stake of a validator is the sum of the delegations to
+// it. The block rewards of a validator are shared out as they are earned:
+// the validator keeps its commission and the rest is credited to its
+// delegators, itself included, in proportion to their stake. Withdrawn
+// stake unbonds for the UnbondingPeriod of the staking parameters before
+// it is returned, so that it remains at stake for misbehaviour of the
+// validator in that time. A validator without any stake left is removed.
+//
+// Proposer turns go round the validators that are not jailed in address
+// order. A validator that misses MaxMissedBlocks turns in a row is jailed:
+// it has no turns until it sends a TxValidatorUnjail, which it may do
+// once JailPeriod blocks have passed.
+
+var (
+	ErrValidatorExists    = errors.New("validator already registered")
+	ErrValidatorNotFound  = errors.New("validator not found")
+	ErrStakeNotFound      = errors.New("no stake delegated to the validator")
+	ErrInsufficientStake  = errors.New("amount exceeds the delegated stake")
+	ErrInvalidStake       = errors.New("stake amount must be positive")
+	ErrValidatorJailed    = errors.New("validator is jailed")
+	ErrValidatorNotJailed = errors.New("validator is not jailed")
+)
+
+// StakingValidator is a validator registered by a TxValidatorStake
+// transaction.
+type StakingValidator struct {
+	Address     string                           `json:"address"`
+	Description transaction.ValidatorDescription `json:"description"`
+	Tokens      *big.Int                         `json:"tokens"` // bonded stake, delegations included
+	CreatedAt   uint64                           `json:"createdAt"`
+
+	MissedBlocks uint64 `json:"missedBlocks"` // proposer turns missed in a row
+	Jailed       bool   `json:"jailed"`
+	JailedUntil  uint64 `json:"jailedUntil,omitempty"` // first height it may unjail at
+}
+
+// StakeDelegation is the stake an account has delegated to a validator.
+type StakeDelegation struct {
+	Delegator string   `json:"delegator"`
+	Validator string   `json:"validator"`
+	Amount    *big.Int `json:"amount"`
+	Rewards   *big.Int `json:"rewards"` // block rewards credited, in total
+	Height    uint64   `json:"height"`  // of the last change of Amount
+}
+
+// StakeUnbonding is withdrawn stake awaiting its release.
+type StakeUnbonding struct {
+	Delegator  string   `json:"delegator"`
+	Validator  string   `json:"validator"`
+	Amount     *big.Int `json:"amount"`
+	CompleteAt uint64   `json:"completeAt"` // height the stake is released at
+}
+
+func stakeKey(delegator, validator string) string {
+	return delegator + "/" + validator
+}
+
+// CreateValidator registers addr as a validator described by desc at
+// height, bonding amount of its balance as its self-stake.
+func (s *StateDB) CreateValidator(desc transaction.ValidatorDescription, addr string, amount *big.Int, height uint64) error {
+	if amount == nil || amount.Sign() <= 0 {
+		return ErrInvalidStake
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.stakingValidators[addr]; ok {
+		return ErrValidatorExists
+	}
+	if err := s.escrow(addr, amount); err != nil {
+		return err
+	}
+	if s.stakingValidators == nil {
+		s.stakingValidators = make(map[string]*StakingValidator)
+	}
+	s.stakingValidators[addr] = &StakingValidator{Address: addr, Description: desc, Tokens: new(big.Int), CreatedAt: height}
+	s.bond(addr, addr, amount, height)
+	return nil
+}
+
+// DelegateStake bonds amount of the balance of delegator to validator at
+// height. A validator adds to its self-stake by delegating to itself.
+func (s *StateDB) DelegateStake(delegator, validator string, amount *big.Int, height uint64) error {
+	if amount == nil || amount.Sign() <= 0 {
+		return ErrInvalidStake
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.stakingValidators[validator]; !ok {
+		return ErrValidatorNotFound
+	}
+	if err := s.escrow(delegator, amount); err != nil {
+		return err
+	}
+	s.bond(delegator, validator, amount, height)
+	return nil
+}
+
+// UndelegateStake withdraws amount of the stake delegator has delegated to
+// validator at height. The amount is released UnbondingPeriod blocks
+// later.
+func (s *StateDB) UndelegateStake(delegator, validator string, amount *big.Int, height uint64) error {
+	if amount == nil || amount.Sign() <= 0 {
+		return ErrInvalidStake
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.stakingValidators[validator]
+	if !ok {
+		return ErrValidatorNotFound
+	}
+	key := stakeKey(delegator, validator)
+	d, ok := s.stakes[key]
+	if !ok {
+		return ErrStakeNotFound
+	}
+	if amount.Cmp(d.Amount) > 0 {
+		return ErrInsufficientStake
+	}
+	if rest := new(big.Int).Sub(d.Amount, amount); rest.Sign() == 0 {
+		delete(s.stakes, key)
+	} else {
+		nd := *d
+		nd.Amount, nd.Height = rest, height
+		s.stakes[key] = &nd
+	}
+	if tokens := new(big.Int).Sub(v.Tokens, amount); tokens.Sign() == 0 {
+		delete(s.stakingValidators, validator)
+	} else {
+		nv := *v
+		nv.Tokens = tokens
+		s.stakingValidators[validator] = &nv
+	}
+	u := &StakeUnbonding{Delegator: delegator, Validator: validator, Amount: new(big.Int).Set(amount), CompleteAt: height + s.stakingParams.UnbondingPeriod}
+	s.unbondings = append(s.unbondings[:len(s.unbondings):len(s.unbondings)], u)
+	return nil
+}
+
+// bond adds amount to the delegation of delegator to validator, which is
+// registered. The caller holds s.mu.
+func (s *StateDB) bond(delegator, validator string, amount *big.Int, height uint64) {
+	if s.stakes == nil {
+		s.stakes = make(map[string]*StakeDelegation)
+	}
+	key := stakeKey(delegator, validator)
+	d := &StakeDelegation{Delegator: delegator, Validator: validator, Amount: new(big.Int), Rewards: new(big.Int)}
+	if prev, ok := s.stakes[key]; ok {
+		cp := *prev
+		d = &cp
+	}
+	d.Amount, d.Height = new(big.Int).Add(d.Amount, amount), height
+	s.stakes[key] = d
+	v := *s.stakingValidators[validator]
+	v.Tokens = new(big.Int).Add(v.Tokens, amount)
+	s.stakingValidators[validator] = &v
+}
+
+// distributeReward credits a block reward of validator: its commission
+// to the validator and the rest to its delegators in proportion to their
+// stake, the remainder of the division going to the validator. The reward
+// of an address not registered as a validator is credited to it in full.
+// The caller holds s.mu.
+func (s *StateDB) distributeReward(validator string, reward *big.Int) {
+	v, ok := s.stakingValidators[validator]
+	if !ok || v.Tokens.Sign() == 0 {
+		s.release(validator, reward)
+		return
+	}
+	commission := new(big.Int).Mul(reward, new(big.Int).SetUint64(v.Description.Commission))
+	commission.Div(commission, big.NewInt(transaction.MaxCommission))
+	pool := new(big.Int).Sub(reward, commission)
+	rest := new(big.Int).Set(pool)
+	for key, d := range s.stakes {
+		if d.Validator != validator {
+			continue
+		}
+		share := new(big.Int).Mul(pool, d.Amount)
+		share.Div(share, v.Tokens)
+		if share.Sign() == 0 {
+			continue
+		}
+		s.release(d.Delegator, share)
+		rest.Sub(rest, share)
+		nd := *d
+		nd.Rewards = new(big.Int).Add(d.Rewards, share)
+		s.stakes[key] = &nd
+	}
+	s.release(validator, commission.Add(commission, rest))
+}
+
+// RecordProposer records the block proposed by proposer at height. The
+// turn at height belongs to the validator at index height modulo the
+// number of validators not jailed; if that is not proposer, it missed the
+// turn. The consensus engine calls it once per block.
+func (s *StateDB) RecordProposer(proposer string, height uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	active := make([]*StakingValidator, 0, len(s.stakingValidators))
+	for _, v := range s.stakingValidators {
+		if !v.Jailed {
+			active = append(active, v)
+		}
+	}
+	if len(active) == 0 {
+		return
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].Address < active[j].Address })
+	if v, ok := s.stakingValidators[canonicalAddress(proposer)]; ok && v.MissedBlocks > 0 {
+		nv := *v
+		nv.MissedBlocks = 0
+		s.stakingValidators[v.Address] = &nv
+	}
+	v := active[height%uint64(len(active))]
+	if sameAddress(v.Address, proposer) {
+		return
+	}
+	nv := *v
+	nv.MissedBlocks++
+	if max := s.stakingParams.MaxMissedBlocks; max > 0 && nv.MissedBlocks >= max {
+		nv.MissedBlocks, nv.Jailed, nv.JailedUntil = 0, true, height+s.stakingParams.JailPeriod
+	}
+	s.stakingValidators[v.Address] = &nv
+}
+
+// UnjailValidator returns the jailed validator addr to the proposer turns
+// at height, if its JailPeriod has passed.
+func (s *StateDB) UnjailValidator(addr string, height uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.stakingValidators[addr]
+	if !ok {
+		return ErrValidatorNotFound
+	}
+	if !v.Jailed {
+		return ErrValidatorNotJailed
+	}
+	if height < v.JailedUntil {
+		return fmt.Errorf("%w until height %d", ErrValidatorJailed, v.JailedUntil)
+	}
+	nv := *v
+	nv.Jailed, nv.JailedUntil = false, 0
+	s.stakingValidators[addr] = &nv
+	return nil
+}
+
+// SettleStaking releases the unbonding stake whose unbonding has completed
+// by height. The consensus engine calls it once per block.
+func (s *StateDB) SettleStaking(height uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rest []*StakeUnbonding
+	for _, u := range s.unbondings {
+		if height >= u.CompleteAt {
+			s.release(u.Delegator, u.Amount)
+			continue
+		}
+		rest = append(rest, u)
+	}
+	s.unbondings = rest
+}
+
+// GetStakingValidator returns the validator addr.
+func (s *StateDB) GetStakingValidator(addr string) (*StakingValidator, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.stakingValidators[canonicalAddress(addr)]
+	if !ok {
+		return nil, ErrValidatorNotFound
+	}
+	return v.Copy(), nil
+}
+
+// StakingValidators returns the registered validators ordered by address.
+func (s *StateDB) StakingValidators() []*StakingValidator {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*StakingValidator, 0, len(s.stakingValidators))
+	for _, v := range s.stakingValidators {
+		out = append(out, v.Copy())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Address < out[j].Address })
+	return out
+}
+
+// StakeDelegations returns the delegations of delegator ordered by
+// validator.
+func (s *StateDB) StakeDelegations(delegator string) []*StakeDelegation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*StakeDelegation, 0)
+	for _, d := range s.stakes {
+		if sameAddress(d.Delegator, delegator) {
+			out = append(out, d.Copy())
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Validator < out[j].Validator })
+	return out
+}
+
+// StakeUnbondings returns the unbonding stake of delegator, soonest
+// released first.
+func (s *StateDB) StakeUnbondings(delegator string) []*StakeUnbonding {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*StakeUnbonding, 0)
+	for _, u := range s.unbondings {
+		if sameAddress(u.Delegator, delegator) {
+			out = append(out, u.Copy())
+		}
+	}
+	return out
+}
+
+// copyStaking returns copies of s.stakingValidators and s.stakes.
+// Validators and delegations are replaced rather than modified, so they
+// are shared. The caller holds s.mu.
+func (s *StateDB) copyStaking() (map[string]*StakingValidator, map[string]*StakeDelegation) {
+	var validators map[string]*StakingValidator
+	if len(s.stakingValidators) > 0 {
+		validators = make(map[string]*StakingValidator, len(s.stakingValidators))
+		for k, v := range s.stakingValidators {
+			validators[k] = v
+		}
+	}
+	var stakes map[string]*StakeDelegation
+	if len(s.stakes) > 0 {
+		stakes = make(map[string]*StakeDelegation, len(s.stakes))
+		for k, d := range s.stakes {
+			stakes[k] = d
+		}
+	}
+	return validators, stakes
+}
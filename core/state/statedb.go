@@ -1,8 +1,8 @@
 package state
 
 import (
-	"encoding/json"
 	"errors"
+	"fmt"
 	"math/big"
 	"sync"
 
@@ -14,14 +14,19 @@ var (
 	ErrInsufficientBalance = errors.New("insufficient balance")
 	ErrAgentNotFound = errors.New("agent not found")
 	ErrAgentAlreadyRegistered = errors.New("agent already registered")
+	ErrAgentInactive = errors.New("agent is deactivated")
+	ErrAgentActive = errors.New("agent is already active")
+	ErrNotAgentController = errors.New("sender is not the agent's controller")
+	ErrMessageNonce = errors.New("invalid agent message nonce")
 )
 
 // Account holds the state of an address.
 type Account struct {
-	Address string   `json:"address"`
-	Balance *big.Int `json:"balance"`
-	Nonce   uint64   `json:"nonce"`
-	Code    []byte   `json:"code,omitempty"` // AVM bytecode if contract
+	Address string            `json:"address"`
+	Balance *big.Int          `json:"balance"`
+	Nonce   uint64            `json:"nonce"`
+	Code    []byte            `json:"code,omitempty"` // AVM bytecode if contract
+	Storage map[string][]byte `json:"storage,omitempty"`
 }
 
 // AgentRecord stores on-chain agent metadata.
@@ -30,26 +35,205 @@ type AgentRecord struct {
 	RegisteredAt uint64               `json:"registeredAt"` // block height
 	MessageCount uint64               `json:"messageCount"`
 	Active       bool                 `json:"active"`
+	Lifecycle    []LifecycleEvent     `json:"lifecycle"` // oldest first; see lifecycle.go
+	MessageNonce uint64               `json:"messageNonce"` // nonce of the next message from the agent
+	Reputation   Reputation           `json:"reputation"` // see reputation.go
 }
 
 // StateDB is the in-memory world state.
 // In production this wraps an iavl MerkleTrie.
 type StateDB struct {
 	mu       sync.RWMutex
+	journal  journal // undo entries of the open checkpoints; see journal.go
 	accounts map[string]*Account
 	agents   map[string]*AgentRecord // keyed by DID.ID
 	messages []transaction.AgentMessage
+	mailboxes map[string]*Mailbox // per-DID message indexes; see mailbox.go
+	openTasks map[string]uint64   // unanswered TASK messages by sender and recipient; see reputation.go
+
+	// delegations maps a delegate DID to the delegations it received,
+	// keyed by delegating DID and capability; see delegation.go.
+	delegations map[string]map[string]*DelegationRecord
+
+	// offers maps agent and capability name to service offers, and
+	// offersByCapability files their keys by capability; see market.go.
+	offers             map[string]*Offer
+	offersByCapability agentIndex
+
+	// receipts maps inference receipt transaction hashes to their records,
+	// indexed by agent and model hash; see receipt.go. pendingReceipts lists
+	// those within their challenge window, oldest first, and disputes maps
+	// them to their disputes; see dispute.go.
+	receipts        map[string]*ReceiptRecord
+	receiptsByAgent agentIndex
+	receiptsByModel agentIndex
+	pendingReceipts []string
+	disputes        map[string]*Dispute
+
+	// dataAttestations maps off-chain payloads, by network and content
+	// identifier, to the attestations of their retention; see da.go.
+	dataAttestations map[string][]*DataAttestationRecord
+
+	// batches maps the hashes of transactions including receipt batches to
+	// the batches; see batch.go.
+	batches map[string]*BatchRecord
+
+	// reveals maps receipts to their revealed data and pendingReveals lists
+	// the receipts awaiting a reveal, oldest first; see reveal.go.
+	reveals        map[string]*transaction.InferenceReveal
+	pendingReveals []string
+	disputeParams   DisputeParams
+
+	// providers maps addresses to compute providers; see provider.go.
+	providers map[string]*Provider
+
+	// enclaves holds the approved TEE enclave images by platform and
+	// measurement, and attestationRoots the DER root certificates trusted
+	// for each platform; see enclave.go.
+	enclaves         map[string]*EnclaveImage
+	attestationRoots map[string][][]byte
+
+	// evaluators holds the quality oracle committee and evaluations its
+	// scoring of receipts, pendingEvaluations listing the open ones, oldest
+	// first; see oracle.go.
+	evaluators         map[string]*Evaluator
+	evaluations        map[string]*Evaluation
+	pendingEvaluations []string
+	oracleParams       OracleParams
+
+	// stakingValidators maps addresses to validators, stakes the
+	// delegations to them by delegator and validator, and unbondings lists
+	// withdrawn stake, soonest released first; see staking.go.
+	stakingValidators map[string]*StakingValidator
+	stakes            map[string]*StakeDelegation
+	unbondings        []*StakeUnbonding
+
+	// minted and burned count the tokens minted and burned, treasury holds
+	// the community treasury and tokenomics configures the block reward;
+	// see supply.go.
+	minted     *big.Int
+	burned     *big.Int
+	treasury   *big.Int
+	tokenomics TokenomicsParams
+
+	// proposals maps IDs to governance proposals and pendingProposals
+	// lists those in their deposit or voting period in submission order;
+	// upgradePlan is the software upgrade passed by governance, if any,
+	// and govParams configures governance; see gov.go.
+	proposals        map[uint64]*Proposal
+	nextProposalID   uint64
+	pendingProposals []uint64
+	upgradePlan      *transaction.UpgradePlan
+	govParams        GovParams
+
+	// consensusParams, vmParams and stakingParams configure block
+	// production, transaction execution and staking; paramForks lists the
+	// parameter changes scheduled by height, soonest first, and
+	// paramHistory those activated; see params.go.
+	consensusParams ConsensusParams
+	vmParams        VMParams
+	stakingParams   StakingParams
+	paramForks      []*ParamVersion
+	paramHistory    []*ParamVersion
+
+	// ibcClients, ibcConnections and ibcChannels hold the IBC clients of
+	// counterparty chains and the connection and channel ends over them,
+	// ibcCommitments the packets sent and not yet acknowledged, ibcAcks
+	// the packets received, and ibcVouchers the balances of tokens received
+	// from counterparties by denomination and owner; see ibc.go.
+	ibcClients     map[string]*IBCClient
+	ibcConnections map[string]*IBCConnection
+	ibcChannels    map[string]*IBCChannel
+	ibcCommitments map[string]*transaction.IBCPacket
+	ibcAcks        map[string]*IBCPacketAck
+	ibcVouchers    map[string]*big.Int
+
+	// bridgeDeposits maps content hashes to the attested deposits of the
+	// external chain and bridgeProcessed the IDs of the executed deposit
+	// events to them, bridgeWithdrawals lists the withdrawals by nonce,
+	// bridgeLocked is the ZIO escrowed and bridgeWrapped the balances of
+	// wrapped tokens by token and owner; see bridge.go.
+	bridgeDeposits    map[string]*BridgeDepositRecord
+	bridgeProcessed   map[string]string
+	bridgeWithdrawals []*BridgeWithdrawal
+	bridgeLocked      *big.Int
+	bridgeWrapped     map[string]*big.Int
+	bridgeParams      BridgeParams
+
+	// tokens maps symbols to the ZRC-20 tokens, tokenBalances holds their
+	// balances by symbol and holder and tokenAllowances the allowances by
+	// symbol, owner and spender; see token.go.
+	tokens          map[string]*Token
+	tokenBalances   map[string]*big.Int
+	tokenAllowances map[string]*big.Int
+
+	// nftCollections maps symbols to the ZRC-721 collections and nfts
+	// their tokens by key, indexed by owner and by collection; see nft.go.
+	nftCollections   map[string]*NFTCollection
+	nfts             map[string]*NFT
+	nftsByOwner      agentIndex
+	nftsByCollection agentIndex
+
+	// models maps model hashes to the model registry; see model.go.
+	models map[string]*Model
+
+	// verifyingKeys maps model hashes to the zkML verifying keys of their
+	// circuits; see zkml.go.
+	verifyingKeys map[string][]byte
+
+	// Secondary agent indexes; see agentindex.go.
+	agentIDs     []string
+	byController agentIndex
+	byCapability agentIndex
 }
 
 // NewStateDB initializes a fresh StateDB.
 func NewStateDB() *StateDB {
 	return &StateDB{
-		accounts: make(map[string]*Account),
-		agents:   make(map[string]*AgentRecord),
+		accounts:     make(map[string]*Account),
+		agents:       make(map[string]*AgentRecord),
+		byController: make(agentIndex),
+		byCapability: make(agentIndex),
+		offersByCapability: make(agentIndex),
+		nftsByOwner: make(agentIndex),
+		nftsByCollection: make(agentIndex),
+		receiptsByAgent: make(agentIndex),
+		receiptsByModel: make(agentIndex),
+		disputeParams: DefaultDisputeParams(),
+		oracleParams: DefaultOracleParams(),
+		minted: new(big.Int),
+		burned: new(big.Int),
+		treasury: new(big.Int),
+		tokenomics: DefaultTokenomicsParams(),
+		govParams: DefaultGovParams(),
+		consensusParams: DefaultConsensusParams(),
+		vmParams: DefaultVMParams(),
+		stakingParams: DefaultStakingParams(),
+		bridgeLocked: new(big.Int),
+		bridgeParams: DefaultBridgeParams(),
+	}
+}
+
+// Copy returns a deep copy of the account.
+func (a *Account) Copy() *Account {
+	cp := &Account{
+		Address: a.Address,
+		Balance: new(big.Int).Set(a.Balance),
+		Nonce:   a.Nonce,
+		Code:    append([]byte(nil), a.Code...),
+	}
+	if a.Storage != nil {
+		cp.Storage = make(map[string][]byte, len(a.Storage))
+		for k, v := range a.Storage {
+			cp.Storage[k] = append([]byte(nil), v...)
+		}
 	}
+	return cp
 }
 
-// GetAccount returns the account for an address, creating it if needed.
+// GetAccount returns a copy of the account of an address, an empty one if
+// it does not exist. Changing the copy does not change the state; use Update
+// or the setters for that.
 func (s *StateDB) GetAccount(addr string) *Account {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -57,28 +241,216 @@ func (s *StateDB) GetAccount(addr string) *Account {
 	if !ok {
 		return &Account{Address: addr, Balance: big.NewInt(0)}
 	}
-	return acc
+	return acc.Copy()
+}
+
+// GetBalance returns a copy of the balance of an address.
+func (s *StateDB) GetBalance(addr string) *big.Int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if acc, ok := s.accounts[addr]; ok {
+		return new(big.Int).Set(acc.Balance)
+	}
+	return new(big.Int)
+}
+
+// Update changes the account of an address, creating it if needed, by
+// calling fn with a copy under the state lock. The copy replaces the account
+// if fn returns nil and is discarded otherwise, so a failed update leaves the
+// account unchanged. fn must not call back into s.
+func (s *StateDB) Update(addr string, fn func(acc *Account) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.update(addr, func(acc *Account) error {
+		cp := acc.Copy()
+		if err := fn(cp); err != nil {
+			return err
+		}
+		cp.Address = addr
+		if cp.Balance == nil {
+			cp.Balance = new(big.Int)
+		}
+		*acc = *cp
+		return nil
+	})
+}
+
+// update is the path of every change to an account. It calls fn with the
+// account of addr, or a new one, and stores it if fn returns nil. Unlike
+// Update, fn changes the account in place, so it must fail before changing
+// anything. The change is journaled. The caller holds s.mu.
+func (s *StateDB) update(addr string, fn func(acc *Account) error) error {
+	s.saveAccount(addr)
+	acc, ok := s.accounts[addr]
+	if !ok {
+		acc = &Account{Address: addr, Balance: big.NewInt(0)}
+	}
+	if err := fn(acc); err != nil {
+		return err
+	}
+	s.accounts[addr] = acc
+	return nil
+}
+
+// credit adds amount to the balance of addr. The caller holds s.mu.
+func (s *StateDB) credit(addr string, amount *big.Int) {
+	s.update(addr, func(acc *Account) error {
+		acc.Balance.Add(acc.Balance, amount)
+		return nil
+	})
+}
+
+// debit subtracts amount from the balance of addr, or fails with
+// ErrInsufficientBalance. The caller holds s.mu.
+func (s *StateDB) debit(addr string, amount *big.Int) error {
+	return s.update(addr, func(acc *Account) error {
+		if acc.Balance.Cmp(amount) < 0 {
+			return ErrInsufficientBalance
+		}
+		acc.Balance.Sub(acc.Balance, amount)
+		return nil
+	})
 }
 
 // SetBalance sets the balance for an address.
 func (s *StateDB) SetBalance(addr string, balance *big.Int) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	acc := s.getOrCreate(addr)
-	acc.Balance = new(big.Int).Set(balance)
+	s.update(addr, func(acc *Account) error {
+		acc.Balance = new(big.Int).Set(balance)
+		return nil
+	})
+}
+
+// AddBalance credits amount to an address.
+func (s *StateDB) AddBalance(addr string, amount *big.Int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.credit(addr, amount)
+}
+
+// SubBalance debits amount from an address.
+func (s *StateDB) SubBalance(addr string, amount *big.Int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.debit(addr, amount)
+}
+
+// GetNonce returns the next expected nonce for an address.
+func (s *StateDB) GetNonce(addr string) uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if acc, ok := s.accounts[addr]; ok {
+		return acc.Nonce
+	}
+	return 0
+}
+
+// IncrementNonce advances the nonce of an address by one.
+func (s *StateDB) IncrementNonce(addr string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.update(addr, func(acc *Account) error {
+		acc.Nonce++
+		return nil
+	})
 }
 
 // Transfer moves value from one address to another.
 func (s *StateDB) Transfer(from, to string, value *big.Int) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	src := s.getOrCreate(from)
-	if src.Balance.Cmp(value) < 0 {
-		return ErrInsufficientBalance
+	if err := s.debit(from, value); err != nil {
+		return err
+	}
+	s.credit(to, value)
+	return nil
+}
+
+// GetCode returns the AVM bytecode stored at an address.
+func (s *StateDB) GetCode(addr string) []byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if acc, ok := s.accounts[addr]; ok {
+		return acc.Code
+	}
+	return nil
+}
+
+// SetCode sets the AVM bytecode of an address.
+func (s *StateDB) SetCode(addr string, code []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.update(addr, func(acc *Account) error {
+		acc.Code = append([]byte(nil), code...)
+		return nil
+	})
+}
+
+// GetStorage returns the value stored under key in a contract's storage.
+func (s *StateDB) GetStorage(addr string, key []byte) []byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	acc, ok := s.accounts[addr]
+	if !ok {
+		return nil
+	}
+	return acc.Storage[string(key)]
+}
+
+// SetStorage writes value under key in a contract's storage. An empty value
+// deletes the slot. It reports whether a previously non-empty slot was cleared.
+func (s *StateDB) SetStorage(addr string, key, value []byte) (cleared bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.update(addr, func(acc *Account) error {
+		s.saveSlot(acc, string(key))
+		_, existed := acc.Storage[string(key)]
+		if len(value) == 0 {
+			delete(acc.Storage, string(key))
+			cleared = existed
+			return nil
+		}
+		if acc.Storage == nil {
+			acc.Storage = make(map[string][]byte)
+		}
+		acc.Storage[string(key)] = append([]byte(nil), value...)
+		return nil
+	})
+	return cleared
+}
+
+// SelfDestruct moves the full balance of addr to beneficiary and removes the
+// account, including its code and storage.
+func (s *StateDB) SelfDestruct(addr, beneficiary string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acc, ok := s.accounts[addr]
+	if !ok {
+		return
+	}
+	if addr != beneficiary {
+		s.credit(beneficiary, acc.Balance)
+	}
+	s.saveAccount(addr)
+	delete(s.accounts, addr)
+}
+
+// TransferBatch moves values[i] from one address to each to[i]. Either all
+// transfers are applied or none is.
+func (s *StateDB) TransferBatch(from string, to []string, values []*big.Int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	total := new(big.Int)
+	for _, v := range values {
+		total.Add(total, v)
+	}
+	if err := s.debit(from, total); err != nil {
+		return err
+	}
+	for i, addr := range to {
+		s.credit(addr, values[i])
 	}
-	dst := s.getOrCreate(to)
-	src.Balance.Sub(src.Balance, value)
-	dst.Balance.Add(dst.Balance, value)
 	return nil
 }
 
@@ -89,15 +461,22 @@ func (s *StateDB) RegisterAgent(did transaction.AgentDID, blockHeight uint64) er
 	if _, exists := s.agents[did.ID]; exists {
 		return ErrAgentAlreadyRegistered
 	}
-	s.agents[did.ID] = &AgentRecord{
+	rec := &AgentRecord{
 		DID:          did,
 		RegisteredAt: blockHeight,
 		Active:       true,
+		Lifecycle:    []LifecycleEvent{{Action: LifecycleRegistered, Height: blockHeight}},
 	}
+	s.agents[did.ID] = rec
+	s.indexAgent(rec)
+	s.journal.record(func() {
+		delete(s.agents, did.ID)
+		s.unindexAgent(rec)
+	})
 	return nil
 }
 
-// GetAgent returns the agent record for a DID.
+// GetAgent returns a copy of the agent record for a DID.
 func (s *StateDB) GetAgent(didID string) (*AgentRecord, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -105,35 +484,192 @@ func (s *StateDB) GetAgent(didID string) (*AgentRecord, error) {
 	if !ok {
 		return nil, ErrAgentNotFound
 	}
-	return rec, nil
+	return rec.Copy(), nil
 }
 
-// StoreMessage appends an agent message to the log.
-func (s *StateDB) StoreMessage(msg transaction.AgentMessage) {
+// StoreMessage appends an agent message to the log and advances the
+// message nonce of the sending agent. Messages from or to a deactivated
+// agent are rejected with ErrAgentInactive. Callers check the sender with
+// AuthorizeMessage first. TASK and RESULT messages also count towards the
+// reputation of the agents exchanging them.
+func (s *StateDB) StoreMessage(msg transaction.AgentMessage, height uint64) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	return s.storeMessage(msg, height)
+}
+
+// storeMessage is StoreMessage with s.mu held.
+func (s *StateDB) storeMessage(msg transaction.AgentMessage, height uint64) error {
+	for _, id := range []string{msg.From, msg.To} {
+		if rec, ok := s.agents[id]; ok && !rec.Active {
+			return fmt.Errorf("%w: %s", ErrAgentInactive, id)
+		}
+	}
+	s.saveMessage(msg)
+	s.indexMessage(msg, uint64(len(s.messages)))
 	s.messages = append(s.messages, msg)
 	if rec, ok := s.agents[msg.From]; ok {
 		rec.MessageCount++
+		rec.MessageNonce++
 	}
+	s.trackTask(msg, height)
+	return nil
 }
 
-// Snapshot serializes the full state to JSON (simplified; production uses MerkleTrie).
-func (s *StateDB) Snapshot() ([]byte, error) {
+// Copy returns a deep copy of the state that can be mutated without
+// affecting the original. Used for read-only calls and gas estimation.
+func (s *StateDB) Copy() *StateDB {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	type snap struct {
-		Accounts map[string]*Account      `json:"accounts"`
-		Agents   map[string]*AgentRecord  `json:"agents"`
+	cp := NewStateDB()
+	for addr, acc := range s.accounts {
+		cp.accounts[addr] = acc.Copy()
+	}
+	for id, rec := range s.agents {
+		r := *rec
+		cp.agents[id] = &r
 	}
-	return json.Marshal(snap{Accounts: s.accounts, Agents: s.agents})
+	cp.messages = append([]transaction.AgentMessage(nil), s.messages...)
+	cp.mailboxes = s.copyMailboxes()
+	if len(s.openTasks) > 0 {
+		cp.openTasks = make(map[string]uint64, len(s.openTasks))
+		for k, n := range s.openTasks {
+			cp.openTasks[k] = n
+		}
+	}
+	if len(s.delegations) > 0 {
+		cp.delegations = make(map[string]map[string]*DelegationRecord, len(s.delegations))
+		for to, in := range s.delegations {
+			m := make(map[string]*DelegationRecord, len(in))
+			for k, d := range in {
+				r := *d
+				m[k] = &r
+			}
+			cp.delegations[to] = m
+		}
+	}
+	cp.agentIDs = append([]string(nil), s.agentIDs...)
+	cp.byController = s.byController.copy()
+	cp.byCapability = s.byCapability.copy()
+	cp.offers = s.copyOffers()
+	cp.offersByCapability = s.offersByCapability.copy()
+	cp.receipts, cp.disputes = s.copyDisputes()
+	cp.receiptsByAgent = s.receiptsByAgent.copy()
+	cp.receiptsByModel = s.receiptsByModel.copy()
+	cp.pendingReceipts = append([]string(nil), s.pendingReceipts...)
+	cp.reveals = s.copyReveals()
+	cp.batches = s.copyBatches()
+	cp.dataAttestations = s.copyDataAttestations()
+	cp.pendingReveals = append([]string(nil), s.pendingReveals...)
+	cp.disputeParams = s.disputeParams
+	cp.oracleParams = s.oracleParams
+	cp.providers = s.copyProviders()
+	cp.enclaves, cp.attestationRoots = s.copyEnclaves()
+	cp.verifyingKeys = s.copyVerifyingKeys()
+	cp.models = s.copyModels()
+	cp.evaluators, cp.evaluations = s.copyOracle()
+	cp.pendingEvaluations = append([]string(nil), s.pendingEvaluations...)
+	cp.stakingValidators, cp.stakes = s.copyStaking()
+	cp.unbondings = append([]*StakeUnbonding(nil), s.unbondings...)
+	cp.minted.Set(s.minted)
+	cp.burned.Set(s.burned)
+	cp.treasury.Set(s.treasury)
+	cp.tokenomics = s.tokenomics
+	cp.proposals = s.copyProposals()
+	cp.nextProposalID = s.nextProposalID
+	cp.pendingProposals = append([]uint64(nil), s.pendingProposals...)
+	cp.upgradePlan = s.upgradePlan
+	cp.govParams = s.govParams
+	cp.consensusParams = s.consensusParams
+	cp.vmParams = s.vmParams
+	cp.stakingParams = s.stakingParams
+	cp.paramForks = append([]*ParamVersion(nil), s.paramForks...)
+	cp.paramHistory = append([]*ParamVersion(nil), s.paramHistory...)
+	cp.ibcClients, cp.ibcConnections, cp.ibcChannels, cp.ibcCommitments, cp.ibcAcks, cp.ibcVouchers = s.copyIBC()
+	cp.bridgeDeposits, cp.bridgeProcessed, cp.bridgeWrapped = s.copyBridge()
+	cp.bridgeWithdrawals = append([]*BridgeWithdrawal(nil), s.bridgeWithdrawals...)
+	cp.bridgeLocked = s.bridgeLocked
+	cp.bridgeParams = s.bridgeParams
+	cp.tokens, cp.tokenBalances, cp.tokenAllowances = s.copyTokens()
+	cp.nftCollections, cp.nfts = s.copyNFTs()
+	cp.nftsByOwner = s.nftsByOwner.copy()
+	cp.nftsByCollection = s.nftsByCollection.copy()
+	return cp
 }
 
-func (s *StateDB) getOrCreate(addr string) *Account {
-	if acc, ok := s.accounts[addr]; ok {
-		return acc
-	}
-	acc := &Account{Address: addr, Balance: big.NewInt(0)}
-	s.accounts[addr] = acc
-	return acc
+// Replace sets the contents of s to those of st, typically a Copy of s
+// that a block was executed on, so that readers of s see the block applied
+// at once or not at all. st must not be used afterwards.
+func (s *StateDB) Replace(st *StateDB) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.journal = journal{}
+	s.accounts = st.accounts
+	s.agents = st.agents
+	s.messages = st.messages
+	s.mailboxes = st.mailboxes
+	s.openTasks = st.openTasks
+	s.delegations = st.delegations
+	s.offers = st.offers
+	s.offersByCapability = st.offersByCapability
+	s.receipts = st.receipts
+	s.receiptsByAgent = st.receiptsByAgent
+	s.receiptsByModel = st.receiptsByModel
+	s.pendingReceipts = st.pendingReceipts
+	s.disputes = st.disputes
+	s.dataAttestations = st.dataAttestations
+	s.batches = st.batches
+	s.reveals = st.reveals
+	s.pendingReveals = st.pendingReveals
+	s.disputeParams = st.disputeParams
+	s.providers = st.providers
+	s.enclaves = st.enclaves
+	s.attestationRoots = st.attestationRoots
+	s.evaluators = st.evaluators
+	s.evaluations = st.evaluations
+	s.pendingEvaluations = st.pendingEvaluations
+	s.oracleParams = st.oracleParams
+	s.stakingValidators = st.stakingValidators
+	s.stakes = st.stakes
+	s.unbondings = st.unbondings
+	s.minted = st.minted
+	s.burned = st.burned
+	s.treasury = st.treasury
+	s.tokenomics = st.tokenomics
+	s.proposals = st.proposals
+	s.nextProposalID = st.nextProposalID
+	s.pendingProposals = st.pendingProposals
+	s.upgradePlan = st.upgradePlan
+	s.govParams = st.govParams
+	s.consensusParams = st.consensusParams
+	s.vmParams = st.vmParams
+	s.stakingParams = st.stakingParams
+	s.paramForks = st.paramForks
+	s.paramHistory = st.paramHistory
+	s.ibcClients = st.ibcClients
+	s.ibcConnections = st.ibcConnections
+	s.ibcChannels = st.ibcChannels
+	s.ibcCommitments = st.ibcCommitments
+	s.ibcAcks = st.ibcAcks
+	s.ibcVouchers = st.ibcVouchers
+	s.bridgeDeposits = st.bridgeDeposits
+	s.bridgeProcessed = st.bridgeProcessed
+	s.bridgeWithdrawals = st.bridgeWithdrawals
+	s.bridgeLocked = st.bridgeLocked
+	s.bridgeWrapped = st.bridgeWrapped
+	s.bridgeParams = st.bridgeParams
+	s.tokens = st.tokens
+	s.tokenBalances = st.tokenBalances
+	s.tokenAllowances = st.tokenAllowances
+	s.nftCollections = st.nftCollections
+	s.nfts = st.nfts
+	s.nftsByOwner = st.nftsByOwner
+	s.nftsByCollection = st.nftsByCollection
+	s.models = st.models
+	s.verifyingKeys = st.verifyingKeys
+	s.agentIDs = st.agentIDs
+	s.byController = st.byController
+	s.byCapability = st.byCapability
 }
@@ -0,0 +1,195 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+func TestConcurrentUpdates(t *testing.T) {
+	const (
+		workers = 8
+		rounds  = 200
+	)
+	s := NewStateDB()
+	s.SetBalance(journalAlice, big.NewInt(workers*rounds))
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			one := big.NewInt(1)
+			for i := 0; i < rounds; i++ {
+				switch i % 4 {
+				case 0:
+					if err := s.Transfer(journalAlice, journalBob, one); err != nil {
+						t.Error(err)
+					}
+				case 1:
+					if err := s.SubBalance(journalAlice, one); err != nil {
+						t.Error(err)
+					}
+					s.AddBalance(journalBob, one)
+				case 2:
+					if err := s.Update(journalAlice, func(acc *Account) error {
+						if acc.Balance.Sign() == 0 {
+							return ErrInsufficientBalance
+						}
+						acc.Balance.Sub(acc.Balance, one)
+						acc.Nonce++
+						return nil
+					}); err != nil {
+						t.Error(err)
+					}
+					s.AddBalance(journalBob, one)
+				case 3:
+					if err := s.TransferBatch(journalAlice, []string{journalBob}, []*big.Int{one}); err != nil {
+						t.Error(err)
+					}
+				}
+				s.IncrementNonce(journalBob)
+				s.SetStorage(journalBob, []byte(fmt.Sprint(w)), []byte{byte(i)})
+			}
+		}(w)
+		// Readers see whole updates only.
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				a, b := s.GetAccount(journalAlice), s.GetAccount(journalBob)
+				if a.Balance.Sign() < 0 || b.Balance.Sign() < 0 {
+					t.Error("negative balance")
+				}
+				s.StateRoot()
+				s.Copy()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := s.GetBalance(journalAlice); got.Sign() != 0 {
+		t.Errorf("sender balance = %v, want 0", got)
+	}
+	if got := s.GetBalance(journalBob); got.Cmp(big.NewInt(workers*rounds)) != 0 {
+		t.Errorf("recipient balance = %v, want %d", got, workers*rounds)
+	}
+	if got := s.GetNonce(journalAlice); got != workers*rounds/4 {
+		t.Errorf("sender nonce = %d, want %d", got, workers*rounds/4)
+	}
+	if got := s.GetNonce(journalBob); got != workers*rounds {
+		t.Errorf("recipient nonce = %d, want %d", got, workers*rounds)
+	}
+	if acc := s.GetAccount(journalBob); len(acc.Storage) != workers {
+		t.Errorf("recipient holds %d storage slots, want %d", len(acc.Storage), workers)
+	}
+}
+
+func TestFailedUpdate(t *testing.T) {
+	s := NewStateDB()
+	s.SetBalance(journalAlice, big.NewInt(10))
+	s.SetStorage(journalAlice, []byte("k"), []byte("v"))
+	root := s.StateRoot()
+
+	errStop := errors.New("stop")
+	err := s.Update(journalAlice, func(acc *Account) error {
+		acc.Balance.SetInt64(0)
+		acc.Storage["k"] = []byte("w")
+		return errStop
+	})
+	if err != errStop {
+		t.Fatalf("Update = %v, want %v", err, errStop)
+	}
+	if err := s.Transfer(journalAlice, journalBob, big.NewInt(11)); err != ErrInsufficientBalance {
+		t.Fatalf("Transfer = %v, want %v", err, ErrInsufficientBalance)
+	}
+	if s.StateRoot() != root {
+		t.Error("failed updates changed the state")
+	}
+}
+
+func TestConcurrentAgentReads(t *testing.T) {
+	const rounds = 500
+	s := journalState(t)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < rounds; i++ {
+			msg := transaction.AgentMessage{From: "did:agc:a", To: "did:agc:b", Type: transaction.MsgResult, Nonce: uint64(i)}
+			if err := s.StoreMessage(msg, 2); err != nil {
+				t.Error(err)
+				return
+			}
+			if i%10 == 0 {
+				s.SetAgentActive("did:agc:b", journalAlice, false, 2, "paused")
+				s.SetAgentActive("did:agc:b", journalAlice, true, 2, "")
+			}
+		}
+	}()
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				rec, err := s.GetAgent("did:agc:a")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				rec.MessageCount = 0
+				rec.DID.Capabilities[0].Version = "changed"
+				agents, _, _ := s.ListAgents("", 0)
+				if _, err := json.Marshal([]interface{}{rec, agents}); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	rec, err := s.GetAgent("did:agc:a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.MessageCount != rounds || rec.MessageNonce != rounds {
+		t.Errorf("message count %d, nonce %d; want %d", rec.MessageCount, rec.MessageNonce, rounds)
+	}
+	if v := rec.DID.Capabilities[0].Version; v != "1" {
+		t.Errorf("capability version = %q, changed through a read copy", v)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	s := journalState(t)
+	oracle := s.GetOracleParams()
+	oracle.Quorum++
+	s.SetOracleParams(oracle)
+	root := s.StateRoot()
+
+	cp := s.Copy()
+	if got := cp.GetOracleParams(); got.Quorum != oracle.Quorum {
+		t.Fatalf("copied quorum = %d, want %d", got.Quorum, oracle.Quorum)
+	}
+	if err := cp.Transfer(journalAlice, journalBob, big.NewInt(400)); err != nil {
+		t.Fatal(err)
+	}
+	if s.StateRoot() != root {
+		t.Fatal("writing the copy changed the state")
+	}
+	want := cp.StateRoot()
+	s.Replace(cp)
+	if got := s.StateRoot(); got != want {
+		t.Fatalf("root after Replace = %x, want %x", got, want)
+	}
+	if got := s.GetBalance(journalBob); got.Cmp(big.NewInt(400)) != 0 {
+		t.Fatalf("balance after Replace = %s, want 400", got)
+	}
+}
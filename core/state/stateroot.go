@@ -0,0 +1,205 @@
+package state
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The whole state is committed to by Header.StateRoot, the SHA-256 hash of
+// its canonical encoding, which is also the format of snapshots:
+//
+//	uvarint  SnapshotVersion
+//	uvarint  number of entries
+//	entries  uvarint len(key) || key || uvarint len(value) || value
+//
+// Entries are sorted by key, each key appearing once. They are those of
+// the agent state (see agentroot.go) and:
+//
+//	account/<addr>                    balance, nonce and code of an account
+//	storage/<addr>/<slot>             a storage slot of a contract, raw bytes
+//	supply/minted, supply/burned      the tokens minted and burned
+//	supply/treasury                   the community treasury
+//	upgrade                           the pending software upgrade
+//	paramhistory/<i>                  the i-th activated parameter change
+//	attestationroot/<platform>        the trusted TEE root certificates
+//	verifyingkey/<model>              a zkML verifying key
+//	ibc/voucher/<denom>/<addr>        an IBC voucher balance
+//	bridge/locked                     the ZIO escrowed by the bridge
+//	bridge/wrapped/<token>/<addr>     a wrapped token balance
+//	bridge/depositrecord/<hash>       a bridge deposit, executed or not
+//	tokenbalance/<symbol>/<addr>      a ZRC-20 balance
+//	tokenallowance/<symbol>/<owner>/<spender>
+//
+// Values are JSON, except storage slots. Nodes with the same state have
+// byte-identical snapshots and so the same root, which makes the root the
+// value to compare when looking for diverging nodes.
+
+// SnapshotVersion is the version of the snapshot encoding.
+const SnapshotVersion = 1
+
+var ErrSnapshotFormat = errors.New("malformed state snapshot")
+
+// SnapshotInfo describes the canonical encoding of the state.
+type SnapshotInfo struct {
+	Root     [32]byte       `json:"root"`
+	Version  int            `json:"version"`
+	Entries  int            `json:"entries"`
+	Size     int            `json:"size"`     // bytes
+	Sections map[string]int `json:"sections"` // entries by key prefix
+}
+
+// Snapshot returns the canonical encoding of the state.
+func (s *StateDB) Snapshot() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return encodeSnapshot(s.stateEntries()), nil
+}
+
+// StateRoot returns the root committing to the whole state.
+func (s *StateDB) StateRoot() [32]byte {
+	data, _ := s.Snapshot()
+	return sha256.Sum256(data)
+}
+
+// SnapshotInfo returns the root of the state and the shape of its
+// snapshot.
+func (s *StateDB) SnapshotInfo() SnapshotInfo {
+	s.mu.RLock()
+	entries := s.stateEntries()
+	s.mu.RUnlock()
+	data := encodeSnapshot(entries)
+	info := SnapshotInfo{
+		Root:     sha256.Sum256(data),
+		Version:  SnapshotVersion,
+		Entries:  len(entries),
+		Size:     len(data),
+		Sections: make(map[string]int),
+	}
+	for _, e := range entries {
+		section, _, _ := strings.Cut(e.key, "/")
+		info.Sections[section]++
+	}
+	return info
+}
+
+// SnapshotRoot checks that data is a well-formed snapshot and returns its
+// root and number of entries.
+func SnapshotRoot(data []byte) ([32]byte, int, error) {
+	r := bytes.NewReader(data)
+	version, err := binary.ReadUvarint(r)
+	if err != nil || version != SnapshotVersion {
+		return [32]byte{}, 0, fmt.Errorf("%w: version", ErrSnapshotFormat)
+	}
+	n, err := binary.ReadUvarint(r)
+	if err != nil || n > uint64(len(data)) {
+		return [32]byte{}, 0, fmt.Errorf("%w: entry count", ErrSnapshotFormat)
+	}
+	var prev []byte
+	for i := uint64(0); i < n; i++ {
+		key, err := readChunk(r)
+		if err != nil {
+			return [32]byte{}, 0, fmt.Errorf("%w: entry %d key", ErrSnapshotFormat, i)
+		}
+		if i > 0 && bytes.Compare(prev, key) >= 0 {
+			return [32]byte{}, 0, fmt.Errorf("%w: entry %d out of order", ErrSnapshotFormat, i)
+		}
+		if _, err := readChunk(r); err != nil {
+			return [32]byte{}, 0, fmt.Errorf("%w: entry %d value", ErrSnapshotFormat, i)
+		}
+		prev = key
+	}
+	if r.Len() != 0 {
+		return [32]byte{}, 0, fmt.Errorf("%w: trailing data", ErrSnapshotFormat)
+	}
+	return sha256.Sum256(data), int(n), nil
+}
+
+func readChunk(r *bytes.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(r.Len()) {
+		return nil, ErrSnapshotFormat
+	}
+	b := make([]byte, n)
+	_, err = r.Read(b)
+	return b, err
+}
+
+func encodeSnapshot(entries []agentEntry) []byte {
+	var buf bytes.Buffer
+	var tmp [binary.MaxVarintLen64]byte
+	uvarint := func(v uint64) { buf.Write(tmp[:binary.PutUvarint(tmp[:], v)]) }
+	uvarint(SnapshotVersion)
+	uvarint(uint64(len(entries)))
+	for _, e := range entries {
+		uvarint(uint64(len(e.key)))
+		buf.WriteString(e.key)
+		uvarint(uint64(len(e.value)))
+		buf.Write(e.value)
+	}
+	return buf.Bytes()
+}
+
+// stateEntries returns the entries of the whole state sorted by key. The
+// caller holds s.mu.
+func (s *StateDB) stateEntries() []agentEntry {
+	entries, _ := s.agentLeaves()
+	add := func(key string, v interface{}) {
+		b, err := json.Marshal(v)
+		if err != nil {
+			panic(fmt.Sprintf("state: encoding %s: %v", key, err))
+		}
+		entries = append(entries, agentEntry{key, b})
+	}
+	for addr, acc := range s.accounts {
+		add("account/"+addr, Account{Address: acc.Address, Balance: acc.Balance, Nonce: acc.Nonce, Code: acc.Code})
+		for slot, v := range acc.Storage {
+			entries = append(entries, agentEntry{"storage/" + addr + "/" + slot, v})
+		}
+	}
+	add("supply/minted", s.minted)
+	add("supply/burned", s.burned)
+	add("supply/treasury", s.treasury)
+	if s.upgradePlan != nil {
+		add("upgrade", s.upgradePlan)
+	}
+	for i, v := range s.paramHistory {
+		add(fmt.Sprintf("paramhistory/%020d", i), v)
+	}
+	for platform, roots := range s.attestationRoots {
+		add("attestationroot/"+platform, roots)
+	}
+	for key, vk := range s.verifyingKeys {
+		add("verifyingkey/"+key, vk)
+	}
+	for key, bal := range s.ibcVouchers {
+		add("ibc/voucher/"+slashKey(key), bal)
+	}
+	add("bridge/locked", s.bridgeLocked)
+	for key, bal := range s.bridgeWrapped {
+		add("bridge/wrapped/"+slashKey(key), bal)
+	}
+	for hash, d := range s.bridgeDeposits {
+		add("bridge/depositrecord/"+hash, d)
+	}
+	for key, bal := range s.tokenBalances {
+		add("tokenbalance/"+slashKey(key), bal)
+	}
+	for key, v := range s.tokenAllowances {
+		add("tokenallowance/"+slashKey(key), v)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	return entries
+}
+
+// slashKey turns the NUL-separated compound keys of the state maps into
+// key paths.
+func slashKey(key string) string { return strings.ReplaceAll(key, "\x00", "/") }
@@ -0,0 +1,221 @@
+package state
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// The state tracks the total supply of ZIO: tokens are minted by the
+// genesis allocations and the block rewards, and burned by fees and
+// slashing. The block reward is inflation, at an annual rate of the supply
+// that starts at InflationRate and falls by InflationDecay each year down
+// to MinInflationRate. It is split between the proposer, the staking
+// validators by bonded stake, the Proof-of-Intelligence contributors, that
+// is the controllers of agents by the agents' reputation, and the
+// community treasury; the shares of validators are shared with their
+// delegators as in staking.go.
+// A share that finds no recipients, and the remainders of the divisions,
+// go to the treasury.
+
+// TokenomicsParams configures inflation and the block reward split. The
+// shares are percentages of the block reward.
+type TokenomicsParams struct {
+	InflationRate    uint64 `json:"inflationRate"`    // annual, in basis points of the supply
+	InflationDecay   uint64 `json:"inflationDecay"`   // of the rate each year, in basis points
+	MinInflationRate uint64 `json:"minInflationRate"` // in basis points
+	BlocksPerYear    uint64 `json:"blocksPerYear"`
+	ProposerShare    uint64 `json:"proposerShare"`
+	ValidatorShare   uint64 `json:"validatorShare"` // by bonded stake
+	PoIShare         uint64 `json:"poiShare"`       // by agent reputation
+	TreasuryShare    uint64 `json:"treasuryShare"`
+}
+
+// DefaultTokenomicsParams returns the tokenomics parameters of a new state.
+func DefaultTokenomicsParams() TokenomicsParams {
+	return TokenomicsParams{
+		InflationRate:    800,
+		InflationDecay:   100,
+		MinInflationRate: 200,
+		BlocksPerYear:    15_768_000, // 2s blocks
+		ProposerShare:    40,
+		ValidatorShare:   30,
+		PoIShare:         20,
+		TreasuryShare:    10,
+	}
+}
+
+// Validate checks that p is a consistent schedule and split.
+func (p TokenomicsParams) Validate() error {
+	switch {
+	case p.BlocksPerYear == 0:
+		return fmt.Errorf("%w: blocksPerYear must be positive", ErrInvalidParams)
+	case p.MinInflationRate > p.InflationRate:
+		return fmt.Errorf("%w: minInflationRate exceeds inflationRate", ErrInvalidParams)
+	case p.ProposerShare+p.ValidatorShare+p.PoIShare+p.TreasuryShare != 100:
+		return fmt.Errorf("%w: reward shares must add up to 100", ErrInvalidParams)
+	}
+	return nil
+}
+
+// Rate returns the annual inflation rate at height, in basis points.
+func (p TokenomicsParams) Rate(height uint64) uint64 {
+	if p.BlocksPerYear == 0 || p.InflationRate <= p.MinInflationRate {
+		return p.MinInflationRate
+	}
+	if drop := height / p.BlocksPerYear * p.InflationDecay; drop < p.InflationRate-p.MinInflationRate {
+		return p.InflationRate - drop
+	}
+	return p.MinInflationRate
+}
+
+// Supply is the supply of ZIO.
+type Supply struct {
+	Total    *big.Int `json:"total"`
+	Minted   *big.Int `json:"minted"` // genesis allocations included
+	Burned   *big.Int `json:"burned"`
+	Treasury *big.Int `json:"treasury"` // held by the community treasury, part of Total
+}
+
+// SetTokenomicsParams replaces the tokenomics parameters.
+func (s *StateDB) SetTokenomicsParams(p TokenomicsParams) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokenomics = p
+}
+
+// GetTokenomicsParams returns the tokenomics parameters.
+func (s *StateDB) GetTokenomicsParams() TokenomicsParams {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tokenomics
+}
+
+// GetSupply returns the supply of ZIO.
+func (s *StateDB) GetSupply() Supply {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return Supply{
+		Total:    new(big.Int).Sub(s.minted, s.burned),
+		Minted:   new(big.Int).Set(s.minted),
+		Burned:   new(big.Int).Set(s.burned),
+		Treasury: new(big.Int).Set(s.treasury),
+	}
+}
+
+// Mint credits amount of new tokens to addr.
+func (s *StateDB) Mint(addr string, amount *big.Int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.minted.Add(s.minted, amount)
+	s.release(addr, amount)
+}
+
+// Burn records the burning of amount already debited from an account.
+func (s *StateDB) Burn(amount *big.Int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.burn(amount)
+}
+
+// burn records the burning of amount. The caller holds s.mu.
+func (s *StateDB) burn(amount *big.Int) {
+	s.burned.Add(s.burned, amount)
+}
+
+// BlockReward returns the reward of the block at height: the inflation of
+// the supply over one block at the rate of height.
+func (s *StateDB) BlockReward(height uint64) *big.Int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.blockReward(height)
+}
+
+// blockReward is BlockReward. The caller holds s.mu.
+func (s *StateDB) blockReward(height uint64) *big.Int {
+	p := s.tokenomics
+	if p.BlocksPerYear == 0 {
+		return new(big.Int)
+	}
+	r := new(big.Int).Sub(s.minted, s.burned)
+	r.Mul(r, new(big.Int).SetUint64(p.Rate(height)))
+	return r.Div(r, new(big.Int).SetUint64(10_000*p.BlocksPerYear))
+}
+
+// MintBlockReward mints the reward of the block proposed by proposer at
+// height and splits it. It returns the reward.
+func (s *StateDB) MintBlockReward(proposer string, height uint64) *big.Int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reward := s.blockReward(height)
+	if reward.Sign() == 0 {
+		return reward
+	}
+	s.minted.Add(s.minted, reward)
+	share := func(percent uint64) *big.Int {
+		r := new(big.Int).Mul(reward, new(big.Int).SetUint64(percent))
+		return r.Div(r, big.NewInt(100))
+	}
+	rest := new(big.Int).Set(reward)
+
+	proposerReward := share(s.tokenomics.ProposerShare)
+	s.distributeReward(proposer, proposerReward)
+	rest.Sub(rest, proposerReward)
+
+	rest.Sub(rest, s.rewardValidators(share(s.tokenomics.ValidatorShare)))
+	rest.Sub(rest, s.rewardContributors(share(s.tokenomics.PoIShare)))
+	s.treasury.Add(s.treasury, rest)
+	return reward
+}
+
+// rewardValidators splits amount between the staking validators in
+// proportion to their bonded stake and returns the amount paid out. The
+// caller holds s.mu.
+func (s *StateDB) rewardValidators(amount *big.Int) *big.Int {
+	total := new(big.Int)
+	addrs := make([]string, 0, len(s.stakingValidators))
+	for addr, v := range s.stakingValidators {
+		total.Add(total, v.Tokens)
+		addrs = append(addrs, addr)
+	}
+	paid := new(big.Int)
+	if total.Sign() == 0 {
+		return paid
+	}
+	sort.Strings(addrs)
+	for _, addr := range addrs {
+		r := new(big.Int).Mul(amount, s.stakingValidators[addr].Tokens)
+		r.Div(r, total)
+		if r.Sign() > 0 {
+			s.distributeReward(addr, r)
+			paid.Add(paid, r)
+		}
+	}
+	return paid
+}
+
+// rewardContributors splits amount between the controllers of active
+// agents in proportion to the reputation scores of their agents and
+// returns the amount paid out. The caller holds s.mu.
+func (s *StateDB) rewardContributors(amount *big.Int) *big.Int {
+	var total uint64
+	for _, rec := range s.agents {
+		if rec.Active {
+			total += rec.Reputation.Score
+		}
+	}
+	paid := new(big.Int)
+	if total == 0 {
+		return paid
+	}
+	for _, rec := range s.agents {
+		if !rec.Active || rec.Reputation.Score == 0 {
+			continue
+		}
+		r := new(big.Int).Mul(amount, new(big.Int).SetUint64(rec.Reputation.Score))
+		r.Div(r, new(big.Int).SetUint64(total))
+		s.release(canonicalAddress(rec.DID.Controller), r)
+		paid.Add(paid, r)
+	}
+	return paid
+}
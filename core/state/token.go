@@ -0,0 +1,310 @@
+package state
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// ZRC-20 tokens are fungible tokens issued natively, without contracts,
+// so that agent economies can issue reward and credit tokens. A token is
+// registered under a unique symbol by its creator, who becomes its admin:
+// the admin alone mints, up to the max supply if one is set, and can hand
+// the role over or renounce it, fixing the supply. Holders transfer and
+// burn their balances and allow spenders to transfer on their behalf, as
+// in ERC-20.
+
+var (
+	ErrTokenExists           = errors.New("token symbol already registered")
+	ErrTokenNotFound         = errors.New("token not found")
+	ErrNotTokenAdmin         = errors.New("sender is not the token admin")
+	ErrTokenMaxSupply        = errors.New("mint exceeds the token max supply")
+	ErrInsufficientAllowance = errors.New("insufficient token allowance")
+)
+
+// Token is a ZRC-20 token. Admin is empty once renounced.
+type Token struct {
+	Symbol    string   `json:"symbol"`
+	Name      string   `json:"name"`
+	Decimals  uint8    `json:"decimals"`
+	Creator   string   `json:"creator"`
+	Admin     string   `json:"admin,omitempty"`
+	Supply    *big.Int `json:"supply"`
+	MaxSupply *big.Int `json:"maxSupply,omitempty"`
+	CreatedAt uint64   `json:"createdAt"`
+}
+
+func tokenBalanceKey(symbol, addr string) string { return symbol + "\x00" + addr }
+
+func tokenAllowanceKey(symbol, owner, spender string) string {
+	return symbol + "\x00" + owner + "\x00" + spender
+}
+
+// CreateToken registers the token c created by creator at height and
+// mints its initial supply to the creator.
+func (s *StateDB) CreateToken(c transaction.TokenCreate, creator string, height uint64) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tokens[c.Symbol]; ok {
+		return nil, ErrTokenExists
+	}
+	t := &Token{
+		Symbol:    c.Symbol,
+		Name:      c.Name,
+		Decimals:  c.Decimals,
+		Creator:   creator,
+		Admin:     creator,
+		Supply:    new(big.Int),
+		CreatedAt: height,
+	}
+	if c.MaxSupply != nil {
+		t.MaxSupply = new(big.Int).Set(c.MaxSupply)
+	}
+	if s.tokens == nil {
+		s.tokens = make(map[string]*Token)
+	}
+	s.tokens[c.Symbol] = t
+	if c.InitialSupply != nil && c.InitialSupply.Sign() > 0 {
+		if err := s.mintToken(c.Symbol, creator, c.InitialSupply); err != nil {
+			delete(s.tokens, c.Symbol)
+			return nil, err
+		}
+	}
+	return s.tokens[c.Symbol].Copy(), nil
+}
+
+// MintToken mints m as admin, the sender.
+func (s *StateDB) MintToken(m transaction.TokenMint, admin string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tokens[m.Symbol]
+	if !ok {
+		return ErrTokenNotFound
+	}
+	if t.Admin == "" || !sameAddress(t.Admin, admin) {
+		return ErrNotTokenAdmin
+	}
+	return s.mintToken(m.Symbol, m.To, m.Amount)
+}
+
+// mintToken credits amount of the token symbol to addr. The caller holds
+// s.mu.
+func (s *StateDB) mintToken(symbol, addr string, amount *big.Int) error {
+	t := *s.tokens[symbol]
+	t.Supply = new(big.Int).Add(t.Supply, amount)
+	if t.MaxSupply != nil && t.Supply.Cmp(t.MaxSupply) > 0 {
+		return ErrTokenMaxSupply
+	}
+	s.tokens[symbol] = &t
+	s.creditToken(symbol, addr, amount)
+	return nil
+}
+
+// BurnToken burns amount of the token symbol held by holder.
+func (s *StateDB) BurnToken(symbol, holder string, amount *big.Int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, ok := s.tokens[symbol]
+	if !ok {
+		return ErrTokenNotFound
+	}
+	if err := s.debitToken(symbol, holder, amount); err != nil {
+		return err
+	}
+	t := *cur
+	t.Supply = new(big.Int).Sub(t.Supply, amount)
+	s.tokens[symbol] = &t
+	return nil
+}
+
+// TransferToken moves amount of the token symbol from from to to.
+func (s *StateDB) TransferToken(symbol, from, to string, amount *big.Int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tokens[symbol]; !ok {
+		return ErrTokenNotFound
+	}
+	if err := s.debitToken(symbol, from, amount); err != nil {
+		return err
+	}
+	s.creditToken(symbol, to, amount)
+	return nil
+}
+
+// ApproveToken sets the allowance of spender over the token symbol of
+// owner to amount.
+func (s *StateDB) ApproveToken(symbol, owner, spender string, amount *big.Int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tokens[symbol]; !ok {
+		return ErrTokenNotFound
+	}
+	key := tokenAllowanceKey(symbol, owner, spender)
+	if amount.Sign() == 0 {
+		delete(s.tokenAllowances, key)
+		return nil
+	}
+	if s.tokenAllowances == nil {
+		s.tokenAllowances = make(map[string]*big.Int)
+	}
+	s.tokenAllowances[key] = new(big.Int).Set(amount)
+	return nil
+}
+
+// TransferTokenFrom moves amount of the token symbol from from to to on
+// behalf of spender, spending its allowance.
+func (s *StateDB) TransferTokenFrom(symbol, spender, from, to string, amount *big.Int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tokens[symbol]; !ok {
+		return ErrTokenNotFound
+	}
+	key := tokenAllowanceKey(symbol, from, spender)
+	allowance, ok := s.tokenAllowances[key]
+	if !ok || allowance.Cmp(amount) < 0 {
+		return ErrInsufficientAllowance
+	}
+	if err := s.debitToken(symbol, from, amount); err != nil {
+		return err
+	}
+	if allowance.Cmp(amount) == 0 {
+		delete(s.tokenAllowances, key)
+	} else {
+		s.tokenAllowances[key] = new(big.Int).Sub(allowance, amount)
+	}
+	s.creditToken(symbol, to, amount)
+	return nil
+}
+
+// SetTokenAdmin hands the admin of the token symbol over from admin to
+// newAdmin, or renounces it if newAdmin is empty.
+func (s *StateDB) SetTokenAdmin(symbol, admin, newAdmin string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, ok := s.tokens[symbol]
+	if !ok {
+		return ErrTokenNotFound
+	}
+	if cur.Admin == "" || !sameAddress(cur.Admin, admin) {
+		return ErrNotTokenAdmin
+	}
+	t := *cur
+	t.Admin = newAdmin
+	s.tokens[symbol] = &t
+	return nil
+}
+
+// creditToken adds amount of the token symbol to the balance of addr. The
+// caller holds s.mu.
+func (s *StateDB) creditToken(symbol, addr string, amount *big.Int) {
+	if s.tokenBalances == nil {
+		s.tokenBalances = make(map[string]*big.Int)
+	}
+	key := tokenBalanceKey(symbol, addr)
+	bal := new(big.Int).Set(amount)
+	if cur, ok := s.tokenBalances[key]; ok {
+		bal.Add(bal, cur)
+	}
+	s.tokenBalances[key] = bal
+}
+
+// debitToken subtracts amount of the token symbol from the balance of
+// addr. The caller holds s.mu.
+func (s *StateDB) debitToken(symbol, addr string, amount *big.Int) error {
+	key := tokenBalanceKey(symbol, addr)
+	cur, ok := s.tokenBalances[key]
+	if !ok || cur.Cmp(amount) < 0 {
+		return ErrInsufficientBalance
+	}
+	if cur.Cmp(amount) == 0 {
+		delete(s.tokenBalances, key)
+		return nil
+	}
+	s.tokenBalances[key] = new(big.Int).Sub(cur, amount)
+	return nil
+}
+
+// GetToken returns the token symbol.
+func (s *StateDB) GetToken(symbol string) (*Token, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.tokens[symbol]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	return t.Copy(), nil
+}
+
+// Tokens returns the tokens ordered by symbol.
+func (s *StateDB) Tokens() []*Token {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*Token, 0, len(s.tokens))
+	for _, t := range s.tokens {
+		out = append(out, t.Copy())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Symbol < out[j].Symbol })
+	return out
+}
+
+// TokenBalance returns the balance of the token symbol of addr.
+func (s *StateDB) TokenBalance(symbol, addr string) *big.Int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if bal, ok := s.tokenBalances[tokenBalanceKey(symbol, addr)]; ok {
+		return new(big.Int).Set(bal)
+	}
+	return new(big.Int)
+}
+
+// TokenBalances returns the token balances of addr by symbol.
+func (s *StateDB) TokenBalances(addr string) map[string]*big.Int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]*big.Int)
+	for key, bal := range s.tokenBalances {
+		if symbol, owner, _ := strings.Cut(key, "\x00"); owner == addr {
+			out[symbol] = new(big.Int).Set(bal)
+		}
+	}
+	return out
+}
+
+// TokenAllowance returns the allowance of spender over the token symbol of
+// owner.
+func (s *StateDB) TokenAllowance(symbol, owner, spender string) *big.Int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if a, ok := s.tokenAllowances[tokenAllowanceKey(symbol, owner, spender)]; ok {
+		return new(big.Int).Set(a)
+	}
+	return new(big.Int)
+}
+
+// copyTokens returns copies of the token maps. Their records and balances
+// are replaced rather than modified, so they are shared. The caller holds
+// s.mu.
+func (s *StateDB) copyTokens() (tokens map[string]*Token, balances, allowances map[string]*big.Int) {
+	if len(s.tokens) > 0 {
+		tokens = make(map[string]*Token, len(s.tokens))
+		for k, v := range s.tokens {
+			tokens[k] = v
+		}
+	}
+	if len(s.tokenBalances) > 0 {
+		balances = make(map[string]*big.Int, len(s.tokenBalances))
+		for k, v := range s.tokenBalances {
+			balances[k] = v
+		}
+	}
+	if len(s.tokenAllowances) > 0 {
+		allowances = make(map[string]*big.Int, len(s.tokenAllowances))
+		for k, v := range s.tokenAllowances {
+			allowances[k] = v
+		}
+	}
+	return
+}
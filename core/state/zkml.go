@@ -0,0 +1,82 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/core/zk"
+)
+
+// A model can have a Groth16 verifying key registered for its circuit.
+// Receipts of the model may then carry a zkML proof, which raises them to
+// TrustProven once it verifies against the key with the receipt's
+// ProofInputs. A receipt whose proof fails is rejected.
+
+var (
+	ErrNoVerifyingKey = errors.New("no verifying key registered for the model")
+	ErrInvalidZKProof = errors.New("invalid zkML proof")
+)
+
+// SetVerifyingKey registers the encoded Groth16 verifying key vk for the
+// circuit of model, replacing any previous key.
+func (s *StateDB) SetVerifyingKey(model, vk []byte) error {
+	key, err := zk.ParseVerifyingKey(vk)
+	if err != nil {
+		return err
+	}
+	if key.Inputs() != len((&transaction.InferenceReceipt{}).ProofInputs()) {
+		return zk.ErrInputCount
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.verifyingKeys == nil {
+		s.verifyingKeys = make(map[string][]byte)
+	}
+	s.verifyingKeys[receiptKey(model)] = append([]byte(nil), vk...)
+	return nil
+}
+
+// GetVerifyingKey returns the encoded verifying key registered for model.
+func (s *StateDB) GetVerifyingKey(model []byte) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	vk, ok := s.verifyingKeys[receiptKey(model)]
+	if !ok {
+		return nil, ErrNoVerifyingKey
+	}
+	return vk, nil
+}
+
+// verifyZKProof checks the zkML proof of r. The caller holds s.mu.
+func (s *StateDB) verifyZKProof(r transaction.InferenceReceipt) error {
+	enc, ok := s.verifyingKeys[receiptKey(r.ModelHash)]
+	if !ok {
+		return ErrNoVerifyingKey
+	}
+	vk, err := zk.ParseVerifyingKey(enc)
+	if err != nil {
+		return err
+	}
+	proof, err := zk.ParseProof(r.ZKProof)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidZKProof, err)
+	}
+	if err := zk.Verify(vk, proof, r.ProofInputs()); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidZKProof, err)
+	}
+	return nil
+}
+
+// copyVerifyingKeys returns a copy of s.verifyingKeys. Keys are replaced
+// rather than modified, so they are shared. The caller holds s.mu.
+func (s *StateDB) copyVerifyingKeys() map[string][]byte {
+	if len(s.verifyingKeys) == 0 {
+		return nil
+	}
+	cp := make(map[string][]byte, len(s.verifyingKeys))
+	for k, vk := range s.verifyingKeys {
+		cp[k] = vk
+	}
+	return cp
+}
@@ -0,0 +1,131 @@
+package transaction
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/zionlayer/zionlayer/core/crypto"
+	"github.com/zionlayer/zionlayer/core/merkle"
+	"github.com/zionlayer/zionlayer/core/rlp"
+)
+
+// Gas of a receipt batch: a base cost plus a small cost per receipt, far
+// below GasInferenceReceipt, for the reputation credited per receipt.
+const (
+	GasReceiptBatch           = 150000
+	GasReceiptBatchPerReceipt = 500
+
+	// MaxBatchReceipts caps the receipts of a batch.
+	MaxBatchReceipts = 1 << 20
+)
+
+// InferenceBatch is the Data of a TxInferenceBatch transaction: Count
+// receipts of inferences of ModelHash for AgentID, proven by Prover,
+// committed to by the Merkle root of their BatchLeaf hashes. The single
+// signature of the prover over the batch's SigningHash stands in for the
+// ProverSig of each receipt, which the receipts of a batch leave empty.
+type InferenceBatch struct {
+	AgentID   string `json:"agentId"`
+	ModelHash []byte `json:"modelHash"`
+	Root      []byte `json:"root"` // merkle.Root of the receipts' BatchLeaf hashes
+	Count     uint64 `json:"count"`
+	Timestamp int64  `json:"timestamp"` // when the batch was sealed
+	Prover    string `json:"prover"`
+	ProverSig []byte `json:"proverSig"` // by the provider's key over SigningHash
+}
+
+// BatchLeaf returns the Merkle leaf of receipt r in a batch: the leaf hash
+// of its SigningHash.
+func BatchLeaf(r *InferenceReceipt) [32]byte {
+	h := r.SigningHash()
+	return merkle.LeafHash(h[:])
+}
+
+// NewInferenceBatch seals receipts, all of the same agent and model, into
+// a batch proven by prover at timestamp. The batch still needs signing.
+func NewInferenceBatch(receipts []InferenceReceipt, prover string, timestamp int64) InferenceBatch {
+	leaves := make([][32]byte, len(receipts))
+	for i := range receipts {
+		leaves[i] = BatchLeaf(&receipts[i])
+	}
+	root := merkle.Root(leaves)
+	b := InferenceBatch{Root: root[:], Count: uint64(len(receipts)), Timestamp: timestamp, Prover: prover}
+	if len(receipts) > 0 {
+		b.AgentID, b.ModelHash = receipts[0].AgentID, receipts[0].ModelHash
+	}
+	return b
+}
+
+// ProveBatchReceipt returns the inclusion proof of receipt i of receipts
+// in the batch sealing them.
+func ProveBatchReceipt(receipts []InferenceReceipt, i int) [][32]byte {
+	leaves := make([][32]byte, len(receipts))
+	for j := range receipts {
+		leaves[j] = BatchLeaf(&receipts[j])
+	}
+	return merkle.Prove(leaves, i)
+}
+
+// SigningHash returns the digest signed by the prover of the batch: the
+// SHA-256 hash of the RLP list of AgentID, ModelHash, Root, Count and
+// Timestamp.
+func (b *InferenceBatch) SigningHash() [32]byte {
+	return sha256.Sum256(rlp.EncodeList(
+		rlp.EncodeString(b.AgentID),
+		rlp.EncodeBytes(b.ModelHash),
+		rlp.EncodeBytes(b.Root),
+		rlp.EncodeUint(b.Count),
+		rlp.EncodeUint(uint64(b.Timestamp)),
+	))
+}
+
+// Sign sets ProverSig to the signature of the batch by priv, the key of
+// the compute provider Prover.
+func (b *InferenceBatch) Sign(priv crypto.PrivateKey) error {
+	sig, err := crypto.Sign(priv, b.SigningHash())
+	if err != nil {
+		return err
+	}
+	b.ProverSig = sig
+	return nil
+}
+
+// ReceiptBatchGas returns the gas of executing a batch of n receipts.
+func ReceiptBatchGas(n uint64) uint64 {
+	return GasReceiptBatch + GasReceiptBatchPerReceipt*n
+}
+
+func checkInferenceBatch(data []byte) (*InferenceBatch, error) {
+	var b InferenceBatch
+	if err := decodeData(data, &b); err != nil {
+		return nil, err
+	}
+	if b.AgentID == "" || len(b.ModelHash) == 0 {
+		return nil, fmt.Errorf("%w: missing agent or model", ErrInvalidData)
+	}
+	if len(b.Root) != 32 {
+		return nil, fmt.Errorf("%w: batch root must be 32 bytes", ErrInvalidData)
+	}
+	if b.Count == 0 || b.Count > MaxBatchReceipts {
+		return nil, fmt.Errorf("%w: batch must hold 1 to %d receipts", ErrInvalidData, MaxBatchReceipts)
+	}
+	if b.Prover == "" || len(b.ProverSig) == 0 {
+		return nil, fmt.Errorf("%w: missing prover signature", ErrInvalidData)
+	}
+	return &b, nil
+}
+
+// NewInferenceBatchTx creates a transaction submitting a receipt batch.
+func NewInferenceBatchTx(from string, b InferenceBatch, nonce uint64, gasPrice *big.Int) *Tx {
+	data, _ := json.Marshal(b)
+	return &Tx{
+		Type:     TxInferenceBatch,
+		From:     from,
+		Gas:      ReceiptBatchGas(b.Count),
+		GasPrice: gasPrice,
+		Nonce:    nonce,
+		Data:     data,
+	}
+}
@@ -0,0 +1,50 @@
+package transaction
+
+import "crypto/sha256"
+
+// BloomBitLength is the size of a log bloom filter in bits.
+const BloomBitLength = 2048
+
+// Bloom is a 2048-bit filter over the addresses and topics of a set of
+// logs. Each item sets three bits, taken from the first six bytes of its
+// SHA-256 hash as 11-bit indices. A negative test is definitive; a positive
+// one must be confirmed against the logs themselves.
+type Bloom [BloomBitLength / 8]byte
+
+// Add inserts item into the filter.
+func (b *Bloom) Add(item []byte) {
+	h := sha256.Sum256(item)
+	for i := 0; i < 6; i += 2 {
+		bit := (uint(h[i])<<8 | uint(h[i+1])) % BloomBitLength
+		b[len(b)-1-int(bit/8)] |= 1 << (bit % 8)
+	}
+}
+
+// Test reports whether item may have been added.
+func (b *Bloom) Test(item []byte) bool {
+	var probe Bloom
+	probe.Add(item)
+	for i := range probe {
+		if probe[i]&b[i] != probe[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// CreateBloom returns the filter over the logs of receipts.
+func CreateBloom(receipts []*Receipt) Bloom {
+	var b Bloom
+	for _, r := range receipts {
+		if r == nil {
+			continue
+		}
+		for _, l := range r.Logs {
+			b.Add([]byte(l.Address))
+			for _, t := range l.Topics {
+				b.Add(t)
+			}
+		}
+	}
+	return b
+}
@@ -0,0 +1,89 @@
+package transaction
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// GasBridgeOp is the intrinsic gas of attesting a bridge deposit and of
+// withdrawing over the bridge.
+const GasBridgeOp = 60000
+
+// BridgeNativeToken names ZIO in bridge withdrawals; tokens of the bridged
+// chain are named by the address of their contract there.
+const BridgeNativeToken = "zio"
+
+// BridgeDeposit is a deposit event of the bridge contract of the bridged
+// chain, the Data of a TxBridgeAttest transaction. TxHash and LogIndex
+// identify the event; Token is the contract of the deposited token, or of
+// wrapped ZIO burned to return it, and Recipient the ZionLayer address
+// credited.
+type BridgeDeposit struct {
+	TxHash    string   `json:"txHash"`
+	LogIndex  uint64   `json:"logIndex"`
+	Token     string   `json:"token"`
+	Amount    *big.Int `json:"amount"`
+	Recipient string   `json:"recipient"`
+}
+
+// ID returns the identifier of the deposit event on the bridged chain.
+func (d BridgeDeposit) ID() string {
+	return fmt.Sprintf("%s:%d", strings.ToLower(d.TxHash), d.LogIndex)
+}
+
+// BridgeWithdraw is the Data of a TxBridgeWithdraw transaction, which
+// sends Amount of Token, BridgeNativeToken or a wrapped token of the
+// bridged chain, to Recipient there.
+type BridgeWithdraw struct {
+	Token     string   `json:"token"`
+	Amount    *big.Int `json:"amount"`
+	Recipient string   `json:"recipient"`
+}
+
+func checkBridge(typ TxType, data []byte) error {
+	switch typ {
+	case TxBridgeAttest:
+		var d BridgeDeposit
+		if err := decodeData(data, &d); err != nil {
+			return err
+		}
+		if d.TxHash == "" || d.Token == "" || d.Amount == nil || d.Amount.Sign() <= 0 {
+			return fmt.Errorf("%w: deposit needs a transaction hash, a token and a positive amount", ErrInvalidData)
+		}
+		return checkCanonical(d.Recipient)
+	case TxBridgeWithdraw:
+		var w BridgeWithdraw
+		if err := decodeData(data, &w); err != nil {
+			return err
+		}
+		if w.Token == "" || w.Recipient == "" || w.Amount == nil || w.Amount.Sign() <= 0 {
+			return fmt.Errorf("%w: withdrawal needs a token, a positive amount and a recipient", ErrInvalidData)
+		}
+	}
+	return nil
+}
+
+// NewBridgeAttestTx creates a transaction attesting the deposit d.
+func NewBridgeAttestTx(from string, d BridgeDeposit, nonce uint64, gasPrice *big.Int) *Tx {
+	return newBridgeTx(TxBridgeAttest, from, d, nonce, gasPrice)
+}
+
+// NewBridgeWithdrawTx creates a transaction withdrawing w to the bridged
+// chain.
+func NewBridgeWithdrawTx(from string, w BridgeWithdraw, nonce uint64, gasPrice *big.Int) *Tx {
+	return newBridgeTx(TxBridgeWithdraw, from, w, nonce, gasPrice)
+}
+
+func newBridgeTx(typ TxType, from string, payload interface{}, nonce uint64, gasPrice *big.Int) *Tx {
+	data, _ := json.Marshal(payload)
+	return &Tx{
+		Type:     typ,
+		From:     from,
+		Gas:      GasBridgeOp,
+		GasPrice: gasPrice,
+		Nonce:    nonce,
+		Data:     data,
+	}
+}
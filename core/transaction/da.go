@@ -0,0 +1,89 @@
+package transaction
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// Data availability networks holding payloads referenced from the chain.
+const (
+	DANetworkIPFS    = "ipfs"
+	DANetworkArweave = "arweave"
+)
+
+// MaxCIDLength caps the content identifier of a DataRef.
+const MaxCIDLength = 128
+
+// GasDataAttest is the intrinsic gas of a data availability attestation.
+const GasDataAttest = 30000
+
+// DataRef references a payload kept off chain: the content identifier of
+// the payload on a data availability network (an IPFS CID or an Arweave
+// transaction ID), its size and, optionally, its SHA-256 hash.
+type DataRef struct {
+	Network string `json:"network"`
+	CID     string `json:"cid"`
+	Size    uint64 `json:"size,omitempty"`
+	Hash    []byte `json:"hash,omitempty"`
+}
+
+// Check reports whether ref is well formed.
+func (ref *DataRef) Check() error {
+	if ref.Network != DANetworkIPFS && ref.Network != DANetworkArweave {
+		return fmt.Errorf("%w: unknown data availability network %q", ErrInvalidData, ref.Network)
+	}
+	if ref.CID == "" || len(ref.CID) > MaxCIDLength {
+		return fmt.Errorf("%w: content identifier must be 1 to %d bytes", ErrInvalidData, MaxCIDLength)
+	}
+	if len(ref.Hash) != 0 && len(ref.Hash) != 32 {
+		return fmt.Errorf("%w: content hash must be 32 bytes", ErrInvalidData)
+	}
+	return nil
+}
+
+// DataAttestation is the Data of a TxDataAttest transaction: the sender, a
+// pinning provider, vouches that it retains the referenced payload until
+// block Until.
+type DataAttestation struct {
+	Ref   DataRef `json:"ref"`
+	Until uint64  `json:"until"`
+}
+
+func checkDataAttestation(data []byte) error {
+	var a DataAttestation
+	if err := decodeData(data, &a); err != nil {
+		return err
+	}
+	return a.Ref.Check()
+}
+
+// NewDataAttestTx creates a data availability attestation transaction.
+func NewDataAttestTx(from string, a DataAttestation, nonce uint64, gasPrice *big.Int) *Tx {
+	data, _ := json.Marshal(a)
+	return &Tx{
+		Type:     TxDataAttest,
+		From:     from,
+		Gas:      GasDataAttest,
+		GasPrice: gasPrice,
+		Nonce:    nonce,
+		Data:     data,
+	}
+}
+
+// DataRefs returns the off-chain payloads referenced by tx, if any.
+func (tx *Tx) DataRefs() []DataRef {
+	switch tx.Type {
+	case TxAgentMessage:
+		var msg AgentMessage
+		if json.Unmarshal(tx.Data, &msg) == nil && msg.PayloadRef != nil {
+			return []DataRef{*msg.PayloadRef}
+		}
+	case TxInferenceReceipt:
+		var r InferenceReceipt
+		if json.Unmarshal(tx.Data, &r) == nil {
+			return r.DataRefs
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package transaction
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// GasDisputeOp is the intrinsic gas of opening and voting on a dispute.
+const GasDisputeOp = 60000
+
+// Arbitration methods of a dispute.
+const (
+	// ArbitrateReexecution has the designated verifiers re-run the
+	// inference and commit the output hash they obtained; the receipt is
+	// upheld by the verifiers whose output matches it.
+	ArbitrateReexecution = "reexecution"
+	// ArbitrateVote has the designated verifiers vote on the receipt.
+	ArbitrateVote = "vote"
+)
+
+// DisputeOpen is the Data of a TxDisputeOpen transaction challenging the
+// inference receipt included by the transaction ReceiptHash.
+type DisputeOpen struct {
+	ReceiptHash []byte `json:"receiptHash"`
+	Method      string `json:"method"`             // ArbitrateReexecution or ArbitrateVote
+	Evidence    string `json:"evidence,omitempty"` // e.g. a URI to the challenger's re-run
+}
+
+// DisputeVote is the Data of a TxDisputeVote transaction sent by a
+// designated verifier. Under ArbitrateReexecution, OutputHash commits to
+// the output of the verifier's re-run and Valid is ignored.
+type DisputeVote struct {
+	ReceiptHash []byte `json:"receiptHash"`
+	Valid       bool   `json:"valid"`
+	OutputHash  []byte `json:"outputHash,omitempty"`
+}
+
+func checkDisputeOpen(data []byte) error {
+	var d DisputeOpen
+	if err := decodeData(data, &d); err != nil {
+		return err
+	}
+	if len(d.ReceiptHash) != 32 {
+		return fmt.Errorf("%w: receipt hash must be 32 bytes", ErrInvalidData)
+	}
+	if d.Method != ArbitrateReexecution && d.Method != ArbitrateVote {
+		return fmt.Errorf("%w: unknown arbitration method %q", ErrInvalidData, d.Method)
+	}
+	return nil
+}
+
+func checkDisputeVote(data []byte) error {
+	var v DisputeVote
+	if err := decodeData(data, &v); err != nil {
+		return err
+	}
+	if len(v.ReceiptHash) != 32 {
+		return fmt.Errorf("%w: receipt hash must be 32 bytes", ErrInvalidData)
+	}
+	return nil
+}
+
+// NewDisputeOpenTx creates a transaction challenging an inference receipt.
+func NewDisputeOpenTx(from string, d DisputeOpen, nonce uint64, gasPrice *big.Int) *Tx {
+	return newDisputeTx(TxDisputeOpen, from, d, nonce, gasPrice)
+}
+
+// NewDisputeVoteTx creates a transaction casting a verifier's vote on a
+// dispute.
+func NewDisputeVoteTx(from string, v DisputeVote, nonce uint64, gasPrice *big.Int) *Tx {
+	return newDisputeTx(TxDisputeVote, from, v, nonce, gasPrice)
+}
+
+func newDisputeTx(typ TxType, from string, payload interface{}, nonce uint64, gasPrice *big.Int) *Tx {
+	data, _ := json.Marshal(payload)
+	return &Tx{
+		Type:     typ,
+		From:     from,
+		Gas:      GasDisputeOp,
+		GasPrice: gasPrice,
+		Nonce:    nonce,
+		Data:     data,
+	}
+}
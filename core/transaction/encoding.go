@@ -0,0 +1,112 @@
+package transaction
+
+import (
+	"github.com/zionlayer/zionlayer/core/rlp"
+)
+
+// baseFields returns the RLP-encoded base fields of tx in canonical order,
+// optionally including the signature.
+func baseFields(tx *Tx, withSig bool) [][]byte {
+	fields := [][]byte{
+		rlp.EncodeUint(tx.ChainID),
+		rlp.EncodeUint(uint64(tx.Type)),
+		rlp.EncodeString(tx.From),
+		rlp.EncodeString(tx.To),
+		rlp.EncodeBigInt(tx.Value),
+		rlp.EncodeUint(tx.Gas),
+		rlp.EncodeBigInt(tx.GasPrice),
+		rlp.EncodeUint(tx.Nonce),
+		rlp.EncodeBytes(tx.Data),
+		rlp.EncodeBytes(tx.PublicKey),
+	}
+	if withSig {
+		fields = append(fields, rlp.EncodeBytes(tx.Signature))
+	}
+	return fields
+}
+
+// splitBaseFields decodes the base fields written by baseFields(tx, true)
+// from the content of an RLP list and returns the remaining content.
+func splitBaseFields(b []byte) (*Tx, []byte, error) {
+	var (
+		dec Tx
+		typ uint64
+		buf []byte
+		err error
+	)
+	if dec.ChainID, b, err = rlp.SplitUint(b); err != nil {
+		return nil, nil, err
+	}
+	if typ, b, err = rlp.SplitUint(b); err != nil {
+		return nil, nil, err
+	}
+	if typ > 0xff {
+		return nil, nil, rlp.ErrUintOverflow
+	}
+	dec.Type = TxType(typ)
+	if buf, b, err = rlp.SplitBytes(b); err != nil {
+		return nil, nil, err
+	}
+	dec.From = string(buf)
+	if buf, b, err = rlp.SplitBytes(b); err != nil {
+		return nil, nil, err
+	}
+	dec.To = string(buf)
+	if dec.Value, b, err = rlp.SplitBigInt(b); err != nil {
+		return nil, nil, err
+	}
+	if dec.Gas, b, err = rlp.SplitUint(b); err != nil {
+		return nil, nil, err
+	}
+	if dec.GasPrice, b, err = rlp.SplitBigInt(b); err != nil {
+		return nil, nil, err
+	}
+	if dec.Nonce, b, err = rlp.SplitUint(b); err != nil {
+		return nil, nil, err
+	}
+	if buf, b, err = rlp.SplitBytes(b); err != nil {
+		return nil, nil, err
+	}
+	dec.Data = cloneBytes(buf)
+	if buf, b, err = rlp.SplitBytes(b); err != nil {
+		return nil, nil, err
+	}
+	dec.PublicKey = cloneBytes(buf)
+	if buf, b, err = rlp.SplitBytes(b); err != nil {
+		return nil, nil, err
+	}
+	dec.Signature = cloneBytes(buf)
+	return &dec, b, nil
+}
+
+// encodeV1 returns the EnvelopeV1 payload of tx: an RLP list of its base
+// fields, optionally including the signature.
+func encodeV1(tx *Tx, withSig bool) []byte {
+	return rlp.EncodeList(baseFields(tx, withSig)...)
+}
+
+// decodeV1 decodes an EnvelopeV1 payload.
+func decodeV1(payload []byte) (*Tx, error) {
+	b, rest, err := rlp.SplitList(payload)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, rlp.ErrTrailingData
+	}
+	dec, b, err := splitBaseFields(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > 0 {
+		return nil, rlp.ErrTrailingData
+	}
+	return dec, nil
+}
+
+func cloneBytes(b []byte) []byte {
+	if len(b) == 0 {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
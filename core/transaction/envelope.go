@@ -0,0 +1,94 @@
+package transaction
+
+import (
+	"errors"
+	"fmt"
+)
+
+// EnvelopeType identifies the wire format of an encoded transaction. The
+// encoding of a transaction is the envelope type byte followed by a
+// type-specific payload, so new formats can be introduced without changing
+// how existing ones are framed or decoded.
+type EnvelopeType uint8
+
+const (
+	EnvelopeV1 EnvelopeType = 0x01 // RLP list of the base Tx fields
+)
+
+var (
+	ErrEmptyEnvelope   = errors.New("empty transaction envelope")
+	ErrUnknownEnvelope = errors.New("unknown transaction envelope type")
+)
+
+// EnvelopeCodec encodes and decodes the payload of one envelope type.
+type EnvelopeCodec struct {
+	// Encode returns the payload for tx, optionally including the signature.
+	Encode func(tx *Tx, withSig bool) []byte
+	// Decode parses a payload produced by Encode(tx, true).
+	Decode func(payload []byte) (*Tx, error)
+}
+
+var envelopes = map[EnvelopeType]EnvelopeCodec{}
+
+func init() {
+	RegisterEnvelope(EnvelopeV1, EnvelopeCodec{Encode: encodeV1, Decode: decodeV1})
+}
+
+// RegisterEnvelope adds a codec for an envelope type. It panics if the type
+// is already registered; it is meant to be called from init functions.
+func RegisterEnvelope(t EnvelopeType, codec EnvelopeCodec) {
+	if _, exists := envelopes[t]; exists {
+		panic(fmt.Sprintf("transaction: envelope type %#x already registered", uint8(t)))
+	}
+	envelopes[t] = codec
+}
+
+// envelopeType returns the envelope tx is encoded with. The zero value means
+// EnvelopeV1.
+func (tx *Tx) envelopeType() EnvelopeType {
+	if tx.Envelope == 0 {
+		return EnvelopeV1
+	}
+	return tx.Envelope
+}
+
+// encode returns the enveloped encoding of tx.
+func (tx *Tx) encode(withSig bool) ([]byte, error) {
+	t := tx.envelopeType()
+	codec, ok := envelopes[t]
+	if !ok {
+		return nil, ErrUnknownEnvelope
+	}
+	return append([]byte{byte(t)}, codec.Encode(tx, withSig)...), nil
+}
+
+// MarshalBinary returns the canonical enveloped encoding of tx, including
+// its signature. This is the wire and storage format.
+func (tx *Tx) MarshalBinary() ([]byte, error) {
+	return tx.encode(true)
+}
+
+// UnmarshalBinary decodes an enveloped transaction.
+func (tx *Tx) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return ErrEmptyEnvelope
+	}
+	t := EnvelopeType(data[0])
+	codec, ok := envelopes[t]
+	if !ok {
+		return fmt.Errorf("%w: %#x", ErrUnknownEnvelope, data[0])
+	}
+	dec, err := codec.Decode(data[1:])
+	if err != nil {
+		return err
+	}
+	dec.Envelope = t
+	*tx = *dec
+	return nil
+}
+
+// Size returns the length in bytes of the canonical encoding of tx.
+func (tx *Tx) Size() int {
+	enc, _ := tx.encode(true)
+	return len(enc)
+}
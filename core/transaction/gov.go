@@ -0,0 +1,168 @@
+package transaction
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// GasGovOp is the intrinsic gas of submitting, depositing on and voting on
+// a governance proposal.
+const GasGovOp = 50000
+
+// Limits of proposal texts, in bytes.
+const (
+	MaxProposalTitle       = 140
+	MaxProposalDescription = 10_000
+)
+
+// Proposal kinds.
+const (
+	ProposalParamChange   = "param_change"     // change protocol parameters
+	ProposalTreasurySpend = "treasury_spend"   // pay out of the community treasury
+	ProposalUpgrade       = "software_upgrade" // signal a software upgrade
+)
+
+// Vote options.
+const (
+	VoteYes        = "yes"
+	VoteNo         = "no"
+	VoteAbstain    = "abstain"
+	VoteNoWithVeto = "no_with_veto"
+)
+
+// ParamChange replaces parameters of Subspace, one of the parameter sets of
+// the state such as "consensus", "vm" or "tokenomics", with the fields set
+// in Value, a JSON object; fields left out keep their values.
+type ParamChange struct {
+	Subspace string          `json:"subspace"`
+	Value    json.RawMessage `json:"value"`
+}
+
+// TreasurySpend pays Amount out of the community treasury to Recipient.
+type TreasurySpend struct {
+	Recipient string   `json:"recipient"`
+	Amount    *big.Int `json:"amount"`
+}
+
+// UpgradePlan signals that the software upgrade Name takes effect at
+// Height. Info typically links to the release.
+type UpgradePlan struct {
+	Name   string `json:"name"`
+	Height uint64 `json:"height"`
+	Info   string `json:"info,omitempty"`
+}
+
+// Proposal is the Data of a TxGovSubmitProposal transaction, whose Value
+// is the initial deposit. The field matching Kind holds its content. The
+// Changes of a passed proposal take effect at once, or at ActivationHeight
+// if it is still ahead.
+type Proposal struct {
+	Kind             string         `json:"kind"`
+	Title            string         `json:"title"`
+	Description      string         `json:"description,omitempty"`
+	Changes          []ParamChange  `json:"changes,omitempty"`
+	ActivationHeight uint64         `json:"activationHeight,omitempty"`
+	Spend            *TreasurySpend `json:"spend,omitempty"`
+	Upgrade          *UpgradePlan   `json:"upgrade,omitempty"`
+}
+
+// ProposalDeposit is the Data of a TxGovDeposit transaction, which adds
+// its Value to the deposit of a proposal.
+type ProposalDeposit struct {
+	ProposalID uint64 `json:"proposalId"`
+}
+
+// ProposalVote is the Data of a TxGovVote transaction. A later vote of the
+// same voter replaces its earlier one.
+type ProposalVote struct {
+	ProposalID uint64 `json:"proposalId"`
+	Option     string `json:"option"`
+}
+
+// Check validates the form of p.
+func (p *Proposal) Check() error {
+	if p.Title == "" || len(p.Title) > MaxProposalTitle {
+		return fmt.Errorf("%w: title must be 1 to %d bytes", ErrInvalidData, MaxProposalTitle)
+	}
+	if len(p.Description) > MaxProposalDescription {
+		return fmt.Errorf("%w: description exceeds %d bytes", ErrInvalidData, MaxProposalDescription)
+	}
+	if p.ActivationHeight != 0 && p.Kind != ProposalParamChange {
+		return fmt.Errorf("%w: only parameter changes have an activation height", ErrInvalidData)
+	}
+	switch p.Kind {
+	case ProposalParamChange:
+		if len(p.Changes) == 0 {
+			return fmt.Errorf("%w: no parameter changes", ErrInvalidData)
+		}
+		for _, c := range p.Changes {
+			var fields map[string]json.RawMessage
+			if c.Subspace == "" || json.Unmarshal(c.Value, &fields) != nil || len(fields) == 0 {
+				return fmt.Errorf("%w: parameter change needs a subspace and an object value", ErrInvalidData)
+			}
+		}
+	case ProposalTreasurySpend:
+		if p.Spend == nil || p.Spend.Amount == nil || p.Spend.Amount.Sign() <= 0 {
+			return fmt.Errorf("%w: treasury spend needs a positive amount", ErrInvalidData)
+		}
+		return checkCanonical(p.Spend.Recipient)
+	case ProposalUpgrade:
+		if p.Upgrade == nil || p.Upgrade.Name == "" || p.Upgrade.Height == 0 {
+			return fmt.Errorf("%w: upgrade needs a name and a height", ErrInvalidData)
+		}
+	default:
+		return fmt.Errorf("%w: unknown proposal kind %q", ErrInvalidData, p.Kind)
+	}
+	return nil
+}
+
+func checkProposal(data []byte) error {
+	var p Proposal
+	if err := decodeData(data, &p); err != nil {
+		return err
+	}
+	return p.Check()
+}
+
+func checkProposalVote(data []byte) error {
+	var v ProposalVote
+	if err := decodeData(data, &v); err != nil {
+		return err
+	}
+	switch v.Option {
+	case VoteYes, VoteNo, VoteAbstain, VoteNoWithVeto:
+		return nil
+	}
+	return fmt.Errorf("%w: unknown vote option %q", ErrInvalidData, v.Option)
+}
+
+// NewGovSubmitProposalTx creates a transaction submitting p with an
+// initial deposit.
+func NewGovSubmitProposalTx(from string, p Proposal, deposit *big.Int, nonce uint64, gasPrice *big.Int) *Tx {
+	return newGovTx(TxGovSubmitProposal, from, p, deposit, nonce, gasPrice)
+}
+
+// NewGovDepositTx creates a transaction adding amount to the deposit of
+// proposal id.
+func NewGovDepositTx(from string, id uint64, amount *big.Int, nonce uint64, gasPrice *big.Int) *Tx {
+	return newGovTx(TxGovDeposit, from, ProposalDeposit{ProposalID: id}, amount, nonce, gasPrice)
+}
+
+// NewGovVoteTx creates a transaction voting option on proposal id.
+func NewGovVoteTx(from string, id uint64, option string, nonce uint64, gasPrice *big.Int) *Tx {
+	return newGovTx(TxGovVote, from, ProposalVote{ProposalID: id, Option: option}, nil, nonce, gasPrice)
+}
+
+func newGovTx(typ TxType, from string, payload interface{}, value *big.Int, nonce uint64, gasPrice *big.Int) *Tx {
+	data, _ := json.Marshal(payload)
+	return &Tx{
+		Type:     typ,
+		From:     from,
+		Value:    value,
+		Gas:      GasGovOp,
+		GasPrice: gasPrice,
+		Nonce:    nonce,
+		Data:     data,
+	}
+}
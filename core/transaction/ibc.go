@@ -0,0 +1,283 @@
+package transaction
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// GasIBCOp is the intrinsic gas of the IBC client, handshake and packet
+// transactions. Sending an agent message over IBC costs its MessageGas on
+// top.
+const GasIBCOp = 80000
+
+// IBC ports, each bound to the application handling its packets.
+const (
+	IBCPortTransfer = "transfer" // token transfers
+	IBCPortAgent    = "agent"    // agent messages
+)
+
+// IBCNativeDenom is the denomination of ZIO in IBC token transfers.
+const IBCNativeDenom = "zio"
+
+// Steps of the connection and channel handshakes. The chain starting the
+// handshake sends init and ack, the counterparty try and confirm.
+const (
+	IBCStepInit    = "init"
+	IBCStepTry     = "try"
+	IBCStepAck     = "ack"
+	IBCStepConfirm = "confirm"
+)
+
+// IBCValidator is a validator of a counterparty chain tracked by an IBC
+// client.
+type IBCValidator struct {
+	Address   string `json:"address"`
+	PublicKey []byte `json:"publicKey"`
+	Power     int64  `json:"power"`
+}
+
+// IBCCommitSig is the signature of a counterparty validator over the
+// signing hash of a header, besides that of its proposer.
+type IBCCommitSig struct {
+	Validator string `json:"validator"`
+	Signature []byte `json:"signature"`
+}
+
+// IBCProof proves an entry of the agent state of the counterparty chain
+// under the AgentRoot of its header at Height, which the client must
+// hold. The verifier derives the key of the entry; Value is its JSON
+// encoding, as returned by zion_getAgentProof.
+type IBCProof struct {
+	Height uint64          `json:"height"`
+	Value  json.RawMessage `json:"value"`
+	Index  int             `json:"index"`
+	Total  int             `json:"total"`
+	Proof  [][32]byte      `json:"proof"`
+}
+
+// IBCCreateClient is the Data of a TxIBCCreateClient transaction, which
+// creates a client of the counterparty chain trusting Header, its RLP
+// encoding, and the validator set signing the headers following it.
+type IBCCreateClient struct {
+	ChainID    uint64         `json:"chainId"`
+	Header     []byte         `json:"header"`
+	Validators []IBCValidator `json:"validators"`
+}
+
+// IBCUpdateClient is the Data of a TxIBCUpdateClient transaction, which
+// adds a header committed by the validators of the client. Validators,
+// if set, replaces the validator set of the client.
+type IBCUpdateClient struct {
+	ClientID   string         `json:"clientId"`
+	Header     []byte         `json:"header"`
+	Commit     []IBCCommitSig `json:"commit,omitempty"`
+	Validators []IBCValidator `json:"validators,omitempty"`
+}
+
+// IBCConnectionOpen is the Data of a TxIBCConnectionOpen transaction,
+// taking Step of the handshake of a connection between ClientID and
+// CounterpartyClientID. Proof proves the connection end of the
+// counterparty in the previous step, except for init.
+type IBCConnectionOpen struct {
+	Step                     string    `json:"step"`
+	ConnectionID             string    `json:"connectionId,omitempty"` // ack, confirm
+	ClientID                 string    `json:"clientId,omitempty"`     // init, try
+	CounterpartyClientID     string    `json:"counterpartyClientId,omitempty"`
+	CounterpartyConnectionID string    `json:"counterpartyConnectionId,omitempty"` // try, ack
+	Proof                    *IBCProof `json:"proof,omitempty"`
+}
+
+// IBCChannelOpen is the Data of a TxIBCChannelOpen transaction, taking
+// Step of the handshake of a channel of Port over ConnectionID. Proof
+// proves the channel end of the counterparty in the previous step, except
+// for init.
+type IBCChannelOpen struct {
+	Step                  string    `json:"step"`
+	Port                  string    `json:"port"`
+	ChannelID             string    `json:"channelId,omitempty"`             // ack, confirm
+	ConnectionID          string    `json:"connectionId,omitempty"`          // init, try
+	CounterpartyChannelID string    `json:"counterpartyChannelId,omitempty"` // try, ack
+	Proof                 *IBCProof `json:"proof,omitempty"`
+}
+
+// IBCPacket carries Data from a channel end to its counterparty. It times
+// out once the counterparty reaches TimeoutHeight, if set.
+type IBCPacket struct {
+	Sequence      uint64          `json:"sequence"`
+	SourcePort    string          `json:"sourcePort"`
+	SourceChannel string          `json:"sourceChannel"`
+	DestPort      string          `json:"destPort"`
+	DestChannel   string          `json:"destChannel"`
+	Data          json.RawMessage `json:"data"`
+	TimeoutHeight uint64          `json:"timeoutHeight,omitempty"`
+}
+
+// IBCAcknowledgement is the outcome of receiving a packet: Error is set if
+// the application failed, or the packet timed out.
+type IBCAcknowledgement struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// IBCTransferData is the packet data of the transfer port. Denom is the
+// path of the token, IBCNativeDenom prefixed with a port/channel/ for each
+// hop away from its chain.
+type IBCTransferData struct {
+	Denom    string `json:"denom"`
+	Amount   string `json:"amount"`
+	Sender   string `json:"sender"`
+	Receiver string `json:"receiver"`
+}
+
+// IBCTransfer is the Data of a TxIBCTransfer transaction, which sends
+// Amount of Denom of the sender to Receiver over a channel of the transfer
+// port.
+type IBCTransfer struct {
+	ChannelID     string   `json:"channelId"`
+	Denom         string   `json:"denom"`
+	Amount        *big.Int `json:"amount"`
+	Receiver      string   `json:"receiver"`
+	TimeoutHeight uint64   `json:"timeoutHeight,omitempty"`
+}
+
+// IBCSendMessage is the Data of a TxIBCSendMessage transaction, which
+// sends Message from an agent of the sender to an agent of the
+// counterparty over a channel of the agent port.
+type IBCSendMessage struct {
+	ChannelID     string       `json:"channelId"`
+	Message       AgentMessage `json:"message"`
+	TimeoutHeight uint64       `json:"timeoutHeight,omitempty"`
+}
+
+// IBCRecvPacket is the Data of a TxIBCRecvPacket transaction, relaying
+// Packet with the proof of its commitment on the counterparty.
+type IBCRecvPacket struct {
+	Packet IBCPacket `json:"packet"`
+	Proof  IBCProof  `json:"proof"`
+}
+
+// IBCAcknowledgePacket is the Data of a TxIBCAcknowledgePacket
+// transaction, relaying the acknowledgement of a sent Packet with the
+// proof of its record on the counterparty.
+type IBCAcknowledgePacket struct {
+	Packet          IBCPacket          `json:"packet"`
+	Acknowledgement IBCAcknowledgement `json:"acknowledgement"`
+	Proof           IBCProof           `json:"proof"`
+}
+
+func checkIBCID(field, id string) error {
+	if id == "" || len(id) > 64 || strings.ContainsAny(id, "/\x00") {
+		return fmt.Errorf("%w: invalid %s %q", ErrInvalidData, field, id)
+	}
+	return nil
+}
+
+func checkIBCPort(port string) error {
+	if port != IBCPortTransfer && port != IBCPortAgent {
+		return fmt.Errorf("%w: unknown port %q", ErrInvalidData, port)
+	}
+	return nil
+}
+
+func checkIBCStep(step string, proof *IBCProof) error {
+	switch step {
+	case IBCStepInit:
+		return nil
+	case IBCStepTry, IBCStepAck, IBCStepConfirm:
+		if proof == nil {
+			return fmt.Errorf("%w: %s needs a proof", ErrInvalidData, step)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: unknown handshake step %q", ErrInvalidData, step)
+}
+
+func checkIBC(typ TxType, data []byte) error {
+	switch typ {
+	case TxIBCCreateClient:
+		var c IBCCreateClient
+		if err := decodeData(data, &c); err != nil {
+			return err
+		}
+		if len(c.Header) == 0 || len(c.Validators) == 0 {
+			return fmt.Errorf("%w: client needs a header and validators", ErrInvalidData)
+		}
+	case TxIBCUpdateClient:
+		var u IBCUpdateClient
+		if err := decodeData(data, &u); err != nil {
+			return err
+		}
+		if len(u.Header) == 0 {
+			return fmt.Errorf("%w: update needs a header", ErrInvalidData)
+		}
+		return checkIBCID("client", u.ClientID)
+	case TxIBCConnectionOpen:
+		var c IBCConnectionOpen
+		if err := decodeData(data, &c); err != nil {
+			return err
+		}
+		return checkIBCStep(c.Step, c.Proof)
+	case TxIBCChannelOpen:
+		var c IBCChannelOpen
+		if err := decodeData(data, &c); err != nil {
+			return err
+		}
+		if err := checkIBCPort(c.Port); err != nil {
+			return err
+		}
+		return checkIBCStep(c.Step, c.Proof)
+	case TxIBCTransfer:
+		var t IBCTransfer
+		if err := decodeData(data, &t); err != nil {
+			return err
+		}
+		if t.Amount == nil || t.Amount.Sign() <= 0 || t.Denom == "" || t.Receiver == "" {
+			return fmt.Errorf("%w: transfer needs a denom, a positive amount and a receiver", ErrInvalidData)
+		}
+		return checkIBCID("channel", t.ChannelID)
+	case TxIBCSendMessage:
+		var m IBCSendMessage
+		if err := decodeData(data, &m); err != nil {
+			return err
+		}
+		if m.Message.From == "" || m.Message.To == "" {
+			return fmt.Errorf("%w: message needs a sender and a recipient", ErrInvalidData)
+		}
+		return checkIBCID("channel", m.ChannelID)
+	case TxIBCRecvPacket:
+		var r IBCRecvPacket
+		return decodeData(data, &r)
+	case TxIBCAcknowledgePacket:
+		var a IBCAcknowledgePacket
+		return decodeData(data, &a)
+	}
+	return nil
+}
+
+// IBCGas returns the intrinsic gas of the IBC transaction tx.
+func IBCGas(tx *Tx) uint64 {
+	if tx.Type == TxIBCSendMessage {
+		var m IBCSendMessage
+		if json.Unmarshal(tx.Data, &m) == nil {
+			return GasIBCOp + MessageGas(m.Message)
+		}
+	}
+	return GasIBCOp
+}
+
+// NewIBCTx creates an IBC transaction of type typ carrying payload, one of
+// the IBC Data types.
+func NewIBCTx(typ TxType, from string, payload interface{}, nonce uint64, gasPrice *big.Int) *Tx {
+	data, _ := json.Marshal(payload)
+	tx := &Tx{
+		Type:     typ,
+		From:     from,
+		GasPrice: gasPrice,
+		Nonce:    nonce,
+		Data:     data,
+	}
+	tx.Gas = IBCGas(tx)
+	return tx
+}
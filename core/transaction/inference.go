@@ -0,0 +1,54 @@
+package transaction
+
+import (
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/zionlayer/zionlayer/core/crypto"
+	"github.com/zionlayer/zionlayer/core/rlp"
+	"github.com/zionlayer/zionlayer/core/zk"
+)
+
+// SigningHash returns the digest signed by the prover of the receipt: the
+// SHA-256 hash of the RLP list of AgentID, ModelHash, InputHash, OutputHash
+// and Timestamp.
+func (r *InferenceReceipt) SigningHash() [32]byte {
+	return sha256.Sum256(rlp.EncodeList(
+		rlp.EncodeString(r.AgentID),
+		rlp.EncodeBytes(r.ModelHash),
+		rlp.EncodeBytes(r.InputHash),
+		rlp.EncodeBytes(r.OutputHash),
+		rlp.EncodeUint(uint64(r.Timestamp)),
+	))
+}
+
+// Sign sets ProverSig to the signature of the receipt by priv, the key of
+// the compute provider Prover.
+func (r *InferenceReceipt) Sign(priv crypto.PrivateKey) error {
+	sig, err := crypto.Sign(priv, r.SigningHash())
+	if err != nil {
+		return err
+	}
+	r.ProverSig = sig
+	return nil
+}
+
+// ProofInputs returns the public inputs of the zkML proof of the receipt:
+// its ModelHash, InputHash and OutputHash, each reduced into the scalar
+// field. The circuit of the model must expose them in this order.
+func (r *InferenceReceipt) ProofInputs() []*big.Int {
+	return []*big.Int{
+		zk.InputFromBytes(r.ModelHash),
+		zk.InputFromBytes(r.InputHash),
+		zk.InputFromBytes(r.OutputHash),
+	}
+}
+
+// ReceiptGas returns the gas of executing receipt r: GasInferenceReceipt,
+// plus the verification of its zkML proof if it carries one.
+func ReceiptGas(r *InferenceReceipt) uint64 {
+	if len(r.ZKProof) == 0 {
+		return GasInferenceReceipt
+	}
+	return GasInferenceReceipt + zk.VerifyGas(len(r.ZKProof), len(r.ProofInputs()))
+}
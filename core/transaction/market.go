@@ -0,0 +1,84 @@
+package transaction
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// GasOfferOp is the intrinsic gas of posting, updating and withdrawing a
+// service offer.
+const GasOfferOp = 40000
+
+// ServiceSLA is the service level an agent commits to for an offer.
+type ServiceSLA struct {
+	LatencyMs    uint64 `json:"latencyMs"`              // p95 response time
+	Availability uint32 `json:"availability,omitempty"` // basis points, e.g. 9990 for 99.9%
+}
+
+// ServiceOffer is the Data of TxOfferPost and TxOfferUpdate transactions:
+// an agent offers to serve calls of one of its capabilities at a price.
+// An agent has at most one offer per capability name, and only its
+// controller may post, update or withdraw it.
+type ServiceOffer struct {
+	AgentID      string            `json:"agentId"`
+	Capability   Capability        `json:"capability"`
+	PricePerCall *big.Int          `json:"pricePerCall"` // in the smallest $ZIO unit
+	SLA          ServiceSLA        `json:"sla"`
+	Endpoint     string            `json:"endpoint,omitempty"`
+	Models       [][]byte          `json:"models,omitempty"` // hashes of registered models served
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+// OfferRef is the Data of a TxOfferWithdraw transaction.
+type OfferRef struct {
+	AgentID    string `json:"agentId"`
+	Capability string `json:"capability"` // capability name
+}
+
+// checkOffer validates the Data of an offer transaction.
+func checkOffer(data []byte) error {
+	var o ServiceOffer
+	if err := decodeData(data, &o); err != nil {
+		return err
+	}
+	if o.AgentID == "" || o.Capability.Name == "" {
+		return fmt.Errorf("%w: offer agent and capability required", ErrInvalidData)
+	}
+	if o.PricePerCall == nil || o.PricePerCall.Sign() < 0 {
+		return fmt.Errorf("%w: offer price must be non-negative", ErrInvalidData)
+	}
+	if o.SLA.Availability > 10000 {
+		return fmt.Errorf("%w: availability above 10000 basis points", ErrInvalidData)
+	}
+	return nil
+}
+
+// NewOfferPostTx creates a transaction posting offer.
+func NewOfferPostTx(from string, offer ServiceOffer, nonce uint64, gasPrice *big.Int) *Tx {
+	return newOfferTx(TxOfferPost, from, offer, nonce, gasPrice)
+}
+
+// NewOfferUpdateTx creates a transaction replacing the terms of an offer
+// posted for the same agent and capability.
+func NewOfferUpdateTx(from string, offer ServiceOffer, nonce uint64, gasPrice *big.Int) *Tx {
+	return newOfferTx(TxOfferUpdate, from, offer, nonce, gasPrice)
+}
+
+// NewOfferWithdrawTx creates a transaction withdrawing the offer of agentID
+// for capability.
+func NewOfferWithdrawTx(from, agentID, capability string, nonce uint64, gasPrice *big.Int) *Tx {
+	return newOfferTx(TxOfferWithdraw, from, OfferRef{AgentID: agentID, Capability: capability}, nonce, gasPrice)
+}
+
+func newOfferTx(typ TxType, from string, payload interface{}, nonce uint64, gasPrice *big.Int) *Tx {
+	data, _ := json.Marshal(payload)
+	return &Tx{
+		Type:     typ,
+		From:     from,
+		Gas:      GasOfferOp,
+		GasPrice: gasPrice,
+		Nonce:    nonce,
+		Data:     data,
+	}
+}
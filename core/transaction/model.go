@@ -0,0 +1,79 @@
+package transaction
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// GasModelOp is the intrinsic gas of registering, updating and deprecating
+// a model.
+const GasModelOp = 50000
+
+// ModelInfo is the Data of TxModelRegister and TxModelUpdate transactions:
+// the metadata of the model whose weights hash to Hash. The sender of the
+// registration owns the model and is the only one who may update or
+// deprecate it.
+type ModelInfo struct {
+	Hash     []byte            `json:"hash"`          // hash of the weights, as in InferenceReceipt.ModelHash
+	CID      string            `json:"cid,omitempty"` // content identifier of the weights
+	Name     string            `json:"name"`
+	Version  string            `json:"version,omitempty"`
+	License  string            `json:"license,omitempty"` // SPDX identifier
+	Runtime  string            `json:"runtime,omitempty"` // expected runtime, e.g. onnx or gguf
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// ModelRef is the Data of a TxModelDeprecate transaction.
+type ModelRef struct {
+	Hash []byte `json:"hash"`
+}
+
+func checkModel(data []byte) error {
+	var m ModelInfo
+	if err := decodeData(data, &m); err != nil {
+		return err
+	}
+	if len(m.Hash) == 0 || m.Name == "" {
+		return fmt.Errorf("%w: model hash and name required", ErrInvalidData)
+	}
+	return nil
+}
+
+func checkModelRef(data []byte) error {
+	var ref ModelRef
+	if err := decodeData(data, &ref); err != nil {
+		return err
+	}
+	if len(ref.Hash) == 0 {
+		return fmt.Errorf("%w: model hash required", ErrInvalidData)
+	}
+	return nil
+}
+
+// NewModelRegisterTx creates a transaction registering a model.
+func NewModelRegisterTx(from string, m ModelInfo, nonce uint64, gasPrice *big.Int) *Tx {
+	return newModelTx(TxModelRegister, from, m, nonce, gasPrice)
+}
+
+// NewModelUpdateTx creates a transaction replacing the metadata of a model.
+func NewModelUpdateTx(from string, m ModelInfo, nonce uint64, gasPrice *big.Int) *Tx {
+	return newModelTx(TxModelUpdate, from, m, nonce, gasPrice)
+}
+
+// NewModelDeprecateTx creates a transaction deprecating the model hash.
+func NewModelDeprecateTx(from string, hash []byte, nonce uint64, gasPrice *big.Int) *Tx {
+	return newModelTx(TxModelDeprecate, from, ModelRef{Hash: hash}, nonce, gasPrice)
+}
+
+func newModelTx(typ TxType, from string, payload interface{}, nonce uint64, gasPrice *big.Int) *Tx {
+	data, _ := json.Marshal(payload)
+	return &Tx{
+		Type:     typ,
+		From:     from,
+		Gas:      GasModelOp,
+		GasPrice: gasPrice,
+		Nonce:    nonce,
+		Data:     data,
+	}
+}
@@ -0,0 +1,192 @@
+package transaction
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/zionlayer/zionlayer/core/common"
+	"github.com/zionlayer/zionlayer/core/crypto"
+	"github.com/zionlayer/zionlayer/core/rlp"
+)
+
+// EnvelopeMultisig is a transaction sent from a native M-of-N multisig
+// account. The account address is derived from the threshold and ordered
+// key set, which travel with the transaction; Signature holds one entry per
+// key (empty for keys that did not sign), packed with EncodeSignatures.
+const EnvelopeMultisig EnvelopeType = 0x03
+
+// MaxMultisigKeys caps the size of a multisig key set.
+const MaxMultisigKeys = 16
+
+var (
+	ErrInvalidMultisig   = errors.New("invalid multisig key set")
+	ErrMultisigKey       = errors.New("key is not part of the multisig")
+	ErrMultisigThreshold = errors.New("not enough valid multisig signatures")
+)
+
+// MultisigKeys describes a native multisig account.
+type MultisigKeys struct {
+	Threshold  uint8    `json:"threshold"`
+	PublicKeys [][]byte `json:"publicKeys"`
+}
+
+// Address returns the account address of the key set.
+func (m *MultisigKeys) Address() common.Address {
+	return crypto.MultisigAddress(m.Threshold, m.PublicKeys)
+}
+
+// Validate checks that the threshold is reachable and keys are well-formed
+// and distinct.
+func (m *MultisigKeys) Validate() error {
+	n := len(m.PublicKeys)
+	if n == 0 || n > MaxMultisigKeys || m.Threshold == 0 || int(m.Threshold) > n {
+		return ErrInvalidMultisig
+	}
+	for i, pub := range m.PublicKeys {
+		if len(pub) != crypto.PublicKeySize {
+			return ErrInvalidMultisig
+		}
+		for _, other := range m.PublicKeys[:i] {
+			if bytes.Equal(pub, other) {
+				return ErrInvalidMultisig
+			}
+		}
+	}
+	return nil
+}
+
+func init() {
+	RegisterEnvelope(EnvelopeMultisig, EnvelopeCodec{Encode: encodeMultisig, Decode: decodeMultisig})
+}
+
+func encodeMultisig(tx *Tx, withSig bool) []byte {
+	var m MultisigKeys
+	if tx.Multisig != nil {
+		m = *tx.Multisig
+	}
+	keys := make([][]byte, len(m.PublicKeys))
+	for i, pub := range m.PublicKeys {
+		keys[i] = rlp.EncodeBytes(pub)
+	}
+	fields := append(baseFields(tx, withSig), rlp.EncodeUint(uint64(m.Threshold)), rlp.EncodeList(keys...))
+	return rlp.EncodeList(fields...)
+}
+
+func decodeMultisig(payload []byte) (*Tx, error) {
+	b, rest, err := rlp.SplitList(payload)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, rlp.ErrTrailingData
+	}
+	dec, b, err := splitBaseFields(b)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		m         MultisigKeys
+		threshold uint64
+		keys      []byte
+	)
+	if threshold, b, err = rlp.SplitUint(b); err != nil {
+		return nil, err
+	}
+	if threshold > 0xff {
+		return nil, rlp.ErrUintOverflow
+	}
+	m.Threshold = uint8(threshold)
+	if keys, b, err = rlp.SplitList(b); err != nil {
+		return nil, err
+	}
+	if len(b) > 0 {
+		return nil, rlp.ErrTrailingData
+	}
+	for len(keys) > 0 {
+		var pub []byte
+		if pub, keys, err = rlp.SplitBytes(keys); err != nil {
+			return nil, err
+		}
+		m.PublicKeys = append(m.PublicKeys, cloneBytes(pub))
+	}
+	dec.Multisig = &m
+	return dec, nil
+}
+
+// SetMultisig turns tx into a multisig transaction sent from the account of
+// keys and bound to chainID. It must be called before any key signs.
+func (tx *Tx) SetMultisig(keys MultisigKeys, chainID uint64) error {
+	if err := keys.Validate(); err != nil {
+		return err
+	}
+	tx.Envelope = EnvelopeMultisig
+	tx.ChainID = chainID
+	tx.Multisig = &keys
+	tx.From = keys.Address().String()
+	tx.PublicKey = nil
+	tx.Signature = EncodeSignatures(make([][]byte, len(keys.PublicKeys)))
+	tx.resetHash()
+	return nil
+}
+
+// SignMultisig adds the signature of priv, which must be one of the keys
+// of the multisig account.
+func (tx *Tx) SignMultisig(priv crypto.PrivateKey) error {
+	if tx.Multisig == nil {
+		return ErrInvalidMultisig
+	}
+	pub, ok := priv.Public().(crypto.PublicKey)
+	if !ok {
+		return crypto.ErrInvalidPrivateKey
+	}
+	sigs, err := DecodeSignatures(tx.Signature)
+	if err != nil || len(sigs) != len(tx.Multisig.PublicKeys) {
+		sigs = make([][]byte, len(tx.Multisig.PublicKeys))
+	}
+	for i, key := range tx.Multisig.PublicKeys {
+		if bytes.Equal(key, pub) {
+			sig, err := crypto.Sign(priv, tx.SigningHash())
+			if err != nil {
+				return err
+			}
+			sigs[i] = sig
+			tx.Signature = EncodeSignatures(sigs)
+			return nil
+		}
+	}
+	return ErrMultisigKey
+}
+
+// multisigSender verifies a multisig transaction: the key set must derive
+// From and at least Threshold keys must have signed.
+func (tx *Tx) multisigSender() (common.Address, error) {
+	m := tx.Multisig
+	if m == nil {
+		return common.Address{}, ErrInvalidMultisig
+	}
+	if err := m.Validate(); err != nil {
+		return common.Address{}, err
+	}
+	addr := m.Address()
+	if addr.String() != tx.From {
+		return common.Address{}, ErrInvalidSender
+	}
+	sigs, err := DecodeSignatures(tx.Signature)
+	if err != nil || len(sigs) != len(m.PublicKeys) {
+		return common.Address{}, ErrMissingSignature
+	}
+	h, err := tx.verifyingHash()
+	if err != nil {
+		return common.Address{}, err
+	}
+	valid := 0
+	for i, sig := range sigs {
+		if len(sig) > 0 && crypto.Verify(m.PublicKeys[i], h, sig) == nil {
+			valid++
+		}
+	}
+	if valid < int(m.Threshold) {
+		return common.Address{}, ErrMultisigThreshold
+	}
+	return addr, nil
+}
@@ -0,0 +1,121 @@
+package transaction
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// GasNFTOp is the intrinsic gas of the ZRC-721 transactions.
+const GasNFTOp = 50000
+
+// Limits of ZRC-721 metadata, in bytes.
+const (
+	MaxNFTID   = 64
+	MaxNFTURI  = 512
+	MaxNFTName = 64
+)
+
+// NFTCreateCollection is the Data of a TxNFTCreateCollection transaction,
+// which registers a ZRC-721 collection under Symbol whose tokens only the
+// sender mints.
+type NFTCreateCollection struct {
+	Symbol string `json:"symbol"`
+	Name   string `json:"name"`
+}
+
+// NFTMint is the Data of a TxNFTMint transaction, which mints the token ID
+// of Collection to To. URI locates its metadata and ContentHash, if set,
+// is the SHA-256 digest of the artifact it represents, such as a model
+// checkpoint or a dataset.
+type NFTMint struct {
+	Collection  string `json:"collection"`
+	ID          string `json:"id"`
+	To          string `json:"to"`
+	URI         string `json:"uri"`
+	ContentHash []byte `json:"contentHash,omitempty"`
+}
+
+// NFTTransfer is the Data of a TxNFTTransfer transaction, which moves the
+// token ID of Collection from the sender to To.
+type NFTTransfer struct {
+	Collection string `json:"collection"`
+	ID         string `json:"id"`
+	To         string `json:"to"`
+}
+
+// NFTBurn is the Data of a TxNFTBurn transaction, which burns the token ID
+// of Collection owned by the sender.
+type NFTBurn struct {
+	Collection string `json:"collection"`
+	ID         string `json:"id"`
+}
+
+// CheckNFTID checks that id is a valid ZRC-721 token ID: 1 to MaxNFTID
+// bytes without slashes or control characters.
+func CheckNFTID(id string) error {
+	if id == "" || len(id) > MaxNFTID || strings.ContainsAny(id, "/\x00") {
+		return fmt.Errorf("%w: invalid token id %q", ErrInvalidData, id)
+	}
+	return nil
+}
+
+func checkNFT(typ TxType, data []byte) error {
+	switch typ {
+	case TxNFTCreateCollection:
+		var c NFTCreateCollection
+		if err := decodeData(data, &c); err != nil {
+			return err
+		}
+		if c.Name == "" || len(c.Name) > MaxNFTName {
+			return fmt.Errorf("%w: collection name must be 1 to %d bytes", ErrInvalidData, MaxNFTName)
+		}
+		return CheckTokenSymbol(c.Symbol)
+	case TxNFTMint:
+		var m NFTMint
+		if err := decodeData(data, &m); err != nil {
+			return err
+		}
+		if len(m.URI) > MaxNFTURI {
+			return fmt.Errorf("%w: uri exceeds %d bytes", ErrInvalidData, MaxNFTURI)
+		}
+		if m.ContentHash != nil && len(m.ContentHash) != 32 {
+			return fmt.Errorf("%w: content hash must be 32 bytes", ErrInvalidData)
+		}
+		if err := CheckNFTID(m.ID); err != nil {
+			return err
+		}
+		return checkCanonical(m.To)
+	case TxNFTTransfer:
+		var t NFTTransfer
+		if err := decodeData(data, &t); err != nil {
+			return err
+		}
+		if err := CheckNFTID(t.ID); err != nil {
+			return err
+		}
+		return checkCanonical(t.To)
+	case TxNFTBurn:
+		var b NFTBurn
+		if err := decodeData(data, &b); err != nil {
+			return err
+		}
+		return CheckNFTID(b.ID)
+	}
+	return nil
+}
+
+// NewNFTTx creates a ZRC-721 transaction of type typ carrying payload, one
+// of the NFT Data types.
+func NewNFTTx(typ TxType, from string, payload interface{}, nonce uint64, gasPrice *big.Int) *Tx {
+	data, _ := json.Marshal(payload)
+	return &Tx{
+		Type:     typ,
+		From:     from,
+		Gas:      GasNFTOp,
+		GasPrice: gasPrice,
+		Nonce:    nonce,
+		Data:     data,
+	}
+}
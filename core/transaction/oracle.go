@@ -0,0 +1,86 @@
+package transaction
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// GasOracleOp is the intrinsic gas of registering and deregistering a
+// quality evaluator and of submitting a quality score.
+const GasOracleOp = 40000
+
+// MaxQualityScore is the highest quality score, in basis points.
+const MaxQualityScore = 10_000
+
+// EvaluatorRegistration is the Data of a TxEvaluatorRegister transaction.
+// The sender joins the oracle committee scoring the quality of inference
+// outputs and escrows Stake, which weighs its scores, until it
+// deregisters.
+type EvaluatorRegistration struct {
+	Stake *big.Int `json:"stake"`
+}
+
+// QualityScore is the Data of a TxQualityScore transaction sent by a
+// committee evaluator: its score of the output of the inference receipt
+// included by the transaction ReceiptHash.
+type QualityScore struct {
+	ReceiptHash []byte `json:"receiptHash"`
+	Score       uint64 `json:"score"` // 0 to MaxQualityScore
+}
+
+func checkEvaluatorRegistration(data []byte) error {
+	var reg EvaluatorRegistration
+	if err := decodeData(data, &reg); err != nil {
+		return err
+	}
+	if reg.Stake == nil || reg.Stake.Sign() <= 0 {
+		return fmt.Errorf("%w: stake must be positive", ErrInvalidData)
+	}
+	return nil
+}
+
+func checkQualityScore(data []byte) error {
+	var q QualityScore
+	if err := decodeData(data, &q); err != nil {
+		return err
+	}
+	if len(q.ReceiptHash) != 32 {
+		return fmt.Errorf("%w: receipt hash must be 32 bytes", ErrInvalidData)
+	}
+	if q.Score > MaxQualityScore {
+		return fmt.Errorf("%w: score exceeds %d", ErrInvalidData, MaxQualityScore)
+	}
+	return nil
+}
+
+// NewEvaluatorRegisterTx creates a transaction registering the sender as a
+// quality evaluator.
+func NewEvaluatorRegisterTx(from string, reg EvaluatorRegistration, nonce uint64, gasPrice *big.Int) *Tx {
+	data, _ := json.Marshal(reg)
+	return newOracleTx(TxEvaluatorRegister, from, data, nonce, gasPrice)
+}
+
+// NewEvaluatorDeregisterTx creates a transaction deregistering the sender
+// as a quality evaluator, which starts the unbonding of its stake.
+func NewEvaluatorDeregisterTx(from string, nonce uint64, gasPrice *big.Int) *Tx {
+	return newOracleTx(TxEvaluatorDeregister, from, nil, nonce, gasPrice)
+}
+
+// NewQualityScoreTx creates a transaction scoring the output of an
+// inference receipt.
+func NewQualityScoreTx(from string, q QualityScore, nonce uint64, gasPrice *big.Int) *Tx {
+	data, _ := json.Marshal(q)
+	return newOracleTx(TxQualityScore, from, data, nonce, gasPrice)
+}
+
+func newOracleTx(typ TxType, from string, data []byte, nonce uint64, gasPrice *big.Int) *Tx {
+	return &Tx{
+		Type:     typ,
+		From:     from,
+		Gas:      GasOracleOp,
+		GasPrice: gasPrice,
+		Nonce:    nonce,
+		Data:     data,
+	}
+}
@@ -0,0 +1,65 @@
+package transaction
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/zionlayer/zionlayer/core/crypto"
+)
+
+// GasProviderOp is the intrinsic gas of registering and deregistering a
+// compute provider.
+const GasProviderOp = 50000
+
+// ProviderRegistration is the Data of a TxProviderRegister transaction. The
+// sender becomes a compute provider proving inferences of Models with
+// signatures by PubKey, and escrows Bond until it deregisters.
+type ProviderRegistration struct {
+	PubKey []byte   `json:"pubKey"` // ed25519 key signing receipts
+	Models [][]byte `json:"models"` // model hashes the provider serves
+	Bond   *big.Int `json:"bond"`
+}
+
+func checkProviderRegistration(data []byte) error {
+	var reg ProviderRegistration
+	if err := decodeData(data, &reg); err != nil {
+		return err
+	}
+	if len(reg.PubKey) != crypto.PublicKeySize {
+		return fmt.Errorf("%w: provider key must be %d bytes", ErrInvalidData, crypto.PublicKeySize)
+	}
+	if len(reg.Models) == 0 {
+		return fmt.Errorf("%w: no models", ErrInvalidData)
+	}
+	if reg.Bond == nil || reg.Bond.Sign() <= 0 {
+		return fmt.Errorf("%w: bond must be positive", ErrInvalidData)
+	}
+	return nil
+}
+
+// NewProviderRegisterTx creates a transaction registering the sender as a
+// compute provider.
+func NewProviderRegisterTx(from string, reg ProviderRegistration, nonce uint64, gasPrice *big.Int) *Tx {
+	data, _ := json.Marshal(reg)
+	return &Tx{
+		Type:     TxProviderRegister,
+		From:     from,
+		Gas:      GasProviderOp,
+		GasPrice: gasPrice,
+		Nonce:    nonce,
+		Data:     data,
+	}
+}
+
+// NewProviderDeregisterTx creates a transaction deregistering the sender as
+// a compute provider, which starts the unbonding of its bond.
+func NewProviderDeregisterTx(from string, nonce uint64, gasPrice *big.Int) *Tx {
+	return &Tx{
+		Type:     TxProviderDeregister,
+		From:     from,
+		Gas:      GasProviderOp,
+		GasPrice: gasPrice,
+		Nonce:    nonce,
+	}
+}
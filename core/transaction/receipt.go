@@ -0,0 +1,71 @@
+package transaction
+
+import (
+	"math/big"
+
+	"github.com/zionlayer/zionlayer/core/rlp"
+)
+
+// ReceiptStatus reports whether a transaction executed successfully.
+type ReceiptStatus uint8
+
+const (
+	ReceiptFailed  ReceiptStatus = 0
+	ReceiptSuccess ReceiptStatus = 1
+)
+
+// Receipt records the outcome of executing a transaction.
+type Receipt struct {
+	TxHash      [32]byte      `json:"txHash"`
+	Status      ReceiptStatus `json:"status"`
+	GasUsed     uint64        `json:"gasUsed"`            // net of refund
+	GasRefunded uint64        `json:"gasRefunded"`        // capped refund applied at commit
+	Fee         *big.Int      `json:"fee"`                // GasUsed * GasPrice charged to the sender
+	FeeBurned   *big.Int      `json:"feeBurned"`          // portion of Fee removed from supply
+	FeePayer    string        `json:"feePayer"`           // sender, or paymaster if sponsored
+	Contract    string        `json:"contract,omitempty"` // address created by TxDeployContract
+	Logs        []*Log        `json:"logs,omitempty"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// Log is an event emitted by contract code during execution.
+type Log struct {
+	Address string   `json:"address"`
+	Topics  [][]byte `json:"topics"`
+	Data    []byte   `json:"data"`
+}
+
+// MarshalBinary returns the canonical RLP encoding of the receipt, which
+// the ReceiptRoot of its block commits to. Error is left out: Status alone
+// records the failure, so that nodes need not agree on error texts.
+func (r *Receipt) MarshalBinary() ([]byte, error) {
+	return rlp.AppendList(nil, func(dst []byte) []byte {
+		dst = rlp.AppendBytes(dst, r.TxHash[:])
+		dst = rlp.AppendUint(dst, uint64(r.Status))
+		dst = rlp.AppendUint(dst, r.GasUsed)
+		dst = rlp.AppendUint(dst, r.GasRefunded)
+		dst = rlp.AppendBigInt(dst, r.Fee)
+		dst = rlp.AppendBigInt(dst, r.FeeBurned)
+		dst = rlp.AppendString(dst, r.FeePayer)
+		dst = rlp.AppendString(dst, r.Contract)
+		return rlp.AppendList(dst, func(dst []byte) []byte {
+			for _, l := range r.Logs {
+				dst = l.appendRLP(dst)
+			}
+			return dst
+		})
+	}), nil
+}
+
+func (l *Log) appendRLP(dst []byte) []byte {
+	return rlp.AppendList(dst, func(dst []byte) []byte {
+		dst = rlp.AppendString(dst, l.Address)
+		dst = rlp.AppendList(dst, func(dst []byte) []byte {
+			for _, t := range l.Topics {
+				dst = rlp.AppendBytes(dst, t)
+			}
+			return dst
+		})
+		return rlp.AppendBytes(dst, l.Data)
+	})
+}
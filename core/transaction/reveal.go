@@ -0,0 +1,80 @@
+package transaction
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/zionlayer/zionlayer/core/rlp"
+)
+
+// Gas of revealing the data of an inference receipt: a base cost plus a
+// cost per byte revealed, which is kept in state.
+const (
+	GasRevealBase    = 40000
+	GasRevealPerByte = 16
+)
+
+// InferenceReveal is the Data of a TxInferenceReveal transaction: the data
+// behind the hashes of an inference receipt that committed to it. Each of
+// the input and output is revealed either inline or as the content
+// identifier of its off-chain copy, encrypted under Key if Key is set.
+// Inline data must also hash to the receipt's InputHash or OutputHash.
+type InferenceReveal struct {
+	ReceiptHash []byte `json:"receiptHash"` // of the transaction including the receipt
+	Salt        []byte `json:"salt,omitempty"`
+	Input       []byte `json:"input,omitempty"`
+	Output      []byte `json:"output,omitempty"`
+	InputCID    string `json:"inputCid,omitempty"`
+	OutputCID   string `json:"outputCid,omitempty"`
+	Key         []byte `json:"key,omitempty"` // decrypts the content of the CIDs
+}
+
+// Commitment returns the commitment to v that a receipt carries as its
+// Commitment: the SHA-256 hash of the RLP list of Salt, Input, Output,
+// InputCID, OutputCID and Key.
+func (v *InferenceReveal) Commitment() [32]byte {
+	return sha256.Sum256(rlp.EncodeList(
+		rlp.EncodeBytes(v.Salt),
+		rlp.EncodeBytes(v.Input),
+		rlp.EncodeBytes(v.Output),
+		rlp.EncodeString(v.InputCID),
+		rlp.EncodeString(v.OutputCID),
+		rlp.EncodeBytes(v.Key),
+	))
+}
+
+// RevealGas returns the gas of executing reveal v.
+func RevealGas(v *InferenceReveal) uint64 {
+	n := len(v.Salt) + len(v.Input) + len(v.Output) + len(v.InputCID) + len(v.OutputCID) + len(v.Key)
+	return GasRevealBase + GasRevealPerByte*uint64(n)
+}
+
+func checkReveal(data []byte) (*InferenceReveal, error) {
+	var v InferenceReveal
+	if err := decodeData(data, &v); err != nil {
+		return nil, err
+	}
+	if len(v.ReceiptHash) != 32 {
+		return nil, fmt.Errorf("%w: receipt hash must be 32 bytes", ErrInvalidData)
+	}
+	if (len(v.Input) == 0) == (v.InputCID == "") || (len(v.Output) == 0) == (v.OutputCID == "") {
+		return nil, fmt.Errorf("%w: input and output must each be revealed inline or by CID", ErrInvalidData)
+	}
+	return &v, nil
+}
+
+// NewInferenceRevealTx creates a transaction revealing the data committed
+// to by an inference receipt.
+func NewInferenceRevealTx(from string, v InferenceReveal, nonce uint64, gasPrice *big.Int) *Tx {
+	data, _ := json.Marshal(v)
+	return &Tx{
+		Type:     TxInferenceReveal,
+		From:     from,
+		Gas:      RevealGas(&v),
+		GasPrice: gasPrice,
+		Nonce:    nonce,
+		Data:     data,
+	}
+}
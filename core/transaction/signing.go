@@ -0,0 +1,103 @@
+package transaction
+
+import (
+	"errors"
+
+	"github.com/zionlayer/zionlayer/core/common"
+	"github.com/zionlayer/zionlayer/core/crypto"
+	"github.com/zionlayer/zionlayer/core/rlp"
+)
+
+// DevnetChainID is the chain ID of the local development network.
+const DevnetChainID uint64 = 1
+
+var (
+	ErrMissingSignature = errors.New("transaction is not signed")
+	ErrInvalidSender    = errors.New("signer does not match sender")
+	ErrWrongChain       = errors.New("transaction signed for a different chain")
+	ErrTxModified       = errors.New("transaction modified after it was hashed")
+)
+
+// SigningHash returns the digest that is signed by the sender. The preimage
+// includes ChainID, so a signature is only valid on a single network. It is
+// the Hash of the current fields of tx, never the cached one.
+func (tx *Tx) SigningHash() [32]byte {
+	return tx.hashFields()
+}
+
+// Sign binds tx to chainID and sets its public key and signature using priv.
+// From is set to the address derived from the key.
+func (tx *Tx) Sign(priv crypto.PrivateKey, chainID uint64) error {
+	pub, ok := priv.Public().(crypto.PublicKey)
+	if !ok || len(priv) != crypto.PrivateKeySize {
+		return crypto.ErrInvalidPrivateKey
+	}
+	tx.resetHash()
+	tx.ChainID = chainID
+	tx.PublicKey = append([]byte(nil), pub...)
+	tx.From = crypto.PubkeyToAddress(pub).String()
+	sig, err := crypto.Sign(priv, tx.SigningHash())
+	if err != nil {
+		return err
+	}
+	tx.Signature = sig
+	return nil
+}
+
+// Sender verifies the signature of tx and returns the address of the signer,
+// or of the multisig account for EnvelopeMultisig. It fails if tx was signed
+// for a chain other than chainID or if the signer does not match tx.From.
+func (tx *Tx) Sender(chainID uint64) (common.Address, error) {
+	if tx.ChainID != chainID {
+		return common.Address{}, ErrWrongChain
+	}
+	if tx.envelopeType() == EnvelopeMultisig {
+		return tx.multisigSender()
+	}
+	if len(tx.Signature) == 0 || len(tx.PublicKey) == 0 {
+		return common.Address{}, ErrMissingSignature
+	}
+	h, err := tx.verifyingHash()
+	if err != nil {
+		return common.Address{}, err
+	}
+	if err := crypto.Verify(tx.PublicKey, h, tx.Signature); err != nil {
+		return common.Address{}, err
+	}
+	addr := crypto.PubkeyToAddress(tx.PublicKey)
+	if addr.String() != tx.From {
+		return common.Address{}, ErrInvalidSender
+	}
+	return addr, nil
+}
+
+// EncodeSignatures packs several signatures over SigningHash into a single
+// Tx.Signature. Used by smart accounts, whose validation code checks the
+// signatures instead of a single PublicKey.
+func EncodeSignatures(sigs [][]byte) []byte {
+	items := make([][]byte, len(sigs))
+	for i, sig := range sigs {
+		items[i] = rlp.EncodeBytes(sig)
+	}
+	return rlp.EncodeList(items...)
+}
+
+// DecodeSignatures unpacks a signature list produced by EncodeSignatures.
+func DecodeSignatures(b []byte) ([][]byte, error) {
+	content, rest, err := rlp.SplitList(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, rlp.ErrTrailingData
+	}
+	var sigs [][]byte
+	for len(content) > 0 {
+		var sig []byte
+		if sig, content, err = rlp.SplitBytes(content); err != nil {
+			return nil, err
+		}
+		sigs = append(sigs, cloneBytes(sig))
+	}
+	return sigs, nil
+}
@@ -0,0 +1,50 @@
+package transaction
+
+import (
+	"github.com/zionlayer/zionlayer/core/crypto"
+)
+
+// Signatures returns the key signatures carried by tx: the sender's, those
+// of the multisig keys that signed, and the paymaster's. The signatures of
+// smart accounts, checked by contract code, are not included, nor are any
+// of a transaction modified after it was hashed.
+func (tx *Tx) Signatures() []crypto.SignedDigest {
+	h, err := tx.verifyingHash()
+	if err != nil {
+		return nil
+	}
+	var sigs []crypto.SignedDigest
+	switch {
+	case tx.envelopeType() == EnvelopeMultisig:
+		if tx.Multisig == nil {
+			break
+		}
+		list, err := DecodeSignatures(tx.Signature)
+		if err != nil || len(list) != len(tx.Multisig.PublicKeys) {
+			break
+		}
+		for i, sig := range list {
+			if len(sig) > 0 {
+				sigs = append(sigs, crypto.SignedDigest{PublicKey: tx.Multisig.PublicKeys[i], Hash: h, Signature: sig})
+			}
+		}
+	case len(tx.PublicKey) > 0 && len(tx.Signature) > 0:
+		sigs = append(sigs, crypto.SignedDigest{PublicKey: tx.PublicKey, Hash: h, Signature: tx.Signature})
+	}
+	if tx.IsSponsored() && len(tx.PaymasterKey) > 0 && len(tx.PaymasterSig) > 0 {
+		sigs = append(sigs, crypto.SignedDigest{PublicKey: tx.PaymasterKey, Hash: tx.SponsorHash(), Signature: tx.PaymasterSig})
+	}
+	return sigs
+}
+
+// Preverify checks the key signatures of txs in parallel, in batches, on
+// the default verifier pool. The valid ones are cached, so that the Sender
+// and FeePayer calls that follow do not check them again; the invalid ones
+// are left for those calls to report.
+func Preverify(txs ...*Tx) {
+	var sigs []crypto.SignedDigest
+	for _, tx := range txs {
+		sigs = append(sigs, tx.Signatures()...)
+	}
+	crypto.DefaultVerifierPool().Verify(sigs)
+}
@@ -0,0 +1,145 @@
+package transaction
+
+import (
+	"crypto/sha256"
+	"errors"
+
+	"github.com/zionlayer/zionlayer/core/common"
+	"github.com/zionlayer/zionlayer/core/crypto"
+	"github.com/zionlayer/zionlayer/core/rlp"
+)
+
+// EnvelopeSponsored is a transaction whose gas is paid by a paymaster. The
+// sender signs the base fields and the paymaster address; the paymaster
+// then signs the result, including the sender's signature.
+const EnvelopeSponsored EnvelopeType = 0x02
+
+var (
+	ErrNotSponsored           = errors.New("transaction is not sponsored")
+	ErrMissingPaymasterSig    = errors.New("sponsored transaction is missing paymaster signature")
+	ErrInvalidPaymasterSigner = errors.New("paymaster signer does not match paymaster")
+)
+
+func init() {
+	RegisterEnvelope(EnvelopeSponsored, EnvelopeCodec{Encode: encodeSponsored, Decode: decodeSponsored})
+}
+
+// sponsoredFields encodes a sponsored transaction. With neither signature
+// it is the sender's preimage, which omits the paymaster key; with only the
+// sender signature it is the paymaster's preimage.
+func sponsoredFields(tx *Tx, userSig, paymasterSig bool) []byte {
+	fields := append(baseFields(tx, userSig), rlp.EncodeString(tx.Paymaster))
+	if userSig {
+		fields = append(fields, rlp.EncodeBytes(tx.PaymasterKey))
+	}
+	if paymasterSig {
+		fields = append(fields, rlp.EncodeBytes(tx.PaymasterSig))
+	}
+	return rlp.EncodeList(fields...)
+}
+
+func encodeSponsored(tx *Tx, withSig bool) []byte {
+	return sponsoredFields(tx, withSig, withSig)
+}
+
+func decodeSponsored(payload []byte) (*Tx, error) {
+	b, rest, err := rlp.SplitList(payload)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, rlp.ErrTrailingData
+	}
+	dec, b, err := splitBaseFields(b)
+	if err != nil {
+		return nil, err
+	}
+	var buf []byte
+	if buf, b, err = rlp.SplitBytes(b); err != nil {
+		return nil, err
+	}
+	dec.Paymaster = string(buf)
+	if buf, b, err = rlp.SplitBytes(b); err != nil {
+		return nil, err
+	}
+	dec.PaymasterKey = cloneBytes(buf)
+	if buf, b, err = rlp.SplitBytes(b); err != nil {
+		return nil, err
+	}
+	dec.PaymasterSig = cloneBytes(buf)
+	if len(b) > 0 {
+		return nil, rlp.ErrTrailingData
+	}
+	return dec, nil
+}
+
+// IsSponsored reports whether the gas of tx is paid by a paymaster.
+func (tx *Tx) IsSponsored() bool {
+	return tx.envelopeType() == EnvelopeSponsored
+}
+
+// Sponsor marks tx as sponsored by paymaster. It must be called before the
+// sender signs, since the sender's signature commits to the paymaster.
+func (tx *Tx) Sponsor(paymaster common.Address) {
+	tx.Envelope = EnvelopeSponsored
+	tx.Paymaster = paymaster.String()
+	tx.resetHash()
+}
+
+// SponsorHash returns the digest signed by the paymaster. It covers the
+// sender's signature, so the paymaster approves an exact signed payload.
+func (tx *Tx) SponsorHash() [32]byte {
+	return sha256.Sum256(append([]byte{byte(EnvelopeSponsored)}, sponsoredFields(tx, true, false)...))
+}
+
+// SignAsPaymaster sets the paymaster key and signature of a sponsored tx.
+// The sender must already have signed.
+func (tx *Tx) SignAsPaymaster(priv crypto.PrivateKey) error {
+	if !tx.IsSponsored() {
+		return ErrNotSponsored
+	}
+	pub, ok := priv.Public().(crypto.PublicKey)
+	if !ok || len(priv) != crypto.PrivateKeySize {
+		return crypto.ErrInvalidPrivateKey
+	}
+	if crypto.PubkeyToAddress(pub).String() != tx.Paymaster {
+		return ErrInvalidPaymasterSigner
+	}
+	tx.PaymasterKey = append([]byte(nil), pub...)
+	sig, err := crypto.Sign(priv, tx.SponsorHash())
+	if err != nil {
+		return err
+	}
+	tx.PaymasterSig = sig
+	return nil
+}
+
+// FeePayer returns the account that pays for gas: the verified paymaster
+// of a sponsored transaction, or the verified sender otherwise.
+func (tx *Tx) FeePayer(chainID uint64) (common.Address, error) {
+	sender, err := tx.Sender(chainID)
+	if err != nil || !tx.IsSponsored() {
+		return sender, err
+	}
+	return tx.PaymasterAddress()
+}
+
+// PaymasterAddress verifies the paymaster signature of a sponsored
+// transaction and returns the paymaster address. It does not verify the
+// sender.
+func (tx *Tx) PaymasterAddress() (common.Address, error) {
+	if !tx.IsSponsored() {
+		return common.Address{}, ErrNotSponsored
+	}
+	if len(tx.PaymasterSig) == 0 || len(tx.PaymasterKey) == 0 {
+		return common.Address{}, ErrMissingPaymasterSig
+	}
+	if err := crypto.Verify(tx.PaymasterKey, tx.SponsorHash(), tx.PaymasterSig); err != nil {
+		return common.Address{}, err
+	}
+	addr := crypto.PubkeyToAddress(tx.PaymasterKey)
+	if addr.String() != tx.Paymaster {
+		return common.Address{}, ErrInvalidPaymasterSigner
+	}
+	return addr, nil
+}
@@ -0,0 +1,49 @@
+package transaction
+
+import (
+	"encoding/json"
+	"math/big"
+)
+
+// MaxCommission is the highest validator commission, in basis points.
+const MaxCommission = 10_000
+
+// StakeDelegation is the Data of TxStakeDelegate and TxStakeUndelegate
+// transactions, which delegate the transaction Value to Validator or
+// withdraw it from there.
+type StakeDelegation struct {
+	Validator string `json:"validator"`
+}
+
+func checkStakeDelegation(data []byte) error {
+	var d StakeDelegation
+	if err := decodeData(data, &d); err != nil {
+		return err
+	}
+	return checkCanonical(d.Validator)
+}
+
+// NewStakeDelegateTx creates a transaction delegating value of the
+// sender's balance to validator.
+func NewStakeDelegateTx(from, validator string, value *big.Int, nonce uint64, gasPrice *big.Int) *Tx {
+	return newStakeTx(TxStakeDelegate, from, validator, value, nonce, gasPrice)
+}
+
+// NewStakeUndelegateTx creates a transaction withdrawing value of the
+// sender's delegation to validator, which starts its unbonding.
+func NewStakeUndelegateTx(from, validator string, value *big.Int, nonce uint64, gasPrice *big.Int) *Tx {
+	return newStakeTx(TxStakeUndelegate, from, validator, value, nonce, gasPrice)
+}
+
+func newStakeTx(typ TxType, from, validator string, value *big.Int, nonce uint64, gasPrice *big.Int) *Tx {
+	data, _ := json.Marshal(StakeDelegation{Validator: validator})
+	return &Tx{
+		Type:     typ,
+		From:     from,
+		Value:    value,
+		Gas:      GasValidatorOp,
+		GasPrice: gasPrice,
+		Nonce:    nonce,
+		Data:     data,
+	}
+}
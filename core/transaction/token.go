@@ -0,0 +1,188 @@
+package transaction
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// GasTokenOp is the intrinsic gas of the ZRC-20 token transactions.
+const GasTokenOp = 40000
+
+// Limits of ZRC-20 token metadata.
+const (
+	MaxTokenSymbol   = 12
+	MaxTokenName     = 64
+	MaxTokenDecimals = 18
+)
+
+// TokenCreate is the Data of a TxTokenCreate transaction, which registers
+// a ZRC-20 token under Symbol with the sender as its admin and mints
+// InitialSupply to the sender. MaxSupply caps the supply if set.
+type TokenCreate struct {
+	Symbol        string   `json:"symbol"`
+	Name          string   `json:"name"`
+	Decimals      uint8    `json:"decimals"`
+	InitialSupply *big.Int `json:"initialSupply,omitempty"`
+	MaxSupply     *big.Int `json:"maxSupply,omitempty"`
+}
+
+// TokenMint is the Data of a TxTokenMint transaction, which mints Amount
+// of the token Symbol to To. Only the admin of the token mints.
+type TokenMint struct {
+	Symbol string   `json:"symbol"`
+	To     string   `json:"to"`
+	Amount *big.Int `json:"amount"`
+}
+
+// TokenBurn is the Data of a TxTokenBurn transaction, which burns Amount
+// of the token Symbol held by the sender.
+type TokenBurn struct {
+	Symbol string   `json:"symbol"`
+	Amount *big.Int `json:"amount"`
+}
+
+// TokenTransfer is the Data of a TxTokenTransfer transaction, which moves
+// Amount of the token Symbol from the sender to To.
+type TokenTransfer struct {
+	Symbol string   `json:"symbol"`
+	To     string   `json:"to"`
+	Amount *big.Int `json:"amount"`
+}
+
+// TokenApprove is the Data of a TxTokenApprove transaction, which allows
+// Spender to transfer up to Amount of the token Symbol of the sender,
+// replacing the previous allowance.
+type TokenApprove struct {
+	Symbol  string   `json:"symbol"`
+	Spender string   `json:"spender"`
+	Amount  *big.Int `json:"amount"`
+}
+
+// TokenTransferFrom is the Data of a TxTokenTransferFrom transaction, which
+// moves Amount of the token Symbol from From to To, spending the allowance
+// From gave the sender.
+type TokenTransferFrom struct {
+	Symbol string   `json:"symbol"`
+	From   string   `json:"from"`
+	To     string   `json:"to"`
+	Amount *big.Int `json:"amount"`
+}
+
+// TokenSetAdmin is the Data of a TxTokenSetAdmin transaction, which hands
+// the admin of the token Symbol over to Admin, or renounces it, fixing
+// the supply, if Admin is empty.
+type TokenSetAdmin struct {
+	Symbol string `json:"symbol"`
+	Admin  string `json:"admin,omitempty"`
+}
+
+// CheckTokenSymbol checks that symbol is a valid ZRC-20 token symbol:
+// 2 to MaxTokenSymbol upper case letters and digits, starting with a
+// letter, other than ZIO.
+func CheckTokenSymbol(symbol string) error {
+	if len(symbol) < 2 || len(symbol) > MaxTokenSymbol || symbol == "ZIO" {
+		return fmt.Errorf("%w: invalid token symbol %q", ErrInvalidData, symbol)
+	}
+	for i, c := range symbol {
+		if !(c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+			return fmt.Errorf("%w: invalid token symbol %q", ErrInvalidData, symbol)
+		}
+	}
+	return nil
+}
+
+func checkTokenAmount(amount *big.Int, zeroOK bool) error {
+	if amount == nil || amount.Sign() < 0 || amount.Sign() == 0 && !zeroOK {
+		return fmt.Errorf("%w: invalid token amount", ErrInvalidData)
+	}
+	return nil
+}
+
+func checkToken(typ TxType, data []byte) error {
+	switch typ {
+	case TxTokenCreate:
+		var c TokenCreate
+		if err := decodeData(data, &c); err != nil {
+			return err
+		}
+		if c.Name == "" || len(c.Name) > MaxTokenName || c.Decimals > MaxTokenDecimals {
+			return fmt.Errorf("%w: token name must be 1 to %d bytes and decimals at most %d", ErrInvalidData, MaxTokenName, MaxTokenDecimals)
+		}
+		if c.InitialSupply != nil && c.InitialSupply.Sign() < 0 || c.MaxSupply != nil && c.MaxSupply.Sign() <= 0 {
+			return fmt.Errorf("%w: invalid token supply", ErrInvalidData)
+		}
+		if c.InitialSupply != nil && c.MaxSupply != nil && c.InitialSupply.Cmp(c.MaxSupply) > 0 {
+			return fmt.Errorf("%w: initial supply exceeds the max supply", ErrInvalidData)
+		}
+		return CheckTokenSymbol(c.Symbol)
+	case TxTokenMint:
+		var m TokenMint
+		if err := decodeData(data, &m); err != nil {
+			return err
+		}
+		if err := checkTokenAmount(m.Amount, false); err != nil {
+			return err
+		}
+		return checkCanonical(m.To)
+	case TxTokenBurn:
+		var b TokenBurn
+		if err := decodeData(data, &b); err != nil {
+			return err
+		}
+		return checkTokenAmount(b.Amount, false)
+	case TxTokenTransfer:
+		var t TokenTransfer
+		if err := decodeData(data, &t); err != nil {
+			return err
+		}
+		if err := checkTokenAmount(t.Amount, false); err != nil {
+			return err
+		}
+		return checkCanonical(t.To)
+	case TxTokenApprove:
+		var a TokenApprove
+		if err := decodeData(data, &a); err != nil {
+			return err
+		}
+		if err := checkTokenAmount(a.Amount, true); err != nil {
+			return err
+		}
+		return checkCanonical(a.Spender)
+	case TxTokenTransferFrom:
+		var t TokenTransferFrom
+		if err := decodeData(data, &t); err != nil {
+			return err
+		}
+		if err := checkTokenAmount(t.Amount, false); err != nil {
+			return err
+		}
+		if err := checkCanonical(t.From); err != nil {
+			return err
+		}
+		return checkCanonical(t.To)
+	case TxTokenSetAdmin:
+		var a TokenSetAdmin
+		if err := decodeData(data, &a); err != nil {
+			return err
+		}
+		if a.Admin != "" {
+			return checkCanonical(a.Admin)
+		}
+	}
+	return nil
+}
+
+// NewTokenTx creates a ZRC-20 token transaction of type typ carrying
+// payload, one of the token Data types.
+func NewTokenTx(typ TxType, from string, payload interface{}, nonce uint64, gasPrice *big.Int) *Tx {
+	data, _ := json.Marshal(payload)
+	return &Tx{
+		Type:     typ,
+		From:     from,
+		Gas:      GasTokenOp,
+		GasPrice: gasPrice,
+		Nonce:    nonce,
+		Data:     data,
+	}
+}
@@ -3,7 +3,26 @@ package transaction
 import (
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"math/big"
+	"sync/atomic"
+
+	"github.com/zionlayer/zionlayer/core/attestation"
+	"github.com/zionlayer/zionlayer/core/common"
+	"github.com/zionlayer/zionlayer/core/rlp"
+)
+
+// MaxTxSize is the maximum size of an encoded transaction in bytes.
+const MaxTxSize = 128 * 1024
+
+var (
+	// ErrNonCanonicalAddress is returned when a transaction address is not in
+	// checksummed form.
+	ErrNonCanonicalAddress = errors.New("address is not in canonical checksummed form")
+	// ErrTxTooLarge is returned when an encoded transaction exceeds MaxTxSize.
+	ErrTxTooLarge = errors.New("transaction exceeds maximum size")
+	// ErrInvalidBatch is returned for an empty, oversized or malformed batch.
+	ErrInvalidBatch = errors.New("invalid batch transfer")
 )
 
 // TxType classifies the transaction.
@@ -19,6 +38,52 @@ const (
 	TxInferenceReceipt                // submit verifiable inference proof
 	TxValidatorStake                  // stake tokens as validator
 	TxValidatorUnstake                // unstake tokens
+	TxBatchTransfer                   // atomic transfer to multiple recipients
+	TxValidatorUnjail                 // return a jailed validator to the active set
+	TxAgentDeactivate                 // suspend an agent DID
+	TxAgentReactivate                 // restore a deactivated agent DID
+	TxOfferPost                       // post a service offer to the marketplace
+	TxOfferUpdate                     // change the terms of a service offer
+	TxOfferWithdraw                   // withdraw a service offer
+	TxDisputeOpen                     // challenge an inference receipt
+	TxDisputeVote                     // arbitrate a dispute as designated verifier
+	TxProviderRegister                // register the sender as a compute provider
+	TxProviderDeregister              // deregister a compute provider and unbond
+	TxModelRegister                   // register a model in the model registry
+	TxModelUpdate                     // update the metadata of a registered model
+	TxModelDeprecate                  // deprecate a registered model
+	TxInferenceReveal                 // reveal the data committed to by a receipt
+	TxEvaluatorRegister               // join the quality oracle committee
+	TxEvaluatorDeregister             // leave the quality oracle committee and unbond
+	TxQualityScore                    // score the output of an inference receipt
+	TxInferenceBatch                  // submit a Merkle-committed batch of receipts
+	TxDataAttest                      // vouch for the retention of off-chain data
+	TxStakeDelegate                   // delegate stake to a validator
+	TxStakeUndelegate                 // withdraw delegated stake and unbond
+	TxGovSubmitProposal               // submit a governance proposal
+	TxGovDeposit                      // add to the deposit of a proposal
+	TxGovVote                         // vote on a proposal
+	TxIBCCreateClient                 // create a client of a counterparty chain
+	TxIBCUpdateClient                 // add a counterparty header to a client
+	TxIBCConnectionOpen               // take a step of a connection handshake
+	TxIBCChannelOpen                  // take a step of a channel handshake
+	TxIBCTransfer                     // send tokens over an IBC channel
+	TxIBCSendMessage                  // send an agent message over an IBC channel
+	TxIBCRecvPacket                   // relay a packet from a counterparty
+	TxIBCAcknowledgePacket            // relay the acknowledgement of a sent packet
+	TxBridgeAttest                    // attest a deposit on the bridged chain as committee member
+	TxBridgeWithdraw                  // lock or burn tokens to withdraw to the bridged chain
+	TxTokenCreate                     // create a ZRC-20 token
+	TxTokenMint                       // mint a ZRC-20 token as its admin
+	TxTokenBurn                       // burn ZRC-20 tokens of the sender
+	TxTokenTransfer                   // transfer ZRC-20 tokens
+	TxTokenApprove                    // allow a spender to transfer ZRC-20 tokens of the sender
+	TxTokenTransferFrom               // transfer ZRC-20 tokens under an allowance
+	TxTokenSetAdmin                   // hand over or renounce the admin of a ZRC-20 token
+	TxNFTCreateCollection             // create a ZRC-721 collection
+	TxNFTMint                         // mint a ZRC-721 token as the collection creator
+	TxNFTTransfer                     // transfer a ZRC-721 token
+	TxNFTBurn                         // burn a ZRC-721 token
 )
 
 // Capability represents a named agent capability.
@@ -53,6 +118,68 @@ type AgentMessage struct {
 	Type    MessageType `json:"type"`
 	Payload []byte      `json:"payload"`
 	Nonce   uint64      `json:"nonce"`
+	// PayloadRef optionally references a payload too large for the chain,
+	// kept on a data availability network.
+	PayloadRef *DataRef `json:"payloadRef,omitempty"`
+}
+
+// AgentStatusChange is the Data of TxAgentDeactivate and TxAgentReactivate
+// transactions, which only the agent's controller may send.
+type AgentStatusChange struct {
+	ID     string `json:"id"` // agent DID
+	Reason string `json:"reason,omitempty"`
+}
+
+// DeployPayload is the Data of a TxDeployContract transaction.
+type DeployPayload struct {
+	Code []byte `json:"code"`
+}
+
+// ValidatorDescription is the Data of a TxValidatorStake transaction that
+// creates a validator. Stake transactions that only add bond carry no Data.
+type ValidatorDescription struct {
+	Moniker string `json:"moniker"`
+	Website string `json:"website,omitempty"`
+	Details string `json:"details,omitempty"`
+	// Commission is the share of the block rewards the validator keeps
+	// before sharing the rest with its delegators, in basis points.
+	Commission uint64 `json:"commission,omitempty"`
+}
+
+// MaxBatchRecipients caps the number of recipients in a batch transfer.
+const MaxBatchRecipients = 256
+
+// BatchTransferEntry is a single recipient of a batch transfer.
+type BatchTransferEntry struct {
+	To    string   `json:"to"`
+	Value *big.Int `json:"value"`
+}
+
+// CheckBatch validates the recipients of a batch transfer.
+func CheckBatch(entries []BatchTransferEntry) error {
+	if len(entries) == 0 || len(entries) > MaxBatchRecipients {
+		return ErrInvalidBatch
+	}
+	for _, e := range entries {
+		if e.Value == nil || e.Value.Sign() < 0 {
+			return ErrInvalidBatch
+		}
+		if err := checkCanonical(e.To); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Gas for a batch transfer: a base cost plus a per-recipient cost.
+const (
+	GasBatchTransferBase      = 21000
+	GasBatchTransferRecipient = 9000
+)
+
+// BatchGas returns the gas charged for a batch transfer to n recipients.
+func BatchGas(n int) uint64 {
+	return GasBatchTransferBase + uint64(n)*GasBatchTransferRecipient
 }
 
 // InferenceReceipt is a verifiable proof of AI inference.
@@ -62,11 +189,28 @@ type InferenceReceipt struct {
 	InputHash  []byte `json:"inputHash"`
 	OutputHash []byte `json:"outputHash"`
 	Timestamp  int64  `json:"timestamp"`
-	ProverSig  []byte `json:"proverSig"`
+	Prover     string `json:"prover"`    // address of the registered compute provider
+	ProverSig  []byte `json:"proverSig"` // by the provider's key over SigningHash
+
+	// Attestation optionally proves that the inference ran in an approved
+	// TEE enclave; its ReportData must be SigningHash.
+	Attestation *attestation.Document `json:"attestation,omitempty"`
+	// ZKProof optionally proves the inference with a Groth16 proof against
+	// the verifying key registered for ModelHash; see ProofInputs.
+	ZKProof []byte `json:"zkProof,omitempty"`
+	// Commitment optionally commits the submitter to reveal the data behind
+	// the receipt within the reveal window; it is the Commitment of the
+	// InferenceReveal to come.
+	Commitment []byte `json:"commitment,omitempty"`
+	// DataRefs optionally reference the input and output kept on a data
+	// availability network.
+	DataRefs []DataRef `json:"dataRefs,omitempty"`
 }
 
 // Tx is a signed transaction on ZionLayer.
 type Tx struct {
+	Envelope  EnvelopeType    `json:"envelope,omitempty"`
+	ChainID   uint64          `json:"chainId"` // network the tx is valid on
 	Type      TxType          `json:"type"`
 	From      string          `json:"from"`    // sender address (hex)
 	To        string          `json:"to"`      // recipient address (hex)
@@ -75,15 +219,107 @@ type Tx struct {
 	GasPrice  *big.Int        `json:"gasPrice"`
 	Nonce     uint64          `json:"nonce"`
 	Data      json.RawMessage `json:"data"`    // type-specific payload
+	PublicKey []byte          `json:"pubKey"`  // signer's public key
 	Signature []byte          `json:"sig"`
+
+	// Set only for EnvelopeSponsored transactions.
+	Paymaster    string `json:"paymaster,omitempty"`
+	PaymasterKey []byte `json:"paymasterKey,omitempty"`
+	PaymasterSig []byte `json:"paymasterSig,omitempty"`
+
+	// Set only for EnvelopeMultisig transactions.
+	Multisig *MultisigKeys `json:"multisig,omitempty"`
+
+	hash atomic.Value // *txHash cached by Hash
 }
 
-// Hash returns the SHA-256 hash of the transaction (excluding signature).
+// txHash is the hash of the transaction at tx. Copies of a transaction
+// carry the cache of the original, which they do not use.
+type txHash struct {
+	tx  *Tx
+	sum [32]byte
+}
+
+// Hash returns the SHA-256 hash of the canonical enveloped encoding of the
+// transaction (excluding signature). The hash of a signed transaction is
+// computed once: a signed transaction must only be changed through its
+// methods, which drop the cached hash. Signatures are checked against the
+// hash of the current fields, and a transaction changed otherwise after it
+// was hashed fails them with ErrTxModified; see CheckHash.
 func (tx *Tx) Hash() [32]byte {
-	cp := *tx
-	cp.Signature = nil
-	data, _ := json.Marshal(cp)
-	return sha256.Sum256(data)
+	if c, ok := tx.hash.Load().(*txHash); ok && c.tx == tx {
+		return c.sum
+	}
+	sum := tx.hashFields()
+	if len(tx.Signature) > 0 {
+		tx.hash.Store(&txHash{tx: tx, sum: sum})
+	}
+	return sum
+}
+
+// CheckHash returns ErrTxModified if the fields of tx no longer match the
+// hash cached by Hash.
+func (tx *Tx) CheckHash() error {
+	_, err := tx.verifyingHash()
+	return err
+}
+
+// verifyingHash returns SigningHash, or ErrTxModified if it differs from the
+// hash cached by Hash, which may already identify tx in the pool or a block.
+func (tx *Tx) verifyingHash() ([32]byte, error) {
+	sum := tx.hashFields()
+	if c, ok := tx.hash.Load().(*txHash); ok && c.tx == tx && c.sum != sum {
+		return sum, ErrTxModified
+	}
+	return sum, nil
+}
+
+// hashFields computes Hash from the current fields of tx.
+func (tx *Tx) hashFields() [32]byte {
+	t := tx.envelopeType()
+	codec, ok := envelopes[t]
+	if !ok {
+		return sha256.Sum256(nil)
+	}
+	buf := rlp.GetBuffer()
+	*buf = append(append(*buf, byte(t)), codec.Encode(tx, false)...)
+	sum := sha256.Sum256(*buf)
+	rlp.PutBuffer(buf)
+	return sum
+}
+
+// resetHash drops the cached hash of tx.
+func (tx *Tx) resetHash() {
+	tx.hash.Store(&txHash{})
+}
+
+// CheckAddresses verifies that From, To if set, and the paymaster of a
+// sponsored transaction are valid addresses in canonical checksummed form,
+// so that state is always keyed consistently.
+func (tx *Tx) CheckAddresses() error {
+	if err := checkCanonical(tx.From); err != nil {
+		return err
+	}
+	if tx.To != "" {
+		if err := checkCanonical(tx.To); err != nil {
+			return err
+		}
+	}
+	if tx.IsSponsored() {
+		return checkCanonical(tx.Paymaster)
+	}
+	return nil
+}
+
+func checkCanonical(s string) error {
+	addr, err := common.ParseAddress(s)
+	if err != nil {
+		return err
+	}
+	if addr.String() != s {
+		return ErrNonCanonicalAddress
+	}
+	return nil
 }
 
 // NewTransferTx creates a basic token transfer transaction.
@@ -93,9 +329,39 @@ func NewTransferTx(from, to string, value *big.Int, nonce uint64, gasPrice *big.
 		From:     from,
 		To:       to,
 		Value:    value,
-		Gas:      21000,
+		Gas:      GasTransfer,
+		GasPrice: gasPrice,
+		Nonce:    nonce,
+	}
+}
+
+// NewBatchTransferTx creates a transfer to several recipients that is
+// executed atomically.
+func NewBatchTransferTx(from string, entries []BatchTransferEntry, nonce uint64, gasPrice *big.Int) *Tx {
+	data, _ := json.Marshal(entries)
+	return &Tx{
+		Type:     TxBatchTransfer,
+		From:     from,
+		Value:    new(big.Int),
+		Gas:      BatchGas(len(entries)),
+		GasPrice: gasPrice,
+		Nonce:    nonce,
+		Data:     data,
+	}
+}
+
+// NewDeployContractTx creates a transaction deploying AVM bytecode to a new
+// contract address derived from the sender and nonce.
+func NewDeployContractTx(from string, code []byte, nonce uint64, gasPrice *big.Int) *Tx {
+	data, _ := json.Marshal(DeployPayload{Code: code})
+	return &Tx{
+		Type:     TxDeployContract,
+		From:     from,
+		Value:    new(big.Int),
+		Gas:      GasDeployBase + GasDeployPerByte*uint64(len(code)),
 		GasPrice: gasPrice,
 		Nonce:    nonce,
+		Data:     data,
 	}
 }
 
@@ -105,7 +371,7 @@ func NewAgentRegisterTx(from string, did AgentDID, nonce uint64, gasPrice *big.I
 	return &Tx{
 		Type:     TxAgentRegister,
 		From:     from,
-		Gas:      200000,
+		Gas:      GasAgentRegister,
 		GasPrice: gasPrice,
 		Nonce:    nonce,
 		Data:     data,
@@ -118,20 +384,88 @@ func NewAgentMessageTx(from string, msg AgentMessage, nonce uint64, gasPrice *bi
 	return &Tx{
 		Type:     TxAgentMessage,
 		From:     from,
-		Gas:      50000,
+		Gas:      MessageGas(msg),
+		GasPrice: gasPrice,
+		Nonce:    nonce,
+		Data:     data,
+	}
+}
+
+// NewAgentDeactivateTx creates a transaction deactivating the agent id.
+func NewAgentDeactivateTx(from, id, reason string, nonce uint64, gasPrice *big.Int) *Tx {
+	return newAgentStatusTx(TxAgentDeactivate, from, id, reason, nonce, gasPrice)
+}
+
+// NewAgentReactivateTx creates a transaction reactivating the agent id.
+func NewAgentReactivateTx(from, id, reason string, nonce uint64, gasPrice *big.Int) *Tx {
+	return newAgentStatusTx(TxAgentReactivate, from, id, reason, nonce, gasPrice)
+}
+
+func newAgentStatusTx(typ TxType, from, id, reason string, nonce uint64, gasPrice *big.Int) *Tx {
+	data, _ := json.Marshal(AgentStatusChange{ID: id, Reason: reason})
+	return &Tx{
+		Type:     typ,
+		From:     from,
+		Gas:      GasAgentStatus,
 		GasPrice: gasPrice,
 		Nonce:    nonce,
 		Data:     data,
 	}
 }
 
+// NewValidatorStakeTx creates a transaction staking value as validator
+// bond of the sender.
+func NewValidatorStakeTx(from string, value *big.Int, nonce uint64, gasPrice *big.Int) *Tx {
+	return &Tx{
+		Type:     TxValidatorStake,
+		From:     from,
+		Value:    value,
+		Gas:      GasValidatorOp,
+		GasPrice: gasPrice,
+		Nonce:    nonce,
+	}
+}
+
+// NewValidatorCreateTx creates a transaction registering the sender as a
+// validator described by desc with value as its initial bond.
+func NewValidatorCreateTx(from string, value *big.Int, desc ValidatorDescription, nonce uint64, gasPrice *big.Int) *Tx {
+	tx := NewValidatorStakeTx(from, value, nonce, gasPrice)
+	tx.Data, _ = json.Marshal(desc)
+	return tx
+}
+
+// NewValidatorUnstakeTx creates a transaction withdrawing value from the
+// validator bond of the sender.
+func NewValidatorUnstakeTx(from string, value *big.Int, nonce uint64, gasPrice *big.Int) *Tx {
+	return &Tx{
+		Type:     TxValidatorUnstake,
+		From:     from,
+		Value:    value,
+		Gas:      GasValidatorOp,
+		GasPrice: gasPrice,
+		Nonce:    nonce,
+	}
+}
+
+// NewValidatorUnjailTx creates a transaction asking for the sender's jailed
+// validator to be returned to the active set.
+func NewValidatorUnjailTx(from string, nonce uint64, gasPrice *big.Int) *Tx {
+	return &Tx{
+		Type:     TxValidatorUnjail,
+		From:     from,
+		Gas:      GasValidatorOp,
+		GasPrice: gasPrice,
+		Nonce:    nonce,
+	}
+}
+
 // NewInferenceReceiptTx creates an inference receipt submission transaction.
 func NewInferenceReceiptTx(from string, receipt InferenceReceipt, nonce uint64, gasPrice *big.Int) *Tx {
 	data, _ := json.Marshal(receipt)
 	return &Tx{
 		Type:     TxInferenceReceipt,
 		From:     from,
-		Gas:      100000,
+		Gas:      ReceiptGas(&receipt),
 		GasPrice: gasPrice,
 		Nonce:    nonce,
 		Data:     data,
@@ -0,0 +1,48 @@
+package transaction
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/zionlayer/zionlayer/core/common"
+	"github.com/zionlayer/zionlayer/core/crypto"
+)
+
+func TestModifiedAfterHash(t *testing.T) {
+	priv, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.BytesToAddress([]byte{0xc0}).String()
+	tx := NewTransferTx("", to, big.NewInt(1), 0, big.NewInt(1))
+	if err := tx.Sign(priv, DevnetChainID); err != nil {
+		t.Fatal(err)
+	}
+	h := tx.Hash()
+	if _, err := tx.Sender(DevnetChainID); err != nil {
+		t.Fatal(err)
+	}
+
+	tx.Gas++
+	if tx.Hash() != h {
+		t.Fatal("hash of a signed transaction not cached")
+	}
+	if _, err := tx.Sender(DevnetChainID); !errors.Is(err, ErrTxModified) {
+		t.Errorf("Sender of a modified transaction = %v, want %v", err, ErrTxModified)
+	}
+	if sigs := tx.Signatures(); len(sigs) != 0 {
+		t.Errorf("modified transaction carries %d signatures to check", len(sigs))
+	}
+
+	// Re-signing through Sign drops the cached hash.
+	if err := tx.Sign(priv, DevnetChainID); err != nil {
+		t.Fatal(err)
+	}
+	if tx.Hash() == h {
+		t.Error("hash not updated after re-signing")
+	}
+	if _, err := tx.Sender(DevnetChainID); err != nil {
+		t.Errorf("Sender after re-signing = %v", err)
+	}
+}
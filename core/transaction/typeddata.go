@@ -0,0 +1,133 @@
+package transaction
+
+import (
+	"github.com/zionlayer/zionlayer/core/crypto"
+)
+
+// Typed data domain used for all ZionLayer payloads.
+const (
+	TypedDomainName    = "ZionLayer"
+	TypedDomainVersion = "1"
+)
+
+// Delegation grants a capability from one agent to another. It is the
+// Payload of a DELEGATE agent message between the same two agents.
+type Delegation struct {
+	From       string     `json:"from"` // delegating agent DID
+	To         string     `json:"to"`   // receiving agent DID
+	Capability Capability `json:"capability"`
+	Nonce      uint64     `json:"nonce"`
+	// ExpiresAt is the first block height at which the delegation no
+	// longer applies; 0 never expires.
+	ExpiresAt uint64 `json:"expiresAt,omitempty"`
+	// MaxDepth is how many further times the receiving agent may
+	// re-delegate the capability; 0 forbids re-delegation.
+	MaxDepth uint64 `json:"maxDepth"`
+}
+
+// CapabilityMessage is held by every agent for itself. Delegating it lets
+// the controller of the receiving agent send messages from the delegating
+// agent.
+const CapabilityMessage = "agent.message"
+
+// MaxDelegationDepth bounds Delegation.MaxDepth and so the length of a
+// delegation chain.
+const MaxDelegationDepth = 8
+
+// Revocation withdraws the delegation of Capability from the sender of a
+// REVOKE agent message to its recipient. It is the message Payload.
+type Revocation struct {
+	Capability string `json:"capability"` // capability name
+}
+
+var capabilityType = []crypto.TypedField{
+	{Name: "name", Type: "string"},
+	{Name: "version", Type: "string"},
+}
+
+func domain(chainID uint64) crypto.TypedDomain {
+	return crypto.TypedDomain{Name: TypedDomainName, Version: TypedDomainVersion, ChainID: chainID}
+}
+
+func capabilityValue(c Capability) map[string]interface{} {
+	return map[string]interface{}{"name": c.Name, "version": c.Version}
+}
+
+// TypedData returns the structured form of an agent registration for
+// wallet display and signing.
+func (did AgentDID) TypedData(chainID uint64) *crypto.TypedData {
+	caps := make([]interface{}, len(did.Capabilities))
+	for i, c := range did.Capabilities {
+		caps[i] = capabilityValue(c)
+	}
+	return &crypto.TypedData{
+		Types: map[string][]crypto.TypedField{
+			"AgentRegistration": {
+				{Name: "id", Type: "string"},
+				{Name: "controller", Type: "address"},
+				{Name: "capabilities", Type: "Capability[]"},
+				{Name: "publicKey", Type: "bytes"},
+			},
+			"Capability": capabilityType,
+		},
+		PrimaryType: "AgentRegistration",
+		Domain:      domain(chainID),
+		Message: map[string]interface{}{
+			"id":           did.ID,
+			"controller":   did.Controller,
+			"capabilities": caps,
+			"publicKey":    did.PublicKey,
+		},
+	}
+}
+
+// TypedData returns the structured form of a delegation.
+func (d Delegation) TypedData(chainID uint64) *crypto.TypedData {
+	return &crypto.TypedData{
+		Types: map[string][]crypto.TypedField{
+			"Delegation": {
+				{Name: "from", Type: "string"},
+				{Name: "to", Type: "string"},
+				{Name: "capability", Type: "Capability"},
+				{Name: "nonce", Type: "uint64"},
+				{Name: "expiresAt", Type: "uint64"},
+				{Name: "maxDepth", Type: "uint64"},
+			},
+			"Capability": capabilityType,
+		},
+		PrimaryType: "Delegation",
+		Domain:      domain(chainID),
+		Message: map[string]interface{}{
+			"from":       d.From,
+			"to":         d.To,
+			"capability": capabilityValue(d.Capability),
+			"nonce":      d.Nonce,
+			"expiresAt":  d.ExpiresAt,
+			"maxDepth":   d.MaxDepth,
+		},
+	}
+}
+
+// TypedData returns the structured form of an inference receipt.
+func (r InferenceReceipt) TypedData(chainID uint64) *crypto.TypedData {
+	return &crypto.TypedData{
+		Types: map[string][]crypto.TypedField{
+			"InferenceReceipt": {
+				{Name: "agentId", Type: "string"},
+				{Name: "modelHash", Type: "bytes"},
+				{Name: "inputHash", Type: "bytes"},
+				{Name: "outputHash", Type: "bytes"},
+				{Name: "timestamp", Type: "uint64"},
+			},
+		},
+		PrimaryType: "InferenceReceipt",
+		Domain:      domain(chainID),
+		Message: map[string]interface{}{
+			"agentId":    r.AgentID,
+			"modelHash":  r.ModelHash,
+			"inputHash":  r.InputHash,
+			"outputHash": r.OutputHash,
+			"timestamp":  uint64(r.Timestamp),
+		},
+	}
+}
@@ -0,0 +1,330 @@
+package transaction
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/zionlayer/zionlayer/core/attestation"
+	"github.com/zionlayer/zionlayer/core/zk"
+)
+
+// Intrinsic gas charged by each transaction type before any execution.
+const (
+	GasTransfer         = 21000
+	GasAgentRegister    = 200000
+	GasAgentMessage     = 50000
+	GasMessagePerByte   = 16 // per byte of AgentMessage.Payload
+	GasAgentStatus      = 30000
+	GasInferenceReceipt = 100000
+	GasDeployBase       = 32000
+	GasDeployPerByte    = 200
+	GasCallContract     = 21000
+	GasValidatorOp      = 21000
+)
+
+var (
+	ErrUnknownTxType    = errors.New("unknown transaction type")
+	ErrMissingSender    = errors.New("missing sender")
+	ErrMissingRecipient = errors.New("missing recipient")
+	ErrNegativeValue    = errors.New("negative value")
+	ErrNegativeGasPrice = errors.New("negative gas price")
+	ErrIntrinsicGas     = errors.New("gas below intrinsic minimum")
+	ErrInvalidData      = errors.New("malformed transaction data")
+)
+
+// ValidateBasic performs stateless checks on tx: size, address form, sign
+// of amounts, a well-formed Data payload for its type and enough gas for
+// its intrinsic cost. It does not check signatures, nonces or balances.
+func (tx *Tx) ValidateBasic() error {
+	if tx.Size() > MaxTxSize {
+		return ErrTxTooLarge
+	}
+	if tx.From == "" {
+		return ErrMissingSender
+	}
+	if err := tx.CheckAddresses(); err != nil {
+		return err
+	}
+	if tx.Value != nil && tx.Value.Sign() < 0 {
+		return ErrNegativeValue
+	}
+	if tx.GasPrice != nil && tx.GasPrice.Sign() < 0 {
+		return ErrNegativeGasPrice
+	}
+	if (tx.Type == TxTransfer || tx.Type == TxCallContract) && tx.To == "" {
+		return ErrMissingRecipient
+	}
+	gas, err := IntrinsicGas(tx)
+	if err != nil {
+		return err
+	}
+	if tx.Gas < gas {
+		return fmt.Errorf("%w: have %d, need %d", ErrIntrinsicGas, tx.Gas, gas)
+	}
+	return nil
+}
+
+// IntrinsicGas decodes the Data of tx according to its type and returns the
+// minimum gas it must provide.
+func IntrinsicGas(tx *Tx) (uint64, error) {
+	switch tx.Type {
+	case TxTransfer:
+		return GasTransfer, nil
+	case TxAgentRegister:
+		var did AgentDID
+		if err := decodeData(tx.Data, &did); err != nil {
+			return 0, err
+		}
+		if did.ID == "" {
+			return 0, fmt.Errorf("%w: empty DID", ErrInvalidData)
+		}
+		return GasAgentRegister, nil
+	case TxAgentMessage:
+		var msg AgentMessage
+		if err := decodeData(tx.Data, &msg); err != nil {
+			return 0, err
+		}
+		if msg.From == "" || msg.To == "" {
+			return 0, fmt.Errorf("%w: message endpoints required", ErrInvalidData)
+		}
+		if err := CheckDelegationPayload(msg); err != nil {
+			return 0, err
+		}
+		if msg.PayloadRef != nil {
+			if err := msg.PayloadRef.Check(); err != nil {
+				return 0, err
+			}
+		}
+		return MessageGas(msg), nil
+	case TxAgentDeactivate, TxAgentReactivate:
+		var change AgentStatusChange
+		if err := decodeData(tx.Data, &change); err != nil {
+			return 0, err
+		}
+		if change.ID == "" {
+			return 0, fmt.Errorf("%w: empty DID", ErrInvalidData)
+		}
+		return GasAgentStatus, nil
+	case TxOfferPost, TxOfferUpdate:
+		if err := checkOffer(tx.Data); err != nil {
+			return 0, err
+		}
+		return GasOfferOp, nil
+	case TxOfferWithdraw:
+		var ref OfferRef
+		if err := decodeData(tx.Data, &ref); err != nil {
+			return 0, err
+		}
+		if ref.AgentID == "" || ref.Capability == "" {
+			return 0, fmt.Errorf("%w: offer agent and capability required", ErrInvalidData)
+		}
+		return GasOfferOp, nil
+	case TxDisputeOpen:
+		if err := checkDisputeOpen(tx.Data); err != nil {
+			return 0, err
+		}
+		return GasDisputeOp, nil
+	case TxDisputeVote:
+		if err := checkDisputeVote(tx.Data); err != nil {
+			return 0, err
+		}
+		return GasDisputeOp, nil
+	case TxInferenceReceipt:
+		var receipt InferenceReceipt
+		if err := decodeData(tx.Data, &receipt); err != nil {
+			return 0, err
+		}
+		if receipt.AgentID == "" {
+			return 0, fmt.Errorf("%w: missing agent", ErrInvalidData)
+		}
+		if receipt.Prover == "" || len(receipt.ProverSig) == 0 {
+			return 0, fmt.Errorf("%w: missing prover signature", ErrInvalidData)
+		}
+		if a := receipt.Attestation; a != nil && (!attestation.KnownPlatform(a.Platform) || len(a.Measurement) == 0) {
+			return 0, fmt.Errorf("%w: malformed attestation", ErrInvalidData)
+		}
+		if len(receipt.ZKProof) > 0 && len(receipt.ZKProof) != zk.ProofSize {
+			return 0, fmt.Errorf("%w: malformed zk proof", ErrInvalidData)
+		}
+		if len(receipt.Commitment) > 0 && len(receipt.Commitment) != 32 {
+			return 0, fmt.Errorf("%w: commitment must be 32 bytes", ErrInvalidData)
+		}
+		for i := range receipt.DataRefs {
+			if err := receipt.DataRefs[i].Check(); err != nil {
+				return 0, err
+			}
+		}
+		return ReceiptGas(&receipt), nil
+	case TxInferenceBatch:
+		b, err := checkInferenceBatch(tx.Data)
+		if err != nil {
+			return 0, err
+		}
+		return ReceiptBatchGas(b.Count), nil
+	case TxProviderRegister:
+		if err := checkProviderRegistration(tx.Data); err != nil {
+			return 0, err
+		}
+		return GasProviderOp, nil
+	case TxProviderDeregister:
+		return GasProviderOp, nil
+	case TxModelRegister, TxModelUpdate:
+		if err := checkModel(tx.Data); err != nil {
+			return 0, err
+		}
+		return GasModelOp, nil
+	case TxModelDeprecate:
+		if err := checkModelRef(tx.Data); err != nil {
+			return 0, err
+		}
+		return GasModelOp, nil
+	case TxInferenceReveal:
+		v, err := checkReveal(tx.Data)
+		if err != nil {
+			return 0, err
+		}
+		return RevealGas(v), nil
+	case TxEvaluatorRegister:
+		if err := checkEvaluatorRegistration(tx.Data); err != nil {
+			return 0, err
+		}
+		return GasOracleOp, nil
+	case TxEvaluatorDeregister:
+		return GasOracleOp, nil
+	case TxQualityScore:
+		if err := checkQualityScore(tx.Data); err != nil {
+			return 0, err
+		}
+		return GasOracleOp, nil
+	case TxDataAttest:
+		if err := checkDataAttestation(tx.Data); err != nil {
+			return 0, err
+		}
+		return GasDataAttest, nil
+	case TxDeployContract:
+		var payload DeployPayload
+		if err := decodeData(tx.Data, &payload); err != nil {
+			return 0, err
+		}
+		return GasDeployBase + GasDeployPerByte*uint64(len(payload.Code)), nil
+	case TxCallContract:
+		return GasCallContract, nil
+	case TxValidatorStake:
+		if len(tx.Data) > 0 {
+			var desc ValidatorDescription
+			if err := decodeData(tx.Data, &desc); err != nil {
+				return 0, err
+			}
+			if desc.Moniker == "" {
+				return 0, fmt.Errorf("%w: empty moniker", ErrInvalidData)
+			}
+			if desc.Commission > MaxCommission {
+				return 0, fmt.Errorf("%w: commission exceeds %d", ErrInvalidData, MaxCommission)
+			}
+		}
+		return GasValidatorOp, nil
+	case TxValidatorUnstake, TxValidatorUnjail:
+		return GasValidatorOp, nil
+	case TxGovSubmitProposal:
+		if err := checkProposal(tx.Data); err != nil {
+			return 0, err
+		}
+		return GasGovOp, nil
+	case TxGovDeposit:
+		var d ProposalDeposit
+		if err := decodeData(tx.Data, &d); err != nil {
+			return 0, err
+		}
+		return GasGovOp, nil
+	case TxGovVote:
+		if err := checkProposalVote(tx.Data); err != nil {
+			return 0, err
+		}
+		return GasGovOp, nil
+	case TxIBCCreateClient, TxIBCUpdateClient, TxIBCConnectionOpen, TxIBCChannelOpen,
+		TxIBCTransfer, TxIBCSendMessage, TxIBCRecvPacket, TxIBCAcknowledgePacket:
+		if err := checkIBC(tx.Type, tx.Data); err != nil {
+			return 0, err
+		}
+		return IBCGas(tx), nil
+	case TxBridgeAttest, TxBridgeWithdraw:
+		if err := checkBridge(tx.Type, tx.Data); err != nil {
+			return 0, err
+		}
+		return GasBridgeOp, nil
+	case TxTokenCreate, TxTokenMint, TxTokenBurn, TxTokenTransfer, TxTokenApprove, TxTokenTransferFrom, TxTokenSetAdmin:
+		if err := checkToken(tx.Type, tx.Data); err != nil {
+			return 0, err
+		}
+		return GasTokenOp, nil
+	case TxNFTCreateCollection, TxNFTMint, TxNFTTransfer, TxNFTBurn:
+		if err := checkNFT(tx.Type, tx.Data); err != nil {
+			return 0, err
+		}
+		return GasNFTOp, nil
+	case TxStakeDelegate, TxStakeUndelegate:
+		if err := checkStakeDelegation(tx.Data); err != nil {
+			return 0, err
+		}
+		return GasValidatorOp, nil
+	case TxBatchTransfer:
+		var entries []BatchTransferEntry
+		if err := decodeData(tx.Data, &entries); err != nil {
+			return 0, err
+		}
+		if err := CheckBatch(entries); err != nil {
+			return 0, err
+		}
+		return BatchGas(len(entries)), nil
+	default:
+		return 0, ErrUnknownTxType
+	}
+}
+
+// MessageGas returns the gas charged for an agent message: a base cost plus
+// a cost per payload byte, so that large messages pay for the state they
+// occupy.
+func MessageGas(msg AgentMessage) uint64 {
+	return GasAgentMessage + GasMessagePerByte*uint64(len(msg.Payload))
+}
+
+// CheckDelegationPayload validates the Payload of DELEGATE and REVOKE
+// messages.
+func CheckDelegationPayload(msg AgentMessage) error {
+	switch msg.Type {
+	case MsgDelegate:
+		var d Delegation
+		if err := decodeData(msg.Payload, &d); err != nil {
+			return err
+		}
+		if d.From != msg.From || d.To != msg.To {
+			return fmt.Errorf("%w: delegation endpoints differ from message", ErrInvalidData)
+		}
+		if d.Capability.Name == "" {
+			return fmt.Errorf("%w: empty capability", ErrInvalidData)
+		}
+		if d.MaxDepth > MaxDelegationDepth {
+			return fmt.Errorf("%w: delegation depth above %d", ErrInvalidData, MaxDelegationDepth)
+		}
+	case MsgRevoke:
+		var r Revocation
+		if err := decodeData(msg.Payload, &r); err != nil {
+			return err
+		}
+		if r.Capability == "" {
+			return fmt.Errorf("%w: empty capability", ErrInvalidData)
+		}
+	}
+	return nil
+}
+
+func decodeData(data []byte, v interface{}) error {
+	if len(data) == 0 {
+		return fmt.Errorf("%w: empty", ErrInvalidData)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidData, err)
+	}
+	return nil
+}
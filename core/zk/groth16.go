@@ -0,0 +1,161 @@
+// Package zk verifies succinct zero-knowledge proofs of inference.
+//
+// Proofs are Groth16 proofs over the BN254 curve, the curve of the EVM's
+// pairing precompiles, so that circuits compiled for Ethereum verifiers
+// (circom/snarkjs, gnark) can be verified unchanged. Points use the EVM
+// encoding: a G1 point is 64 bytes (x, y) and a G2 point 128 bytes
+// (x.imag, x.real, y.imag, y.real), big-endian.
+package zk
+
+import (
+	"errors"
+	"math/big"
+
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+)
+
+const (
+	g1Size = 64
+	g2Size = 128
+
+	// ProofSize is the size of an encoded Groth16 proof: A, B and C.
+	ProofSize = 2*g1Size + g2Size
+
+	// Pairings is the number of pairings a Groth16 verification computes.
+	Pairings = 4
+)
+
+// Verification gas, priced after the EVM's BN254 precompiles.
+const (
+	GasVerifyBase   = 45000
+	GasPerPairing   = 34000
+	GasPerInput     = 6150 // one G1 scalar multiplication and addition
+	GasPerProofByte = 16
+)
+
+var (
+	ErrInvalidKey   = errors.New("zk: malformed verifying key")
+	ErrInvalidProof = errors.New("zk: malformed proof")
+	ErrInputCount   = errors.New("zk: public input count does not match the verifying key")
+	ErrInputRange   = errors.New("zk: public input not in the scalar field")
+	ErrVerification = errors.New("zk: proof verification failed")
+)
+
+// VerifyingKey is a Groth16 verifying key. IC holds one point per public
+// input plus the constant term first.
+type VerifyingKey struct {
+	Alpha *bn256.G1
+	Beta  *bn256.G2
+	Gamma *bn256.G2
+	Delta *bn256.G2
+	IC    []*bn256.G1
+}
+
+// Proof is a Groth16 proof.
+type Proof struct {
+	A *bn256.G1
+	B *bn256.G2
+	C *bn256.G1
+}
+
+// Inputs returns the number of public inputs vk takes.
+func (vk *VerifyingKey) Inputs() int { return len(vk.IC) - 1 }
+
+// ParseVerifyingKey decodes a verifying key encoded as Alpha, Beta, Gamma,
+// Delta and then the IC points.
+func ParseVerifyingKey(b []byte) (*VerifyingKey, error) {
+	if len(b) < g1Size+3*g2Size+g1Size || (len(b)-3*g2Size)%g1Size != 0 {
+		return nil, ErrInvalidKey
+	}
+	vk := &VerifyingKey{Alpha: new(bn256.G1), Beta: new(bn256.G2), Gamma: new(bn256.G2), Delta: new(bn256.G2)}
+	var err error
+	if b, err = vk.Alpha.Unmarshal(b); err != nil {
+		return nil, ErrInvalidKey
+	}
+	for _, p := range []*bn256.G2{vk.Beta, vk.Gamma, vk.Delta} {
+		if b, err = p.Unmarshal(b); err != nil {
+			return nil, ErrInvalidKey
+		}
+	}
+	for len(b) > 0 {
+		p := new(bn256.G1)
+		if b, err = p.Unmarshal(b); err != nil {
+			return nil, ErrInvalidKey
+		}
+		vk.IC = append(vk.IC, p)
+	}
+	return vk, nil
+}
+
+// Marshal encodes vk as ParseVerifyingKey decodes it.
+func (vk *VerifyingKey) Marshal() []byte {
+	out := append([]byte(nil), vk.Alpha.Marshal()...)
+	out = append(out, vk.Beta.Marshal()...)
+	out = append(out, vk.Gamma.Marshal()...)
+	out = append(out, vk.Delta.Marshal()...)
+	for _, p := range vk.IC {
+		out = append(out, p.Marshal()...)
+	}
+	return out
+}
+
+// ParseProof decodes a proof encoded as A, B and C.
+func ParseProof(b []byte) (*Proof, error) {
+	if len(b) != ProofSize {
+		return nil, ErrInvalidProof
+	}
+	p := &Proof{A: new(bn256.G1), B: new(bn256.G2), C: new(bn256.G1)}
+	var err error
+	if b, err = p.A.Unmarshal(b); err != nil {
+		return nil, ErrInvalidProof
+	}
+	if b, err = p.B.Unmarshal(b); err != nil {
+		return nil, ErrInvalidProof
+	}
+	if _, err = p.C.Unmarshal(b); err != nil {
+		return nil, ErrInvalidProof
+	}
+	return p, nil
+}
+
+// Marshal encodes p as ParseProof decodes it.
+func (p *Proof) Marshal() []byte {
+	out := append([]byte(nil), p.A.Marshal()...)
+	out = append(out, p.B.Marshal()...)
+	return append(out, p.C.Marshal()...)
+}
+
+// InputFromBytes maps b, typically a hash, to a public input: the
+// big-endian integer b reduced modulo the scalar field order.
+func InputFromBytes(b []byte) *big.Int {
+	return new(big.Int).Mod(new(big.Int).SetBytes(b), bn256.Order)
+}
+
+// VerifyGas returns the gas of verifying a proof of proofLen bytes with
+// inputs public inputs.
+func VerifyGas(proofLen, inputs int) uint64 {
+	return GasVerifyBase + GasPerPairing*Pairings + GasPerInput*uint64(inputs) + GasPerProofByte*uint64(proofLen)
+}
+
+// Verify checks proof against vk and the public inputs.
+func Verify(vk *VerifyingKey, proof *Proof, inputs []*big.Int) error {
+	if len(inputs) != vk.Inputs() {
+		return ErrInputCount
+	}
+	x := new(bn256.G1).Set(vk.IC[0])
+	for i, in := range inputs {
+		if in.Sign() < 0 || in.Cmp(bn256.Order) >= 0 {
+			return ErrInputRange
+		}
+		x.Add(x, new(bn256.G1).ScalarMult(vk.IC[i+1], in))
+	}
+	// e(A, B) = e(Alpha, Beta) · e(x, Gamma) · e(C, Delta)
+	ok := bn256.PairingCheck(
+		[]*bn256.G1{new(bn256.G1).Neg(proof.A), vk.Alpha, x, proof.C},
+		[]*bn256.G2{proof.B, vk.Beta, vk.Gamma, vk.Delta},
+	)
+	if !ok {
+		return ErrVerification
+	}
+	return nil
+}
@@ -0,0 +1,341 @@
+// Package indexer copies finalized blocks into a SQL database for explorer
+// queries the state cannot answer efficiently, such as the transactions of
+// an address or the messages of an agent over a range of blocks. It writes
+// through database/sql; the binary must link a driver, SQLite ("sqlite",
+// modernc.org/sqlite) or PostgreSQL ("postgres", github.com/lib/pq). The
+// tables are described in schema.go.
+package indexer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/zionlayer/zionlayer/core/block"
+	"github.com/zionlayer/zionlayer/core/chain"
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrSchemaVersion = errors.New("indexer: database written with another schema version")
+	ErrUnknownDriver = errors.New("indexer: unsupported driver")
+)
+
+// Config selects the database of the indexer.
+type Config struct {
+	Driver string // "sqlite" or "postgres"; empty disables the indexer
+	DSN    string // data source name passed to the driver
+}
+
+// Indexer writes the blocks of a chain store to a database, in height
+// order, remembering the last height written so that a restarted node
+// resumes where it stopped.
+type Indexer struct {
+	db     *sql.DB
+	chain  *chain.Store
+	logger *zap.Logger
+	dollar bool // PostgreSQL placeholders ($1) instead of ?
+	notify chan struct{}
+	last   uint64
+}
+
+// Open connects to the database of config, creates the tables if needed
+// and returns an indexer of the blocks of store. store may be nil for an
+// indexer that is only rolled back.
+func Open(config Config, store *chain.Store, logger *zap.Logger) (*Indexer, error) {
+	var dollar bool
+	switch config.Driver {
+	case "sqlite", "sqlite3":
+	case "postgres", "pgx":
+		dollar = true
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownDriver, config.Driver)
+	}
+	db, err := sql.Open(config.Driver, config.DSN)
+	if err != nil {
+		return nil, err
+	}
+	if !dollar {
+		// SQLite allows one writer; queries wait for the indexer rather
+		// than fail with SQLITE_BUSY.
+		db.SetMaxOpenConns(1)
+	}
+	ix := &Indexer{db: db, chain: store, logger: logger, dollar: dollar, notify: make(chan struct{}, 1)}
+	if err := ix.init(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return ix, nil
+}
+
+// init creates the schema, or checks the version of an existing one, and
+// loads the last indexed height.
+func (ix *Indexer) init() error {
+	for _, stmt := range schema {
+		if _, err := ix.db.Exec(stmt); err != nil {
+			return fmt.Errorf("indexer: creating schema: %w", err)
+		}
+	}
+	version, ok, err := ix.meta("schema_version")
+	if err != nil {
+		return err
+	}
+	if ok && version != SchemaVersion {
+		return fmt.Errorf("%w: %d", ErrSchemaVersion, version)
+	}
+	if !ok {
+		if _, err := ix.db.Exec(ix.query(`INSERT INTO meta (key, value) VALUES (?, ?)`), "schema_version", SchemaVersion); err != nil {
+			return err
+		}
+	}
+	ix.last, _, err = ix.meta("last_height")
+	return err
+}
+
+func (ix *Indexer) meta(key string) (uint64, bool, error) {
+	var v int64
+	err := ix.db.QueryRow(ix.query(`SELECT value FROM meta WHERE key = ?`), key).Scan(&v)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, false, nil
+	case err != nil:
+		return 0, false, err
+	}
+	return uint64(v), true, nil
+}
+
+// DB returns the database, for queries.
+func (ix *Indexer) DB() *sql.DB { return ix.db }
+
+// Close closes the database.
+func (ix *Indexer) Close() error { return ix.db.Close() }
+
+// Notify wakes Run up to index the blocks added to the store. It never
+// blocks.
+func (ix *Indexer) Notify() {
+	select {
+	case ix.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Run indexes the blocks of the store above the last indexed height
+// whenever Notify is called, until ctx is done. Blocks pruned from the
+// store before they could be indexed are skipped.
+func (ix *Indexer) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ix.notify:
+		}
+		if err := ix.CatchUp(ctx); err != nil && ctx.Err() == nil {
+			ix.logger.Error("sql indexing failed", zap.Uint64("height", ix.last+1), zap.Error(err))
+		}
+	}
+}
+
+// CatchUp indexes the blocks of the store above the last indexed height.
+// It must not be called concurrently with Run.
+func (ix *Indexer) CatchUp(ctx context.Context) error {
+	for head := ix.chain.Head(); ix.last < head; {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		height := ix.last + 1
+		b, err := ix.chain.BlockByHeight(height)
+		if errors.Is(err, chain.ErrBlockNotFound) {
+			ix.logger.Warn("block pruned before sql indexing", zap.Uint64("height", height))
+			ix.last = height
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := ix.index(ctx, b); err != nil {
+			return err
+		}
+		ix.last = height
+	}
+	return nil
+}
+
+// Rollback deletes the rows of the blocks above height and sets the last
+// indexed height to height if it was higher, in one database transaction.
+// It must not be called concurrently with Run.
+func (ix *Indexer) Rollback(ctx context.Context, height uint64) error {
+	if height >= ix.last {
+		return nil
+	}
+	dbtx, err := ix.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer dbtx.Rollback()
+	stmts := []string{`DELETE FROM blocks WHERE height > ?`}
+	for _, table := range []string{"txs", "receipts", "logs", "agents", "messages", "inference_receipts"} {
+		stmts = append(stmts, `DELETE FROM `+table+` WHERE block_height > ?`)
+	}
+	stmts = append(stmts, `UPDATE meta SET value = ? WHERE key = 'last_height'`)
+	for _, q := range stmts {
+		if _, err := dbtx.ExecContext(ctx, ix.query(q), int64(height)); err != nil {
+			return fmt.Errorf("indexer: rollback to %d: %w", height, err)
+		}
+	}
+	if err := dbtx.Commit(); err != nil {
+		return err
+	}
+	ix.last = height
+	return nil
+}
+
+// Height returns the last indexed height.
+func (ix *Indexer) Height() uint64 { return ix.last }
+
+// index writes b, its transactions and their effects, and advances the
+// last indexed height, in one database transaction. Rows already present
+// are kept, so a block may be written again after a crash.
+func (ix *Indexer) index(ctx context.Context, b *block.Block) error {
+	dbtx, err := ix.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer dbtx.Rollback()
+	exec := func(q string, args ...interface{}) error {
+		_, err := dbtx.ExecContext(ctx, ix.query(q), args...)
+		return err
+	}
+
+	h := b.Header
+	height := int64(h.Height)
+	blockHash := b.Hash()
+	if err := exec(`INSERT INTO blocks (height, hash, prev_hash, timestamp, validator, state_root, tx_root, agent_root, tx_count)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`,
+		height, hexBytes(blockHash[:]), hexBytes(h.PrevHash[:]), h.Timestamp, string(h.ValidatorAddr),
+		hexBytes(h.StateRoot[:]), hexBytes(h.TxRoot[:]), hexBytes(h.AgentRoot[:]), len(b.Txs)); err != nil {
+		return fmt.Errorf("indexer: block %d: %w", h.Height, err)
+	}
+	for i, tx := range b.Txs {
+		rawHash := tx.Hash()
+		txHash := hexBytes(rawHash[:])
+		if err := exec(`INSERT INTO txs (hash, block_height, tx_index, type, from_addr, to_addr, value, nonce, gas, gas_price, paymaster, data)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`,
+			txHash, height, i, int(tx.Type), tx.From, tx.To, decimal(tx.Value), int64(tx.Nonce), int64(tx.Gas),
+			decimal(tx.GasPrice), tx.Paymaster, string(tx.Data)); err != nil {
+			return fmt.Errorf("indexer: tx %s: %w", txHash, err)
+		}
+		if r, err := ix.chain.Receipt(rawHash); err == nil {
+			if err := ix.indexReceipt(exec, txHash, height, r); err != nil {
+				return fmt.Errorf("indexer: receipt %s: %w", txHash, err)
+			}
+		}
+		if err := ix.indexPayload(exec, txHash, height, i, tx); err != nil {
+			return fmt.Errorf("indexer: tx %s: %w", txHash, err)
+		}
+	}
+	if err := exec(`INSERT INTO meta (key, value) VALUES ('last_height', ?)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value`, height); err != nil {
+		return err
+	}
+	return dbtx.Commit()
+}
+
+func (ix *Indexer) indexReceipt(exec func(string, ...interface{}) error, txHash string, height int64, r *transaction.Receipt) error {
+	if err := exec(`INSERT INTO receipts (tx_hash, block_height, status, gas_used, fee, fee_payer, contract, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`,
+		txHash, height, int(r.Status), int64(r.GasUsed), decimal(r.Fee), r.FeePayer, r.Contract, r.Error); err != nil {
+		return err
+	}
+	for i, l := range r.Logs {
+		topics := make([]string, len(l.Topics))
+		for j, t := range l.Topics {
+			topics[j] = hexBytes(t)
+		}
+		enc, _ := json.Marshal(topics)
+		if err := exec(`INSERT INTO logs (tx_hash, log_index, block_height, address, topics, data)
+			VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`,
+			txHash, i, height, l.Address, string(enc), hexBytes(l.Data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexPayload writes the agent records carried by tx. Payloads that do
+// not decode are left to the txs table.
+func (ix *Indexer) indexPayload(exec func(string, ...interface{}) error, txHash string, height int64, index int, tx *transaction.Tx) error {
+	switch tx.Type {
+	case transaction.TxAgentRegister:
+		var did transaction.AgentDID
+		if json.Unmarshal(tx.Data, &did) != nil || did.ID == "" {
+			return nil
+		}
+		if did.Capabilities == nil {
+			did.Capabilities = []transaction.Capability{}
+		}
+		if did.Metadata == nil {
+			did.Metadata = map[string]string{}
+		}
+		caps, _ := json.Marshal(did.Capabilities)
+		meta, _ := json.Marshal(did.Metadata)
+		return exec(`INSERT INTO agents (did, controller, block_height, tx_hash, capabilities, metadata)
+			VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`,
+			did.ID, did.Controller, height, txHash, string(caps), string(meta))
+	case transaction.TxAgentMessage:
+		var msg transaction.AgentMessage
+		if json.Unmarshal(tx.Data, &msg) != nil {
+			return nil
+		}
+		ref := ""
+		if msg.PayloadRef != nil {
+			enc, _ := json.Marshal(msg.PayloadRef)
+			ref = string(enc)
+		}
+		return exec(`INSERT INTO messages (tx_hash, block_height, tx_index, from_did, to_did, type, nonce, payload, payload_ref)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`,
+			txHash, height, index, msg.From, msg.To, string(msg.Type), int64(msg.Nonce), hexBytes(msg.Payload), ref)
+	case transaction.TxInferenceReceipt:
+		var r transaction.InferenceReceipt
+		if json.Unmarshal(tx.Data, &r) != nil || r.AgentID == "" {
+			return nil
+		}
+		return exec(`INSERT INTO inference_receipts (tx_hash, block_height, agent_id, model_hash, input_hash, output_hash, prover, timestamp)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`,
+			txHash, height, r.AgentID, hexBytes(r.ModelHash), hexBytes(r.InputHash), hexBytes(r.OutputHash), r.Prover, r.Timestamp)
+	}
+	return nil
+}
+
+// query rewrites the ? placeholders of q for the driver.
+func (ix *Indexer) query(q string) string {
+	if !ix.dollar {
+		return q
+	}
+	var sb strings.Builder
+	n := 0
+	for _, c := range q {
+		if c == '?' {
+			n++
+			sb.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+func hexBytes(b []byte) string { return fmt.Sprintf("0x%x", b) }
+
+// decimal formats an optional amount.
+func decimal(v *big.Int) string {
+	if v == nil {
+		return "0"
+	}
+	return v.String()
+}
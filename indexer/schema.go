@@ -0,0 +1,110 @@
+package indexer
+
+// SchemaVersion is recorded in the meta table; an index written with
+// another version is rejected rather than mixed with it.
+const SchemaVersion = 1
+
+// schema creates the index tables. The statements run on SQLite and
+// PostgreSQL alike: hashes, addresses and byte strings are 0x-prefixed
+// hex TEXT, amounts decimal TEXT, and JSON documents TEXT.
+//
+//	meta               key/value bookkeeping: schema_version, last_height
+//	blocks             one row per finalized block
+//	txs                one row per included transaction
+//	receipts           the outcome of each executed transaction
+//	logs               the events emitted by contract code
+//	agents             agent registrations, first one per DID
+//	messages           agent messages, in the order they were included
+//	inference_receipts inference receipts submitted for agents
+//
+// Agents, messages and inference receipts are read from the transactions
+// of a block; join them with receipts on tx_hash to keep the ones that
+// executed successfully (status 1).
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS meta (
+		key   TEXT PRIMARY KEY,
+		value BIGINT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS blocks (
+		height     BIGINT PRIMARY KEY,
+		hash       TEXT NOT NULL UNIQUE,
+		prev_hash  TEXT NOT NULL,
+		timestamp  BIGINT NOT NULL, -- Unix nanoseconds
+		validator  TEXT NOT NULL,
+		state_root TEXT NOT NULL,
+		tx_root    TEXT NOT NULL,
+		agent_root TEXT NOT NULL,
+		tx_count   INTEGER NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS txs (
+		hash         TEXT PRIMARY KEY,
+		block_height BIGINT NOT NULL,
+		tx_index     INTEGER NOT NULL,
+		type         INTEGER NOT NULL, -- transaction.TxType
+		from_addr    TEXT NOT NULL,
+		to_addr      TEXT NOT NULL,
+		value        TEXT NOT NULL,
+		nonce        BIGINT NOT NULL,
+		gas          BIGINT NOT NULL,
+		gas_price    TEXT NOT NULL,
+		paymaster    TEXT NOT NULL,
+		data         TEXT NOT NULL -- JSON payload of the type
+	)`,
+	`CREATE INDEX IF NOT EXISTS txs_block ON txs (block_height, tx_index)`,
+	`CREATE INDEX IF NOT EXISTS txs_from ON txs (from_addr, block_height)`,
+	`CREATE INDEX IF NOT EXISTS txs_to ON txs (to_addr, block_height)`,
+	`CREATE TABLE IF NOT EXISTS receipts (
+		tx_hash      TEXT PRIMARY KEY,
+		block_height BIGINT NOT NULL,
+		status       INTEGER NOT NULL, -- 1 success, 0 failure
+		gas_used     BIGINT NOT NULL,
+		fee          TEXT NOT NULL,
+		fee_payer    TEXT NOT NULL,
+		contract     TEXT NOT NULL, -- address created by a deployment
+		error        TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS logs (
+		tx_hash      TEXT NOT NULL,
+		log_index    INTEGER NOT NULL,
+		block_height BIGINT NOT NULL,
+		address      TEXT NOT NULL,
+		topics       TEXT NOT NULL, -- JSON array of hex topics
+		data         TEXT NOT NULL,
+		PRIMARY KEY (tx_hash, log_index)
+	)`,
+	`CREATE INDEX IF NOT EXISTS logs_address ON logs (address, block_height)`,
+	`CREATE TABLE IF NOT EXISTS agents (
+		did          TEXT PRIMARY KEY,
+		controller   TEXT NOT NULL,
+		block_height BIGINT NOT NULL,
+		tx_hash      TEXT NOT NULL,
+		capabilities TEXT NOT NULL, -- JSON array of {name, version}
+		metadata     TEXT NOT NULL  -- JSON object
+	)`,
+	`CREATE INDEX IF NOT EXISTS agents_controller ON agents (controller)`,
+	`CREATE TABLE IF NOT EXISTS messages (
+		tx_hash      TEXT PRIMARY KEY,
+		block_height BIGINT NOT NULL,
+		tx_index     INTEGER NOT NULL,
+		from_did     TEXT NOT NULL,
+		to_did       TEXT NOT NULL,
+		type         TEXT NOT NULL, -- TASK, RESULT, DELEGATE or REVOKE
+		nonce        BIGINT NOT NULL,
+		payload      TEXT NOT NULL,
+		payload_ref  TEXT NOT NULL -- JSON data reference, or empty
+	)`,
+	`CREATE INDEX IF NOT EXISTS messages_from ON messages (from_did, block_height)`,
+	`CREATE INDEX IF NOT EXISTS messages_to ON messages (to_did, block_height)`,
+	`CREATE TABLE IF NOT EXISTS inference_receipts (
+		tx_hash      TEXT PRIMARY KEY,
+		block_height BIGINT NOT NULL,
+		agent_id     TEXT NOT NULL,
+		model_hash   TEXT NOT NULL,
+		input_hash   TEXT NOT NULL,
+		output_hash  TEXT NOT NULL,
+		prover       TEXT NOT NULL,
+		timestamp    BIGINT NOT NULL -- Unix seconds
+	)`,
+	`CREATE INDEX IF NOT EXISTS inference_agent ON inference_receipts (agent_id, block_height)`,
+	`CREATE INDEX IF NOT EXISTS inference_model ON inference_receipts (model_hash, block_height)`,
+}
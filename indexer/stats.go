@@ -0,0 +1,132 @@
+package indexer
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// DefaultStatsDays and MaxStatsDays bound the days covered by Stats.
+const (
+	DefaultStatsDays = 30
+	MaxStatsDays     = 365
+)
+
+// dayNanos is the length of a day in block timestamps.
+const dayNanos = int64(24 * time.Hour)
+
+// DayCount is a count for one UTC day, formatted 2006-01-02.
+type DayCount struct {
+	Day   string `json:"day"`
+	Count uint64 `json:"count"`
+}
+
+// Stats summarizes the indexed chain.
+type Stats struct {
+	Height       uint64 `json:"height"` // last indexed block
+	Transactions uint64 `json:"transactions"`
+	Messages     uint64 `json:"messages"`
+	// VerifiedReceipts counts the inference receipts that executed
+	// successfully, per day of the block including them, oldest first.
+	// Days without receipts are omitted.
+	VerifiedReceipts []DayCount `json:"verifiedReceipts"`
+}
+
+// Stats returns the totals of the index and the verified inference
+// receipts of the last days days, counting today.
+func (ix *Indexer) Stats(ctx context.Context, days int) (*Stats, error) {
+	if days <= 0 {
+		days = DefaultStatsDays
+	}
+	if days > MaxStatsDays {
+		days = MaxStatsDays
+	}
+	st := &Stats{VerifiedReceipts: []DayCount{}}
+	var height int64
+	if err := ix.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(height), 0) FROM blocks`).Scan(&height); err != nil {
+		return nil, err
+	}
+	st.Height = uint64(height)
+	if err := ix.count(ctx, &st.Transactions, `SELECT COUNT(*) FROM txs`); err != nil {
+		return nil, err
+	}
+	if err := ix.count(ctx, &st.Messages, `SELECT COUNT(*) FROM messages`); err != nil {
+		return nil, err
+	}
+
+	today := time.Now().UTC().UnixNano() / dayNanos
+	rows, err := ix.db.QueryContext(ctx, ix.query(`SELECT b.timestamp / ? AS day, COUNT(*)
+		FROM inference_receipts ir
+		JOIN receipts r ON r.tx_hash = ir.tx_hash
+		JOIN blocks b ON b.height = ir.block_height
+		WHERE r.status = 1 AND b.timestamp >= ?
+		GROUP BY day ORDER BY day`), dayNanos, (today-int64(days)+1)*dayNanos)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var day int64
+		var n uint64
+		if err := rows.Scan(&day, &n); err != nil {
+			return nil, err
+		}
+		st.VerifiedReceipts = append(st.VerifiedReceipts, DayCount{
+			Day:   time.Unix(0, day*dayNanos).UTC().Format(time.DateOnly),
+			Count: n,
+		})
+	}
+	return st, rows.Err()
+}
+
+// Activity summarizes the indexed transactions of an address.
+type Activity struct {
+	Address      string `json:"address"`
+	TxsSent      uint64 `json:"txsSent"`
+	TxsReceived  uint64 `json:"txsReceived"`
+	GasUsed      uint64 `json:"gasUsed"`     // by the executed transactions sent
+	FirstHeight  uint64 `json:"firstHeight"` // of the first transaction sent or received, 0 if none
+	LastHeight   uint64 `json:"lastHeight"`
+	Agents       uint64 `json:"agents"`    // registered with the address as controller
+	Contracts    uint64 `json:"contracts"` // deployed by the address
+	MessagesSent uint64 `json:"messagesSent"`
+}
+
+// Activity returns the activity of addr, which must be formatted as in
+// transactions, with its checksum.
+func (ix *Indexer) Activity(ctx context.Context, addr string) (*Activity, error) {
+	a := &Activity{Address: addr}
+	counts := []struct {
+		dst *uint64
+		q   string
+	}{
+		{&a.TxsSent, `SELECT COUNT(*) FROM txs WHERE from_addr = ?`},
+		{&a.TxsReceived, `SELECT COUNT(*) FROM txs WHERE to_addr = ?`},
+		{&a.GasUsed, `SELECT COALESCE(SUM(r.gas_used), 0) FROM receipts r JOIN txs t ON t.hash = r.tx_hash WHERE t.from_addr = ?`},
+		{&a.Agents, `SELECT COUNT(*) FROM agents WHERE controller = ?`},
+		{&a.Contracts, `SELECT COUNT(*) FROM receipts r JOIN txs t ON t.hash = r.tx_hash WHERE t.from_addr = ? AND r.contract <> ''`},
+		{&a.MessagesSent, `SELECT COUNT(*) FROM messages m JOIN txs t ON t.hash = m.tx_hash WHERE t.from_addr = ?`},
+	}
+	for _, c := range counts {
+		if err := ix.count(ctx, c.dst, c.q, addr); err != nil {
+			return nil, err
+		}
+	}
+	var first, last sql.NullInt64
+	err := ix.db.QueryRowContext(ctx, ix.query(`SELECT MIN(block_height), MAX(block_height) FROM txs
+		WHERE from_addr = ? OR to_addr = ?`), addr, addr).Scan(&first, &last)
+	if err != nil {
+		return nil, err
+	}
+	a.FirstHeight, a.LastHeight = uint64(first.Int64), uint64(last.Int64)
+	return a, nil
+}
+
+func (ix *Indexer) count(ctx context.Context, dst *uint64, q string, args ...interface{}) error {
+	var n int64
+	if err := ix.db.QueryRowContext(ctx, ix.query(q), args...).Scan(&n); err != nil {
+		return err
+	}
+	*dst = uint64(n)
+	return nil
+}
@@ -0,0 +1,220 @@
+// Package ws is a minimal RFC 6455 WebSocket implementation shared by the
+// RPC server and client: text and binary messages, fragmentation, ping/pong
+// and the closing handshake. Extensions are not negotiated.
+package ws
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+const (
+	guid = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	// DefaultReadLimit bounds the size of a reassembled message unless
+	// SetReadLimit is called.
+	DefaultReadLimit = 1 << 20
+)
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+var (
+	ErrHandshake   = errors.New("websocket: bad handshake")
+	ErrProtocol    = errors.New("websocket: protocol error")
+	ErrTooLarge    = errors.New("websocket: message too large")
+	ErrClosed      = errors.New("websocket: connection closed")
+	ErrUnsupported = errors.New("websocket: hijacking not supported")
+)
+
+// Conn is a WebSocket connection, either end. Reads must come from a single
+// goroutine; writes may be concurrent.
+type Conn struct {
+	conn  net.Conn
+	br    *bufio.Reader
+	wmu   sync.Mutex
+	limit int
+
+	// client connections mask the frames they send and expect unmasked
+	// frames; servers the reverse.
+	client bool
+}
+
+func newConn(conn net.Conn, br *bufio.Reader, client bool) *Conn {
+	return &Conn{conn: conn, br: br, limit: DefaultReadLimit, client: client}
+}
+
+// acceptKey returns the Sec-WebSocket-Accept value answering key.
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + guid))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// SetReadLimit sets the maximum size of a reassembled message.
+func (c *Conn) SetReadLimit(n int) {
+	c.limit = n
+}
+
+// ReadMessage returns the next data message. Control frames are handled
+// internally; a close frame is answered and reported as io.EOF.
+func (c *Conn) ReadMessage() ([]byte, error) {
+	var msg []byte
+	started := false
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			c.writeFrame(opClose, payload)
+			return nil, io.EOF
+		case opText, opBinary:
+			if started {
+				return nil, ErrProtocol
+			}
+			started = true
+		case opContinuation:
+			if !started {
+				return nil, ErrProtocol
+			}
+		default:
+			return nil, ErrProtocol
+		}
+		if len(msg)+len(payload) > c.limit {
+			return nil, ErrTooLarge
+		}
+		msg = append(msg, payload...)
+		if fin {
+			return msg, nil
+		}
+	}
+}
+
+func (c *Conn) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var hdr [2]byte
+	if _, err = io.ReadFull(c.br, hdr[:]); err != nil {
+		return
+	}
+	fin = hdr[0]&0x80 != 0
+	op = hdr[0] & 0x0F
+	masked := hdr[1]&0x80 != 0
+	if hdr[0]&0x70 != 0 || masked == c.client {
+		// Reserved bits without an extension, a masked server frame or an
+		// unmasked client frame.
+		return false, 0, nil, ErrProtocol
+	}
+	size := uint64(hdr[1] & 0x7F)
+	switch size {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		size = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		size = binary.BigEndian.Uint64(ext[:])
+	}
+	if op >= opClose && (!fin || size > 125) {
+		return false, 0, nil, ErrProtocol
+	}
+	if size > uint64(c.limit) {
+		return false, 0, nil, ErrTooLarge
+	}
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+			return
+		}
+	}
+	payload = make([]byte, size)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	if masked {
+		maskBytes(payload, mask)
+	}
+	return fin, op, payload, nil
+}
+
+// WriteText sends data as a single text frame.
+func (c *Conn) WriteText(data []byte) error {
+	return c.writeFrame(opText, data)
+}
+
+// writeFrame sends payload in one frame, masked with a fresh key on the
+// client side as RFC 6455 requires.
+func (c *Conn) writeFrame(op byte, payload []byte) error {
+	var maskBit byte
+	if c.client {
+		maskBit = 0x80
+	}
+	hdr := make([]byte, 2, 14+len(payload))
+	hdr[0] = 0x80 | op
+	switch n := len(payload); {
+	case n < 126:
+		hdr[1] = maskBit | byte(n)
+	case n <= 0xFFFF:
+		hdr[1] = maskBit | 126
+		hdr = binary.BigEndian.AppendUint16(hdr, uint16(n))
+	default:
+		hdr[1] = maskBit | 127
+		hdr = binary.BigEndian.AppendUint64(hdr, uint64(n))
+	}
+	frame := hdr
+	if c.client {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		frame = append(frame, mask[:]...)
+		frame = append(frame, payload...)
+		maskBytes(frame[len(frame)-len(payload):], mask)
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if _, err := c.conn.Write(frame); err != nil {
+		return fmt.Errorf("%w: %v", ErrClosed, err)
+	}
+	return nil
+}
+
+func maskBytes(b []byte, mask [4]byte) {
+	for i := range b {
+		b[i] ^= mask[i%4]
+	}
+}
+
+// Close sends a normal closure frame and closes the connection.
+func (c *Conn) Close() error {
+	c.writeFrame(opClose, []byte{0x03, 0xE8})
+	return c.conn.Close()
+}
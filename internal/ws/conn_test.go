@@ -0,0 +1,83 @@
+package ws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteText(msg); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	conn, err := Dial(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// Sizes covering the 7-bit, 16-bit and 64-bit length encodings.
+	for _, n := range []int{0, 125, 126, 0xFFFF, 0x10000} {
+		msg := bytes.Repeat([]byte{'x'}, n)
+		if err := conn.WriteText(msg); err != nil {
+			t.Fatal(err)
+		}
+		got, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("%d bytes: %v", n, err)
+		}
+		if !bytes.Equal(got, msg) {
+			t.Fatalf("%d bytes: echoed %d bytes", n, len(got))
+		}
+	}
+
+	conn.SetReadLimit(16)
+	if err := conn.WriteText(make([]byte, 17)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.ReadMessage(); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("ReadMessage over the limit = %v, want %v", err, ErrTooLarge)
+	}
+}
+
+func TestClose(t *testing.T) {
+	done := make(chan error, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			done <- err
+			return
+		}
+		_, err = conn.ReadMessage()
+		done <- err
+	}))
+	defer srv.Close()
+
+	conn, err := Dial(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if err := <-done; err != io.EOF {
+		t.Fatalf("server ReadMessage after close = %v, want %v", err, io.EOF)
+	}
+}
@@ -0,0 +1,157 @@
+package light
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/zionlayer/zionlayer/core/block"
+	"github.com/zionlayer/zionlayer/core/state"
+)
+
+// MaxTrustedHeaders is the number of recent verified headers a client
+// keeps.
+const MaxTrustedHeaders = 1024
+
+// TrustOptions is the root of trust of a client: a header obtained out of
+// band, for example from a block explorer or a trusted operator, and the
+// validator set signing the blocks following it.
+type TrustOptions struct {
+	Header     block.Header
+	Validators *ValidatorSet
+}
+
+// Client follows the chain from a trusted header, verifying each header
+// against the commit of the validator set.
+type Client struct {
+	mu      sync.RWMutex
+	vals    *ValidatorSet
+	headers map[uint64]*block.Header // verified, by height
+	latest  *block.Header
+}
+
+// NewClient creates a client trusting opts.
+func NewClient(opts TrustOptions) (*Client, error) {
+	if opts.Validators == nil || len(opts.Validators.Validators) == 0 {
+		return nil, fmt.Errorf("%w: empty", ErrInvalidValidators)
+	}
+	h := opts.Header
+	return &Client{
+		vals:    opts.Validators,
+		headers: map[uint64]*block.Header{h.Height: &h},
+		latest:  &h,
+	}, nil
+}
+
+// Verify checks that sh is of the network of the trusted header, follows
+// the latest trusted header and is committed by the validator set, and then
+// trusts it. A vals differing from the
+// trusted validator set replaces it if it committed sh and the trusted set
+// vouches for it with more than a third of its power in the same commit.
+func (c *Client) Verify(sh *SignedHeader, vals *ValidatorSet) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h := &sh.Header
+	if h.ChainID != c.latest.ChainID || h.GenesisHash != c.latest.GenesisHash {
+		return fmt.Errorf("%w: chain %d genesis %x", ErrWrongNetwork, h.ChainID, h.GenesisHash)
+	}
+	if h.Height != c.latest.Height+1 || h.PrevHash != (&block.Block{Header: *c.latest}).Hash() {
+		return fmt.Errorf("%w: height %d", ErrNonSequential, h.Height)
+	}
+	next := c.vals
+	if vals != nil && !vals.Equal(c.vals) {
+		if err := c.vals.verifyPower(sh, 1, 3); err != nil {
+			return fmt.Errorf("validator set change: %w", err)
+		}
+		next = vals
+	}
+	if err := next.VerifyCommit(sh); err != nil {
+		return err
+	}
+	hc := *h
+	c.vals, c.latest = next, &hc
+	c.headers[hc.Height] = &hc
+	if hc.Height >= MaxTrustedHeaders {
+		delete(c.headers, hc.Height-MaxTrustedHeaders)
+	}
+	return nil
+}
+
+// Sync verifies the headers p has produced since the latest trusted one
+// and returns the height of the latest trusted header.
+func (c *Client) Sync(ctx context.Context, p Provider) (uint64, error) {
+	head, err := p.LatestHeight(ctx)
+	if err != nil {
+		return c.LatestHeight(), err
+	}
+	vals, err := p.ValidatorSet(ctx)
+	if err != nil {
+		return c.LatestHeight(), err
+	}
+	for height := c.LatestHeight() + 1; height <= head; height++ {
+		sh, err := p.SignedHeader(ctx, height)
+		if err != nil {
+			return height - 1, err
+		}
+		if err := c.Verify(sh, vals); err != nil {
+			return height - 1, err
+		}
+	}
+	return c.LatestHeight(), nil
+}
+
+// LatestHeight returns the height of the latest trusted header.
+func (c *Client) LatestHeight() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.latest.Height
+}
+
+// LatestHeader returns the latest trusted header.
+func (c *Client) LatestHeader() block.Header {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return *c.latest
+}
+
+// Header returns the trusted header at height.
+func (c *Client) Header(height uint64) (block.Header, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	h, ok := c.headers[height]
+	if !ok {
+		return block.Header{}, fmt.Errorf("%w: height %d", ErrNotTrusted, height)
+	}
+	return *h, nil
+}
+
+// Validators returns the trusted validator set.
+func (c *Client) Validators() *ValidatorSet {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.vals
+}
+
+// VerifyAgentProof checks proof against the AgentRoot of the trusted
+// headers, newest first, and returns the height of the header committing
+// to it.
+func (c *Client) VerifyAgentProof(proof *state.AgentStateProof) (uint64, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for height := c.latest.Height; ; height-- {
+		h, ok := c.headers[height]
+		if !ok {
+			break
+		}
+		if h.AgentRoot == proof.Root {
+			if !proof.Verify(h.AgentRoot) {
+				break
+			}
+			return height, nil
+		}
+		if height == 0 {
+			break
+		}
+	}
+	return 0, fmt.Errorf("%w: root %x", ErrInvalidProof, proof.Root)
+}
@@ -0,0 +1,181 @@
+// Package light verifies ZionLayer block headers and state proofs without
+// the full state. A light client starts from a trusted header and the
+// validator set that signs blocks, follows the chain one header at a time,
+// checking that each links to the last and carries a commit of the
+// validator set, and checks Merkle proofs of agent state entries against
+// the AgentRoot of a verified header. It can be embedded in Go
+// applications and is the basis for bridges.
+//
+// A commit consists of the signature of the proposer in the header and the
+// signatures of other validators over the same SigningHash. It is valid
+// when validators holding more than two thirds of the voting power signed.
+// ZionBFT commits blocks with the proposer signature alone, so today a
+// commit verifies against a set in which the proposer holds that power,
+// such as a single-validator network.
+package light
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/zionlayer/zionlayer/core/block"
+	"github.com/zionlayer/zionlayer/core/crypto"
+)
+
+var (
+	ErrNotTrusted        = errors.New("light: header not trusted")
+	ErrNonSequential     = errors.New("light: header does not follow the trusted header")
+	ErrInsufficientPower = errors.New("light: commit lacks voting power")
+	ErrInvalidValidators = errors.New("light: invalid validator set")
+	ErrInvalidProof      = errors.New("light: proof does not match the header")
+	ErrWrongNetwork      = errors.New("light: header is from another network")
+)
+
+// Validator is a member of a validator set. Its JSON encoding is that of
+// zion_getValidators, so a saved result is a validator set to trust.
+type Validator struct {
+	Address string
+	PubKey  crypto.PublicKey
+	Power   int64
+}
+
+type validatorJSON struct {
+	Address     string `json:"address"`
+	PublicKey   string `json:"publicKey"`
+	VotingPower int64  `json:"votingPower"`
+}
+
+// MarshalJSON encodes v with a hex public key.
+func (v Validator) MarshalJSON() ([]byte, error) {
+	return json.Marshal(validatorJSON{Address: v.Address, PublicKey: fmt.Sprintf("0x%x", []byte(v.PubKey)), VotingPower: v.Power})
+}
+
+// UnmarshalJSON decodes v from its MarshalJSON encoding.
+func (v *Validator) UnmarshalJSON(raw []byte) error {
+	var dec validatorJSON
+	if err := json.Unmarshal(raw, &dec); err != nil {
+		return err
+	}
+	pub, err := decodeHex(dec.PublicKey)
+	if err != nil {
+		return fmt.Errorf("%w: public key of %s", ErrInvalidValidators, dec.Address)
+	}
+	*v = Validator{Address: dec.Address, PubKey: pub, Power: dec.VotingPower}
+	return nil
+}
+
+// ValidatorSet is a set of validators by address.
+type ValidatorSet struct {
+	Validators []Validator `json:"validators"`
+}
+
+// NewValidatorSet returns the set of vals ordered by address. Each must
+// have a positive power and a public key matching its address.
+func NewValidatorSet(vals []Validator) (*ValidatorSet, error) {
+	if len(vals) == 0 {
+		return nil, fmt.Errorf("%w: empty", ErrInvalidValidators)
+	}
+	out := append([]Validator(nil), vals...)
+	sort.Slice(out, func(i, j int) bool { return out[i].Address < out[j].Address })
+	for i, v := range out {
+		if v.Power <= 0 {
+			return nil, fmt.Errorf("%w: %s has no power", ErrInvalidValidators, v.Address)
+		}
+		if len(v.PubKey) != crypto.PublicKeySize || crypto.PubkeyToAddress(v.PubKey).String() != v.Address {
+			return nil, fmt.Errorf("%w: key of %s does not match its address", ErrInvalidValidators, v.Address)
+		}
+		if i > 0 && out[i-1].Address == v.Address {
+			return nil, fmt.Errorf("%w: %s listed twice", ErrInvalidValidators, v.Address)
+		}
+	}
+	return &ValidatorSet{Validators: out}, nil
+}
+
+// TotalPower returns the voting power of the set.
+func (vs *ValidatorSet) TotalPower() int64 {
+	var total int64
+	for _, v := range vs.Validators {
+		total += v.Power
+	}
+	return total
+}
+
+// get returns the validator addr.
+func (vs *ValidatorSet) get(addr string) (Validator, bool) {
+	i := sort.Search(len(vs.Validators), func(i int) bool { return vs.Validators[i].Address >= addr })
+	if i < len(vs.Validators) && vs.Validators[i].Address == addr {
+		return vs.Validators[i], true
+	}
+	return Validator{}, false
+}
+
+// Equal reports whether vs and other have the same members and powers.
+func (vs *ValidatorSet) Equal(other *ValidatorSet) bool {
+	if len(vs.Validators) != len(other.Validators) {
+		return false
+	}
+	for i, v := range vs.Validators {
+		o := other.Validators[i]
+		if v.Address != o.Address || v.Power != o.Power || !bytes.Equal(v.PubKey, o.PubKey) {
+			return false
+		}
+	}
+	return true
+}
+
+// CommitSig is the signature of a validator over the SigningHash of a
+// header.
+type CommitSig struct {
+	Validator string `json:"validator"`
+	Signature []byte `json:"signature"`
+}
+
+// SignedHeader is a header with the signatures committing it besides that
+// of its proposer.
+type SignedHeader struct {
+	Header block.Header `json:"-"`
+	Commit []CommitSig  `json:"commit"`
+}
+
+// Hash returns the hash of the block of the header.
+func (sh *SignedHeader) Hash() [32]byte {
+	b := block.Block{Header: sh.Header}
+	return b.Hash()
+}
+
+// signedPower returns the voting power of the validators of vs that signed
+// sh. Signatures of others, and repeated ones, are ignored.
+func (vs *ValidatorSet) signedPower(sh *SignedHeader) int64 {
+	digest := sh.Header.SigningHash()
+	sigs := append([]CommitSig{{Validator: string(sh.Header.ValidatorAddr), Signature: sh.Header.Signature}}, sh.Commit...)
+	seen := make(map[string]bool, len(sigs))
+	var power int64
+	for _, sig := range sigs {
+		v, ok := vs.get(sig.Validator)
+		if !ok || seen[v.Address] || crypto.Verify(v.PubKey, digest, sig.Signature) != nil {
+			continue
+		}
+		seen[v.Address] = true
+		power += v.Power
+	}
+	return power
+}
+
+// VerifyCommit checks that validators of vs holding more than two thirds
+// of its voting power signed sh.
+func (vs *ValidatorSet) VerifyCommit(sh *SignedHeader) error {
+	return vs.verifyPower(sh, 2, 3)
+}
+
+// verifyPower checks that validators of vs holding more than num/den of
+// its voting power signed sh.
+func (vs *ValidatorSet) verifyPower(sh *SignedHeader, num, den int64) error {
+	signed, total := vs.signedPower(sh), vs.TotalPower()
+	if signed*den <= total*num {
+		return fmt.Errorf("%w: %d of %d signed at height %d", ErrInsufficientPower, signed, total, sh.Header.Height)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package light
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zionlayer/zionlayer/core/state"
+)
+
+// Provider serves the headers, validator set and state proofs of a chain,
+// typically a full node. Nothing it serves is trusted until verified.
+type Provider interface {
+	LatestHeight(ctx context.Context) (uint64, error)
+	SignedHeader(ctx context.Context, height uint64) (*SignedHeader, error)
+	// ValidatorSet returns the current validator set, or nil if unknown,
+	// in which case the trusted set is assumed unchanged.
+	ValidatorSet(ctx context.Context) (*ValidatorSet, error)
+	AgentProof(ctx context.Context, key string) (*state.AgentStateProof, error)
+}
+
+// RPCProvider is a Provider backed by the JSON-RPC API of a full node.
+type RPCProvider struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// NewRPCProvider returns a provider querying the JSON-RPC endpoint url,
+// authenticating with apiKey if it is not empty.
+func NewRPCProvider(url, apiKey string) *RPCProvider {
+	return &RPCProvider{url: url, apiKey: apiKey, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// call invokes method with params and decodes its result into result.
+func (p *RPCProvider) call(ctx context.Context, result interface{}, method string, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var out struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return fmt.Errorf("%s: %s", method, resp.Status)
+	}
+	if out.Error != nil {
+		return fmt.Errorf("%s: %s (code %d)", method, out.Error.Message, out.Error.Code)
+	}
+	return json.Unmarshal(out.Result, result)
+}
+
+// LatestHeight returns the height of the latest block of the node.
+func (p *RPCProvider) LatestHeight(ctx context.Context) (uint64, error) {
+	var head string
+	if err := p.call(ctx, &head, "eth_blockNumber"); err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimPrefix(head, "0x"), 16, 64)
+}
+
+// SignedHeader returns the header at height, from zion_getHeader.
+func (p *RPCProvider) SignedHeader(ctx context.Context, height uint64) (*SignedHeader, error) {
+	var res struct {
+		Header string `json:"header"`
+	}
+	if err := p.call(ctx, &res, "zion_getHeader", height); err != nil {
+		return nil, err
+	}
+	raw, err := decodeHex(res.Header)
+	if err != nil {
+		return nil, fmt.Errorf("zion_getHeader: %w", err)
+	}
+	sh := &SignedHeader{}
+	if err := sh.Header.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("zion_getHeader: %w", err)
+	}
+	return sh, nil
+}
+
+// ValidatorSet returns the validator set of the node, from
+// zion_getValidators, or nil if the node reports none.
+func (p *RPCProvider) ValidatorSet(ctx context.Context) (*ValidatorSet, error) {
+	var vals []Validator
+	if err := p.call(ctx, &vals, "zion_getValidators"); err != nil || len(vals) == 0 {
+		return nil, err
+	}
+	return NewValidatorSet(vals)
+}
+
+// AgentProof returns the entry of the agent state under key and its proof,
+// from zion_getAgentProof.
+func (p *RPCProvider) AgentProof(ctx context.Context, key string) (*state.AgentStateProof, error) {
+	var res struct {
+		Root  string          `json:"root"`
+		Key   string          `json:"key"`
+		Value json.RawMessage `json:"value"`
+		Index int             `json:"index"`
+		Total int             `json:"total"`
+		Proof []string        `json:"proof"`
+	}
+	if err := p.call(ctx, &res, "zion_getAgentProof", key); err != nil {
+		return nil, err
+	}
+	proof := &state.AgentStateProof{Key: res.Key, Value: res.Value, Index: res.Index, Total: res.Total}
+	if err := decodeHash(res.Root, &proof.Root); err != nil {
+		return nil, fmt.Errorf("zion_getAgentProof: %w", err)
+	}
+	proof.Proof = make([][32]byte, len(res.Proof))
+	for i, h := range res.Proof {
+		if err := decodeHash(h, &proof.Proof[i]); err != nil {
+			return nil, fmt.Errorf("zion_getAgentProof: %w", err)
+		}
+	}
+	return proof, nil
+}
+
+func decodeHex(s string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(s, "0x"))
+}
+
+func decodeHash(s string, h *[32]byte) error {
+	b, err := decodeHex(s)
+	if err != nil || len(b) != len(h) {
+		return fmt.Errorf("invalid hash %q", s)
+	}
+	copy(h[:], b)
+	return nil
+}
@@ -0,0 +1,357 @@
+// Package node wires the ZionLayer subsystems together and runs them as
+// one process: it starts them in dependency order and shuts them down in
+// reverse, flushing state that must survive a restart.
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/zionlayer/zionlayer/consensus"
+	"github.com/zionlayer/zionlayer/core/chain"
+	"github.com/zionlayer/zionlayer/core/crypto"
+	"github.com/zionlayer/zionlayer/core/event"
+	"github.com/zionlayer/zionlayer/core/genesis"
+	"github.com/zionlayer/zionlayer/core/mempool"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/indexer"
+	"github.com/zionlayer/zionlayer/vm"
+	"go.uber.org/zap"
+)
+
+// MaintenanceInterval is how often the mempool is expired and journaled and
+// old blocks are pruned.
+const MaintenanceInterval = time.Minute
+
+var (
+	ErrAlreadyStarted = errors.New("node: already started")
+	ErrNotStarted     = errors.New("node: not started")
+)
+
+// Service is a network-facing subsystem run by the node, such as the
+// JSON-RPC, REST or gRPC server. Start blocks until the service stops and
+// returns nil when it was stopped by Shutdown.
+type Service interface {
+	Start() error
+	Shutdown(ctx context.Context) error
+}
+
+// Config holds the node parameters that are not owned by a service.
+type Config struct {
+	DataDir    string
+	Validator  string        // proposer address
+	BlockTime  time.Duration // interval between proposed blocks
+	Mempool    mempool.Config
+	KeepBlocks uint64 // recent blocks kept in the chain index, 0 keeps all
+
+	// ValidatorKey signs the headers of the proposed blocks; nil leaves
+	// them unsigned.
+	ValidatorKey crypto.PrivateKey
+
+	// IPFSEndpoint is the HTTP API of an IPFS node pinning the payloads
+	// referenced by finalized blocks; empty disables pinning.
+	IPFSEndpoint string
+
+	// Indexer is the SQL database finalized blocks are copied to for
+	// explorer queries; an empty driver disables it.
+	Indexer indexer.Config
+}
+
+// DefaultConfig returns the default node configuration.
+func DefaultConfig() Config {
+	return Config{
+		DataDir:   "./data",
+		BlockTime: consensus.BlockTime,
+		Mempool:   mempool.DefaultConfig(),
+	}
+}
+
+type service struct {
+	name string
+	svc  Service
+}
+
+// Node owns the chain state and the subsystems built on it.
+type Node struct {
+	State   *state.StateDB
+	Pool    *mempool.Pool
+	Chain   *chain.Store
+	Engine  *consensus.ZionBFT
+	AVM     *vm.AVM
+	Events  *event.Bus
+	ChainID uint64
+
+	// Index is the SQL indexer, nil unless Config.Indexer names a driver.
+	Index *indexer.Indexer
+
+	config   Config
+	logger   *zap.Logger
+	services []service
+	errCh    chan error
+
+	mu       sync.Mutex
+	started  bool
+	stopped  bool
+	cancel   context.CancelFunc
+	indexer  sync.WaitGroup
+	maintain sync.WaitGroup
+	pinner   *pinner
+	pinning  sync.WaitGroup
+	sqlRun   sync.WaitGroup
+	upgrades map[string]UpgradeHandler
+}
+
+// New builds the core subsystems on the state described by gen.
+func New(config Config, gen *genesis.Genesis, logger *zap.Logger) (*Node, error) {
+	if config.BlockTime <= 0 {
+		return nil, fmt.Errorf("node: invalid block time %s", config.BlockTime)
+	}
+	genesisHash, err := gen.Hash()
+	if err != nil {
+		return nil, err
+	}
+	stateDB := state.NewStateDB()
+	if err := gen.Apply(stateDB); err != nil {
+		return nil, err
+	}
+	n := &Node{
+		State:   stateDB,
+		Pool:    mempool.NewPool(gen.ChainID, stateDB, config.Mempool),
+		Chain:   chain.NewStore(),
+		Engine:  consensus.NewZionBFT(stateDB, logger),
+		AVM:     vm.NewAVM(logger),
+		Events:  event.NewBus(),
+		ChainID: gen.ChainID,
+		config:  config,
+		logger:  logger,
+		errCh:   make(chan error, 1),
+	}
+	n.Engine.SetBlockTime(config.BlockTime)
+	n.Engine.SetNetwork(gen.ChainID, genesisHash)
+	n.Engine.SetExecutor(n.AVM)
+	if config.ValidatorKey != nil {
+		n.Engine.SetSigner(config.ValidatorKey)
+	}
+	if config.IPFSEndpoint != "" {
+		n.pinner = newPinner(config.IPFSEndpoint, logger)
+	}
+	if config.Indexer.Driver != "" {
+		ix, err := indexer.Open(config.Indexer, n.Chain, logger)
+		if err != nil {
+			return nil, err
+		}
+		n.Index = ix
+	}
+	n.Pool.SetEventBus(n.Events)
+	n.AVM.SetEventBus(n.Events)
+	n.Pool.SetVerifier(func(tx *transaction.Tx) (string, error) {
+		return n.AVM.VerifyTx(stateDB, tx, gen.ChainID)
+	})
+	return n, nil
+}
+
+// Register adds a service to be started by Start and shut down by Stop.
+// Services start in the order they were registered and stop in reverse.
+func (n *Node) Register(name string, svc Service) {
+	n.services = append(n.services, service{name: name, svc: svc})
+}
+
+// Err returns a channel receiving the first error of a service that
+// stopped on its own.
+func (n *Node) Err() <-chan error {
+	return n.errCh
+}
+
+func (n *Node) journalPath() string {
+	return filepath.Join(n.config.DataDir, mempool.JournalFile)
+}
+
+// Start applies a pending software upgrade, restores the mempool journal,
+// starts consensus and the background loops, and then the registered
+// services. The background loops stop when ctx is cancelled or the node is
+// stopped. Start fails with ErrUpgradeNeeded on a data directory halted
+// for an upgrade this binary does not handle.
+func (n *Node) Start(ctx context.Context) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.started {
+		return ErrAlreadyStarted
+	}
+	if err := n.applyUpgrade(); err != nil {
+		return err
+	}
+	n.started = true
+
+	restored, dropped, err := n.Pool.Load(n.journalPath())
+	if err != nil {
+		n.logger.Warn("mempool journal damaged", zap.Error(err))
+	}
+	n.logger.Info("mempool restored", zap.Int("txs", restored), zap.Int("dropped", dropped))
+
+	ctx, n.cancel = context.WithCancel(ctx)
+	n.Engine.SetKnownUpgrades(n.knownUpgrades()...)
+	blocks := n.Engine.SubscribeBlocks()
+	n.Engine.Start(n.config.Validator, n.Pool)
+	n.indexer.Add(1)
+	go n.indexBlocks(blocks)
+	n.maintain.Add(1)
+	go n.runMaintenance(ctx)
+	if n.pinner != nil {
+		n.pinning.Add(1)
+		go func() {
+			defer n.pinning.Done()
+			n.pinner.run(ctx)
+		}()
+	}
+	if n.Index != nil {
+		n.sqlRun.Add(1)
+		go func() {
+			defer n.sqlRun.Done()
+			n.Index.Run(ctx)
+		}()
+	}
+
+	for _, s := range n.services {
+		s := s
+		go func() {
+			if err := s.svc.Start(); err != nil {
+				select {
+				case n.errCh <- fmt.Errorf("%s: %w", s.name, err):
+				default:
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+// Stop shuts the node down in order: services stop accepting requests,
+// consensus finishes its block in progress, the blocks already produced are
+// indexed, and the mempool is journaled. ctx bounds the whole shutdown.
+func (n *Node) Stop(ctx context.Context) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.started {
+		return ErrNotStarted
+	}
+	if n.stopped {
+		return nil
+	}
+	n.stopped = true
+
+	var errs []error
+	for i := len(n.services) - 1; i >= 0; i-- {
+		s := n.services[i]
+		if err := s.svc.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+		}
+	}
+
+	n.Engine.Stop()
+	if err := wait(ctx, &n.indexer); err != nil {
+		errs = append(errs, fmt.Errorf("block indexer: %w", err))
+	}
+	n.cancel()
+	if err := wait(ctx, &n.maintain); err != nil {
+		errs = append(errs, fmt.Errorf("maintenance: %w", err))
+	}
+	if err := wait(ctx, &n.pinning); err != nil {
+		errs = append(errs, fmt.Errorf("pinning: %w", err))
+	}
+	if n.Index != nil {
+		if err := wait(ctx, &n.sqlRun); err != nil {
+			errs = append(errs, fmt.Errorf("sql indexer: %w", err))
+		} else if err := n.Index.CatchUp(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("sql indexer: %w", err))
+		}
+		if err := n.Index.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("sql indexer: %w", err))
+		}
+	}
+
+	if err := n.Pool.Save(n.journalPath()); err != nil {
+		errs = append(errs, fmt.Errorf("mempool journal: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// wait waits for wg or ctx, whichever is done first.
+func wait(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// indexBlocks indexes finalized blocks, publishes them on the event bus,
+// hands them to the SQL indexer and queues the payloads they reference for
+// pinning, until the engine stops and sub is drained. It then reports an
+// upgrade the engine halted for. If it falls so far behind that sub is
+// disconnected, the blocks missed are never indexed, and it fails the node
+// through Err instead.
+func (n *Node) indexBlocks(sub *consensus.BlockSubscription) {
+	defer n.indexer.Done()
+	for fb := range sub.Blocks() {
+		b := fb.Block
+		n.logger.Info("✅ block finalized",
+			zap.Uint64("height", b.Header.Height),
+			zap.Int("txs", len(b.Txs)),
+			zap.Uint64("gasUsed", b.Header.GasUsed),
+		)
+		n.Chain.Add(b, fb.Receipts)
+		n.Events.NewBlock.Send(event.NewBlock{Block: b, Receipts: fb.Receipts})
+		if n.Index != nil {
+			n.Index.Notify()
+		}
+		if n.pinner != nil {
+			n.pinner.enqueue(b)
+		}
+	}
+	if err := sub.Err(); err != nil {
+		n.logger.Error("block indexing stopped", zap.Error(err))
+		select {
+		case n.errCh <- fmt.Errorf("block indexer: %w", err):
+		default:
+		}
+		return
+	}
+	if plan := n.Engine.Halted(); plan != nil {
+		n.haltForUpgrade(*plan)
+	}
+}
+
+// runMaintenance periodically expires and journals the mempool and prunes
+// old blocks.
+func (n *Node) runMaintenance(ctx context.Context) {
+	defer n.maintain.Done()
+	ticker := time.NewTicker(MaintenanceInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		n.Pool.Prune()
+		if err := n.Pool.Save(n.journalPath()); err != nil {
+			n.logger.Warn("mempool journal write failed", zap.Error(err))
+		}
+		if head, keep := n.Chain.Head(), n.config.KeepBlocks; keep > 0 && head >= keep {
+			if pruned := n.Chain.Prune(head - keep + 1); pruned > 0 {
+				n.logger.Debug("blocks pruned", zap.Int("blocks", pruned))
+			}
+		}
+	}
+}
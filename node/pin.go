@@ -0,0 +1,93 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/zionlayer/zionlayer/core/block"
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"go.uber.org/zap"
+)
+
+const (
+	// PinQueueSize bounds the payloads waiting to be pinned; references
+	// beyond it are dropped rather than stall block indexing.
+	PinQueueSize = 1024
+
+	// PinTimeout bounds one pin request to the IPFS node.
+	PinTimeout = 2 * time.Minute
+)
+
+// pinner pins the IPFS payloads referenced by finalized blocks through the
+// HTTP API of an IPFS node, such as Kubo's /api/v0/pin/add.
+type pinner struct {
+	endpoint string
+	client   *http.Client
+	queue    chan string
+	logger   *zap.Logger
+}
+
+func newPinner(endpoint string, logger *zap.Logger) *pinner {
+	return &pinner{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		client:   &http.Client{Timeout: PinTimeout},
+		queue:    make(chan string, PinQueueSize),
+		logger:   logger,
+	}
+}
+
+// enqueue queues the IPFS payloads referenced by the transactions of b.
+func (p *pinner) enqueue(b *block.Block) {
+	for _, tx := range b.Txs {
+		for _, ref := range tx.DataRefs() {
+			if ref.Network != transaction.DANetworkIPFS {
+				continue
+			}
+			select {
+			case p.queue <- ref.CID:
+			default:
+				p.logger.Warn("pin queue full, payload not pinned", zap.String("cid", ref.CID))
+			}
+		}
+	}
+}
+
+// run pins queued payloads until ctx is cancelled.
+func (p *pinner) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case cid := <-p.queue:
+			if err := p.pin(ctx, cid); err != nil {
+				p.logger.Warn("pin failed", zap.String("cid", cid), zap.Error(err))
+				continue
+			}
+			p.logger.Debug("payload pinned", zap.String("cid", cid))
+		}
+	}
+}
+
+func (p *pinner) pin(ctx context.Context, cid string) error {
+	u := p.endpoint + "/api/v0/pin/add?arg=" + url.QueryEscape(cid)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("ipfs: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
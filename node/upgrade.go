@@ -0,0 +1,132 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"go.uber.org/zap"
+)
+
+// A software upgrade passed by governance halts every node whose binary
+// does not handle it before the block at the upgrade height. The halting
+// node writes the plan to UpgradeInfoFile in its data directory and exits
+// with ErrUpgradeNeeded. Process managers such as cosmovisor watch for the
+// file to swap in the binary named after the upgrade. A binary refuses to
+// start on a data directory halted for an upgrade it has no handler for.
+// The binary that does runs the handler once, to migrate the state, and
+// marks the upgrade applied in the file before resuming.
+
+// UpgradeInfoFile is the name of the upgrade metadata in the data
+// directory.
+const UpgradeInfoFile = "upgrade-info.json"
+
+var ErrUpgradeNeeded = errors.New("node: upgrade needed")
+
+// UpgradeHandler migrates the state for the upgrade plan when the binary
+// handling it first starts on a data directory halted for it.
+type UpgradeHandler func(s *state.StateDB, plan transaction.UpgradePlan) error
+
+// UpgradeInfo is the content of UpgradeInfoFile. Name, Height and Info
+// are laid out as cosmovisor expects them.
+type UpgradeInfo struct {
+	transaction.UpgradePlan
+	Applied bool `json:"applied,omitempty"` // by the handler of the new binary
+}
+
+// RegisterUpgrade declares that this binary handles the upgrade name, with
+// handler migrating the state. It must be called before Start.
+func (n *Node) RegisterUpgrade(name string, handler UpgradeHandler) {
+	if n.upgrades == nil {
+		n.upgrades = make(map[string]UpgradeHandler)
+	}
+	n.upgrades[name] = handler
+}
+
+func (n *Node) upgradeInfoPath() string {
+	return filepath.Join(n.config.DataDir, UpgradeInfoFile)
+}
+
+// ReadUpgradeInfo returns the upgrade metadata of the data directory
+// dataDir, or nil if no node there halted for an upgrade.
+func ReadUpgradeInfo(dataDir string) (*UpgradeInfo, error) {
+	raw, err := os.ReadFile(filepath.Join(dataDir, UpgradeInfoFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var info UpgradeInfo
+	if err := json.Unmarshal(raw, &info); err != nil {
+		return nil, fmt.Errorf("%s: %w", UpgradeInfoFile, err)
+	}
+	return &info, nil
+}
+
+func (n *Node) writeUpgradeInfo(info UpgradeInfo) error {
+	raw, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(n.config.DataDir, 0o755); err != nil {
+		return err
+	}
+	tmp := n.upgradeInfoPath() + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, n.upgradeInfoPath())
+}
+
+// applyUpgrade validates the upgrade metadata of the data directory
+// against the upgrades this binary handles, running the handler of an
+// upgrade not yet applied.
+func (n *Node) applyUpgrade() error {
+	info, err := ReadUpgradeInfo(n.config.DataDir)
+	if err != nil || info == nil || info.Applied {
+		return err
+	}
+	handler, ok := n.upgrades[info.Name]
+	if !ok {
+		return fmt.Errorf("%w: %q at height %d; start the binary of the upgrade", ErrUpgradeNeeded, info.Name, info.Height)
+	}
+	if handler != nil {
+		if err := handler(n.State, info.UpgradePlan); err != nil {
+			return fmt.Errorf("node: upgrade %q: %w", info.Name, err)
+		}
+	}
+	info.Applied = true
+	if err := n.writeUpgradeInfo(*info); err != nil {
+		return fmt.Errorf("node: upgrade %q: %w", info.Name, err)
+	}
+	n.logger.Info("upgrade applied", zap.String("upgrade", info.Name), zap.Uint64("height", info.Height))
+	return nil
+}
+
+// knownUpgrades returns the names of the upgrades this binary handles.
+func (n *Node) knownUpgrades() []string {
+	names := make([]string, 0, len(n.upgrades))
+	for name := range n.upgrades {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// haltForUpgrade records the upgrade the engine halted for in the data
+// directory and reports it as the error of the node.
+func (n *Node) haltForUpgrade(plan transaction.UpgradePlan) {
+	if err := n.writeUpgradeInfo(UpgradeInfo{UpgradePlan: plan}); err != nil {
+		n.logger.Error("upgrade info write failed", zap.Error(err))
+	}
+	select {
+	case n.errCh <- fmt.Errorf("%w: %q at height %d", ErrUpgradeNeeded, plan.Name, plan.Height):
+	default:
+	}
+}
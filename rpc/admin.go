@@ -0,0 +1,161 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+
+	"github.com/zionlayer/zionlayer/core/state"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// The admin_ namespace exposes node operations. It is only served by a
+// Server whose Config.Namespaces includes NamespaceAdmin, which ziond does
+// on the separate, authenticated admin listener.
+
+// ErrNoPeerManager is returned by the peer methods when the node runs
+// without networking.
+var ErrNoPeerManager = errors.New("peer-to-peer networking is not enabled")
+
+// PeerInfo describes a connected peer.
+type PeerInfo struct {
+	ID      string `json:"id"`
+	Address string `json:"address"`
+	Inbound bool   `json:"inbound"`
+}
+
+// PeerManager is the networking layer as seen by admin_ methods.
+type PeerManager interface {
+	Peers() []PeerInfo
+	AddPeer(addr string) error
+	RemovePeer(id string) error
+}
+
+// SetPeerManager enables admin_peers, admin_addPeer and admin_removePeer.
+func (s *Server) SetPeerManager(pm PeerManager) {
+	s.peers = pm
+}
+
+// SetLogLevel lets admin_setLogLevel change level at runtime.
+func (s *Server) SetLogLevel(level zap.AtomicLevel) {
+	s.logLevel = &level
+}
+
+// SnapshotDir is the directory, under Config.DataDir, that
+// admin_exportSnapshot writes to.
+const SnapshotDir = "snapshots"
+
+// dispatchAdmin handles admin_ methods. ok is false for unknown methods.
+func (s *Server) dispatchAdmin(method string, params json.RawMessage) (result interface{}, rpcErr *RPCError, ok bool) {
+	switch method {
+	case "admin_peers":
+		if s.peers == nil {
+			return nil, errorFrom(ErrNoPeerManager), true
+		}
+		return s.peers.Peers(), nil, true
+	case "admin_addPeer", "admin_removePeer":
+		if s.peers == nil {
+			return nil, errorFrom(ErrNoPeerManager), true
+		}
+		var args []string
+		if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+			return nil, invalidParams("invalid params"), true
+		}
+		var err error
+		if method == "admin_addPeer" {
+			err = s.peers.AddPeer(args[0])
+		} else {
+			err = s.peers.RemovePeer(args[0])
+		}
+		if err != nil {
+			return nil, errorFrom(err), true
+		}
+		return true, nil, true
+	case "admin_flushMempool":
+		n := s.pool.Flush()
+		s.logger.Warn("mempool flushed by admin", zap.Int("txs", n))
+		return map[string]int{"removed": n}, nil, true
+	case "admin_setLogLevel":
+		result, rpcErr = s.setLogLevel(params)
+	case "admin_compact":
+		result = s.compact()
+	case "admin_exportSnapshot":
+		result, rpcErr = s.exportSnapshot()
+	case "admin_cacheStats":
+		result = s.cacheStats()
+	default:
+		return nil, nil, false
+	}
+	return result, rpcErr, true
+}
+
+// setLogLevel takes [level], one of debug, info, warn or error, and returns
+// the previous level.
+func (s *Server) setLogLevel(params json.RawMessage) (interface{}, *RPCError) {
+	if s.logLevel == nil {
+		return nil, &RPCError{Code: CodeServerError, Message: "log level is not adjustable"}
+	}
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(args[0])); err != nil {
+		return nil, invalidParams(fmt.Sprintf("invalid log level %q", args[0]))
+	}
+	prev := s.logLevel.Level()
+	s.logLevel.SetLevel(level)
+	s.logger.Info("log level changed by admin", zap.Stringer("from", prev), zap.Stringer("to", level))
+	return prev.String(), nil
+}
+
+// compact drops dead mempool entries and idle log filters, then returns
+// freed memory to the operating system. State and blocks are held in
+// memory, so there is no on-disk store to compact.
+func (s *Server) compact() interface{} {
+	before := s.pool.Size()
+	s.pool.Prune()
+	filters := s.filters.expire(time.Now())
+	debug.FreeOSMemory()
+	return map[string]int{
+		"mempoolPruned":  before - s.pool.Size(),
+		"filtersExpired": filters,
+	}
+}
+
+// exportSnapshot writes the canonical encoding of the current state to a
+// new file under Config.DataDir and returns its path and root.
+func (s *Server) exportSnapshot() (interface{}, *RPCError) {
+	if s.config.DataDir == "" {
+		return nil, &RPCError{Code: CodeServerError, Message: "no data directory configured"}
+	}
+	data, err := s.state.Snapshot()
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	root, entries, err := state.SnapshotRoot(data)
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	dir := filepath.Join(s.config.DataDir, SnapshotDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errorFrom(err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("state-%d-%d.snap", s.chain.Head(), time.Now().Unix()))
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return nil, errorFrom(err)
+	}
+	s.logger.Info("state snapshot exported", zap.String("path", path))
+	return map[string]interface{}{
+		"path":    path,
+		"height":  s.chain.Head(),
+		"size":    len(data),
+		"entries": entries,
+		"root":    fmt.Sprintf("0x%x", root),
+	}, nil
+}
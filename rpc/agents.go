@@ -0,0 +1,262 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zionlayer/zionlayer/core/did"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// getAgentHistory handles zion_getAgentHistory(did), returning the
+// registration, deactivations and reactivations of an agent, oldest first.
+func (s *Server) getAgentHistory(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	rec, err := s.state.GetAgent(args[0])
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return rec.Lifecycle, nil
+}
+
+// getReputation handles zion_getReputation(did).
+func (s *Server) getReputation(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	rep, err := s.state.GetReputation(args[0])
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return rep, nil
+}
+
+// getDelegations handles zion_getDelegations(did), listing the
+// delegations the agent received, expired ones included. Given an account
+// address instead of a DID, it returns the account's stake delegations;
+// see staking.go.
+func (s *Server) getDelegations(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	if !strings.HasPrefix(args[0], did.Prefix) {
+		return s.stakeDelegations(args[0]), nil
+	}
+	return s.state.Delegations(args[0]), nil
+}
+
+// AgentProofResult is the result of zion_getAgentProof. Proof lists the
+// sibling hashes from the leaf up; Value is the JSON encoding of the entry
+// committed to.
+type AgentProofResult struct {
+	Root  string          `json:"root"`
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+	Index int             `json:"index"`
+	Total int             `json:"total"`
+	Proof []string        `json:"proof"`
+}
+
+// getAgentProof handles zion_getAgentProof(key), proving an entry of the
+// agent state under the current agent root, which is the AgentRoot of the
+// next block. key is an agent state key such as "mailbox/<did>", or a DID
+// standing for "agent/<did>".
+func (s *Server) getAgentProof(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	key := args[0]
+	if strings.HasPrefix(key, did.Prefix) {
+		key = state.AgentKey(key)
+	}
+	p, err := s.state.AgentProof(key)
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	proof := make([]string, len(p.Proof))
+	for i, h := range p.Proof {
+		proof[i] = fmt.Sprintf("0x%x", h)
+	}
+	return &AgentProofResult{
+		Root:  fmt.Sprintf("0x%x", p.Root),
+		Key:   p.Key,
+		Value: p.Value,
+		Index: p.Index,
+		Total: p.Total,
+		Proof: proof,
+	}, nil
+}
+
+// DelegationCheck is the result of zion_verifyDelegation. Chain leads from
+// the delegation the agent received to the agent that declared the
+// capability; it is empty when the agent declared it itself.
+type DelegationCheck struct {
+	Valid  bool                     `json:"valid"`
+	Chain  []transaction.Delegation `json:"chain,omitempty"`
+	Reason string                   `json:"reason,omitempty"`
+	Height uint64                   `json:"height"`
+}
+
+// verifyDelegation handles zion_verifyDelegation(did, capability), where
+// capability is {name, version}, checking whether the agent holds the
+// capability at the chain head.
+func (s *Server) verifyDelegation(params json.RawMessage) (interface{}, *RPCError) {
+	var args []json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil || len(args) < 2 {
+		return nil, invalidParams("invalid params")
+	}
+	var id string
+	var capability transaction.Capability
+	if err := json.Unmarshal(args[0], &id); err != nil {
+		return nil, invalidParams("invalid did")
+	}
+	if err := json.Unmarshal(args[1], &capability); err != nil || capability.Name == "" {
+		return nil, invalidParams("invalid capability")
+	}
+	height := s.chain.Head()
+	chain, err := s.state.VerifyDelegation(id, capability, height)
+	if err != nil {
+		return DelegationCheck{Reason: err.Error(), Height: height}, nil
+	}
+	return DelegationCheck{Valid: true, Chain: chain, Height: height}, nil
+}
+
+// resolveDID handles zion_resolveDID(did). Resolution failures are
+// reported in the didResolutionMetadata of the result, as DID resolvers do,
+// rather than as JSON-RPC errors.
+func (s *Server) resolveDID(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	return did.Resolve(s.state, args[0]), nil
+}
+
+// listAgents handles zion_listAgents([page]).
+func (s *Server) listAgents(params json.RawMessage) (interface{}, *RPCError) {
+	var args []json.RawMessage
+	if len(params) > 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, invalidParams("invalid params")
+		}
+	}
+	var page PageArgs
+	if len(args) > 0 {
+		var rpcErr *RPCError
+		if page, rpcErr = parsePage(args[0]); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+	agents, next, total := s.state.ListAgents(page.Cursor, page.limit())
+	return newPage(agents, next, page, total), nil
+}
+
+// getAgentsByController handles zion_getAgentsByController(address, [page]).
+func (s *Server) getAgentsByController(params json.RawMessage) (interface{}, *RPCError) {
+	var args []json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	var addr string
+	if err := json.Unmarshal(args[0], &addr); err != nil {
+		return nil, invalidParams("invalid address")
+	}
+	controller, rpcErr := parseAddress(addr)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	var page PageArgs
+	if len(args) > 1 {
+		if page, rpcErr = parsePage(args[1]); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+	agents, next, total := s.state.AgentsByController(controller, page.Cursor, page.limit())
+	return newPage(agents, next, page, total), nil
+}
+
+// SearchAgentsArgs are the parameters of zion_searchAgents.
+type SearchAgentsArgs struct {
+	Capability string `json:"capability"`
+	PageArgs
+}
+
+// searchAgents handles zion_searchAgents({capability, cursor, limit}),
+// listing the agents that advertise a capability.
+func (s *Server) searchAgents(params json.RawMessage) (interface{}, *RPCError) {
+	var args []SearchAgentsArgs
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 || args[0].Capability == "" {
+		return nil, invalidParams("invalid params")
+	}
+	a := args[0]
+	agents, next, total := s.state.AgentsByCapability(a.Capability, a.Cursor, a.limit())
+	return newPage(agents, next, a.PageArgs, total), nil
+}
+
+// AgentMessageEntry is a stored agent message and its position in the
+// message log.
+type AgentMessageEntry struct {
+	Seq uint64 `json:"seq"`
+	transaction.AgentMessage
+}
+
+// getAgentMessages handles zion_getAgentMessages(did, [direction], [page]).
+// direction is "in", "out" or "all" (the default). Messages are returned
+// oldest first; real-time delivery is available through the agentMessages
+// subscription.
+func (s *Server) getAgentMessages(params json.RawMessage) (interface{}, *RPCError) {
+	var args []json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	var did string
+	if err := json.Unmarshal(args[0], &did); err != nil || did == "" {
+		return nil, invalidParams("invalid did")
+	}
+	dir := state.MessagesAll
+	if len(args) > 1 && string(args[1]) != "null" {
+		var d string
+		if err := json.Unmarshal(args[1], &d); err != nil {
+			return nil, invalidParams("invalid direction")
+		}
+		switch d {
+		case "in":
+			dir = state.MessagesIn
+		case "out":
+			dir = state.MessagesOut
+		case "all", "":
+		default:
+			return nil, invalidParams(fmt.Sprintf("invalid direction %q", d))
+		}
+	}
+	var page PageArgs
+	if len(args) > 2 {
+		var rpcErr *RPCError
+		if page, rpcErr = parsePage(args[2]); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+	start, rpcErr := page.offset()
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	msgs, seqs, next, total := s.state.AgentMessages(did, dir, uint64(start), page.limit())
+	entries := make([]AgentMessageEntry, len(msgs))
+	for i, m := range msgs {
+		entries[i] = AgentMessageEntry{Seq: seqs[i], AgentMessage: m}
+	}
+	cursor := ""
+	if next != 0 {
+		cursor = strconv.FormatUint(next, 10)
+	}
+	return newPage(entries, cursor, page, total), nil
+}
@@ -0,0 +1,120 @@
+package rpc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Method namespaces. A method belongs to the namespace before its first
+// underscore, e.g. zion_getBalance to NamespaceZion.
+const (
+	NamespaceZion  = "zion"
+	NamespaceEth   = "eth"
+	NamespaceAdmin = "admin"
+)
+
+// PublicNamespaces are the namespaces served on the public listener.
+var PublicNamespaces = []string{NamespaceZion, NamespaceEth}
+
+var (
+	errUnauthorized = errors.New("unauthorized")
+	errTokenExpired = errors.New("token expired")
+)
+
+// jwtLeeway tolerates clock skew when checking token times.
+const jwtLeeway = 30 * time.Second
+
+// namespaceAllowed reports whether method may be called on this server.
+func (s *Server) namespaceAllowed(method string) bool {
+	ns, _, ok := strings.Cut(method, "_")
+	if !ok {
+		return false
+	}
+	for _, allowed := range s.config.Namespaces {
+		if ns == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticate checks the credentials of r. With neither API keys nor a
+// JWT secret configured every request is accepted. Otherwise the request
+// must carry "Authorization: Bearer <credential>" or "X-API-Key", where the
+// credential is one of the API keys or an HS256 JWT signed with the secret.
+func (s *Server) authenticate(r *http.Request) error {
+	if len(s.config.APIKeys) == 0 && len(s.config.JWTSecret) == 0 {
+		return nil
+	}
+	cred := r.Header.Get("X-API-Key")
+	if cred == "" {
+		auth := r.Header.Get("Authorization")
+		if len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+			cred = strings.TrimSpace(auth[7:])
+		}
+	}
+	if cred == "" {
+		return errUnauthorized
+	}
+	for _, key := range s.config.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(cred), []byte(key)) == 1 {
+			return nil
+		}
+	}
+	if len(s.config.JWTSecret) > 0 && strings.Count(cred, ".") == 2 {
+		return verifyJWT(cred, s.config.JWTSecret, time.Now())
+	}
+	return errUnauthorized
+}
+
+// verifyJWT checks an HS256 JSON Web Token and its exp and nbf claims.
+func verifyJWT(token string, secret []byte, now time.Time) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errUnauthorized
+	}
+	hdrJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return errUnauthorized
+	}
+	var hdr struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(hdrJSON, &hdr); err != nil || hdr.Alg != "HS256" {
+		return errUnauthorized
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return errUnauthorized
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return errUnauthorized
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return errUnauthorized
+	}
+	var claims struct {
+		Exp *int64 `json:"exp"`
+		Nbf *int64 `json:"nbf"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return errUnauthorized
+	}
+	if claims.Exp != nil && now.After(time.Unix(*claims.Exp, 0).Add(jwtLeeway)) {
+		return errTokenExpired
+	}
+	if claims.Nbf != nil && now.Add(jwtLeeway).Before(time.Unix(*claims.Nbf, 0)) {
+		return errUnauthorized
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/zionlayer/zionlayer/core/block"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// BlockResult is the block representation returned by zion_getBlockByHeight
+// and zion_getBlockByHash. Txs holds transaction hashes, or full
+// transactions when requested.
+type BlockResult struct {
+	Hash        string        `json:"hash"`
+	Height      uint64        `json:"height"`
+	Version     uint32        `json:"version"`
+	ChainID     uint64        `json:"chainId"`
+	GenesisHash string        `json:"genesisHash"`
+	Timestamp   int64         `json:"timestamp"`
+	PrevHash    string        `json:"prevHash"`
+	StateRoot   string        `json:"stateRoot"`
+	TxRoot      string        `json:"txRoot"`
+	ReceiptRoot string        `json:"receiptRoot"`
+	AgentRoot   string        `json:"agentRoot"`
+	GasUsed     uint64        `json:"gasUsed"`
+	Txs         []interface{} `json:"txs"`
+	Commit      CommitInfo    `json:"commit"`
+}
+
+// CommitInfo describes how a block was committed. ZionBFT blocks are final
+// once committed, so Finalized is set for every stored block.
+type CommitInfo struct {
+	Validator     string `json:"validator"`
+	Signature     string `json:"signature"`
+	Finalized     bool   `json:"finalized"`
+	Confirmations uint64 `json:"confirmations"`
+}
+
+// TxResult is a full transaction inside a BlockResult.
+type TxResult struct {
+	*transaction.Tx
+	Hash string `json:"hash"`
+}
+
+// TransactionResult is an included transaction as returned by
+// zion_getTransaction. Receipt is nil if the transaction was not executed.
+type TransactionResult struct {
+	TxResult
+	BlockHash   string               `json:"blockHash"`
+	BlockHeight uint64               `json:"blockHeight"`
+	Index       int                  `json:"index"`
+	Receipt     *transaction.Receipt `json:"receipt"`
+}
+
+// getTransaction takes [hash].
+func (s *Server) getTransaction(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	raw, err := hex.DecodeString(strings.TrimPrefix(args[0], "0x"))
+	if err != nil || len(raw) != 32 {
+		return nil, invalidParams("invalid transaction hash")
+	}
+	var hash [32]byte
+	copy(hash[:], raw)
+	tx, loc, err := s.chain.Transaction(hash)
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	res := &TransactionResult{
+		TxResult:    TxResult{Tx: tx, Hash: fmt.Sprintf("0x%x", hash)},
+		BlockHash:   fmt.Sprintf("0x%x", loc.BlockHash),
+		BlockHeight: loc.BlockHeight,
+		Index:       loc.Index,
+	}
+	if r, err := s.chain.Receipt(hash); err == nil {
+		res.Receipt = r
+	}
+	return res, nil
+}
+
+// TxProofResult is returned by zion_getTransactionProof. Tx is the
+// canonical encoding of the transaction, and Proof lists the sibling hashes
+// from its leaf up to Root, the TxRoot of the block.
+type TxProofResult struct {
+	BlockHash   string   `json:"blockHash"`
+	BlockHeight uint64   `json:"blockHeight"`
+	Root        string   `json:"root"`
+	Tx          string   `json:"tx"`
+	Index       int      `json:"index"`
+	Total       int      `json:"total"`
+	Proof       []string `json:"proof"`
+}
+
+// getTransactionProof takes [hash] and proves the inclusion of the
+// transaction in its block.
+func (s *Server) getTransactionProof(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	raw, err := hex.DecodeString(strings.TrimPrefix(args[0], "0x"))
+	if err != nil || len(raw) != 32 {
+		return nil, invalidParams("invalid transaction hash")
+	}
+	var hash [32]byte
+	copy(hash[:], raw)
+	_, loc, err := s.chain.Transaction(hash)
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	b, err := s.chain.BlockByHeight(loc.BlockHeight)
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	p, err := b.TxProof(loc.Index)
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	proof := make([]string, len(p.Proof))
+	for i, h := range p.Proof {
+		proof[i] = fmt.Sprintf("0x%x", h)
+	}
+	return &TxProofResult{
+		BlockHash:   fmt.Sprintf("0x%x", loc.BlockHash),
+		BlockHeight: loc.BlockHeight,
+		Root:        fmt.Sprintf("0x%x", p.Root),
+		Tx:          fmt.Sprintf("0x%x", p.Tx),
+		Index:       p.Index,
+		Total:       p.Total,
+		Proof:       proof,
+	}, nil
+}
+
+// getBlockByHeight takes [height, fullTxs?].
+func (s *Server) getBlockByHeight(params json.RawMessage) (interface{}, *RPCError) {
+	var args []json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	var height uint64
+	if err := json.Unmarshal(args[0], &height); err != nil {
+		return nil, invalidParams("invalid height")
+	}
+	full, rpcErr := parseFullTxs(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	b, err := s.chain.BlockByHeight(height)
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return s.blockResult(b, full), nil
+}
+
+// getBlockByHash takes [hash, fullTxs?].
+func (s *Server) getBlockByHash(params json.RawMessage) (interface{}, *RPCError) {
+	var args []json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	var hexHash string
+	if err := json.Unmarshal(args[0], &hexHash); err != nil {
+		return nil, invalidParams("invalid hash")
+	}
+	raw, err := hex.DecodeString(strings.TrimPrefix(hexHash, "0x"))
+	if err != nil || len(raw) != 32 {
+		return nil, invalidParams("invalid hash")
+	}
+	full, rpcErr := parseFullTxs(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	var hash [32]byte
+	copy(hash[:], raw)
+	b, err := s.chain.BlockByHash(hash)
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return s.blockResult(b, full), nil
+}
+
+func parseFullTxs(args []json.RawMessage) (bool, *RPCError) {
+	var full bool
+	if len(args) > 1 {
+		if err := json.Unmarshal(args[1], &full); err != nil {
+			return false, invalidParams("invalid fullTxs flag")
+		}
+	}
+	return full, nil
+}
+
+func (s *Server) blockResult(b *block.Block, full bool) *BlockResult {
+	h := b.Header
+	res := &BlockResult{
+		Hash:        fmt.Sprintf("0x%x", b.Hash()),
+		Height:      h.Height,
+		Version:     h.Version,
+		ChainID:     h.ChainID,
+		GenesisHash: fmt.Sprintf("0x%x", h.GenesisHash),
+		Timestamp:   h.Timestamp,
+		PrevHash:    fmt.Sprintf("0x%x", h.PrevHash),
+		StateRoot:   fmt.Sprintf("0x%x", h.StateRoot),
+		TxRoot:      fmt.Sprintf("0x%x", h.TxRoot),
+		ReceiptRoot: fmt.Sprintf("0x%x", h.ReceiptRoot),
+		AgentRoot:   fmt.Sprintf("0x%x", h.AgentRoot),
+		GasUsed:     h.GasUsed,
+		Txs:         make([]interface{}, 0, len(b.Txs)),
+		Commit: CommitInfo{
+			Validator: string(h.ValidatorAddr),
+			Signature: fmt.Sprintf("0x%x", h.Signature),
+			Finalized: true,
+		},
+	}
+	if head := s.chain.Head(); head >= h.Height {
+		res.Commit.Confirmations = head - h.Height + 1
+	}
+	for _, tx := range b.Txs {
+		hash := fmt.Sprintf("0x%x", tx.Hash())
+		if full {
+			res.Txs = append(res.Txs, TxResult{Tx: tx, Hash: hash})
+		} else {
+			res.Txs = append(res.Txs, hash)
+		}
+	}
+	return res
+}
+
+// HeaderResult is returned by zion_getHeader. Header is the canonical RLP
+// encoding of the block header, from which light clients recompute Hash
+// and check the proposer signature.
+type HeaderResult struct {
+	Hash   string `json:"hash"`
+	Height uint64 `json:"height"`
+	Header string `json:"header"`
+}
+
+// getHeader takes [height] and returns the encoded header of the block.
+func (s *Server) getHeader(params json.RawMessage) (interface{}, *RPCError) {
+	var args []uint64
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	b, err := s.chain.BlockByHeight(args[0])
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	raw, err := b.Header.MarshalBinary()
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return &HeaderResult{
+		Hash:   fmt.Sprintf("0x%x", b.Hash()),
+		Height: b.Header.Height,
+		Header: fmt.Sprintf("0x%x", raw),
+	}, nil
+}
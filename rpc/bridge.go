@@ -0,0 +1,124 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/zionlayer/zionlayer/core/state"
+)
+
+// BridgeResult is the result of zion_getBridge.
+type BridgeResult struct {
+	Params        state.BridgeParams `json:"params"`
+	Locked        string             `json:"locked"`        // ZIO in the bridge escrow
+	WrappedSupply map[string]string  `json:"wrappedSupply"` // by token contract
+	Withdrawals   int                `json:"withdrawals"`
+}
+
+// BridgeWithdrawalProof is the result of zion_getBridgeWithdrawalProof:
+// the proof of a withdrawal against the AgentRoot of the header at Height,
+// the next block, for the bridge contract of the external chain.
+type BridgeWithdrawalProof struct {
+	Withdrawal *state.BridgeWithdrawal `json:"withdrawal"`
+	Height     uint64                  `json:"height"`
+	AgentProofResult
+}
+
+// getBridge handles zion_getBridge(), returning the bridge parameters and
+// the tokens it holds.
+func (s *Server) getBridge(json.RawMessage) (interface{}, *RPCError) {
+	supply := make(map[string]string)
+	for token, amount := range s.state.BridgeWrappedSupply() {
+		supply[token] = amount.String()
+	}
+	return &BridgeResult{
+		Params:        s.state.GetBridgeParams(),
+		Locked:        s.state.BridgeLocked().String(),
+		WrappedSupply: supply,
+		Withdrawals:   len(s.state.BridgeWithdrawals()),
+	}, nil
+}
+
+// getBridgeDeposit handles zion_getBridgeDeposit(key), returning the
+// deposit record of a content hash, or the executed record of a deposit
+// event "<txHash>:<logIndex>".
+func (s *Server) getBridgeDeposit(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	rec, err := s.state.GetBridgeDeposit(args[0])
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return rec, nil
+}
+
+// getBridgeWithdrawals handles zion_getBridgeWithdrawals([page]), listing
+// the withdrawals in nonce order.
+func (s *Server) getBridgeWithdrawals(params json.RawMessage) (interface{}, *RPCError) {
+	var args []json.RawMessage
+	if len(params) > 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, invalidParams("invalid params")
+		}
+	}
+	var page PageArgs
+	if len(args) > 0 {
+		var rpcErr *RPCError
+		if page, rpcErr = parsePage(args[0]); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+	return paginate(s.state.BridgeWithdrawals(), page)
+}
+
+// getBridgeWithdrawalProof handles zion_getBridgeWithdrawalProof(nonce).
+func (s *Server) getBridgeWithdrawalProof(params json.RawMessage) (interface{}, *RPCError) {
+	var args []uint64
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	w, err := s.state.GetBridgeWithdrawal(args[0])
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	p, err := s.state.AgentProof(state.BridgeWithdrawalKey(w.Nonce))
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	proof := make([]string, len(p.Proof))
+	for i, h := range p.Proof {
+		proof[i] = fmt.Sprintf("0x%x", h)
+	}
+	return &BridgeWithdrawalProof{
+		Withdrawal: w,
+		Height:     s.chain.Head() + 1,
+		AgentProofResult: AgentProofResult{
+			Root:  fmt.Sprintf("0x%x", p.Root),
+			Key:   p.Key,
+			Value: p.Value,
+			Index: p.Index,
+			Total: p.Total,
+			Proof: proof,
+		},
+	}, nil
+}
+
+// getBridgeBalances handles zion_getBridgeBalances(address), returning the
+// wrapped token balances of the address by token contract.
+func (s *Server) getBridgeBalances(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	addr, rpcErr := parseAddress(args[0])
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	out := make(map[string]string)
+	for token, bal := range s.state.BridgeBalances(addr) {
+		out[token] = bal.String()
+	}
+	return out, nil
+}
@@ -0,0 +1,38 @@
+package rpc
+
+import (
+	"github.com/zionlayer/zionlayer/core/cache"
+	"github.com/zionlayer/zionlayer/core/state"
+)
+
+// AccountCacheSize is the number of account reads cached by the server.
+const AccountCacheSize = 16384
+
+// accountKey caches an account as of a chain head. Entries of past heads
+// are never served again and age out of the cache, so writes to the state
+// need not invalidate it; reads may lag the state by the block being
+// applied.
+type accountKey struct {
+	head uint64
+	addr string
+}
+
+// account returns the balance and nonce of addr through the account cache.
+func (s *Server) account(addr string) state.Account {
+	key := accountKey{s.chain.Head(), addr}
+	if acc, ok := s.accounts.Get(key); ok {
+		return acc
+	}
+	acc := *s.state.GetAccount(addr)
+	acc.Code, acc.Storage = nil, nil
+	s.accounts.Add(key, acc)
+	return acc
+}
+
+// cacheStats handles admin_cacheStats, the counters of the block, receipt
+// and account caches.
+func (s *Server) cacheStats() map[string]cache.Stats {
+	stats := s.chain.CacheStats()
+	stats["accounts"] = s.accounts.Stats()
+	return stats
+}
@@ -0,0 +1,87 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/zionlayer/zionlayer/consensus"
+)
+
+// ErrNoEngine is returned by consensus methods when the server was not
+// given the consensus engine.
+var ErrNoEngine = errors.New("consensus engine not available")
+
+// ValidatorResult is a validator as returned by zion_getValidators. Stake
+// is a decimal string in base units.
+type ValidatorResult struct {
+	Address     string  `json:"address"`
+	PublicKey   string  `json:"publicKey"`
+	Stake       string  `json:"stake"`
+	PoIScore    float64 `json:"poiScore"`
+	VotingPower int64   `json:"votingPower"`
+}
+
+// ValidatorStatus is returned by zion_getValidatorStatus. Validator is nil
+// when the address is not in the validator set.
+type ValidatorStatus struct {
+	Address     string                `json:"address"`
+	Validator   *ValidatorResult      `json:"validator"`
+	Signing     consensus.SigningInfo `json:"signing"`
+	Height      uint64                `json:"height"`
+	Jailed      bool                  `json:"jailed"`
+	JailedUntil uint64                `json:"jailedUntil,omitempty"` // first height it may unjail at
+}
+
+// SetEngine enables zion_getValidators and zion_getValidatorStatus.
+func (s *Server) SetEngine(engine *consensus.ZionBFT) {
+	s.engine = engine
+}
+
+func (s *Server) getValidators() (interface{}, *RPCError) {
+	if s.engine == nil {
+		return nil, errorFrom(ErrNoEngine)
+	}
+	vals := s.engine.Validators()
+	out := make([]ValidatorResult, len(vals))
+	for i, v := range vals {
+		out[i] = newValidatorResult(v)
+	}
+	return out, nil
+}
+
+func newValidatorResult(v *consensus.Validator) ValidatorResult {
+	return ValidatorResult{
+		Address:     v.Address,
+		PublicKey:   fmt.Sprintf("0x%x", v.PublicKey),
+		Stake:       v.Stake.String(),
+		PoIScore:    v.PoIScore,
+		VotingPower: v.VotingPower,
+	}
+}
+
+func (s *Server) getValidatorStatus(params json.RawMessage) (interface{}, *RPCError) {
+	if s.engine == nil {
+		return nil, errorFrom(ErrNoEngine)
+	}
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) < 1 {
+		return nil, invalidParams("invalid params")
+	}
+	addr, rpcErr := parseAddress(args[0])
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	status := ValidatorStatus{Address: addr, Signing: s.engine.SigningInfo(addr), Height: s.chain.Head()}
+	if sv, err := s.state.GetStakingValidator(addr); err == nil {
+		status.Jailed, status.JailedUntil = sv.Jailed, sv.JailedUntil
+	}
+	for _, v := range s.engine.Validators() {
+		if v.Address == addr {
+			res := newValidatorResult(v)
+			status.Validator = &res
+			break
+		}
+	}
+	return status, nil
+}
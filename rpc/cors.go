@@ -0,0 +1,42 @@
+package rpc
+
+import (
+	"net/http"
+	"strings"
+)
+
+// corsHeaders sets the CORS response headers for r. Only origins listed in
+// Config.CORSOrigins, or any origin when the list holds "*", are allowed;
+// without a list no CORS headers are sent and browsers apply the
+// same-origin policy. It reports whether r was a preflight request, which
+// needs no further handling.
+func (s *Server) corsHeaders(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	allowed := ""
+	for _, o := range s.config.CORSOrigins {
+		if o == "*" {
+			allowed = "*"
+			break
+		}
+		if strings.EqualFold(o, origin) {
+			allowed = origin
+			break
+		}
+	}
+	if allowed == "" {
+		return r.Method == http.MethodOptions
+	}
+	h.Set("Access-Control-Allow-Origin", allowed)
+	if r.Method != http.MethodOptions {
+		return false
+	}
+	h.Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS")
+	h.Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
+	h.Set("Access-Control-Max-Age", "600")
+	return true
+}
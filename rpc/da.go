@@ -0,0 +1,14 @@
+package rpc
+
+import "encoding/json"
+
+// getDataAttestations handles zion_getDataAttestations(network, cid),
+// listing the attestations in force of pinning providers retaining an
+// off-chain payload.
+func (s *Server) getDataAttestations(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) < 2 {
+		return nil, invalidParams("invalid params")
+	}
+	return s.state.DataAttestations(args[0], args[1]), nil
+}
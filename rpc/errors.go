@@ -0,0 +1,218 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zionlayer/zionlayer/core/chain"
+	"github.com/zionlayer/zionlayer/core/mempool"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/vm"
+)
+
+// Standard JSON-RPC 2.0 error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Server error codes. -32000 to -32005 follow the Ethereum conventions
+// (EIP-1474); the rest are ZionLayer specific and grouped by subsystem.
+const (
+	CodeServerError   = -32000 // generic failure
+	CodeUnauthorized  = -32001 // missing or invalid credentials
+	CodeNotFound      = -32002 // block, transaction, agent or filter unknown
+	CodeTxRejected    = -32003 // transaction failed validation
+	CodeLimitExceeded = -32005 // rate or range limit
+
+	// Transaction pool admission (-32010 to -32019).
+	CodeInsufficientFunds  = -32010
+	CodeNonceTooLow        = -32011
+	CodeNonceTooHigh       = -32012
+	CodeUnderpriced        = -32013
+	CodeReplaceUnderpriced = -32014
+	CodeAlreadyKnown       = -32015
+	CodePoolFull           = -32016
+	CodeInvalidSignature   = -32017
+	CodeSenderLimit        = -32018
+	CodeWrongChain         = -32019
+
+	// Agents (-32020 to -32029).
+	CodeAgentExists        = -32020
+	CodeAgentNotFound      = -32021
+	CodeAgentInactive      = -32022
+	CodeNotAgentController = -32023
+
+	// Execution (-32030 to -32039).
+	CodeOutOfGas          = -32030
+	CodeExecutionReverted = -32031
+	CodeInvalidOpcode     = -32032
+)
+
+// ErrorData is the data member of an RPCError. Reason is a stable,
+// machine-readable identifier for the error; ReturnData carries the output
+// of a reverted call as 0x-prefixed hex.
+type ErrorData struct {
+	Reason     string `json:"reason"`
+	ReturnData string `json:"returnData,omitempty"`
+}
+
+// errorKinds maps sentinel errors of the node onto error codes and
+// reasons. The first match wins, so more specific errors come first.
+var errorKinds = []struct {
+	err    error
+	code   int
+	reason string
+}{
+	{mempool.ErrInsufficientFunds, CodeInsufficientFunds, "insufficient_funds"},
+	{vm.ErrInsufficientFundsForGas, CodeInsufficientFunds, "insufficient_funds"},
+	{vm.ErrPaymasterFunds, CodeInsufficientFunds, "paymaster_insufficient_funds"},
+	{state.ErrInsufficientBalance, CodeInsufficientFunds, "insufficient_funds"},
+	{mempool.ErrNonceTooLow, CodeNonceTooLow, "nonce_too_low"},
+	{vm.ErrNonceTooLow, CodeNonceTooLow, "nonce_too_low"},
+	{mempool.ErrNonceTooHigh, CodeNonceTooHigh, "nonce_too_high"},
+	{vm.ErrNonceTooHigh, CodeNonceTooHigh, "nonce_too_high"},
+	{mempool.ErrUnderpriced, CodeUnderpriced, "underpriced"},
+	{mempool.ErrReplaceUnderpriced, CodeReplaceUnderpriced, "replacement_underpriced"},
+	{mempool.ErrDuplicateTx, CodeAlreadyKnown, "already_known"},
+	{mempool.ErrPoolFull, CodePoolFull, "pool_full"},
+	{mempool.ErrSenderLimit, CodeSenderLimit, "sender_limit"},
+	{transaction.ErrWrongChain, CodeWrongChain, "wrong_chain"},
+	{transaction.ErrMissingSignature, CodeInvalidSignature, "missing_signature"},
+	{transaction.ErrInvalidSender, CodeInvalidSignature, "invalid_sender"},
+	{transaction.ErrMultisigThreshold, CodeInvalidSignature, "multisig_threshold"},
+	{transaction.ErrMissingPaymasterSig, CodeInvalidSignature, "missing_paymaster_signature"},
+	{transaction.ErrInvalidPaymasterSigner, CodeInvalidSignature, "invalid_paymaster_signer"},
+	{vm.ErrAccountValidation, CodeInvalidSignature, "account_validation_failed"},
+	{state.ErrAgentAlreadyRegistered, CodeAgentExists, "agent_exists"},
+	{state.ErrAgentNotFound, CodeAgentNotFound, "agent_not_found"},
+	{state.ErrAgentInactive, CodeAgentInactive, "agent_inactive"},
+	{state.ErrAgentActive, CodeTxRejected, "agent_active"},
+	{state.ErrNotAgentController, CodeNotAgentController, "not_agent_controller"},
+	{state.ErrMessageNonce, CodeTxRejected, "message_nonce"},
+	{state.ErrCapabilityNotHeld, CodeTxRejected, "capability_not_held"},
+	{state.ErrDelegationNotFound, CodeNotFound, "delegation_not_found"},
+	{state.ErrDelegationExpired, CodeTxRejected, "delegation_expired"},
+	{state.ErrDelegationDepth, CodeTxRejected, "delegation_depth"},
+	{state.ErrOfferExists, CodeTxRejected, "offer_exists"},
+	{state.ErrOfferNotFound, CodeNotFound, "offer_not_found"},
+	{state.ErrAgentStateNotFound, CodeNotFound, "agent_state_not_found"},
+	{state.ErrReceiptNotFound, CodeNotFound, "receipt_not_found"},
+	{state.ErrChallengeWindowClosed, CodeTxRejected, "challenge_window_closed"},
+	{state.ErrDisputeExists, CodeTxRejected, "dispute_exists"},
+	{state.ErrDisputeNotFound, CodeNotFound, "dispute_not_found"},
+	{state.ErrDisputeClosed, CodeTxRejected, "dispute_closed"},
+	{state.ErrNoVerifiers, CodeTxRejected, "no_verifiers"},
+	{state.ErrNotVerifier, CodeTxRejected, "not_verifier"},
+	{state.ErrAlreadyVoted, CodeTxRejected, "already_voted"},
+	{state.ErrSelfChallenge, CodeTxRejected, "self_challenge"},
+	{state.ErrAttestationPeriod, CodeTxRejected, "attestation_period"},
+	{state.ErrBatchNotFound, CodeNotFound, "batch_not_found"},
+	{state.ErrNotInBatch, CodeTxRejected, "not_in_batch"},
+	{state.ErrNoCommitment, CodeTxRejected, "no_commitment"},
+	{state.ErrAlreadyRevealed, CodeTxRejected, "already_revealed"},
+	{state.ErrRevealWindowClosed, CodeTxRejected, "reveal_window_closed"},
+	{state.ErrCommitmentMismatch, CodeTxRejected, "commitment_mismatch"},
+	{state.ErrRevealNotFound, CodeNotFound, "reveal_not_found"},
+	{state.ErrEvaluatorExists, CodeTxRejected, "evaluator_exists"},
+	{state.ErrEvaluatorNotFound, CodeNotFound, "evaluator_not_found"},
+	{state.ErrEvaluatorInactive, CodeTxRejected, "evaluator_inactive"},
+	{state.ErrEvaluatorStake, CodeTxRejected, "evaluator_stake"},
+	{state.ErrEvaluationClosed, CodeTxRejected, "evaluation_closed"},
+	{state.ErrEvaluationNotFound, CodeNotFound, "evaluation_not_found"},
+	{state.ErrAlreadyScored, CodeTxRejected, "already_scored"},
+	{state.ErrSelfEvaluation, CodeTxRejected, "self_evaluation"},
+	{state.ErrValidatorExists, CodeTxRejected, "validator_exists"},
+	{state.ErrValidatorNotFound, CodeNotFound, "validator_not_found"},
+	{state.ErrStakeNotFound, CodeNotFound, "stake_not_found"},
+	{state.ErrInsufficientStake, CodeTxRejected, "insufficient_stake"},
+	{state.ErrValidatorJailed, CodeTxRejected, "validator_jailed"},
+	{state.ErrValidatorNotJailed, CodeTxRejected, "validator_not_jailed"},
+	{state.ErrInvalidStake, CodeTxRejected, "invalid_stake"},
+	{state.ErrProposalNotFound, CodeNotFound, "proposal_not_found"},
+	{state.ErrDepositClosed, CodeTxRejected, "deposit_closed"},
+	{state.ErrVotingClosed, CodeTxRejected, "voting_closed"},
+	{state.ErrNoVotingPower, CodeTxRejected, "no_voting_power"},
+	{state.ErrInvalidParams, CodeTxRejected, "invalid_params"},
+	{state.ErrIBCClientNotFound, CodeNotFound, "ibc_client_not_found"},
+	{state.ErrIBCConnectionNotFound, CodeNotFound, "ibc_connection_not_found"},
+	{state.ErrIBCChannelNotFound, CodeNotFound, "ibc_channel_not_found"},
+	{state.ErrIBCInvalidHeader, CodeTxRejected, "ibc_invalid_header"},
+	{state.ErrIBCInvalidProof, CodeTxRejected, "ibc_invalid_proof"},
+	{state.ErrIBCHandshake, CodeTxRejected, "ibc_handshake"},
+	{state.ErrIBCInvalidPacket, CodeTxRejected, "ibc_invalid_packet"},
+	{state.ErrBridgeDisabled, CodeTxRejected, "bridge_disabled"},
+	{state.ErrNotBridgeAttester, CodeTxRejected, "not_bridge_attester"},
+	{state.ErrBridgeAttested, CodeTxRejected, "bridge_attested"},
+	{state.ErrBridgeDepositProcessed, CodeTxRejected, "bridge_deposit_processed"},
+	{state.ErrBridgeDepositNotFound, CodeNotFound, "bridge_deposit_not_found"},
+	{state.ErrBridgeWithdrawalNotFound, CodeNotFound, "bridge_withdrawal_not_found"},
+	{state.ErrBridgeEscrow, CodeTxRejected, "bridge_escrow"},
+	{state.ErrTokenExists, CodeTxRejected, "token_exists"},
+	{state.ErrTokenNotFound, CodeNotFound, "token_not_found"},
+	{state.ErrNotTokenAdmin, CodeTxRejected, "not_token_admin"},
+	{state.ErrTokenMaxSupply, CodeTxRejected, "token_max_supply"},
+	{state.ErrInsufficientAllowance, CodeTxRejected, "insufficient_allowance"},
+	{state.ErrNFTCollectionExists, CodeTxRejected, "nft_collection_exists"},
+	{state.ErrNFTCollectionNotFound, CodeNotFound, "nft_collection_not_found"},
+	{state.ErrNFTExists, CodeTxRejected, "nft_exists"},
+	{state.ErrNFTNotFound, CodeNotFound, "nft_not_found"},
+	{state.ErrNotNFTOwner, CodeTxRejected, "not_nft_owner"},
+	{state.ErrNotNFTMinter, CodeTxRejected, "not_nft_minter"},
+	{state.ErrProviderExists, CodeTxRejected, "provider_exists"},
+	{state.ErrProviderNotFound, CodeNotFound, "provider_not_found"},
+	{state.ErrProviderInactive, CodeTxRejected, "provider_inactive"},
+	{state.ErrModelNotSupported, CodeTxRejected, "model_not_supported"},
+	{state.ErrInvalidProverSig, CodeInvalidSignature, "invalid_prover_signature"},
+	{state.ErrReceiptTimestamp, CodeTxRejected, "receipt_timestamp"},
+	{state.ErrEnclaveNotApproved, CodeTxRejected, "enclave_not_approved"},
+	{state.ErrAttestationBinding, CodeTxRejected, "attestation_binding"},
+	{state.ErrInvalidAttestation, CodeTxRejected, "invalid_attestation"},
+	{state.ErrNoAttestationRoots, CodeTxRejected, "no_attestation_roots"},
+	{state.ErrNoVerifyingKey, CodeNotFound, "verifying_key_not_found"},
+	{state.ErrInvalidZKProof, CodeTxRejected, "invalid_zk_proof"},
+	{state.ErrModelExists, CodeTxRejected, "model_exists"},
+	{state.ErrModelNotFound, CodeNotFound, "model_not_found"},
+	{state.ErrNotModelOwner, CodeTxRejected, "not_model_owner"},
+	{state.ErrModelDeprecated, CodeTxRejected, "model_deprecated"},
+	{chain.ErrBlockNotFound, CodeNotFound, "block_not_found"},
+	{chain.ErrTxNotFound, CodeNotFound, "transaction_not_found"},
+	{vm.ErrOutOfGas, CodeOutOfGas, "out_of_gas"},
+	{vm.ErrExecutionReverted, CodeExecutionReverted, "execution_reverted"},
+	{vm.ErrInvalidOpcode, CodeInvalidOpcode, "invalid_opcode"},
+	{vm.ErrStackUnderflow, CodeInvalidOpcode, "stack_underflow"},
+	{transaction.ErrIntrinsicGas, CodeTxRejected, "intrinsic_gas"},
+	{transaction.ErrInvalidData, CodeTxRejected, "invalid_data"},
+	{transaction.ErrTxTooLarge, CodeTxRejected, "tx_too_large"},
+	{transaction.ErrNonCanonicalAddress, CodeTxRejected, "non_canonical_address"},
+}
+
+// errorFrom converts err into an RPCError, classifying known node errors
+// and falling back to CodeServerError.
+func errorFrom(err error) *RPCError {
+	for _, k := range errorKinds {
+		if errors.Is(err, k.err) {
+			return &RPCError{Code: k.code, Message: err.Error(), Data: &ErrorData{Reason: k.reason}}
+		}
+	}
+	return &RPCError{Code: CodeServerError, Message: err.Error()}
+}
+
+// callError converts a failed call into an RPCError, attaching the return
+// data of a reverted execution.
+func callError(err error, res *vm.CallResult) *RPCError {
+	rpcErr := errorFrom(err)
+	if data, ok := rpcErr.Data.(*ErrorData); ok && res != nil && len(res.ReturnData) > 0 {
+		data.ReturnData = fmt.Sprintf("0x%x", res.ReturnData)
+	}
+	return rpcErr
+}
+
+// invalidParams returns a CodeInvalidParams error with msg.
+func invalidParams(msg string) *RPCError {
+	return &RPCError{Code: CodeInvalidParams, Message: msg}
+}
@@ -0,0 +1,209 @@
+package rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/vm"
+)
+
+// The eth_ namespace maps the most common Ethereum JSON-RPC methods onto
+// ZionLayer so that generic tooling can query the node. Quantities are
+// 0x-prefixed hex as in Ethereum. Differences worth knowing:
+//
+//   - eth_sendRawTransaction takes a ZionLayer binary transaction envelope,
+//     not an Ethereum RLP transaction; ZionLayer signatures are Ed25519.
+//   - Only the latest state is kept, so block tags other than "latest" and
+//     "pending" are rejected.
+
+// dispatchEth handles eth_ methods. ok is false for unknown methods.
+func (s *Server) dispatchEth(ctx context.Context, method string, params json.RawMessage, ip string) (result interface{}, rpcErr *RPCError, ok bool) {
+	switch method {
+	case "eth_chainId":
+		result = hexUint(s.chainID)
+	case "eth_blockNumber":
+		result = hexUint(s.chain.Head())
+	case "eth_getBalance":
+		result, rpcErr = s.ethGetBalance(params)
+	case "eth_sendRawTransaction":
+		if s.limiter != nil && !s.limiter.allow(ip) {
+			rpcErr = &RPCError{Code: CodeLimitExceeded, Message: "rate limit exceeded"}
+			break
+		}
+		result, rpcErr = s.sendRawTransaction(ctx, params)
+	case "eth_getTransactionReceipt":
+		result, rpcErr = s.ethGetTransactionReceipt(params)
+	case "eth_call":
+		result, rpcErr = s.ethCall(params)
+	default:
+		return nil, nil, false
+	}
+	return result, rpcErr, true
+}
+
+func (s *Server) ethGetBalance(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	if rpcErr := checkBlockTag(args[1:]); rpcErr != nil {
+		return nil, rpcErr
+	}
+	addr, rpcErr := parseAddress(args[0])
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	return hexBig(s.account(addr).Balance), nil
+}
+
+func (s *Server) ethGetTransactionReceipt(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	b, err := hex.DecodeString(strings.TrimPrefix(args[0], "0x"))
+	if err != nil || len(b) != 32 {
+		return nil, invalidParams("invalid transaction hash")
+	}
+	var hash [32]byte
+	copy(hash[:], b)
+
+	tx, loc, err := s.chain.Transaction(hash)
+	if err != nil {
+		// Ethereum returns null for unknown or still pending transactions.
+		return nil, nil
+	}
+	r, err := s.chain.Receipt(hash)
+	if err != nil {
+		return nil, nil
+	}
+	var to, contract interface{}
+	if tx.To != "" {
+		to = tx.To
+	}
+	if r.Contract != "" {
+		contract = r.Contract
+	}
+	logs := make([]interface{}, 0, len(r.Logs))
+	for i, lg := range r.Logs {
+		topics := make([]string, len(lg.Topics))
+		for j, t := range lg.Topics {
+			topics[j] = fmt.Sprintf("0x%x", t)
+		}
+		logs = append(logs, map[string]interface{}{
+			"address":          lg.Address,
+			"topics":           topics,
+			"data":             fmt.Sprintf("0x%x", lg.Data),
+			"logIndex":         hexUint(uint64(i)),
+			"transactionHash":  fmt.Sprintf("0x%x", r.TxHash),
+			"transactionIndex": hexUint(uint64(loc.Index)),
+			"blockHash":        fmt.Sprintf("0x%x", loc.BlockHash),
+			"blockNumber":      hexUint(loc.BlockHeight),
+		})
+	}
+	status := "0x0"
+	if r.Status == transaction.ReceiptSuccess {
+		status = "0x1"
+	}
+	return map[string]interface{}{
+		"transactionHash":   fmt.Sprintf("0x%x", r.TxHash),
+		"transactionIndex":  hexUint(uint64(loc.Index)),
+		"blockHash":         fmt.Sprintf("0x%x", loc.BlockHash),
+		"blockNumber":       hexUint(loc.BlockHeight),
+		"from":              tx.From,
+		"to":                to,
+		"gasUsed":           hexUint(r.GasUsed),
+		"effectiveGasPrice": hexBig(tx.GasPrice),
+		"contractAddress":   contract,
+		"logs":              logs,
+		"status":            status,
+	}, nil
+}
+
+func (s *Server) ethCall(params json.RawMessage) (interface{}, *RPCError) {
+	var args []json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	var tags []string
+	if len(args) > 1 {
+		var tag string
+		if err := json.Unmarshal(args[1], &tag); err != nil {
+			return nil, invalidParams("invalid block tag")
+		}
+		tags = append(tags, tag)
+	}
+	if rpcErr := checkBlockTag(tags); rpcErr != nil {
+		return nil, rpcErr
+	}
+	var call struct {
+		From  string `json:"from"`
+		To    string `json:"to"`
+		Gas   string `json:"gas"`
+		Data  string `json:"data"`
+		Input string `json:"input"`
+	}
+	if err := json.Unmarshal(args[0], &call); err != nil {
+		return nil, invalidParams("invalid params")
+	}
+	input := call.Input
+	if input == "" {
+		input = call.Data
+	}
+	data, err := hex.DecodeString(strings.TrimPrefix(input, "0x"))
+	if err != nil {
+		return nil, invalidParams("invalid data")
+	}
+	msg := vm.CallMsg{Data: data}
+	if call.Gas != "" {
+		gas, ok := new(big.Int).SetString(strings.TrimPrefix(call.Gas, "0x"), 16)
+		if !ok || !gas.IsUint64() {
+			return nil, invalidParams("invalid gas")
+		}
+		msg.Gas = gas.Uint64()
+	}
+	var rpcErr *RPCError
+	if call.From != "" {
+		if msg.From, rpcErr = parseAddress(call.From); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+	if call.To != "" {
+		if msg.To, rpcErr = parseAddress(call.To); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+	res, err := s.avm.Call(s.state, msg, s.chain.Head())
+	if err != nil {
+		return nil, callError(err, res)
+	}
+	return fmt.Sprintf("0x%x", res.ReturnData), nil
+}
+
+// checkBlockTag rejects block parameters that refer to historical state.
+func checkBlockTag(tags []string) *RPCError {
+	if len(tags) == 0 {
+		return nil
+	}
+	switch tags[0] {
+	case "", "latest", "pending":
+		return nil
+	}
+	return invalidParams("only the latest state is available")
+}
+
+func hexUint(u uint64) string {
+	return fmt.Sprintf("0x%x", u)
+}
+
+func hexBig(i *big.Int) string {
+	if i == nil {
+		return "0x0"
+	}
+	return "0x" + i.Text(16)
+}
@@ -0,0 +1,338 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+const (
+	// MaxLogRange is the widest block range zion_getLogs will scan.
+	MaxLogRange = 10_000
+
+	// MaxLogResults caps the logs returned by one zion_getLogs or
+	// zion_getFilterChanges call.
+	MaxLogResults = 10_000
+
+	// filterTimeout is how long an installed filter survives without being
+	// polled.
+	filterTimeout = 5 * time.Minute
+)
+
+// FilterArgs are the parameters of zion_getLogs and zion_newFilter.
+// FromBlock and ToBlock are heights or "latest"; Address is one address or
+// a list; Topics holds, per position, null for any topic, one topic, or a
+// list of alternatives.
+type FilterArgs struct {
+	FromBlock json.RawMessage   `json:"fromBlock"`
+	ToBlock   json.RawMessage   `json:"toBlock"`
+	Address   json.RawMessage   `json:"address"`
+	Topics    []json.RawMessage `json:"topics"`
+}
+
+// logCriteria is a parsed FilterArgs. A nil from or to means the head.
+type logCriteria struct {
+	from, to  *uint64
+	addresses []string
+	topics    [][][]byte
+}
+
+type logFilter struct {
+	crit     logCriteria
+	next     uint64 // first height not yet reported
+	lastPoll time.Time
+}
+
+type filterSet struct {
+	mu      sync.Mutex
+	filters map[string]*logFilter
+}
+
+// expire removes filters not polled within filterTimeout and returns how
+// many were removed.
+func (fs *filterSet) expire(now time.Time) int {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.expireLocked(now)
+}
+
+func (fs *filterSet) expireLocked(now time.Time) int {
+	n := 0
+	for id, f := range fs.filters {
+		if now.Sub(f.lastPoll) > filterTimeout {
+			delete(fs.filters, id)
+			n++
+		}
+	}
+	return n
+}
+
+func parseFilterArgs(params json.RawMessage) (*logCriteria, *RPCError) {
+	var args []FilterArgs
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	a := args[0]
+	crit := &logCriteria{}
+	var rpcErr *RPCError
+	if crit.from, rpcErr = parseBlockParam(a.FromBlock); rpcErr != nil {
+		return nil, rpcErr
+	}
+	if crit.to, rpcErr = parseBlockParam(a.ToBlock); rpcErr != nil {
+		return nil, rpcErr
+	}
+	if len(a.Address) > 0 && string(a.Address) != "null" {
+		var list []string
+		if err := json.Unmarshal(a.Address, &list); err != nil {
+			var one string
+			if err := json.Unmarshal(a.Address, &one); err != nil {
+				return nil, invalidParams("invalid address")
+			}
+			list = []string{one}
+		}
+		for _, addr := range list {
+			canonical, rpcErr := parseAddress(addr)
+			if rpcErr != nil {
+				return nil, rpcErr
+			}
+			crit.addresses = append(crit.addresses, canonical)
+		}
+	}
+	for _, raw := range a.Topics {
+		var alts []string
+		if len(raw) > 0 && string(raw) != "null" {
+			if err := json.Unmarshal(raw, &alts); err != nil {
+				var one string
+				if err := json.Unmarshal(raw, &one); err != nil {
+					return nil, invalidParams("invalid topic")
+				}
+				alts = []string{one}
+			}
+		}
+		var pos [][]byte
+		for _, t := range alts {
+			b, err := hex.DecodeString(strings.TrimPrefix(t, "0x"))
+			if err != nil {
+				return nil, invalidParams("invalid topic")
+			}
+			pos = append(pos, b)
+		}
+		crit.topics = append(crit.topics, pos)
+	}
+	return crit, nil
+}
+
+func parseBlockParam(raw json.RawMessage) (*uint64, *RPCError) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var tag string
+	if err := json.Unmarshal(raw, &tag); err == nil {
+		switch tag {
+		case "latest", "pending":
+			return nil, nil
+		case "earliest":
+			zero := uint64(0)
+			return &zero, nil
+		}
+		return nil, invalidParams(fmt.Sprintf("invalid block %q", tag))
+	}
+	var height uint64
+	if err := json.Unmarshal(raw, &height); err != nil {
+		return nil, invalidParams("invalid block")
+	}
+	return &height, nil
+}
+
+// mayMatch checks the block bloom for the addresses and topics of c.
+func (c *logCriteria) mayMatch(bloom *transaction.Bloom) bool {
+	if len(c.addresses) > 0 {
+		found := false
+		for _, addr := range c.addresses {
+			if bloom.Test([]byte(addr)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, alts := range c.topics {
+		if len(alts) == 0 {
+			continue
+		}
+		found := false
+		for _, t := range alts {
+			if bloom.Test(t) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *logCriteria) matches(l *transaction.Log) bool {
+	if len(c.addresses) > 0 {
+		found := false
+		for _, addr := range c.addresses {
+			if addr == l.Address {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(c.topics) > len(l.Topics) {
+		return false
+	}
+	for i, alts := range c.topics {
+		if len(alts) == 0 {
+			continue
+		}
+		found := false
+		for _, t := range alts {
+			if bytes.Equal(t, l.Topics[i]) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// collectLogs returns the logs in heights [from, to] matching c, skipping
+// blocks whose bloom rules them out. It fails once more than MaxLogResults
+// logs match.
+func (s *Server) collectLogs(c *logCriteria, from, to uint64) ([]LogResult, *RPCError) {
+	logs := []LogResult{}
+	for h := from; h <= to; h++ {
+		bloom, err := s.chain.Bloom(h)
+		if err != nil || !c.mayMatch(&bloom) {
+			continue
+		}
+		receipts, err := s.chain.BlockReceipts(h)
+		if err != nil {
+			continue
+		}
+		for _, r := range receipts {
+			for _, l := range r.Logs {
+				if !c.matches(l) {
+					continue
+				}
+				if len(logs) == MaxLogResults {
+					return nil, &RPCError{Code: CodeLimitExceeded, Message: fmt.Sprintf("query returns more than %d logs; narrow the block range", MaxLogResults)}
+				}
+				logs = append(logs, newLogResult(l, r.TxHash, h))
+			}
+		}
+		if h == to {
+			break
+		}
+	}
+	return logs, nil
+}
+
+func (s *Server) getLogs(params json.RawMessage) (interface{}, *RPCError) {
+	crit, rpcErr := parseFilterArgs(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	head := s.chain.Head()
+	from, to := head, head
+	if crit.from != nil {
+		from = *crit.from
+	}
+	if crit.to != nil && *crit.to < head {
+		to = *crit.to
+	}
+	if from > to {
+		return []LogResult{}, nil
+	}
+	if to-from >= MaxLogRange {
+		return nil, &RPCError{Code: CodeLimitExceeded, Message: fmt.Sprintf("block range exceeds %d blocks", MaxLogRange)}
+	}
+	return s.collectLogs(crit, from, to)
+}
+
+func (s *Server) newFilter(params json.RawMessage) (interface{}, *RPCError) {
+	crit, rpcErr := parseFilterArgs(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	next := s.chain.Head() + 1
+	if crit.from != nil {
+		next = *crit.from
+	}
+	id := newSubscriptionID()
+	now := time.Now()
+
+	s.filters.mu.Lock()
+	defer s.filters.mu.Unlock()
+	if s.filters.filters == nil {
+		s.filters.filters = make(map[string]*logFilter)
+	}
+	s.filters.expireLocked(now)
+	s.filters.filters[id] = &logFilter{crit: *crit, next: next, lastPoll: now}
+	return id, nil
+}
+
+// getFilterChanges returns the logs of blocks added since the last poll.
+func (s *Server) getFilterChanges(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	s.filters.mu.Lock()
+	f, ok := s.filters.filters[args[0]]
+	if !ok {
+		s.filters.mu.Unlock()
+		return nil, &RPCError{Code: CodeNotFound, Message: "filter not found", Data: &ErrorData{Reason: "filter_not_found"}}
+	}
+	f.lastPoll = time.Now()
+	from := f.next
+	to := s.chain.Head()
+	if f.crit.to != nil && *f.crit.to < to {
+		to = *f.crit.to
+	}
+	if to >= from && to-from >= MaxLogRange {
+		to = from + MaxLogRange - 1
+	}
+	if to >= from {
+		f.next = to + 1
+	}
+	crit := f.crit
+	s.filters.mu.Unlock()
+
+	if from > to {
+		return []LogResult{}, nil
+	}
+	return s.collectLogs(&crit, from, to)
+}
+
+func (s *Server) uninstallFilter(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	s.filters.mu.Lock()
+	defer s.filters.mu.Unlock()
+	_, ok := s.filters.filters[args[0]]
+	delete(s.filters.filters, args[0])
+	return ok, nil
+}
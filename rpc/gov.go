@@ -0,0 +1,55 @@
+package rpc
+
+import (
+	"encoding/json"
+)
+
+// getProposal handles zion_getProposal(id), returning a governance
+// proposal with its deposits, votes and, once voting has ended, its tally.
+func (s *Server) getProposal(params json.RawMessage) (interface{}, *RPCError) {
+	var args []uint64
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	p, err := s.state.GetProposal(args[0])
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return p, nil
+}
+
+// getProposals handles zion_getProposals([status], [page]), listing the
+// governance proposals with status, or all of them if it is empty, newest
+// first.
+func (s *Server) getProposals(params json.RawMessage) (interface{}, *RPCError) {
+	var args []json.RawMessage
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, invalidParams("invalid params")
+		}
+	}
+	var status string
+	if len(args) > 0 && string(args[0]) != "null" {
+		if err := json.Unmarshal(args[0], &status); err != nil {
+			return nil, invalidParams("invalid status")
+		}
+	}
+	var page PageArgs
+	if len(args) > 1 {
+		var rpcErr *RPCError
+		if page, rpcErr = parsePage(args[1]); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+	out, rpcErr := paginate(s.state.Proposals(status), page)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	return out, nil
+}
+
+// getUpgradePlan handles zion_getUpgradePlan(), returning the software
+// upgrade passed by governance, or null if there is none.
+func (s *Server) getUpgradePlan(json.RawMessage) (interface{}, *RPCError) {
+	return s.state.GetUpgradePlan(), nil
+}
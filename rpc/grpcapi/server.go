@@ -0,0 +1,268 @@
+// Package grpcapi serves the node's gRPC query and broadcast services
+// defined in proto/zion/node/v1.
+package grpcapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/zionlayer/zionlayer/core/block"
+	"github.com/zionlayer/zionlayer/core/chain"
+	"github.com/zionlayer/zionlayer/core/common"
+	"github.com/zionlayer/zionlayer/core/mempool"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/rpc/grpcapi/nodev1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements nodev1.QueryServiceServer and nodev1.TxServiceServer.
+type Server struct {
+	nodev1.UnimplementedQueryServiceServer
+	nodev1.UnimplementedTxServiceServer
+
+	state   *state.StateDB
+	pool    *mempool.Pool
+	chain   *chain.Store
+	logger  *zap.Logger
+	chainID uint64
+	port    int
+	grpc    *grpc.Server
+}
+
+// NewServer creates a gRPC server.
+func NewServer(stateDB *state.StateDB, pool *mempool.Pool, chainStore *chain.Store, logger *zap.Logger, chainID uint64, port int) *Server {
+	s := &Server{state: stateDB, pool: pool, chain: chainStore, logger: logger, chainID: chainID, port: port}
+	s.grpc = grpc.NewServer(grpc.MaxRecvMsgSize(2 * transaction.MaxTxSize))
+	nodev1.RegisterQueryServiceServer(s.grpc, s)
+	nodev1.RegisterTxServiceServer(s.grpc, s)
+	return s
+}
+
+// Start begins serving gRPC requests.
+func (s *Server) Start() error {
+	addr := fmt.Sprintf(":%d", s.port)
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.logger.Info("gRPC server starting", zap.String("addr", addr))
+	return s.grpc.Serve(lis)
+}
+
+// Stop gracefully stops the server.
+func (s *Server) Stop() {
+	s.grpc.GracefulStop()
+}
+
+// Shutdown gracefully stops the server, closing open streams and pending
+// requests forcibly if ctx expires first.
+func (s *Server) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.grpc.Stop()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (s *Server) GetChainInfo(ctx context.Context, req *nodev1.GetChainInfoRequest) (*nodev1.GetChainInfoResponse, error) {
+	return &nodev1.GetChainInfoResponse{
+		ChainId:     s.chainID,
+		Height:      s.chain.Head(),
+		MempoolSize: uint32(s.pool.Size()),
+	}, nil
+}
+
+func (s *Server) GetAccount(ctx context.Context, req *nodev1.GetAccountRequest) (*nodev1.GetAccountResponse, error) {
+	addr, err := common.ParseAddress(req.GetAddress())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	acc := s.state.GetAccount(addr.String())
+	resp := &nodev1.GetAccountResponse{
+		Address: acc.Address,
+		Balance: acc.Balance.String(),
+		Nonce:   acc.Nonce,
+	}
+	if len(acc.Code) > 0 {
+		h := sha256.Sum256(acc.Code)
+		resp.CodeHash = h[:]
+	}
+	return resp, nil
+}
+
+func (s *Server) GetBlock(ctx context.Context, req *nodev1.GetBlockRequest) (*nodev1.GetBlockResponse, error) {
+	var b *block.Block
+	var err error
+	switch sel := req.GetSelector().(type) {
+	case *nodev1.GetBlockRequest_Height:
+		b, err = s.chain.BlockByHeight(sel.Height)
+	case *nodev1.GetBlockRequest_Hash:
+		var hash [32]byte
+		if hash, err = toHash(sel.Hash); err != nil {
+			return nil, err
+		}
+		b, err = s.chain.BlockByHash(hash)
+	default:
+		b, err = s.chain.BlockByHeight(s.chain.Head())
+	}
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	hash := b.Hash()
+	h := b.Header
+	resp := &nodev1.GetBlockResponse{
+		Hash: hash[:],
+		Header: &nodev1.BlockHeader{
+			Version:   h.Version,
+			Height:    h.Height,
+			Timestamp: h.Timestamp,
+			PrevHash:  h.PrevHash[:],
+			StateRoot: h.StateRoot[:],
+			TxRoot:    h.TxRoot[:],
+			AgentRoot: h.AgentRoot[:],
+			Validator: string(h.ValidatorAddr),
+		},
+	}
+	for _, tx := range b.Txs {
+		txHash := tx.Hash()
+		resp.TxHashes = append(resp.TxHashes, txHash[:])
+		if req.GetFullTxs() {
+			enc, err := tx.MarshalBinary()
+			if err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+			resp.Txs = append(resp.Txs, enc)
+		}
+	}
+	return resp, nil
+}
+
+func (s *Server) GetTransaction(ctx context.Context, req *nodev1.GetTransactionRequest) (*nodev1.GetTransactionResponse, error) {
+	hash, err := toHash(req.GetHash())
+	if err != nil {
+		return nil, err
+	}
+	tx, loc, err := s.chain.Transaction(hash)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	enc, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	resp := &nodev1.GetTransactionResponse{
+		Tx:          enc,
+		BlockHash:   loc.BlockHash[:],
+		BlockHeight: loc.BlockHeight,
+		Index:       uint32(loc.Index),
+	}
+	if r, err := s.chain.Receipt(hash); err == nil {
+		resp.Receipt = &nodev1.Receipt{
+			Success:  r.Status == transaction.ReceiptSuccess,
+			GasUsed:  r.GasUsed,
+			FeePayer: r.FeePayer,
+			Contract: r.Contract,
+			Error:    r.Error,
+		}
+		if r.Fee != nil {
+			resp.Receipt.Fee = r.Fee.String()
+		}
+	}
+	return resp, nil
+}
+
+func (s *Server) GetAgent(ctx context.Context, req *nodev1.GetAgentRequest) (*nodev1.GetAgentResponse, error) {
+	rec, err := s.state.GetAgent(req.GetDid())
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	resp := &nodev1.GetAgentResponse{
+		Did:          rec.DID.ID,
+		Controller:   rec.DID.Controller,
+		PublicKey:    rec.DID.PublicKey,
+		Metadata:     rec.DID.Metadata,
+		RegisteredAt: rec.RegisteredAt,
+		MessageCount: rec.MessageCount,
+		Active:       rec.Active,
+	}
+	for _, c := range rec.DID.Capabilities {
+		resp.Capabilities = append(resp.Capabilities, &nodev1.Capability{Name: c.Name, Version: c.Version})
+	}
+	return resp, nil
+}
+
+func (s *Server) GetInferenceReceipt(ctx context.Context, req *nodev1.GetInferenceReceiptRequest) (*nodev1.GetInferenceReceiptResponse, error) {
+	hash, err := toHash(req.GetTxHash())
+	if err != nil {
+		return nil, err
+	}
+	tx, loc, err := s.chain.Transaction(hash)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if tx.Type != transaction.TxInferenceReceipt {
+		return nil, status.Error(codes.InvalidArgument, "transaction is not an inference receipt")
+	}
+	var r transaction.InferenceReceipt
+	if err := json.Unmarshal(tx.Data, &r); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &nodev1.GetInferenceReceiptResponse{
+		AgentId:     r.AgentID,
+		ModelHash:   r.ModelHash,
+		InputHash:   r.InputHash,
+		OutputHash:  r.OutputHash,
+		Timestamp:   r.Timestamp,
+		ProverSig:   r.ProverSig,
+		Submitter:   tx.From,
+		BlockHeight: loc.BlockHeight,
+	}, nil
+}
+
+func (s *Server) BroadcastTx(ctx context.Context, req *nodev1.BroadcastTxRequest) (*nodev1.BroadcastTxResponse, error) {
+	tx := new(transaction.Tx)
+	if err := tx.UnmarshalBinary(req.GetTx()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := tx.ValidateBasic(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := s.pool.Add(tx); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	hash := tx.Hash()
+	return &nodev1.BroadcastTxResponse{Hash: hash[:]}, nil
+}
+
+func toHash(b []byte) ([32]byte, error) {
+	var h [32]byte
+	if len(b) != len(h) {
+		return h, status.Error(codes.InvalidArgument, "hash must be 32 bytes")
+	}
+	copy(h[:], b)
+	return h, nil
+}
+
+func notFound(err error) error {
+	if errors.Is(err, chain.ErrBlockNotFound) || errors.Is(err, chain.ErrTxNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,152 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Health check statuses.
+const (
+	CheckOK      = "ok"
+	CheckFailed  = "failed"
+	CheckSkipped = "skipped" // the subsystem is not enabled on this node
+)
+
+// stateCheckTimeout bounds how long the state check waits for the state
+// DB to answer a read.
+const stateCheckTimeout = time.Second
+
+// CheckResult is the outcome of one readiness check.
+type CheckResult struct {
+	Status  string                 `json:"status"`
+	Error   string                 `json:"error,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// HealthReport is the body of /readyz. Status is CheckOK when no check
+// failed.
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// livez answers as long as the process serves HTTP. Orchestrators restart
+// the node when it stops answering.
+func (s *Server) livez(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": CheckOK})
+}
+
+// readyz reports whether the node should receive traffic: it is in sync
+// with the chain, connected to peers, its state DB responds and consensus
+// is running. It answers 503 when any check fails.
+func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	report := s.Readiness()
+	w.Header().Set("Content-Type", "application/json")
+	if report.Status != CheckOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+// Readiness runs the readiness checks.
+func (s *Server) Readiness() HealthReport {
+	report := HealthReport{
+		Status: CheckOK,
+		Checks: map[string]CheckResult{
+			"sync":      s.checkSync(),
+			"peers":     s.checkPeers(),
+			"state":     s.checkState(),
+			"consensus": s.checkConsensus(),
+		},
+	}
+	for _, c := range report.Checks {
+		if c.Status == CheckFailed {
+			report.Status = CheckFailed
+		}
+	}
+	return report
+}
+
+// checkSync fails when the latest block is older than Config.MaxBlockAge,
+// meaning the node has fallen behind or the chain has halted.
+func (s *Server) checkSync() CheckResult {
+	head := s.chain.Head()
+	b, err := s.chain.BlockByHeight(head)
+	if err != nil {
+		return CheckResult{Status: CheckFailed, Error: "no blocks yet", Details: map[string]interface{}{"height": head}}
+	}
+	age := time.Since(time.Unix(0, b.Header.Timestamp)).Round(time.Millisecond)
+	res := CheckResult{Status: CheckOK, Details: map[string]interface{}{
+		"height":   head,
+		"blockAge": age.String(),
+	}}
+	if max := s.config.MaxBlockAge; max > 0 && age > max {
+		res.Status = CheckFailed
+		res.Error = fmt.Sprintf("latest block is older than %s", max)
+	}
+	return res
+}
+
+// checkPeers fails when fewer than Config.MinPeers peers are connected.
+func (s *Server) checkPeers() CheckResult {
+	if s.peers == nil {
+		return CheckResult{Status: CheckSkipped, Details: map[string]interface{}{"reason": ErrNoPeerManager.Error()}}
+	}
+	n := len(s.peers.Peers())
+	res := CheckResult{Status: CheckOK, Details: map[string]interface{}{
+		"peers":    n,
+		"minPeers": s.config.MinPeers,
+	}}
+	if n < s.config.MinPeers {
+		res.Status = CheckFailed
+		res.Error = fmt.Sprintf("%d peers connected, %d required", n, s.config.MinPeers)
+	}
+	return res
+}
+
+// checkState fails when the state DB does not answer a read in time, as
+// happens when a writer holds it locked.
+func (s *Server) checkState() CheckResult {
+	start := time.Now()
+	done := make(chan struct{})
+	go func() {
+		s.state.GetNonce("")
+		close(done)
+	}()
+	select {
+	case <-done:
+		return CheckResult{Status: CheckOK, Details: map[string]interface{}{
+			"latency": time.Since(start).String(),
+		}}
+	case <-time.After(stateCheckTimeout):
+		return CheckResult{Status: CheckFailed, Error: fmt.Sprintf("state DB did not respond within %s", stateCheckTimeout)}
+	}
+}
+
+// checkConsensus fails when the consensus engine has stopped producing
+// blocks. The details report whether the proposer is in the validator set
+// and its signing record.
+func (s *Server) checkConsensus() CheckResult {
+	if s.engine == nil {
+		return CheckResult{Status: CheckSkipped, Details: map[string]interface{}{"reason": ErrNoEngine.Error()}}
+	}
+	proposer, running := s.engine.Running()
+	if !running {
+		return CheckResult{Status: CheckFailed, Error: "consensus engine is not running"}
+	}
+	validator := false
+	for _, v := range s.engine.Validators() {
+		if v.Address == proposer {
+			validator = true
+			break
+		}
+	}
+	return CheckResult{Status: CheckOK, Details: map[string]interface{}{
+		"proposer":  proposer,
+		"validator": validator,
+		"signing":   s.engine.SigningInfo(proposer),
+	}}
+}
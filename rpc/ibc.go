@@ -0,0 +1,171 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// IBCProofResult is the result of zion_getIBCProof and
+// zion_getIBCPacketProof. Proof is ready to be relayed in an IBC
+// transaction to the counterparty once its client of this chain holds the
+// header at Proof.Height, the next block, whose AgentRoot is Root.
+type IBCProofResult struct {
+	Key   string               `json:"key"`
+	Root  string               `json:"root"`
+	Proof transaction.IBCProof `json:"proof"`
+}
+
+// IBCPacketsResult is the result of zion_getIBCPackets.
+type IBCPacketsResult struct {
+	Pending          []*transaction.IBCPacket `json:"pending"`          // sent, awaiting acknowledgement
+	Acknowledgements []*state.IBCPacketAck    `json:"acknowledgements"` // received
+}
+
+// getIBCClient handles zion_getIBCClient(id).
+func (s *Server) getIBCClient(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	c, err := s.state.GetIBCClient(args[0])
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return c, nil
+}
+
+// getIBCConnection handles zion_getIBCConnection(id).
+func (s *Server) getIBCConnection(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	c, err := s.state.GetIBCConnection(args[0])
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return c, nil
+}
+
+// getIBCChannels handles zion_getIBCChannels([port, id]), returning the
+// channel end id of port, or every channel end without arguments.
+func (s *Server) getIBCChannels(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &args); err != nil || len(args) == 1 {
+			return nil, invalidParams("invalid params")
+		}
+	}
+	if len(args) == 0 {
+		return s.state.IBCChannels(), nil
+	}
+	ch, err := s.state.GetIBCChannel(args[0], args[1])
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return ch, nil
+}
+
+// getIBCPackets handles zion_getIBCPackets(port, channel, [fromSequence]),
+// listing the packets sent over the channel end awaiting acknowledgement
+// and those received from fromSequence on with their acknowledgements.
+func (s *Server) getIBCPackets(params json.RawMessage) (interface{}, *RPCError) {
+	var args []json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil || len(args) < 2 {
+		return nil, invalidParams("invalid params")
+	}
+	var port, channel string
+	var from uint64
+	if json.Unmarshal(args[0], &port) != nil || json.Unmarshal(args[1], &channel) != nil {
+		return nil, invalidParams("invalid port or channel")
+	}
+	if len(args) > 2 && json.Unmarshal(args[2], &from) != nil {
+		return nil, invalidParams("invalid sequence")
+	}
+	if _, err := s.state.GetIBCChannel(port, channel); err != nil {
+		return nil, errorFrom(err)
+	}
+	return &IBCPacketsResult{
+		Pending:          s.state.IBCPendingPackets(port, channel),
+		Acknowledgements: s.state.IBCAcknowledgements(port, channel, from),
+	}, nil
+}
+
+// getIBCProof handles zion_getIBCProof(key), proving the IBC record under
+// the agent state key, such as "ibc/connection/<id>" or
+// "ibc/channel/<port>/<id>", for a handshake step of the counterparty.
+func (s *Server) getIBCProof(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	if !strings.HasPrefix(args[0], "ibc/") {
+		return nil, invalidParams("not an IBC key")
+	}
+	return s.ibcProof(args[0])
+}
+
+// getIBCPacketProof handles zion_getIBCPacketProof(port, channel, sequence,
+// kind), proving the commitment to the packet sent over the channel end,
+// for kind "commitment", or to the acknowledgement of the packet received
+// over it, for kind "ack".
+func (s *Server) getIBCPacketProof(params json.RawMessage) (interface{}, *RPCError) {
+	var args []json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil || len(args) < 4 {
+		return nil, invalidParams("invalid params")
+	}
+	var port, channel, kind string
+	var seq uint64
+	if json.Unmarshal(args[0], &port) != nil || json.Unmarshal(args[1], &channel) != nil ||
+		json.Unmarshal(args[2], &seq) != nil || json.Unmarshal(args[3], &kind) != nil {
+		return nil, invalidParams("invalid params")
+	}
+	switch kind {
+	case "commitment":
+		return s.ibcProof(state.IBCCommitmentKey(port, channel, seq))
+	case "ack":
+		return s.ibcProof(state.IBCAckKey(port, channel, seq))
+	}
+	return nil, invalidParams(fmt.Sprintf("unknown proof kind %q", kind))
+}
+
+func (s *Server) ibcProof(key string) (interface{}, *RPCError) {
+	p, err := s.state.AgentProof(key)
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return &IBCProofResult{
+		Key:  key,
+		Root: fmt.Sprintf("0x%x", p.Root),
+		Proof: transaction.IBCProof{
+			Height: s.chain.Head() + 1,
+			Value:  p.Value,
+			Index:  p.Index,
+			Total:  p.Total,
+			Proof:  p.Proof,
+		},
+	}, nil
+}
+
+// getIBCVouchers handles zion_getIBCVouchers(address), returning the
+// balances of tokens received over IBC held by the address, by
+// denomination.
+func (s *Server) getIBCVouchers(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	addr, rpcErr := parseAddress(args[0])
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	out := make(map[string]string)
+	for denom, bal := range s.state.IBCVouchers(addr) {
+		out[denom] = bal.String()
+	}
+	return out, nil
+}
@@ -0,0 +1,288 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// Verification states of an inference receipt.
+const (
+	// InferencePending receipts are included but not yet executed.
+	InferencePending = "pending"
+	// InferenceAccepted receipts executed successfully.
+	InferenceAccepted = "accepted"
+	// InferenceRejected receipts failed execution.
+	InferenceRejected = "rejected"
+)
+
+// InferenceReceiptResult is an included inference receipt. Hashes and
+// signatures are 0x-prefixed hex.
+type InferenceReceiptResult struct {
+	TxHash      string `json:"txHash"`
+	AgentID     string `json:"agentId"`
+	ModelHash   string `json:"modelHash"`
+	InputHash   string `json:"inputHash"`
+	OutputHash  string `json:"outputHash"`
+	Timestamp   int64  `json:"timestamp"`
+	Prover      string `json:"prover"`
+	ProverSig   string `json:"proverSig"`
+	Submitter   string `json:"submitter"`
+	BlockHeight uint64 `json:"blockHeight"`
+	Status      string `json:"status"`
+	// Challenge is the state of the receipt's challenge window; see
+	// zion_getDispute.
+	Challenge string `json:"challenge,omitempty"`
+	// Trust is the trust tier of the receipt: 1 if signed by a compute
+	// provider, 2 if also attested by an approved TEE enclave.
+	Trust int `json:"trust,omitempty"`
+	// Commitment is the state of the receipt's commitment to reveal its
+	// data, if it carries one; see zion_getReveal.
+	Commitment string `json:"commitment,omitempty"`
+}
+
+// inferenceResult loads the inference receipt carried by transaction hash.
+func (s *Server) inferenceResult(hash [32]byte) (*InferenceReceiptResult, *RPCError) {
+	tx, loc, err := s.chain.Transaction(hash)
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	if tx.Type != transaction.TxInferenceReceipt {
+		return nil, invalidParams("transaction is not an inference receipt")
+	}
+	var r transaction.InferenceReceipt
+	if err := json.Unmarshal(tx.Data, &r); err != nil {
+		return nil, errorFrom(err)
+	}
+	status := InferencePending
+	if rcpt, err := s.chain.Receipt(hash); err == nil {
+		status = InferenceRejected
+		if rcpt.Status == transaction.ReceiptSuccess {
+			status = InferenceAccepted
+		}
+	}
+	var challenge, commitment string
+	var trust int
+	if rec, err := s.state.GetReceipt(hash[:]); err == nil {
+		challenge, trust, commitment = rec.Status, rec.Trust, rec.Commitment
+	}
+	return &InferenceReceiptResult{
+		TxHash:      fmt.Sprintf("0x%x", hash),
+		AgentID:     r.AgentID,
+		ModelHash:   fmt.Sprintf("0x%x", r.ModelHash),
+		InputHash:   fmt.Sprintf("0x%x", r.InputHash),
+		OutputHash:  fmt.Sprintf("0x%x", r.OutputHash),
+		Timestamp:   r.Timestamp,
+		Prover:      r.Prover,
+		ProverSig:   fmt.Sprintf("0x%x", r.ProverSig),
+		Submitter:   tx.From,
+		BlockHeight: loc.BlockHeight,
+		Status:      status,
+		Challenge:   challenge,
+		Trust:       trust,
+		Commitment:  commitment,
+	}, nil
+}
+
+// getInferenceReceipt handles zion_getInferenceReceipt(txHash).
+func (s *Server) getInferenceReceipt(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	raw, err := hex.DecodeString(strings.TrimPrefix(args[0], "0x"))
+	if err != nil || len(raw) != 32 {
+		return nil, invalidParams("invalid hash")
+	}
+	var hash [32]byte
+	copy(hash[:], raw)
+	return s.inferenceResult(hash)
+}
+
+// getDispute handles zion_getDispute(txHash), returning the dispute of the
+// inference receipt included by the transaction.
+func (s *Server) getDispute(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	raw, err := hex.DecodeString(strings.TrimPrefix(args[0], "0x"))
+	if err != nil || len(raw) != 32 {
+		return nil, invalidParams("invalid hash")
+	}
+	d, err := s.state.GetDispute(raw)
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return d, nil
+}
+
+// getReveal handles zion_getReveal(txHash), returning the data revealed
+// for the inference receipt included by the transaction.
+func (s *Server) getReveal(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	raw, err := hex.DecodeString(strings.TrimPrefix(args[0], "0x"))
+	if err != nil || len(raw) != 32 {
+		return nil, invalidParams("invalid hash")
+	}
+	v, err := s.state.GetReveal(raw)
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return v, nil
+}
+
+// getInferenceReceipts handles zion_getInferenceReceipts(agentId,
+// fromHeight, toHeight, [page]). Heights accept the same values as
+// zion_getLogs and default to the head; the range is capped at MaxLogRange
+// blocks.
+func (s *Server) getInferenceReceipts(params json.RawMessage) (interface{}, *RPCError) {
+	var args []json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	var agentID string
+	if err := json.Unmarshal(args[0], &agentID); err != nil || agentID == "" {
+		return nil, invalidParams("invalid agent id")
+	}
+	head := s.chain.Head()
+	from, to := head, head
+	if len(args) > 1 {
+		h, rpcErr := parseBlockParam(args[1])
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		if h != nil {
+			from = *h
+		}
+	}
+	if len(args) > 2 {
+		h, rpcErr := parseBlockParam(args[2])
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		if h != nil && *h < head {
+			to = *h
+		}
+	}
+	var page PageArgs
+	if len(args) > 3 {
+		var rpcErr *RPCError
+		if page, rpcErr = parsePage(args[3]); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+	var hashes [][32]byte
+	if from <= to {
+		if to-from >= MaxLogRange {
+			return nil, &RPCError{Code: CodeLimitExceeded, Message: fmt.Sprintf("block range exceeds %d blocks", MaxLogRange)}
+		}
+		hashes = s.chain.InferenceReceipts(agentID, from, to)
+	}
+	selected, rpcErr := paginate(hashes, page)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	out := make([]*InferenceReceiptResult, 0, len(selected.Items))
+	for _, h := range selected.Items {
+		if r, rpcErr := s.inferenceResult(h); rpcErr == nil {
+			out = append(out, r)
+		}
+	}
+	return newPage(out, selected.Next, page, selected.Total), nil
+}
+
+// getModelReceipts handles zion_getModelReceipts(modelHash, [page]),
+// listing the inference receipts of a model, oldest first.
+func (s *Server) getModelReceipts(params json.RawMessage) (interface{}, *RPCError) {
+	var args []json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	var model string
+	if err := json.Unmarshal(args[0], &model); err != nil {
+		return nil, invalidParams("invalid model hash")
+	}
+	raw, err := hex.DecodeString(strings.TrimPrefix(model, "0x"))
+	if err != nil || len(raw) == 0 {
+		return nil, invalidParams("invalid model hash")
+	}
+	var page PageArgs
+	if len(args) > 1 {
+		var rpcErr *RPCError
+		if page, rpcErr = parsePage(args[1]); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+	out, rpcErr := paginate(s.state.ModelReceipts(raw), page)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	return out, nil
+}
+
+// getReceiptBatch handles zion_getReceiptBatch(txHash), returning the
+// receipt batch included by the transaction.
+func (s *Server) getReceiptBatch(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	raw, err := hex.DecodeString(strings.TrimPrefix(args[0], "0x"))
+	if err != nil || len(raw) != 32 {
+		return nil, invalidParams("invalid hash")
+	}
+	b, err := s.state.GetBatch(raw)
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return b, nil
+}
+
+// BatchedReceiptArgs are the arguments of zion_verifyBatchedReceipt: a
+// receipt, its index in the batch and its inclusion proof, as 0x-prefixed
+// sibling hashes, lowest first.
+type BatchedReceiptArgs struct {
+	TxHash  string                       `json:"txHash"`
+	Receipt transaction.InferenceReceipt `json:"receipt"`
+	Index   uint64                       `json:"index"`
+	Proof   []string                     `json:"proof"`
+}
+
+// verifyBatchedReceipt handles zion_verifyBatchedReceipt(args), reporting
+// whether a receipt is part of the batch included by a transaction.
+func (s *Server) verifyBatchedReceipt(params json.RawMessage) (interface{}, *RPCError) {
+	var args []BatchedReceiptArgs
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	a := args[0]
+	hash, err := hex.DecodeString(strings.TrimPrefix(a.TxHash, "0x"))
+	if err != nil || len(hash) != 32 {
+		return nil, invalidParams("invalid hash")
+	}
+	proof := make([][32]byte, len(a.Proof))
+	for i, p := range a.Proof {
+		b, err := hex.DecodeString(strings.TrimPrefix(p, "0x"))
+		if err != nil || len(b) != 32 {
+			return nil, invalidParams("invalid proof")
+		}
+		copy(proof[i][:], b)
+	}
+	switch err := s.state.VerifyBatchedReceipt(hash, a.Receipt, a.Index, proof); {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, state.ErrNotInBatch):
+		return false, nil
+	default:
+		return nil, errorFrom(err)
+	}
+}
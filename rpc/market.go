@@ -0,0 +1,53 @@
+package rpc
+
+import (
+	"encoding/json"
+
+	"github.com/zionlayer/zionlayer/core/state"
+)
+
+// getOffer handles zion_getOffer(did, capability), returning the offer of
+// an agent for a capability, active or withdrawn.
+func (s *Server) getOffer(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) < 2 {
+		return nil, invalidParams("invalid params")
+	}
+	o, err := s.state.GetOffer(args[0], args[1])
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return o, nil
+}
+
+// getAgentOffers handles zion_getAgentOffers(did), listing the offers of
+// an agent, active and withdrawn.
+func (s *Server) getAgentOffers(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	return s.state.AgentOffers(args[0]), nil
+}
+
+// SearchOffersArgs are the parameters of zion_searchOffers.
+type SearchOffersArgs struct {
+	state.OfferQuery
+	PageArgs
+}
+
+// searchOffers handles zion_searchOffers({capability, version, maxPrice,
+// maxLatencyMs, minReputation, cursor, limit}), listing the active offers
+// matching the query at the chain head, cheapest first.
+func (s *Server) searchOffers(params json.RawMessage) (interface{}, *RPCError) {
+	var args []SearchOffersArgs
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 || args[0].Capability == "" {
+		return nil, invalidParams("invalid params")
+	}
+	a := args[0]
+	page, rpcErr := paginate(s.state.MatchOffers(a.OfferQuery, s.chain.Head()), a.PageArgs)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	return page, nil
+}
@@ -0,0 +1,57 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/zionlayer/zionlayer/core/state"
+)
+
+// getModel handles zion_getModel(modelHash), returning a registered model,
+// deprecated or not.
+func (s *Server) getModel(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	hash, err := hex.DecodeString(strings.TrimPrefix(args[0], "0x"))
+	if err != nil || len(hash) == 0 {
+		return nil, invalidParams("invalid model hash")
+	}
+	m, err := s.state.GetModel(hash)
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return m, nil
+}
+
+// listModels handles zion_listModels([query[, page]]), listing the
+// registered models matching a state.ModelQuery by name and version.
+// Deprecated models are listed only if the query asks for them.
+func (s *Server) listModels(params json.RawMessage) (interface{}, *RPCError) {
+	var args []json.RawMessage
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, invalidParams("invalid params")
+		}
+	}
+	var q state.ModelQuery
+	if len(args) > 0 && string(args[0]) != "null" {
+		if err := json.Unmarshal(args[0], &q); err != nil {
+			return nil, invalidParams("invalid model query")
+		}
+	}
+	var page PageArgs
+	if len(args) > 1 {
+		var rpcErr *RPCError
+		if page, rpcErr = parsePage(args[1]); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+	out, rpcErr := paginate(s.state.Models(q), page)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	return out, nil
+}
@@ -0,0 +1,98 @@
+package rpc
+
+import "encoding/json"
+
+// getNFTCollection handles zion_getNFTCollection(symbol).
+func (s *Server) getNFTCollection(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	c, err := s.state.GetNFTCollection(args[0])
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return c, nil
+}
+
+// listNFTCollections handles zion_listNFTCollections([page]), listing the
+// ZRC-721 collections by symbol.
+func (s *Server) listNFTCollections(params json.RawMessage) (interface{}, *RPCError) {
+	var args []json.RawMessage
+	if len(params) > 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, invalidParams("invalid params")
+		}
+	}
+	var page PageArgs
+	if len(args) > 0 {
+		var rpcErr *RPCError
+		if page, rpcErr = parsePage(args[0]); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+	return paginate(s.state.NFTCollections(), page)
+}
+
+// getNFT handles zion_getNFT(collection, id).
+func (s *Server) getNFT(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) < 2 {
+		return nil, invalidParams("invalid params")
+	}
+	n, err := s.state.GetNFT(args[0], args[1])
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return n, nil
+}
+
+// getNFTsByOwner handles zion_getNFTsByOwner(address, [page]), listing the
+// tokens of the address by collection and id.
+func (s *Server) getNFTsByOwner(params json.RawMessage) (interface{}, *RPCError) {
+	var args []json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	var addr string
+	if err := json.Unmarshal(args[0], &addr); err != nil {
+		return nil, invalidParams("invalid address")
+	}
+	owner, rpcErr := parseAddress(addr)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	var page PageArgs
+	if len(args) > 1 {
+		if page, rpcErr = parsePage(args[1]); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+	nfts, next, total := s.state.NFTsByOwner(owner, page.Cursor, page.limit())
+	return newPage(nfts, next, page, total), nil
+}
+
+// getNFTsByCollection handles zion_getNFTsByCollection(symbol, [page]),
+// listing the tokens of the collection by id.
+func (s *Server) getNFTsByCollection(params json.RawMessage) (interface{}, *RPCError) {
+	var args []json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	var symbol string
+	if err := json.Unmarshal(args[0], &symbol); err != nil {
+		return nil, invalidParams("invalid collection")
+	}
+	if _, err := s.state.GetNFTCollection(symbol); err != nil {
+		return nil, errorFrom(err)
+	}
+	var page PageArgs
+	if len(args) > 1 {
+		var rpcErr *RPCError
+		if page, rpcErr = parsePage(args[1]); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+	nfts, next, total := s.state.NFTsByCollection(symbol, page.Cursor, page.limit())
+	return newPage(nfts, next, page, total), nil
+}
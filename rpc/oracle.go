@@ -0,0 +1,62 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// getEvaluator handles zion_getEvaluator(address), returning a member of
+// the quality oracle committee, active or unbonding.
+func (s *Server) getEvaluator(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	ev, err := s.state.GetEvaluator(args[0])
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return ev, nil
+}
+
+// getEvaluators handles zion_getEvaluators([page]), listing the quality
+// oracle committee by address.
+func (s *Server) getEvaluators(params json.RawMessage) (interface{}, *RPCError) {
+	var args []json.RawMessage
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, invalidParams("invalid params")
+		}
+	}
+	var page PageArgs
+	if len(args) > 0 {
+		var rpcErr *RPCError
+		if page, rpcErr = parsePage(args[0]); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+	out, rpcErr := paginate(s.state.Evaluators(), page)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	return out, nil
+}
+
+// getEvaluation handles zion_getEvaluation(txHash), returning the quality
+// evaluation of the inference receipt included by the transaction.
+func (s *Server) getEvaluation(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	raw, err := hex.DecodeString(strings.TrimPrefix(args[0], "0x"))
+	if err != nil || len(raw) != 32 {
+		return nil, invalidParams("invalid hash")
+	}
+	e, err := s.state.GetEvaluation(raw)
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return e, nil
+}
@@ -0,0 +1,96 @@
+package rpc
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+const (
+	// DefaultPageSize and MaxPageSize bound the items returned by one call
+	// of a listing method.
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+
+	// DefaultMaxResponseSize caps an encoded response in bytes.
+	DefaultMaxResponseSize = 10 * 1024 * 1024
+)
+
+// PageArgs selects a page of a listing. Cursor is the value of Next from
+// the previous page, or empty for the first one. Limit defaults to
+// DefaultPageSize and is capped at MaxPageSize.
+type PageArgs struct {
+	Cursor string `json:"cursor"`
+	Limit  int    `json:"limit"`
+}
+
+// Page is the envelope returned by every listing method. Next is the
+// cursor of the following page and is empty on the last one; Total counts
+// all items of the listing, across pages.
+type Page[T any] struct {
+	Items []T    `json:"items"`
+	Next  string `json:"next,omitempty"`
+	Limit int    `json:"limit"`
+	Total int    `json:"total"`
+}
+
+func (p *PageArgs) limit() int {
+	switch {
+	case p.Limit <= 0:
+		return DefaultPageSize
+	case p.Limit > MaxPageSize:
+		return MaxPageSize
+	}
+	return p.Limit
+}
+
+// offset decodes a cursor that is a position in the listing.
+func (p *PageArgs) offset() (int, *RPCError) {
+	if p.Cursor == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(p.Cursor)
+	if err != nil || n < 0 {
+		return 0, invalidParams("invalid cursor")
+	}
+	return n, nil
+}
+
+// parsePage decodes an optional PageArgs from raw.
+func parsePage(raw json.RawMessage) (PageArgs, *RPCError) {
+	var p PageArgs
+	if len(raw) == 0 || string(raw) == "null" {
+		return p, nil
+	}
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return p, invalidParams("invalid page")
+	}
+	return p, nil
+}
+
+// newPage builds the envelope for items. A nil items encodes as an empty
+// list.
+func newPage[T any](items []T, next string, args PageArgs, total int) Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return Page[T]{Items: items, Next: next, Limit: args.limit(), Total: total}
+}
+
+// paginate returns the page of all selected by a position cursor.
+func paginate[T any](all []T, args PageArgs) (Page[T], *RPCError) {
+	start, rpcErr := args.offset()
+	if rpcErr != nil {
+		return Page[T]{}, rpcErr
+	}
+	if start > len(all) {
+		start = len(all)
+	}
+	end := start + args.limit()
+	next := ""
+	if end < len(all) {
+		next = strconv.Itoa(end)
+	} else {
+		end = len(all)
+	}
+	return newPage(all[start:end], next, args, len(all)), nil
+}
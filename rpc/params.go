@@ -0,0 +1,24 @@
+package rpc
+
+import (
+	"encoding/json"
+
+	"github.com/zionlayer/zionlayer/core/state"
+)
+
+// ParamsResult is returned by zion_getParams.
+type ParamsResult struct {
+	Params  state.Params          `json:"params"`
+	Forks   []*state.ParamVersion `json:"forks"`   // scheduled, soonest first
+	History []*state.ParamVersion `json:"history"` // activated, in order
+}
+
+// getParams handles zion_getParams(), returning the protocol parameters in
+// effect with the parameter forks scheduled and activated.
+func (s *Server) getParams(json.RawMessage) (interface{}, *RPCError) {
+	return ParamsResult{
+		Params:  s.state.GetParams(),
+		Forks:   s.state.ParamForks(),
+		History: s.state.ParamHistory(),
+	}, nil
+}
@@ -0,0 +1,69 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// getProvider handles zion_getProvider(address), returning a registered
+// compute provider, active or unbonding.
+func (s *Server) getProvider(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	p, err := s.state.GetProvider(args[0])
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return p, nil
+}
+
+// getProviders handles zion_getProviders([page]), listing the registered
+// compute providers by address.
+func (s *Server) getProviders(params json.RawMessage) (interface{}, *RPCError) {
+	var args []json.RawMessage
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, invalidParams("invalid params")
+		}
+	}
+	var page PageArgs
+	if len(args) > 0 {
+		var rpcErr *RPCError
+		if page, rpcErr = parsePage(args[0]); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+	out, rpcErr := paginate(s.state.Providers(), page)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	return out, nil
+}
+
+// getEnclaves handles zion_getEnclaves(), listing the approved TEE enclave
+// images.
+func (s *Server) getEnclaves(params json.RawMessage) (interface{}, *RPCError) {
+	return s.state.EnclaveImages(), nil
+}
+
+// getVerifyingKey handles zion_getVerifyingKey(modelHash), returning the
+// hex Groth16 verifying key registered for the circuit of a model.
+func (s *Server) getVerifyingKey(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	model, err := hex.DecodeString(strings.TrimPrefix(args[0], "0x"))
+	if err != nil || len(model) == 0 {
+		return nil, invalidParams("invalid model hash")
+	}
+	vk, err := s.state.GetVerifyingKey(model)
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return fmt.Sprintf("0x%x", vk), nil
+}
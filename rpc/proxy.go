@@ -0,0 +1,77 @@
+package rpc
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// proxySet is the set of reverse proxies trusted to report the client
+// address in X-Forwarded-For.
+type proxySet []*net.IPNet
+
+// parseProxies parses a list of IP addresses and CIDR ranges.
+func parseProxies(list []string) (proxySet, error) {
+	var set proxySet
+	for _, entry := range list {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("trusted proxy %q: invalid IP address", entry)
+			}
+			bits := 8 * len(ip.To4())
+			if bits == 0 {
+				bits = 8 * net.IPv6len
+			}
+			set = append(set, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", entry, err)
+		}
+		set = append(set, ipNet)
+	}
+	return set, nil
+}
+
+func (p proxySet) contains(ip net.IP) bool {
+	for _, n := range p {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the address of the client behind r. Requests arriving
+// from a trusted proxy are attributed to the rightmost X-Forwarded-For
+// entry that is not itself a trusted proxy; the header is ignored
+// otherwise, since any client can set it.
+func (s *Server) clientIP(r *http.Request) string {
+	peer := remoteIP(r)
+	if len(s.proxies) == 0 {
+		return peer
+	}
+	ip := net.ParseIP(peer)
+	if ip == nil || !s.proxies.contains(ip) {
+		return peer
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		if !s.proxies.contains(hop) {
+			return hop.String()
+		}
+		peer = hop.String()
+	}
+	return peer
+}
@@ -0,0 +1,200 @@
+package rpc
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/zionlayer/zionlayer/core/did"
+	"github.com/zionlayer/zionlayer/telemetry"
+	"go.uber.org/zap"
+)
+
+// openAPISpec describes the REST gateway; the routes in NewGateway follow
+// it and it is served at /v1/openapi.yaml.
+//
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// Gateway serves a REST view of the JSON-RPC API on its own listener.
+// Requests are translated into JSON-RPC calls on the wrapped server, so
+// authentication, namespaces and rate limits apply unchanged.
+type Gateway struct {
+	rpc    *Server
+	config Config
+	http   *http.Server
+}
+
+// NewGateway creates a REST gateway for s. config supplies the listen
+// address, timeouts and TLS files; auth, CORS and trusted proxies are
+// taken from s.
+func NewGateway(s *Server, config Config) *Gateway {
+	g := &Gateway{rpc: s, config: config}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/openapi.yaml", g.spec)
+	mux.HandleFunc("GET /v1/accounts/{address}", g.getAccount)
+	mux.HandleFunc("GET /v1/agents/{did}", g.getAgent)
+	mux.HandleFunc("GET /v1/identifiers/{did}", g.resolveDID)
+	mux.HandleFunc("GET /v1/blocks/{height}", g.getBlock)
+	mux.HandleFunc("POST /v1/txs", g.sendTx)
+	g.http = &http.Server{
+		Addr:              net.JoinHostPort(config.Host, strconv.Itoa(config.Port)),
+		Handler:           g.middleware(mux),
+		ReadHeaderTimeout: config.ReadTimeout,
+		ReadTimeout:       config.ReadTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+	}
+	return g
+}
+
+// Start listens and serves until Shutdown. It returns nil after a clean
+// shutdown.
+func (g *Gateway) Start() error {
+	if g.rpc.configErr != nil {
+		return g.rpc.configErr
+	}
+	g.rpc.logger.Info("REST gateway starting",
+		zap.String("addr", g.http.Addr),
+		zap.Bool("tls", g.config.TLSCertFile != ""),
+	)
+	return serve(g.http, g.config)
+}
+
+// Shutdown stops the gateway, waiting for in-flight requests until ctx
+// expires.
+func (g *Gateway) Shutdown(ctx context.Context) error {
+	return g.http.Shutdown(ctx)
+}
+
+func (g *Gateway) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := g.rpc
+		if s.corsHeaders(w, r) {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if s.throttle(w, r) {
+			return
+		}
+		if err := s.authenticate(r); err != nil {
+			writeREST(w, http.StatusUnauthorized, restError(&RPCError{Code: CodeUnauthorized, Message: err.Error()}))
+			return
+		}
+		if s.config.MaxRequestSize > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxRequestSize)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (g *Gateway) spec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(openAPISpec)
+}
+
+func (g *Gateway) getAccount(w http.ResponseWriter, r *http.Request) {
+	g.call(w, r, "zion_getBalance", http.StatusOK, r.PathValue("address"))
+}
+
+func (g *Gateway) getAgent(w http.ResponseWriter, r *http.Request) {
+	g.call(w, r, "zion_getAgent", http.StatusOK, r.PathValue("did"))
+}
+
+// resolveDID serves a DID resolution result with the status codes of the
+// DIF universal resolver: 400 for an invalid DID, 404 for an unknown one,
+// 501 for another DID method and 410 for a deactivated one.
+func (g *Gateway) resolveDID(w http.ResponseWriter, r *http.Request) {
+	resp := g.dispatch(r, "zion_resolveDID", r.PathValue("did"))
+	if resp.Error != nil {
+		writeREST(w, restStatus(resp.Error), restError(resp.Error))
+		return
+	}
+	res := resp.Result.(did.Result)
+	status := http.StatusOK
+	switch {
+	case res.ResolutionMetadata.Error == did.ErrorInvalidDID:
+		status = http.StatusBadRequest
+	case res.ResolutionMetadata.Error == did.ErrorNotFound:
+		status = http.StatusNotFound
+	case res.ResolutionMetadata.Error == did.ErrorMethodNotSupported:
+		status = http.StatusNotImplemented
+	case res.DocumentMetadata != nil && res.DocumentMetadata.Deactivated:
+		status = http.StatusGone
+	}
+	writeREST(w, status, res)
+}
+
+func (g *Gateway) getBlock(w http.ResponseWriter, r *http.Request) {
+	height, err := strconv.ParseUint(r.PathValue("height"), 10, 64)
+	if err != nil {
+		writeREST(w, http.StatusBadRequest, restError(invalidParams("invalid height")))
+		return
+	}
+	full := r.URL.Query().Get("full") == "true"
+	g.call(w, r, "zion_getBlockByHeight", http.StatusOK, height, full)
+}
+
+func (g *Gateway) sendTx(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Tx string `json:"tx"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Tx == "" {
+		writeREST(w, http.StatusBadRequest, restError(invalidParams(`body must be {"tx": "0x..."}`)))
+		return
+	}
+	resp := g.dispatch(r, "zion_sendRawTransaction", body.Tx)
+	if resp.Error != nil {
+		writeREST(w, restStatus(resp.Error), restError(resp.Error))
+		return
+	}
+	writeREST(w, http.StatusAccepted, map[string]interface{}{"hash": resp.Result})
+}
+
+// call runs method with params and writes its result with status ok.
+func (g *Gateway) call(w http.ResponseWriter, r *http.Request, method string, ok int, params ...interface{}) {
+	resp := g.dispatch(r, method, params...)
+	if resp.Error != nil {
+		writeREST(w, restStatus(resp.Error), restError(resp.Error))
+		return
+	}
+	writeREST(w, ok, resp.Result)
+}
+
+func (g *Gateway) dispatch(r *http.Request, method string, params ...interface{}) Response {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return Response{Error: invalidParams(err.Error())}
+	}
+	return g.rpc.dispatch(telemetry.Extract(r.Context(), r.Header), &Request{JSONRPC: "2.0", Method: method, Params: raw}, g.rpc.clientIP(r))
+}
+
+// restStatus maps a JSON-RPC error onto an HTTP status.
+func restStatus(e *RPCError) int {
+	switch e.Code {
+	case CodeParseError, CodeInvalidRequest, CodeInvalidParams:
+		return http.StatusBadRequest
+	case CodeMethodNotFound, CodeNotFound, CodeAgentNotFound:
+		return http.StatusNotFound
+	case CodeUnauthorized:
+		return http.StatusUnauthorized
+	case CodeLimitExceeded:
+		return http.StatusTooManyRequests
+	case CodeServerError, CodeInternalError:
+		return http.StatusInternalServerError
+	}
+	return http.StatusUnprocessableEntity
+}
+
+func restError(e *RPCError) interface{} {
+	return map[string]*RPCError{"error": e}
+}
+
+func writeREST(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
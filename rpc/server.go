@@ -1,13 +1,32 @@
 package rpc
 
 import (
+	"context"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/zionlayer/zionlayer/consensus"
+	"github.com/zionlayer/zionlayer/core/cache"
+	"github.com/zionlayer/zionlayer/core/chain"
+	"github.com/zionlayer/zionlayer/core/common"
+	"github.com/zionlayer/zionlayer/core/event"
 	"github.com/zionlayer/zionlayer/core/mempool"
 	"github.com/zionlayer/zionlayer/core/state"
 	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/indexer"
+	"github.com/zionlayer/zionlayer/telemetry"
+	"github.com/zionlayer/zionlayer/version"
+	"github.com/zionlayer/zionlayer/vm"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -29,71 +48,530 @@ type Response struct {
 
 // RPCError represents a JSON-RPC error object.
 type RPCError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return e.Message
+}
+
+// CallArgs are the parameters of zion_call and zion_estimateGas.
+type CallArgs struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Gas  uint64 `json:"gas"`
+	Data string `json:"data"` // hex-encoded bytecode
+}
+
+// Config holds the HTTP server parameters.
+type Config struct {
+	// Host is the interface to listen on; empty means all interfaces.
+	Host string
+	Port int
+	// Namespaces lists the method namespaces served, e.g. "zion".
+	Namespaces []string
+	// APIKeys and JWTSecret, when set, require clients to authenticate.
+	APIKeys   []string
+	JWTSecret []byte
+	// CORSOrigins lists the browser origins allowed to call the server;
+	// "*" allows any.
+	CORSOrigins []string
+	// ReadTimeout bounds reading a whole request, body included.
+	ReadTimeout time.Duration
+	// WriteTimeout bounds handling a request and writing the response.
+	WriteTimeout time.Duration
+	// IdleTimeout bounds how long a keep-alive connection waits for the
+	// next request.
+	IdleTimeout time.Duration
+	// MaxRequestSize caps the body of a request in bytes.
+	MaxRequestSize int64
+	// MaxResponseSize caps an encoded response in bytes; larger results
+	// are replaced by an error.
+	MaxResponseSize int
+	// TLSCertFile and TLSKeyFile, when both set, serve HTTPS. HTTP/2 is
+	// negotiated automatically over TLS.
+	TLSCertFile string
+	TLSKeyFile  string
+	// DataDir is where admin_exportSnapshot writes state snapshots.
+	DataDir string
+	// TrustedProxies lists the IP addresses or CIDR ranges of reverse
+	// proxies whose X-Forwarded-For header identifies the client.
+	TrustedProxies []string
+	// MaxBlockAge is how old the latest block may be before /readyz
+	// reports the node out of sync; zero disables the limit.
+	MaxBlockAge time.Duration
+	// MinPeers is the number of peers /readyz requires when peer-to-peer
+	// networking is enabled.
+	MinPeers int
+}
+
+// serve runs srv with the TLS settings of config until it is shut down.
+func serve(srv *http.Server, config Config) error {
+	var err error
+	if config.TLSCertFile != "" || config.TLSKeyFile != "" {
+		err = srv.ListenAndServeTLS(config.TLSCertFile, config.TLSKeyFile)
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// DefaultConfig returns the default server parameters for port.
+func DefaultConfig(port int) Config {
+	return Config{
+		Port:            port,
+		Namespaces:      PublicNamespaces,
+		ReadTimeout:     10 * time.Second,
+		WriteTimeout:    30 * time.Second,
+		IdleTimeout:     2 * time.Minute,
+		MaxRequestSize:  5 * 1024 * 1024,
+		MaxResponseSize: DefaultMaxResponseSize,
+		MaxBlockAge:     30 * time.Second,
+		MinPeers:        1,
+	}
 }
 
 // Server is the ZionLayer JSON-RPC server.
 type Server struct {
 	state   *state.StateDB
 	pool    *mempool.Pool
+	chain   *chain.Store
+	avm     *vm.AVM
 	logger  *zap.Logger
-	port    int
+	chainID uint64
+	config  Config
+	proxies proxySet
+	limiter *rateLimiter // transaction submissions
+	reqRate *rateLimiter // all requests
+	http    *http.Server
+
+	// configErr is a Config problem found by NewServer and reported by
+	// Start.
+	configErr error
+
+	deprecated sync.Once
+
+	// Node operations for the admin_ namespace; see admin.go.
+	peers    PeerManager
+	logLevel *zap.AtomicLevel
+
+	engine *consensus.ZionBFT // see consensus.go
+
+	indexer *indexer.Indexer // see stats.go
+
+	accounts *cache.LRU[accountKey, state.Account] // see cache.go
+
+	events     *event.Bus // see SetEventBus
+	eventsStop chan struct{}
+	stopEvents sync.Once
+
+	sessMu   sync.Mutex
+	sessions map[*wsSession]struct{}
+
+	subMu sync.Mutex
+	subs  map[string]*subscription
+
+	filters filterSet
 }
 
 // NewServer creates a new RPC server.
-func NewServer(stateDB *state.StateDB, pool *mempool.Pool, logger *zap.Logger, port int) *Server {
-	return &Server{state: stateDB, pool: pool, logger: logger, port: port}
+func NewServer(stateDB *state.StateDB, pool *mempool.Pool, chainStore *chain.Store, avm *vm.AVM, logger *zap.Logger, chainID uint64, config Config) *Server {
+	s := &Server{state: stateDB, pool: pool, chain: chainStore, avm: avm, logger: logger, chainID: chainID, config: config}
+	s.accounts = cache.NewLRU[accountKey, state.Account](AccountCacheSize)
+	s.proxies, s.configErr = parseProxies(config.TrustedProxies)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handle)
+	mux.HandleFunc("/livez", s.livez)
+	mux.HandleFunc("/readyz", s.readyz)
+	mux.HandleFunc("/health", s.livez) // deprecated alias of /livez
+	mux.HandleFunc("/ws", s.serveWS)
+	s.http = &http.Server{
+		Addr:              net.JoinHostPort(config.Host, strconv.Itoa(config.Port)),
+		Handler:           mux,
+		ReadHeaderTimeout: config.ReadTimeout,
+		ReadTimeout:       config.ReadTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+	}
+	return s
+}
+
+// SetTxRateLimit limits transaction submissions to rate per second from
+// each client IP, allowing bursts of up to burst transactions. A
+// non-positive rate disables limiting. It must be called before Start.
+func (s *Server) SetTxRateLimit(rate float64, burst int) {
+	if rate <= 0 {
+		s.limiter = nil
+		return
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	s.limiter = newRateLimiter(rate, burst)
 }
 
-// Start begins listening for RPC requests.
+// SetRequestRateLimit limits requests of any kind to rate per second from
+// each client IP, allowing bursts of up to burst requests. Clients over the
+// limit get HTTP 429. A non-positive rate disables limiting. It must be
+// called before Start.
+func (s *Server) SetRequestRateLimit(rate float64, burst int) {
+	if rate <= 0 {
+		s.reqRate = nil
+		return
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	s.reqRate = newRateLimiter(rate, burst)
+}
+
+// throttle answers r with HTTP 429 if its client is over the request rate
+// limit, and reports whether it did.
+func (s *Server) throttle(w http.ResponseWriter, r *http.Request) bool {
+	if s.reqRate == nil || s.reqRate.allow(s.clientIP(r)) {
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", "1")
+	w.WriteHeader(http.StatusTooManyRequests)
+	writeError(w, nil, CodeLimitExceeded, "rate limit exceeded")
+	return true
+}
+
+// Start begins listening for RPC requests. It blocks until the server is
+// shut down, in which case it returns nil.
 func (s *Server) Start() error {
-	mux := http.NewServeMux()
-	mux.HandleFunc("/", s.handle)
-	mux.HandleFunc("/health", s.health)
-	addr := fmt.Sprintf(":%d", s.port)
-	s.logger.Info("RPC server starting", zap.String("addr", addr))
-	return http.ListenAndServe(addr, mux)
+	if s.configErr != nil {
+		return s.configErr
+	}
+	s.logger.Info("RPC server starting",
+		zap.String("addr", s.http.Addr),
+		zap.Bool("tls", s.config.TLSCertFile != ""),
+	)
+	return serve(s.http, s.config)
+}
+
+// Shutdown stops accepting requests, closes WebSocket connections and waits
+// for in-flight requests to finish or ctx to expire.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.sessMu.Lock()
+	sessions := s.sessions
+	s.sessions = nil
+	s.sessMu.Unlock()
+	for sess := range sessions {
+		sess.close()
+	}
+	if s.eventsStop != nil {
+		s.stopEvents.Do(func() { close(s.eventsStop) })
+	}
+	return s.http.Shutdown(ctx)
 }
 
 func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if s.corsHeaders(w, r) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if s.throttle(w, r) {
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
-	var req Request
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, nil, -32700, "parse error")
+	if err := s.authenticate(r); err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		writeError(w, nil, CodeUnauthorized, err.Error())
+		return
+	}
+	if s.config.MaxRequestSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxRequestSize)
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, nil, CodeInvalidRequest, "request too large")
+			return
+		}
+		writeError(w, nil, CodeParseError, "parse error")
 		return
 	}
+	req, rpcErr := parseRequest(body)
+	if rpcErr != nil {
+		json.NewEncoder(w).Encode(Response{JSONRPC: "2.0", ID: req.ID, Error: rpcErr})
+		return
+	}
+
+	resp := s.dispatch(telemetry.Extract(r.Context(), r.Header), req, s.clientIP(r))
+	w.Write(s.encodeResponse(resp))
+}
 
+// encodeResponse marshals resp, replacing a result larger than
+// Config.MaxResponseSize with an error.
+func (s *Server) encodeResponse(resp Response) []byte {
+	b, err := json.Marshal(resp)
+	if err != nil {
+		b, _ = json.Marshal(Response{JSONRPC: "2.0", ID: resp.ID, Error: &RPCError{Code: CodeInternalError, Message: err.Error()}})
+		return b
+	}
+	if max := s.config.MaxResponseSize; max > 0 && len(b) > max {
+		b, _ = json.Marshal(Response{JSONRPC: "2.0", ID: resp.ID, Error: &RPCError{
+			Code:    CodeLimitExceeded,
+			Message: fmt.Sprintf("response exceeds %d bytes; request a smaller page or range", max),
+		}})
+	}
+	return b
+}
+
+// parseRequest decodes a JSON-RPC request. On failure the returned request
+// still carries the ID if one could be recovered, so that the error
+// response can echo it.
+func parseRequest(body []byte) (*Request, *RPCError) {
+	req := new(Request)
+	if err := json.Unmarshal(body, req); err != nil {
+		// A well-formed object with a mistyped member still has a usable
+		// id; anything else is a parse error.
+		var partial struct {
+			ID interface{} `json:"id"`
+		}
+		if json.Unmarshal(body, &partial) != nil {
+			return req, &RPCError{Code: CodeParseError, Message: "parse error"}
+		}
+		req.ID = partial.ID
+		return req, &RPCError{Code: CodeInvalidRequest, Message: "invalid request"}
+	}
+	if req.Method == "" {
+		return req, &RPCError{Code: CodeInvalidRequest, Message: "missing method"}
+	}
+	return req, nil
+}
+
+// dispatch executes req on behalf of the client at ip.
+func (s *Server) dispatch(ctx context.Context, req *Request, ip string) Response {
 	var result interface{}
 	var rpcErr *RPCError
 
+	ctx, span := telemetry.Start(ctx, "rpc."+req.Method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(telemetry.AttrRPCMethod.String(req.Method)),
+	)
+	defer func() {
+		if rpcErr != nil {
+			telemetry.End(span, rpcErr)
+			return
+		}
+		span.End()
+	}()
+
+	if !s.namespaceAllowed(req.Method) {
+		return Response{JSONRPC: "2.0", ID: req.ID, Error: &RPCError{Code: CodeMethodNotFound, Message: "method not found"}}
+	}
 	switch req.Method {
 	case "zion_getBalance":
 		result, rpcErr = s.getBalance(req.Params)
+	case "zion_getTransactionCount":
+		result, rpcErr = s.getTransactionCount(req.Params)
 	case "zion_sendTransaction":
-		result, rpcErr = s.sendTransaction(req.Params)
+		if s.limiter != nil && !s.limiter.allow(ip) {
+			rpcErr = &RPCError{Code: CodeLimitExceeded, Message: "rate limit exceeded"}
+			break
+		}
+		result, rpcErr = s.sendTransaction(ctx, req.Params)
+	case "zion_sendRawTransaction":
+		if s.limiter != nil && !s.limiter.allow(ip) {
+			rpcErr = &RPCError{Code: CodeLimitExceeded, Message: "rate limit exceeded"}
+			break
+		}
+		result, rpcErr = s.sendRawTransaction(ctx, req.Params)
 	case "zion_getAgent":
 		result, rpcErr = s.getAgent(req.Params)
+	case "zion_getDelegations":
+		result, rpcErr = s.getDelegations(req.Params)
+	case "zion_verifyDelegation":
+		result, rpcErr = s.verifyDelegation(req.Params)
+	case "zion_getReputation":
+		result, rpcErr = s.getReputation(req.Params)
+	case "zion_getAgentProof":
+		result, rpcErr = s.getAgentProof(req.Params)
+	case "zion_getAgentHistory":
+		result, rpcErr = s.getAgentHistory(req.Params)
+	case "zion_resolveDID":
+		result, rpcErr = s.resolveDID(req.Params)
+	case "zion_listAgents":
+		result, rpcErr = s.listAgents(req.Params)
+	case "zion_getAgentsByController":
+		result, rpcErr = s.getAgentsByController(req.Params)
+	case "zion_searchAgents":
+		result, rpcErr = s.searchAgents(req.Params)
+	case "zion_getOffer":
+		result, rpcErr = s.getOffer(req.Params)
+	case "zion_getAgentOffers":
+		result, rpcErr = s.getAgentOffers(req.Params)
+	case "zion_searchOffers":
+		result, rpcErr = s.searchOffers(req.Params)
+	case "zion_getAgentMessages":
+		result, rpcErr = s.getAgentMessages(req.Params)
+	case "zion_getInferenceReceipt":
+		result, rpcErr = s.getInferenceReceipt(req.Params)
+	case "zion_getInferenceReceipts":
+		result, rpcErr = s.getInferenceReceipts(req.Params)
+	case "zion_getModelReceipts":
+		result, rpcErr = s.getModelReceipts(req.Params)
+	case "zion_getDispute":
+		result, rpcErr = s.getDispute(req.Params)
+	case "zion_getDataAttestations":
+		result, rpcErr = s.getDataAttestations(req.Params)
+	case "zion_getReceiptBatch":
+		result, rpcErr = s.getReceiptBatch(req.Params)
+	case "zion_verifyBatchedReceipt":
+		result, rpcErr = s.verifyBatchedReceipt(req.Params)
+	case "zion_getReveal":
+		result, rpcErr = s.getReveal(req.Params)
+	case "zion_getEvaluator":
+		result, rpcErr = s.getEvaluator(req.Params)
+	case "zion_getEvaluators":
+		result, rpcErr = s.getEvaluators(req.Params)
+	case "zion_getEvaluation":
+		result, rpcErr = s.getEvaluation(req.Params)
+	case "zion_getProvider":
+		result, rpcErr = s.getProvider(req.Params)
+	case "zion_getProviders":
+		result, rpcErr = s.getProviders(req.Params)
+	case "zion_getEnclaves":
+		result, rpcErr = s.getEnclaves(req.Params)
+	case "zion_getVerifyingKey":
+		result, rpcErr = s.getVerifyingKey(req.Params)
+	case "zion_getModel":
+		result, rpcErr = s.getModel(req.Params)
+	case "zion_listModels":
+		result, rpcErr = s.listModels(req.Params)
+	case "zion_call":
+		result, rpcErr = s.call(req.Params)
+	case "zion_estimateGas":
+		result, rpcErr = s.estimateGas(req.Params)
+	case "zion_simulateTransaction":
+		result, rpcErr = s.simulateTransaction(req.Params)
+	case "zion_getBlockByHeight":
+		result, rpcErr = s.getBlockByHeight(req.Params)
+	case "zion_getTransaction":
+		result, rpcErr = s.getTransaction(req.Params)
+	case "zion_getTransactionProof":
+		result, rpcErr = s.getTransactionProof(req.Params)
+	case "zion_getValidators":
+		result, rpcErr = s.getValidators()
+	case "zion_getValidatorStatus":
+		result, rpcErr = s.getValidatorStatus(req.Params)
+	case "zion_getSupply":
+		result, rpcErr = s.getSupply(req.Params)
+	case "zion_getStakingValidator":
+		result, rpcErr = s.getStakingValidator(req.Params)
+	case "zion_getProposal":
+		result, rpcErr = s.getProposal(req.Params)
+	case "zion_getProposals":
+		result, rpcErr = s.getProposals(req.Params)
+	case "zion_getUpgradePlan":
+		result, rpcErr = s.getUpgradePlan(req.Params)
+	case "zion_getParams":
+		result, rpcErr = s.getParams(req.Params)
+	case "zion_getIBCClient":
+		result, rpcErr = s.getIBCClient(req.Params)
+	case "zion_getIBCConnection":
+		result, rpcErr = s.getIBCConnection(req.Params)
+	case "zion_getIBCChannels":
+		result, rpcErr = s.getIBCChannels(req.Params)
+	case "zion_getIBCPackets":
+		result, rpcErr = s.getIBCPackets(req.Params)
+	case "zion_getIBCProof":
+		result, rpcErr = s.getIBCProof(req.Params)
+	case "zion_getIBCPacketProof":
+		result, rpcErr = s.getIBCPacketProof(req.Params)
+	case "zion_getIBCVouchers":
+		result, rpcErr = s.getIBCVouchers(req.Params)
+	case "zion_getBridge":
+		result, rpcErr = s.getBridge(req.Params)
+	case "zion_getBridgeDeposit":
+		result, rpcErr = s.getBridgeDeposit(req.Params)
+	case "zion_getBridgeWithdrawals":
+		result, rpcErr = s.getBridgeWithdrawals(req.Params)
+	case "zion_getBridgeWithdrawalProof":
+		result, rpcErr = s.getBridgeWithdrawalProof(req.Params)
+	case "zion_getBridgeBalances":
+		result, rpcErr = s.getBridgeBalances(req.Params)
+	case "zion_getToken":
+		result, rpcErr = s.getToken(req.Params)
+	case "zion_listTokens":
+		result, rpcErr = s.listTokens(req.Params)
+	case "zion_getTokenBalance":
+		result, rpcErr = s.getTokenBalance(req.Params)
+	case "zion_getTokenBalances":
+		result, rpcErr = s.getTokenBalances(req.Params)
+	case "zion_getTokenAllowance":
+		result, rpcErr = s.getTokenAllowance(req.Params)
+	case "zion_getNFTCollection":
+		result, rpcErr = s.getNFTCollection(req.Params)
+	case "zion_listNFTCollections":
+		result, rpcErr = s.listNFTCollections(req.Params)
+	case "zion_getNFT":
+		result, rpcErr = s.getNFT(req.Params)
+	case "zion_getNFTsByOwner":
+		result, rpcErr = s.getNFTsByOwner(req.Params)
+	case "zion_getNFTsByCollection":
+		result, rpcErr = s.getNFTsByCollection(req.Params)
+	case "zion_getChainStats":
+		result, rpcErr = s.getChainStats(ctx, req.Params)
+	case "zion_getAddressActivity":
+		result, rpcErr = s.getAddressActivity(ctx, req.Params)
+	case "zion_getStateRoot":
+		result, rpcErr = s.getStateRoot(req.Params)
+	case "zion_getHeader":
+		result, rpcErr = s.getHeader(req.Params)
+	case "zion_getBlockByHash":
+		result, rpcErr = s.getBlockByHash(req.Params)
+	case "zion_getLogs":
+		result, rpcErr = s.getLogs(req.Params)
+	case "zion_newFilter":
+		result, rpcErr = s.newFilter(req.Params)
+	case "zion_getFilterChanges":
+		result, rpcErr = s.getFilterChanges(req.Params)
+	case "zion_uninstallFilter":
+		result, rpcErr = s.uninstallFilter(req.Params)
 	case "zion_getMempoolSize":
 		result = map[string]int{"size": s.pool.Size()}
+	case "zion_mempoolContent":
+		result = s.mempoolContent()
+	case "zion_mempoolInspect":
+		result = s.mempoolInspect()
 	case "zion_chainId":
-		result = "0x1" // chain ID 1 for devnet
+		result = fmt.Sprintf("0x%x", s.chainID)
+	case "zion_clientVersion":
+		result = version.Get()
 	default:
-		rpcErr = &RPCError{Code: -32601, Message: "method not found"}
+		var ok bool
+		if result, rpcErr, ok = s.dispatchEth(ctx, req.Method, req.Params, ip); ok {
+			break
+		}
+		if result, rpcErr, ok = s.dispatchAdmin(req.Method, req.Params); !ok {
+			rpcErr = &RPCError{Code: CodeMethodNotFound, Message: "method not found"}
+		}
 	}
 
-	resp := Response{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr}
-	json.NewEncoder(w).Encode(resp)
+	return Response{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr}
 }
 
 func (s *Server) getBalance(params json.RawMessage) (interface{}, *RPCError) {
 	var args []string
 	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
-		return nil, &RPCError{Code: -32602, Message: "invalid params"}
+		return nil, invalidParams("invalid params")
 	}
-	acc := s.state.GetAccount(args[0])
+	addr, rpcErr := parseAddress(args[0])
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	acc := s.account(addr)
 	return map[string]string{
 		"address": acc.Address,
 		"balance": acc.Balance.String(),
@@ -101,34 +579,196 @@ func (s *Server) getBalance(params json.RawMessage) (interface{}, *RPCError) {
 	}, nil
 }
 
-func (s *Server) sendTransaction(params json.RawMessage) (interface{}, *RPCError) {
+// getTransactionCount returns the next nonce of an address, counting its
+// pooled transactions when the block tag is "pending".
+func (s *Server) getTransactionCount(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	addr, rpcErr := parseAddress(args[0])
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if rpcErr := checkBlockTag(args[1:]); rpcErr != nil {
+		return nil, rpcErr
+	}
+	if len(args) > 1 && args[1] == "pending" {
+		return hexUint(s.pool.PendingNonce(addr)), nil
+	}
+	return hexUint(s.account(addr).Nonce), nil
+}
+
+// sendTransaction accepts a transaction as JSON.
+//
+// Deprecated: the JSON form has no canonical encoding, so what the client
+// signed and what the node decodes can differ. Clients should sign locally
+// and submit the binary envelope with zion_sendRawTransaction.
+func (s *Server) sendTransaction(ctx context.Context, params json.RawMessage) (interface{}, *RPCError) {
+	s.deprecated.Do(func() {
+		s.logger.Warn("zion_sendTransaction is deprecated; use zion_sendRawTransaction")
+	})
 	var txs []*transaction.Tx
 	if err := json.Unmarshal(params, &txs); err != nil || len(txs) == 0 {
-		return nil, &RPCError{Code: -32602, Message: "invalid params"}
+		return nil, invalidParams("invalid params")
 	}
 	tx := txs[0]
-	if err := s.pool.Add(tx); err != nil {
-		return nil, &RPCError{Code: -32000, Message: err.Error()}
+	if err := tx.ValidateBasic(); err != nil {
+		return nil, invalidParams(err.Error())
+	}
+	if err := s.pool.AddContext(ctx, tx); err != nil {
+		return nil, errorFrom(err)
 	}
 	hash := tx.Hash()
 	return fmt.Sprintf("0x%x", hash), nil
 }
 
+// sendRawTransaction decodes a hex-encoded binary transaction envelope,
+// signed by the client, and submits it to the pool. The pool's verifier
+// checks the signature, so smart-account senders are handled the same way
+// as key-signed ones.
+func (s *Server) sendRawTransaction(ctx context.Context, params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	raw, err := hex.DecodeString(strings.TrimPrefix(args[0], "0x"))
+	if err != nil {
+		return nil, invalidParams("invalid hex")
+	}
+	tx := new(transaction.Tx)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return nil, invalidParams(err.Error())
+	}
+	if err := tx.ValidateBasic(); err != nil {
+		return nil, invalidParams(err.Error())
+	}
+	if err := s.pool.AddContext(ctx, tx); err != nil {
+		return nil, errorFrom(err)
+	}
+	return fmt.Sprintf("0x%x", tx.Hash()), nil
+}
+
 func (s *Server) getAgent(params json.RawMessage) (interface{}, *RPCError) {
 	var args []string
 	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
-		return nil, &RPCError{Code: -32602, Message: "invalid params"}
+		return nil, invalidParams("invalid params")
 	}
 	rec, err := s.state.GetAgent(args[0])
 	if err != nil {
-		return nil, &RPCError{Code: -32000, Message: err.Error()}
+		return nil, errorFrom(err)
 	}
 	return rec, nil
 }
 
-func (s *Server) health(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+func (s *Server) call(params json.RawMessage) (interface{}, *RPCError) {
+	msg, rpcErr := parseCallArgs(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	res, err := s.avm.Call(s.state, msg, 0)
+	if err != nil {
+		return nil, callError(err, res)
+	}
+	return fmt.Sprintf("0x%x", res.ReturnData), nil
+}
+
+func (s *Server) estimateGas(params json.RawMessage) (interface{}, *RPCError) {
+	msg, rpcErr := parseCallArgs(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	gas, err := s.avm.EstimateGas(s.state, msg, 0)
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return fmt.Sprintf("0x%x", gas), nil
+}
+
+// mempoolContent returns the pooled transactions, split into pending and
+// queued, keyed by sender and then by nonce.
+func (s *Server) mempoolContent() interface{} {
+	group := func(bySender map[string][]*transaction.Tx) map[string]map[string]*transaction.Tx {
+		out := make(map[string]map[string]*transaction.Tx, len(bySender))
+		for from, txs := range bySender {
+			out[from] = make(map[string]*transaction.Tx, len(txs))
+			for _, tx := range txs {
+				out[from][fmt.Sprintf("%d", tx.Nonce)] = tx
+			}
+		}
+		return out
+	}
+	return map[string]interface{}{
+		"pending": group(s.pool.Pending()),
+		"queued":  group(s.pool.Queued()),
+	}
+}
+
+// mempoolInspect is mempoolContent with each transaction reduced to a one
+// line summary.
+func (s *Server) mempoolInspect() interface{} {
+	group := func(bySender map[string][]*transaction.Tx) map[string]map[string]string {
+		out := make(map[string]map[string]string, len(bySender))
+		for from, txs := range bySender {
+			out[from] = make(map[string]string, len(txs))
+			for _, tx := range txs {
+				out[from][fmt.Sprintf("%d", tx.Nonce)] = inspectTx(tx)
+			}
+		}
+		return out
+	}
+	return map[string]interface{}{
+		"pending": group(s.pool.Pending()),
+		"queued":  group(s.pool.Queued()),
+	}
+}
+
+func inspectTx(tx *transaction.Tx) string {
+	to := tx.To
+	if to == "" {
+		to = "contract creation"
+	}
+	value, price := "0", "0"
+	if tx.Value != nil {
+		value = tx.Value.String()
+	}
+	if tx.GasPrice != nil {
+		price = tx.GasPrice.String()
+	}
+	return fmt.Sprintf("%s: %s wei + %d gas × %s wei", to, value, tx.Gas, price)
+}
+
+func parseCallArgs(params json.RawMessage) (msg vm.CallMsg, rpcErr *RPCError) {
+	var args []CallArgs
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return vm.CallMsg{}, invalidParams("invalid params")
+	}
+	data, err := hex.DecodeString(strings.TrimPrefix(args[0].Data, "0x"))
+	if err != nil {
+		return vm.CallMsg{}, invalidParams("invalid data")
+	}
+	msg = vm.CallMsg{Gas: args[0].Gas, Data: data}
+	if args[0].From != "" {
+		if msg.From, rpcErr = parseAddress(args[0].From); rpcErr != nil {
+			return vm.CallMsg{}, rpcErr
+		}
+	}
+	if args[0].To != "" {
+		if msg.To, rpcErr = parseAddress(args[0].To); rpcErr != nil {
+			return vm.CallMsg{}, rpcErr
+		}
+	}
+	return msg, nil
+}
+
+// parseAddress strictly parses an address parameter and returns it in the
+// canonical form used to key state.
+func parseAddress(s string) (string, *RPCError) {
+	addr, err := common.ParseAddress(s)
+	if err != nil {
+		return "", invalidParams(err.Error())
+	}
+	return addr.String(), nil
 }
 
 func writeError(w http.ResponseWriter, id interface{}, code int, msg string) {
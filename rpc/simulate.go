@@ -0,0 +1,178 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// SimulationResult is the outcome of zion_simulateTransaction. Quantities
+// and byte strings are 0x-prefixed hex.
+type SimulationResult struct {
+	Status     string      `json:"status"` // "success" or "failed"
+	Error      string      `json:"error,omitempty"`
+	GasUsed    string      `json:"gasUsed"`
+	Fee        string      `json:"fee"`
+	FeePayer   string      `json:"feePayer"`
+	Contract   string      `json:"contract,omitempty"`
+	ReturnData string      `json:"returnData"`
+	Logs       []LogResult `json:"logs"`
+	StateDiff  StateDiff   `json:"stateDiff"`
+}
+
+// StateDiff lists the state changes a simulated transaction would make.
+type StateDiff struct {
+	Accounts []AccountDiff              `json:"accounts"`
+	Agents   []AgentDiff                `json:"agents"`
+	Messages []transaction.AgentMessage `json:"messages"`
+}
+
+// AccountDiff holds the changed fields of one account; unchanged fields
+// are omitted.
+type AccountDiff struct {
+	Address string         `json:"address"`
+	Created bool           `json:"created,omitempty"`
+	Deleted bool           `json:"deleted,omitempty"`
+	Balance *ValueDiff     `json:"balance,omitempty"`
+	Nonce   *ValueDiff     `json:"nonce,omitempty"`
+	Code    *ValueDiff     `json:"code,omitempty"`
+	Storage []StorageEntry `json:"storage,omitempty"`
+}
+
+// ValueDiff is a value before and after the transaction.
+type ValueDiff struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// StorageEntry is a changed storage slot. An empty slot reads "0x".
+type StorageEntry struct {
+	Key  string `json:"key"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// AgentDiff is a registered or modified agent record. From is nil for a
+// newly registered agent.
+type AgentDiff struct {
+	ID   string             `json:"id"`
+	From *state.AgentRecord `json:"from"`
+	To   *state.AgentRecord `json:"to"`
+}
+
+// simulateTransaction executes a transaction against the latest state
+// without committing it. The transaction is raw hex as accepted by
+// zion_sendRawTransaction or a JSON object; in either case it need not be
+// signed.
+func (s *Server) simulateTransaction(params json.RawMessage) (interface{}, *RPCError) {
+	var args []json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 || len(args) > 2 {
+		return nil, invalidParams("invalid params")
+	}
+	if len(args) == 2 {
+		var tag string
+		if err := json.Unmarshal(args[1], &tag); err != nil {
+			return nil, invalidParams("invalid block tag")
+		}
+		if rpcErr := checkBlockTag([]string{tag}); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+	tx, rpcErr := s.parseSimulatedTx(args[0])
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	res, err := s.avm.Simulate(s.state, tx, s.chainID, s.chain.Head()+1)
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	r := res.Receipt
+	out := &SimulationResult{
+		Status:     "success",
+		Error:      r.Error,
+		GasUsed:    hexUint(r.GasUsed),
+		Fee:        hexBig(r.Fee),
+		FeePayer:   r.FeePayer,
+		Contract:   r.Contract,
+		ReturnData: fmt.Sprintf("0x%x", res.ReturnData),
+		Logs:       make([]LogResult, len(r.Logs)),
+		StateDiff:  newStateDiff(state.Diff(s.state, res.State)),
+	}
+	if r.Status != transaction.ReceiptSuccess {
+		out.Status = "failed"
+	}
+	for i, l := range r.Logs {
+		out.Logs[i] = newLogResult(l, r.TxHash, 0)
+	}
+	return out, nil
+}
+
+func (s *Server) parseSimulatedTx(raw json.RawMessage) (*transaction.Tx, *RPCError) {
+	tx := new(transaction.Tx)
+	var encoded string
+	if err := json.Unmarshal(raw, &encoded); err == nil {
+		b, err := hex.DecodeString(strings.TrimPrefix(encoded, "0x"))
+		if err != nil {
+			return nil, invalidParams("invalid hex")
+		}
+		if err := tx.UnmarshalBinary(b); err != nil {
+			return nil, invalidParams(err.Error())
+		}
+	} else if err := json.Unmarshal(raw, tx); err != nil {
+		return nil, invalidParams("invalid transaction")
+	}
+	if tx.ChainID == 0 {
+		tx.ChainID = s.chainID
+	}
+	if err := tx.ValidateBasic(); err != nil {
+		return nil, invalidParams(err.Error())
+	}
+	return tx, nil
+}
+
+func newStateDiff(d *state.StateDiff) StateDiff {
+	out := StateDiff{
+		Accounts: make([]AccountDiff, 0, len(d.Accounts)),
+		Agents:   make([]AgentDiff, 0, len(d.Agents)),
+		Messages: d.Messages,
+	}
+	if out.Messages == nil {
+		out.Messages = []transaction.AgentMessage{}
+	}
+	for _, a := range d.Accounts {
+		ad := AccountDiff{Address: a.Address, Created: a.Before == nil, Deleted: a.After == nil}
+		before, after := a.Before, a.After
+		if before == nil {
+			before = &state.Account{}
+		}
+		if after == nil {
+			after = &state.Account{}
+		}
+		if hexBig(before.Balance) != hexBig(after.Balance) {
+			ad.Balance = &ValueDiff{From: hexBig(before.Balance), To: hexBig(after.Balance)}
+		}
+		if before.Nonce != after.Nonce {
+			ad.Nonce = &ValueDiff{From: hexUint(before.Nonce), To: hexUint(after.Nonce)}
+		}
+		if from, to := fmt.Sprintf("0x%x", before.Code), fmt.Sprintf("0x%x", after.Code); from != to {
+			ad.Code = &ValueDiff{From: from, To: to}
+		}
+		for _, st := range a.Storage {
+			ad.Storage = append(ad.Storage, StorageEntry{
+				Key:  fmt.Sprintf("0x%x", st.Key),
+				From: fmt.Sprintf("0x%x", st.Before),
+				To:   fmt.Sprintf("0x%x", st.After),
+			})
+		}
+		out.Accounts = append(out.Accounts, ad)
+	}
+	for _, a := range d.Agents {
+		out.Agents = append(out.Agents, AgentDiff{ID: a.ID, From: a.Before, To: a.After})
+	}
+	return out
+}
@@ -0,0 +1,37 @@
+package rpc
+
+import (
+	"encoding/json"
+
+	"github.com/zionlayer/zionlayer/core/state"
+)
+
+// StakeDelegationsResult is returned by zion_getDelegations for an account
+// address.
+type StakeDelegationsResult struct {
+	Delegations []*state.StakeDelegation `json:"delegations"`
+	Unbondings  []*state.StakeUnbonding  `json:"unbondings"`
+}
+
+// stakeDelegations returns the stake addr has delegated to validators,
+// its self-stake included, and its stake awaiting release.
+func (s *Server) stakeDelegations(addr string) StakeDelegationsResult {
+	return StakeDelegationsResult{
+		Delegations: s.state.StakeDelegations(addr),
+		Unbondings:  s.state.StakeUnbondings(addr),
+	}
+}
+
+// getStakingValidator handles zion_getStakingValidator(address), returning
+// the description, commission and bonded stake of a validator.
+func (s *Server) getStakingValidator(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	v, err := s.state.GetStakingValidator(args[0])
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return v, nil
+}
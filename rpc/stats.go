@@ -0,0 +1,119 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/zionlayer/zionlayer/indexer"
+)
+
+// ErrNoIndexer is returned by the methods served from the SQL indexer when
+// the node runs without one.
+var ErrNoIndexer = errors.New("sql indexer not enabled")
+
+// ChainStatsResult is returned by zion_getChainStats. The agent and stake
+// figures come from the state; Index, the transaction totals and the
+// verified receipts per day, from the SQL indexer, and is nil when the
+// node runs without one.
+type ChainStatsResult struct {
+	Height       uint64         `json:"height"`
+	Agents       int            `json:"agents"`
+	ActiveAgents int            `json:"activeAgents"`
+	Validators   int            `json:"validators"`
+	TotalStake   *big.Int       `json:"totalStake"` // bonded, delegations included
+	Index        *indexer.Stats `json:"index"`
+}
+
+// SetIndexer enables zion_getAddressActivity and the indexed part of
+// zion_getChainStats.
+func (s *Server) SetIndexer(ix *indexer.Indexer) {
+	s.indexer = ix
+}
+
+// getChainStats handles zion_getChainStats([days]), where days bounds the
+// daily receipt counts (indexer.DefaultStatsDays by default).
+func (s *Server) getChainStats(ctx context.Context, params json.RawMessage) (interface{}, *RPCError) {
+	var args []int
+	if len(params) > 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, invalidParams("invalid days")
+		}
+	}
+	vals := s.state.StakingValidators()
+	res := ChainStatsResult{
+		Height:       s.chain.Head(),
+		Agents:       s.state.AgentCount(),
+		ActiveAgents: s.state.ActiveAgentCount(),
+		Validators:   len(vals),
+		TotalStake:   new(big.Int),
+	}
+	for _, v := range vals {
+		if v.Tokens != nil {
+			res.TotalStake.Add(res.TotalStake, v.Tokens)
+		}
+	}
+	if s.indexer != nil {
+		days := 0
+		if len(args) > 0 {
+			days = args[0]
+		}
+		st, err := s.indexer.Stats(ctx, days)
+		if err != nil {
+			return nil, errorFrom(err)
+		}
+		res.Index = st
+	}
+	return res, nil
+}
+
+// getAddressActivity handles zion_getAddressActivity(address), the
+// summary of the indexed transactions of address.
+func (s *Server) getAddressActivity(ctx context.Context, params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	addr, rpcErr := parseAddress(args[0])
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if s.indexer == nil {
+		return nil, errorFrom(ErrNoIndexer)
+	}
+	a, err := s.indexer.Activity(ctx, addr)
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return a, nil
+}
+
+// StateRootResult is returned by zion_getStateRoot. Root commits to the
+// canonical encoding of the state described by the other fields, and is
+// the StateRoot of the block at Height once that block is applied; nodes at
+// the same height with different roots have diverged, and Sections, the
+// entries by key prefix, narrows down where.
+type StateRootResult struct {
+	Height   uint64         `json:"height"`
+	Root     string         `json:"root"`
+	Version  int            `json:"version"`
+	Entries  int            `json:"entries"`
+	Size     int            `json:"size"`
+	Sections map[string]int `json:"sections"`
+}
+
+// getStateRoot handles zion_getStateRoot().
+func (s *Server) getStateRoot(params json.RawMessage) (interface{}, *RPCError) {
+	height := s.chain.Head()
+	info := s.state.SnapshotInfo()
+	return &StateRootResult{
+		Height:   height,
+		Root:     fmt.Sprintf("0x%x", info.Root),
+		Version:  info.Version,
+		Entries:  info.Entries,
+		Size:     info.Size,
+		Sections: info.Sections,
+	}, nil
+}
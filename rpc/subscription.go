@@ -0,0 +1,460 @@
+package rpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/zionlayer/zionlayer/core/block"
+	"github.com/zionlayer/zionlayer/core/event"
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/internal/ws"
+	"go.uber.org/zap"
+)
+
+// Subscription kinds accepted by zion_subscribe.
+const (
+	SubNewHeads            = "newHeads"
+	SubPendingTransactions = "pendingTransactions"
+	SubLogs                = "logs"
+	SubAgentMessages       = "agentMessages"
+)
+
+// wsSendQueue is the number of outbound messages buffered per connection.
+// A client that falls this far behind is disconnected rather than allowed
+// to stall event delivery to everyone else.
+const wsSendQueue = 256
+
+// blockEventQueue is the number of finalized blocks buffered for
+// subscription delivery. If more are waiting, the clients subscribed to
+// blocks are disconnected.
+const blockEventQueue = 16
+
+// SubscriptionFilter narrows a subscription. Address applies to logs; From
+// and To, agent DIDs, to agent messages. Empty fields match everything.
+type SubscriptionFilter struct {
+	Address string `json:"address,omitempty"`
+	From    string `json:"from,omitempty"`
+	To      string `json:"to,omitempty"`
+}
+
+// HeadResult is the payload of a newHeads notification.
+type HeadResult struct {
+	Height    uint64 `json:"height"`
+	Hash      string `json:"hash"`
+	PrevHash  string `json:"prevHash"`
+	StateRoot string `json:"stateRoot"`
+	TxRoot    string `json:"txRoot"`
+	Timestamp int64  `json:"timestamp"`
+	Validator string `json:"validator"`
+	TxCount   int    `json:"txCount"`
+}
+
+// LogResult is a log as returned by logs notifications and the filter
+// methods. Topics and Data are 0x-prefixed hex.
+type LogResult struct {
+	Address     string   `json:"address"`
+	Topics      []string `json:"topics"`
+	Data        string   `json:"data"`
+	TxHash      string   `json:"txHash"`
+	BlockHeight uint64   `json:"blockHeight"`
+}
+
+func newLogResult(l *transaction.Log, txHash [32]byte, height uint64) LogResult {
+	topics := make([]string, len(l.Topics))
+	for i, t := range l.Topics {
+		topics[i] = fmt.Sprintf("0x%x", t)
+	}
+	return LogResult{
+		Address:     l.Address,
+		Topics:      topics,
+		Data:        fmt.Sprintf("0x%x", l.Data),
+		TxHash:      fmt.Sprintf("0x%x", txHash),
+		BlockHeight: height,
+	}
+}
+
+// AgentMessageResult is the payload of an agentMessages notification.
+type AgentMessageResult struct {
+	transaction.AgentMessage
+	TxHash      string `json:"txHash"`
+	BlockHeight uint64 `json:"blockHeight"`
+}
+
+type notification struct {
+	JSONRPC string             `json:"jsonrpc"`
+	Method  string             `json:"method"`
+	Params  notificationParams `json:"params"`
+}
+
+type notificationParams struct {
+	Subscription string      `json:"subscription"`
+	Result       interface{} `json:"result"`
+}
+
+type subscription struct {
+	id      string
+	kind    string
+	filter  SubscriptionFilter
+	session *wsSession
+	cancel  func()
+}
+
+// wsSession is one WebSocket client and its subscriptions.
+type wsSession struct {
+	server *Server
+	conn   *ws.Conn
+	ip     string
+	out    chan []byte
+	done   chan struct{}
+	once   sync.Once
+
+	mu   sync.Mutex
+	subs map[string]*subscription
+}
+
+func (s *Server) serveWS(w http.ResponseWriter, r *http.Request) {
+	if s.throttle(w, r) {
+		return
+	}
+	if err := s.authenticate(r); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	conn, err := ws.Upgrade(w, r)
+	if err != nil {
+		return
+	}
+	sess := &wsSession{
+		server: s,
+		conn:   conn,
+		ip:     s.clientIP(r),
+		out:    make(chan []byte, wsSendQueue),
+		done:   make(chan struct{}),
+		subs:   make(map[string]*subscription),
+	}
+	s.sessMu.Lock()
+	if s.sessions == nil {
+		s.sessions = make(map[*wsSession]struct{})
+	}
+	s.sessions[sess] = struct{}{}
+	s.sessMu.Unlock()
+	go sess.writeLoop()
+	defer sess.close()
+	for {
+		msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		sess.handle(msg)
+	}
+}
+
+func (sess *wsSession) writeLoop() {
+	for {
+		select {
+		case msg := <-sess.out:
+			if err := sess.conn.WriteText(msg); err != nil {
+				sess.close()
+				return
+			}
+		case <-sess.done:
+			return
+		}
+	}
+}
+
+// send queues v for delivery, disconnecting the client if its queue is
+// full.
+func (sess *wsSession) send(v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	sess.sendRaw(b)
+}
+
+// sendRaw queues an encoded message; see send.
+func (sess *wsSession) sendRaw(b []byte) {
+	select {
+	case <-sess.done:
+	case sess.out <- b:
+	default:
+		sess.server.logger.Warn("websocket client too slow, disconnecting", zap.String("ip", sess.ip))
+		sess.close()
+	}
+}
+
+func (sess *wsSession) notify(id string, result interface{}) {
+	sess.send(notification{
+		JSONRPC: "2.0",
+		Method:  "zion_subscription",
+		Params:  notificationParams{Subscription: id, Result: result},
+	})
+}
+
+func (sess *wsSession) close() {
+	sess.once.Do(func() {
+		close(sess.done)
+		sess.mu.Lock()
+		subs := sess.subs
+		sess.subs = nil
+		sess.mu.Unlock()
+		for _, sub := range subs {
+			sub.cancel()
+		}
+		sess.conn.Close()
+		sess.server.sessMu.Lock()
+		delete(sess.server.sessions, sess)
+		sess.server.sessMu.Unlock()
+	})
+}
+
+func (sess *wsSession) handle(msg []byte) {
+	req, rpcErr := parseRequest(msg)
+	if rpcErr != nil {
+		sess.send(Response{JSONRPC: "2.0", ID: req.ID, Error: rpcErr})
+		return
+	}
+	if sess.server.reqRate != nil && !sess.server.reqRate.allow(sess.ip) {
+		sess.send(Response{JSONRPC: "2.0", ID: req.ID, Error: &RPCError{Code: CodeLimitExceeded, Message: "rate limit exceeded"}})
+		return
+	}
+	var result interface{}
+	switch req.Method {
+	case "zion_subscribe":
+		result, rpcErr = sess.subscribe(req.Params)
+	case "zion_unsubscribe":
+		result, rpcErr = sess.unsubscribe(req.Params)
+	default:
+		sess.sendRaw(sess.server.encodeResponse(sess.server.dispatch(context.Background(), req, sess.ip)))
+		return
+	}
+	sess.send(Response{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr})
+}
+
+func (sess *wsSession) subscribe(params json.RawMessage) (interface{}, *RPCError) {
+	var args []json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	var kind string
+	if err := json.Unmarshal(args[0], &kind); err != nil {
+		return nil, invalidParams("invalid params")
+	}
+	var filter SubscriptionFilter
+	if len(args) > 1 {
+		if err := json.Unmarshal(args[1], &filter); err != nil {
+			return nil, invalidParams("invalid filter")
+		}
+		// From and To name agent DIDs, matched verbatim.
+		if filter.Address != "" {
+			addr, rpcErr := parseAddress(filter.Address)
+			if rpcErr != nil {
+				return nil, rpcErr
+			}
+			filter.Address = addr
+		}
+	}
+
+	sub := &subscription{id: newSubscriptionID(), kind: kind, filter: filter, session: sess}
+	switch kind {
+	case SubPendingTransactions:
+		notify := func(tx *transaction.Tx) {
+			sess.notify(sub.id, fmt.Sprintf("0x%x", tx.Hash()))
+		}
+		stop := make(chan struct{})
+		var unsubscribe func()
+		if bus := sess.server.events; bus != nil {
+			ch := make(chan event.NewTx, wsSendQueue)
+			unsubscribe = bus.NewTx.Subscribe(ch).Unsubscribe
+			go relay(ch, stop, func(e event.NewTx) { notify(e.Tx) })
+		} else {
+			ch := make(chan *transaction.Tx, wsSendQueue)
+			unsubscribe = sess.server.pool.SubscribeNewTx(ch).Unsubscribe
+			go relay(ch, stop, notify)
+		}
+		var once sync.Once
+		sub.cancel = func() {
+			once.Do(func() {
+				unsubscribe()
+				close(stop)
+			})
+		}
+	case SubNewHeads, SubLogs, SubAgentMessages:
+		s := sess.server
+		s.subMu.Lock()
+		if s.subs == nil {
+			s.subs = make(map[string]*subscription)
+		}
+		s.subs[sub.id] = sub
+		s.subMu.Unlock()
+		sub.cancel = func() {
+			s.subMu.Lock()
+			delete(s.subs, sub.id)
+			s.subMu.Unlock()
+		}
+	default:
+		return nil, invalidParams(fmt.Sprintf("unknown subscription %q", kind))
+	}
+
+	sess.mu.Lock()
+	if sess.subs == nil {
+		sess.mu.Unlock()
+		sub.cancel()
+		return nil, &RPCError{Code: CodeServerError, Message: "connection closed"}
+	}
+	sess.subs[sub.id] = sub
+	sess.mu.Unlock()
+	return sub.id, nil
+}
+
+func (sess *wsSession) unsubscribe(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	sess.mu.Lock()
+	sub, ok := sess.subs[args[0]]
+	delete(sess.subs, args[0])
+	sess.mu.Unlock()
+	if ok {
+		sub.cancel()
+	}
+	return ok, nil
+}
+
+// relay calls fn with each value received on ch until stop is closed.
+func relay[T any](ch <-chan T, stop <-chan struct{}, fn func(T)) {
+	for {
+		select {
+		case v := <-ch:
+			fn(v)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// SetEventBus makes the server take new blocks and pending transactions
+// for its WebSocket subscriptions from bus, instead of having them pushed
+// with NotifyBlock and read from the mempool. It must be called before
+// Start.
+func (s *Server) SetEventBus(bus *event.Bus) {
+	s.events = bus
+	s.eventsStop = make(chan struct{})
+	go func() {
+		for {
+			ch := make(chan event.NewBlock, blockEventQueue)
+			if !s.relayBlocks(ch, bus.NewBlock.SubscribeAll(ch)) {
+				return
+			}
+		}
+	}()
+}
+
+// relayBlocks passes the blocks received on ch to NotifyBlock until the
+// server stops, and then returns false. If sub falls behind, it instead
+// disconnects the clients subscribed to blocks, which have missed some, and
+// returns true.
+func (s *Server) relayBlocks(ch <-chan event.NewBlock, sub *event.Subscription) bool {
+	defer sub.Unsubscribe()
+	for {
+		select {
+		case e := <-ch:
+			s.NotifyBlock(e.Block, e.Receipts)
+		case err := <-sub.Err():
+			s.logger.Warn("block notifications fell behind, disconnecting subscribers", zap.Error(err))
+			s.subMu.Lock()
+			sessions := make(map[*wsSession]struct{})
+			for _, sub := range s.subs {
+				sessions[sub.session] = struct{}{}
+			}
+			s.subMu.Unlock()
+			for sess := range sessions {
+				sess.close()
+			}
+			return true
+		case <-s.eventsStop:
+			return false
+		}
+	}
+}
+
+func newSubscriptionID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return fmt.Sprintf("0x%x", b)
+}
+
+// NotifyBlock pushes a finalized block to WebSocket subscribers: its header
+// to newHeads, its agent message transactions to agentMessages and the logs
+// in receipts to logs. receipts may be nil if the block's transactions have
+// not been executed.
+func (s *Server) NotifyBlock(b *block.Block, receipts []*transaction.Receipt) {
+	s.subMu.Lock()
+	subs := make([]*subscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		subs = append(subs, sub)
+	}
+	s.subMu.Unlock()
+	if len(subs) == 0 {
+		return
+	}
+
+	height := b.Header.Height
+	head := HeadResult{
+		Height:    height,
+		Hash:      fmt.Sprintf("0x%x", b.Hash()),
+		PrevHash:  fmt.Sprintf("0x%x", b.Header.PrevHash),
+		StateRoot: fmt.Sprintf("0x%x", b.Header.StateRoot),
+		TxRoot:    fmt.Sprintf("0x%x", b.Header.TxRoot),
+		Timestamp: b.Header.Timestamp,
+		Validator: string(b.Header.ValidatorAddr),
+		TxCount:   len(b.Txs),
+	}
+	var messages []AgentMessageResult
+	for _, tx := range b.Txs {
+		if tx.Type != transaction.TxAgentMessage {
+			continue
+		}
+		var msg transaction.AgentMessage
+		if err := json.Unmarshal(tx.Data, &msg); err != nil {
+			continue
+		}
+		messages = append(messages, AgentMessageResult{
+			AgentMessage: msg,
+			TxHash:       fmt.Sprintf("0x%x", tx.Hash()),
+			BlockHeight:  height,
+		})
+	}
+	var logs []LogResult
+	for _, r := range receipts {
+		for _, l := range r.Logs {
+			logs = append(logs, newLogResult(l, r.TxHash, height))
+		}
+	}
+
+	for _, sub := range subs {
+		switch sub.kind {
+		case SubNewHeads:
+			sub.session.notify(sub.id, head)
+		case SubAgentMessages:
+			for _, m := range messages {
+				if (sub.filter.From == "" || sub.filter.From == m.From) &&
+					(sub.filter.To == "" || sub.filter.To == m.To) {
+					sub.session.notify(sub.id, m)
+				}
+			}
+		case SubLogs:
+			for _, l := range logs {
+				if sub.filter.Address == "" || sub.filter.Address == l.Address {
+					sub.session.notify(sub.id, l)
+				}
+			}
+		}
+	}
+}
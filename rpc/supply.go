@@ -0,0 +1,31 @@
+package rpc
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/zionlayer/zionlayer/core/state"
+)
+
+// SupplyResult is returned by zion_getSupply. Amounts are in base units;
+// InflationRate is the annual rate of the next block, in basis points, and
+// BlockReward its reward.
+type SupplyResult struct {
+	state.Supply
+	InflationRate uint64                 `json:"inflationRate"`
+	BlockReward   *big.Int               `json:"blockReward"`
+	Params        state.TokenomicsParams `json:"params"`
+}
+
+// getSupply handles zion_getSupply(), returning the supply of ZIO, the
+// community treasury and the inflation schedule.
+func (s *Server) getSupply(json.RawMessage) (interface{}, *RPCError) {
+	next := s.chain.Head() + 1
+	p := s.state.GetTokenomicsParams()
+	return SupplyResult{
+		Supply:        s.state.GetSupply(),
+		InflationRate: p.Rate(next),
+		BlockReward:   s.state.BlockReward(next),
+		Params:        p,
+	}, nil
+}
@@ -0,0 +1,106 @@
+package rpc
+
+import (
+	"encoding/json"
+)
+
+// TokenBalanceResult is the result of zion_getTokenBalance.
+type TokenBalanceResult struct {
+	Symbol   string `json:"symbol"`
+	Address  string `json:"address"`
+	Balance  string `json:"balance"`
+	Decimals uint8  `json:"decimals"`
+}
+
+// getToken handles zion_getToken(symbol).
+func (s *Server) getToken(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	t, err := s.state.GetToken(args[0])
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	return t, nil
+}
+
+// listTokens handles zion_listTokens([page]), listing the ZRC-20 tokens by
+// symbol.
+func (s *Server) listTokens(params json.RawMessage) (interface{}, *RPCError) {
+	var args []json.RawMessage
+	if len(params) > 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, invalidParams("invalid params")
+		}
+	}
+	var page PageArgs
+	if len(args) > 0 {
+		var rpcErr *RPCError
+		if page, rpcErr = parsePage(args[0]); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+	return paginate(s.state.Tokens(), page)
+}
+
+// getTokenBalance handles zion_getTokenBalance(symbol, address).
+func (s *Server) getTokenBalance(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) < 2 {
+		return nil, invalidParams("invalid params")
+	}
+	t, err := s.state.GetToken(args[0])
+	if err != nil {
+		return nil, errorFrom(err)
+	}
+	addr, rpcErr := parseAddress(args[1])
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	return &TokenBalanceResult{
+		Symbol:   t.Symbol,
+		Address:  addr,
+		Balance:  s.state.TokenBalance(t.Symbol, addr).String(),
+		Decimals: t.Decimals,
+	}, nil
+}
+
+// getTokenBalances handles zion_getTokenBalances(address), returning the
+// ZRC-20 balances of the address by symbol.
+func (s *Server) getTokenBalances(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, invalidParams("invalid params")
+	}
+	addr, rpcErr := parseAddress(args[0])
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	out := make(map[string]string)
+	for symbol, bal := range s.state.TokenBalances(addr) {
+		out[symbol] = bal.String()
+	}
+	return out, nil
+}
+
+// getTokenAllowance handles zion_getTokenAllowance(symbol, owner, spender),
+// returning the amount spender may still transfer from owner.
+func (s *Server) getTokenAllowance(params json.RawMessage) (interface{}, *RPCError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) < 3 {
+		return nil, invalidParams("invalid params")
+	}
+	if _, err := s.state.GetToken(args[0]); err != nil {
+		return nil, errorFrom(err)
+	}
+	owner, rpcErr := parseAddress(args[1])
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	spender, rpcErr := parseAddress(args[2])
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	return s.state.TokenAllowance(args[0], owner, spender).String(), nil
+}
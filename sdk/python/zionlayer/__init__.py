@@ -44,6 +44,7 @@ class InferenceReceipt:
     input_hash: str
     output_hash: str
     timestamp: int = field(default_factory=lambda: int(time.time()))
+    prover: str = ""                            # registered compute provider
     prover_sig: str = ""
 
 
@@ -129,16 +130,30 @@ class AgentsAPI:
         """Fetch an agent record by DID string."""
         return self._client.call("zion_getAgent", [did_id])
 
+    def reputation(self, did_id: str) -> dict:
+        """Fetch the reputation of an agent."""
+        return self._client.call("zion_getReputation", [did_id])
+
+    def resolve(self, did_id: str) -> dict:
+        """Resolve a DID into its W3C DID resolution result."""
+        return self._client.call("zion_resolveDID", [did_id])
+
     def send_message(self, wallet: AgentWallet, msg: AgentMessage) -> str:
-        """Send an on-chain agent message. Returns tx hash."""
-        msg.nonce = self._nonce(wallet.address)
+        """Send an on-chain agent message. Returns tx hash.
+
+        The wallet must control msg.from_did or an agent it delegated
+        agent.message to. The message nonce is the sending agent's next
+        message nonce, and gas grows with the payload size.
+        """
+        agent = self.get(msg.from_did) or {}
+        msg.nonce = int(agent.get("messageNonce", 0))
         payload = asdict(msg)
         tx = {
             "type": 3,
             "from": wallet.address,
-            "gas": 50000,
+            "gas": 50000 + 16 * len(json.dumps(msg.payload)),
             "gasPrice": "1000000000",
-            "nonce": msg.nonce,
+            "nonce": self._nonce(wallet.address),
             "data": json.dumps(payload),
         }
         return self._client.call("zion_sendTransaction", [tx])
@@ -179,6 +194,14 @@ class ChainAPI:
     def get_chain_id(self) -> str:
         return self._client.call("zion_chainId", [])
 
+    def get_client_version(self) -> dict:
+        """Version, commit, build date and Go version of the node."""
+        return self._client.call("zion_clientVersion", [])
+
+    def send_raw_transaction(self, raw_tx: str) -> str:
+        """Submit a signed, binary-encoded transaction (0x-prefixed hex)."""
+        return self._client.call("zion_sendRawTransaction", [raw_tx])
+
 
 # ─── Quick usage example ──────────────────────────────────────────────────────
 
@@ -31,6 +31,7 @@ export interface InferenceReceipt {
   inputHash: string;
   outputHash: string;
   timestamp: number;
+  prover: string;      // registered compute provider
   proverSig: string;
 }
 
@@ -40,6 +41,15 @@ export interface TransactionOptions {
   nonce?: number;
 }
 
+export interface ClientVersion {
+  version: string;
+  commit: string;
+  modified?: boolean;
+  buildDate: string;
+  goVersion: string;
+  platform: string;
+}
+
 // ─── Client ────────────────────────────────────────────────────────────────
 
 export class AgenticClient {
@@ -105,19 +115,36 @@ export class AgentsAPI {
     return this.client.call('zion_getAgent', [didId]) as Promise<AgentDID>;
   }
 
-  /** Send an on-chain agent message. */
+  /** Fetch the reputation of an agent. */
+  async reputation(didId: string): Promise<Record<string, number>> {
+    return this.client.call('zion_getReputation', [didId]) as Promise<Record<string, number>>;
+  }
+
+  /**
+   * Resolve a DID into its W3C DID resolution result
+   * ({ didDocument, didResolutionMetadata, didDocumentMetadata }).
+   */
+  async resolve(didId: string): Promise<Record<string, unknown>> {
+    return this.client.call('zion_resolveDID', [didId]) as Promise<Record<string, unknown>>;
+  }
+
+  /**
+   * Send an on-chain agent message. The wallet must control msg.from or an
+   * agent it delegated agent.message to. The message nonce is the sending
+   * agent's next message nonce, and gas grows with the payload size.
+   */
   async sendMessage(
     wallet: AgentWallet,
     msg: Omit<AgentMessage, 'nonce'>
   ): Promise<string> {
-    const nonce = await this.getNonce(wallet.address);
-    const fullMsg: AgentMessage = { ...msg, nonce };
+    const agent = (await this.client.call('zion_getAgent', [msg.from])) as { messageNonce?: number } | null;
+    const fullMsg: AgentMessage = { ...msg, nonce: agent?.messageNonce ?? 0 };
     const tx = {
       type: 3, // TxAgentMessage
       from: wallet.address,
-      gas: 50000,
+      gas: 50000 + 16 * JSON.stringify(msg.payload ?? null).length,
       gasPrice: '1000000000',
-      nonce,
+      nonce: await this.getNonce(wallet.address),
       data: JSON.stringify(fullMsg),
     };
     return this.client.call('zion_sendTransaction', [tx]) as Promise<string>;
@@ -163,6 +190,16 @@ export class ChainAPI {
   async getChainId(): Promise<string> {
     return this.client.call('zion_chainId', []) as Promise<string>;
   }
+
+  /** Version, commit, build date and Go version of the node. */
+  async getClientVersion(): Promise<ClientVersion> {
+    return this.client.call('zion_clientVersion', []) as Promise<ClientVersion>;
+  }
+
+  /** Submit a signed, binary-encoded transaction (0x-prefixed hex). */
+  async sendRawTransaction(rawTx: string): Promise<string> {
+    return this.client.call('zion_sendRawTransaction', [rawTx]) as Promise<string>;
+  }
 }
 
 // ─── Wallet ────────────────────────────────────────────────────────────────
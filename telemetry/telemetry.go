@@ -0,0 +1,117 @@
+// Package telemetry exports OpenTelemetry traces of the transaction
+// lifecycle: RPC submission, mempool admission, block production and AVM
+// execution. Spans are recorded through the global tracer provider, which
+// is a no-op until Setup installs an OTLP exporter.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/zionlayer/zionlayer/version"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName names the tracer of all ZionLayer spans.
+const InstrumentationName = "github.com/zionlayer/zionlayer"
+
+// Span attribute keys shared across packages.
+const (
+	AttrTxHash      = attribute.Key("zion.tx.hash")
+	AttrTxType      = attribute.Key("zion.tx.type")
+	AttrTxFrom      = attribute.Key("zion.tx.from")
+	AttrBlockHeight = attribute.Key("zion.block.height")
+	AttrBlockTxs    = attribute.Key("zion.block.txs")
+	AttrGasUsed     = attribute.Key("zion.gas.used")
+	AttrRPCMethod   = attribute.Key("rpc.method")
+)
+
+var ErrInvalidSampleRatio = errors.New("telemetry: sample ratio must be between 0 and 1")
+
+// Config selects where traces are exported.
+type Config struct {
+	// Endpoint is the OTLP/gRPC collector address, e.g. "localhost:4317".
+	// Empty disables tracing.
+	Endpoint string
+	// Insecure disables TLS to the collector.
+	Insecure bool
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string
+	// SampleRatio is the fraction of new traces recorded. Traces started
+	// by a sampled remote parent are always recorded.
+	SampleRatio float64
+}
+
+// DefaultConfig returns a configuration with tracing disabled.
+func DefaultConfig() Config {
+	return Config{ServiceName: "ziond", SampleRatio: 1}
+}
+
+// Setup installs the global tracer provider and W3C trace context
+// propagator described by config. The returned function flushes pending
+// spans and must be called on shutdown.
+func Setup(ctx context.Context, config Config) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if config.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	if config.SampleRatio < 0 || config.SampleRatio > 1 {
+		return nil, ErrInvalidSampleRatio
+	}
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(config.Endpoint)}
+	if config.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	res := resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(config.ServiceName),
+		semconv.ServiceVersion(version.Get().Version),
+	)
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer used for ZionLayer spans.
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// Start starts a span named name as a child of the span in ctx.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// Extract returns ctx carrying the remote span propagated in header, if
+// any, so that server spans join the caller's trace.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// End records err on span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,75 @@
+// Package version reports the version of the running ziond build.
+//
+// Version, Commit and BuildDate are set at link time:
+//
+//	go build -ldflags "-X github.com/zionlayer/zionlayer/version.Version=v0.2.0 \
+//	    -X github.com/zionlayer/zionlayer/version.Commit=$(git rev-parse HEAD) \
+//	    -X github.com/zionlayer/zionlayer/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Without them the commit and date recorded by the Go toolchain are used.
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+var (
+	Version   = "v0.1.0"
+	Commit    = ""
+	BuildDate = ""
+)
+
+// Info describes a build.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Modified  bool   `json:"modified,omitempty"` // built from a tree with uncommitted changes
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+	Platform  string `json:"platform"`
+}
+
+// Get returns the build information of the running binary.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok && Commit == "" {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				info.Commit = s.Value
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = s.Value
+				}
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+	}
+	return info
+}
+
+// String formats i as a client version in the usual
+// name/version-commit/platform/go form.
+func (i Info) String() string {
+	v := i.Version
+	if i.Commit != "" {
+		commit := i.Commit
+		if len(commit) > 8 {
+			commit = commit[:8]
+		}
+		v += "-" + commit
+	}
+	if i.Modified {
+		v += "-dirty"
+	}
+	return fmt.Sprintf("ziond/%s/%s/%s", v, i.Platform, i.GoVersion)
+}
@@ -0,0 +1,137 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zionlayer/zionlayer/core/crypto"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// ValidationGasLimit caps the gas a smart account may spend validating a
+// transaction. The gas spent is charged to the transaction, out of its gas
+// limit, before it executes.
+const ValidationGasLimit = 100_000
+
+// MaxAccountSignatures caps the signatures a smart account transaction may
+// carry. OpCheckSig charges GasSigVerify for each signature it verifies.
+const (
+	MaxAccountSignatures = 16
+	GasSigVerify         = 3000
+)
+
+var (
+	ErrNotSmartAccount   = errors.New("sender has no validation code")
+	ErrNoAuthContext     = errors.New("opcode only valid during account validation")
+	ErrAccountValidation = errors.New("smart account rejected transaction")
+	ErrValidationReentry = errors.New("transaction validation not allowed during account validation")
+)
+
+// AuthContext is the input to a smart account's validation code: the
+// transaction signing hash and the signatures supplied with it.
+type AuthContext struct {
+	Hash       [32]byte
+	Signatures [][]byte
+	Approvals  int
+
+	approved map[string]bool // public keys already counted
+}
+
+// ValidateAccount authorizes a transaction sent from a smart account, such
+// as an agent whose DID controller is a contract. The account's code runs
+// read-only against the state with the tx signatures available through
+// OpCheckSig and OpRequireSigs, so it can implement multisig, session keys
+// or thresholds of delegated agents. The tx is accepted if the code
+// completes without error. It returns the gas the code used, at most
+// ValidationGasLimit and the gas limit of tx, whether or not it accepted
+// the tx.
+func (avm *AVM) ValidateAccount(stateDB *state.StateDB, tx *transaction.Tx, chainID uint64) (uint64, error) {
+	return avm.validateAccount(stateDB, tx, chainID, ValidationGasLimit)
+}
+
+// validateAccount is ValidateAccount with the validation code given at
+// most limit gas.
+func (avm *AVM) validateAccount(stateDB *state.StateDB, tx *transaction.Tx, chainID, limit uint64) (uint64, error) {
+	if tx.ChainID != chainID {
+		return 0, transaction.ErrWrongChain
+	}
+	code := stateDB.GetCode(tx.From)
+	if len(code) == 0 {
+		return 0, ErrNotSmartAccount
+	}
+	sigs, err := transaction.DecodeSignatures(tx.Signature)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrAccountValidation, err)
+	}
+	if err := tx.CheckHash(); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrAccountValidation, err)
+	}
+	if len(sigs) > MaxAccountSignatures {
+		return 0, fmt.Errorf("%w: %d signatures, at most %d", ErrAccountValidation, len(sigs), MaxAccountSignatures)
+	}
+	if tx.Gas < limit {
+		limit = tx.Gas
+	}
+	ctx := &ExecutionContext{
+		Caller:   tx.From,
+		Origin:   tx.From,
+		GasLimit: limit,
+		ChainID:  chainID,
+		Address:  tx.From,
+		Auth:     &AuthContext{Hash: tx.SigningHash(), Signatures: sigs},
+		State:    stateDB,
+		ReadOnly: true,
+	}
+	if _, err := avm.Execute(ctx, code); err != nil {
+		return ctx.GasUsed, fmt.Errorf("%w: %v", ErrAccountValidation, err)
+	}
+	return ctx.GasUsed, nil
+}
+
+// checkSig records an approval for pub if one of the supplied signatures
+// is valid for it, charging GasSigVerify for each signature verified. Each
+// key is counted at most once.
+func (a *AuthContext) checkSig(ctx *ExecutionContext, pub []byte) error {
+	if a.approved[string(pub)] {
+		return nil
+	}
+	for _, sig := range a.Signatures {
+		if err := ctx.UseGas(GasSigVerify); err != nil {
+			return err
+		}
+		if crypto.Verify(pub, a.Hash, sig) == nil {
+			if a.approved == nil {
+				a.approved = make(map[string]bool)
+			}
+			a.approved[string(pub)] = true
+			a.Approvals++
+			return nil
+		}
+	}
+	return nil
+}
+
+func (avm *AVM) registerAccountBuiltins() {
+	// Check Sig precompile: args are an ed25519 public key.
+	avm.precompiles[OpCheckSig] = func(ctx *ExecutionContext, args []byte) ([]byte, error) {
+		if ctx.Auth == nil {
+			return nil, ErrNoAuthContext
+		}
+		return nil, ctx.Auth.checkSig(ctx, args)
+	}
+
+	// Require Sigs precompile: args are a one-byte approval threshold.
+	avm.precompiles[OpRequireSigs] = func(ctx *ExecutionContext, args []byte) ([]byte, error) {
+		if ctx.Auth == nil {
+			return nil, ErrNoAuthContext
+		}
+		if len(args) != 1 {
+			return nil, ErrStackUnderflow
+		}
+		if ctx.Auth.Approvals < int(args[0]) {
+			return nil, ErrExecutionReverted
+		}
+		return nil, nil
+	}
+}
@@ -0,0 +1,163 @@
+package vm
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/zionlayer/zionlayer/core/common"
+	"github.com/zionlayer/zionlayer/core/crypto"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"go.uber.org/zap"
+)
+
+var testAccount = common.BytesToAddress([]byte{0xa0}).String()
+
+// accountTx returns a transfer from testAccount carrying sigs made with
+// keys over its signing hash.
+func accountTx(t *testing.T, keys ...crypto.PrivateKey) *transaction.Tx {
+	t.Helper()
+	tx := transaction.NewTransferTx(testAccount, testContract, big.NewInt(1), 0, big.NewInt(1))
+	tx.Gas = 60000
+	tx.ChainID = testChainID
+	sigs := make([][]byte, len(keys))
+	for i, k := range keys {
+		sig, err := crypto.Sign(k, tx.SigningHash())
+		if err != nil {
+			t.Fatal(err)
+		}
+		sigs[i] = sig
+	}
+	tx.Signature = transaction.EncodeSignatures(sigs)
+	return tx
+}
+
+// accountState returns a state where testAccount is funded and runs code.
+func accountState(code []byte) *state.StateDB {
+	st := state.NewStateDB()
+	st.SetBalance(testAccount, big.NewInt(1e9))
+	st.SetCode(testAccount, code)
+	return st
+}
+
+func TestValidateAccount(t *testing.T) {
+	owner, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Require the signature of owner.
+	code := append(push(owner.Public().(crypto.PublicKey)), byte(OpCheckSig))
+	code = append(append(code, push([]byte{1})...), byte(OpRequireSigs))
+	avm := NewAVM(zap.NewNop())
+
+	t.Run("accepted", func(t *testing.T) {
+		st := accountState(code)
+		tx := accountTx(t, other, owner)
+		gas, err := avm.ValidateAccount(st, tx, testChainID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gas != 2*GasSigVerify {
+			t.Errorf("validation gas = %d, want %d for two verified signatures", gas, 2*GasSigVerify)
+		}
+
+		receipt, err := avm.ApplyTransaction(&ExecutionContext{Height: 1, ChainID: testChainID, State: st}, tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := gas + transaction.GasTransfer; receipt.GasUsed != want {
+			t.Errorf("receipt gas used = %d, want %d including validation", receipt.GasUsed, want)
+		}
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		_, err := avm.ValidateAccount(accountState(code), accountTx(t, other), testChainID)
+		if !errors.Is(err, ErrAccountValidation) {
+			t.Errorf("ValidateAccount = %v, want %v", err, ErrAccountValidation)
+		}
+	})
+
+	t.Run("too many signatures", func(t *testing.T) {
+		keys := make([]crypto.PrivateKey, MaxAccountSignatures+1)
+		for i := range keys {
+			keys[i] = other
+		}
+		gas, err := avm.ValidateAccount(accountState(code), accountTx(t, keys...), testChainID)
+		if !errors.Is(err, ErrAccountValidation) || gas != 0 {
+			t.Errorf("ValidateAccount = %d, %v; want 0, %v", gas, err, ErrAccountValidation)
+		}
+	})
+
+	t.Run("gas limit", func(t *testing.T) {
+		// Each CHECKSIG of a new key verifies every signature.
+		var spin []byte
+		for i := 0; i < 8; i++ {
+			spin = append(append(spin, push([]byte{byte(i), 1, 2, 3})...), byte(OpCheckSig))
+		}
+		keys := make([]crypto.PrivateKey, MaxAccountSignatures)
+		for i := range keys {
+			keys[i] = other
+		}
+		gas, err := avm.ValidateAccount(accountState(spin), accountTx(t, keys...), testChainID)
+		if !errors.Is(err, ErrAccountValidation) || !strings.Contains(err.Error(), ErrOutOfGas.Error()) {
+			t.Errorf("ValidateAccount = %v, want %v", err, ErrOutOfGas)
+		}
+		if gas > ValidationGasLimit {
+			t.Errorf("validation gas = %d, above the limit %d", gas, ValidationGasLimit)
+		}
+	})
+
+	t.Run("read-only", func(t *testing.T) {
+		st := accountState(append(append(push([]byte("v")), push([]byte("k"))...), byte(OpSStore)))
+		_, err := avm.ValidateAccount(st, accountTx(t, owner), testChainID)
+		if !errors.Is(err, ErrAccountValidation) || !strings.Contains(err.Error(), ErrWriteProtection.Error()) {
+			t.Fatalf("ValidateAccount = %v, want %v", err, ErrWriteProtection)
+		}
+		if got := st.GetStorage(testAccount, []byte("k")); got != nil {
+			t.Errorf("validation wrote storage %q", got)
+		}
+	})
+}
+
+func TestValidationReentry(t *testing.T) {
+	// Validation code that validates a sponsored tx from its own account,
+	// which would run the same code again.
+	sponsored := transaction.NewTransferTx(testAccount, testContract, big.NewInt(1), 0, big.NewInt(1))
+	sponsored.Gas = 60000
+	sponsored.ChainID = testChainID
+	sponsored.Sponsor(common.BytesToAddress([]byte{0xb0}))
+	sponsored.Signature = transaction.EncodeSignatures(nil)
+	enc, err := sponsored.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := append([]byte{byte(OpPushLong), byte(len(enc) >> 8), byte(len(enc))}, enc...)
+	code = append(code, byte(OpPaymasterValidate))
+	avm := NewAVM(zap.NewNop())
+
+	t.Run("validation", func(t *testing.T) {
+		_, err := avm.ValidateAccount(accountState(code), accountTx(t), testChainID)
+		if !errors.Is(err, ErrAccountValidation) || !strings.Contains(err.Error(), ErrValidationReentry.Error()) {
+			t.Fatalf("ValidateAccount = %v, want %v", err, ErrValidationReentry)
+		}
+	})
+
+	t.Run("contract call", func(t *testing.T) {
+		const gas = 150000
+		st, tx := callFixture(t, code, gas)
+		st.SetCode(testAccount, code)
+		receipt, err := avm.ApplyTransaction(&ExecutionContext{Height: 1, ChainID: testChainID, State: st}, tx)
+		if !errors.Is(err, ErrAccountValidation) || !strings.Contains(err.Error(), ErrValidationReentry.Error()) {
+			t.Fatalf("ApplyTransaction = %v, want %v", err, ErrValidationReentry)
+		}
+		if receipt.GasUsed > gas {
+			t.Errorf("gas used = %d, above the limit %d", receipt.GasUsed, gas)
+		}
+	})
+}
@@ -1,10 +1,22 @@
 package vm
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math/big"
+	"time"
 
+	"github.com/zionlayer/zionlayer/core/common"
+	"github.com/zionlayer/zionlayer/core/crypto"
+	"github.com/zionlayer/zionlayer/core/event"
+	"github.com/zionlayer/zionlayer/core/rlp"
 	"github.com/zionlayer/zionlayer/core/state"
 	"github.com/zionlayer/zionlayer/core/transaction"
+	"github.com/zionlayer/zionlayer/core/zk"
+	"github.com/zionlayer/zionlayer/telemetry"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -12,22 +24,45 @@ import (
 type Opcode byte
 
 const (
-	OpStop          Opcode = 0x00
-	OpAgentRegister Opcode = 0x10 // register agent DID
-	OpAgentSend     Opcode = 0x11 // send agent message
-	OpAgentDelegate Opcode = 0x12 // delegate capability
-	OpInferProve    Opcode = 0x20 // submit inference receipt
-	OpInferVerify   Opcode = 0x21 // verify inference receipt on-chain
-	OpTokenTransfer Opcode = 0x30
-	OpReturn        Opcode = 0xF3
-	OpRevert        Opcode = 0xFD
+	OpStop              Opcode = 0x00
+	OpAgentRegister     Opcode = 0x10 // register agent DID
+	OpAgentSend         Opcode = 0x11 // send agent message
+	OpAgentDelegate     Opcode = 0x12 // delegate capability
+	OpCheckSig          Opcode = 0x14 // approve if a tx signature matches the given key
+	OpRequireSigs       Opcode = 0x15 // revert unless enough keys approved
+	OpInferProve        Opcode = 0x20 // submit inference receipt
+	OpInferVerify       Opcode = 0x21 // verify inference receipt on-chain
+	OpTokenTransfer     Opcode = 0x30
+	OpPaymasterValidate Opcode = 0x50 // validate a sponsored tx's paymaster
+	OpSStore            Opcode = 0x55 // write contract storage slot
+	OpPush              Opcode = 0x60 // push the next n bytes; n is the following byte
+	OpPushLong          Opcode = 0x61 // push the next n bytes; n is the following two bytes, big-endian
+	OpLog0              Opcode = 0xA0 // emit a log with no topics
+	OpLog1              Opcode = 0xA1
+	OpLog2              Opcode = 0xA2
+	OpLog3              Opcode = 0xA3
+	OpLog4              Opcode = 0xA4 // emit a log with four topics
+	OpReturn            Opcode = 0xF3
+	OpRevert            Opcode = 0xFD
+	OpSelfDestruct      Opcode = 0xFF // destroy contract, send balance to beneficiary
 )
 
+// MaxRefundQuotient caps the refund at gasUsed / MaxRefundQuotient. The
+// gas costs of storage, logs and self-destruct are VM parameters of the
+// state.
+const MaxRefundQuotient = 5
+
 var (
-	ErrOutOfGas      = errors.New("out of gas")
-	ErrInvalidOpcode = errors.New("invalid opcode")
-	ErrStackUnderflow = errors.New("stack underflow")
-	ErrExecutionReverted = errors.New("execution reverted")
+	ErrOutOfGas                = errors.New("out of gas")
+	ErrInvalidOpcode           = errors.New("invalid opcode")
+	ErrStackUnderflow          = errors.New("stack underflow")
+	ErrExecutionReverted       = errors.New("execution reverted")
+	ErrNonceTooLow             = errors.New("nonce too low")
+	ErrNonceTooHigh            = errors.New("nonce too high")
+	ErrInsufficientFundsForGas = errors.New("insufficient funds for gas * price")
+	ErrPaymasterFunds          = errors.New("paymaster cannot cover gas * price")
+	ErrContractExists          = errors.New("contract already exists at address")
+	ErrWriteProtection         = errors.New("state write in read-only execution")
 )
 
 // ExecutionContext carries the runtime context for a single AVM call.
@@ -36,8 +71,31 @@ type ExecutionContext struct {
 	Origin   string
 	GasLimit uint64
 	GasUsed  uint64
+	Refund   uint64 // accumulated refund counter, capped at commit
 	Height   uint64
+	Time     int64 // timestamp of the block in Unix nanoseconds; zero if unknown
+	ChainID  uint64
+	Coinbase string       // block proposer receiving fees
+	Address  string       // account whose code is executing
+	Auth     *AuthContext // set while validating a smart account tx; blocks re-entry
+	Logs     []*transaction.Log
 	State    *state.StateDB
+
+	// ReturnData holds the output of a TxCallContract execution.
+	ReturnData []byte
+	// Simulate skips signature and smart account validation so unsigned
+	// transactions can be dry-run; see AVM.Simulate.
+	Simulate bool
+	// ReadOnly makes state writes fail with ErrWriteProtection, for code
+	// run against the live state without changing it, such as smart
+	// account validation.
+	ReadOnly bool
+	// Quiet suppresses the events of applied transactions, for blocks
+	// executed again to be validated.
+	Quiet bool
+	// TraceContext carries the span, typically of the block being built,
+	// under which transaction executions are traced. Nil starts new traces.
+	TraceContext context.Context
 }
 
 // GasLeft returns remaining gas.
@@ -57,10 +115,36 @@ func (ctx *ExecutionContext) UseGas(amount uint64) error {
 	return nil
 }
 
+// checkWrite returns ErrWriteProtection if the state must not be changed.
+func (ctx *ExecutionContext) checkWrite() error {
+	if ctx.ReadOnly {
+		return ErrWriteProtection
+	}
+	return nil
+}
+
+// AddRefund adds to the refund counter.
+func (ctx *ExecutionContext) AddRefund(amount uint64) {
+	ctx.Refund += amount
+}
+
+// CommitRefund applies the accumulated refund, capped at
+// GasUsed/MaxRefundQuotient, and returns the amount refunded.
+func (ctx *ExecutionContext) CommitRefund() uint64 {
+	refund := ctx.Refund
+	if limit := ctx.GasUsed / MaxRefundQuotient; refund > limit {
+		refund = limit
+	}
+	ctx.GasUsed -= refund
+	ctx.Refund = 0
+	return refund
+}
+
 // AVM is the Agent Virtual Machine.
 type AVM struct {
-	logger     *zap.Logger
+	logger      *zap.Logger
 	precompiles map[Opcode]PrecompileFunc
+	events      *event.Bus
 }
 
 // PrecompileFunc is a built-in AVM function.
@@ -73,11 +157,20 @@ func NewAVM(logger *zap.Logger) *AVM {
 		precompiles: make(map[Opcode]PrecompileFunc),
 	}
 	avm.registerBuiltins()
+	avm.registerAccountBuiltins()
 	return avm
 }
 
-// Execute runs AVM bytecode in the given context.
+// SetEventBus publishes agent registrations and verified inference receipts
+// executed outside of simulations on bus.
+func (avm *AVM) SetEventBus(bus *event.Bus) {
+	avm.events = bus
+}
+
+// Execute runs AVM bytecode in the given context, charging gas by the VM
+// parameters of its state.
 func (avm *AVM) Execute(ctx *ExecutionContext, code []byte) ([]byte, error) {
+	params := ctx.State.GetVMParams()
 	pc := 0
 	stack := make([][]byte, 0, 16)
 
@@ -104,6 +197,79 @@ func (avm *AVM) Execute(ctx *ExecutionContext, code []byte) ([]byte, error) {
 		switch op {
 		case OpStop:
 			return nil, nil
+		case OpPush:
+			if pc >= len(code) {
+				return nil, ErrInvalidOpcode
+			}
+			n := int(code[pc])
+			pc++
+			if pc+n > len(code) {
+				return nil, ErrInvalidOpcode
+			}
+			stack = append(stack, code[pc:pc+n])
+			pc += n
+		case OpPushLong:
+			if pc+2 > len(code) {
+				return nil, ErrInvalidOpcode
+			}
+			n := int(code[pc])<<8 | int(code[pc+1])
+			pc += 2
+			if pc+n > len(code) {
+				return nil, ErrInvalidOpcode
+			}
+			stack = append(stack, code[pc:pc+n])
+			pc += n
+		case OpSStore:
+			if len(stack) < 2 {
+				return nil, ErrStackUnderflow
+			}
+			if err := ctx.checkWrite(); err != nil {
+				return nil, err
+			}
+			key, value := stack[len(stack)-1], stack[len(stack)-2]
+			stack = stack[:len(stack)-2]
+			cost := params.GasSStoreSet
+			if len(value) == 0 {
+				cost = params.GasSStoreClear
+			}
+			if err := ctx.UseGas(cost); err != nil {
+				return nil, err
+			}
+			if ctx.State.SetStorage(ctx.Address, key, value) {
+				ctx.AddRefund(params.RefundSStoreClear)
+			}
+		case OpLog0, OpLog1, OpLog2, OpLog3, OpLog4:
+			// Pops the data, then one item per topic.
+			n := int(op - OpLog0)
+			if len(stack) < n+1 {
+				return nil, ErrStackUnderflow
+			}
+			data := stack[len(stack)-1]
+			topics := make([][]byte, n)
+			for i := range topics {
+				topics[i] = append([]byte(nil), stack[len(stack)-2-i]...)
+			}
+			stack = stack[:len(stack)-n-1]
+			if err := ctx.UseGas(params.GasLog + uint64(n)*params.GasLogTopic + uint64(len(data))*params.GasLogByte); err != nil {
+				return nil, err
+			}
+			ctx.Logs = append(ctx.Logs, &transaction.Log{
+				Address: ctx.Address,
+				Topics:  topics,
+				Data:    append([]byte(nil), data...),
+			})
+		case OpSelfDestruct:
+			if len(stack) == 0 {
+				return nil, ErrStackUnderflow
+			}
+			if err := ctx.checkWrite(); err != nil {
+				return nil, err
+			}
+			if err := ctx.UseGas(params.GasSelfDestruct); err != nil {
+				return nil, err
+			}
+			ctx.State.SelfDestruct(ctx.Address, string(stack[len(stack)-1]))
+			return nil, nil
 		case OpReturn:
 			if len(stack) == 0 {
 				return nil, ErrStackUnderflow
@@ -119,17 +285,193 @@ func (avm *AVM) Execute(ctx *ExecutionContext, code []byte) ([]byte, error) {
 	return nil, nil
 }
 
-// ApplyTransaction processes a transaction through the AVM.
-func (avm *AVM) ApplyTransaction(ctx *ExecutionContext, tx *transaction.Tx) error {
+// ApplyTransaction processes a transaction through the AVM and returns its
+// receipt. The per-call fields of ctx (gas, caller) are reset from tx. The
+// full gas allowance is charged up front and the unused part, including the
+// capped refund, is returned afterwards, so Receipt.Fee is exactly
+// GasUsed * GasPrice. The fee is paid by the paymaster of a sponsored tx
+// and by the sender otherwise. The FeeBurnPercent of the VM parameters of
+// the state is burned and the rest is credited to ctx.Coinbase; without a
+// coinbase all of it is burned.
+func (avm *AVM) ApplyTransaction(ctx *ExecutionContext, tx *transaction.Tx) (receipt *transaction.Receipt, err error) {
+	_, span := telemetry.Start(ctx.TraceContext, "avm.apply_transaction", trace.WithAttributes(
+		telemetry.AttrTxHash.String(fmt.Sprintf("0x%x", tx.Hash())),
+		telemetry.AttrTxType.Int(int(tx.Type)),
+		telemetry.AttrBlockHeight.Int64(int64(ctx.Height)),
+	))
+	defer func() {
+		if receipt != nil {
+			span.SetAttributes(telemetry.AttrGasUsed.Int64(int64(receipt.GasUsed)))
+		}
+		telemetry.End(span, err)
+	}()
+	receipt, err = avm.applyTx(ctx, tx)
+	if err == nil && !ctx.Simulate && !ctx.Quiet && avm.events != nil {
+		avm.publish(ctx, tx)
+	}
+	return receipt, err
+}
+
+// publish announces the effects of the successfully applied tx.
+func (avm *AVM) publish(ctx *ExecutionContext, tx *transaction.Tx) {
+	switch tx.Type {
+	case transaction.TxAgentRegister:
+		var did transaction.AgentDID
+		if unmarshalJSON(tx.Data, &did) == nil {
+			avm.events.AgentRegistered.Send(event.AgentRegistered{DID: did, TxHash: tx.Hash(), Height: ctx.Height})
+		}
+	case transaction.TxInferenceReceipt:
+		var r transaction.InferenceReceipt
+		if unmarshalJSON(tx.Data, &r) == nil {
+			avm.events.InferenceVerified.Send(event.InferenceVerified{Receipt: r, TxHash: tx.Hash(), Height: ctx.Height})
+		}
+	}
+}
+
+func (avm *AVM) applyTx(ctx *ExecutionContext, tx *transaction.Tx) (*transaction.Receipt, error) {
+	if err := tx.ValidateBasic(); err != nil {
+		return nil, err
+	}
+	payer, validationGas, err := avm.txPayer(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	switch nonce := ctx.State.GetNonce(tx.From); {
+	case tx.Nonce < nonce:
+		return nil, ErrNonceTooLow
+	case tx.Nonce > nonce:
+		return nil, ErrNonceTooHigh
+	}
+	gasPrice := tx.GasPrice
+	if gasPrice == nil {
+		gasPrice = new(big.Int)
+	}
+	maxFee := new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas), gasPrice)
+	if err := ctx.State.SubBalance(payer, maxFee); err != nil {
+		return nil, ErrInsufficientFundsForGas
+	}
+	// The nonce is consumed and the fee charged even if execution fails;
+	// every other write of a failed transaction is reverted.
+	ctx.State.IncrementNonce(tx.From)
+
+	ctx.Caller, ctx.Origin = tx.From, tx.From
+	ctx.Address = ""
+	// Smart account validation is paid for like execution.
+	ctx.GasLimit, ctx.GasUsed, ctx.Refund = tx.Gas, validationGas, 0
+	ctx.Logs, ctx.ReturnData = nil, nil
+	cp := ctx.State.Checkpoint()
+	err = avm.applyTransaction(ctx, tx)
+	if err != nil {
+		ctx.State.RevertToCheckpoint(cp)
+	} else {
+		ctx.State.DiscardCheckpoint(cp)
+	}
+	receipt := &transaction.Receipt{
+		TxHash:   tx.Hash(),
+		Status:   transaction.ReceiptSuccess,
+		FeePayer: payer,
+	}
+	if err != nil {
+		receipt.Status = transaction.ReceiptFailed
+		receipt.Error = err.Error()
+		ctx.Refund = 0
+		ctx.Logs = nil
+	}
+	receipt.Logs = ctx.Logs
+	if err == nil && tx.Type == transaction.TxDeployContract {
+		receipt.Contract = ctx.Address
+	}
+	receipt.GasRefunded = ctx.CommitRefund()
+	receipt.GasUsed = ctx.GasUsed
+
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(ctx.GasUsed), gasPrice)
+	ctx.State.AddBalance(payer, new(big.Int).Sub(maxFee, fee))
+	burned := new(big.Int).Set(fee)
+	if ctx.Coinbase != "" {
+		percent := new(big.Int).SetUint64(ctx.State.GetVMParams().FeeBurnPercent)
+		burned.Div(burned.Mul(burned, percent), big.NewInt(100))
+		ctx.State.AddBalance(ctx.Coinbase, new(big.Int).Sub(fee, burned))
+	}
+	ctx.State.Burn(burned)
+	receipt.Fee, receipt.FeeBurned = fee, burned
+	return receipt, err
+}
+
+// VerifyTx authenticates tx against stateDB and returns the address that
+// pays for its gas. Senders with code are smart accounts and are validated
+// by running that code; other senders must carry a valid signature. For
+// sponsored transactions the paymaster signature is verified and the
+// paymaster must hold enough balance to cover the full gas allowance.
+func (avm *AVM) VerifyTx(stateDB *state.StateDB, tx *transaction.Tx, chainID uint64) (string, error) {
+	payer, _, err := avm.verifyTx(stateDB, tx, chainID, ValidationGasLimit)
+	return payer, err
+}
+
+// verifyTx is VerifyTx, giving smart account validation at most gasLimit
+// gas and also returning the gas it spent.
+func (avm *AVM) verifyTx(stateDB *state.StateDB, tx *transaction.Tx, chainID, gasLimit uint64) (string, uint64, error) {
+	if len(stateDB.GetCode(tx.From)) > 0 {
+		gas, err := avm.validateAccount(stateDB, tx, chainID, gasLimit)
+		if err != nil {
+			return "", gas, err
+		}
+		if !tx.IsSponsored() {
+			return tx.From, gas, nil
+		}
+		addr, err := tx.PaymasterAddress()
+		if err != nil {
+			return "", gas, err
+		}
+		return addr.String(), gas, checkPaymasterFunds(stateDB, addr.String(), tx)
+	}
+
+	addr, err := tx.FeePayer(chainID)
+	if err != nil {
+		return "", 0, err
+	}
+	payer := addr.String()
+	if tx.IsSponsored() {
+		return payer, 0, checkPaymasterFunds(stateDB, payer, tx)
+	}
+	return payer, 0, nil
+}
+
+// txPayer returns the fee payer of tx and the gas spent validating it.
+// Simulated transactions are not authenticated: the paymaster named by a
+// sponsored tx pays, otherwise the sender.
+func (avm *AVM) txPayer(ctx *ExecutionContext, tx *transaction.Tx) (string, uint64, error) {
+	if !ctx.Simulate {
+		return avm.verifyTx(ctx.State, tx, ctx.ChainID, ValidationGasLimit)
+	}
+	if tx.IsSponsored() {
+		return tx.Paymaster, 0, checkPaymasterFunds(ctx.State, tx.Paymaster, tx)
+	}
+	return tx.From, 0, nil
+}
+
+func checkPaymasterFunds(stateDB *state.StateDB, paymaster string, tx *transaction.Tx) error {
+	maxFee := new(big.Int).SetUint64(tx.Gas)
+	if tx.GasPrice != nil {
+		maxFee.Mul(maxFee, tx.GasPrice)
+	} else {
+		maxFee.SetInt64(0)
+	}
+	if stateDB.GetBalance(paymaster).Cmp(maxFee) < 0 {
+		return ErrPaymasterFunds
+	}
+	return nil
+}
+
+func (avm *AVM) applyTransaction(ctx *ExecutionContext, tx *transaction.Tx) error {
 	switch tx.Type {
 	case transaction.TxTransfer:
-		if err := ctx.UseGas(21000); err != nil {
+		if err := ctx.UseGas(transaction.GasTransfer); err != nil {
 			return err
 		}
 		return ctx.State.Transfer(tx.From, tx.To, tx.Value)
 
 	case transaction.TxAgentRegister:
-		if err := ctx.UseGas(200000); err != nil {
+		if err := ctx.UseGas(transaction.GasAgentRegister); err != nil {
 			return err
 		}
 		var did transaction.AgentDID
@@ -139,33 +481,397 @@ func (avm *AVM) ApplyTransaction(ctx *ExecutionContext, tx *transaction.Tx) erro
 		return ctx.State.RegisterAgent(did, ctx.Height)
 
 	case transaction.TxAgentMessage:
-		if err := ctx.UseGas(50000); err != nil {
-			return err
-		}
 		var msg transaction.AgentMessage
 		if err := unmarshalJSON(tx.Data, &msg); err != nil {
 			return err
 		}
-		ctx.State.StoreMessage(msg)
+		if err := ctx.UseGas(transaction.MessageGas(msg)); err != nil {
+			return err
+		}
+		if err := ctx.State.AuthorizeMessage(msg, tx.From, ctx.Height); err != nil {
+			return err
+		}
+		if err := applyDelegation(ctx, msg); err != nil {
+			return err
+		}
+		return ctx.State.StoreMessage(msg, ctx.Height)
+
+	case transaction.TxAgentDeactivate, transaction.TxAgentReactivate:
+		if err := ctx.UseGas(transaction.GasAgentStatus); err != nil {
+			return err
+		}
+		var change transaction.AgentStatusChange
+		if err := unmarshalJSON(tx.Data, &change); err != nil {
+			return err
+		}
+		return ctx.State.SetAgentActive(change.ID, tx.From, tx.Type == transaction.TxAgentReactivate, ctx.Height, change.Reason)
+
+	case transaction.TxOfferPost, transaction.TxOfferUpdate:
+		if err := ctx.UseGas(transaction.GasOfferOp); err != nil {
+			return err
+		}
+		var offer transaction.ServiceOffer
+		if err := unmarshalJSON(tx.Data, &offer); err != nil {
+			return err
+		}
+		if tx.Type == transaction.TxOfferUpdate {
+			return ctx.State.UpdateOffer(offer, tx.From, ctx.Height)
+		}
+		return ctx.State.PostOffer(offer, tx.From, ctx.Height)
+
+	case transaction.TxOfferWithdraw:
+		if err := ctx.UseGas(transaction.GasOfferOp); err != nil {
+			return err
+		}
+		var ref transaction.OfferRef
+		if err := unmarshalJSON(tx.Data, &ref); err != nil {
+			return err
+		}
+		return ctx.State.WithdrawOffer(ref.AgentID, ref.Capability, tx.From, ctx.Height)
+
+	case transaction.TxBatchTransfer:
+		var entries []transaction.BatchTransferEntry
+		if err := unmarshalJSON(tx.Data, &entries); err != nil {
+			return err
+		}
+		if err := transaction.CheckBatch(entries); err != nil {
+			return err
+		}
+		if err := ctx.UseGas(transaction.BatchGas(len(entries))); err != nil {
+			return err
+		}
+		to := make([]string, len(entries))
+		values := make([]*big.Int, len(entries))
+		for i, e := range entries {
+			to[i], values[i] = e.To, e.Value
+		}
+		return ctx.State.TransferBatch(tx.From, to, values)
+
+	case transaction.TxDeployContract:
+		var payload transaction.DeployPayload
+		if err := unmarshalJSON(tx.Data, &payload); err != nil {
+			return err
+		}
+		if err := ctx.UseGas(transaction.GasDeployBase + transaction.GasDeployPerByte*uint64(len(payload.Code))); err != nil {
+			return err
+		}
+		from, err := common.ParseAddress(tx.From)
+		if err != nil {
+			return err
+		}
+		addr := crypto.CreateAddress(from, tx.Nonce).String()
+		if len(ctx.State.GetCode(addr)) > 0 {
+			return ErrContractExists
+		}
+		if tx.Value != nil && tx.Value.Sign() > 0 {
+			if err := ctx.State.Transfer(tx.From, addr, tx.Value); err != nil {
+				return err
+			}
+		}
+		ctx.State.SetCode(addr, payload.Code)
+		ctx.Address = addr
 		return nil
 
+	case transaction.TxCallContract:
+		if err := ctx.UseGas(transaction.GasCallContract); err != nil {
+			return err
+		}
+		ctx.Address = tx.To
+		ret, err := avm.Execute(ctx, ctx.State.GetCode(tx.To))
+		ctx.ReturnData = ret
+		return err
+
 	case transaction.TxInferenceReceipt:
-		if err := ctx.UseGas(100000); err != nil {
+		var r transaction.InferenceReceipt
+		if err := unmarshalJSON(tx.Data, &r); err != nil {
+			return err
+		}
+		if err := ctx.UseGas(transaction.ReceiptGas(&r)); err != nil {
+			return err
+		}
+		var blockTime time.Time
+		if ctx.Time != 0 {
+			blockTime = time.Unix(0, ctx.Time)
+		}
+		if err := ctx.State.VerifyReceipt(r, blockTime); err != nil {
+			return err
+		}
+		avm.logger.Info("inference receipt submitted", zap.String("from", tx.From), zap.String("prover", r.Prover))
+		if err := ctx.State.RecordReceipt(tx.Hash(), r, tx.From, ctx.Height); err != nil {
 			return err
 		}
-		// Verify and store inference receipt
-		// Full implementation: check prover signature against registered compute providers
-		avm.logger.Info("inference receipt submitted", zap.String("from", tx.From))
+		ctx.State.RecordInference(r.AgentID, state.ReceiptTrust(r), ctx.Height)
 		return nil
 
+	case transaction.TxInferenceBatch:
+		var b transaction.InferenceBatch
+		if err := unmarshalJSON(tx.Data, &b); err != nil {
+			return err
+		}
+		if err := ctx.UseGas(transaction.ReceiptBatchGas(b.Count)); err != nil {
+			return err
+		}
+		var blockTime time.Time
+		if ctx.Time != 0 {
+			blockTime = time.Unix(0, ctx.Time)
+		}
+		if err := ctx.State.VerifyBatch(b, blockTime); err != nil {
+			return err
+		}
+		avm.logger.Info("inference receipt batch submitted", zap.String("from", tx.From), zap.String("prover", b.Prover), zap.Uint64("count", b.Count))
+		ctx.State.RecordBatch(tx.Hash(), b, tx.From, ctx.Height)
+		return nil
+
+	case transaction.TxDataAttest:
+		if err := ctx.UseGas(transaction.GasDataAttest); err != nil {
+			return err
+		}
+		var a transaction.DataAttestation
+		if err := unmarshalJSON(tx.Data, &a); err != nil {
+			return err
+		}
+		return ctx.State.AttestData(a, tx.From, ctx.Height)
+
+	case transaction.TxValidatorStake:
+		if err := ctx.UseGas(transaction.GasValidatorOp); err != nil {
+			return err
+		}
+		if len(tx.Data) == 0 {
+			return ctx.State.DelegateStake(tx.From, tx.From, tx.Value, ctx.Height)
+		}
+		var desc transaction.ValidatorDescription
+		if err := unmarshalJSON(tx.Data, &desc); err != nil {
+			return err
+		}
+		return ctx.State.CreateValidator(desc, tx.From, tx.Value, ctx.Height)
+
+	case transaction.TxValidatorUnstake:
+		if err := ctx.UseGas(transaction.GasValidatorOp); err != nil {
+			return err
+		}
+		return ctx.State.UndelegateStake(tx.From, tx.From, tx.Value, ctx.Height)
+
+	case transaction.TxValidatorUnjail:
+		if err := ctx.UseGas(transaction.GasValidatorOp); err != nil {
+			return err
+		}
+		return ctx.State.UnjailValidator(tx.From, ctx.Height)
+
+	case transaction.TxStakeDelegate, transaction.TxStakeUndelegate:
+		if err := ctx.UseGas(transaction.GasValidatorOp); err != nil {
+			return err
+		}
+		var d transaction.StakeDelegation
+		if err := unmarshalJSON(tx.Data, &d); err != nil {
+			return err
+		}
+		if tx.Type == transaction.TxStakeDelegate {
+			return ctx.State.DelegateStake(tx.From, d.Validator, tx.Value, ctx.Height)
+		}
+		return ctx.State.UndelegateStake(tx.From, d.Validator, tx.Value, ctx.Height)
+
+	case transaction.TxGovSubmitProposal:
+		if err := ctx.UseGas(transaction.GasGovOp); err != nil {
+			return err
+		}
+		var p transaction.Proposal
+		if err := unmarshalJSON(tx.Data, &p); err != nil {
+			return err
+		}
+		_, err := ctx.State.SubmitProposal(p, tx.From, tx.Value, ctx.Height)
+		return err
+
+	case transaction.TxGovDeposit:
+		if err := ctx.UseGas(transaction.GasGovOp); err != nil {
+			return err
+		}
+		var d transaction.ProposalDeposit
+		if err := unmarshalJSON(tx.Data, &d); err != nil {
+			return err
+		}
+		return ctx.State.DepositProposal(d.ProposalID, tx.From, tx.Value, ctx.Height)
+
+	case transaction.TxGovVote:
+		if err := ctx.UseGas(transaction.GasGovOp); err != nil {
+			return err
+		}
+		var v transaction.ProposalVote
+		if err := unmarshalJSON(tx.Data, &v); err != nil {
+			return err
+		}
+		return ctx.State.VoteProposal(v, tx.From, ctx.Height)
+
+	case transaction.TxIBCCreateClient, transaction.TxIBCUpdateClient, transaction.TxIBCConnectionOpen, transaction.TxIBCChannelOpen,
+		transaction.TxIBCTransfer, transaction.TxIBCSendMessage, transaction.TxIBCRecvPacket, transaction.TxIBCAcknowledgePacket:
+		if err := ctx.UseGas(transaction.IBCGas(tx)); err != nil {
+			return err
+		}
+		return applyIBC(ctx, tx)
+
+	case transaction.TxBridgeAttest:
+		if err := ctx.UseGas(transaction.GasBridgeOp); err != nil {
+			return err
+		}
+		var d transaction.BridgeDeposit
+		if err := unmarshalJSON(tx.Data, &d); err != nil {
+			return err
+		}
+		_, err := ctx.State.AttestBridgeDeposit(d, tx.From, ctx.Height)
+		return err
+
+	case transaction.TxBridgeWithdraw:
+		if err := ctx.UseGas(transaction.GasBridgeOp); err != nil {
+			return err
+		}
+		var w transaction.BridgeWithdraw
+		if err := unmarshalJSON(tx.Data, &w); err != nil {
+			return err
+		}
+		_, err := ctx.State.WithdrawBridge(w, tx.From, ctx.Height)
+		return err
+
+	case transaction.TxTokenCreate, transaction.TxTokenMint, transaction.TxTokenBurn, transaction.TxTokenTransfer,
+		transaction.TxTokenApprove, transaction.TxTokenTransferFrom, transaction.TxTokenSetAdmin:
+		if err := ctx.UseGas(transaction.GasTokenOp); err != nil {
+			return err
+		}
+		return applyToken(ctx, tx)
+
+	case transaction.TxNFTCreateCollection, transaction.TxNFTMint, transaction.TxNFTTransfer, transaction.TxNFTBurn:
+		if err := ctx.UseGas(transaction.GasNFTOp); err != nil {
+			return err
+		}
+		return applyNFT(ctx, tx)
+
+	case transaction.TxProviderRegister:
+		if err := ctx.UseGas(transaction.GasProviderOp); err != nil {
+			return err
+		}
+		var reg transaction.ProviderRegistration
+		if err := unmarshalJSON(tx.Data, &reg); err != nil {
+			return err
+		}
+		return ctx.State.RegisterProvider(reg, tx.From, ctx.Height)
+
+	case transaction.TxProviderDeregister:
+		if err := ctx.UseGas(transaction.GasProviderOp); err != nil {
+			return err
+		}
+		return ctx.State.DeregisterProvider(tx.From, ctx.Height)
+
+	case transaction.TxModelRegister, transaction.TxModelUpdate:
+		if err := ctx.UseGas(transaction.GasModelOp); err != nil {
+			return err
+		}
+		var m transaction.ModelInfo
+		if err := unmarshalJSON(tx.Data, &m); err != nil {
+			return err
+		}
+		if tx.Type == transaction.TxModelUpdate {
+			return ctx.State.UpdateModel(m, tx.From, ctx.Height)
+		}
+		return ctx.State.RegisterModel(m, tx.From, ctx.Height)
+
+	case transaction.TxModelDeprecate:
+		if err := ctx.UseGas(transaction.GasModelOp); err != nil {
+			return err
+		}
+		var ref transaction.ModelRef
+		if err := unmarshalJSON(tx.Data, &ref); err != nil {
+			return err
+		}
+		return ctx.State.DeprecateModel(ref.Hash, tx.From, ctx.Height)
+
+	case transaction.TxInferenceReveal:
+		var v transaction.InferenceReveal
+		if err := unmarshalJSON(tx.Data, &v); err != nil {
+			return err
+		}
+		if err := ctx.UseGas(transaction.RevealGas(&v)); err != nil {
+			return err
+		}
+		return ctx.State.RevealInference(v, ctx.Height)
+
+	case transaction.TxEvaluatorRegister:
+		if err := ctx.UseGas(transaction.GasOracleOp); err != nil {
+			return err
+		}
+		var reg transaction.EvaluatorRegistration
+		if err := unmarshalJSON(tx.Data, &reg); err != nil {
+			return err
+		}
+		return ctx.State.RegisterEvaluator(reg, tx.From, ctx.Height)
+
+	case transaction.TxEvaluatorDeregister:
+		if err := ctx.UseGas(transaction.GasOracleOp); err != nil {
+			return err
+		}
+		return ctx.State.DeregisterEvaluator(tx.From, ctx.Height)
+
+	case transaction.TxQualityScore:
+		if err := ctx.UseGas(transaction.GasOracleOp); err != nil {
+			return err
+		}
+		var q transaction.QualityScore
+		if err := unmarshalJSON(tx.Data, &q); err != nil {
+			return err
+		}
+		return ctx.State.SubmitQualityScore(q, tx.From, ctx.Height)
+
+	case transaction.TxDisputeOpen:
+		if err := ctx.UseGas(transaction.GasDisputeOp); err != nil {
+			return err
+		}
+		var d transaction.DisputeOpen
+		if err := unmarshalJSON(tx.Data, &d); err != nil {
+			return err
+		}
+		return ctx.State.OpenDispute(d, tx.From, ctx.Height)
+
+	case transaction.TxDisputeVote:
+		if err := ctx.UseGas(transaction.GasDisputeOp); err != nil {
+			return err
+		}
+		var v transaction.DisputeVote
+		if err := unmarshalJSON(tx.Data, &v); err != nil {
+			return err
+		}
+		return ctx.State.VoteDispute(v, tx.From, ctx.Height)
+
 	default:
 		return ErrInvalidOpcode
 	}
 }
 
+// applyDelegation grants or revokes the capability carried by a DELEGATE
+// or REVOKE message. Other messages are left alone.
+func applyDelegation(ctx *ExecutionContext, msg transaction.AgentMessage) error {
+	if err := transaction.CheckDelegationPayload(msg); err != nil {
+		return err
+	}
+	switch msg.Type {
+	case transaction.MsgDelegate:
+		var d transaction.Delegation
+		if err := unmarshalJSON(msg.Payload, &d); err != nil {
+			return err
+		}
+		return ctx.State.Delegate(d, ctx.Height)
+	case transaction.MsgRevoke:
+		var r transaction.Revocation
+		if err := unmarshalJSON(msg.Payload, &r); err != nil {
+			return err
+		}
+		return ctx.State.Revoke(msg.From, msg.To, r.Capability)
+	}
+	return nil
+}
+
 func (avm *AVM) registerBuiltins() {
 	// Agent Register precompile
 	avm.precompiles[OpAgentRegister] = func(ctx *ExecutionContext, args []byte) ([]byte, error) {
+		if err := ctx.checkWrite(); err != nil {
+			return nil, err
+		}
 		if err := ctx.UseGas(200000); err != nil {
 			return nil, err
 		}
@@ -178,15 +884,53 @@ func (avm *AVM) registerBuiltins() {
 
 	// Agent Send precompile
 	avm.precompiles[OpAgentSend] = func(ctx *ExecutionContext, args []byte) ([]byte, error) {
-		if err := ctx.UseGas(50000); err != nil {
+		if err := ctx.checkWrite(); err != nil {
 			return nil, err
 		}
 		var msg transaction.AgentMessage
 		if err := unmarshalJSON(args, &msg); err != nil {
 			return nil, err
 		}
-		ctx.State.StoreMessage(msg)
-		return nil, nil
+		if err := ctx.UseGas(transaction.MessageGas(msg)); err != nil {
+			return nil, err
+		}
+		if err := ctx.State.AuthorizeMessage(msg, ctx.Caller, ctx.Height); err != nil {
+			return nil, err
+		}
+		if err := applyDelegation(ctx, msg); err != nil {
+			return nil, err
+		}
+		return nil, ctx.State.StoreMessage(msg, ctx.Height)
+	}
+
+	// Paymaster Validate precompile: args are a binary-encoded sponsored tx.
+	// Smart account validation of the sponsored tx runs on the caller's gas,
+	// reserved before it starts, and may not itself validate transactions.
+	avm.precompiles[OpPaymasterValidate] = func(ctx *ExecutionContext, args []byte) ([]byte, error) {
+		if ctx.Auth != nil {
+			return nil, ErrValidationReentry
+		}
+		if err := ctx.UseGas(30000); err != nil {
+			return nil, err
+		}
+		var tx transaction.Tx
+		if err := tx.UnmarshalBinary(args); err != nil {
+			return nil, err
+		}
+		if !tx.IsSponsored() {
+			return nil, transaction.ErrNotSponsored
+		}
+		reserved := ctx.GasLeft()
+		if reserved > ValidationGasLimit {
+			reserved = ValidationGasLimit
+		}
+		ctx.GasUsed += reserved
+		_, gas, err := avm.verifyTx(ctx.State, &tx, ctx.ChainID, reserved)
+		ctx.GasUsed -= reserved - gas
+		if err != nil {
+			return nil, err
+		}
+		return []byte{1}, nil
 	}
 
 	// Inference Prove precompile
@@ -197,13 +941,61 @@ func (avm *AVM) registerBuiltins() {
 		avm.logger.Info("inference proof submitted by precompile", zap.String("caller", ctx.Caller))
 		return []byte{1}, nil // success
 	}
+
+	// Inference Verify precompile: args are an RLP list of a Groth16
+	// verifying key, a proof and the public inputs as 32-byte big-endian
+	// integers. Pushes 1 if the proof verifies and 0 otherwise.
+	avm.precompiles[OpInferVerify] = func(ctx *ExecutionContext, args []byte) ([]byte, error) {
+		vkBytes, proofBytes, inputs, err := decodeVerifyArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		if err := ctx.UseGas(zk.VerifyGas(len(proofBytes), len(inputs))); err != nil {
+			return nil, err
+		}
+		vk, err := zk.ParseVerifyingKey(vkBytes)
+		if err != nil {
+			return nil, err
+		}
+		proof, err := zk.ParseProof(proofBytes)
+		if err != nil {
+			return nil, err
+		}
+		if err := zk.Verify(vk, proof, inputs); err != nil {
+			if errors.Is(err, zk.ErrVerification) {
+				return []byte{0}, nil
+			}
+			return nil, err
+		}
+		return []byte{1}, nil
+	}
 }
 
-func unmarshalJSON(data []byte, v interface{}) error {
-	import_json := func() error {
-		return nil
+// decodeVerifyArgs splits the arguments of the Inference Verify precompile.
+func decodeVerifyArgs(args []byte) (vk, proof []byte, inputs []*big.Int, err error) {
+	content, rest, err := rlp.SplitList(args)
+	if err != nil {
+		return nil, nil, nil, err
 	}
-	_ = import_json
-	// Use encoding/json at call site
-	return nil
+	if len(rest) > 0 {
+		return nil, nil, nil, rlp.ErrTrailingData
+	}
+	if vk, content, err = rlp.SplitBytes(content); err != nil {
+		return nil, nil, nil, err
+	}
+	if proof, content, err = rlp.SplitBytes(content); err != nil {
+		return nil, nil, nil, err
+	}
+	for len(content) > 0 {
+		var in []byte
+		if in, content, err = rlp.SplitBytes(content); err != nil {
+			return nil, nil, nil, err
+		}
+		inputs = append(inputs, new(big.Int).SetBytes(in))
+	}
+	return vk, proof, inputs, nil
+}
+
+func unmarshalJSON(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
 }
@@ -0,0 +1,176 @@
+package vm
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/zionlayer/zionlayer/core/common"
+	"github.com/zionlayer/zionlayer/core/crypto"
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+	"go.uber.org/zap"
+)
+
+const testChainID = 7
+
+var testContract = common.BytesToAddress([]byte{0xc0}).String()
+
+// push returns the bytecode pushing data.
+func push(data []byte) []byte {
+	return append([]byte{byte(OpPush), byte(len(data))}, data...)
+}
+
+// callFixture deploys code at testContract and returns a state with a
+// funded sender and a signed call to the contract with the given gas.
+func callFixture(t *testing.T, code []byte, gas uint64) (*state.StateDB, *transaction.Tx) {
+	t.Helper()
+	priv, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := state.NewStateDB()
+	st.SetBalance(crypto.PubkeyToAddress(priv.Public().(crypto.PublicKey)).String(), big.NewInt(1e9))
+	st.SetCode(testContract, code)
+	tx := &transaction.Tx{
+		Type:     transaction.TxCallContract,
+		To:       testContract,
+		Value:    new(big.Int),
+		Gas:      gas,
+		GasPrice: big.NewInt(1),
+	}
+	if err := tx.Sign(priv, testChainID); err != nil {
+		t.Fatal(err)
+	}
+	return st, tx
+}
+
+func TestFailedTransactionReverted(t *testing.T) {
+	did, _ := json.Marshal(transaction.AgentDID{ID: "did:agc:reverted", Controller: testContract})
+	sstore := func(key, value string) []byte {
+		code := append(push([]byte(value)), push([]byte(key))...)
+		return append(code, byte(OpSStore))
+	}
+	tests := []struct {
+		name string
+		code []byte
+		gas  uint64
+		err  error
+	}{
+		{
+			name: "revert after store",
+			code: append(sstore("k", "v"), byte(OpRevert)),
+			gas:  100000,
+			err:  ErrExecutionReverted,
+		},
+		{
+			name: "out of gas after store",
+			code: append(sstore("k", "v"), sstore("k2", "v")...),
+			gas:  transaction.GasCallContract + state.DefaultVMParams().GasSStoreSet + 1,
+			err:  ErrOutOfGas,
+		},
+		{
+			name: "revert after precompile",
+			code: append(append(push(did), byte(OpAgentRegister)), byte(OpRevert)),
+			gas:  500000,
+			err:  ErrExecutionReverted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, tx := callFixture(t, tt.code, tt.gas)
+			balance := st.GetBalance(tx.From)
+			ref := st.Copy()
+			ref.IncrementNonce(tx.From)
+
+			avm := NewAVM(zap.NewNop())
+			ctx := &ExecutionContext{Height: 1, ChainID: testChainID, State: st}
+			receipt, err := avm.ApplyTransaction(ctx, tx)
+			if receipt == nil || !errors.Is(err, tt.err) {
+				t.Fatalf("ApplyTransaction = %v, %v; want a receipt and %v", receipt, err, tt.err)
+			}
+			if receipt.Status != transaction.ReceiptFailed {
+				t.Fatalf("receipt status = %v, want failed", receipt.Status)
+			}
+			if got := st.GetStorage(testContract, []byte("k")); got != nil {
+				t.Errorf("storage of the failed call = %q, want none", got)
+			}
+			if _, err := st.GetAgent("did:agc:reverted"); err == nil {
+				t.Error("agent registered by the failed call")
+			}
+			if got := st.GetNonce(tx.From); got != 1 {
+				t.Errorf("nonce = %d, want 1", got)
+			}
+			want := new(big.Int).Sub(balance, receipt.Fee)
+			if got := st.GetBalance(tx.From); got.Cmp(want) != 0 {
+				t.Errorf("balance = %v, want %v", got, want)
+			}
+
+			// Only the nonce bump and the fee charge remain.
+			ref.SubBalance(tx.From, receipt.Fee)
+			ref.Burn(receipt.FeeBurned)
+			if st.StateRoot() != ref.StateRoot() {
+				t.Error("failed call left writes besides the nonce and fee")
+			}
+		})
+	}
+}
+
+func TestValidatorUnjail(t *testing.T) {
+	st := state.NewStateDB()
+	params := st.GetStakingParams()
+	params.MaxMissedBlocks, params.JailPeriod = 2, 10
+	st.SetStakingParams(params)
+
+	priv, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jailed := crypto.PubkeyToAddress(priv.Public().(crypto.PublicKey)).String()
+	proposer := common.BytesToAddress([]byte{0x01}).String() // sorts first
+	for _, addr := range []string{jailed, proposer} {
+		st.SetBalance(addr, big.NewInt(1e9))
+		if err := st.CreateValidator(transaction.ValidatorDescription{Moniker: addr}, addr, big.NewInt(1000), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The turns at odd heights are those of jailed, which proposer takes.
+	for h := uint64(1); h <= 3; h++ {
+		st.RecordProposer(proposer, h)
+	}
+	v, err := st.GetStakingValidator(jailed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.Jailed || v.JailedUntil != 13 {
+		t.Fatalf("validator jailed = %v until %d, want jailed until 13", v.Jailed, v.JailedUntil)
+	}
+	// Jailed validators have no turns to miss.
+	st.RecordProposer(proposer, 5)
+	if v, _ := st.GetStakingValidator(jailed); v.MissedBlocks != 0 {
+		t.Errorf("jailed validator missed %d blocks", v.MissedBlocks)
+	}
+
+	avm := NewAVM(zap.NewNop())
+	for i, tt := range []struct {
+		height uint64
+		err    error
+	}{
+		{12, state.ErrValidatorJailed},
+		{13, nil},
+		{14, state.ErrValidatorNotJailed},
+	} {
+		tx := transaction.NewValidatorUnjailTx(jailed, uint64(i), big.NewInt(1))
+		if err := tx.Sign(priv, testChainID); err != nil {
+			t.Fatal(err)
+		}
+		receipt, err := avm.ApplyTransaction(&ExecutionContext{Height: tt.height, ChainID: testChainID, State: st}, tx)
+		if receipt == nil || !errors.Is(err, tt.err) {
+			t.Fatalf("unjail at height %d = %v, want %v", tt.height, err, tt.err)
+		}
+	}
+	if v, _ := st.GetStakingValidator(jailed); v.Jailed {
+		t.Error("validator still jailed after unjail")
+	}
+}
@@ -0,0 +1,86 @@
+package vm
+
+import (
+	"github.com/zionlayer/zionlayer/core/state"
+	"github.com/zionlayer/zionlayer/core/transaction"
+)
+
+// DefaultCallGas is the gas limit applied to read-only calls that do not
+// specify one.
+const DefaultCallGas = 10_000_000
+
+// CallMsg describes a read-only AVM invocation. If To holds contract code
+// it is executed; otherwise Data is executed directly as bytecode.
+type CallMsg struct {
+	From string
+	To   string
+	Gas  uint64
+	Data []byte
+}
+
+// CallResult is the outcome of a read-only call.
+type CallResult struct {
+	ReturnData []byte
+	GasUsed    uint64
+}
+
+// Call executes msg against a copy of stateDB. No state changes are
+// persisted, regardless of whether execution succeeds.
+func (avm *AVM) Call(stateDB *state.StateDB, msg CallMsg, height uint64) (*CallResult, error) {
+	gas := msg.Gas
+	if gas == 0 {
+		gas = DefaultCallGas
+	}
+	ctx := &ExecutionContext{
+		Caller:   msg.From,
+		Origin:   msg.From,
+		GasLimit: gas,
+		Height:   height,
+		Address:  msg.To,
+		State:    stateDB.Copy(),
+	}
+
+	code := msg.Data
+	if msg.To != "" {
+		if c := ctx.State.GetCode(msg.To); len(c) > 0 {
+			code = c
+		}
+	}
+
+	ret, err := avm.Execute(ctx, code)
+	return &CallResult{ReturnData: ret, GasUsed: ctx.GasUsed}, err
+}
+
+// EstimateGas returns the gas consumed by executing msg as a read-only call.
+func (avm *AVM) EstimateGas(stateDB *state.StateDB, msg CallMsg, height uint64) (uint64, error) {
+	res, err := avm.Call(stateDB, msg, height)
+	if err != nil {
+		return 0, err
+	}
+	return res.GasUsed, nil
+}
+
+// SimulationResult is the outcome of a simulated transaction.
+type SimulationResult struct {
+	Receipt    *transaction.Receipt
+	ReturnData []byte
+	State      *state.StateDB // the copy tx was applied to
+}
+
+// Simulate applies tx to a copy of stateDB as if it were included at
+// height, without verifying its signatures. Execution failures are
+// reported in the receipt; an error is returned only if tx could not be
+// included at all, e.g. for a bad nonce or unaffordable gas.
+func (avm *AVM) Simulate(stateDB *state.StateDB, tx *transaction.Tx, chainID, height uint64) (*SimulationResult, error) {
+	ctx := &ExecutionContext{
+		Height:   height,
+		ChainID:  chainID,
+		State:    stateDB.Copy(),
+		Simulate: true,
+	}
+	receipt, err := avm.ApplyTransaction(ctx, tx)
+	if receipt == nil {
+		return nil, err
+	}
+	return &SimulationResult{Receipt: receipt, ReturnData: ctx.ReturnData, State: ctx.State}, nil
+}
@@ -0,0 +1,74 @@
+package vm
+
+import "github.com/zionlayer/zionlayer/core/transaction"
+
+// applyIBC executes an IBC transaction; its gas is charged by the caller.
+func applyIBC(ctx *ExecutionContext, tx *transaction.Tx) error {
+	switch tx.Type {
+	case transaction.TxIBCCreateClient:
+		var c transaction.IBCCreateClient
+		if err := unmarshalJSON(tx.Data, &c); err != nil {
+			return err
+		}
+		_, err := ctx.State.CreateIBCClient(c, ctx.Height)
+		return err
+
+	case transaction.TxIBCUpdateClient:
+		var u transaction.IBCUpdateClient
+		if err := unmarshalJSON(tx.Data, &u); err != nil {
+			return err
+		}
+		return ctx.State.UpdateIBCClient(u)
+
+	case transaction.TxIBCConnectionOpen:
+		var c transaction.IBCConnectionOpen
+		if err := unmarshalJSON(tx.Data, &c); err != nil {
+			return err
+		}
+		_, err := ctx.State.OpenIBCConnection(c)
+		return err
+
+	case transaction.TxIBCChannelOpen:
+		var c transaction.IBCChannelOpen
+		if err := unmarshalJSON(tx.Data, &c); err != nil {
+			return err
+		}
+		_, err := ctx.State.OpenIBCChannel(c)
+		return err
+
+	case transaction.TxIBCTransfer:
+		var t transaction.IBCTransfer
+		if err := unmarshalJSON(tx.Data, &t); err != nil {
+			return err
+		}
+		_, err := ctx.State.SendIBCTransfer(t, tx.From)
+		return err
+
+	case transaction.TxIBCSendMessage:
+		var m transaction.IBCSendMessage
+		if err := unmarshalJSON(tx.Data, &m); err != nil {
+			return err
+		}
+		if err := ctx.State.AuthorizeMessage(m.Message, tx.From, ctx.Height); err != nil {
+			return err
+		}
+		_, err := ctx.State.SendIBCMessage(m)
+		return err
+
+	case transaction.TxIBCRecvPacket:
+		var r transaction.IBCRecvPacket
+		if err := unmarshalJSON(tx.Data, &r); err != nil {
+			return err
+		}
+		_, err := ctx.State.RecvIBCPacket(r, ctx.Height)
+		return err
+
+	case transaction.TxIBCAcknowledgePacket:
+		var a transaction.IBCAcknowledgePacket
+		if err := unmarshalJSON(tx.Data, &a); err != nil {
+			return err
+		}
+		return ctx.State.AcknowledgeIBCPacket(a)
+	}
+	return ErrInvalidOpcode
+}
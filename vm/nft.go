@@ -0,0 +1,38 @@
+package vm
+
+import "github.com/zionlayer/zionlayer/core/transaction"
+
+// applyNFT executes a ZRC-721 transaction; its gas is charged by the
+// caller.
+func applyNFT(ctx *ExecutionContext, tx *transaction.Tx) error {
+	switch tx.Type {
+	case transaction.TxNFTCreateCollection:
+		var c transaction.NFTCreateCollection
+		if err := unmarshalJSON(tx.Data, &c); err != nil {
+			return err
+		}
+		return ctx.State.CreateNFTCollection(c, tx.From, ctx.Height)
+
+	case transaction.TxNFTMint:
+		var m transaction.NFTMint
+		if err := unmarshalJSON(tx.Data, &m); err != nil {
+			return err
+		}
+		return ctx.State.MintNFT(m, tx.From, ctx.Height)
+
+	case transaction.TxNFTTransfer:
+		var t transaction.NFTTransfer
+		if err := unmarshalJSON(tx.Data, &t); err != nil {
+			return err
+		}
+		return ctx.State.TransferNFT(t.Collection, t.ID, tx.From, t.To)
+
+	case transaction.TxNFTBurn:
+		var b transaction.NFTBurn
+		if err := unmarshalJSON(tx.Data, &b); err != nil {
+			return err
+		}
+		return ctx.State.BurnNFT(b.Collection, b.ID, tx.From)
+	}
+	return ErrInvalidOpcode
+}
@@ -0,0 +1,60 @@
+package vm
+
+import "github.com/zionlayer/zionlayer/core/transaction"
+
+// applyToken executes a ZRC-20 token transaction; its gas is charged by
+// the caller.
+func applyToken(ctx *ExecutionContext, tx *transaction.Tx) error {
+	switch tx.Type {
+	case transaction.TxTokenCreate:
+		var c transaction.TokenCreate
+		if err := unmarshalJSON(tx.Data, &c); err != nil {
+			return err
+		}
+		_, err := ctx.State.CreateToken(c, tx.From, ctx.Height)
+		return err
+
+	case transaction.TxTokenMint:
+		var m transaction.TokenMint
+		if err := unmarshalJSON(tx.Data, &m); err != nil {
+			return err
+		}
+		return ctx.State.MintToken(m, tx.From)
+
+	case transaction.TxTokenBurn:
+		var b transaction.TokenBurn
+		if err := unmarshalJSON(tx.Data, &b); err != nil {
+			return err
+		}
+		return ctx.State.BurnToken(b.Symbol, tx.From, b.Amount)
+
+	case transaction.TxTokenTransfer:
+		var t transaction.TokenTransfer
+		if err := unmarshalJSON(tx.Data, &t); err != nil {
+			return err
+		}
+		return ctx.State.TransferToken(t.Symbol, tx.From, t.To, t.Amount)
+
+	case transaction.TxTokenApprove:
+		var a transaction.TokenApprove
+		if err := unmarshalJSON(tx.Data, &a); err != nil {
+			return err
+		}
+		return ctx.State.ApproveToken(a.Symbol, tx.From, a.Spender, a.Amount)
+
+	case transaction.TxTokenTransferFrom:
+		var t transaction.TokenTransferFrom
+		if err := unmarshalJSON(tx.Data, &t); err != nil {
+			return err
+		}
+		return ctx.State.TransferTokenFrom(t.Symbol, tx.From, t.From, t.To, t.Amount)
+
+	case transaction.TxTokenSetAdmin:
+		var a transaction.TokenSetAdmin
+		if err := unmarshalJSON(tx.Data, &a); err != nil {
+			return err
+		}
+		return ctx.State.SetTokenAdmin(a.Symbol, tx.From, a.Admin)
+	}
+	return ErrInvalidOpcode
+}